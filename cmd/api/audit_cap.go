@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// defaultAuditCapInterval is used when audit.capcheckinterval is not positive
+const defaultAuditCapInterval = 10 * time.Minute
+
+// enforceAuditCap checks the audit log table against its row cap now and then
+// every interval until ctx is done. A check in progress is not interrupted.
+func enforceAuditCap(ctx context.Context, auditCap *services.AuditCap, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultAuditCapInterval
+	}
+	enforce := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if _, err := auditCap.Enforce(ctx); err != nil {
+			logger.Error("Failed to enforce the audit log row cap", "error", err)
+		}
+	}
+
+	enforce()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			enforce()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
+)
+
+// buildAuditSink creates the audit sinks listed in config. The first sink is
+// primary; the rest receive best-effort copies.
+func buildAuditSink(cfg configs.AuditConfig, repo *repository.AuditLogRepository) (services.AuditSink, error) {
+	if len(cfg.Sinks) == 0 {
+		return repo, nil
+	}
+
+	sinks := make([]services.AuditSink, 0, len(cfg.Sinks))
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "database":
+			sinks = append(sinks, repo)
+		case "stdout":
+			sinks = append(sinks, services.NewJSONLSink(os.Stdout))
+		case "file":
+			f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open audit file %q: %w", cfg.FilePath, err)
+			}
+			sinks = append(sinks, services.NewJSONLSink(f))
+		case "http":
+			if cfg.HTTPURL == "" {
+				return nil, fmt.Errorf("audit sink \"http\" requires audit.httpurl")
+			}
+			sinks = append(sinks, services.NewHTTPSink(cfg.HTTPURL, cfg.HTTPTimeout))
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return services.NewMultiSink(sinks[0], sinks[1:]...), nil
+}
+
+// buildAuditEnrichers creates the audit metadata enrichers enabled in config
+func buildAuditEnrichers(cfg configs.AuditConfig) ([]services.MetadataEnricher, error) {
+	var enrichers []services.MetadataEnricher
+	if len(cfg.CaptureHeaders) > 0 {
+		enrichers = append(enrichers, services.NewHeaderEnricher(cfg.CaptureHeaders...))
+	}
+	if cfg.ParseUserAgent {
+		enrichers = append(enrichers, services.UserAgentEnricher{})
+	}
+	if cfg.GeoIPDatabase != "" {
+		geoip, err := services.NewGeoIPEnricher(cfg.GeoIPDatabase)
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, geoip)
+	}
+	return enrichers, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/middleware"
+	"Go-Lang-project-01/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// buildChaos creates the fault injector behind /api/v1/_chaos. It returns nil
+// unless chaos.enabled is set, and always in production.
+func buildChaos(cfg configs.ChaosConfig, app configs.AppConfig, db *gorm.DB) (*middleware.Chaos, error) {
+	if !cfg.Enabled || app.Environment == "production" {
+		return nil, nil
+	}
+	pool, err := database.Primary(db).DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database pool: %w", err)
+	}
+	return middleware.NewChaos(pool), nil
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/pkg/reporting"
+)
+
+// buildErrorReporter creates the error reporter from config; without a DSN reports are discarded
+func buildErrorReporter(cfg configs.ReportingConfig, app configs.AppConfig) (reporting.Reporter, error) {
+	if cfg.SentryDSN == "" {
+		return reporting.Nop{}, nil
+	}
+	return reporting.NewSentryReporter(cfg.SentryDSN, app.Environment, app.Name+"@"+app.Version)
+}
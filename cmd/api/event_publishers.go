@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/websocket"
+)
+
+// buildEventPublisher creates the event publishers listed in config
+func buildEventPublisher(cfg configs.EventsConfig, hub *websocket.Hub) (events.Publisher, error) {
+	publishers := make([]events.Publisher, 0, len(cfg.Publishers))
+	for _, name := range cfg.Publishers {
+		switch name {
+		case "websocket":
+			publishers = append(publishers, events.NewHubPublisher(hub))
+		case "log":
+			publishers = append(publishers, events.LogPublisher{})
+		default:
+			return nil, fmt.Errorf("unknown event publisher %q", name)
+		}
+	}
+
+	if len(publishers) == 0 {
+		return events.Nop{}, nil
+	}
+	return events.NewComposite(publishers...), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http/pprof"
+	"strings"
+
+	"Go-Lang-project-01/internal/handlers"
+	"Go-Lang-project-01/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// newInternalRouter returns the router of the internal listener
+// (server.internalport). It serves Prometheus metrics, pprof profiles and
+// health details without authentication, so the port must only be reachable
+// from inside the deployment. main adds the /api/v1/admin group to it.
+func newInternalRouter(healthHandler *handlers.HealthHandler) *gin.Engine {
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NoRoute())
+	r.NoMethod(middleware.NoMethod())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Recovery())
+	r.Use(middleware.Logger())
+	r.Use(middleware.IDFormat())
+	r.Use(middleware.ErrorHandler())
+
+	healthTimeout := middleware.Timeout(defaultRequestTimeout)
+	r.GET("/health", healthTimeout, healthHandler.DetailedHealthCheck)
+	r.HEAD("/health", healthTimeout, healthHandler.DetailedHealthCheck)
+
+	metricsHandler := gin.WrapH(promhttp.Handler())
+	r.GET("/metrics", metricsHandler)
+	r.HEAD("/metrics", metricsHandler)
+
+	r.Any("/debug/pprof/*profile", pprofHandler)
+	return r
+}
+
+// pprofHandler dispatches /debug/pprof/* to net/http/pprof. A single
+// wildcard route is needed because gin does not allow static routes next to
+// a catch-all one.
+func pprofHandler(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("profile"), "/") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request) // Index page and named profiles such as heap or goroutine
+	}
+}
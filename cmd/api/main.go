@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"Go-Lang-project-01/configs"
 	_ "Go-Lang-project-01/docs" // Import generated docs
 	"Go-Lang-project-01/graph"
 	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/flags"
 	"Go-Lang-project-01/internal/handlers"
 	"Go-Lang-project-01/internal/health"
 	"Go-Lang-project-01/internal/metrics"
@@ -20,6 +26,8 @@ import (
 	"Go-Lang-project-01/internal/websocket"
 	"Go-Lang-project-01/pkg/database"
 	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/reporting"
+	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
@@ -30,6 +38,14 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// Request deadlines of the API routes. Routes that stream (audit export) or
+// upgrade the connection (/ws) have none.
+const (
+	defaultRequestTimeout = 5 * time.Second
+	listRequestTimeout    = 10 * time.Second // Listings, stats and bulk updates
+	batchRequestTimeout   = 30 * time.Second // Batch create and CSV import
+)
+
 // @title           Go REST API with JWT Authentication
 // @version         1.0
 // @description     Production-ready REST API built with Go, Gin, GORM, and JWT authentication
@@ -64,15 +80,25 @@ func main() {
 		"port", cfg.Server.Port,
 	)
 
-	// Connect to database (SQLite)
-	if err := database.Connect(); err != nil {
+	// Error reporting (panics and 5xx responses), delivered in the background
+	errorReporter, err := buildErrorReporter(cfg.Reporting, cfg.App)
+	if err != nil {
+		logger.Error("❌ Invalid error reporting configuration", "error", err)
+		os.Exit(1)
+	}
+	reporting.Init(errorReporter, cfg.Reporting.QueueSize)
+	defer reporting.Close(5 * time.Second)
+	logger.Info("✅ Error reporting configured", "enabled", cfg.Reporting.SentryDSN != "")
+
+	// Connect to database (driver from database.driver)
+	if err := database.Connect(cfg.Database); err != nil {
 		logger.Error("❌ Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
 
 	// Auto migrate
 	db := database.GetDB()
-	if err := db.AutoMigrate(&models.User{}, &models.AuditLog{}); err != nil {
+	if err := database.Primary(db).AutoMigrate(&models.User{}, &models.AuditLog{}, &models.RevokedToken{}, &models.RefreshToken{}, &models.APIUsage{}, &models.FeatureFlag{}); err != nil {
 		logger.Error("❌ Failed to migrate database", "error", err)
 		os.Exit(1)
 	}
@@ -91,17 +117,36 @@ func main() {
 	}
 
 	// Initialize JWT Manager
-	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, accessDuration, refreshDuration)
-	logger.Info("✅ JWT authentication initialized")
+	jwtManager := auth.NewJWTManagerWithOptions(cfg.JWT.SecretKey, accessDuration, refreshDuration, auth.JWTOptions{
+		Issuer:             cfg.JWT.Issuer,
+		Audience:           cfg.JWT.Audience,
+		PreviousSecretKeys: cfg.JWT.PreviousSecretKeys,
+	})
+	logger.Info("✅ JWT authentication initialized",
+		"issuer", cfg.JWT.Issuer,
+		"audience", cfg.JWT.Audience,
+		"previous_keys", len(cfg.JWT.PreviousSecretKeys),
+	)
 
 	// Initialize health service with checkers
 	healthService := health.NewHealthService()
 
-	// Register database health checker (5 second timeout)
-	healthService.RegisterChecker("database", &health.DatabaseChecker{
+	// Register database health checker (5 second timeout); traffic is drained while it fails
+	healthService.RegisterReadinessChecker("database", &health.DatabaseChecker{
 		DB:      db,
 		Timeout: 5 * time.Second,
 	})
+	for i, replica := range database.Replicas() {
+		checker := &health.ReplicaChecker{
+			DB:      replica,
+			Timeout: 5 * time.Second,
+			MaxLag:  cfg.Database.ReplicaMaxLag,
+		}
+		if cfg.Database.Driver == "postgres" {
+			checker.LagQuery = health.PostgresReplicaLagQuery
+		}
+		healthService.RegisterChecker(fmt.Sprintf("database_replica_%d", i+1), checker)
+	}
 
 	// Register disk space checker (80% warning, 90% critical)
 	healthService.RegisterChecker("disk", &health.DiskSpaceChecker{
@@ -110,29 +155,276 @@ func main() {
 		CriticalThreshold: 90.0,
 	})
 
-	// Register memory checker (500MB warning, 1GB critical)
-	healthService.RegisterChecker("memory", &health.MemoryChecker{
+	// Register memory checker (500MB warning, 1GB critical); the process is restarted while it fails
+	healthService.RegisterLivenessChecker("memory", &health.MemoryChecker{
 		WarningThresholdMB:  500,
 		CriticalThresholdMB: 1024,
 	})
 
+	// Register downstream HTTP services; they gate readiness like the database
+	for _, check := range cfg.HealthChecks.HTTP {
+		if check.Name == "" || check.URL == "" {
+			logger.Error("❌ Invalid HTTP health check: name and url are required", "name", check.Name)
+			os.Exit(1)
+		}
+		healthService.RegisterReadinessChecker(check.Name, &health.HTTPChecker{
+			URL:            check.URL,
+			Method:         check.Method,
+			ExpectedStatus: check.ExpectedStatus,
+			Timeout:        check.Timeout,
+			WarnThreshold:  check.WarnThreshold,
+			BodyContains:   check.BodyContains,
+		})
+	}
+
+	// Register crypto checker; it also gates readiness and must pass at startup
+	cryptoChecker := &health.CryptoChecker{
+		JWT:        jwtManager,
+		HashBudget: cfg.Health.CryptoHashBudget,
+	}
+	healthService.RegisterReadinessChecker("crypto", cryptoChecker)
+	if result := cryptoChecker.Check(context.Background()); result.Status == health.StatusUnhealthy {
+		logger.Error("❌ Crypto self-check failed", "error", result.Message, "details", result.Details)
+		os.Exit(1)
+	}
+
 	logger.Info("✅ Health checks configured")
 
+	// Initialize Prometheus metrics (before the hub, which reports to them)
+	prometheusMetrics := metrics.NewMetrics(metrics.Config{SizeSummaries: cfg.Metrics.SizeSummaries})
+	if err := database.UseQueryMetrics(db, cfg.Database, prometheusMetrics.DBRecorder()); err != nil {
+		logger.Error("❌ Failed to configure database query metrics", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("✅ Database query metrics configured",
+		"enabled", cfg.Database.QueryMetrics,
+		"slow_query_threshold", cfg.Database.SlowQueryThreshold,
+	)
+
 	// Initialize WebSocket hub
-	wsHub := websocket.NewHub()
+	dropPolicy, err := websocket.ParseDropPolicy(cfg.WebSocket.DropPolicy)
+	if err != nil {
+		logger.Error("❌ Invalid WebSocket configuration", "error", err)
+		os.Exit(1)
+	}
+	wsHub := websocket.NewHubWithConfig(websocket.HubConfig{
+		BroadcastBufferSize:   cfg.WebSocket.BroadcastBufferSize,
+		DropPolicy:            dropPolicy,
+		BlockTimeout:          cfg.WebSocket.BlockTimeout,
+		Metrics:               prometheusMetrics.WebSocketRecorder(),
+		MaxConnections:        cfg.WebSocket.MaxConnections,
+		MaxConnectionsPerUser: cfg.WebSocket.MaxConnectionsPerUser,
+		StaleTimeout:          cfg.WebSocket.StaleTimeout,
+		SweepInterval:         cfg.WebSocket.SweepInterval,
+	})
 	go wsHub.Run() // Start hub in background
-	logger.Info("✅ WebSocket hub initialized")
+	logger.Info("✅ WebSocket hub initialized",
+		"buffer", cfg.WebSocket.BroadcastBufferSize,
+		"drop_policy", dropPolicy,
+		"max_connections", cfg.WebSocket.MaxConnections,
+		"max_connections_per_user", cfg.WebSocket.MaxConnectionsPerUser,
+	)
 
 	// Initialize dependencies (Dependency Injection)
 	userRepo := repository.NewUserRepository(db)
+	if normalized, err := userRepo.NormalizeRoles(context.Background()); err != nil {
+		logger.Error("❌ Failed to normalize user roles", "error", err)
+		os.Exit(1)
+	} else if normalized > 0 {
+		logger.Warn("⚠️  Reset invalid user roles to 'user'", "count", normalized)
+	}
+	if normalized, collisions, err := userRepo.NormalizeEmails(context.Background()); err != nil {
+		logger.Error("❌ Failed to normalize user emails", "error", err)
+		os.Exit(1)
+	} else {
+		if normalized > 0 {
+			logger.Info("✅ Lowercased user emails", "count", normalized)
+		}
+		if len(collisions) > 0 {
+			logger.Warn("⚠️  Emails left unchanged: another user has them in lowercase", "emails", collisions)
+		}
+	}
 	auditRepo := repository.NewAuditLogRepository(db)
-	auditService := services.NewAuditService(auditRepo)
-	userService := services.NewUserService(userRepo)
-	userHandler := handlers.NewUserHandler(userService)
-	authHandler := handlers.NewAuthHandler(userRepo, jwtManager, auditService)
-	healthHandler := handlers.NewHealthHandler(healthService)
-	wsHandler := handlers.NewWebSocketHandler(wsHub, jwtManager)
-	auditHandler := handlers.NewAuditHandler(auditService)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	pruneCtx, stopPruning := context.WithCancel(context.Background())
+	pruningStopped := make(chan struct{})
+	go func() {
+		defer close(pruningStopped)
+		pruneExpiredTokens(pruneCtx, tokenPruneInterval, revokedTokenRepo, refreshTokenRepo)
+	}()
+	refreshTokens := services.NewRefreshTokenService(jwtManager, refreshTokenRepo)
+	usageConfig := services.UsageConfig(cfg.Usage)
+	if err := usageConfig.Validate(); err != nil {
+		logger.Error("❌ Invalid usage configuration", "error", err)
+		os.Exit(1)
+	}
+	usageService := services.NewUsageService(repository.NewAPIUsageRepository(db), usageConfig)
+	go usageService.Run()
+	flagService := flags.NewService(repository.NewFeatureFlagRepository(db), flags.Config{CacheTTL: cfg.Flags.CacheTTL})
+	auditSink, err := buildAuditSink(cfg.Audit, auditRepo)
+	if err != nil {
+		logger.Error("❌ Invalid audit sink configuration", "error", err)
+		os.Exit(1)
+	}
+	auditEnrichers, err := buildAuditEnrichers(cfg.Audit)
+	if err != nil {
+		logger.Error("❌ Invalid audit enricher configuration", "error", err)
+		os.Exit(1)
+	}
+	auditService := services.NewAuditService(auditSink, auditEnrichers...)
+	logger.Info("✅ Audit logging configured", "sinks", cfg.Audit.Sinks)
+	eventPublisher, err := buildEventPublisher(cfg.Events, wsHub)
+	if err != nil {
+		logger.Error("❌ Invalid event publisher configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("✅ Event publishing configured", "publishers", cfg.Events.Publishers)
+	var auditCap *services.AuditCap
+	auditCapCtx, stopAuditCap := context.WithCancel(context.Background())
+	auditCapStopped := make(chan struct{})
+	if cfg.Audit.MaxRows > 0 {
+		auditCap = services.NewAuditCap(auditRepo, services.AuditCapConfig{
+			MaxRows:      cfg.Audit.MaxRows,
+			MinRetention: cfg.Audit.MinRetention,
+			BatchSize:    cfg.Audit.CapTrimBatch,
+		}, eventPublisher)
+		healthService.RegisterChecker("audit_log", auditCap)
+		go func() {
+			defer close(auditCapStopped)
+			enforceAuditCap(auditCapCtx, auditCap, cfg.Audit.CapCheckInterval)
+		}()
+		logger.Info("✅ Audit log row cap configured", "max_rows", cfg.Audit.MaxRows, "min_retention", cfg.Audit.MinRetention)
+	} else {
+		close(auditCapStopped)
+	}
+	auditRetention := services.NewAuditRetention(auditService, services.AuditRetentionConfig{
+		RetentionDays: cfg.Audit.RetentionDays,
+		Interval:      cfg.Audit.CleanupInterval,
+	})
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	retentionStopped := make(chan struct{})
+	go func() {
+		defer close(retentionStopped)
+		auditRetention.Run(retentionCtx)
+	}()
+	if auditRetention.Enabled() {
+		logger.Info("✅ Audit log retention scheduled", "retention_days", cfg.Audit.RetentionDays, "interval", cfg.Audit.CleanupInterval)
+	}
+	healthWatcher := health.NewWatcher(healthService, wsHub, health.WatcherConfig{Interval: cfg.Health.WatchInterval})
+	watcherCtx, stopWatcher := context.WithCancel(context.Background())
+	watcherStopped := make(chan struct{})
+	go func() {
+		defer close(watcherStopped)
+		healthWatcher.Run(watcherCtx)
+	}()
+	if healthWatcher.Enabled() {
+		logger.Info("✅ Health status watcher started", "interval", cfg.Health.WatchInterval)
+	}
+	userService := services.NewUserServiceWithConfig(userRepo, services.BatchConfig{
+		Concurrency:   cfg.App.BatchConcurrency,
+		InsertTimeout: cfg.App.BatchInsertTimeout,
+		OnBatch: func(size int) {
+			prometheusMetrics.UserBatchSize.Observe(float64(size))
+		},
+		OnItem: func(d time.Duration, err error) {
+			prometheusMetrics.UserBatchItemDuration.Observe(d.Seconds())
+			switch {
+			case err == nil:
+			case errors.Is(err, context.DeadlineExceeded):
+				prometheusMetrics.UserBatchFailures.WithLabelValues("timeout").Inc()
+			case errors.Is(err, context.Canceled):
+				prometheusMetrics.UserBatchFailures.WithLabelValues("canceled").Inc()
+			default:
+				prometheusMetrics.UserBatchFailures.WithLabelValues("error").Inc()
+			}
+		},
+	})
+	if cfg.Cache.Enabled {
+		userService.EnableCache(cfg.Cache.TTL)
+		userRepo.OnChange(userService.InvalidateUsers) // GraphQL, offboarding and registration write through the repository
+		logger.Info("✅ User cache enabled", "ttl", cfg.Cache.TTL)
+	}
+	utils.SetPasswordPolicy(utils.PasswordPolicy{
+		MinLength:             cfg.App.PasswordMinLength,
+		RequireLetterAndDigit: cfg.App.PasswordRequireLetterAndDigit,
+		RejectCommon:          cfg.App.PasswordRejectCommon,
+	})
+	registrationGuard, err := buildRegistrationGuard(cfg.Register)
+	if err != nil {
+		logger.Error("❌ Invalid registration configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("✅ Registration limits configured",
+		"ip_per_hour", cfg.Register.IPPerHour,
+		"domain_per_hour", cfg.Register.DomainPerHour,
+		"challenge", cfg.Register.Challenge,
+	)
+	userHandler := handlers.NewUserHandler(userService, eventPublisher, auditService, wsHub, handlers.UserImportConfig{
+		MaxBytes: cfg.App.ImportMaxBytes,
+		MaxRows:  cfg.App.ImportMaxRows,
+	})
+	authHandler := handlers.NewAuthHandler(userRepo, revokedTokenRepo, refreshTokens, jwtManager, auditService, eventPublisher, registrationGuard)
+	healthHandler := handlers.NewHealthHandler(healthService, cfg.Health.DetailToken)
+	wsHandler := handlers.NewWebSocketHandler(wsHub, jwtManager, cfg.WebSocket.AllowedOrigins)
+	auditHandler := handlers.NewAuditHandler(auditService, auditCap)
+	offboardService := services.NewOffboardService(userRepo, wsHub) // Hub closes the user's live connections
+	offboardHandler := handlers.NewOffboardHandler(offboardService, auditService)
+	usageHandler := handlers.NewUsageHandler(usageService)
+	activityHandler := handlers.NewActivityHandler(services.NewUserActivityService(auditService, userRepo))
+	flagHandler := handlers.NewFlagHandler(flagService, auditService)
+
+	// Concurrency limits for slow admin endpoints (independent of the per-IP rate limiter)
+	newThrottle := func(group string, limit int) *middleware.ConcurrencyLimiter {
+		return middleware.NewConcurrencyLimiter(group, middleware.ConcurrencyLimiterConfig{
+			Limit:      limit,
+			RetryAfter: cfg.Throttle.RetryAfter,
+			OnChange: func(group string, inFlight int) {
+				prometheusMetrics.ThrottledRequestsInFlight.WithLabelValues(group).Set(float64(inFlight))
+			},
+			OnReject: func(group string) {
+				prometheusMetrics.ThrottledRequestsRejected.WithLabelValues(group).Inc()
+			},
+		})
+	}
+	auditThrottle := newThrottle("audit", cfg.Throttle.AuditLimit)
+	statsThrottle := newThrottle("stats", cfg.Throttle.StatsLimit)
+	userThrottle := middleware.NewUserConcurrencyLimiter(middleware.UserConcurrencyLimiterConfig{
+		Limit:      cfg.Throttle.PerUserLimit,
+		RetryAfter: cfg.Throttle.RetryAfter,
+		OnChange: func(userID uint, inFlight int) {
+			label := strconv.FormatUint(uint64(userID), 10)
+			if inFlight == 0 {
+				// Keep one series per active user only
+				prometheusMetrics.UserRequestsInFlight.DeleteLabelValues(label)
+				return
+			}
+			prometheusMetrics.UserRequestsInFlight.WithLabelValues(label).Set(float64(inFlight))
+		},
+		OnReject: func(uint) {
+			prometheusMetrics.UserRequestsRejected.Inc()
+		},
+	})
+	trackUsage := middleware.TrackUsage(usageService) // After JWTAuth, before userThrottle so its 429s count as rate limited
+	rbacRules, err := buildRBACRules(cfg.RBAC, func(rule, route string, role models.Role) {
+		prometheusMetrics.RBACShadowDenials.WithLabelValues(route, role.String()).Inc()
+	})
+	if err != nil {
+		logger.Error("❌ Invalid RBAC rule configuration", "error", err)
+		os.Exit(1)
+	}
+	throttleHandler := handlers.NewThrottleHandler(userThrottle, auditThrottle, statsThrottle)
+	configHandler := handlers.NewConfigHandler(configs.Effective())
+	chaos, err := buildChaos(cfg.Chaos, cfg.App, db)
+	if err != nil {
+		logger.Error("❌ Invalid chaos configuration", "error", err)
+		os.Exit(1)
+	}
+	if chaos != nil {
+		logger.Warn("⚠️  Chaos endpoints enabled: faults can be injected through /api/v1/_chaos")
+	} else if cfg.Chaos.Enabled {
+		logger.Warn("⚠️  chaos.enabled is ignored in production")
+	}
 
 	// Set Gin mode from config
 	if cfg.App.Environment == "production" {
@@ -143,41 +435,101 @@ func main() {
 
 	// Initialize Gin router
 	r := gin.New()
-
-	// Initialize Prometheus metrics
-	prometheusMetrics := metrics.NewMetrics()
+	r.HandleMethodNotAllowed = true // Answer 405 with an Allow header instead of 404 for known paths
+	r.NoRoute(middleware.NoRoute())
+	r.NoMethod(middleware.NoMethod())
+
+	// Cross-origin policy for browser clients
+	corsConfig := middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	}
 
 	// Apply global middleware
-	r.Use(middleware.Recovery())          // Panic recovery
-	r.Use(middleware.Logger())            // Custom logger
-	r.Use(middleware.CORS())              // CORS support
-	r.Use(prometheusMetrics.Middleware()) // Prometheus metrics
-	r.Use(middleware.ErrorHandler())      // Centralized error handling
+	r.Use(middleware.RequestID())                                      // X-Request-ID for logs and error reports
+	r.Use(middleware.Recovery())                                       // Panic recovery
+	r.Use(middleware.Logger())                                         // Custom logger
+	r.Use(middleware.CORSWithConfig(corsConfig))                       // CORS support
+	r.Use(prometheusMetrics.Middleware())                              // Prometheus metrics
+	r.Use(middleware.ResponseSizeLimit(middleware.ResponseLimitConfig{ // After metrics, so they observe the size actually sent
+		MaxBytes: cfg.Response.MaxBytes,
+		Routes:   cfg.Response.Routes,
+		OnViolation: func(method, route string) {
+			prometheusMetrics.HTTPResponseTooLarge.WithLabelValues(method, route).Inc()
+		},
+	}))
+	r.Use(middleware.BodySizeLimit(cfg.Request.MaxBodyBytes))
+	r.Use(middleware.StrictBinding(flagService)) // Unknown JSON fields fail validation while strict_binding is on
+	r.Use(middleware.IDFormat())                 // X-ID-Format: string quotes IDs for JavaScript clients
+	r.Use(middleware.ErrorHandler())             // Centralized error handling
+	if chaos != nil {
+		r.Use(chaos.Inject()) // Before the rate limiter, so delayed requests still pass through it, auth and handlers
+	}
 
 	// Rate limiting middleware (from config)
 	// Convert per-minute to per-second: 100 req/min = 100/60 req/sec
 	ratePerSecond := rate.Limit(float64(cfg.App.RateLimitPerMinute) / 60.0)
-	rateLimiter := middleware.NewRateLimiter(
-		ratePerSecond,
-		cfg.App.RateLimitBurst,
-	)
+	rateLimiter := middleware.NewRateLimiterWithConfig(middleware.RateLimiterConfig{
+		Rate:    ratePerSecond,
+		Burst:   cfg.App.RateLimitBurst,
+		IdleTTL: cfg.App.RateLimitIdleTTL,
+	})
+	defer rateLimiter.Close()
 	r.Use(rateLimiter.RateLimit())
+	limitByUser := rateLimiter.RateLimitByUser()
+
+	// Internal listener: metrics, pprof, health details and /api/v1/admin move off the public port
+	var internal *gin.Engine
+	if cfg.Server.InternalPort != "" {
+		internal = newInternalRouter(healthHandler)
+	}
 
-	// Health check routes
-	r.GET("/health", healthHandler.HealthCheck)
-	r.GET("/ready", healthHandler.ReadinessCheck)
+	// Health check routes (HEAD is used by load balancers and uptime monitors)
+	// Component details are only returned to admins or callers presenting the health token,
+	// or never on the public port when the internal listener serves them
+	healthTimeout := middleware.Timeout(defaultRequestTimeout)
+	if internal == nil {
+		optionalAuth := middleware.OptionalAuthMiddleware(jwtManager, userRepo)
+		r.GET("/health", healthTimeout, optionalAuth, healthHandler.HealthCheck)
+		r.HEAD("/health", healthTimeout, optionalAuth, healthHandler.HealthCheck)
+		r.GET("/ready", healthTimeout, healthHandler.ReadinessCheck)
+		r.HEAD("/ready", healthTimeout, healthHandler.ReadinessCheck)
+	} else {
+		publicHealthHandler := handlers.NewHealthHandler(healthService, "")
+		r.GET("/health", healthTimeout, publicHealthHandler.HealthCheck)
+		r.HEAD("/health", healthTimeout, publicHealthHandler.HealthCheck)
+		r.GET("/ready", healthTimeout, publicHealthHandler.ReadinessCheck)
+		r.HEAD("/ready", healthTimeout, publicHealthHandler.ReadinessCheck)
+	}
 
-	// Prometheus metrics endpoint
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// Prometheus metrics endpoint (bearer token in production)
+	if internal == nil {
+		production := cfg.App.Environment == "production"
+		if production && cfg.Metrics.AuthToken == "" {
+			logger.Warn("⚠️  metrics.authtoken is empty: /metrics rejects every request in production; set it or server.internalport")
+		}
+		metricsAuth := middleware.MetricsAuth(production, cfg.Metrics.AuthToken)
+		metricsHandler := gin.WrapH(promhttp.Handler())
+		r.GET("/metrics", metricsAuth, middleware.AllowLargeResponse(), metricsHandler)
+		r.HEAD("/metrics", metricsAuth, middleware.AllowLargeResponse(), metricsHandler)
+	}
 
-	// Swagger documentation
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Swagger documentation (in production only with app.enableswagger)
+	if swaggerEnabled(cfg.App) {
+		r.GET("/swagger/*any", middleware.AllowLargeResponse(), ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
 	// GraphQL endpoints
 	graphqlResolver := &graph.Resolver{
-		UserService: userService,
-		UserRepo:    userRepo,
-		JWTManager:  jwtManager,
+		UserService:   userService,
+		UserRepo:      userRepo,
+		JWTManager:    jwtManager,
+		RefreshTokens: refreshTokens,
+		AuditService:  auditService,
 	}
 	graphqlServer := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: graphqlResolver}))
 
@@ -187,19 +539,25 @@ func main() {
 		logger.Info("📊 GraphQL Playground enabled", "url", "http://localhost:8080/graphql")
 	}
 
-	// GraphQL query endpoint (with JWT authentication)
+	// GraphQL query endpoint. Anonymous requests reach the resolvers, which
+	// only let them register and log in; a token that is sent goes through
+	// JWTAuth like on REST routes, so invalid tokens and inactive users are
+	// rejected and the role comes from the stored user.
+	graphqlAuth := middleware.JWTAuth(jwtManager, userRepo)
 	r.POST("/query", func(c *gin.Context) {
-		// Extract JWT token and add userID to context
-		authHeader := c.GetHeader("Authorization")
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			tokenString := authHeader[7:]
-			claims, err := jwtManager.ValidateToken(tokenString)
-			if err == nil {
-				// Add userID to context for resolvers
-				c.Request = c.Request.WithContext(
-					context.WithValue(c.Request.Context(), "userID", claims.UserID),
-				)
-			}
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		graphqlAuth(c)
+	}, func(c *gin.Context) {
+		if user, ok := c.Get("user"); ok {
+			u := user.(*models.User)
+			c.Request = c.Request.WithContext(graph.WithClaims(c.Request.Context(), &auth.JWTClaims{
+				UserID: uint(u.ID),
+				Email:  u.Email,
+				Role:   u.Role,
+			}))
 		}
 		graphqlServer.ServeHTTP(c.Writer, c.Request)
 	})
@@ -210,75 +568,134 @@ func main() {
 
 	// WebSocket management endpoints (protected)
 	wsRoutes := r.Group("/ws")
-	wsRoutes.Use(middleware.JWTAuth(jwtManager, userRepo))
+	wsRoutes.Use(middleware.Timeout(defaultRequestTimeout), middleware.JWTAuth(jwtManager, userRepo))
 	{
 		wsRoutes.GET("/stats", wsHandler.GetStats)
 		wsRoutes.POST("/broadcast", wsHandler.BroadcastMessage)
+		wsRoutes.POST("/disconnect/:userId", middleware.RequireAdmin(), wsHandler.DisconnectUser)
 	}
 	logger.Info("✅ WebSocket endpoints configured")
 
-	// API v1 routes
+	// Auth and user routes are served under /api/v1 and /api/v2 by the same handlers.
+	// v1 keeps the {success, message, data} envelope; v2 renders bare resources
+	// and application/problem+json errors.
 	v1 := r.Group("/api/v1")
-	{
+	v2 := r.Group("/api/v2", utils.UseResponseWriter(utils.ProblemWriter{}))
+	for _, api := range []*gin.RouterGroup{v1, v2} {
 		// Public auth routes (no authentication required)
-		authRoutes := v1.Group("/auth")
+		authRoutes := api.Group("/auth", middleware.Timeout(defaultRequestTimeout))
 		{
 			authRoutes.POST("/register", authHandler.Register)
 			authRoutes.POST("/login", authHandler.Login)
 			authRoutes.POST("/refresh", authHandler.RefreshToken)
+			authRoutes.POST("/logout", authHandler.Logout)
 		}
 
 		// Protected auth routes (requires authentication)
-		authProtected := v1.Group("/auth")
-		authProtected.Use(middleware.AuthMiddleware(jwtManager))
+		authProtected := api.Group("/auth")
+		authProtected.Use(middleware.Timeout(defaultRequestTimeout), middleware.JWTAuth(jwtManager, userRepo))
 		{
 			authProtected.GET("/profile", authHandler.GetProfile)
 		}
 
 		// User routes (protected with RBAC)
-		users := v1.Group("/users")
-		users.Use(middleware.JWTAuth(jwtManager, userRepo)) // All user endpoints require authentication
+		users := api.Group("/users")
+		users.Use(middleware.Timeout(defaultRequestTimeout), middleware.JWTAuth(jwtManager, userRepo), trackUsage, limitByUser, userThrottle.Limit(), middleware.AuditTrail(auditService)) // All user endpoints require authentication
 		{
 			// Profile management - any authenticated user can access their own profile
 			users.GET("/me", userHandler.GetMe)
 			users.PUT("/me", userHandler.UpdateMe)
 			users.PUT("/me/password", userHandler.ChangePassword)
+			users.GET("/me/usage", usageHandler.GetMyUsage)
 
 			// Anyone authenticated can view users
-			users.GET("", userHandler.GetAllUsers)
-			users.GET("/stats", userHandler.GetUserStats) // Must be before /:id
+			users.GET("", middleware.Timeout(listRequestTimeout), rbacRules["users_list_admin_only"].Handler(), userHandler.GetAllUsers)
+			users.GET("/stats", middleware.Timeout(listRequestTimeout), statsThrottle.Limit(), userHandler.GetUserStats) // Must be before /:id
 			users.GET("/:id", userHandler.GetUserByID)
 
 			// Only admin and superadmin can create/update/delete users
+			users.GET("/by-email", middleware.RequireAdmin(), userHandler.GetUserByEmail)
 			users.POST("", middleware.RequireAdmin(), userHandler.CreateUser)
-			users.POST("/batch", middleware.RequireAdmin(), userHandler.BatchCreateUsers)
+			users.POST("/batch", middleware.Timeout(batchRequestTimeout), middleware.RequireAdmin(), userHandler.BatchCreateUsers)
+			users.POST("/import", middleware.Timeout(batchRequestTimeout), middleware.BodySizeLimit(userHandler.ImportBodyLimit()), middleware.RequireAdmin(), userHandler.ImportUsers)
 			users.PUT("/:id", middleware.RequireAdmin(), userHandler.UpdateUser)
 			users.DELETE("/:id", middleware.RequireAdmin(), userHandler.DeleteUser)
+			users.GET("/deleted", middleware.Timeout(listRequestTimeout), middleware.RequireAdmin(), userHandler.ListDeletedUsers)
+			users.POST("/:id/restore", middleware.RequireAdmin(), userHandler.RestoreUser)
+			users.DELETE("", middleware.Timeout(listRequestTimeout), middleware.RequireAdmin(), userHandler.BulkDeleteUsers)
+			users.PUT("/:id/deactivate", middleware.RequireAdmin(), userHandler.DeactivateUser)
+			users.PUT("/:id/activate", middleware.RequireAdmin(), userHandler.ActivateUser)
+			users.POST("/:id/reset-password", middleware.RequireAdmin(), userHandler.ResetPassword)
+			users.GET("/:id/auth-summary", middleware.RequireAdmin(), auditHandler.GetUserAuthSummary)
+			users.GET("/:id/usage", middleware.RequireAdmin(), usageHandler.GetUserUsage)
+			users.GET("/:id/activity", middleware.RequireAdmin(), activityHandler.GetUserActivity)
 
 			// Only superadmin can change roles
 			users.PUT("/:id/role", middleware.RequireSuperAdmin(), userHandler.UpdateUserRole)
+			users.PUT("/roles", middleware.Timeout(listRequestTimeout), middleware.RequireSuperAdmin(), userHandler.BulkUpdateRoles)
+			users.DELETE("/:id/purge", middleware.RequireSuperAdmin(), userHandler.PurgeUser)
+			users.POST("/:id/offboard", middleware.Timeout(listRequestTimeout), middleware.RequireSuperAdmin(), offboardHandler.OffboardUser)
 		}
+	}
 
+	// v1-only routes
+	{
 		// Audit log routes (protected)
 		auditLogs := v1.Group("/audit-logs")
-		auditLogs.Use(middleware.JWTAuth(jwtManager, userRepo))
+		auditLogs.Use(middleware.JWTAuth(jwtManager, userRepo), trackUsage, limitByUser, userThrottle.Limit())
 		{
 			// Any authenticated user can view their own audit logs
-			auditLogs.GET("/me", auditHandler.GetMyAuditLogs)
+			auditLogs.GET("/me", middleware.Timeout(listRequestTimeout), auditHandler.GetMyAuditLogs)
+
+			// Admin endpoints (no timeout on the export, it streams)
+			auditLogs.GET("", middleware.Timeout(listRequestTimeout), middleware.RequireAdmin(), auditThrottle.Limit(), auditHandler.GetAuditLogs)
+			auditLogs.GET("/stats", middleware.Timeout(listRequestTimeout), middleware.RequireAdmin(), auditThrottle.Limit(), auditHandler.GetAuditStats)
+			auditLogs.GET("/export", middleware.AllowLargeResponse(), middleware.RequireAdmin(), auditThrottle.Limit(), auditHandler.ExportAuditLogs)
+			auditLogs.GET("/:id", middleware.Timeout(defaultRequestTimeout), middleware.RequireAdmin(), auditHandler.GetAuditLog)
+			auditLogs.DELETE("/cleanup", middleware.Timeout(listRequestTimeout), middleware.RequireAdmin(), auditHandler.CleanupOldLogs)
+		}
+
+		// Admin routes, on the internal listener when it is enabled
+		adminBase := v1
+		if internal != nil {
+			adminBase = internal.Group("/api/v1")
+		}
+		admin := adminBase.Group("/admin")
+		admin.Use(middleware.Timeout(defaultRequestTimeout), middleware.JWTAuth(jwtManager, userRepo), trackUsage, limitByUser, userThrottle.Limit(), middleware.RequireAdmin())
+		{
+			admin.GET("/throttle", throttleHandler.GetStats)
+			admin.GET("/config", middleware.RequireSuperAdmin(), configHandler.GetConfig)
+
+			admin.GET("/flags", flagHandler.ListFlags)
+			admin.POST("/flags", flagHandler.CreateFlag)
+			admin.GET("/flags/:name", flagHandler.GetFlag)
+			admin.PUT("/flags/:name", flagHandler.UpdateFlag)
+			admin.DELETE("/flags/:name", flagHandler.DeleteFlag)
+		}
 
-			// Admin endpoints
-			auditLogs.GET("", middleware.RequireAdmin(), auditHandler.GetAuditLogs)
-			auditLogs.GET("/stats", middleware.RequireAdmin(), auditHandler.GetAuditStats)
-			auditLogs.GET("/:id", middleware.RequireAdmin(), auditHandler.GetAuditLog)
-			auditLogs.DELETE("/cleanup", middleware.RequireAdmin(), auditHandler.CleanupOldLogs)
+		// Fault injection for resilience testing (never in production)
+		if chaos != nil {
+			chaosHandler := handlers.NewChaosHandler(chaos, auditService)
+			chaosRoutes := v1.Group("/_chaos")
+			chaosRoutes.Use(middleware.Timeout(defaultRequestTimeout), middleware.JWTAuth(jwtManager, userRepo), middleware.RequireSuperAdmin())
+			{
+				chaosRoutes.GET("/faults", chaosHandler.ListFaults)
+				chaosRoutes.POST("/faults", chaosHandler.InjectFault)
+				chaosRoutes.DELETE("/faults", chaosHandler.ClearFaults)
+				chaosRoutes.DELETE("/faults/:id", chaosHandler.RemoveFault)
+				chaosRoutes.POST("/db-exhaust", chaosHandler.ExhaustPool)
+			}
 		}
 	}
 
 	// Start server
 	port := fmt.Sprintf(":%s", cfg.Server.Port)
+	logStartupSummary(cfg, chaos != nil)
 	logger.Info("🚀 Server starting...")
 	logger.Info("⚙️  Environment", "mode", cfg.App.Environment)
 	logger.Info("🛡️  Rate Limit", "per_minute", cfg.App.RateLimitPerMinute, "burst", cfg.App.RateLimitBurst)
+	logger.Info("🛡️  Concurrency Limit", "audit", cfg.Throttle.AuditLimit, "stats", cfg.Throttle.StatsLimit)
+	logger.Info("🛡️  CORS", "origins", cfg.CORS.AllowedOrigins, "credentials", cfg.CORS.AllowCredentials)
 	logger.Info("� JWT Authentication", "access_expiry", cfg.JWT.AccessTokenDuration, "refresh_expiry", cfg.JWT.RefreshTokenDuration)
 	logger.Info(" API Endpoints registered")
 	logger.Info("   Health endpoints", "liveness", "/health", "readiness", "/ready")
@@ -287,6 +704,7 @@ func main() {
 		"register", "POST /api/v1/auth/register",
 		"login", "POST /api/v1/auth/login",
 		"refresh", "POST /api/v1/auth/refresh",
+		"logout", "POST /api/v1/auth/logout",
 		"profile", "GET /api/v1/auth/profile [protected]",
 	)
 	logger.Info("   User endpoints",
@@ -295,14 +713,72 @@ func main() {
 		"get", "GET /api/v1/users/:id",
 		"create", "POST /api/v1/users",
 		"batch", "POST /api/v1/users/batch",
+		"import", "POST /api/v1/users/import",
 		"update", "PUT /api/v1/users/:id",
 		"delete", "DELETE /api/v1/users/:id",
+		"deactivate", "PUT /api/v1/users/:id/deactivate",
+		"activate", "PUT /api/v1/users/:id/activate",
+		"reset_password", "POST /api/v1/users/:id/reset-password",
+		"offboard", "POST /api/v1/users/:id/offboard [superadmin]",
 	)
+	logger.Info("   API v2", "prefix", "/api/v2", "routes", "auth, users", "errors", "application/problem+json")
 	logger.Info("🎯 Framework", "name", "Gin", "version", "v1.11.0")
 	logger.Info("🌐 Server listening", "address", fmt.Sprintf("http://localhost%s", port))
 
-	if err := r.Run(port); err != nil {
-		logger.Error("❌ Failed to start server", "error", err)
+	servers := []*http.Server{{
+		Addr:         port,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}}
+	if internal != nil {
+		internalPort := fmt.Sprintf(":%s", cfg.Server.InternalPort)
+		logger.Info("🔒 Internal listener", "address", fmt.Sprintf("http://localhost%s", internalPort),
+			"routes", "/metrics, /debug/pprof, /health, /api/v1/admin")
+		servers = append(servers, &http.Server{
+			Addr:        internalPort,
+			Handler:     internal,
+			ReadTimeout: cfg.Server.ReadTimeout,
+			IdleTimeout: cfg.Server.IdleTimeout,
+			// No WriteTimeout: CPU profiles and traces stream for as long as ?seconds asks
+		})
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := serve(ctx, cfg.Server.ShutdownTimeout, servers...)
+	if serveErr != nil {
+		logger.Error("❌ Server stopped with error", "error", serveErr)
+	}
+	stop() // A second signal kills the process
+
+	// Orderly shutdown: HTTP first so no handler uses the hub or database afterwards
+	logger.Info("🛑 Stopping health status watcher")
+	stopWatcher()
+	<-watcherStopped // No broadcast after the hub stops
+	logger.Info("🛑 Closing WebSocket connections")
+	wsHub.Stop()
+	logger.Info("🛑 Flushing API usage counters")
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := usageService.Close(flushCtx); err != nil {
+		logger.Error("❌ Failed to flush API usage", "error", err)
+	}
+	cancelFlush()
+	logger.Info("🛑 Stopping audit log retention")
+	stopRetention()
+	<-retentionStopped // A run in progress finishes before the database closes
+	logger.Info("🛑 Stopping audit log row cap")
+	stopAuditCap()
+	<-auditCapStopped
+	logger.Info("🛑 Stopping expired token pruning")
+	stopPruning()
+	<-pruningStopped
+	logger.Info("🛑 Closing database connections")
+	if err := database.Close(); err != nil {
+		logger.Error("❌ Failed to close database", "error", err)
+	}
+	if serveErr != nil {
 		os.Exit(1)
 	}
+	logger.Info("👋 Shutdown complete")
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/middleware"
+	"Go-Lang-project-01/internal/models"
+)
+
+// rbacRules are permission changes being rolled out. Each starts in the
+// mode set under rbac.rules and is switched to enforce by configuration
+// once its shadow denials stay at zero.
+var rbacRules = []middleware.RBACRule{
+	{Name: "users_list_admin_only", MinRole: models.RoleAdmin}, // GET /users
+}
+
+// buildRBACRules applies the configured modes to rbacRules, keyed by rule name
+func buildRBACRules(cfg configs.RBACConfig, onShadowDeny func(rule, route string, role models.Role)) (map[string]middleware.RBACRule, error) {
+	rules := make(map[string]middleware.RBACRule, len(rbacRules))
+	for _, rule := range rbacRules {
+		mode, err := middleware.ParseRuleMode(cfg.Rules[rule.Name])
+		if err != nil {
+			return nil, fmt.Errorf("rbac rule %s: %w", rule.Name, err)
+		}
+		rule.Mode = mode
+		rule.OnShadowDeny = onShadowDeny
+		rules[rule.Name] = rule
+	}
+
+	for name := range cfg.Rules {
+		if _, ok := rules[name]; !ok {
+			return nil, fmt.Errorf("unknown rbac rule %q", name)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/services"
+)
+
+// buildRegistrationGuard creates the limits and challenge of POST /auth/register
+func buildRegistrationGuard(cfg configs.RegisterConfig) (*services.RegistrationGuard, error) {
+	var challenge services.RegistrationChallenge
+	switch cfg.Challenge {
+	case "", "none":
+		challenge = services.NoChallenge{}
+	case "pow":
+		if cfg.PowDifficulty < 1 || cfg.PowDifficulty > 32 {
+			return nil, fmt.Errorf("register.powdifficulty must be between 1 and 32, got %d", cfg.PowDifficulty)
+		}
+		challenge = services.ProofOfWork{Difficulty: cfg.PowDifficulty}
+	default:
+		return nil, fmt.Errorf("unknown registration challenge %q", cfg.Challenge)
+	}
+
+	return services.NewRegistrationGuard(services.RegistrationGuardConfig{
+		IPPerHour:     cfg.IPPerHour,
+		IPBurst:       cfg.IPBurst,
+		DomainPerHour: cfg.DomainPerHour,
+		DomainBurst:   cfg.DomainBurst,
+		Challenge:     challenge,
+	}), nil
+}
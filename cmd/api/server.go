@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// serve runs servers until one fails or ctx is cancelled (SIGINT/SIGTERM).
+// Then all of them stop accepting connections and in-flight requests get up
+// to grace to finish before the remaining connections are closed.
+func serve(ctx context.Context, grace time.Duration, servers ...*http.Server) error {
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			errCh <- srv.ListenAndServe()
+		}()
+	}
+
+	var serveErr error
+	running := len(servers)
+	select {
+	case serveErr = <-errCh:
+		running-- // A failed listener takes the others down with it
+	case <-ctx.Done():
+		logger.Info("🛑 Shutdown signal received, draining in-flight requests", "grace_period", grace.String())
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, srv := range servers {
+		wg.Go(func() {
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				srv.Close()
+				mu.Lock()
+				serveErr = errors.Join(serveErr, err)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	for ; running > 0; running-- {
+		if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+			serveErr = errors.Join(serveErr, err)
+		}
+	}
+	if serveErr != nil {
+		return serveErr
+	}
+	logger.Info("✅ HTTP server stopped")
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// logStartupSummary logs a single structured line with what the process runs
+// with, so an environment can be identified from one log entry. Values come
+// from the loaded configuration; secrets are only reported as enabled.
+func logStartupSummary(cfg *configs.Config, chaosEnabled bool) {
+	listeners := map[string]string{"public": ":" + cfg.Server.Port}
+	if cfg.Server.InternalPort != "" {
+		listeners["internal"] = ":" + cfg.Server.InternalPort
+	}
+
+	logger.Info("📋 Startup summary",
+		"app", cfg.App.Name,
+		"version", cfg.App.Version,
+		"environment", cfg.App.Environment,
+		"gin_mode", cfg.Server.Mode,
+		"driver", cfg.Database.Driver,
+		"replicas", len(cfg.Database.Replicas),
+		"listeners", listeners,
+		"features", enabledFeatures(cfg, chaosEnabled),
+		"audit_sinks", cfg.Audit.Sinks,
+		"event_publishers", cfg.Events.Publishers,
+	)
+}
+
+// enabledFeatures lists the optional features turned on by the configuration
+func enabledFeatures(cfg *configs.Config, chaosEnabled bool) []string {
+	features := []string{}
+	add := func(enabled bool, name string) {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	add(cfg.Server.InternalPort != "", "internal_listener")
+	add(len(cfg.Database.Replicas) > 0, "read_replicas")
+	add(cfg.App.Environment != "production", "graphql_playground")
+	add(swaggerEnabled(cfg.App), "swagger")
+	add(cfg.Metrics.AuthToken != "", "metrics_token")
+	add(chaosEnabled, "chaos")
+	add(cfg.Reporting.SentryDSN != "", "error_reporting")
+	add(cfg.Health.DetailToken != "", "health_detail_token")
+	add(len(cfg.HealthChecks.HTTP) > 0, "http_health_checks")
+	add(cfg.Health.WatchInterval > 0, "health_watcher")
+	add(cfg.Audit.ParseUserAgent, "audit_user_agent")
+	add(cfg.Audit.GeoIPDatabase != "", "audit_geoip")
+	add(cfg.Audit.MaxRows > 0, "audit_row_cap")
+	add(cfg.Audit.RetentionDays > 0, "audit_retention")
+	add(cfg.Register.Challenge != "" && cfg.Register.Challenge != "none", "register_challenge_"+cfg.Register.Challenge)
+	add(cfg.Throttle.PerUserLimit > 0, "per_user_throttle")
+	add(cfg.Cache.Enabled, "user_cache")
+	return features
+}
+
+// swaggerEnabled reports whether /swagger is served: always outside
+// production, and in production only with app.enableswagger
+func swaggerEnabled(app configs.AppConfig) bool {
+	return app.Environment != "production" || app.EnableSwagger
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// tokenPruneInterval is how often expired token revocations and refresh token records are deleted
+const tokenPruneInterval = time.Hour
+
+// expiredTokenPruner deletes rows of tokens that expired before now
+type expiredTokenPruner interface {
+	PruneExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// pruneExpiredTokens deletes the rows of expired refresh tokens from every
+// repository now and then every interval until ctx is done. A run in progress
+// is not interrupted.
+func pruneExpiredTokens(ctx context.Context, interval time.Duration, repos ...expiredTokenPruner) {
+	prune := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for _, repo := range repos {
+			pruned, err := repo.PruneExpired(ctx, time.Now())
+			if err != nil {
+				logger.Error("Failed to prune expired tokens", "error", err)
+				continue
+			}
+			if pruned > 0 {
+				logger.Info("Pruned expired tokens", "count", pruned)
+			}
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			prune()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
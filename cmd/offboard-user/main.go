@@ -0,0 +1,92 @@
+// Command offboard-user revokes all access of a user from the command line,
+// for use when the API is unavailable or no superadmin token is at hand.
+//
+// Usage:
+//
+//	go run ./cmd/offboard-user <email>
+//
+// WebSocket connections live in the API process and are not closed by this
+// command; use POST /api/v1/users/:id/offboard to drop them as well.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/database"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: go run ./cmd/offboard-user <email>")
+		os.Exit(1)
+	}
+	email := os.Args[1]
+
+	cfg, err := configs.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := database.Connect(cfg.Database); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	db := database.GetDB()
+	if err := db.AutoMigrate(&models.User{}, &models.AuditLog{}); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to migrate database: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	userRepo := repository.NewUserRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+
+	user, err := userRepo.GetByEmail(ctx, email)
+	if err != nil || user == nil {
+		fmt.Fprintf(os.Stderr, "❌ User not found: %s\n", email)
+		os.Exit(1)
+	}
+
+	// Actor 0 means "system": the CLI has no authenticated caller
+	report, err := services.NewOffboardService(userRepo, nil).OffboardUser(ctx, 0, uint(user.ID))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Offboarding failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	details, schema, _ := services.EncodeAuditDetails(models.AuditActionUserOffboard, report)
+	auditLog := &models.AuditLog{
+		Action:        models.AuditActionUserOffboard,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    &user.ID,
+		Details:       details,
+		DetailsSchema: schema,
+		UserAgent:     "offboard-user-cli",
+		Success:       report.Success,
+		CreatedAt:     time.Now(),
+	}
+	if !report.Success {
+		auditLog.ErrorMsg = "one or more offboarding steps failed"
+	}
+	if err := auditRepo.Create(auditLog); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to write audit log: %v\n", err)
+	}
+
+	pretty, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(pretty))
+
+	if !report.Success {
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %s has been offboarded\n", user.Email)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+)
+
+// demoDomain is the email domain of every demo user. It is reserved, so the
+// addresses can never reach a real mailbox, and it marks the rows -reset deletes.
+const demoDomain = "demo.example"
+
+// demoPassword is the password of every demo user
+const demoPassword = "demo-password"
+
+var (
+	demoFirstNames = []string{"Ada", "Alan", "Barbara", "Claude", "Donald", "Edsger", "Frances", "Grace", "Hedy", "Ivan", "Joan", "Ken", "Linus", "Margaret", "Niklaus", "Radia", "Shafi", "Tim"}
+	demoLastNames  = []string{"Allen", "Bartik", "Cerf", "Dijkstra", "Engelbart", "Goldberg", "Hamilton", "Hopper", "Knuth", "Lamarr", "Liskov", "Perlman", "Ritchie", "Sammet", "Thompson", "Wirth"}
+	demoBios       = []string{"", "", "Keeps the lights on.", "Ask me about the billing migration.", "On call this week.", "Coffee first, tickets second."}
+	demoUserAgents = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+	}
+)
+
+// demoOptions controls the size and timing of the demo data
+type demoOptions struct {
+	Seed   uint64
+	Admins int
+	Users  int
+	Weeks  int
+	Until  time.Time // Day the history ends on, at midnight UTC
+}
+
+// demoUser is a generated user and its history
+type demoUser struct {
+	User      models.User
+	IP        string
+	UserAgent string
+}
+
+// demoData is everything the seeder writes. Audit logs refer to users by
+// their index in Users; IDs are assigned on insert.
+type demoData struct {
+	Users []demoUser
+	Logs  []demoLog
+}
+
+// demoLog is an audit log whose actor and target are indexes into demoData.Users
+type demoLog struct {
+	Log    models.AuditLog
+	Actor  int
+	Target int // -1 when the log has no target user
+}
+
+// generateDemo builds the demo data. The same options always produce the
+// same data, down to names, flags and timestamps.
+func generateDemo(opts demoOptions) demoData {
+	rng := rand.New(rand.NewPCG(opts.Seed, 0x5eed))
+	start := opts.Until.AddDate(0, 0, -7*opts.Weeks)
+	var data demoData
+
+	// The superadmin exists from the first day and creates everyone else
+	roles := []models.Role{models.RoleSuperAdmin}
+	for range opts.Admins {
+		roles = append(roles, models.RoleAdmin)
+	}
+	for range opts.Users {
+		roles = append(roles, models.RoleUser)
+	}
+	counts := map[models.Role]int{}
+	for i, role := range roles {
+		counts[role]++
+		first := demoFirstNames[rng.IntN(len(demoFirstNames))]
+		last := demoLastNames[rng.IntN(len(demoLastNames))]
+		email := fmt.Sprintf("%s%d@%s", role, counts[role], demoDomain)
+		if role == models.RoleSuperAdmin {
+			email = "superadmin@" + demoDomain
+		}
+
+		createdAt := start.Add(9 * time.Hour)
+		if i > 0 {
+			// Later accounts join during office hours in the first half of the period
+			day := start.AddDate(0, 0, rng.IntN(7*opts.Weeks/2+1))
+			createdAt = day.Add(9*time.Hour + time.Duration(rng.Int64N(int64(8*time.Hour)))).Truncate(time.Second)
+		}
+		user := models.User{
+			Name:      first + " " + last,
+			Email:     email,
+			Age:       22 + rng.IntN(45),
+			Role:      role,
+			IsActive:  role != models.RoleUser || rng.IntN(5) > 0, // About one user in five is deactivated
+			Bio:       demoBios[rng.IntN(len(demoBios))],
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		if rng.IntN(3) == 0 {
+			user.PhoneNumber = fmt.Sprintf("+1-555-01%02d", rng.IntN(100))
+		}
+		data.Users = append(data.Users, demoUser{
+			User:      user,
+			IP:        fmt.Sprintf("198.51.100.%d", 10+i),
+			UserAgent: demoUserAgents[rng.IntN(len(demoUserAgents))],
+		})
+		if i > 0 {
+			data.Logs = append(data.Logs, demoAuditLog(data.Users[0], 0, models.AuditActionUserCreate, models.AuditResourceUser, i, createdAt))
+		}
+	}
+
+	// Daily activity from each account's creation on
+	for day := start; day.Before(opts.Until); day = day.AddDate(0, 0, 1) {
+		for i, u := range data.Users {
+			if day.Before(u.User.CreatedAt.Truncate(24 * time.Hour)) {
+				continue
+			}
+			weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+			if rng.IntN(10) < 4 || (weekend && rng.IntN(3) > 0) {
+				continue
+			}
+			at := day.Add(8*time.Hour + time.Duration(rng.Int64N(int64(10*time.Hour))))
+			if at.Before(u.User.CreatedAt) {
+				continue
+			}
+
+			if rng.IntN(8) == 0 {
+				failed := demoAuditLog(u, i, models.AuditActionLoginFailed, models.AuditResourceAuth, -1, at)
+				failed.Log.Success = false
+				failed.Log.ErrorMsg = "Invalid password"
+				data.Logs = append(data.Logs, failed)
+				at = at.Add(time.Duration(20+rng.IntN(40)) * time.Second)
+			}
+			data.Logs = append(data.Logs, demoAuditLog(u, i, models.AuditActionLogin, models.AuditResourceAuth, -1, at))
+
+			switch n := rng.IntN(20); {
+			case n == 0:
+				data.Logs = append(data.Logs, demoAuditLog(u, i, models.AuditActionPasswordChange, models.AuditResourceProfile, i, at.Add(3*time.Minute)))
+			case n < 3:
+				data.Logs = append(data.Logs, demoAuditLog(u, i, models.AuditActionProfileUpdate, models.AuditResourceProfile, i, at.Add(5*time.Minute)))
+			case n < 5 && u.User.Role.AtLeast(models.RoleAdmin):
+				target := 1 + opts.Admins + rng.IntN(max(opts.Users, 1))
+				if target < len(data.Users) {
+					data.Logs = append(data.Logs, demoAuditLog(u, i, models.AuditActionUserUpdate, models.AuditResourceUser, target, at.Add(10*time.Minute)))
+				}
+			}
+			if rng.IntN(3) == 0 {
+				data.Logs = append(data.Logs, demoAuditLog(u, i, models.AuditActionLogout, models.AuditResourceAuth, -1, at.Add(time.Duration(1+rng.IntN(8))*time.Hour)))
+			}
+		}
+	}
+
+	// Deactivated users were deactivated by an admin near the end of the period
+	for i, u := range data.Users {
+		if u.User.IsActive {
+			continue
+		}
+		actor := rng.IntN(1 + opts.Admins)
+		at := opts.Until.AddDate(0, 0, -1-rng.IntN(7)).Add(15 * time.Hour)
+		if at.Before(u.User.CreatedAt) {
+			at = u.User.CreatedAt.Add(time.Hour)
+		}
+		data.Logs = append(data.Logs, demoAuditLog(data.Users[actor], actor, models.AuditActionUserDeactivate, models.AuditResourceUser, i, at))
+		data.Users[i].User.UpdatedAt = at.Truncate(time.Second)
+	}
+
+	// Insert in time order, so IDs grow with created_at like in a live database
+	slices.SortStableFunc(data.Logs, func(a, b demoLog) int { return a.Log.CreatedAt.Compare(b.Log.CreatedAt) })
+	return data
+}
+
+// demoAuditLog returns a successful audit log of actor (at index actorIndex)
+func demoAuditLog(actor demoUser, actorIndex int, action models.AuditAction, resource models.AuditResource, target int, at time.Time) demoLog {
+	return demoLog{
+		Log: models.AuditLog{
+			Action:    action,
+			Resource:  resource,
+			IPAddress: actor.IP,
+			UserAgent: actor.UserAgent,
+			Success:   true,
+			CreatedAt: at.Truncate(time.Second),
+		},
+		Actor:  actorIndex,
+		Target: target,
+	}
+}
+
+// parseDay parses a YYYY-MM-DD date as midnight UTC
+func parseDay(s string) (time.Time, error) {
+	t, err := time.Parse(models.DateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,212 @@
+// Command seed fills the local database with data for development.
+//
+// Usage:
+//
+//	go run ./cmd/seed demo [flags]
+//
+// Run it with -h for the flags and the accounts it creates. It refuses to run
+// when app.environment is production.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `Usage: go run ./cmd/seed <command> [flags]
+
+Commands:
+  demo    create demo users and several weeks of audit history
+
+Run "go run ./cmd/seed demo -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Print(usage)
+		return
+	}
+	switch os.Args[1] {
+	case "demo":
+		if err := seedDemo(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// seedDemo runs the demo command
+func seedDemo(args []string) error {
+	fs := flag.NewFlagSet("demo", flag.ExitOnError)
+	seed := fs.Uint64("seed", 1, "seed of the generator; the same seed and -until always produce the same data")
+	admins := fs.Int("admins", 2, "number of admins besides the superadmin")
+	users := fs.Int("users", 8, "number of regular users; about one in five is deactivated")
+	weeks := fs.Int("weeks", 6, "weeks of audit history")
+	until := fs.String("until", time.Now().UTC().Format(models.DateLayout), "last day of the history (YYYY-MM-DD); pin it to reproduce data on another day")
+	reset := fs.Bool("reset", false, "delete existing demo users and their audit logs first")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `Usage: go run ./cmd/seed demo [flags]
+
+Creates a superadmin, admins and users with varied roles and activity flags,
+plus audit history (logins, failed logins, profile changes, user updates and
+deactivations) spanning several weeks before -until. Everything is derived
+from -seed, so screenshots and local tests can be reproduced exactly; only
+the IDs depend on what the database held before.
+
+Accounts (password %q for all):
+  superadmin@%[2]s        superadmin
+  admin1@%[2]s, ...       admins
+  user1@%[2]s, ...        users, some deactivated
+
+Data goes into the database configured for the API. The command refuses to
+run when app.environment is production, and fails if demo users already exist
+unless -reset is given. Only rows of %[2]s users are touched.
+
+Flags:
+`, demoPassword, demoDomain)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := configs.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.App.Environment == "production" {
+		return fmt.Errorf("refusing to seed demo data: app.environment is production")
+	}
+	if *admins < 0 || *users < 0 || *weeks < 1 {
+		return fmt.Errorf("-admins and -users must not be negative and -weeks must be at least 1")
+	}
+	untilDay, err := parseDay(*until)
+	if err != nil {
+		return err
+	}
+
+	if err := database.Connect(cfg.Database); err != nil {
+		return err
+	}
+	db := database.GetDB().Session(&gorm.Session{Logger: logger.Discard})
+	if err := db.AutoMigrate(&models.User{}, &models.AuditLog{}); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	data := generateDemo(demoOptions{Seed: *seed, Admins: *admins, Users: *users, Weeks: *weeks, Until: untilDay})
+	hashed, err := auth.HashPassword(demoPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if *reset {
+			if err := deleteDemo(tx); err != nil {
+				return err
+			}
+		}
+		return insertDemo(tx, data, hashed)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Seeded %d users and %d audit logs (seed %d, %d weeks until %s)\n",
+		len(data.Users), len(data.Logs), *seed, *weeks, untilDay.Format(models.DateLayout))
+	for _, u := range data.Users {
+		status := "active"
+		if !u.User.IsActive {
+			status = "inactive"
+		}
+		fmt.Printf("   %-28s %-10s %-8s %s\n", u.User.Email, u.User.Role, status, u.User.Name)
+	}
+	fmt.Printf("   Password for all: %s\n", demoPassword)
+	return nil
+}
+
+// demoUsers selects the demo users, including soft-deleted ones
+func demoUsers(tx *gorm.DB) *gorm.DB {
+	return tx.Unscoped().Model(&models.User{}).Where("email LIKE ?", "%@"+demoDomain)
+}
+
+// deleteDemo removes the demo users and every audit log by or about them
+func deleteDemo(tx *gorm.DB) error {
+	var ids []models.ID
+	if err := demoUsers(tx).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to find demo users: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("user_id IN ? OR (resource = ? AND resource_id IN ?)", ids, models.AuditResourceUser, ids).
+		Delete(&models.AuditLog{}).Error; err != nil {
+		return fmt.Errorf("failed to delete demo audit logs: %w", err)
+	}
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.User{}).Error; err != nil {
+		return fmt.Errorf("failed to delete demo users: %w", err)
+	}
+	return nil
+}
+
+// insertDemo writes the generated users and audit logs
+func insertDemo(tx *gorm.DB, data demoData, hashedPassword string) error {
+	var existing int64
+	if err := demoUsers(tx).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to count demo users: %w", err)
+	}
+	if existing > 0 {
+		return fmt.Errorf("%d demo users already exist; run with -reset to replace them", existing)
+	}
+
+	ids := make([]models.ID, len(data.Users))
+	for i := range data.Users {
+		user := data.Users[i].User
+		user.Password = hashedPassword
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create %s: %w", user.Email, err)
+		}
+		// is_active has a database default of true, so a false value is skipped on insert
+		if !user.IsActive {
+			if err := tx.Model(&user).UpdateColumn("is_active", false).Error; err != nil {
+				return fmt.Errorf("failed to deactivate %s: %w", user.Email, err)
+			}
+		}
+		ids[i] = user.ID
+	}
+
+	logs := make([]models.AuditLog, len(data.Logs))
+	for i, entry := range data.Logs {
+		logs[i] = entry.Log
+		logs[i].UserID = &ids[entry.Actor]
+		if entry.Target >= 0 {
+			logs[i].ResourceID = &ids[entry.Target]
+		}
+	}
+	if err := tx.CreateInBatches(logs, 500).Error; err != nil {
+		return fmt.Errorf("failed to create audit logs: %w", err)
+	}
+	// success has a database default of true, so false values are skipped on insert
+	var failed []models.ID
+	for i, entry := range data.Logs {
+		if !entry.Log.Success {
+			failed = append(failed, logs[i].ID)
+		}
+	}
+	if len(failed) > 0 {
+		if err := tx.Model(&models.AuditLog{}).Where("id IN ?", failed).Update("success", false).Error; err != nil {
+			return fmt.Errorf("failed to mark failed logins: %w", err)
+		}
+	}
+	return nil
+}
@@ -13,11 +13,28 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Logger   LoggerConfig
-	App      AppConfig
-	JWT      JWTConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Logger       LoggerConfig
+	App          AppConfig
+	JWT          JWTConfig
+	Health       HealthConfig
+	HealthChecks HealthChecksConfig
+	Audit        AuditConfig
+	WebSocket    WebSocketConfig
+	Throttle     ThrottleConfig
+	Events       EventsConfig
+	Reporting    ReportingConfig
+	RBAC         RBACConfig
+	Usage        UsageConfig
+	Flags        FlagsConfig
+	Chaos        ChaosConfig
+	Register     RegisterConfig
+	Request      RequestConfig
+	Response     ResponseConfig
+	Cache        CacheConfig
+	CORS         CORSConfig
+	Metrics      MetricsConfig
 }
 
 // ServerConfig holds server configuration
@@ -27,20 +44,31 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	// ShutdownTimeout is how long in-flight requests get to finish on SIGINT/SIGTERM
+	ShutdownTimeout time.Duration
+
+	// InternalPort, when set, starts a second listener for metrics, pprof,
+	// health details and /api/v1/admin; they are then removed from Port
+	InternalPort string
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Driver          string
-	Host            string
-	Port            int
-	User            string
-	Password        string
-	DBName          string
-	SSLMode         string
-	MaxIdleConns    int
-	MaxOpenConns    int
-	ConnMaxLifetime time.Duration
+	Driver             string
+	Host               string
+	Port               int
+	User               string
+	Password           string
+	DBName             string
+	SSLMode            string
+	MaxIdleConns       int
+	MaxOpenConns       int
+	ConnMaxLifetime    time.Duration
+	Replicas           []string // Read replica DSNs; empty = primary only
+	ReplicaMaxLag      time.Duration
+	QueryMetrics       bool          // Record db_query_duration_seconds and log slow queries
+	SlowQueryThreshold time.Duration // Queries at least this slow are logged; 0 disables the log
 }
 
 // LoggerConfig holds logger configuration
@@ -54,9 +82,19 @@ type LoggerConfig struct {
 type AppConfig struct {
 	Name               string
 	Version            string
-	Environment        string // "development", "staging", "production"
-	RateLimitPerMinute int    // Requests per minute per IP
-	RateLimitBurst     int    // Burst size for rate limiter
+	Environment        string        // "development", "staging", "production"
+	RateLimitPerMinute int           // Requests per minute per IP
+	RateLimitBurst     int           // Burst size for rate limiter
+	RateLimitIdleTTL   time.Duration // Idle time after which a client's rate limit bucket is dropped
+	BatchConcurrency   int           // Passwords hashed at once by POST /users/batch
+	BatchInsertTimeout time.Duration // Time budget for the transaction inserting a whole batch
+	ImportMaxBytes     int64         // Largest CSV file accepted by POST /users/import
+	ImportMaxRows      int           // Most users per CSV file
+	EnableSwagger      bool          // Serve /swagger in production; always served in other environments
+
+	PasswordMinLength             int  // Shortest password accepted by the strong_password tag
+	PasswordRequireLetterAndDigit bool // Passwords need at least one letter and one digit
+	PasswordRejectCommon          bool // Reject passwords on the embedded common-password list
 }
 
 // JWTConfig holds JWT authentication configuration
@@ -64,6 +102,148 @@ type JWTConfig struct {
 	SecretKey            string
 	AccessTokenDuration  string
 	RefreshTokenDuration string
+	Issuer               string   // "iss" claim; give each deployment its own
+	Audience             string   // "aud" claim; give each deployment its own
+	PreviousSecretKeys   []string // Still accepted for validation after a key rotation, never used to sign
+}
+
+// HealthConfig holds health endpoint configuration
+type HealthConfig struct {
+	DetailToken      string        // Shared secret for X-Health-Token; empty disables token access to details
+	CryptoHashBudget time.Duration // Slowest acceptable password hash in the crypto readiness check
+	WatchInterval    time.Duration // How often the status is checked for health.status.changed WebSocket events; 0 disables
+}
+
+// MetricsConfig holds optional Prometheus metrics
+type MetricsConfig struct {
+	SizeSummaries bool   // Record http_request_size_bytes and http_response_size_bytes
+	AuthToken     string // Bearer token required on the public /metrics in production; empty rejects every scrape there
+}
+
+// CacheConfig holds the in-process cache of user lookups and stats
+type CacheConfig struct {
+	Enabled bool
+	TTL     time.Duration // Longest a write that bypasses the user service stays unseen
+}
+
+// HealthChecksConfig holds optional health checks of downstream services
+type HealthChecksConfig struct {
+	HTTP []HTTPCheckConfig // Gate /ready like the database
+}
+
+// HTTPCheckConfig configures the health check of a downstream HTTP service
+type HTTPCheckConfig struct {
+	Name           string        // Component name in health reports, e.g. notifications
+	URL            string        // Endpoint requested on every check
+	Method         string        // Defaults to GET
+	ExpectedStatus []int         // Accepted status codes; empty accepts any 2xx
+	Timeout        time.Duration // Defaults to 5s
+	WarnThreshold  time.Duration // Slower successful responses report degraded; 0 disables
+	BodyContains   string        // Substring the response body must contain; empty skips the check
+}
+
+// AuditConfig holds audit log sink configuration
+type AuditConfig struct {
+	Sinks       []string      // "database", "stdout", "file", "http"; the first is primary
+	FilePath    string        // JSONL file for the "file" sink
+	HTTPURL     string        // Collector endpoint for the "http" sink
+	HTTPTimeout time.Duration // Request timeout for the "http" sink
+
+	CaptureHeaders []string // Request headers copied into audit metadata, e.g. X-Client-Version
+	ParseUserAgent bool     // Store a browser/OS/device summary in audit metadata
+	GeoIPDatabase  string   // MaxMind country MMDB path; empty disables GeoIP lookups
+
+	MaxRows          int64         // Rows kept in the audit log table; 0 disables the cap
+	MinRetention     time.Duration // Rows younger than this are never trimmed, even above MaxRows
+	CapCheckInterval time.Duration // How often the row count is checked against MaxRows
+	CapTrimBatch     int           // Rows deleted per statement when trimming
+
+	RetentionDays   int           // Logs older than this are deleted on a schedule; 0 disables the job
+	CleanupInterval time.Duration // How often logs older than RetentionDays are deleted
+}
+
+// WebSocketConfig holds WebSocket hub configuration
+type WebSocketConfig struct {
+	BroadcastBufferSize   int           // Capacity of the hub broadcast queue
+	DropPolicy            string        // "drop_newest", "drop_oldest" or "block_with_timeout"
+	BlockTimeout          time.Duration // Max wait for "block_with_timeout"
+	AllowedOrigins        []string      // Browser origins allowed to connect besides the server's own; "*" allows all
+	MaxConnections        int           // Connections across all users; -1 disables the limit
+	MaxConnectionsPerUser int           // Connections per user; -1 disables the limit
+	StaleTimeout          time.Duration // Clients without a pong for this long are evicted; -1 disables eviction
+	SweepInterval         time.Duration // How often stale clients are looked for
+}
+
+// ThrottleConfig holds concurrency limits for expensive admin endpoint groups
+type ThrottleConfig struct {
+	AuditLimit   int           // Max concurrent admin audit log queries; 0 disables the limit
+	StatsLimit   int           // Max concurrent statistics requests; 0 disables the limit
+	RetryAfter   time.Duration // Retry-After advertised when a group is saturated
+	PerUserLimit int           // Max concurrent requests per authenticated user; 0 disables the limit
+}
+
+// CORSConfig holds the cross-origin policy for browser clients
+type CORSConfig struct {
+	AllowedOrigins   []string      // Exact origins or subdomain patterns like "https://*.example.com"; "*" allows all
+	AllowedMethods   []string      // Methods announced in preflight responses
+	AllowedHeaders   []string      // Request headers announced in preflight responses
+	ExposedHeaders   []string      // Response headers readable by browser scripts
+	AllowCredentials bool          // Send Access-Control-Allow-Credentials
+	MaxAge           time.Duration // How long browsers may cache a preflight; 0 omits the header
+}
+
+// EventsConfig holds domain event publisher configuration
+type EventsConfig struct {
+	Publishers []string // "websocket", "log"; empty discards events
+}
+
+// ReportingConfig holds error reporting configuration
+type ReportingConfig struct {
+	SentryDSN string // Sentry-compatible DSN; empty disables reporting
+	QueueSize int    // Reports waiting for delivery; further reports are dropped
+}
+
+// RBACConfig holds the rollout mode of permission rules
+type RBACConfig struct {
+	Rules map[string]string // Rule name -> "off", "shadow" or "enforce"
+}
+
+// UsageConfig holds per-user API usage accounting configuration
+type UsageConfig struct {
+	Bucket        time.Duration // Aggregation granularity; must divide 24h
+	RetentionDays int           // Days of usage kept; also the longest report window
+	FlushInterval time.Duration // How often in-memory counters are written to the database
+}
+
+// RequestConfig holds the request body limits of the public router
+type RequestConfig struct {
+	MaxBodyBytes int64 // Largest request body in bytes; 0 disables the limit. POST /users/import allows app.importmaxbytes instead
+}
+
+// ResponseConfig holds the response size ceilings of the public router
+type ResponseConfig struct {
+	MaxBytes int64            // Largest response body in bytes; 0 disables the ceiling
+	Routes   map[string]int64 // Ceilings per route template (e.g. /api/v1/users), overriding MaxBytes; 0 disables it for the route
+}
+
+// ChaosConfig holds fault injection configuration
+type ChaosConfig struct {
+	Enabled bool // Serve /api/v1/_chaos; ignored when app.environment is production
+}
+
+// RegisterConfig holds the limits of the public registration endpoint
+type RegisterConfig struct {
+	IPPerHour     int    // Registrations per client IP and hour; 0 disables the limit
+	IPBurst       int    // Registrations an IP may make at once
+	DomainPerHour int    // Registrations per email domain and hour; 0 disables the limit
+	DomainBurst   int    // Registrations a domain may get at once
+	Challenge     string // "none" or "pow"
+	PowDifficulty int    // Leading zero bits required by the "pow" challenge
+}
+
+// FlagsConfig holds feature flag configuration
+type FlagsConfig struct {
+	CacheTTL time.Duration // How long flags are served from memory before being reloaded
 }
 
 // LoadConfig loads configuration from environment and config file using Viper
@@ -89,6 +269,7 @@ func LoadConfig() (*Config, error) {
 	// Override with environment variables
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	applyRenamedKeys()
 
 	// Unmarshal config
 	var config Config
@@ -99,6 +280,25 @@ func LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
+// renamedKeys maps old configuration keys to the keys replacing them
+var renamedKeys = map[string]string{
+	"app.batchitemtimeout": "app.batchinserttimeout",
+}
+
+// sourcePrecedence ranks the sources reported by source
+var sourcePrecedence = map[string]int{SourceDefault: 0, SourceFile: 1, SourceEnv: 2}
+
+// applyRenamedKeys keeps old keys working: the value of an old key is used
+// when it comes from a source that overrides the one of its new key, e.g. an
+// old environment variable over the new key in config.yaml
+func applyRenamedKeys() {
+	for old, key := range renamedKeys {
+		if sourcePrecedence[source(old)] > sourcePrecedence[source(key)] {
+			viper.Set(key, viper.Get(old))
+		}
+	}
+}
+
 // setDefaults sets default configuration values
 func setDefaults() {
 	// Server defaults
@@ -107,6 +307,8 @@ func setDefaults() {
 	viper.SetDefault("server.readtimeout", 10*time.Second)
 	viper.SetDefault("server.writetimeout", 10*time.Second)
 	viper.SetDefault("server.idletimeout", 60*time.Second)
+	viper.SetDefault("server.shutdowntimeout", 30*time.Second)
+	viper.SetDefault("server.internalport", "")
 
 	// Database defaults
 	viper.SetDefault("database.driver", "sqlite")
@@ -119,6 +321,10 @@ func setDefaults() {
 	viper.SetDefault("database.maxidleconns", 10)
 	viper.SetDefault("database.maxopenconns", 100)
 	viper.SetDefault("database.connmaxlifetime", 1*time.Hour)
+	viper.SetDefault("database.replicas", []string{})
+	viper.SetDefault("database.replicamaxlag", 30*time.Second)
+	viper.SetDefault("database.querymetrics", true)
+	viper.SetDefault("database.slowquerythreshold", 200*time.Millisecond)
 
 	// Logger defaults
 	viper.SetDefault("logger.level", "info")
@@ -131,11 +337,115 @@ func setDefaults() {
 	viper.SetDefault("app.environment", "development")
 	viper.SetDefault("app.ratelimitperminute", 100) // 100 requests per minute
 	viper.SetDefault("app.ratelimitburst", 10)      // Allow burst of 10 requests
+	viper.SetDefault("app.ratelimitidlettl", 10*time.Minute)
+	viper.SetDefault("app.batchconcurrency", 5)
+	viper.SetDefault("app.batchinserttimeout", 30*time.Second)
+	viper.SetDefault("app.importmaxbytes", 1<<20)
+	viper.SetDefault("app.importmaxrows", 1000)
+	viper.SetDefault("app.enableswagger", false)
+	viper.SetDefault("app.passwordminlength", 8)
+	viper.SetDefault("app.passwordrequireletteranddigit", true)
+	viper.SetDefault("app.passwordrejectcommon", true)
 
 	// JWT defaults
 	viper.SetDefault("jwt.secretkey", "change-this-secret-key-in-production")
 	viper.SetDefault("jwt.accesstokenduration", "24h")
 	viper.SetDefault("jwt.refreshtokenduration", "168h") // 7 days
+	viper.SetDefault("jwt.issuer", "Go-Lang-project-01")
+	viper.SetDefault("jwt.audience", "Go-Lang-project-01-api")
+	viper.SetDefault("jwt.previoussecretkeys", []string{})
+
+	// Health defaults
+	viper.SetDefault("health.detailtoken", "")
+	viper.SetDefault("health.cryptohashbudget", 1*time.Second)
+	viper.SetDefault("health.watchinterval", 30*time.Second)
+
+	// Downstream health checks, e.g.
+	// healthchecks.http: [{name: notifications, url: "http://notify:8080/health", warnthreshold: 500ms}]
+	viper.SetDefault("healthchecks.http", []interface{}{})
+
+	// Audit defaults
+	viper.SetDefault("audit.sinks", []string{"database"})
+	viper.SetDefault("audit.filepath", "audit.jsonl")
+	viper.SetDefault("audit.httpurl", "")
+	viper.SetDefault("audit.httptimeout", 5*time.Second)
+	viper.SetDefault("audit.captureheaders", []string{"X-Client-Version"})
+	viper.SetDefault("audit.parseuseragent", true)
+	viper.SetDefault("audit.geoipdatabase", "")
+	viper.SetDefault("audit.maxrows", 0)
+	viper.SetDefault("audit.minretention", 720*time.Hour)
+	viper.SetDefault("audit.capcheckinterval", 10*time.Minute)
+	viper.SetDefault("audit.captrimbatch", 1000)
+	viper.SetDefault("audit.retentiondays", 0)
+	viper.SetDefault("audit.cleanupinterval", 24*time.Hour)
+
+	// WebSocket defaults
+	viper.SetDefault("websocket.broadcastbuffersize", 256)
+	viper.SetDefault("websocket.droppolicy", "drop_newest")
+	viper.SetDefault("websocket.blocktimeout", 100*time.Millisecond)
+	viper.SetDefault("websocket.allowedorigins", []string{})
+	viper.SetDefault("websocket.maxconnections", 10000)
+	viper.SetDefault("websocket.maxconnectionsperuser", 5)
+	viper.SetDefault("websocket.staletimeout", 2*time.Minute)
+	viper.SetDefault("websocket.sweepinterval", 15*time.Second)
+
+	// Throttle defaults
+	viper.SetDefault("throttle.auditlimit", 4)
+	viper.SetDefault("throttle.statslimit", 4)
+	viper.SetDefault("throttle.retryafter", 2*time.Second)
+	viper.SetDefault("throttle.peruserlimit", 10)
+
+	// Events defaults
+	viper.SetDefault("events.publishers", []string{"websocket"})
+
+	// CORS defaults (any origin, as before the policy was configurable)
+	viper.SetDefault("cors.allowedorigins", []string{"*"})
+	viper.SetDefault("cors.allowedmethods", []string{"POST", "OPTIONS", "GET", "HEAD", "PUT", "DELETE", "PATCH"})
+	viper.SetDefault("cors.allowedheaders", []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", "X-Request-ID", "X-ID-Format"})
+	viper.SetDefault("cors.exposedheaders", []string{"X-Request-ID"})
+	viper.SetDefault("cors.allowcredentials", true)
+	viper.SetDefault("cors.maxage", time.Duration(0))
+
+	// Reporting defaults
+	viper.SetDefault("reporting.sentrydsn", "")
+	viper.SetDefault("reporting.queuesize", 100)
+
+	// RBAC defaults
+	viper.SetDefault("rbac.rules", map[string]string{"users_list_admin_only": "shadow"})
+
+	// Usage defaults
+	viper.SetDefault("usage.bucket", 24*time.Hour)
+	viper.SetDefault("usage.retentiondays", 90)
+	viper.SetDefault("usage.flushinterval", 10*time.Second)
+
+	// Feature flag defaults
+	viper.SetDefault("flags.cachettl", 30*time.Second)
+
+	// Request body defaults
+	viper.SetDefault("request.maxbodybytes", 1<<20)
+
+	// Response size defaults
+	viper.SetDefault("response.maxbytes", 5<<20)
+	viper.SetDefault("response.routes", map[string]int64{})
+
+	// Cache defaults
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.ttl", 30*time.Second)
+
+	// Metrics defaults
+	viper.SetDefault("metrics.sizesummaries", true)
+	viper.SetDefault("metrics.authtoken", "")
+
+	// Chaos defaults
+	viper.SetDefault("chaos.enabled", false)
+
+	// Registration defaults
+	viper.SetDefault("register.ipperhour", 10)
+	viper.SetDefault("register.ipburst", 5)
+	viper.SetDefault("register.domainperhour", 100)
+	viper.SetDefault("register.domainburst", 20)
+	viper.SetDefault("register.challenge", "none")
+	viper.SetDefault("register.powdifficulty", 20)
 }
 
 // GetDSN returns database connection string for PostgreSQL
@@ -0,0 +1,78 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_PostgresDSN(t *testing.T) {
+	t.Setenv("DATABASE_DRIVER", "postgres")
+	t.Setenv("DATABASE_HOST", "db.staging.internal")
+	t.Setenv("DATABASE_PORT", "6432")
+	t.Setenv("DATABASE_USER", "api")
+	t.Setenv("DATABASE_PASSWORD", "s3cret")
+	t.Setenv("DATABASE_DBNAME", "users")
+	t.Setenv("DATABASE_SSLMODE", "verify-full")
+	t.Setenv("DATABASE_CONNMAXLIFETIME", "15m")
+	loadTestConfig(t)
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", cfg.Database.Driver)
+	assert.Equal(t, "host=db.staging.internal port=6432 user=api password=s3cret dbname=users sslmode=verify-full", cfg.Database.GetDSN())
+	assert.Equal(t, 15*time.Minute, cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, 100, cfg.Database.MaxOpenConns, "unset values keep the file defaults")
+}
+
+func TestConfig_HTTPHealthChecks(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	setDefaults()
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`
+healthchecks:
+  http:
+    - name: notifications
+      url: "http://notifications:8080/health"
+      expectedstatus: [200, 204]
+      timeout: 2s
+      warnthreshold: 500ms
+      bodycontains: ok
+`)))
+
+	var cfg Config
+	require.NoError(t, viper.Unmarshal(&cfg))
+	require.Len(t, cfg.HealthChecks.HTTP, 1)
+	assert.Equal(t, HTTPCheckConfig{
+		Name:           "notifications",
+		URL:            "http://notifications:8080/health",
+		ExpectedStatus: []int{200, 204},
+		Timeout:        2 * time.Second,
+		WarnThreshold:  500 * time.Millisecond,
+		BodyContains:   "ok",
+	}, cfg.HealthChecks.HTTP[0])
+}
+
+func TestLoadConfig_RenamedBatchInsertTimeout(t *testing.T) {
+	loadTestConfig(t)
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.App.BatchInsertTimeout)
+
+	t.Setenv("APP_BATCHITEMTIMEOUT", "45s")
+	loadTestConfig(t)
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, cfg.App.BatchInsertTimeout, "the old key is still read")
+
+	t.Setenv("APP_BATCHINSERTTIMEOUT", "20s")
+	loadTestConfig(t)
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Second, cfg.App.BatchInsertTimeout, "the new key wins")
+}
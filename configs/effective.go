@@ -0,0 +1,147 @@
+package configs
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Redacted replaces secret values in Effective
+const Redacted = "[REDACTED]"
+
+// Sources of a configuration value, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// secretKeyParts mark a key as secret when its last segment contains one of them
+var secretKeyParts = []string{"secret", "password", "token", "key", "dsn", "credential"}
+
+// notSecretKeys are keys matched by secretKeyParts that hold no secret
+var notSecretKeys = map[string]bool{
+	"jwt.accesstokenduration":  true,
+	"jwt.refreshtokenduration": true,
+}
+
+// Setting is one effective configuration value and where it came from
+type Setting struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"` // "default", "file" or "env"
+}
+
+// Effective returns every key loaded by LoadConfig with its redacted value
+// and its source. Call it after LoadConfig.
+func Effective() map[string]Setting {
+	settings := make(map[string]Setting)
+	for _, key := range viper.AllKeys() {
+		settings[key] = Setting{Value: Redact(key, viper.Get(key)), Source: source(key)}
+	}
+	return settings
+}
+
+// source reports where viper took the value of key from
+func source(key string) string {
+	if _, ok := os.LookupEnv(strings.ToUpper(strings.ReplaceAll(key, ".", "_"))); ok {
+		return SourceEnv
+	}
+	if viper.InConfig(key) {
+		return SourceFile
+	}
+	return SourceDefault
+}
+
+// IsSecretKey reports whether the whole value of key is a secret
+func IsSecretKey(key string) bool {
+	if notSecretKeys[key] {
+		return false
+	}
+	name := key[strings.LastIndexByte(key, '.')+1:]
+	for _, part := range secretKeyParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns value safe to show to operators. Non-empty values of secret
+// keys become Redacted; empty ones stay empty so an unset secret is visible.
+// Other strings, including list elements, are redacted when they carry
+// secret parameters, and URLs lose their password.
+func Redact(key string, value interface{}) interface{} {
+	if IsSecretKey(key) {
+		if isEmpty(value) {
+			return value
+		}
+		return Redacted
+	}
+	return redactValue(value)
+}
+
+// redactValue removes embedded credentials from strings
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redactString(v)
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = redactString(s)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactValue(item)
+		}
+		return redacted
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			redacted[k] = Redact(k, item)
+		}
+		return redacted
+	case time.Duration:
+		return v.String()
+	default:
+		return value
+	}
+}
+
+// redactString hides strings with secret parameters, such as
+// "password=" in connection strings or "token=" in URL queries, and the
+// password of URLs
+func redactString(s string) string {
+	lower := strings.ToLower(s)
+	for _, part := range secretKeyParts {
+		if strings.Contains(lower, part+"=") {
+			return Redacted
+		}
+	}
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return s
+}
+
+// isEmpty reports whether value is nil, an empty string or an empty list
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -462,7 +462,7 @@ const docTemplate = `{
         },
         "/users/stats": {
             "get": {
-                "description": "Get statistics about users (total count, active count, etc.)",
+                "description": "Get statistics about users (total, active and inactive counts, counts per role and users created in the last 7 days)",
                 "consumes": [
                     "application/json"
                 ],
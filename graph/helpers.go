@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"Go-Lang-project-01/graph/model"
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Helper function to convert DB model to GraphQL model
+func toGraphQLUser(user *models.User) *model.User {
+	return &model.User{
+		ID:          fmt.Sprintf("%d", user.ID),
+		Name:        user.Name,
+		Email:       user.Email,
+		Role:        toGraphQLRole(user.Role),
+		Age:         toGraphQLAge(user.AgeAt(time.Now())),
+		DateOfBirth: user.DateOfBirth,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// toGraphQLAge returns nil for unknown (zero) ages
+func toGraphQLAge(age int) *int32 {
+	if age == 0 {
+		return nil
+	}
+	value := int32(age)
+	return &value
+}
+
+// setAge applies the optional age or date of birth of an input with the
+// same rules as the REST requests: not both, age within 1..MaxAge, and a
+// plausible date of birth. Setting the age clears the date of birth.
+func setAge(user *models.User, age *int32, dateOfBirth *time.Time) error {
+	switch {
+	case age != nil && dateOfBirth != nil:
+		return errors.New("age cannot be combined with dateOfBirth")
+	case age != nil:
+		if *age < 1 || *age > models.MaxAge {
+			return fmt.Errorf("age must be between 1 and %d", models.MaxAge)
+		}
+		user.Age = int(*age)
+		user.DateOfBirth = nil
+	case dateOfBirth != nil:
+		date := models.NewDate(*dateOfBirth)
+		if !models.ValidDateOfBirth(date.Time, time.Now()) {
+			return fmt.Errorf("dateOfBirth must be in the past and at most %d years ago", models.MaxAge)
+		}
+		user.DateOfBirth = date.TimePtr()
+	}
+	return nil
+}
+
+// toGraphQLRole converts a stored role to its GraphQL enum value (user -> USER)
+func toGraphQLRole(role models.Role) model.Role {
+	return model.Role(strings.ToUpper(role.String()))
+}
+
+// toModelRole converts a GraphQL role enum value to the stored role (USER -> user)
+func toModelRole(role model.Role) models.Role {
+	return models.Role(strings.ToLower(role.String()))
+}
+
+// toGraphQLAuditLog converts an audit log row to a GraphQL audit log entry
+func toGraphQLAuditLog(log *models.AuditLog) *model.AuditLogEntry {
+	entry := &model.AuditLogEntry{
+		ID:        fmt.Sprintf("%d", log.ID),
+		Action:    string(log.Action),
+		Resource:  string(log.Resource),
+		Success:   log.Success,
+		IPAddress: log.IPAddress,
+		CreatedAt: log.CreatedAt,
+	}
+	if log.ResourceID != nil {
+		resourceID := fmt.Sprintf("%d", *log.ResourceID)
+		entry.ResourceID = &resourceID
+	}
+	return entry
+}
+
+// unauthenticated returns an error clients can detect by extensions.code
+func unauthenticated(ctx context.Context) error {
+	return &gqlerror.Error{
+		Message:    "unauthenticated: a valid access token is required",
+		Path:       graphql.GetPath(ctx),
+		Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"},
+	}
+}
+
+// badUserInput returns err as an error clients can detect by extensions.code
+func badUserInput(ctx context.Context, err error) error {
+	return &gqlerror.Error{
+		Message:    err.Error(),
+		Path:       graphql.GetPath(ctx),
+		Extensions: map[string]interface{}{"code": "BAD_USER_INPUT"},
+	}
+}
+
+// claimsKey is the context key of the authenticated user's claims
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying the claims of the authenticated
+// user. The /query route sets them once the token and the user are checked,
+// with Role taken from the stored user rather than the token.
+func WithClaims(ctx context.Context, claims *auth.JWTClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// claimsFromContext returns the claims set by WithClaims
+func claimsFromContext(ctx context.Context) (*auth.JWTClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*auth.JWTClaims)
+	return claims, ok && claims != nil
+}
+
+// forbidden returns an error clients can detect by extensions.code
+func forbidden(ctx context.Context, message string) error {
+	return &gqlerror.Error{
+		Message:    "forbidden: " + message,
+		Path:       graphql.GetPath(ctx),
+		Extensions: map[string]interface{}{"code": "FORBIDDEN"},
+	}
+}
+
+// requireRole returns the claims of the authenticated user if their role is
+// at least role, mirroring RequireAdmin and RequireSuperAdmin on REST routes
+func requireRole(ctx context.Context, role models.Role) (*auth.JWTClaims, error) {
+	claims, ok := claimsFromContext(ctx)
+	if !ok {
+		return nil, unauthenticated(ctx)
+	}
+	if !claims.Role.AtLeast(role) {
+		return nil, forbidden(ctx, role.String()+" access required")
+	}
+	return claims, nil
+}
+
+// Get user ID from context (set by the /query route)
+func getUserIDFromContext(ctx context.Context) (uint, error) {
+	claims, ok := claimsFromContext(ctx)
+	if !ok {
+		return 0, errors.New("unauthorized: no user in context")
+	}
+	return claims.UserID, nil
+}
@@ -10,6 +10,16 @@ import (
 	"time"
 )
 
+type AuditLogEntry struct {
+	ID         string    `json:"id"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	ResourceID *string   `json:"resourceId,omitempty"`
+	Success    bool      `json:"success"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
 type AuthPayload struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
@@ -17,10 +27,12 @@ type AuthPayload struct {
 }
 
 type CreateUserInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     *Role  `json:"role,omitempty"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Password    string     `json:"password"`
+	Role        *Role      `json:"role,omitempty"`
+	Age         *int32     `json:"age,omitempty"`
+	DateOfBirth *time.Time `json:"dateOfBirth,omitempty"`
 }
 
 type LoginInput struct {
@@ -35,9 +47,11 @@ type Query struct {
 }
 
 type RegisterInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Password    string     `json:"password"`
+	Age         *int32     `json:"age,omitempty"`
+	DateOfBirth *time.Time `json:"dateOfBirth,omitempty"`
 }
 
 type RoleCount struct {
@@ -46,8 +60,10 @@ type RoleCount struct {
 }
 
 type UpdateUserInput struct {
-	Name  *string `json:"name,omitempty"`
-	Email *string `json:"email,omitempty"`
+	Name        *string    `json:"name,omitempty"`
+	Email       *string    `json:"email,omitempty"`
+	Age         *int32     `json:"age,omitempty"`
+	DateOfBirth *time.Time `json:"dateOfBirth,omitempty"`
 }
 
 type UpdateUserRoleInput struct {
@@ -55,12 +71,22 @@ type UpdateUserRoleInput struct {
 }
 
 type User struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Role      Role      `json:"role"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Role        Role       `json:"role"`
+	Age         *int32     `json:"age,omitempty"`
+	DateOfBirth *time.Time `json:"dateOfBirth,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+type UserPage struct {
+	Items      []*User `json:"items"`
+	Page       int32   `json:"page"`
+	Limit      int32   `json:"limit"`
+	Total      int32   `json:"total"`
+	TotalPages int32   `json:"totalPages"`
 }
 
 type UserStats struct {
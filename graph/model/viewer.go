@@ -0,0 +1,6 @@
+package model
+
+// Viewer is the root of the authenticated user's data
+type Viewer struct {
+	UserID uint
+}
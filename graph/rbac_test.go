@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newRBACServer returns a GraphQL server over a fresh database holding one
+// user per role. do runs a query as the given user, or anonymously for nil.
+func newRBACServer(t *testing.T) (do func(as *models.User, query string) graphQLResponse, users map[models.Role]*models.User, repo *repository.UserRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}))
+
+	repo = repository.NewUserRepository(db)
+	users = make(map[models.Role]*models.User)
+	for _, role := range []models.Role{models.RoleUser, models.RoleAdmin, models.RoleSuperAdmin} {
+		user := &models.User{Name: "RBAC " + role.String(), Email: role.String() + "@test.com", Age: 30, Role: role}
+		require.NoError(t, repo.Create(context.Background(), user))
+		users[role] = user
+	}
+
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: &Resolver{UserRepo: repo, UserService: services.NewUserService(repo)}}))
+	do = func(as *models.User, query string) graphQLResponse {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if as != nil {
+			req = req.WithContext(WithClaims(req.Context(), &auth.JWTClaims{UserID: uint(as.ID), Email: as.Email, Role: as.Role}))
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		var resp graphQLResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	return do, users, repo
+}
+
+// errorCode returns extensions.code of the only error of resp
+func errorCode(t *testing.T, resp graphQLResponse) interface{} {
+	t.Helper()
+	require.Len(t, resp.Errors, 1, "expected one error")
+	return resp.Errors[0].Extensions["code"]
+}
+
+func TestMutations_RegularUserIsForbidden(t *testing.T) {
+	do, users, repo := newRBACServer(t)
+	admin := users[models.RoleAdmin]
+
+	mutations := map[string]string{
+		"createUser":     `mutation { createUser(input: {name: "New", email: "new@test.com", password: "password123"}) { id } }`,
+		"updateUser":     fmt.Sprintf(`mutation { updateUser(id: "%d", input: {name: "Renamed"}) { id } }`, admin.ID),
+		"deleteUser":     fmt.Sprintf(`mutation { deleteUser(id: "%d") }`, admin.ID),
+		"updateUserRole": fmt.Sprintf(`mutation { updateUserRole(id: "%d", input: {role: USER}) { id } }`, admin.ID),
+	}
+	for name, mutation := range mutations {
+		t.Run(name, func(t *testing.T) {
+			resp := do(users[models.RoleUser], mutation)
+			assert.Equal(t, "FORBIDDEN", errorCode(t, resp))
+			assert.Equal(t, []interface{}{name}, resp.Errors[0].Path)
+
+			resp = do(nil, mutation)
+			assert.Equal(t, "UNAUTHENTICATED", errorCode(t, resp))
+		})
+	}
+
+	stored, err := repo.GetByID(context.Background(), uint(admin.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "RBAC admin", stored.Name, "nothing is changed")
+	assert.Equal(t, models.RoleAdmin, stored.Role)
+	created, err := repo.GetByEmail(context.Background(), "new@test.com")
+	require.NoError(t, err)
+	assert.Nil(t, created, "nothing is created")
+}
+
+func TestMutations_FollowRESTPermissionMatrix(t *testing.T) {
+	do, users, _ := newRBACServer(t)
+	admin, superadmin := users[models.RoleAdmin], users[models.RoleSuperAdmin]
+	target := users[models.RoleUser]
+
+	resp := do(admin, `mutation { createUser(input: {name: "Made", email: "made@test.com", password: "password123", role: ADMIN}) { role } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"createUser": {"role": "ADMIN"}}`, string(resp.Data))
+
+	resp = do(admin, `mutation { createUser(input: {name: "Escalated", email: "escalated@test.com", password: "password123", role: SUPERADMIN}) { id } }`)
+	assert.Equal(t, "FORBIDDEN", errorCode(t, resp), "admins cannot create superadmins")
+
+	resp = do(admin, fmt.Sprintf(`mutation { updateUser(id: "%d", input: {name: "Renamed"}) { name } }`, target.ID))
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"updateUser": {"name": "Renamed"}}`, string(resp.Data))
+
+	resp = do(admin, fmt.Sprintf(`mutation { updateUserRole(id: "%d", input: {role: ADMIN}) { role } }`, target.ID))
+	assert.Equal(t, "FORBIDDEN", errorCode(t, resp), "only superadmins change roles")
+
+	resp = do(superadmin, fmt.Sprintf(`mutation { updateUserRole(id: "%d", input: {role: ADMIN}) { role } }`, target.ID))
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"updateUserRole": {"role": "ADMIN"}}`, string(resp.Data))
+
+	resp = do(admin, fmt.Sprintf(`mutation { deleteUser(id: "%d") }`, target.ID))
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"deleteUser": true}`, string(resp.Data))
+}
+
+func TestQueries_OpenToEveryRole(t *testing.T) {
+	do, users, _ := newRBACServer(t)
+
+	resp := do(users[models.RoleUser], `{ users { items { email } } }`)
+	require.Empty(t, resp.Errors)
+	assert.Contains(t, string(resp.Data), "superadmin@test.com")
+
+	resp = do(nil, `{ users { items { email } } }`)
+	assert.NotEmpty(t, resp.Errors, "queries still need a token")
+}
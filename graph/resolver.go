@@ -11,7 +11,9 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	UserService *services.UserService
-	UserRepo    *repository.UserRepository
-	JWTManager  *auth.JWTManager
+	UserService   *services.UserService
+	UserRepo      *repository.UserRepository
+	JWTManager    *auth.JWTManager
+	RefreshTokens *services.RefreshTokenService
+	AuditService  *services.AuditService
 }
@@ -7,6 +7,7 @@ package graph
 import (
 	"Go-Lang-project-01/graph/model"
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
 	"context"
 	"errors"
 	"fmt"
@@ -15,27 +16,6 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Helper function to convert DB model to GraphQL model
-func toGraphQLUser(user *models.User) *model.User {
-	return &model.User{
-		ID:        fmt.Sprintf("%d", user.ID),
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      model.Role(user.Role),
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}
-}
-
-// Get user ID from context (set by auth middleware)
-func getUserIDFromContext(ctx context.Context) (uint, error) {
-	userID, ok := ctx.Value("userID").(uint)
-	if !ok {
-		return 0, errors.New("unauthorized: no user in context")
-	}
-	return userID, nil
-}
-
 // Register is the resolver for the register field.
 func (r *mutationResolver) Register(ctx context.Context, input model.RegisterInput) (*model.AuthPayload, error) {
 	// Hash password
@@ -49,7 +29,10 @@ func (r *mutationResolver) Register(ctx context.Context, input model.RegisterInp
 		Name:     input.Name,
 		Email:    input.Email,
 		Password: string(hashedPassword),
-		Role:     string(models.RoleUser), // Default role
+		Role:     models.RoleUser, // Default role
+	}
+	if err := setAge(user, input.Age, input.DateOfBirth); err != nil {
+		return nil, err
 	}
 
 	if err := r.UserRepo.Create(ctx, user); err != nil {
@@ -57,12 +40,12 @@ func (r *mutationResolver) Register(ctx context.Context, input model.RegisterInp
 	}
 
 	// Generate tokens
-	accessToken, err := r.JWTManager.GenerateAccessToken(user.ID, user.Email, user.Role)
+	accessToken, err := r.JWTManager.GenerateAccessToken(uint(user.ID), user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := r.JWTManager.GenerateRefreshToken(user.ID, user.Email, user.Role)
+	refreshToken, err := r.RefreshTokens.Issue(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -88,12 +71,12 @@ func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*
 	}
 
 	// Generate tokens
-	accessToken, err := r.JWTManager.GenerateAccessToken(user.ID, user.Email, user.Role)
+	accessToken, err := r.JWTManager.GenerateAccessToken(uint(user.ID), user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := r.JWTManager.GenerateRefreshToken(user.ID, user.Email, user.Role)
+	refreshToken, err := r.RefreshTokens.Issue(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -125,6 +108,9 @@ func (r *mutationResolver) UpdateMe(ctx context.Context, input model.UpdateUserI
 	if input.Email != nil {
 		user.Email = *input.Email
 	}
+	if err := setAge(user, input.Age, input.DateOfBirth); err != nil {
+		return nil, err
+	}
 
 	if err := r.UserRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
@@ -135,19 +121,18 @@ func (r *mutationResolver) UpdateMe(ctx context.Context, input model.UpdateUserI
 
 // CreateUser is the resolver for the createUser field.
 func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
-	// Check if user is admin (should be done by middleware)
-	userID, err := getUserIDFromContext(ctx)
+	claims, err := requireRole(ctx, models.RoleAdmin)
 	if err != nil {
 		return nil, err
 	}
 
-	currentUser, err := r.UserRepo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, errors.New("unauthorized")
+	// Like POST /users, nobody creates a user ranking above themselves
+	role := models.RoleUser
+	if input.Role != nil {
+		role = toModelRole(*input.Role)
 	}
-
-	if currentUser.Role != string(models.RoleAdmin) && currentUser.Role != string(models.RoleSuperAdmin) {
-		return nil, errors.New("forbidden: admin access required")
+	if !claims.Role.AtLeast(role) {
+		return nil, forbidden(ctx, fmt.Sprintf("%s cannot create %s", claims.Role, role))
 	}
 
 	// Hash password
@@ -157,10 +142,6 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUse
 	}
 
 	// Create user
-	role := string(models.RoleUser)
-	if input.Role != nil {
-		role = string(*input.Role)
-	}
 
 	user := &models.User{
 		Name:     input.Name,
@@ -168,6 +149,9 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUse
 		Password: string(hashedPassword),
 		Role:     role,
 	}
+	if err := setAge(user, input.Age, input.DateOfBirth); err != nil {
+		return nil, err
+	}
 
 	if err := r.UserRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -179,20 +163,10 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUse
 // UpdateUser is the resolver for the updateUser field.
 func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUserInput) (*model.User, error) {
 	// Check admin access
-	userID, err := getUserIDFromContext(ctx)
-	if err != nil {
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
 		return nil, err
 	}
 
-	currentUser, err := r.UserRepo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, errors.New("unauthorized")
-	}
-
-	if currentUser.Role != string(models.RoleAdmin) && currentUser.Role != string(models.RoleSuperAdmin) {
-		return nil, errors.New("forbidden: admin access required")
-	}
-
 	// Parse target user ID
 	targetID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
@@ -212,6 +186,9 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input mode
 	if input.Email != nil {
 		user.Email = *input.Email
 	}
+	if err := setAge(user, input.Age, input.DateOfBirth); err != nil {
+		return nil, err
+	}
 
 	if err := r.UserRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
@@ -223,20 +200,10 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input mode
 // DeleteUser is the resolver for the deleteUser field.
 func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, error) {
 	// Check admin access
-	userID, err := getUserIDFromContext(ctx)
-	if err != nil {
+	if _, err := requireRole(ctx, models.RoleAdmin); err != nil {
 		return false, err
 	}
 
-	currentUser, err := r.UserRepo.GetByID(ctx, userID)
-	if err != nil {
-		return false, errors.New("unauthorized")
-	}
-
-	if currentUser.Role != string(models.RoleAdmin) && currentUser.Role != string(models.RoleSuperAdmin) {
-		return false, errors.New("forbidden: admin access required")
-	}
-
 	// Parse target user ID
 	targetID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
@@ -254,20 +221,10 @@ func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, err
 // UpdateUserRole is the resolver for the updateUserRole field.
 func (r *mutationResolver) UpdateUserRole(ctx context.Context, id string, input model.UpdateUserRoleInput) (*model.User, error) {
 	// Check superadmin access
-	userID, err := getUserIDFromContext(ctx)
-	if err != nil {
+	if _, err := requireRole(ctx, models.RoleSuperAdmin); err != nil {
 		return nil, err
 	}
 
-	currentUser, err := r.UserRepo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, errors.New("unauthorized")
-	}
-
-	if currentUser.Role != string(models.RoleSuperAdmin) {
-		return nil, errors.New("forbidden: superadmin access required")
-	}
-
 	// Parse target user ID
 	targetID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
@@ -281,7 +238,7 @@ func (r *mutationResolver) UpdateUserRole(ctx context.Context, id string, input
 	}
 
 	// Update role
-	user.Role = string(input.Role)
+	user.Role = toModelRole(input.Role)
 
 	if err := r.UserRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to update user role: %w", err)
@@ -291,44 +248,51 @@ func (r *mutationResolver) UpdateUserRole(ctx context.Context, id string, input
 }
 
 // Users is the resolver for the users field.
-func (r *queryResolver) Users(ctx context.Context, limit *int32, offset *int32) ([]*model.User, error) {
+func (r *queryResolver) Users(ctx context.Context, page *int32, limit *int32, search *string, sort *string, order *string) (*model.UserPage, error) {
 	// Check authentication
 	_, err := getUserIDFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get all users
-	dbUsers, err := r.UserRepo.GetAll(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	// The service applies the defaults and the limit cap of GET /users
+	query := models.PaginationQuery{}
+	if page != nil {
+		query.Page = int(*page)
 	}
-
-	// Apply pagination if provided
-	start := 0
-	end := len(dbUsers)
-
-	if offset != nil {
-		start = int(*offset)
-		if start > len(dbUsers) {
-			start = len(dbUsers)
-		}
+	if limit != nil {
+		query.Limit = int(*limit)
+	}
+	if search != nil {
+		query.Search = *search
+	}
+	if sort != nil {
+		query.Sort = *sort
+	}
+	if order != nil {
+		query.Order = *order
 	}
 
-	if limit != nil {
-		end = start + int(*limit)
-		if end > len(dbUsers) {
-			end = len(dbUsers)
-		}
+	dbUsers, meta, err := r.UserService.GetAllUsersPaginated(ctx, query)
+	if errors.Is(err, repository.ErrInvalidSort) {
+		return nil, badUserInput(ctx, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
 	}
 
-	// Convert to GraphQL models
-	users := make([]*model.User, 0, end-start)
-	for i := start; i < end; i++ {
-		users = append(users, toGraphQLUser(dbUsers[i]))
+	users := make([]*model.User, len(dbUsers))
+	for i, user := range dbUsers {
+		users[i] = toGraphQLUser(user)
 	}
 
-	return users, nil
+	return &model.UserPage{
+		Items:      users,
+		Page:       int32(meta.Page),
+		Limit:      int32(meta.Limit),
+		Total:      int32(meta.Total),
+		TotalPages: int32(meta.TotalPages),
+	}, nil
 }
 
 // User is the resolver for the user field.
@@ -384,7 +348,7 @@ func (r *queryResolver) UserStats(ctx context.Context) (*model.UserStats, error)
 	}
 
 	// Count by role
-	roleCounts := make(map[string]int)
+	roleCounts := make(map[models.Role]int)
 	for _, user := range users {
 		roleCounts[user.Role]++
 	}
@@ -393,7 +357,7 @@ func (r *queryResolver) UserStats(ctx context.Context) (*model.UserStats, error)
 	roleCountsGQL := make([]*model.RoleCount, 0, len(roleCounts))
 	for role, count := range roleCounts {
 		roleCountsGQL = append(roleCountsGQL, &model.RoleCount{
-			Role:  model.Role(role),
+			Role:  toGraphQLRole(role),
 			Count: int32(count),
 		})
 	}
@@ -415,11 +379,53 @@ func (r *queryResolver) UserStats(ctx context.Context) (*model.UserStats, error)
 	}, nil
 }
 
+// Viewer is the resolver for the viewer field.
+func (r *queryResolver) Viewer(ctx context.Context) (*model.Viewer, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticated(ctx)
+	}
+	return &model.Viewer{UserID: userID}, nil
+}
+
+// Me is the resolver for the me field.
+func (r *viewerResolver) Me(ctx context.Context, obj *model.Viewer) (*model.User, error) {
+	user, err := r.UserRepo.GetByID(ctx, obj.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return toGraphQLUser(user), nil
+}
+
+// AuditLogs is the resolver for the auditLogs field.
+func (r *viewerResolver) AuditLogs(ctx context.Context, obj *model.Viewer, limit *int32) ([]*model.AuditLogEntry, error) {
+	// Same bounds as GET /audit-logs/me
+	n := 50
+	if limit != nil && *limit > 0 && *limit <= 100 {
+		n = int(*limit)
+	}
+
+	logs, err := r.AuditService.GetRecentByUser(obj.UserID, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	entries := make([]*model.AuditLogEntry, len(logs))
+	for i := range logs {
+		entries[i] = toGraphQLAuditLog(&logs[i])
+	}
+	return entries, nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Viewer returns ViewerResolver implementation.
+func (r *Resolver) Viewer() ViewerResolver { return &viewerResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type viewerResolver struct{ *Resolver }
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userPage struct {
+	Items []struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"items"`
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
+}
+
+// queryUsers runs a users query as an admin of a server holding n more
+// users, named "Member i", created a minute apart
+func queryUsers(t *testing.T, n int) func(args string) (userPage, graphQLResponse) {
+	t.Helper()
+	do, users, repo := newRBACServer(t)
+	start := time.Now().Add(time.Minute) // After the users of newRBACServer
+	for i := range n {
+		require.NoError(t, repo.Create(context.Background(), &models.User{
+			Name:      fmt.Sprintf("Member %d", i),
+			Email:     fmt.Sprintf("member%d@test.com", i),
+			Age:       30,
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	return func(args string) (userPage, graphQLResponse) {
+		resp := do(users[models.RoleAdmin], fmt.Sprintf(`{ users%s { items { name email } page limit total totalPages } }`, args))
+		var data struct {
+			Users userPage `json:"users"`
+		}
+		if len(resp.Errors) == 0 {
+			require.NoError(t, json.Unmarshal(resp.Data, &data))
+		}
+		return data.Users, resp
+	}
+}
+
+func TestUsers_DefaultsMatchREST(t *testing.T) {
+	query := queryUsers(t, 12)
+
+	page, resp := query("")
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 10, page.Limit)
+	assert.Equal(t, 15, page.Total, "the 12 members and one user per role")
+	assert.Equal(t, 2, page.TotalPages)
+	require.Len(t, page.Items, 10)
+	assert.Equal(t, "member11@test.com", page.Items[0].Email, "newest first")
+
+	page, resp = query(`(page: 2, sort: "name", order: "asc")`)
+	require.Empty(t, resp.Errors)
+	require.Len(t, page.Items, 5)
+	assert.Equal(t, "RBAC admin", page.Items[2].Name)
+}
+
+func TestUsers_Search(t *testing.T) {
+	query := queryUsers(t, 12)
+
+	page, resp := query(`(search: "MEMBER1")`)
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, 3, page.Total, "member1, member10 and member11, case-insensitively")
+	for _, item := range page.Items {
+		assert.Contains(t, item.Email, "member1")
+	}
+}
+
+func TestUsers_LimitIsCappedAt100(t *testing.T) {
+	query := queryUsers(t, 110)
+
+	page, resp := query(`(limit: 500)`)
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, 100, page.Limit)
+	assert.Len(t, page.Items, 100)
+	assert.Equal(t, 113, page.Total)
+	assert.Equal(t, 2, page.TotalPages)
+}
+
+func TestUsers_RejectsUnknownSort(t *testing.T) {
+	query := queryUsers(t, 0)
+
+	_, resp := query(`(sort: "password")`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "BAD_USER_INPUT", resp.Errors[0].Extensions["code"])
+}
@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const viewerQuery = `{ viewer { me { id email role } auditLogs(limit: 10) { action success } } }`
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// newViewerServer returns a GraphQL server over a fresh database holding one
+// user with a login in the audit log. userID, when non-zero, is put in the
+// request context like the /query route does for a valid token.
+func newViewerServer(t *testing.T) (serve func(userID uint) graphQLResponse, user *models.User) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.AuditLog{}))
+
+	userRepo := repository.NewUserRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	user = &models.User{Name: "Viewer", Email: "viewer@test.com", Age: 30, Role: models.RoleAdmin}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	require.NoError(t, auditRepo.Create(&models.AuditLog{UserID: &user.ID, Action: models.AuditActionLogin, Success: true}))
+
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: &Resolver{
+		UserRepo:     userRepo,
+		AuditService: services.NewAuditService(auditRepo),
+	}}))
+
+	serve = func(userID uint) graphQLResponse {
+		body, _ := json.Marshal(map[string]string{"query": viewerQuery})
+		req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != 0 {
+			req = req.WithContext(WithClaims(req.Context(), &auth.JWTClaims{UserID: userID, Role: user.Role}))
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		var resp graphQLResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	return serve, user
+}
+
+func TestViewer_ResolvesAuthenticatedUser(t *testing.T) {
+	serve, user := newViewerServer(t)
+
+	resp := serve(uint(user.ID))
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"viewer": {
+		"me": {"id": "1", "email": "viewer@test.com", "role": "ADMIN"},
+		"auditLogs": [{"action": "login", "success": true}]
+	}}`, string(resp.Data))
+}
+
+func TestViewer_UnauthenticatedIsTypedError(t *testing.T) {
+	serve, _ := newViewerServer(t)
+
+	resp := serve(0)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "UNAUTHENTICATED", resp.Errors[0].Extensions["code"])
+	assert.Equal(t, []interface{}{"viewer"}, resp.Errors[0].Path)
+	assert.JSONEq(t, `null`, string(resp.Data))
+}
@@ -3,9 +3,16 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"Go-Lang-project-01/internal/models"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -15,14 +22,31 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 	// ErrExpiredToken is returned when a token's expiration time has passed.
 	ErrExpiredToken = errors.New("token has expired")
+	// ErrEmptySecret is returned by Probe when the signing secret is empty or whitespace.
+	ErrEmptySecret = errors.New("JWT secret is empty")
+	// ErrWrongTokenType is returned when a refresh token is used as an access token or vice versa.
+	ErrWrongTokenType = errors.New("wrong token type")
+)
+
+// TokenType tells access tokens and refresh tokens apart
+type TokenType string
+
+// Token types set in the token_type claim
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
 )
 
+// probeEmail identifies the throwaway token signed by Probe
+const probeEmail = "probe@health.local"
+
 // JWTClaims represents the custom claims embedded in JWT tokens.
 // It extends the standard JWT registered claims with user-specific information.
 type JWTClaims struct {
-	UserID uint   `json:"user_id"` // User's unique identifier
-	Email  string `json:"email"`   // User's email address
-	Role   string `json:"role"`    // User's role for RBAC (user, admin, superadmin)
+	UserID uint        `json:"user_id"` // User's unique identifier
+	Email  string      `json:"email"`   // User's email address
+	Role   models.Role `json:"role"`    // User's role for RBAC (user, admin, superadmin)
+	Type   TokenType   `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
@@ -32,6 +56,30 @@ type JWTManager struct {
 	secretKey            string        // Secret key for signing tokens
 	accessTokenDuration  time.Duration // Lifetime of access tokens
 	refreshTokenDuration time.Duration // Lifetime of refresh tokens
+
+	keys     []signingKey // Validation keys; keys[0] is secretKey
+	issuer   string       // Set on and required in every token when not empty
+	audience string       // Set on and required in every token when not empty
+}
+
+// JWTOptions configures the claims and keys of NewJWTManagerWithOptions
+type JWTOptions struct {
+	Issuer             string   // "iss" claim; tokens from another issuer are rejected
+	Audience           string   // "aud" claim; tokens for another audience are rejected
+	PreviousSecretKeys []string // Validate tokens signed before a key rotation; never used to sign
+}
+
+// signingKey is an HMAC secret with the ID sent in the "kid" header
+type signingKey struct {
+	id     string
+	secret []byte
+}
+
+// newSigningKey derives the key ID from the secret, so every instance sharing
+// a secret agrees on it without configuration
+func newSigningKey(secret string) signingKey {
+	sum := sha256.Sum256([]byte(secret))
+	return signingKey{id: hex.EncodeToString(sum[:8]), secret: []byte(secret)}
 }
 
 // NewJWTManager creates a new JWT manager with the specified configuration.
@@ -39,83 +87,218 @@ type JWTManager struct {
 // Access tokens are typically short-lived (minutes to hours).
 // Refresh tokens are long-lived (days to weeks).
 func NewJWTManager(secretKey string, accessDuration, refreshDuration time.Duration) *JWTManager {
+	return NewJWTManagerWithOptions(secretKey, accessDuration, refreshDuration, JWTOptions{})
+}
+
+// NewJWTManagerWithOptions creates a JWT manager that also sets and verifies
+// the issuer and audience, and accepts tokens signed with previous keys
+func NewJWTManagerWithOptions(secretKey string, accessDuration, refreshDuration time.Duration, opts JWTOptions) *JWTManager {
+	keys := []signingKey{newSigningKey(secretKey)}
+	for _, previous := range opts.PreviousSecretKeys {
+		if previous != "" && previous != secretKey {
+			keys = append(keys, newSigningKey(previous))
+		}
+	}
 	return &JWTManager{
 		secretKey:            secretKey,
 		accessTokenDuration:  accessDuration,
 		refreshTokenDuration: refreshDuration,
+		keys:                 keys,
+		issuer:               opts.Issuer,
+		audience:             opts.Audience,
 	}
 }
 
+// AccessTokenTTL returns the lifetime of the access tokens the manager issues
+func (m *JWTManager) AccessTokenTTL() time.Duration {
+	return m.accessTokenDuration
+}
+
 // GenerateAccessToken generates a new JWT access token for the given user.
 // Access tokens are short-lived and used for API authentication.
 // Returns the signed token string or an error if generation fails.
-func (m *JWTManager) GenerateAccessToken(userID uint, email, role string) (string, error) {
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.accessTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+func (m *JWTManager) GenerateAccessToken(userID uint, email string, role models.Role) (string, error) {
+	claims := &JWTClaims{
+		UserID:           userID,
+		Email:            email,
+		Role:             role,
+		Type:             TokenTypeAccess,
+		RegisteredClaims: m.registeredClaims("", m.accessTokenDuration),
 	}
+	return m.sign(claims)
+}
+
+// registeredClaims returns the standard claims of a token with the given ID
+// that lives for ttl
+func (m *JWTManager) registeredClaims(id string, ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ID:        id,
+		Issuer:    m.issuer,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	return claims
+}
 
+// sign signs claims with the primary key and names it in the "kid" header
+func (m *JWTManager) sign(claims *JWTClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	token.Header["kid"] = m.keys[0].id
+	return token.SignedString(m.keys[0].secret)
 }
 
 // GenerateRefreshToken generates a new refresh token
-func (m *JWTManager) GenerateRefreshToken(userID uint, email, role string) (string, error) {
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.refreshTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+func (m *JWTManager) GenerateRefreshToken(userID uint, email string, role models.Role) (string, error) {
+	token, _, err := m.NewRefreshToken(userID, email, role)
+	return token, err
+}
+
+// NewRefreshToken generates a new refresh token with a random ID (jti) and
+// returns it with its claims, so the caller can record the ID for rotation
+func (m *JWTManager) NewRefreshToken(userID uint, email string, role models.Role) (string, *JWTClaims, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	claims := &JWTClaims{
+		UserID:           userID,
+		Email:            email,
+		Role:             role,
+		Type:             TokenTypeRefresh,
+		RegisteredClaims: m.registeredClaims(jti, m.refreshTokenDuration),
+	}
+
+	token, err := m.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// newTokenID returns a random 128-bit token ID in hex
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims. The key named
+// by the "kid" header is tried first; tokens without a known kid, e.g.
+// those issued before kids were added, are tried against every key.
 func (m *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&JWTClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, ErrInvalidToken
-			}
-			return []byte(m.secretKey), nil
-		},
-	)
+	parser := jwt.NewParser(m.parserOptions()...)
+
+	var err error
+	for _, key := range m.candidateKeys(parser, tokenString) {
+		claims := &JWTClaims{}
+		var token *jwt.Token
+		token, err = parser.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+			return key.secret, nil
+		})
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			continue // Signed with another key
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !token.Valid {
+			return nil, ErrInvalidToken
+		}
+
+		// Check if token is expired
+		if claims.ExpiresAt.Before(time.Now()) {
+			return nil, ErrExpiredToken
+		}
+		return claims, nil
+	}
+	return nil, err
+}
+
+// ValidateAccessToken validates an access token; refresh tokens and tokens
+// without a type fail with ErrWrongTokenType
+func (m *JWTManager) ValidateAccessToken(tokenString string) (*JWTClaims, error) {
+	return m.validateType(tokenString, TokenTypeAccess)
+}
 
+// ValidateRefreshToken validates a refresh token; access tokens and tokens
+// without a type fail with ErrWrongTokenType
+func (m *JWTManager) ValidateRefreshToken(tokenString string) (*JWTClaims, error) {
+	return m.validateType(tokenString, TokenTypeRefresh)
+}
+
+// validateType validates a token and checks that it is of type want
+func (m *JWTManager) validateType(tokenString string, want TokenType) (*JWTClaims, error) {
+	claims, err := m.ValidateToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
+	if claims.Type != want {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
 
-	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
-		return nil, ErrInvalidToken
+// parserOptions restricts tokens to HMAC-SHA256 and to the configured
+// issuer and audience
+func (m *JWTManager) parserOptions() []jwt.ParserOption {
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
 	}
+	if m.audience != "" {
+		opts = append(opts, jwt.WithAudience(m.audience))
+	}
+	return opts
+}
 
-	// Check if token is expired
-	if claims.ExpiresAt.Before(time.Now()) {
-		return nil, ErrExpiredToken
+// candidateKeys returns the keys to validate tokenString with: the key its
+// "kid" header names, or every key if it names none
+func (m *JWTManager) candidateKeys(parser *jwt.Parser, tokenString string) []signingKey {
+	if token, _, err := parser.ParseUnverified(tokenString, &JWTClaims{}); err == nil {
+		if kid, ok := token.Header["kid"].(string); ok {
+			for _, key := range m.keys {
+				if key.id == kid {
+					return []signingKey{key}
+				}
+			}
+		}
 	}
+	return m.keys
+}
 
-	return claims, nil
+// Probe signs and validates a throwaway access token, proving the manager
+// accepts the tokens it issues. It fails on an empty secret and on access
+// token durations too short for a token to be valid.
+func (m *JWTManager) Probe() error {
+	if strings.TrimSpace(m.secretKey) == "" {
+		return ErrEmptySecret
+	}
+
+	token, err := m.GenerateAccessToken(0, probeEmail, models.RoleUser)
+	if err != nil {
+		return fmt.Errorf("failed to sign probe token: %w", err)
+	}
+	claims, err := m.ValidateAccessToken(token)
+	if err != nil {
+		return fmt.Errorf("failed to validate probe token: %w", err)
+	}
+	if claims.Email != probeEmail {
+		return ErrInvalidToken
+	}
+	return nil
 }
 
 // RefreshAccessToken generates a new access token from a valid refresh token
 func (m *JWTManager) RefreshAccessToken(refreshToken string) (string, error) {
-	claims, err := m.ValidateToken(refreshToken)
+	claims, err := m.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return "", err
 	}
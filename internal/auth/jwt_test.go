@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestManager returns a manager for issuer "api" and audience "clients"
+func newTestManager(secret string, previous ...string) *JWTManager {
+	return NewJWTManagerWithOptions(secret, time.Minute, time.Hour, JWTOptions{
+		Issuer:             "api",
+		Audience:           "clients",
+		PreviousSecretKeys: previous,
+	})
+}
+
+func TestJWTManager_SetsIssuerAudienceAndKeyID(t *testing.T) {
+	manager := newTestManager("current-secret")
+
+	token, err := manager.GenerateAccessToken(7, "user@test.com", models.RoleAdmin)
+	require.NoError(t, err)
+	claims, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.Equal(t, "api", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"clients"}, claims.Audience)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, newSigningKey("current-secret").id, parsed.Header["kid"])
+
+	refresh, _, err := manager.NewRefreshToken(7, "user@test.com", models.RoleAdmin)
+	require.NoError(t, err)
+	claims, err = manager.ValidateToken(refresh)
+	require.NoError(t, err)
+	assert.Equal(t, "api", claims.Issuer)
+}
+
+func TestJWTManager_KeyRotation(t *testing.T) {
+	old := newTestManager("old-secret")
+	oldToken, err := old.GenerateAccessToken(1, "user@test.com", models.RoleUser)
+	require.NoError(t, err)
+
+	rotated := newTestManager("new-secret", "old-secret")
+	claims, err := rotated.ValidateToken(oldToken)
+	require.NoError(t, err, "a token signed with the previous key still validates")
+	assert.Equal(t, uint(1), claims.UserID)
+
+	newToken, err := rotated.GenerateAccessToken(1, "user@test.com", models.RoleUser)
+	require.NoError(t, err)
+	_, err = old.ValidateToken(newToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid, "new tokens are signed with the new key only")
+
+	_, err = newTestManager("new-secret").ValidateToken(oldToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid, "dropping the previous key ends its tokens")
+}
+
+func TestJWTManager_TokensWithoutKeyIDTryEveryKey(t *testing.T) {
+	claims := &JWTClaims{UserID: 3, RegisteredClaims: newTestManager("x").registeredClaims("", time.Minute)}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("old-secret"))
+	require.NoError(t, err)
+
+	validated, err := newTestManager("new-secret", "old-secret").ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), validated.UserID)
+}
+
+func TestJWTManager_RejectsOtherIssuersAndAudiences(t *testing.T) {
+	sign := func(claims jwt.RegisteredClaims) string {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &JWTClaims{UserID: 1, RegisteredClaims: claims}).
+			SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return token
+	}
+	manager := newTestManager("secret")
+
+	tests := []struct {
+		name   string
+		claims jwt.RegisteredClaims
+		want   error
+	}{
+		{"wrong audience", jwt.RegisteredClaims{Issuer: "api", Audience: jwt.ClaimStrings{"staging-clients"}}, jwt.ErrTokenInvalidAudience},
+		{"missing audience", jwt.RegisteredClaims{Issuer: "api"}, jwt.ErrTokenRequiredClaimMissing},
+		{"wrong issuer", jwt.RegisteredClaims{Issuer: "staging", Audience: jwt.ClaimStrings{"clients"}}, jwt.ErrTokenInvalidIssuer},
+		{"missing issuer", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"clients"}}, jwt.ErrTokenRequiredClaimMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.ValidateToken(sign(tt.claims))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	_, err := manager.ValidateToken(sign(jwt.RegisteredClaims{Issuer: "api", Audience: jwt.ClaimStrings{"clients"}}))
+	assert.NoError(t, err)
+}
+
+func TestJWTManager_RejectsOtherSigningMethods(t *testing.T) {
+	claims := &JWTClaims{UserID: 1, RegisteredClaims: newTestManager("secret").registeredClaims("", time.Minute)}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = newTestManager("secret").ValidateToken(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+}
+
+func TestJWTManager_TokenTypes(t *testing.T) {
+	manager := newTestManager("secret")
+
+	access, err := manager.GenerateAccessToken(1, "user@test.com", models.RoleUser)
+	require.NoError(t, err)
+	refresh, _, err := manager.NewRefreshToken(1, "user@test.com", models.RoleUser)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateAccessToken(access)
+	require.NoError(t, err)
+	assert.Equal(t, TokenTypeAccess, claims.Type)
+	claims, err = manager.ValidateRefreshToken(refresh)
+	require.NoError(t, err)
+	assert.Equal(t, TokenTypeRefresh, claims.Type)
+
+	_, err = manager.ValidateAccessToken(refresh)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+	_, err = manager.ValidateRefreshToken(access)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+	_, err = manager.RefreshAccessToken(access)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+}
+
+func TestJWTManager_TokensWithoutTypeAreRejected(t *testing.T) {
+	manager := newTestManager("secret")
+	claims := &JWTClaims{UserID: 1, RegisteredClaims: manager.registeredClaims("", time.Minute)}
+	token, err := manager.sign(claims)
+	require.NoError(t, err)
+
+	_, err = manager.ValidateAccessToken(token)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+	_, err = manager.ValidateRefreshToken(token)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+}
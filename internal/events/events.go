@@ -0,0 +1,110 @@
+// Package events defines the domain events emitted by handlers and the
+// publishers that deliver them to downstream consumers (WebSocket clients,
+// logs, ...), so handlers don't have to know who is listening.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// Type identifies a logical event
+type Type string
+
+const (
+	UserCreated     Type = "user.created"
+	UserUpdated     Type = "user.updated"
+	UserDeleted     Type = "user.deleted"
+	UserRoleChanged Type = "user.role.changed"
+	UserRegistered  Type = "user.registered"
+	UserLoggedIn    Type = "user.logged_in"
+	ProfileUpdated  Type = "profile.updated"
+	PasswordChanged Type = "password.changed"
+	SystemAlert     Type = "system.alert"
+)
+
+// Event is a domain event. ActorID is the user who caused it (0 for the system),
+// TargetID the user it is about.
+type Event struct {
+	Type       Type                   `json:"type"`
+	ActorID    uint                   `json:"actor_id"`
+	TargetID   uint                   `json:"target_id"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Publisher delivers events to a consumer
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Nop discards every event
+type Nop struct{}
+
+// Publish does nothing
+func (Nop) Publish(context.Context, Event) error { return nil }
+
+// Composite fans events out to several publishers. Every publisher is called
+// even if an earlier one fails; failures are joined into the returned error.
+type Composite struct {
+	publishers []Publisher
+}
+
+// NewComposite creates a fan-out publisher
+func NewComposite(publishers ...Publisher) *Composite {
+	return &Composite{publishers: publishers}
+}
+
+// Publish stamps OccurredAt if unset and sends the event to every publisher
+func (c *Composite) Publish(ctx context.Context, event Event) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	var errs []error
+	for _, p := range c.publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%T: %w", p, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogPublisher writes every event to the application log
+type LogPublisher struct{}
+
+// Publish logs the event
+func (LogPublisher) Publish(_ context.Context, event Event) error {
+	logger.Info("Event published",
+		"type", event.Type,
+		"actor_id", event.ActorID,
+		"target_id", event.TargetID,
+	)
+	return nil
+}
+
+// Recorder keeps published events in memory, e.g. to assert on them in tests
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Publish records the event
+func (r *Recorder) Publish(_ context.Context, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// Events returns a copy of the recorded events
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPublisher rejects every event
+type failingPublisher struct {
+	err error
+}
+
+func (p failingPublisher) Publish(context.Context, Event) error {
+	return p.err
+}
+
+func TestCompositeFansOutToAllPublishers(t *testing.T) {
+	first, second := &Recorder{}, &Recorder{}
+	composite := NewComposite(first, second)
+
+	err := composite.Publish(context.Background(), Event{Type: UserCreated, ActorID: 1, TargetID: 2})
+	require.NoError(t, err)
+
+	for _, r := range []*Recorder{first, second} {
+		published := r.Events()
+		require.Len(t, published, 1)
+		assert.Equal(t, UserCreated, published[0].Type)
+		assert.False(t, published[0].OccurredAt.IsZero(), "OccurredAt should be stamped")
+	}
+}
+
+func TestCompositeKeepsOccurredAt(t *testing.T) {
+	recorder := &Recorder{}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, NewComposite(recorder).Publish(context.Background(), Event{Type: UserDeleted, OccurredAt: at}))
+
+	assert.Equal(t, at, recorder.Events()[0].OccurredAt)
+}
+
+func TestCompositeContinuesAfterFailure(t *testing.T) {
+	boom := errors.New("boom")
+	recorder := &Recorder{}
+	composite := NewComposite(failingPublisher{err: boom}, recorder)
+
+	err := composite.Publish(context.Background(), Event{Type: UserUpdated})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, boom)
+	assert.Len(t, recorder.Events(), 1, "later publishers still receive the event")
+}
+
+func TestNopAndLogPublishers(t *testing.T) {
+	assert.NoError(t, Nop{}.Publish(context.Background(), Event{Type: UserCreated}))
+	assert.NoError(t, LogPublisher{}.Publish(context.Background(), Event{Type: UserCreated}))
+}
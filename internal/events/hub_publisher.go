@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/websocket"
+)
+
+// Broadcaster is the part of the WebSocket hub used by HubPublisher
+type Broadcaster interface {
+	BroadcastToUser(userID uint, eventType websocket.EventType, data map[string]interface{})
+	BroadcastToMinimumRole(role models.Role, eventType websocket.EventType, data map[string]interface{})
+}
+
+// HubPublisher forwards events to connected WebSocket clients
+type HubPublisher struct {
+	hub Broadcaster
+}
+
+// NewHubPublisher creates a publisher broadcasting through hub
+func NewHubPublisher(hub Broadcaster) *HubPublisher {
+	return &HubPublisher{hub: hub}
+}
+
+// Publish routes the event to the affected user and/or to admins.
+// Events without a WebSocket counterpart (e.g. logins) are ignored.
+func (p *HubPublisher) Publish(_ context.Context, event Event) error {
+	var (
+		eventType websocket.EventType
+		toTarget  bool
+		toAdmins  bool
+	)
+	switch event.Type {
+	case UserCreated, UserRegistered:
+		eventType, toAdmins = websocket.EventUserCreated, true
+	case UserDeleted:
+		eventType, toAdmins = websocket.EventUserDeleted, true
+	case UserUpdated:
+		eventType, toTarget, toAdmins = websocket.EventUserUpdated, true, true
+	case UserRoleChanged:
+		eventType, toTarget, toAdmins = websocket.EventUserRoleChanged, true, true
+	case ProfileUpdated:
+		eventType, toTarget = websocket.EventProfileUpdated, true
+	case PasswordChanged:
+		eventType, toTarget = websocket.EventPasswordChanged, true
+	case SystemAlert:
+		eventType, toAdmins = websocket.EventSystemAlert, true
+	default:
+		return nil
+	}
+
+	data := make(map[string]interface{}, len(event.Payload)+2)
+	for k, v := range event.Payload {
+		data[k] = v
+	}
+	data["actor_id"] = event.ActorID
+	data["target_id"] = event.TargetID
+
+	if toTarget && event.TargetID != 0 {
+		p.hub.BroadcastToUser(event.TargetID, eventType, data)
+	}
+	if toAdmins {
+		p.hub.BroadcastToMinimumRole(models.RoleAdmin, eventType, data)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// broadcast is one call made to fakeBroadcaster
+type broadcast struct {
+	userID    uint
+	role      models.Role
+	eventType websocket.EventType
+	data      map[string]interface{}
+}
+
+// fakeBroadcaster records hub calls instead of sending them
+type fakeBroadcaster struct {
+	calls []broadcast
+}
+
+func (b *fakeBroadcaster) BroadcastToUser(userID uint, eventType websocket.EventType, data map[string]interface{}) {
+	b.calls = append(b.calls, broadcast{userID: userID, eventType: eventType, data: data})
+}
+
+func (b *fakeBroadcaster) BroadcastToMinimumRole(role models.Role, eventType websocket.EventType, data map[string]interface{}) {
+	b.calls = append(b.calls, broadcast{role: role, eventType: eventType, data: data})
+}
+
+func TestHubPublisherRouting(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     Type
+		eventType websocket.EventType
+		toTarget  bool
+		toAdmins  bool
+	}{
+		{"user created goes to admins", UserCreated, websocket.EventUserCreated, false, true},
+		{"registration goes to admins", UserRegistered, websocket.EventUserCreated, false, true},
+		{"user deleted goes to admins", UserDeleted, websocket.EventUserDeleted, false, true},
+		{"user updated goes to user and admins", UserUpdated, websocket.EventUserUpdated, true, true},
+		{"role change goes to user and admins", UserRoleChanged, websocket.EventUserRoleChanged, true, true},
+		{"profile update goes to user", ProfileUpdated, websocket.EventProfileUpdated, true, false},
+		{"password change goes to user", PasswordChanged, websocket.EventPasswordChanged, true, false},
+		{"system alert goes to admins", SystemAlert, websocket.EventSystemAlert, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := &fakeBroadcaster{}
+			err := NewHubPublisher(hub).Publish(context.Background(), Event{
+				Type:     tt.event,
+				ActorID:  1,
+				TargetID: 42,
+				Payload:  map[string]interface{}{"key": "value"},
+			})
+			require.NoError(t, err)
+
+			var toUser []uint
+			var toRoles []models.Role
+			for _, call := range hub.calls {
+				assert.Equal(t, tt.eventType, call.eventType)
+				assert.Equal(t, "value", call.data["key"])
+				assert.Equal(t, uint(1), call.data["actor_id"])
+				assert.Equal(t, uint(42), call.data["target_id"])
+				if call.role != "" {
+					toRoles = append(toRoles, call.role)
+				} else {
+					toUser = append(toUser, call.userID)
+				}
+			}
+
+			if tt.toTarget {
+				assert.Equal(t, []uint{42}, toUser)
+			} else {
+				assert.Empty(t, toUser)
+			}
+			if tt.toAdmins {
+				assert.Equal(t, []models.Role{models.RoleAdmin}, toRoles)
+			} else {
+				assert.Empty(t, toRoles)
+			}
+		})
+	}
+}
+
+func TestHubPublisherIgnoresUnroutedEvents(t *testing.T) {
+	hub := &fakeBroadcaster{}
+
+	require.NoError(t, NewHubPublisher(hub).Publish(context.Background(), Event{Type: UserLoggedIn, TargetID: 7}))
+
+	assert.Empty(t, hub.calls)
+}
+
+func TestHubPublisherDoesNotMutatePayload(t *testing.T) {
+	hub := &fakeBroadcaster{}
+	payload := map[string]interface{}{"key": "value"}
+
+	require.NoError(t, NewHubPublisher(hub).Publish(context.Background(), Event{Type: ProfileUpdated, TargetID: 7, Payload: payload}))
+
+	assert.Equal(t, map[string]interface{}{"key": "value"}, payload)
+}
@@ -0,0 +1,273 @@
+// Package flags evaluates database-backed feature flags.
+//
+// Code that depends on a flag takes a Checker, so tests can pass a Static
+// value instead of a Service.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+var (
+	// ErrNotFound is returned for flags that do not exist
+	ErrNotFound = errors.New("feature flag not found")
+	// ErrExists is returned when creating a flag whose name is taken
+	ErrExists = errors.New("feature flag already exists")
+)
+
+// StrictBinding makes JSON request bodies with unknown fields fail validation
+// instead of having them ignored
+const StrictBinding = "strict_binding"
+
+// Checker reports whether a flag is on for the user of ctx. Unknown flags are off.
+type Checker interface {
+	IsEnabled(ctx context.Context, name string) bool
+}
+
+// Static is a Checker with fixed values, for tests
+type Static map[string]bool
+
+// IsEnabled returns the fixed value of name
+func (s Static) IsEnabled(_ context.Context, name string) bool {
+	return s[name]
+}
+
+// Subject is the user a flag is evaluated for
+type Subject struct {
+	UserID uint
+	Role   models.Role
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a context evaluating flags for subject
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the subject set by WithSubject or, on a
+// *gin.Context, the user authenticated by middleware.JWTAuth
+func SubjectFromContext(ctx context.Context) (Subject, bool) {
+	if subject, ok := ctx.Value(subjectKey{}).(Subject); ok {
+		return subject, true
+	}
+	if user, ok := ctx.Value("user").(*models.User); ok && user != nil {
+		return Subject{UserID: uint(user.ID), Role: user.Role}, true
+	}
+	return Subject{}, false
+}
+
+// Evaluate reports whether flag is on for subject. known is false for
+// anonymous requests, which only see flags without role or percentage limits.
+func Evaluate(flag models.FeatureFlag, subject Subject, known bool) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if len(flag.Roles) > 0 && (!known || !slices.Contains(flag.Roles, subject.Role)) {
+		return false
+	}
+	if flag.Percentage >= 100 {
+		return true
+	}
+	if flag.Percentage <= 0 || !known {
+		return false
+	}
+	return rolloutBucket(flag.Name, subject.UserID) < flag.Percentage
+}
+
+// rolloutBucket places a user in 0-99 for a flag. Hashing the name too keeps
+// the same users from being first in every rollout.
+func rolloutBucket(name string, userID uint) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", name, userID)
+	return int(h.Sum32() % 100)
+}
+
+// Config configures the flag cache
+type Config struct {
+	CacheTTL time.Duration // How long flags are served from memory; changes made on other instances show up after it
+}
+
+// DefaultConfig returns the settings used for zero values
+func DefaultConfig() Config {
+	return Config{CacheTTL: 30 * time.Second}
+}
+
+// Service manages flags and evaluates them from an in-memory copy that is
+// reloaded every CacheTTL and after every change made through the Service
+type Service struct {
+	repo   *repository.FeatureFlagRepository
+	config Config
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	cache    map[string]models.FeatureFlag // nil until loaded or after a change
+	loadedAt time.Time
+}
+
+// NewService creates a flag service; zero config values fall back to DefaultConfig
+func NewService(repo *repository.FeatureFlagRepository, config Config) *Service {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultConfig().CacheTTL
+	}
+	return &Service{repo: repo, config: config, now: time.Now}
+}
+
+// IsEnabled reports whether the named flag is on for the user of ctx
+func (s *Service) IsEnabled(ctx context.Context, name string) bool {
+	flag, ok := s.cached(ctx)[name]
+	if !ok {
+		return false
+	}
+	subject, known := SubjectFromContext(ctx)
+	return Evaluate(flag, subject, known)
+}
+
+// cached returns the flag cache, reloading it when stale. If reloading fails
+// the previous copy is kept until the next attempt after CacheTTL.
+func (s *Service) cached(ctx context.Context) map[string]models.FeatureFlag {
+	s.mu.RLock()
+	cache, loadedAt := s.cache, s.loadedAt
+	s.mu.RUnlock()
+	if cache != nil && s.now().Sub(loadedAt) < s.config.CacheTTL {
+		return cache
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache != nil && s.now().Sub(s.loadedAt) < s.config.CacheTTL {
+		return s.cache // Reloaded while waiting for the lock
+	}
+
+	// A canceled request must not leave every flag off until the next reload
+	loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	s.loadedAt = s.now()
+	list, err := s.repo.List(loadCtx)
+	if err != nil {
+		logger.Error("Failed to load feature flags", "error", err)
+		if s.cache == nil {
+			s.cache = map[string]models.FeatureFlag{}
+		}
+		return s.cache
+	}
+	s.cache = make(map[string]models.FeatureFlag, len(list))
+	for _, flag := range list {
+		s.cache[flag.Name] = flag
+	}
+	return s.cache
+}
+
+// invalidate makes the next check reload the flags
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+}
+
+// List returns every flag, read from the database
+func (s *Service) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	return s.repo.List(ctx)
+}
+
+// Get returns the named flag, read from the database
+func (s *Service) Get(ctx context.Context, name string) (*models.FeatureFlag, error) {
+	flag, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if flag == nil {
+		return nil, ErrNotFound
+	}
+	return flag, nil
+}
+
+// Create adds a flag. Percentage defaults to 100.
+func (s *Service) Create(ctx context.Context, req *models.CreateFeatureFlagRequest) (*models.FeatureFlag, error) {
+	name := strings.TrimSpace(req.Name)
+	existing, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrExists
+	}
+
+	flag := &models.FeatureFlag{
+		Name:        name,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Roles:       normalizeRoles(req.Roles),
+		Percentage:  100,
+	}
+	if req.Percentage != nil {
+		flag.Percentage = *req.Percentage
+	}
+	if err := s.repo.Create(ctx, flag); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return flag, nil
+}
+
+// Update changes the fields set in req and returns the flag before and after
+func (s *Service) Update(ctx context.Context, name string, req *models.UpdateFeatureFlagRequest) (before, after *models.FeatureFlag, err error) {
+	flag, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := *flag
+
+	if req.Description != nil {
+		flag.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		flag.Enabled = *req.Enabled
+	}
+	if req.Roles != nil {
+		flag.Roles = normalizeRoles(*req.Roles)
+	}
+	if req.Percentage != nil {
+		flag.Percentage = *req.Percentage
+	}
+	if err := s.repo.Update(ctx, flag); err != nil {
+		return nil, nil, err
+	}
+	s.invalidate()
+	return &previous, flag, nil
+}
+
+// Delete removes the named flag and returns it
+func (s *Service) Delete(ctx context.Context, name string) (*models.FeatureFlag, error) {
+	flag, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Delete(ctx, flag.ID); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return flag, nil
+}
+
+// normalizeRoles drops duplicates and stores "every role" as an empty list, not null
+func normalizeRoles(roles []models.Role) []models.Role {
+	normalized := []models.Role{}
+	for _, role := range roles {
+		if !slices.Contains(normalized, role) {
+			normalized = append(normalized, role)
+		}
+	}
+	return normalized
+}
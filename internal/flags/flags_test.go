@@ -0,0 +1,166 @@
+package flags
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newService returns a Service over a fresh database whose clock reads *now
+func newService(t *testing.T, now *time.Time) (*Service, *repository.FeatureFlagRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "flags.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.FeatureFlag{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	repo := repository.NewFeatureFlagRepository(db)
+	service := NewService(repo, Config{CacheTTL: time.Minute})
+	service.now = func() time.Time { return *now }
+	return service, repo
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestEvaluate(t *testing.T) {
+	admin := Subject{UserID: 1, Role: models.RoleAdmin}
+	user := Subject{UserID: 2, Role: models.RoleUser}
+
+	tests := []struct {
+		name string
+		flag models.FeatureFlag
+		want map[string]bool // Subject -> expected
+	}{
+		{"disabled", models.FeatureFlag{Name: "f", Percentage: 100}, map[string]bool{"admin": false, "user": false, "anonymous": false}},
+		{"global", models.FeatureFlag{Name: "f", Enabled: true, Percentage: 100}, map[string]bool{"admin": true, "user": true, "anonymous": true}},
+		{"per role", models.FeatureFlag{Name: "f", Enabled: true, Roles: []models.Role{models.RoleAdmin}, Percentage: 100}, map[string]bool{"admin": true, "user": false, "anonymous": false}},
+		{"zero percent", models.FeatureFlag{Name: "f", Enabled: true}, map[string]bool{"admin": false, "user": false, "anonymous": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want["admin"], Evaluate(tt.flag, admin, true), "admin")
+			assert.Equal(t, tt.want["user"], Evaluate(tt.flag, user, true), "user")
+			assert.Equal(t, tt.want["anonymous"], Evaluate(tt.flag, Subject{}, false), "anonymous")
+		})
+	}
+}
+
+func TestEvaluate_PercentageRollout(t *testing.T) {
+	flag := models.FeatureFlag{Name: "new_search", Enabled: true, Percentage: 25}
+
+	enabled := 0
+	for id := uint(1); id <= 10000; id++ {
+		subject := Subject{UserID: id, Role: models.RoleUser}
+		on := Evaluate(flag, subject, true)
+		assert.Equal(t, on, Evaluate(flag, subject, true), "stable for user %d", id)
+		if on {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 2500, enabled, 250)
+
+	// Raising the percentage keeps users that already had the flag
+	wider := flag
+	wider.Percentage = 50
+	for id := uint(1); id <= 1000; id++ {
+		subject := Subject{UserID: id, Role: models.RoleUser}
+		if Evaluate(flag, subject, true) {
+			assert.True(t, Evaluate(wider, subject, true), "user %d", id)
+		}
+	}
+
+	assert.False(t, Evaluate(flag, Subject{}, false), "anonymous requests are outside partial rollouts")
+}
+
+func TestSubjectFromContext(t *testing.T) {
+	_, ok := SubjectFromContext(context.Background())
+	assert.False(t, ok)
+
+	subject := Subject{UserID: 7, Role: models.RoleSuperAdmin}
+	got, ok := SubjectFromContext(WithSubject(context.Background(), subject))
+	assert.True(t, ok)
+	assert.Equal(t, subject, got)
+}
+
+func TestService_IsEnabledUsesCache(t *testing.T) {
+	ctx := WithSubject(context.Background(), Subject{UserID: 1, Role: models.RoleUser})
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	service, repo := newService(t, &now)
+
+	assert.False(t, service.IsEnabled(ctx, "strict_binding"), "unknown flags are off")
+
+	// Changes through the service apply at once
+	_, err := service.Create(ctx, &models.CreateFeatureFlagRequest{Name: "strict_binding", Enabled: true})
+	require.NoError(t, err)
+	assert.True(t, service.IsEnabled(ctx, "strict_binding"))
+
+	// Changes made elsewhere, e.g. by another instance, apply after CacheTTL
+	flag, err := repo.GetByName(ctx, "strict_binding")
+	require.NoError(t, err)
+	flag.Enabled = false
+	require.NoError(t, repo.Update(ctx, flag))
+	assert.True(t, service.IsEnabled(ctx, "strict_binding"), "served from cache")
+
+	now = now.Add(time.Minute)
+	assert.False(t, service.IsEnabled(ctx, "strict_binding"), "reloaded after CacheTTL")
+}
+
+func TestService_CRUD(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	service, _ := newService(t, &now)
+
+	created, err := service.Create(ctx, &models.CreateFeatureFlagRequest{
+		Name:  "ws_protocol_v2",
+		Roles: []models.Role{models.RoleAdmin, models.RoleAdmin},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 100, created.Percentage, "defaults to every user")
+	assert.Equal(t, []models.Role{models.RoleAdmin}, created.Roles)
+
+	_, err = service.Create(ctx, &models.CreateFeatureFlagRequest{Name: "ws_protocol_v2"})
+	assert.ErrorIs(t, err, ErrExists)
+
+	enabled := true
+	before, after, err := service.Update(ctx, "ws_protocol_v2", &models.UpdateFeatureFlagRequest{
+		Enabled:    &enabled,
+		Percentage: intPtr(0),
+		Roles:      &[]models.Role{},
+	})
+	require.NoError(t, err)
+	assert.False(t, before.Enabled)
+	assert.Equal(t, []models.Role{models.RoleAdmin}, before.Roles)
+	assert.True(t, after.Enabled)
+	assert.Equal(t, 0, after.Percentage)
+
+	stored, err := service.Get(ctx, "ws_protocol_v2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Percentage, "zero is stored, not replaced by a default")
+	assert.Equal(t, []models.Role{}, stored.Roles)
+
+	deleted, err := service.Delete(ctx, "ws_protocol_v2")
+	require.NoError(t, err)
+	assert.Equal(t, after.ID, deleted.ID)
+	_, err = service.Get(ctx, "ws_protocol_v2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, _, err = service.Update(ctx, "ws_protocol_v2", &models.UpdateFeatureFlagRequest{})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStatic(t *testing.T) {
+	var checker Checker = Static{"on": true}
+	assert.True(t, checker.IsEnabled(context.Background(), "on"))
+	assert.False(t, checker.IsEnabled(context.Background(), "off"))
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultActivityLimit is the page size when ?limit is not given
+const defaultActivityLimit = 20
+
+// ActivityHandler serves the activity feed of users
+type ActivityHandler struct {
+	service *services.UserActivityService
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(service *services.UserActivityService) *ActivityHandler {
+	return &ActivityHandler{service: service}
+}
+
+// GetUserActivity godoc
+// @Summary      Get a user's activity feed
+// @Description  Latest audit logs about a user (admin only): actions on the user and the user's own
+// @Description  auth actions, newest first, each with the name and email of the user who acted.
+// @Description  For the next page, pass next_before from the response as ?before.
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path      int     true   "User ID"
+// @Param        limit   query     int     false  "Entries per page, 1-100 (default: 20)"
+// @Param        before  query     string  false  "Only entries created before this RFC 3339 time"
+// @Success      200     {object}  models.UserActivityResponse
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      403     {object}  models.ErrorResponse
+// @Failure      501     {object}  models.ErrorResponse
+// @Router       /users/{id}/activity [get]
+func (h *ActivityHandler) GetUserActivity(c *gin.Context) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+
+	limit := defaultActivityLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			activityValidationError(c, "limit", "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	var before *time.Time
+	if value := c.Query("before"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			activityValidationError(c, "before", "before must be an RFC 3339 time")
+			return
+		}
+		before = &parsed
+	}
+
+	activity, err := h.service.GetUserActivity(c.Request.Context(), id, before, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidActivityLimit) {
+			activityValidationError(c, "limit", err.Error())
+			return
+		}
+		logger.Error("Failed to retrieve user activity", "error", err, "user_id", id)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to retrieve user activity")
+		return
+	}
+
+	utils.SuccessResponse(c, activity)
+}
+
+// activityValidationError rejects an invalid query parameter
+func activityValidationError(c *gin.Context, field, message string) {
+	utils.ErrorDataResponse(c, http.StatusBadRequest, "Validation failed", []models.ValidationError{{
+		Field:   field,
+		Message: message,
+	}})
+}
@@ -7,6 +7,9 @@ import (
 	"Go-Lang-project-01/internal/models"
 	"Go-Lang-project-01/internal/repository"
 	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -17,40 +20,28 @@ import (
 // AuditHandler handles audit log HTTP requests
 type AuditHandler struct {
 	service *services.AuditService
+	storage *services.AuditCap
 }
 
-// NewAuditHandler creates a new audit handler
-func NewAuditHandler(service *services.AuditService) *AuditHandler {
-	return &AuditHandler{service: service}
+// NewAuditHandler creates a new audit handler. storage reports the table usage
+// in the stats; nil when no row cap is configured.
+func NewAuditHandler(service *services.AuditService, storage *services.AuditCap) *AuditHandler {
+	return &AuditHandler{service: service, storage: storage}
 }
 
-// GetAuditLogs godoc
-// @Summary      Get audit logs
-// @Description  Retrieve audit logs with optional filters (admin only)
-// @Tags         audit
-// @Accept       json
-// @Produce      json
-// @Param        user_id      query  int     false  "Filter by user ID"
-// @Param        action       query  string  false  "Filter by action"
-// @Param        resource     query  string  false  "Filter by resource"
-// @Param        success      query  bool    false  "Filter by success status"
-// @Param        start_date   query  string  false  "Start date (RFC3339)"
-// @Param        end_date     query  string  false  "End date (RFC3339)"
-// @Param        page         query  int     false  "Page number (default: 1)"
-// @Param        page_size    query  int     false  "Page size (default: 20, max: 100)"
-// @Security     Bearer
-// @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      401  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
-// @Router       /audit-logs [get]
-func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
-	filter := &repository.AuditLogFilter{
-		Page:     1,
-		PageSize: 20,
+// auditErrorStatus maps audit service errors to an HTTP status.
+// 501 signals that audit events go to a write-only sink (e.g. a log pipeline).
+func auditErrorStatus(err error, fallback int) int {
+	if errors.Is(err, services.ErrAuditReadsUnsupported) {
+		return http.StatusNotImplemented
 	}
+	return fallback
+}
 
-	// Parse query parameters
+// auditLogFilter reads the filters shared by GetAuditLogs and ExportAuditLogs
+// from the query. Values that do not parse are ignored.
+func auditLogFilter(c *gin.Context) *repository.AuditLogFilter {
+	filter := &repository.AuditLogFilter{}
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		if userID, err := strconv.ParseUint(userIDStr, 10, 32); err == nil {
 			uid := uint(userID)
@@ -86,6 +77,43 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 		}
 	}
 
+	return filter
+}
+
+// GetAuditLogs godoc
+// @Summary      Get audit logs
+// @Description  Retrieve audit logs with optional filters (admin only)
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Param        user_id      query  int     false  "Filter by user ID"
+// @Param        action       query  string  false  "Filter by action"
+// @Param        resource     query  string  false  "Filter by resource"
+// @Param        success      query  bool    false  "Filter by success status"
+// @Param        start_date   query  string  false  "Start date (RFC3339)"
+// @Param        end_date     query  string  false  "End date (RFC3339)"
+// @Param        sort         query  string  false  "Comma-separated sort fields: created_at, action, user_id, success (default: created_at)"
+// @Param        order        query  string  false  "asc or desc, once or per sort field (default: desc)"
+// @Param        page         query  int     false  "Page number (default: 1)"
+// @Param        page_size    query  int     false  "Page size (default: 20, max: 100)"
+// @Security     Bearer
+// @Success      200  {object}  models.AuditLogListResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /audit-logs [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	filter := auditLogFilter(c)
+	filter.Page = 1
+	filter.PageSize = 20
+
+	sort, err := repository.ParseAuditLogSort(c.Query("sort"), c.Query("order"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Sort = sort
+
 	if pageStr := c.Query("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
 			filter.Page = page
@@ -100,25 +128,17 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 
 	logs, total, err := h.service.GetLogs(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to retrieve audit logs",
-			"error":   err.Error(),
-		})
+		logger.Error("Failed to retrieve audit logs", "error", err)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to retrieve audit logs")
 		return
 	}
 
-	totalPages := (int(total) + filter.PageSize - 1) / filter.PageSize
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    logs,
-		"pagination": gin.H{
-			"page":        filter.Page,
-			"page_size":   filter.PageSize,
-			"total_items": total,
-			"total_pages": totalPages,
-		},
+	meta := models.NewPaginationMeta(filter.Page, filter.PageSize, total)
+	utils.SetPaginationLinks(c, meta)
+	c.JSON(http.StatusOK, models.AuditLogListResponse{
+		Success:    true,
+		Data:       logs,
+		Pagination: models.NewAuditLogPagination(meta),
 	})
 }
 
@@ -130,56 +150,42 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 // @Produce      json
 // @Param        id   path  int  true  "Audit Log ID"
 // @Security     Bearer
-// @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      401  {object}  map[string]interface{}
-// @Failure      404  {object}  map[string]interface{}
+// @Success      200  {object}  models.AuditLogResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
 // @Router       /audit-logs/{id} [get]
 func (h *AuditHandler) GetAuditLog(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Invalid audit log ID",
-		})
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
 		return
 	}
 
-	log, err := h.service.GetLogByID(uint(id))
+	log, err := h.service.GetLogByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Audit log not found",
-		})
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusNotFound), "Audit log not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    log,
-	})
+	c.JSON(http.StatusOK, models.AuditLogResponse{Success: true, Data: log})
 }
 
 // GetMyAuditLogs godoc
 // @Summary      Get my audit logs
-// @Description  Retrieve audit logs for the authenticated user
+// @Description  Retrieve audit logs and a login summary for the authenticated user
 // @Tags         audit
 // @Accept       json
 // @Produce      json
 // @Param        limit   query  int  false  "Limit (default: 50)"
 // @Security     Bearer
-// @Success      200  {object}  map[string]interface{}
-// @Failure      401  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.MyAuditLogsResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
 // @Router       /audit-logs/me [get]
 func (h *AuditHandler) GetMyAuditLogs(c *gin.Context) {
 	userIDInterface, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "User not authenticated",
-		})
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -194,47 +200,82 @@ func (h *AuditHandler) GetMyAuditLogs(c *gin.Context) {
 
 	logs, err := h.service.GetRecentByUser(userID, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to retrieve audit logs",
-			"error":   err.Error(),
-		})
+		logger.Error("Failed to retrieve audit logs", "error", err)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to retrieve audit logs")
+		return
+	}
+
+	summary, err := h.service.GetUserAuthSummary(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve login summary", "error", err)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to retrieve login summary")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    logs,
-		"count":   len(logs),
+	c.JSON(http.StatusOK, models.MyAuditLogsResponse{
+		Success:     true,
+		Data:        logs,
+		Count:       len(logs),
+		AuthSummary: summary,
 	})
 }
 
+// GetUserAuthSummary godoc
+// @Summary      Get user login summary
+// @Description  Total and failed logins, auth actions and last five IPs of a user (admin only).
+// @Description  Results may be up to 30 seconds old.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id   path  int  true  "User ID"
+// @Security     Bearer
+// @Success      200  {object}  models.UserAuthSummaryResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Failure      501  {object}  models.ErrorResponse
+// @Router       /users/{id}/auth-summary [get]
+func (h *AuditHandler) GetUserAuthSummary(c *gin.Context) {
+	userID, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetUserAuthSummary(userID)
+	if err != nil {
+		logger.Error("Failed to retrieve login summary", "error", err)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to retrieve login summary")
+		return
+	}
+
+	utils.SuccessResponse(c, summary)
+}
+
 // GetAuditStats godoc
 // @Summary      Get audit statistics
-// @Description  Retrieve audit log statistics (admin only)
+// @Description  Retrieve audit log statistics (admin only). With a row cap configured, "storage" reports the table usage from the last size check.
 // @Tags         audit
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
-// @Success      200  {object}  map[string]interface{}
-// @Failure      401  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.StatsResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
 // @Router       /audit-logs/stats [get]
 func (h *AuditHandler) GetAuditStats(c *gin.Context) {
 	stats, err := h.service.GetStats()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to retrieve audit statistics",
-			"error":   err.Error(),
-		})
+		logger.Error("Failed to retrieve audit statistics", "error", err)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to retrieve audit statistics")
 		return
 	}
+	if h.storage != nil {
+		if usage, ok := h.storage.Usage(); ok {
+			stats["storage"] = usage
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    stats,
-	})
+	c.JSON(http.StatusOK, models.StatsResponse{Success: true, Data: stats})
 }
 
 // CleanupOldLogs godoc
@@ -245,35 +286,29 @@ func (h *AuditHandler) GetAuditStats(c *gin.Context) {
 // @Produce      json
 // @Param        days  query  int  true  "Retention days (logs older than this will be deleted)"
 // @Security     Bearer
-// @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      401  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.AuditCleanupResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
 // @Router       /audit-logs/cleanup [delete]
 func (h *AuditHandler) CleanupOldLogs(c *gin.Context) {
 	daysStr := c.Query("days")
 	days, err := strconv.Atoi(daysStr)
 	if err != nil || days < 1 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Invalid days parameter (must be positive integer)",
-		})
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid days parameter (must be positive integer)")
 		return
 	}
 
 	deleted, err := h.service.CleanupOldLogs(days)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to cleanup old logs",
-			"error":   err.Error(),
-		})
+		logger.Error("Failed to cleanup old logs", "error", err)
+		utils.ErrorResponse(c, auditErrorStatus(err, http.StatusInternalServerError), "Failed to cleanup old logs")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Old audit logs cleaned up successfully",
-		"deleted": deleted,
+	c.JSON(http.StatusOK, models.AuditCleanupResponse{
+		Success: true,
+		Message: "Old audit logs cleaned up successfully",
+		Deleted: deleted,
 	})
 }
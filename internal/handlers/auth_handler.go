@@ -1,14 +1,18 @@
 package handlers
 
 import (
-	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/events"
 	"Go-Lang-project-01/internal/models"
 	"Go-Lang-project-01/internal/repository"
 	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/database"
 	"Go-Lang-project-01/pkg/logger"
 	"Go-Lang-project-01/pkg/utils"
 
@@ -17,52 +21,88 @@ import (
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	userRepo     *repository.UserRepository
-	jwtManager   *auth.JWTManager
-	auditService *services.AuditService
+	userRepo      *repository.UserRepository
+	revokedTokens *repository.RevokedTokenRepository
+	refreshTokens *services.RefreshTokenService
+	jwtManager    *auth.JWTManager
+	auditService  *services.AuditService
+	publisher     events.Publisher
+	registration  *services.RegistrationGuard
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(userRepo *repository.UserRepository, jwtManager *auth.JWTManager, auditService *services.AuditService) *AuthHandler {
+// NewAuthHandler creates a new auth handler.
+// registration may be nil, in which case registrations are not limited.
+func NewAuthHandler(userRepo *repository.UserRepository, revokedTokens *repository.RevokedTokenRepository, refreshTokens *services.RefreshTokenService, jwtManager *auth.JWTManager, auditService *services.AuditService, publisher events.Publisher, registration *services.RegistrationGuard) *AuthHandler {
+	if registration == nil {
+		registration = services.NewRegistrationGuard(services.RegistrationGuardConfig{})
+	}
 	return &AuthHandler{
-		userRepo:     userRepo,
-		jwtManager:   jwtManager,
-		auditService: auditService,
+		userRepo:      userRepo,
+		revokedTokens: revokedTokens,
+		refreshTokens: refreshTokens,
+		jwtManager:    jwtManager,
+		auditService:  auditService,
+		publisher:     publisher,
+		registration:  registration,
 	}
 }
 
 // Register godoc
 // @Summary      Register new user
-// @Description  Create a new user account with email and password
+// @Description  Create a new user account with email and password. Registrations are limited per
+// @Description  client IP and per email domain, and may require a proof-of-work or captcha challenge_token.
 // @Tags         authentication
 // @Accept       json
 // @Produce      json
 // @Param        request  body      models.RegisterRequest  true  "Register request"
-// @Success      201      {object}  map[string]interface{}  "User registered successfully with tokens"
-// @Failure      400      {object}  map[string]interface{}  "Invalid request body"
-// @Failure      409      {object}  map[string]interface{}  "Email already registered"
-// @Failure      500      {object}  map[string]interface{}  "Internal server error"
+// @Success      201      {object}  models.LoginResponseEnvelope  "User registered successfully with tokens"
+// @Failure      400      {object}  models.ErrorResponse          "Invalid request body"
+// @Failure      403      {object}  models.ErrorResponse          "Registration challenge failed"
+// @Failure      409      {object}  models.ErrorResponse          "Email already registered"
+// @Failure      429      {object}  models.Response               "Too many registrations from this IP or email domain; data holds the error code and scope"
+// @Failure      500      {object}  models.ErrorResponse          "Internal server error"
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 
+	// The IP limit comes first, so malformed bodies count against it too
+	clientIP := utils.ClientIP(c)
+	if ok, retryAfter := h.registration.AllowIP(clientIP); !ok {
+		h.registerThrottled(c, models.RegisterThrottleIP, clientIP, "", retryAfter)
+		return
+	}
+
 	// Bind and validate request
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	domain := services.EmailDomain(req.Email)
+	if ok, retryAfter := h.registration.AllowDomain(req.Email); !ok {
+		h.registerThrottled(c, models.RegisterThrottleDomain, domain, domain, retryAfter)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := h.registration.Verify(ctx, &req, clientIP); err != nil {
+		if errors.Is(err, services.ErrChallengeFailed) {
+			logger.Warn("Registration failed: challenge not passed", "domain", domain, "ip", clientIP)
+			h.auditService.LogAuthAction(c, nil, models.AuditActionRegister, false, "Challenge failed")
+			utils.ErrorResponse(c, http.StatusForbidden, "registration challenge failed")
+			return
+		}
+		logger.Error("Failed to verify registration challenge", "error", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to process registration")
+		return
+	}
 
 	// Check if email already exists
 	existingUser, _ := h.userRepo.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
 		logger.Warn("Registration failed: email already exists", "email", req.Email)
-		c.JSON(http.StatusConflict, gin.H{
-			"success": false,
-			"message": "email already registered",
-		})
+		utils.ErrorResponse(c, http.StatusConflict, "email already registered")
 		return
 	}
 
@@ -70,50 +110,46 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
 		logger.Error("Failed to hash password", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "failed to process registration",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to process registration")
 		return
 	}
 
 	// Create user
 	user := models.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: hashedPassword,
-		Age:      req.Age,
-		Role:     string(models.RoleUser), // Default role for new registrations
-		IsActive: true,
+		Name:        req.Name,
+		Email:       req.Email,
+		Password:    hashedPassword,
+		Age:         req.Age,
+		DateOfBirth: req.DateOfBirth.TimePtr(),
+		Role:        models.RoleUser, // Default role for new registrations
+		IsActive:    true,
 	}
 
 	if err := h.userRepo.Create(ctx, &user); err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			// Registered concurrently since the check above
+			logger.Warn("Registration failed: email already exists", "email", req.Email)
+			utils.ErrorResponse(c, http.StatusConflict, "email already registered")
+			return
+		}
 		logger.Error("Failed to create user", "error", err, "email", req.Email)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "failed to create user",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to create user")
 		return
 	}
 
 	// Generate tokens
-	accessToken, err := h.jwtManager.GenerateAccessToken(user.ID, user.Email, user.Role)
+	expiresAt := time.Now().Add(h.jwtManager.AccessTokenTTL()).UTC().Truncate(time.Second)
+	accessToken, err := h.jwtManager.GenerateAccessToken(uint(user.ID), user.Email, user.Role)
 	if err != nil {
 		logger.Error("Failed to generate access token", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "failed to generate tokens",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate tokens")
 		return
 	}
 
-	refreshToken, err := h.jwtManager.GenerateRefreshToken(user.ID, user.Email, user.Role)
+	refreshToken, err := h.refreshTokens.Issue(ctx, &user)
 	if err != nil {
 		logger.Error("Failed to generate refresh token", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "failed to generate tokens",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate tokens")
 		return
 	}
 
@@ -122,20 +158,41 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Log audit trail
 	h.auditService.LogAuthAction(c, &user.ID, models.AuditActionRegister, true, "")
 
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserRegistered,
+		ActorID:  uint(user.ID),
+		TargetID: uint(user.ID),
+		Payload:  map[string]interface{}{"user": user},
+	})
+
 	// Return response
-	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "user registered successfully",
-		"data": models.LoginResponse{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-			TokenType:    "Bearer",
-			ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
-			User:         user,
-		},
+	utils.CreatedResponse(c, "user registered successfully", models.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.jwtManager.AccessTokenTTL().Seconds()),
+		ExpiresAt:    expiresAt,
+		User:         user,
 	})
 }
 
+// registerThrottled answers 429 for a registration over the IP or email
+// domain limit and records it in the audit log
+func (h *AuthHandler) registerThrottled(c *gin.Context, scope models.RegisterThrottleScope, key, domain string, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	logger.Warn("Registration throttled", "scope", scope, "key", key, "retry_after", seconds)
+	if h.registration.ShouldAudit(scope, key) {
+		h.auditService.LogAction(c, nil, models.AuditActionRegisterThrottled, models.AuditResourceAuth, nil,
+			models.RegisterThrottle{Scope: scope, Domain: domain, RetryAfterSeconds: seconds},
+			false, "Registration rate limit exceeded")
+	}
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	utils.ErrorDataResponse(c, http.StatusTooManyRequests,
+		"Too many registrations. Please try again later.",
+		gin.H{"error": "registration_throttled", "scope": scope})
+}
+
 // Login godoc
 // @Summary      User login
 // @Description  Authenticate user with email and password
@@ -143,22 +200,21 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        request  body      models.LoginRequest     true  "Login credentials"
-// @Success      200      {object}  map[string]interface{}  "Login successful with tokens"
-// @Failure      400      {object}  map[string]interface{}  "Invalid request body"
-// @Failure      401      {object}  map[string]interface{}  "Invalid credentials or inactive account"
-// @Failure      500      {object}  map[string]interface{}  "Internal server error"
+// @Success      200      {object}  models.LoginResponseEnvelope  "Login successful with tokens"
+// @Failure      400      {object}  models.ErrorResponse          "Invalid request body"
+// @Failure      401      {object}  models.ErrorResponse          "Invalid credentials or inactive account"
+// @Failure      500      {object}  models.ErrorResponse          "Internal server error"
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 
 	// Bind and validate request
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Find user by email
 	user, err := h.userRepo.GetByEmail(ctx, req.Email)
@@ -166,10 +222,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		logger.Warn("Login failed: user not found", "email", req.Email)
 		// Log failed login attempt
 		h.auditService.LogAuthAction(c, nil, models.AuditActionLoginFailed, false, "User not found")
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "invalid email or password",
-		})
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid email or password")
 		return
 	}
 
@@ -177,10 +230,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	if !user.IsActive {
 		logger.Warn("Login failed: user inactive", "email", req.Email)
 		h.auditService.LogAuthAction(c, &user.ID, models.AuditActionLoginFailed, false, "Account inactive")
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "account is inactive",
-		})
+		utils.ErrorResponse(c, http.StatusUnauthorized, "account is inactive")
 		return
 	}
 
@@ -188,31 +238,23 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	if err := auth.CheckPassword(req.Password, user.Password); err != nil {
 		logger.Warn("Login failed: invalid password", "email", req.Email)
 		h.auditService.LogAuthAction(c, &user.ID, models.AuditActionLoginFailed, false, "Invalid password")
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "invalid email or password",
-		})
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid email or password")
 		return
 	}
 
 	// Generate tokens
-	accessToken, err := h.jwtManager.GenerateAccessToken(user.ID, user.Email, user.Role)
+	expiresAt := time.Now().Add(h.jwtManager.AccessTokenTTL()).UTC().Truncate(time.Second)
+	accessToken, err := h.jwtManager.GenerateAccessToken(uint(user.ID), user.Email, user.Role)
 	if err != nil {
 		logger.Error("Failed to generate access token", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "failed to generate tokens",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate tokens")
 		return
 	}
 
-	refreshToken, err := h.jwtManager.GenerateRefreshToken(user.ID, user.Email, user.Role)
+	refreshToken, err := h.refreshTokens.Issue(ctx, user)
 	if err != nil {
 		logger.Error("Failed to generate refresh token", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "failed to generate tokens",
-		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate tokens")
 		return
 	}
 
@@ -221,71 +263,166 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Log successful login
 	h.auditService.LogAuthAction(c, &user.ID, models.AuditActionLogin, true, "")
 
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserLoggedIn,
+		ActorID:  uint(user.ID),
+		TargetID: uint(user.ID),
+	})
+
 	// Return response
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "login successful",
-		"data": models.LoginResponse{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-			TokenType:    "Bearer",
-			ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
-			User:         *user,
-		},
+	utils.MessageResponse(c, "login successful", models.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.jwtManager.AccessTokenTTL().Seconds()),
+		ExpiresAt:    expiresAt,
+		User:         *user,
+
+		MustChangePassword: user.MustChangePassword,
 	})
 }
 
 // RefreshToken godoc
 // @Summary      Refresh access token
-// @Description  Get a new access token using refresh token
+// @Description  Exchange a refresh token for a new access token and a new refresh token. The presented
+// @Description  refresh token is rotated and cannot be used again; presenting it again revokes every
+// @Description  refresh token issued since the same login.
 // @Tags         authentication
 // @Accept       json
 // @Produce      json
 // @Param        request  body      models.RefreshTokenRequest   true  "Refresh token"
-// @Success      200      {object}  map[string]interface{}       "Token refreshed successfully"
-// @Failure      400      {object}  map[string]interface{}       "Invalid request body"
-// @Failure      401      {object}  map[string]interface{}       "Invalid or expired refresh token"
+// @Success      200      {object}  models.RefreshTokenResponseEnvelope  "Token refreshed successfully"
+// @Failure      400      {object}  models.ErrorResponse                 "Invalid request body"
+// @Failure      401      {object}  models.ErrorResponse                 "Invalid or expired refresh token"
 // @Router       /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req models.RefreshTokenRequest
 
 	// Bind and validate request
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	// Generate new access token
-	accessToken, err := h.jwtManager.RefreshAccessToken(req.RefreshToken)
+	// Validate refresh token to get claims
+	claims, err := h.jwtManager.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		logger.Warn("Token refresh failed", "error", err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "invalid or expired refresh token",
-		})
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Tokens revoked by logout cannot be refreshed
+	revoked, err := h.revokedTokens.IsRevoked(ctx, req.RefreshToken)
+	if err != nil {
+		logger.Error("Failed to check refresh token revocation", "error", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to verify refresh token")
+		return
+	}
+	if revoked {
+		logger.Warn("Revoked refresh token used", "user_id", claims.UserID)
+		h.auditService.LogAuthAction(c, models.IDPtr(&claims.UserID), models.AuditActionRefreshToken, false, "refresh token revoked by logout")
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+
+	// Deactivated users and administratively revoked tokens cannot be refreshed
+	user, err := h.userRepo.GetByID(database.WithPrimary(ctx), claims.UserID)
+	if err != nil || !user.IsActive || (claims.IssuedAt != nil && user.TokenRevoked(claims.IssuedAt.Time)) {
+		logger.Warn("Token refresh rejected", "user_id", claims.UserID)
+		h.auditService.LogAuthAction(c, models.IDPtr(&claims.UserID), models.AuditActionRefreshToken, false, "refresh token revoked or user inactive")
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+
+	// Rotate the refresh token; a token presented twice may have been stolen
+	refreshToken, err := h.refreshTokens.Rotate(ctx, claims, user)
+	if err != nil {
+		var reuse *services.RefreshTokenReuseError
+		switch {
+		case errors.As(err, &reuse):
+			logger.Warn("Rotated refresh token reused, token family revoked", "user_id", claims.UserID, "family_id", reuse.Reuse.FamilyID)
+			h.auditService.LogAction(c, models.IDPtr(&claims.UserID), models.AuditActionRefreshTokenReuse, models.AuditResourceAuth, nil, reuse.Reuse, false, "rotated refresh token reused")
+		case errors.Is(err, services.ErrRefreshTokenUnknown), errors.Is(err, services.ErrRefreshTokenRevoked):
+			logger.Warn("Token refresh rejected", "user_id", claims.UserID, "error", err.Error())
+			h.auditService.LogAuthAction(c, models.IDPtr(&claims.UserID), models.AuditActionRefreshToken, false, err.Error())
+		default:
+			logger.Error("Failed to rotate refresh token", "error", err, "user_id", claims.UserID)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate tokens")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired refresh token")
 		return
 	}
 
-	// Validate refresh token to get claims for audit logging
-	claims, _ := h.jwtManager.ValidateToken(req.RefreshToken)
+	// Generate new access token
+	expiresAt := time.Now().Add(h.jwtManager.AccessTokenTTL()).UTC().Truncate(time.Second)
+	accessToken, err := h.jwtManager.GenerateAccessToken(uint(user.ID), user.Email, user.Role)
+	if err != nil {
+		logger.Error("Failed to generate access token", "error", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate tokens")
+		return
+	}
 
 	logger.Info("Access token refreshed successfully")
 
 	// Log token refresh
-	if claims != nil {
-		h.auditService.LogAuthAction(c, &claims.UserID, models.AuditActionRefreshToken, true, "")
+	h.auditService.LogAuthAction(c, models.IDPtr(&claims.UserID), models.AuditActionRefreshToken, true, "")
+
+	// Return new access token and the rotated refresh token
+	utils.MessageResponse(c, "token refreshed successfully", models.RefreshTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.jwtManager.AccessTokenTTL().Seconds()),
+		ExpiresAt:    expiresAt,
+	})
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke a refresh token so it can no longer be used. Logging out twice with the same token succeeds. Access tokens stay valid until they expire.
+// @Tags         authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.LogoutRequest    true  "Refresh token to revoke"
+// @Success      200      {object}  models.MessageResponse  "Logged out successfully"
+// @Failure      400      {object}  models.ErrorResponse    "Invalid request body"
+// @Failure      401      {object}  models.ErrorResponse    "Invalid or expired refresh token"
+// @Failure      500      {object}  models.ErrorResponse    "Internal server error"
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.LogoutRequest
+
+	// Bind and validate request
+	if err := utils.BindJSON(c, &req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
 	}
 
-	// Return new access token
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "token refreshed successfully",
-		"data": models.RefreshTokenResponse{
-			AccessToken: accessToken,
-			TokenType:   "Bearer",
-			ExpiresIn:   24 * 60 * 60, // 24 hours in seconds
-		},
-	})
+	// Only tokens we issued are recorded; expired ones are unusable already
+	claims, err := h.jwtManager.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		logger.Warn("Logout with invalid refresh token", "error", err.Error())
+		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := h.revokedTokens.Revoke(ctx, req.RefreshToken, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		logger.Error("Failed to revoke refresh token", "error", err, "user_id", claims.UserID)
+		h.auditService.LogAuthAction(c, models.IDPtr(&claims.UserID), models.AuditActionLogout, false, "failed to revoke refresh token")
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to logout")
+		return
+	}
+
+	logger.Info("User logged out", "user_id", claims.UserID)
+	h.auditService.LogAuthAction(c, models.IDPtr(&claims.UserID), models.AuditActionLogout, true, "")
+
+	utils.MessageResponse(c, "logged out successfully", nil)
 }
 
 // GetProfile godoc
@@ -295,37 +432,27 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
-// @Success      200  {object}  map[string]interface{}  "User profile"
-// @Failure      401  {object}  map[string]interface{}  "Unauthorized"
-// @Failure      404  {object}  map[string]interface{}  "User not found"
+// @Success      200  {object}  models.UserResponse   "User profile"
+// @Failure      401  {object}  models.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  models.ErrorResponse  "User not found"
 // @Router       /auth/profile [get]
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "unauthorized",
-		})
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Get user from database
 	user, err := h.userRepo.GetByID(ctx, userID.(uint))
 	if err != nil {
 		logger.Error("Failed to get user profile", "error", err, "user_id", userID)
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "user not found",
-		})
+		utils.ErrorResponse(c, http.StatusNotFound, "user not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    user,
-	})
+	utils.SuccessResponse(c, user)
 }
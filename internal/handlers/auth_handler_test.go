@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupAuthRouter serves the public auth routes from an in-memory database,
+// issuing access tokens that live for accessTTL
+func setupAuthRouter(t *testing.T, accessTTL time.Duration) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.RevokedToken{}, &models.RefreshToken{}))
+
+	jwtManager := auth.NewJWTManager("test-secret", accessTTL, 24*time.Hour)
+	handler := NewAuthHandler(
+		repository.NewUserRepository(db),
+		repository.NewRevokedTokenRepository(db),
+		services.NewRefreshTokenService(jwtManager, repository.NewRefreshTokenRepository(db)),
+		jwtManager,
+		services.NewAuditService(repository.NewAuditLogRepository(db)),
+		events.Nop{},
+		nil,
+	)
+
+	router := gin.New()
+	router.POST("/auth/register", handler.Register)
+	router.POST("/auth/login", handler.Login)
+	router.POST("/auth/refresh", handler.RefreshToken)
+	return router
+}
+
+// postAuth posts body to path and decodes the data of the response envelope into out
+func postAuth(t *testing.T, router *gin.Engine, path string, body interface{}, out interface{}) {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Less(t, w.Code, 300, w.Body.String())
+
+	envelope := struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+}
+
+func TestAuthHandler_ExpiresInFollowsAccessTokenDuration(t *testing.T) {
+	router := setupAuthRouter(t, 15*time.Minute)
+	before := time.Now().Truncate(time.Second)
+
+	var registered models.LoginResponse
+	postAuth(t, router, "/auth/register", map[string]interface{}{
+		"name": "Expiry", "email": "expiry@test.com", "password": "correct-horse-42",
+	}, &registered)
+	assert.Equal(t, int64(900), registered.ExpiresIn)
+
+	var login models.LoginResponse
+	postAuth(t, router, "/auth/login", map[string]string{"email": "expiry@test.com", "password": "correct-horse-42"}, &login)
+	assert.Equal(t, int64(900), login.ExpiresIn)
+	assert.WithinRange(t, login.ExpiresAt, before.Add(15*time.Minute), time.Now().Add(15*time.Minute))
+
+	var refreshed models.RefreshTokenResponse
+	postAuth(t, router, "/auth/refresh", map[string]string{"refresh_token": login.RefreshToken}, &refreshed)
+	assert.Equal(t, int64(900), refreshed.ExpiresIn)
+	assert.WithinRange(t, refreshed.ExpiresAt, before.Add(15*time.Minute), time.Now().Add(15*time.Minute))
+
+	// expires_at matches the exp claim of the access token
+	claims, err := auth.NewJWTManager("test-secret", time.Minute, time.Hour).ValidateToken(refreshed.AccessToken)
+	require.NoError(t, err)
+	assert.WithinDuration(t, claims.ExpiresAt.Time, refreshed.ExpiresAt, time.Second)
+}
+
+func TestAuthHandler_ExpiresAtIsRFC3339(t *testing.T) {
+	router := setupAuthRouter(t, time.Hour)
+
+	var raw map[string]interface{}
+	postAuth(t, router, "/auth/register", map[string]interface{}{
+		"name": "Expiry", "email": "rfc3339@test.com", "password": "correct-horse-42",
+	}, &raw)
+	_, err := time.Parse(time.RFC3339, raw["expires_at"].(string))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3600, raw["expires_in"])
+}
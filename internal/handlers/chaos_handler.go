@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"Go-Lang-project-01/internal/middleware"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChaosHandler manages injected faults for resilience testing
+type ChaosHandler struct {
+	chaos        *middleware.Chaos
+	auditService *services.AuditService
+}
+
+// NewChaosHandler creates a new chaos handler
+func NewChaosHandler(chaos *middleware.Chaos, auditService *services.AuditService) *ChaosHandler {
+	return &ChaosHandler{
+		chaos:        chaos,
+		auditService: auditService,
+	}
+}
+
+// ListFaults godoc
+// @Summary      List injected faults
+// @Description  List the active faults (superadmin only, non-production only)
+// @Tags         chaos
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {array}   models.ChaosFault       "Active faults"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden: superadmin only"
+// @Router       /_chaos/faults [get]
+func (h *ChaosHandler) ListFaults(c *gin.Context) {
+	utils.SuccessResponse(c, h.chaos.Faults())
+}
+
+// InjectFault godoc
+// @Summary      Inject a fault
+// @Description  Add latency to, answer with a status code, or drop the connection of a percentage of
+// @Description  requests under path_prefix until the fault expires (superadmin only, non-production only).
+// @Description  Affected responses carry an X-Chaos-Fault header with the fault ID.
+// @Tags         chaos
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      models.CreateChaosFaultRequest  true  "Fault"
+// @Success      201      {object}  models.ChaosFault               "Fault active"
+// @Failure      400      {object}  map[string]interface{}          "Invalid request"
+// @Failure      403      {object}  map[string]interface{}          "Forbidden: superadmin only"
+// @Router       /_chaos/faults [post]
+func (h *ChaosHandler) InjectFault(c *gin.Context) {
+	var req models.CreateChaosFaultRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	fault, err := h.chaos.Add(&req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.audit(c, models.AuditActionChaosInject, fault)
+
+	utils.CreatedResponse(c, "fault injected", fault)
+}
+
+// RemoveFault godoc
+// @Summary      Remove an injected fault
+// @Description  Deactivate a fault (superadmin only, non-production only)
+// @Tags         chaos
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      string                  true  "Fault ID"
+// @Success      200  {object}  map[string]interface{}  "Fault removed"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden: superadmin only"
+// @Failure      404  {object}  map[string]interface{}  "Fault not found"
+// @Router       /_chaos/faults/{id} [delete]
+func (h *ChaosHandler) RemoveFault(c *gin.Context) {
+	id := c.Param("id")
+	if !h.chaos.Remove(id) {
+		utils.ErrorResponse(c, http.StatusNotFound, "fault not found")
+		return
+	}
+	h.audit(c, models.AuditActionChaosRemove, gin.H{"id": id})
+
+	utils.MessageResponse(c, "fault removed", nil)
+}
+
+// ClearFaults godoc
+// @Summary      Remove all injected faults
+// @Description  Deactivate every fault (superadmin only, non-production only)
+// @Tags         chaos
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  map[string]interface{}  "Faults removed"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden: superadmin only"
+// @Router       /_chaos/faults [delete]
+func (h *ChaosHandler) ClearFaults(c *gin.Context) {
+	removed := h.chaos.Clear()
+	h.audit(c, models.AuditActionChaosRemove, gin.H{"removed": removed})
+
+	utils.MessageResponse(c, "faults removed", gin.H{"removed": removed})
+}
+
+// ExhaustPool godoc
+// @Summary      Exhaust the database pool
+// @Description  Hold the primary database connections for duration_seconds so other queries wait
+// @Description  (superadmin only, non-production only). Unlimited pools are limited to connections meanwhile.
+// @Tags         chaos
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      models.ExhaustPoolRequest  true  "Duration"
+// @Success      200      {object}  models.ChaosPoolReport     "Connections held"
+// @Failure      400      {object}  map[string]interface{}     "Invalid request"
+// @Failure      403      {object}  map[string]interface{}     "Forbidden: superadmin only"
+// @Failure      409      {object}  map[string]interface{}     "Pool already exhausted"
+// @Router       /_chaos/db-exhaust [post]
+func (h *ChaosHandler) ExhaustPool(c *gin.Context) {
+	var req models.ExhaustPoolRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	report, err := h.chaos.ExhaustPool(ctx, time.Duration(req.DurationSeconds)*time.Second, req.Connections)
+	if err != nil {
+		if errors.Is(err, middleware.ErrPoolBusy) {
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(c, models.AuditActionChaosExhaustPool, report)
+
+	utils.MessageResponse(c, "database pool exhausted", report)
+}
+
+// audit records a chaos action of the authenticated user
+func (h *ChaosHandler) audit(c *gin.Context, action models.AuditAction, details interface{}) {
+	var actorID *models.ID
+	if id, ok := c.Get("user_id"); ok {
+		if uid, ok := id.(uint); ok {
+			actorID = models.IDPtr(&uid)
+		}
+	}
+	h.auditService.LogAction(c, actorID, action, models.AuditResourceSystem, nil, details, true, "")
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigHandler serves the effective configuration of the process
+type ConfigHandler struct {
+	settings map[string]configs.Setting
+}
+
+// NewConfigHandler creates a new config handler. settings must already be
+// redacted, as returned by configs.Effective.
+func NewConfigHandler(settings map[string]configs.Setting) *ConfigHandler {
+	return &ConfigHandler{settings: settings}
+}
+
+// GetConfig godoc
+// @Summary      Get effective configuration
+// @Description  Get every configuration key with the value the process runs with and its source (default, file or env).
+// @Description  Secrets are redacted; unset secrets are shown empty (superadmin only)
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  map[string]interface{}  "Effective configuration"
+// @Failure      401  {object}  map[string]interface{}  "Unauthorized"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden"
+// @Router       /admin/config [get]
+func (h *ConfigHandler) GetConfig(c *gin.Context) {
+	utils.SuccessResponse(c, h.settings)
+}
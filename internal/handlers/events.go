@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publishEvent sends event through publisher, taking ActorID from the authenticated
+// user if unset. Delivery is best-effort: failures are logged and never fail the request.
+func publishEvent(c *gin.Context, publisher events.Publisher, event events.Event) {
+	if event.ActorID == 0 {
+		if id, ok := c.Get("user_id"); ok {
+			event.ActorID, _ = id.(uint)
+		}
+	}
+
+	if err := publisher.Publish(c.Request.Context(), event); err != nil {
+		logger.Warn("Failed to publish event", "type", event.Type, "error", err)
+	}
+}
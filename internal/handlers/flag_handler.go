@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"Go-Lang-project-01/internal/flags"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlagHandler manages feature flags
+type FlagHandler struct {
+	service      *flags.Service
+	auditService *services.AuditService
+}
+
+// NewFlagHandler creates a new feature flag handler
+func NewFlagHandler(service *flags.Service, auditService *services.AuditService) *FlagHandler {
+	return &FlagHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+// ListFlags godoc
+// @Summary      List feature flags
+// @Description  List every feature flag (admin only)
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {array}   models.FeatureFlag      "Feature flags"
+// @Failure      401  {object}  map[string]interface{}  "Unauthorized"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden"
+// @Router       /admin/flags [get]
+func (h *FlagHandler) ListFlags(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	list, err := h.service.List(ctx)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to list feature flags")
+		return
+	}
+	utils.SuccessResponse(c, list)
+}
+
+// GetFlag godoc
+// @Summary      Get feature flag
+// @Description  Get a feature flag by name (admin only)
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        name  path      string                  true  "Flag name"
+// @Success      200   {object}  models.FeatureFlag      "Feature flag"
+// @Failure      403   {object}  map[string]interface{}  "Forbidden"
+// @Failure      404   {object}  map[string]interface{}  "Flag not found"
+// @Router       /admin/flags/{name} [get]
+func (h *FlagHandler) GetFlag(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	flag, err := h.service.Get(ctx, c.Param("name"))
+	if err != nil {
+		h.errorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, flag)
+}
+
+// CreateFlag godoc
+// @Summary      Create feature flag
+// @Description  Create a feature flag (admin only). It is on for users whose role is in roles (all roles
+// @Description  if empty) and who fall within the first percentage percent of the rollout (default 100).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      models.CreateFeatureFlagRequest  true  "Feature flag"
+// @Success      201      {object}  models.FeatureFlag               "Flag created"
+// @Failure      400      {object}  map[string]interface{}           "Invalid request"
+// @Failure      403      {object}  map[string]interface{}           "Forbidden"
+// @Failure      409      {object}  map[string]interface{}           "Flag already exists"
+// @Router       /admin/flags [post]
+func (h *FlagHandler) CreateFlag(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req models.CreateFeatureFlagRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	flag, err := h.service.Create(ctx, &req)
+	if err != nil {
+		h.errorResponse(c, err)
+		return
+	}
+	h.logChange(c, models.AuditActionFlagCreate, flag.ID, models.FeatureFlagChange{Name: flag.Name, After: flag})
+
+	utils.CreatedResponse(c, "feature flag created successfully", flag)
+}
+
+// UpdateFlag godoc
+// @Summary      Update feature flag
+// @Description  Update the given fields of a feature flag (admin only). Other instances apply the change
+// @Description  within flags.cachettl.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        name     path      string                           true  "Flag name"
+// @Param        request  body      models.UpdateFeatureFlagRequest  true  "Fields to change"
+// @Success      200      {object}  models.FeatureFlag               "Flag updated"
+// @Failure      400      {object}  map[string]interface{}           "Invalid request"
+// @Failure      403      {object}  map[string]interface{}           "Forbidden"
+// @Failure      404      {object}  map[string]interface{}           "Flag not found"
+// @Router       /admin/flags/{name} [put]
+func (h *FlagHandler) UpdateFlag(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req models.UpdateFeatureFlagRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	before, after, err := h.service.Update(ctx, c.Param("name"), &req)
+	if err != nil {
+		h.errorResponse(c, err)
+		return
+	}
+	h.logChange(c, models.AuditActionFlagUpdate, after.ID, models.FeatureFlagChange{Name: after.Name, Before: before, After: after})
+
+	utils.MessageResponse(c, "feature flag updated successfully", after)
+}
+
+// DeleteFlag godoc
+// @Summary      Delete feature flag
+// @Description  Delete a feature flag (admin only). Checks of a deleted flag report it as off.
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        name  path      string                  true  "Flag name"
+// @Success      200   {object}  map[string]interface{}  "Flag deleted"
+// @Failure      403   {object}  map[string]interface{}  "Forbidden"
+// @Failure      404   {object}  map[string]interface{}  "Flag not found"
+// @Router       /admin/flags/{name} [delete]
+func (h *FlagHandler) DeleteFlag(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	flag, err := h.service.Delete(ctx, c.Param("name"))
+	if err != nil {
+		h.errorResponse(c, err)
+		return
+	}
+	h.logChange(c, models.AuditActionFlagDelete, flag.ID, models.FeatureFlagChange{Name: flag.Name, Before: flag})
+
+	utils.MessageResponse(c, "feature flag deleted successfully", nil)
+}
+
+// logChange writes a flag change to the audit log as the authenticated user
+func (h *FlagHandler) logChange(c *gin.Context, action models.AuditAction, flagID models.ID, change models.FeatureFlagChange) {
+	var actorID *models.ID
+	if id, ok := c.Get("user_id"); ok {
+		if uid, ok := id.(uint); ok {
+			actorID = models.IDPtr(&uid)
+		}
+	}
+	h.auditService.LogAction(c, actorID, action, models.AuditResourceFlag, &flagID, &change, true, "")
+}
+
+// errorResponse maps flag service errors to status codes
+func (h *FlagHandler) errorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, flags.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, flags.ErrExists):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to access feature flags")
+	}
+}
@@ -1,68 +1,115 @@
 package handlers
 
 import (
-	"context"
+	"crypto/subtle"
 	"net/http"
-	"time"
 
 	"Go-Lang-project-01/internal/health"
+	"Go-Lang-project-01/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
+// HealthTokenHeader carries the shared secret that unlocks detailed health output
+const HealthTokenHeader = "X-Health-Token"
+
 // HealthHandler handles health check endpoints
 type HealthHandler struct {
 	healthService *health.HealthService
+	detailToken   string
 }
 
-// NewHealthHandler creates a new health handler
-func NewHealthHandler(healthService *health.HealthService) *HealthHandler {
+// NewHealthHandler creates a new health handler.
+// detailToken may be empty, in which case only admins can see component details.
+func NewHealthHandler(healthService *health.HealthService, detailToken string) *HealthHandler {
 	return &HealthHandler{
 		healthService: healthService,
+		detailToken:   detailToken,
 	}
 }
 
 // HealthCheck godoc
-// @Summary      Enhanced health check
-// @Description  Check service health including all components (database, disk, memory)
+// @Summary      Liveness check
+// @Description  Liveness probe: 503 only when the process itself is broken (liveness checks such
+// @Description  as memory), not when a dependency is down. Admins and callers sending a valid
+// @Description  X-Health-Token header also get every component and system info.
 // @Tags         health
 // @Accept       json
 // @Produce      json
+// @Param        X-Health-Token  header  string  false  "Health detail token"
 // @Success      200  {object}  health.HealthResponse
 // @Failure      503  {object}  health.HealthResponse
 // @Router       /health [get]
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-
-	healthResp := h.healthService.CheckHealth(ctx)
-
-	// Use tagged switch for cleaner status code determination
-	var statusCode int
-	switch healthResp.Status {
-	case health.StatusUnhealthy:
-		statusCode = http.StatusServiceUnavailable
-	case health.StatusDegraded:
-		statusCode = http.StatusOK // Still return 200 for degraded
-	default:
-		statusCode = http.StatusOK // Healthy or unknown status
+	ctx := c.Request.Context()
+
+	// Probes only run the cheap liveness checks; dependencies are for /ready
+	live, _ := h.healthService.CheckLiveness(ctx)
+	statusCode := livenessStatusCode(live)
+	if !h.canViewDetails(c) {
+		c.JSON(statusCode, health.StatusResponse{Status: live})
+		return
+	}
+
+	c.JSON(statusCode, h.healthService.CheckHealth(ctx))
+}
+
+// DetailedHealthCheck is HealthCheck with component details for every caller.
+// It is only routed on the internal listener.
+func (h *HealthHandler) DetailedHealthCheck(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	live, _ := h.healthService.CheckLiveness(ctx)
+	c.JSON(livenessStatusCode(live), h.healthService.CheckHealth(ctx))
+}
+
+// livenessStatusCode is 503 when the liveness checks fail and 200 otherwise,
+// including when only dependencies or degraded components are reported
+func livenessStatusCode(status health.Status) int {
+	if status == health.StatusUnhealthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// canViewDetails reports whether the caller may see component-level health details
+func (h *HealthHandler) canViewDetails(c *gin.Context) bool {
+	if h.detailToken != "" {
+		token := c.GetHeader(HealthTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.detailToken)) == 1 {
+			return true
+		}
 	}
 
-	c.JSON(statusCode, healthResp)
+	// Role is set by OptionalAuthMiddleware from the stored user of a valid bearer token
+	role, _ := c.Value("user_role").(models.Role)
+	return role.AtLeast(models.RoleAdmin)
 }
 
 // ReadinessCheck godoc
 // @Summary      Readiness check
-// @Description  Simple readiness probe for Kubernetes/Docker (lightweight check)
+// @Description  Readiness probe for Kubernetes/Docker. Runs the checks of the dependencies
+// @Description  traffic needs (database, JWT secret and password hashing) and answers 503 when one
+// @Description  is unhealthy, so traffic is drained; details follow /health visibility rules.
 // @Tags         health
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]string
+// @Param        X-Health-Token  header  string  false  "Health detail token"
+// @Success      200  {object}  health.ReadinessResponse
+// @Failure      503  {object}  health.ReadinessResponse
 // @Router       /ready [get]
 func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
-	// Simple check - service is running
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ready",
-		"service": "Go-Lang-project-01",
-	})
+	ctx := c.Request.Context()
+
+	status, components := h.healthService.CheckReadiness(ctx)
+	if status != health.StatusUnhealthy {
+		c.JSON(http.StatusOK, health.ReadinessResponse{Status: "ready", Service: "Go-Lang-project-01"})
+		return
+	}
+
+	resp := health.ReadinessResponse{Status: "not ready", Service: "Go-Lang-project-01"}
+	if h.canViewDetails(c) {
+		resp.Components = components
+	}
+	c.JSON(http.StatusServiceUnavailable, resp)
 }
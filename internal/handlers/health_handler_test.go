@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/health"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestHealthHandler_ClosedDatabaseFailsReadinessOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	service := health.NewHealthService()
+	service.RegisterReadinessChecker("database", &health.DatabaseChecker{DB: db, Timeout: time.Second})
+	handler := NewHealthHandler(service, "token")
+
+	router := gin.New()
+	router.GET("/health", handler.HealthCheck)
+	router.GET("/ready", handler.ReadinessCheck)
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HealthTokenHeader, "token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "traffic is drained while the database is down")
+
+	w = get("/health")
+	require.Equal(t, http.StatusOK, w.Code, "the process itself is fine, so it is not restarted")
+	var resp health.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, health.StatusUnhealthy, resp.Status, "the report still shows the failing dependency")
+	assert.Equal(t, health.StatusUnhealthy, resp.Components["database"].Status)
+}
+
+func TestHealthHandler_FailingLivenessCheckFailsHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := health.NewHealthService()
+	service.RegisterLivenessChecker("memory", failingChecker{})
+	router := gin.New()
+	router.GET("/health", NewHealthHandler(service, "").HealthCheck)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"unhealthy"}`, w.Body.String())
+}
+
+// failingChecker always reports unhealthy
+type failingChecker struct{}
+
+func (failingChecker) Check(ctx context.Context) health.ComponentHealth {
+	return health.ComponentHealth{Status: health.StatusUnhealthy}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OffboardHandler handles administrative account offboarding
+type OffboardHandler struct {
+	service      *services.OffboardService
+	auditService *services.AuditService
+}
+
+// NewOffboardHandler creates a new offboard handler
+func NewOffboardHandler(service *services.OffboardService, auditService *services.AuditService) *OffboardHandler {
+	return &OffboardHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+// OffboardUser godoc
+// @Summary      Offboard user
+// @Description  Deactivate a user, revoke their tokens, scramble their password and close their
+// @Description  WebSocket connections (superadmin only). Returns a per-step report; 500 if any step failed.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int                     true  "User ID"
+// @Success      200  {object}  models.OffboardReport   "User offboarded"
+// @Failure      400  {object}  map[string]interface{}  "Invalid request"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden: superadmin only"
+// @Failure      404  {object}  map[string]interface{}  "User not found"
+// @Failure      500  {object}  models.OffboardReport   "One or more steps failed"
+// @Router       /users/{id}/offboard [post]
+func (h *OffboardHandler) OffboardUser(c *gin.Context) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+
+	actorIDInterface, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	actorID := actorIDInterface.(uint)
+
+	ctx := c.Request.Context()
+
+	report, err := h.service.OffboardUser(ctx, actorID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrCannotOffboardSelf) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	}
+
+	errorMsg := ""
+	if !report.Success {
+		errorMsg = "one or more offboarding steps failed"
+	}
+	h.auditService.LogUserAction(c, actorID, models.AuditActionUserOffboard, id, report, report.Success, errorMsg)
+
+	if !report.Success {
+		utils.ErrorDataResponse(c, http.StatusInternalServerError, errorMsg, report)
+		return
+	}
+
+	utils.MessageResponse(c, "user offboarded successfully", report)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"Go-Lang-project-01/internal/middleware"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ThrottleHandler reports the state of the concurrency limiters
+type ThrottleHandler struct {
+	perUser  *middleware.UserConcurrencyLimiter
+	limiters []*middleware.ConcurrencyLimiter
+}
+
+// NewThrottleHandler creates a new throttle handler. perUser may be nil.
+func NewThrottleHandler(perUser *middleware.UserConcurrencyLimiter, limiters ...*middleware.ConcurrencyLimiter) *ThrottleHandler {
+	return &ThrottleHandler{perUser: perUser, limiters: limiters}
+}
+
+// GetStats godoc
+// @Summary      Get throttle statistics
+// @Description  Get the in-flight and rejected request counts of each concurrency-limited endpoint group and of the per-user limit (admin only)
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  map[string]interface{}  "Throttle statistics"
+// @Failure      401  {object}  map[string]interface{}  "Unauthorized"
+// @Failure      403  {object}  map[string]interface{}  "Forbidden"
+// @Router       /admin/throttle [get]
+func (h *ThrottleHandler) GetStats(c *gin.Context) {
+	groups := make([]map[string]interface{}, 0, len(h.limiters))
+	for _, limiter := range h.limiters {
+		groups = append(groups, limiter.Stats())
+	}
+
+	response := gin.H{
+		"groups": groups,
+	}
+	if h.perUser != nil {
+		response["per_user"] = h.perUser.Stats()
+	}
+	utils.SuccessResponse(c, response)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUsageDays is the report window when ?days is not given
+const defaultUsageDays = 7
+
+// UsageHandler serves per-user API usage reports
+type UsageHandler struct {
+	service *services.UsageService
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(service *services.UsageService) *UsageHandler {
+	return &UsageHandler{service: service}
+}
+
+// GetMyUsage godoc
+// @Summary      Get own API usage
+// @Description  Request, error and rate-limit rejection counts of the authenticated user, per bucket
+// @Description  (daily by default). Counts are recorded after authentication, so requests rejected
+// @Description  by the per-IP rate limiter are not attributed to a user.
+// @Tags         profile
+// @Produce      json
+// @Security     Bearer
+// @Param        days  query     int                     false  "Window in days, today included (default: 7)"
+// @Success      200   {object}  models.UsageReport      "Usage report"
+// @Failure      400   {object}  map[string]interface{}  "Invalid days"
+// @Failure      401   {object}  map[string]interface{}  "Unauthorized"
+// @Router       /users/me/usage [get]
+func (h *UsageHandler) GetMyUsage(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.report(c, userIDInterface.(uint))
+}
+
+// GetUserUsage godoc
+// @Summary      Get a user's API usage
+// @Description  Same report as /users/me/usage for any user (admin only)
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id    path      int                     true   "User ID"
+// @Param        days  query     int                     false  "Window in days, today included (default: 7)"
+// @Success      200   {object}  models.UsageReport      "Usage report"
+// @Failure      400   {object}  map[string]interface{}  "Invalid request"
+// @Failure      403   {object}  map[string]interface{}  "Forbidden: admin only"
+// @Router       /users/{id}/usage [get]
+func (h *UsageHandler) GetUserUsage(c *gin.Context) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+	h.report(c, id)
+}
+
+// report renders the usage of userID over the ?days window
+func (h *UsageHandler) report(c *gin.Context, userID uint) {
+	days := defaultUsageDays
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			utils.ErrorDataResponse(c, http.StatusBadRequest, "Validation failed", []models.ValidationError{{
+				Field:   "days",
+				Message: "days must be a positive integer",
+			}})
+			return
+		}
+		days = parsed
+	}
+
+	ctx := c.Request.Context()
+
+	report, err := h.service.Report(ctx, userID, days)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUsageWindow) {
+			utils.ErrorDataResponse(c, http.StatusBadRequest, "Validation failed", []models.ValidationError{{
+				Field:   "days",
+				Message: err.Error(),
+			}})
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to retrieve usage")
+		return
+	}
+
+	utils.SuccessResponse(c, report)
+}
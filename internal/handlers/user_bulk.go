@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// BulkDeleteUsers godoc
+// @Summary      Bulk delete users
+// @Description  Soft delete up to 100 users in one transaction. The request fails if it lists the requester. Users that do not exist, are listed twice or rank above the requester fail on their own; the response lists the deleted and the failed IDs in request order.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      models.BulkDeleteUsersRequest  true  "IDs of the users to delete"
+// @Success      200      {object}  models.BulkUserResultResponse  "All users deleted"
+// @Success      207      {object}  models.BulkUserResultResponse  "Some users deleted; the others are listed in failed"
+// @Failure      400      {object}  models.ErrorResponse           "Invalid request, own ID listed, or no user could be deleted"
+// @Failure      403      {object}  models.ErrorResponse           "Forbidden: admin only"
+// @Failure      500      {object}  models.ErrorResponse           "Internal server error"
+// @Router       /users [delete]
+func (h *UserHandler) BulkDeleteUsers(c *gin.Context) {
+	var req models.BulkDeleteUsersRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	actorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+	actorID := c.GetUint("user_id")
+
+	ids := make([]uint, len(req.IDs))
+	for i, id := range req.IDs {
+		ids[i] = uint(id)
+	}
+
+	ctx := c.Request.Context()
+
+	result, err := h.service.BulkDelete(ctx, actorID, actorRole, ids)
+	switch {
+	case errors.Is(err, services.ErrDeleteSelf):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to delete users")
+		return
+	}
+
+	for _, id := range result.Succeeded {
+		h.auditService.LogUserAction(c, actorID, models.AuditActionUserBulkDelete, uint(id), nil, true, "")
+		publishEvent(c, h.publisher, events.Event{
+			Type:     events.UserDeleted,
+			TargetID: uint(id),
+		})
+	}
+
+	bulkResponse(c, "deleted", result, len(ids))
+}
+
+// BulkUpdateRoles godoc
+// @Summary      Bulk update user roles
+// @Description  Set the roles of up to 100 users in one transaction (superadmin only). The body is an array of {"id", "role"}. Users that do not exist or are listed twice fail on their own, as does demoting yourself; the response lists the changed and the failed IDs in request order.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      []models.RoleAssignment  true  "New role of each user"
+// @Success      200      {object}  models.BulkUserResultResponse  "All roles updated"
+// @Success      207      {object}  models.BulkUserResultResponse  "Some roles updated; the others are listed in failed"
+// @Failure      400      {object}  models.ErrorResponse           "Invalid request, or no role could be updated"
+// @Failure      403      {object}  models.ErrorResponse           "Forbidden: superadmin only"
+// @Failure      500      {object}  models.ErrorResponse           "Internal server error"
+// @Router       /users/roles [put]
+func (h *UserHandler) BulkUpdateRoles(c *gin.Context) {
+	// Gin skips dive validation on a top-level slice, so the array is
+	// validated through the wrapper
+	var req models.BulkUpdateRolesRequest
+	if err := utils.BindJSON(c, &req.Users); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	actorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+	actorID := c.GetUint("user_id")
+
+	ctx := c.Request.Context()
+
+	result, changes, err := h.service.BulkUpdateRoles(ctx, actorID, actorRole, req.Users)
+	switch {
+	case errors.Is(err, services.ErrRoleChangeNotAllowed):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to update roles")
+		return
+	}
+
+	for _, change := range changes {
+		h.auditService.LogUserAction(c, actorID, models.AuditActionBulkRoleChange, uint(change.UserID), &change, true, "")
+		h.disconnectIfDemoted(uint(change.UserID), change.From, change.To)
+		publishEvent(c, h.publisher, events.Event{
+			Type:     events.UserRoleChanged,
+			ActorID:  actorID,
+			TargetID: uint(change.UserID),
+			Payload: map[string]interface{}{
+				"old_role": change.From,
+				"new_role": change.To,
+			},
+		})
+	}
+
+	bulkResponse(c, "updated", result, len(req.Users))
+}
+
+// bulkResponse answers a bulk change with 200 if every user succeeded, 400
+// if none did, or 207 otherwise
+func bulkResponse(c *gin.Context, verb string, result *models.BulkUserResult, total int) {
+	switch {
+	case len(result.Failed) == 0:
+		utils.MessageResponse(c, fmt.Sprintf("%s %d users", verb, total), result)
+	case len(result.Succeeded) == 0:
+		utils.ErrorDataResponse(c, http.StatusBadRequest, "no users were "+verb, result)
+	default:
+		utils.MultiStatusResponse(c, fmt.Sprintf("%s %d of %d users", verb, len(result.Succeeded), total), result)
+	}
+}
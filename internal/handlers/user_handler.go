@@ -1,27 +1,91 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"strconv"
-	"time"
 
 	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/middleware"
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
 	"Go-Lang-project-01/internal/services"
 	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// UserServiceInterface is the user business logic UserHandler calls.
+// *services.UserService is the implementation.
+type UserServiceInterface interface {
+	GetAllUsersPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, models.PaginationMeta, error)
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	GetUserForUpdate(ctx context.Context, id uint) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserStats(ctx context.Context) (map[string]interface{}, error)
+	CreateUser(ctx context.Context, creatorRole models.Role, req *models.CreateUserRequest) (*models.User, error)
+	BatchCreateUsers(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest) (*models.BatchCreateUsersResult, error)
+	BatchCreateUserResults(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest) []services.BatchCreateResult
+	UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error)
+	UpdateUserRole(ctx context.Context, userID uint, newRole models.Role) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.User, error)
+	SetUserActive(ctx context.Context, id uint, active bool) (*models.User, error)
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error
+	AdminResetPassword(ctx context.Context, id uint, newPassword string) (string, error)
+	DeleteUser(ctx context.Context, id uint) error
+	ListDeletedUsers(ctx context.Context, query models.DeletedUserQuery) ([]models.DeletedUser, models.PaginationMeta, error)
+	RestoreUser(ctx context.Context, id uint) (*models.User, error)
+	PurgeUser(ctx context.Context, id uint) error
+	BulkDelete(ctx context.Context, actorID uint, actorRole models.Role, ids []uint) (*models.BulkUserResult, error)
+	BulkUpdateRoles(ctx context.Context, actorID uint, actorRole models.Role, assignments []models.RoleAssignment) (*models.BulkUserResult, []models.RoleChange, error)
+}
+
 // UserHandler handles HTTP requests
 type UserHandler struct {
-	service *services.UserService
+	service      UserServiceInterface
+	publisher    events.Publisher
+	auditService *services.AuditService
+	sessions     services.SessionRevoker // nil when no live session registry is available
+	imports      UserImportConfig
 }
 
-// NewUserHandler creates a new user handler
-func NewUserHandler(service *services.UserService) *UserHandler {
-	return &UserHandler{service: service}
+// NewUserHandler creates a new user handler. The live sessions of users who
+// are deactivated or demoted are closed through sessions, which may be nil.
+func NewUserHandler(service UserServiceInterface, publisher events.Publisher, auditService *services.AuditService, sessions services.SessionRevoker, imports UserImportConfig) *UserHandler {
+	if imports.MaxBytes <= 0 {
+		imports.MaxBytes = 1 << 20
+	}
+	if imports.MaxRows <= 0 {
+		imports.MaxRows = 1000
+	}
+	return &UserHandler{
+		service:      service,
+		publisher:    publisher,
+		auditService: auditService,
+		sessions:     sessions,
+		imports:      imports,
+	}
+}
+
+// disconnectSessions closes the live sessions of a user whose access shrank,
+// so they stop receiving events they may no longer see
+func (h *UserHandler) disconnectSessions(userID uint, reason string) {
+	if h.sessions != nil {
+		h.sessions.DisconnectUser(userID, reason)
+	}
+}
+
+// disconnectIfDemoted closes the live sessions of a user whose role was lowered
+func (h *UserHandler) disconnectIfDemoted(userID uint, from, to models.Role) {
+	if !to.AtLeast(from) {
+		h.disconnectSessions(userID, fmt.Sprintf("role changed from %s to %s", from, to))
+	}
 }
 
 // GetAllUsers godoc
@@ -34,15 +98,17 @@ func NewUserHandler(service *services.UserService) *UserHandler {
 // @Param        limit    query     int     false  "Items per page (default: 10)"
 // @Param        sort     query     string  false  "Sort field (default: created_at)"
 // @Param        order    query     string  false  "Sort order: asc or desc (default: desc)"
-// @Param        search   query     string  false  "Search in name and email"
+// @Param        search   query     string  false  "Search in name and email; a number also matches the ID, an exact email only that user"
 // @Param        active   query     bool    false  "Filter by active status"
-// @Success      200      {object}  map[string]interface{}  "List of users with pagination metadata"
-// @Failure      400      {object}  map[string]interface{}  "Invalid query parameters"
-// @Failure      500      {object}  map[string]interface{}  "Internal server error"
+// @Param        role     query     string  false  "Filter by role (user, admin or superadmin)"
+// @Param        If-None-Match  header  string  false  "ETag of a previous response"
+// @Success      200      {object}  models.UserListResponse  "List of users with pagination metadata"
+// @Success      304      "Page unchanged since the given ETag"
+// @Failure      400      {object}  models.ErrorResponse     "Invalid query parameters"
+// @Failure      500      {object}  models.ErrorResponse     "Internal server error"
 // @Router       /users [get]
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Parse and validate query parameters
 	var query models.PaginationQuery
@@ -53,11 +119,18 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 
 	// Get paginated users
 	users, meta, err := h.service.GetAllUsersPaginated(ctx, query)
+	if errors.Is(err, repository.ErrInvalidSort) {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if utils.NotModified(c, users, meta) {
+		return
+	}
 	utils.PaginatedResponse(c, users, meta)
 }
 
@@ -68,57 +141,112 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int                     true  "User ID"
-// @Success      200  {object}  map[string]interface{}  "User found"
-// @Failure      400  {object}  map[string]interface{}  "Invalid user ID"
-// @Failure      404  {object}  map[string]interface{}  "User not found"
-// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Param        If-None-Match  header  string  false  "ETag of a previous response"
+// @Success      200  {object}  models.UserResponse   "User found"
+// @Success      304  "User unchanged since the given ETag"
+// @Failure      400  {object}  models.ErrorResponse  "Invalid user ID"
+// @Failure      404  {object}  models.ErrorResponse  "User not found"
+// @Failure      500  {object}  models.ErrorResponse  "Internal server error"
 // @Router       /users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid user id")
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
 		return
 	}
 
-	user, err := h.service.GetUserByID(ctx, uint(id))
+	user, err := h.service.GetUserByID(ctx, id)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
 		return
 	}
 
+	if utils.NotModified(c, user) {
+		return
+	}
+	utils.SuccessResponse(c, user)
+}
+
+// GetUserByEmail godoc
+// @Summary      Get user by email
+// @Description  Look up the user with exactly this email (admin only). Use the search parameter of GET /users for partial matches.
+// @Tags         users
+// @Produce      json
+// @Param        email          query   string  true   "Exact email"
+// @Param        If-None-Match  header  string  false  "ETag of a previous response"
+// @Success      200    {object}  models.UserResponse   "User found"
+// @Success      304    "User unchanged since the given ETag"
+// @Failure      400    {object}  models.ErrorResponse  "Missing or invalid email"
+// @Failure      403    {object}  models.ErrorResponse  "Admin only"
+// @Failure      404    {object}  models.ErrorResponse  "User not found"
+// @Failure      500    {object}  models.ErrorResponse  "Internal server error"
+// @Router       /users/by-email [get]
+func (h *UserHandler) GetUserByEmail(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.UserByEmailQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	user, err := h.service.GetUserByEmail(ctx, query.Email)
+	if errors.Is(err, services.ErrUserNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to look up user")
+		return
+	}
+
+	if utils.NotModified(c, user) {
+		return
+	}
 	utils.SuccessResponse(c, user)
 }
 
 // CreateUser godoc
 // @Summary      Create new user
-// @Description  Create a new user with the provided information
+// @Description  Create a new user with the provided information. Only superadmins can create superadmins.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        request  body      models.CreateUserRequest  true  "User creation request"
-// @Success      201      {object}  map[string]interface{}    "User created successfully"
-// @Failure      400      {object}  map[string]interface{}    "Invalid request body"
-// @Failure      500      {object}  map[string]interface{}    "Internal server error"
+// @Success      201      {object}  models.UserResponse   "User created successfully"
+// @Failure      400      {object}  models.ErrorResponse  "Invalid request body"
+// @Failure      403      {object}  models.ErrorResponse  "Role above the requester's"
+// @Failure      409      {object}  models.ErrorResponse  "Email already exists"
+// @Failure      500      {object}  models.ErrorResponse  "Internal server error"
 // @Router       /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	var req models.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	user, err := h.service.CreateUser(ctx, &req)
+	creatorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.CreateUser(ctx, creatorRole, &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.ErrorResponse(c, createErrorStatus(err), err.Error())
 		return
 	}
 
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserCreated,
+		TargetID: uint(user.ID),
+		Payload:  map[string]interface{}{"user": user},
+	})
+
+	c.Set(middleware.AuditResourceIDKey, user.ID)
 	utils.CreatedResponse(c, "user created successfully", user)
 }
 
@@ -130,38 +258,39 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Produce      json
 // @Param        id       path      int                       true  "User ID"
 // @Param        request  body      models.UpdateUserRequest  true  "User update request"
-// @Success      200      {object}  map[string]interface{}    "User updated successfully"
-// @Failure      400      {object}  map[string]interface{}    "Invalid request"
-// @Failure      404      {object}  map[string]interface{}    "User not found"
-// @Failure      500      {object}  map[string]interface{}    "Internal server error"
+// @Success      200      {object}  models.UserResponse   "User updated successfully"
+// @Failure      400      {object}  models.ErrorResponse  "Invalid request"
+// @Failure      404      {object}  models.ErrorResponse  "User not found"
+// @Failure      409      {object}  models.ErrorResponse  "Email already exists"
+// @Failure      500      {object}  models.ErrorResponse  "Internal server error"
 // @Router       /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid user id")
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
 		return
 	}
 
 	var req models.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	user, err := h.service.UpdateUser(ctx, uint(id), &req)
+	user, err := h.service.UpdateUser(ctx, id, &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.ErrorResponse(c, createErrorStatus(err), err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Message: "user updated successfully",
-		Data:    user,
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserUpdated,
+		TargetID: uint(user.ID),
+		Payload:  map[string]interface{}{"user": user},
 	})
+
+	utils.MessageResponse(c, "user updated successfully", user)
 }
 
 // DeleteUser godoc
@@ -171,78 +300,311 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int                     true  "User ID"
-// @Success      200  {object}  map[string]interface{}  "User deleted successfully"
-// @Failure      400  {object}  map[string]interface{}  "Invalid user ID"
-// @Failure      404  {object}  map[string]interface{}  "User not found"
-// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Success      200  {object}  models.MessageResponse  "User deleted successfully"
+// @Failure      400  {object}  models.ErrorResponse    "Invalid user ID"
+// @Failure      404  {object}  models.ErrorResponse    "User not found"
+// @Failure      500  {object}  models.ErrorResponse    "Internal server error"
 // @Router       /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid user id")
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
 		return
 	}
 
-	if err := h.service.DeleteUser(ctx, uint(id)); err != nil {
+	if err := h.service.DeleteUser(ctx, id); err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Message: "user deleted successfully",
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserDeleted,
+		TargetID: id,
 	})
+
+	utils.MessageResponse(c, "user deleted successfully", nil)
+}
+
+// ListDeletedUsers godoc
+// @Summary      List deleted users
+// @Description  Soft-deleted users, most recently deleted first (admin only). Their emails stay taken
+// @Description  until they are purged.
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        page   query     int  false  "Page number (default: 1)"
+// @Param        limit  query     int  false  "Items per page (default: 10)"
+// @Success      200    {object}  models.DeletedUserListResponse  "Deleted users with pagination metadata"
+// @Failure      400    {object}  models.ErrorResponse            "Invalid query parameters"
+// @Failure      403    {object}  models.ErrorResponse            "Forbidden: admin only"
+// @Failure      500    {object}  models.ErrorResponse            "Internal server error"
+// @Router       /users/deleted [get]
+func (h *UserHandler) ListDeletedUsers(c *gin.Context) {
+	var query models.DeletedUserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	users, meta, err := h.service.ListDeletedUsers(c.Request.Context(), query)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to get deleted users")
+		return
+	}
+
+	utils.PaginatedResponse(c, users, meta)
+}
+
+// RestoreUser godoc
+// @Summary      Restore user
+// @Description  Undo the soft delete of a user (admin only). Tokens issued before the restore stay
+// @Description  rejected, so the user has to log in again.
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int                   true  "User ID"
+// @Success      200  {object}  models.UserResponse   "User restored"
+// @Failure      400  {object}  models.ErrorResponse  "Invalid user ID"
+// @Failure      403  {object}  models.ErrorResponse  "Forbidden: admin only"
+// @Failure      404  {object}  models.ErrorResponse  "No deleted user with this ID"
+// @Failure      500  {object}  models.ErrorResponse  "Internal server error"
+// @Router       /users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.service.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDeletedUserNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to restore user")
+		return
+	}
+
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserUpdated,
+		TargetID: id,
+		Payload:  map[string]interface{}{"user": user},
+	})
+
+	utils.MessageResponse(c, "user restored successfully", user)
+}
+
+// PurgeUser godoc
+// @Summary      Purge user
+// @Description  Permanently delete a soft-deleted user with their refresh tokens and usage counters,
+// @Description  freeing their email (superadmin only). Audit logs are kept. Delete the user first.
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int                     true  "User ID"
+// @Success      200  {object}  models.MessageResponse  "User purged"
+// @Failure      400  {object}  models.ErrorResponse    "Invalid user ID"
+// @Failure      403  {object}  models.ErrorResponse    "Forbidden: superadmin only"
+// @Failure      404  {object}  models.ErrorResponse    "No deleted user with this ID"
+// @Failure      500  {object}  models.ErrorResponse    "Internal server error"
+// @Router       /users/{id}/purge [delete]
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.PurgeUser(c.Request.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrDeletedUserNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to purge user")
+		return
+	}
+
+	utils.MessageResponse(c, "user purged successfully", nil)
+}
+
+// DeactivateUser godoc
+// @Summary      Deactivate user
+// @Description  Deactivate a user (admin only). The user can no longer log in and their tokens are
+// @Description  rejected, but the account is kept and can be reactivated. Admins cannot deactivate
+// @Description  themselves or users ranking above them.
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int                     true  "User ID"
+// @Success      200  {object}  models.UserResponse   "User deactivated"
+// @Failure      400  {object}  models.ErrorResponse  "Invalid user ID or own account"
+// @Failure      403  {object}  models.ErrorResponse  "Forbidden: admin only, or target ranks above the requester"
+// @Failure      404  {object}  models.ErrorResponse  "User not found"
+// @Failure      500  {object}  models.ErrorResponse  "Internal server error"
+// @Router       /users/{id}/deactivate [put]
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// ActivateUser godoc
+// @Summary      Activate user
+// @Description  Reactivate a deactivated user (admin only)
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int                     true  "User ID"
+// @Success      200  {object}  models.UserResponse   "User activated"
+// @Failure      400  {object}  models.ErrorResponse  "Invalid user ID"
+// @Failure      403  {object}  models.ErrorResponse  "Forbidden: admin only, or target ranks above the requester"
+// @Failure      404  {object}  models.ErrorResponse  "User not found"
+// @Failure      500  {object}  models.ErrorResponse  "Internal server error"
+// @Router       /users/{id}/activate [put]
+func (h *UserHandler) ActivateUser(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// setActive implements DeactivateUser and ActivateUser
+func (h *UserHandler) setActive(c *gin.Context, active bool) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+	actorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+	actorID := c.GetUint("user_id")
+
+	action, verb := models.AuditActionUserActivate, "activated"
+	if !active {
+		action, verb = models.AuditActionUserDeactivate, "deactivated"
+	}
+	fail := func(status int, message string) {
+		h.auditService.LogUserAction(c, actorID, action, id, nil, false, message)
+		utils.ErrorResponse(c, status, message)
+	}
+
+	if !active && id == actorID {
+		fail(http.StatusBadRequest, "cannot deactivate yourself")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	target, err := h.service.GetUserForUpdate(ctx, id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	}
+	if !actorRole.AtLeast(target.Role) {
+		fail(http.StatusForbidden, "cannot change the status of a user ranking above you")
+		return
+	}
+
+	user, err := h.service.SetUserActive(ctx, id, active)
+	if err != nil {
+		fail(http.StatusInternalServerError, "failed to update user status")
+		return
+	}
+	h.auditService.LogUserAction(c, actorID, action, id, nil, true, "")
+	if !active {
+		h.disconnectSessions(id, "account deactivated")
+	}
+
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserUpdated,
+		TargetID: id,
+		Payload:  map[string]interface{}{"user": user},
+	})
+
+	utils.MessageResponse(c, "user "+verb+" successfully", user)
 }
 
 // BatchCreateUsers godoc
 // @Summary      Batch create users
-// @Description  Create multiple users in a single request
+// @Description  Create up to 100 users in a single request. A bare array of users is still accepted in place of {"users": [...]}. Every user is validated before any is created; field errors name the user by index, e.g. users[1].email. Users then succeed or fail on their own, e.g. on a taken email or one repeated earlier in the batch, and the others are inserted in one transaction. The response lists the created users and the failed ones by index, in request order.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        request  body      models.BatchCreateUsersRequest  true  "Batch user creation request"
-// @Success      201      {object}  map[string]interface{}          "Users created successfully"
-// @Failure      400      {object}  map[string]interface{}          "Invalid request body"
-// @Failure      500      {object}  map[string]interface{}          "Internal server error"
+// @Success      201      {object}  models.BatchCreateUsersResponse  "All users created"
+// @Success      207      {object}  models.BatchCreateUsersResponse  "Some users created; the others are listed in failed"
+// @Failure      400      {object}  models.ErrorResponse             "Invalid request body or user fields, or no user could be created"
+// @Failure      403      {object}  models.ErrorResponse             "A role above the requester's; nothing is created"
+// @Failure      500      {object}  models.ErrorResponse             "Internal server error"
 // @Router       /users/batch [post]
 func (h *UserHandler) BatchCreateUsers(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	var requests []*models.CreateUserRequest
-	if err := c.ShouldBindJSON(&requests); err != nil {
+	req, err := bindBatchCreateRequest(c)
+	if err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	users, err := h.service.BatchCreateUsers(ctx, requests)
+	creatorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.BatchCreateUsers(ctx, creatorRole, req.Users)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: err.Error(),
-			Data:    users,
-		})
+		utils.ErrorResponse(c, createErrorStatus(err), err.Error())
 		return
 	}
 
-	utils.CreatedResponse(c, "users created successfully", users)
+	for _, user := range result.Created {
+		publishEvent(c, h.publisher, events.Event{
+			Type:     events.UserCreated,
+			TargetID: uint(user.ID),
+			Payload:  map[string]interface{}{"user": user},
+		})
+	}
+
+	switch {
+	case len(result.Failed) == 0:
+		utils.CreatedResponse(c, "users created successfully", result)
+	case len(result.Created) == 0:
+		utils.ErrorDataResponse(c, http.StatusBadRequest, "no users were created", result)
+	default:
+		utils.MultiStatusResponse(c, fmt.Sprintf("created %d of %d users", len(result.Created), len(req.Users)), result)
+	}
+}
+
+// bindBatchCreateRequest decodes {"users": [...]} or, for older clients, a
+// bare array of users, and validates every user. Gin skips dive validation
+// on a top-level slice, so the array is validated through the wrapper.
+func bindBatchCreateRequest(c *gin.Context) (*models.BatchCreateUsersRequest, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	var req models.BatchCreateUsersRequest
+	if trimmed := bytes.TrimLeft(body, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(body, &req.Users)
+	} else {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
 }
 
 // GetUserStats godoc
 // @Summary      Get user statistics
-// @Description  Get statistics about users (total count, active count, etc.)
+// @Description  Get statistics about users (total, active and inactive counts, counts per role and users created in the last 7 days)
 // @Tags         users
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}  "User statistics"
-// @Failure      500  {object}  map[string]interface{}  "Internal server error"
+// @Success      200  {object}  models.StatsResponse  "User statistics"
+// @Failure      500  {object}  models.ErrorResponse  "Internal server error"
 // @Router       /users/stats [get]
 func (h *UserHandler) GetUserStats(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	stats, err := h.service.GetUserStats(ctx)
 	if err != nil {
@@ -262,11 +624,11 @@ func (h *UserHandler) GetUserStats(c *gin.Context) {
 // @Security     Bearer
 // @Param        id       path      int                        true   "User ID"
 // @Param        request  body      models.UpdateRoleRequest   true   "Role update request"
-// @Success      200      {object}  map[string]interface{}     "User role updated successfully"
-// @Failure      400      {object}  map[string]interface{}     "Invalid request"
-// @Failure      403      {object}  map[string]interface{}     "Forbidden: superadmin only"
-// @Failure      404      {object}  map[string]interface{}     "User not found"
-// @Failure      500      {object}  map[string]interface{}     "Internal server error"
+// @Success      200      {object}  models.UserChangeResponse  "User role updated successfully"
+// @Failure      400      {object}  models.ErrorResponse       "Invalid request"
+// @Failure      403      {object}  models.ErrorResponse       "Forbidden: superadmin only"
+// @Failure      404      {object}  models.ErrorResponse       "User not found"
+// @Failure      500      {object}  models.ErrorResponse       "Internal server error"
 // @Router       /users/{id}/role [put]
 func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 	var requestingUserID uint
@@ -292,17 +654,16 @@ func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusForbidden, "only superadmin can change user roles")
 			return
 		}
-		requestingUserID = requestingUser.ID
+		requestingUserID = uint(requestingUser.ID)
 	} else {
-		// Check role from string
-		userRole, ok := userRoleInterface.(string)
+		userRole, ok := userRoleInterface.(models.Role)
 		if !ok {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "invalid role type")
 			return
 		}
 
 		// Only superadmin can change roles
-		if models.Role(userRole) != models.RoleSuperAdmin {
+		if userRole != models.RoleSuperAdmin {
 			utils.ErrorResponse(c, http.StatusForbidden, "only superadmin can change user roles")
 			return
 		}
@@ -313,46 +674,52 @@ func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 	}
 
 	// Get user ID from URL
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid user ID")
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
 		return
 	}
 
 	// Bind request
 	var req models.UpdateRoleRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Get user to update
-	user, err := h.service.GetUserByID(ctx, uint(id))
+	user, err := h.service.GetUserForUpdate(ctx, id)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "user not found")
 		return
 	}
 
 	// Prevent superadmin from demoting themselves
-	if user.ID == requestingUserID && req.Role != string(models.RoleSuperAdmin) {
+	if uint(user.ID) == requestingUserID && req.Role != models.RoleSuperAdmin {
 		utils.ErrorResponse(c, http.StatusBadRequest, "cannot demote yourself")
 		return
 	}
 
 	// Update role using service
-	updatedUser, err := h.service.UpdateUserRole(ctx, user.ID, req.Role)
+	updatedUser, err := h.service.UpdateUserRole(ctx, uint(user.ID), req.Role)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to update role")
 		return
 	}
-
-	utils.SuccessResponse(c, gin.H{
-		"message": "user role updated successfully",
-		"user":    updatedUser,
+	h.disconnectIfDemoted(uint(updatedUser.ID), user.Role, updatedUser.Role)
+
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.UserRoleChanged,
+		ActorID:  requestingUserID,
+		TargetID: uint(updatedUser.ID),
+		Payload: map[string]interface{}{
+			"old_role": user.Role,
+			"new_role": updatedUser.Role,
+		},
 	})
+
+	utils.SuccessResponse(c, models.UserChange{Message: "user role updated successfully", User: updatedUser})
 }
 
 // GetMe godoc
@@ -362,13 +729,12 @@ func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200  {object}  map[string]interface{}  "User profile"
-// @Failure      401  {object}  map[string]interface{}  "Unauthorized"
-// @Failure      404  {object}  map[string]interface{}  "User not found"
+// @Success      200  {object}  models.UserResponse   "User profile"
+// @Failure      401  {object}  models.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  models.ErrorResponse  "User not found"
 // @Router       /users/me [get]
 func (h *UserHandler) GetMe(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Get user ID from context (set by AuthMiddleware)
 	userIDInterface, exists := c.Get("user_id")
@@ -397,14 +763,13 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request  body      models.UpdateProfileRequest  true  "Profile update request"
-// @Success      200      {object}  map[string]interface{}       "Profile updated successfully"
-// @Failure      400      {object}  map[string]interface{}       "Invalid request"
-// @Failure      401      {object}  map[string]interface{}       "Unauthorized"
-// @Failure      500      {object}  map[string]interface{}       "Internal server error"
+// @Success      200      {object}  models.UserChangeResponse  "Profile updated successfully"
+// @Failure      400      {object}  models.ErrorResponse       "Invalid request"
+// @Failure      401      {object}  models.ErrorResponse       "Unauthorized"
+// @Failure      500      {object}  models.ErrorResponse       "Internal server error"
 // @Router       /users/me [put]
 func (h *UserHandler) UpdateMe(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Get user ID from context
 	userIDInterface, exists := c.Get("user_id")
@@ -417,7 +782,7 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 
 	// Bind and validate request
 	var req models.UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
@@ -429,28 +794,30 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, gin.H{
-		"message": "profile updated successfully",
-		"user":    user,
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.ProfileUpdated,
+		TargetID: uint(user.ID),
+		Payload:  map[string]interface{}{"user": user},
 	})
+
+	utils.SuccessResponse(c, models.UserChange{Message: "profile updated successfully", User: user})
 }
 
 // ChangePassword godoc
 // @Summary      Change password
-// @Description  Change authenticated user's password
+// @Description  Change authenticated user's password. The new password must differ from the current one. All refresh tokens of the user are revoked, so other sessions end when their access tokens expire.
 // @Tags         profile
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request  body      models.ChangePasswordRequest  true  "Password change request"
-// @Success      200      {object}  map[string]interface{}        "Password changed successfully"
-// @Failure      400      {object}  map[string]interface{}        "Invalid request or wrong password"
-// @Failure      401      {object}  map[string]interface{}        "Unauthorized"
-// @Failure      500      {object}  map[string]interface{}        "Internal server error"
+// @Success      200      {object}  models.MessageDataResponse  "Password changed successfully"
+// @Failure      400      {object}  models.ErrorResponse        "Invalid request, wrong password or unchanged password"
+// @Failure      401      {object}  models.ErrorResponse        "Unauthorized"
+// @Failure      500      {object}  models.ErrorResponse        "Internal server error"
 // @Router       /users/me/password [put]
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// Get user ID from context
 	userIDInterface, exists := c.Get("user_id")
@@ -463,13 +830,13 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 	// Bind and validate request
 	var req models.ChangePasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
 	// Get user to verify current password
-	user, err := h.service.GetUserByID(ctx, userID)
+	user, err := h.service.GetUserForUpdate(ctx, userID)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "user not found")
 		return
@@ -477,10 +844,18 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 	// Verify current password
 	if err := auth.CheckPassword(req.CurrentPassword, user.Password); err != nil {
+		h.auditService.LogProfileAction(c, userID, models.AuditActionPasswordChange, nil, false, "current password is incorrect")
 		utils.ErrorResponse(c, http.StatusBadRequest, "current password is incorrect")
 		return
 	}
 
+	// The new password must differ from the current one
+	if auth.CheckPassword(req.NewPassword, user.Password) == nil {
+		h.auditService.LogProfileAction(c, userID, models.AuditActionPasswordChange, nil, false, "new password must differ from the current password")
+		utils.ErrorResponse(c, http.StatusBadRequest, "new password must differ from the current password")
+		return
+	}
+
 	// Hash new password
 	hashedPassword, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
@@ -488,13 +863,110 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// Change password
+	// Change password; this also clears a pending admin reset and revokes
+	// the user's refresh tokens
 	if err := h.service.ChangePassword(ctx, userID, user.Password, hashedPassword); err != nil {
+		h.auditService.LogProfileAction(c, userID, models.AuditActionPasswordChange, nil, false, "failed to change password")
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to change password")
 		return
 	}
+	h.auditService.LogProfileAction(c, userID, models.AuditActionPasswordChange, nil, true, "")
 
-	utils.SuccessResponse(c, gin.H{
-		"message": "password changed successfully",
+	publishEvent(c, h.publisher, events.Event{
+		Type:     events.PasswordChanged,
+		TargetID: userID,
 	})
+
+	utils.SuccessResponse(c, models.MessageData{Message: "password changed successfully"})
+}
+
+// ResetPassword godoc
+// @Summary      Reset a user's password
+// @Description  Set a new password for a locked-out user (admin only). Without a body, a random temporary
+// @Description  password is generated and returned once. Either way the user must change the password:
+// @Description  until then, their tokens are only accepted by PUT /users/me/password. Admins cannot reset
+// @Description  the password of users ranking above them.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path      int                                true   "User ID"
+// @Param        request  body      models.AdminResetPasswordRequest   false  "New password; omit to generate one"
+// @Success      200      {object}  models.AdminResetPasswordResponseEnvelope  "Password reset"
+// @Failure      400      {object}  models.ErrorResponse                       "Invalid user ID or password"
+// @Failure      403      {object}  models.ErrorResponse                       "Forbidden: admin only, or target ranks above the requester"
+// @Failure      404      {object}  models.ErrorResponse                       "User not found"
+// @Failure      500      {object}  models.ErrorResponse                       "Internal server error"
+// @Router       /users/{id}/reset-password [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	id, ok := utils.ParamUint(c, "id")
+	if !ok {
+		return
+	}
+	var req models.AdminResetPasswordRequest
+	if err := utils.BindJSON(c, &req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	actorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+	actorID := c.GetUint("user_id")
+
+	ctx := c.Request.Context()
+
+	target, err := h.service.GetUserForUpdate(ctx, id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	}
+	if !actorRole.AtLeast(target.Role) {
+		h.auditService.LogUserAction(c, actorID, models.AuditActionPasswordReset, id, nil, false, "target ranks above the requester")
+		utils.ErrorResponse(c, http.StatusForbidden, "cannot reset the password of a user ranking above you")
+		return
+	}
+
+	temporary, err := h.service.AdminResetPassword(ctx, id, req.NewPassword)
+	if err != nil {
+		h.auditService.LogUserAction(c, actorID, models.AuditActionPasswordReset, id, nil, false, "failed to reset password")
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+	h.auditService.LogUserAction(c, actorID, models.AuditActionPasswordReset, id, nil, true, "")
+
+	utils.MessageResponse(c, "password reset successfully", models.AdminResetPasswordResponse{
+		UserID:             models.ID(id),
+		MustChangePassword: true,
+		TemporaryPassword:  temporary,
+	})
+}
+
+// requesterRole returns the role of the authenticated user. When it is
+// missing the error response is written and ok is false.
+func requesterRole(c *gin.Context) (models.Role, bool) {
+	if role, ok := c.Get("user_role"); ok {
+		if r, ok := role.(models.Role); ok {
+			return r, true
+		}
+	}
+	if user, ok := c.Get("user"); ok {
+		if u, ok := user.(*models.User); ok {
+			return u.Role, true
+		}
+	}
+	utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+	return "", false
+}
+
+// createErrorStatus maps user creation and update errors to status codes
+func createErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRoleNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrDuplicateEmail):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
 }
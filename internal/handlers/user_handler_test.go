@@ -6,13 +6,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
 	"testing"
-	"time"
 
+	"Go-Lang-project-01/internal/events"
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -20,19 +22,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// MockUserService is a mock implementation of UserService
+// MockUserService is a mock implementation of UserServiceInterface
 type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) GetAllUsers(ctx context.Context) ([]*models.User, error) {
-	args := m.Called(ctx)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*models.User), args.Error(1)
-}
-
 func (m *MockUserService) GetAllUsersPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, models.PaginationMeta, error) {
 	args := m.Called(ctx, query)
 	if args.Get(0) == nil {
@@ -49,35 +43,22 @@ func (m *MockUserService) GetUserByID(ctx context.Context, id uint) (*models.Use
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
-	args := m.Called(ctx, req)
+func (m *MockUserService) GetUserForUpdate(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error) {
-	args := m.Called(ctx, id, req)
+func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) DeleteUser(ctx context.Context, id uint) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
-}
-
-func (m *MockUserService) BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) ([]*models.User, error) {
-	args := m.Called(ctx, requests)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*models.User), args.Error(1)
-}
-
 func (m *MockUserService) GetUserStats(ctx context.Context) (map[string]interface{}, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -86,333 +67,131 @@ func (m *MockUserService) GetUserStats(ctx context.Context) (map[string]interfac
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
-func (m *MockUserService) UpdateUserRole(ctx context.Context, userID uint, newRole string) (*models.User, error) {
-	args := m.Called(ctx, userID, newRole)
+func (m *MockUserService) CreateUser(ctx context.Context, creatorRole models.Role, req *models.CreateUserRequest) (*models.User, error) {
+	args := m.Called(ctx, creatorRole, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-// setupTestRouter creates a Gin router for testing
-func setupTestRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	return gin.New()
+func (m *MockUserService) BatchCreateUsers(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest) (*models.BatchCreateUsersResult, error) {
+	args := m.Called(ctx, creatorRole, requests)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BatchCreateUsersResult), args.Error(1)
 }
 
-// UserHandlerTestable is a testable version of UserHandler
-type UserHandlerTestable struct {
-	mockService *MockUserService
+func (m *MockUserService) BatchCreateUserResults(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest) []services.BatchCreateResult {
+	args := m.Called(ctx, creatorRole, requests)
+	return args.Get(0).([]services.BatchCreateResult)
 }
 
-// NewUserHandlerTestable creates a handler with mock service
-func NewUserHandlerTestable(mockService *MockUserService) *UserHandlerTestable {
-	return &UserHandlerTestable{
-		mockService: mockService,
+func (m *MockUserService) UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*models.User), args.Error(1)
 }
 
-// Implement all handler methods using mockService
-func (h *UserHandlerTestable) GetAllUsers(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
-
-	var query models.PaginationQuery
-	if err := c.ShouldBindQuery(&query); err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "validation error",
-			Data:    err.Error(),
-		})
-		return
-	}
-
-	users, meta, err := h.mockService.GetAllUsersPaginated(ctx, query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
+func (m *MockUserService) UpdateUserRole(ctx context.Context, userID uint, newRole models.Role) (*models.User, error) {
+	args := m.Called(ctx, userID, newRole)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-
-	c.JSON(http.StatusOK, models.PaginatedResponse{
-		Success:    true,
-		Data:       users,
-		Pagination: meta,
-	})
+	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (h *UserHandlerTestable) GetUserByID(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "invalid user id",
-		})
-		return
-	}
-
-	user, err := h.mockService.GetUserByID(ctx, uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
+func (m *MockUserService) UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.User, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Data:    user,
-	})
+	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (h *UserHandlerTestable) CreateUser(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-
-	var req models.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "validation error",
-			Data:    err.Error(),
-		})
-		return
-	}
-
-	user, err := h.mockService.CreateUser(ctx, &req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
+func (m *MockUserService) SetUserActive(ctx context.Context, id uint, active bool) (*models.User, error) {
+	args := m.Called(ctx, id, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-
-	c.JSON(http.StatusCreated, models.Response{
-		Success: true,
-		Message: "user created successfully",
-		Data:    user,
-	})
+	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (h *UserHandlerTestable) UpdateUser(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "invalid user id",
-		})
-		return
-	}
-
-	var req models.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "validation error",
-			Data:    err.Error(),
-		})
-		return
-	}
-
-	user, err := h.mockService.UpdateUser(ctx, uint(id), &req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Message: "user updated successfully",
-		Data:    user,
-	})
+func (m *MockUserService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	args := m.Called(ctx, userID, currentPassword, newPassword)
+	return args.Error(0)
 }
 
-func (h *UserHandlerTestable) DeleteUser(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
-
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "invalid user id",
-		})
-		return
-	}
-
-	if err := h.mockService.DeleteUser(ctx, uint(id)); err != nil {
-		c.JSON(http.StatusNotFound, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Message: "user deleted successfully",
-	})
+func (m *MockUserService) AdminResetPassword(ctx context.Context, id uint, newPassword string) (string, error) {
+	args := m.Called(ctx, id, newPassword)
+	return args.String(0), args.Error(1)
 }
 
-func (h *UserHandlerTestable) BatchCreateUsers(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
-	defer cancel()
-
-	var requests []*models.CreateUserRequest
-	if err := c.ShouldBindJSON(&requests); err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "validation error",
-			Data:    err.Error(),
-		})
-		return
-	}
-
-	// Check for empty batch
-	if len(requests) == 0 {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "batch request cannot be empty",
-		})
-		return
-	}
-
-	users, err := h.mockService.BatchCreateUsers(ctx, requests)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: err.Error(),
-			Data:    users,
-		})
-		return
-	}
-
-	c.JSON(http.StatusCreated, models.Response{
-		Success: true,
-		Message: "users created successfully",
-		Data:    users,
-	})
+func (m *MockUserService) DeleteUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
 
-func (h *UserHandlerTestable) GetUserStats(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
-
-	stats, err := h.mockService.GetUserStats(ctx)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.Response{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
+func (m *MockUserService) ListDeletedUsers(ctx context.Context, query models.DeletedUserQuery) ([]models.DeletedUser, models.PaginationMeta, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(models.PaginationMeta), args.Error(2)
 	}
-
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Data:    stats,
-	})
+	return args.Get(0).([]models.DeletedUser), args.Get(1).(models.PaginationMeta), args.Error(2)
 }
 
-func (h *UserHandlerTestable) UpdateUserRole(c *gin.Context) {
-	requestingUserInterface, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, models.Response{
-			Success: false,
-			Message: "unauthorized",
-		})
-		return
-	}
-
-	requestingUser, ok := requestingUserInterface.(*models.User)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, models.Response{
-			Success: false,
-			Message: "invalid user type",
-		})
-		return
-	}
-
-	if !requestingUser.IsSuperAdmin() {
-		c.JSON(http.StatusForbidden, models.Response{
-			Success: false,
-			Message: "only superadmin can change user roles",
-		})
-		return
-	}
-
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "invalid user ID",
-		})
-		return
-	}
-
-	var req models.UpdateRoleRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "validation error",
-			Data:    err.Error(),
-		})
-		return
+func (m *MockUserService) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+func (m *MockUserService) PurgeUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
 
-	user, err := h.mockService.GetUserByID(ctx, uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, models.Response{
-			Success: false,
-			Message: "user not found",
-		})
-		return
+func (m *MockUserService) BulkDelete(ctx context.Context, actorID uint, actorRole models.Role, ids []uint) (*models.BulkUserResult, error) {
+	args := m.Called(ctx, actorID, actorRole, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*models.BulkUserResult), args.Error(1)
+}
 
-	if user.ID == requestingUser.ID && req.Role != string(models.RoleSuperAdmin) {
-		c.JSON(http.StatusBadRequest, models.Response{
-			Success: false,
-			Message: "cannot demote yourself",
-		})
-		return
+func (m *MockUserService) BulkUpdateRoles(ctx context.Context, actorID uint, actorRole models.Role, assignments []models.RoleAssignment) (*models.BulkUserResult, []models.RoleChange, error) {
+	args := m.Called(ctx, actorID, actorRole, assignments)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
 	}
+	return args.Get(0).(*models.BulkUserResult), args.Get(1).([]models.RoleChange), args.Error(2)
+}
 
-	updatedUser, err := h.mockService.UpdateUserRole(ctx, user.ID, req.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.Response{
-			Success: false,
-			Message: "failed to update role",
-		})
-		return
-	}
+// setupTestRouter creates a Gin router for testing
+func setupTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return gin.New()
+}
 
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Data: gin.H{
-			"message": "user role updated successfully",
-			"user":    updatedUser,
-		},
-	})
+// setupHandlerWithMock creates a handler on a mock service whose audit
+// entries are discarded
+func setupHandlerWithMock(mockService *MockUserService) *UserHandler {
+	auditService := services.NewAuditService(services.NewJSONLSink(io.Discard))
+	return NewUserHandler(mockService, events.Nop{}, auditService, nil, UserImportConfig{})
 }
 
-// setupHandlerWithMock creates testable handler with mock service
-func setupHandlerWithMock(mockService *MockUserService) *UserHandlerTestable {
-	return NewUserHandlerTestable(mockService)
+// asUser runs handler as the user with ID 1 and the given role, like JWTAuth would
+func asUser(role models.Role, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", role)
+		handler(c)
+	}
 }
 
 // Test GetAllUsers
@@ -617,6 +396,77 @@ func TestGetUserByID(t *testing.T) {
 	}
 }
 
+func TestGetUserByEmail(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		mockSetup          func(*MockUserService)
+		expectedStatusCode int
+	}{
+		{
+			name:  "User found",
+			query: "?email=john@test.com",
+			mockSetup: func(m *MockUserService) {
+				m.On("GetUserByEmail", mock.Anything, "john@test.com").Return(&models.User{ID: 1, Name: "John Doe", Email: "john@test.com"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:  "User not found",
+			query: "?email=nobody@test.com",
+			mockSetup: func(m *MockUserService) {
+				m.On("GetUserByEmail", mock.Anything, "nobody@test.com").Return(nil, services.ErrUserNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Missing email",
+			query:              "",
+			mockSetup:          func(m *MockUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid email",
+			query:              "?email=john",
+			mockSetup:          func(m *MockUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Service error",
+			query: "?email=john@test.com",
+			mockSetup: func(m *MockUserService) {
+				m.On("GetUserByEmail", mock.Anything, "john@test.com").Return(nil, errors.New("database connection error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			tt.mockSetup(mockService)
+			handler := setupHandlerWithMock(mockService)
+
+			router := setupTestRouter()
+			router.GET("/users/by-email", handler.GetUserByEmail)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/by-email"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedStatusCode == http.StatusOK, response["success"])
+			if w.Code == http.StatusOK {
+				assert.Equal(t, "john@test.com", response["data"].(map[string]interface{})["email"])
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 // Test CreateUser
 func TestCreateUser(t *testing.T) {
 	tests := []struct {
@@ -632,7 +482,7 @@ func TestCreateUser(t *testing.T) {
 			requestBody: models.CreateUserRequest{
 				Name:     "New User",
 				Email:    "new@test.com",
-				Password: "password123",
+				Password: "correct-horse-42",
 				Age:      25,
 			},
 			mockSetup: func(m *MockUserService) {
@@ -644,7 +494,7 @@ func TestCreateUser(t *testing.T) {
 					IsActive: true,
 					Role:     "user",
 				}
-				m.On("CreateUser", mock.Anything, mock.Anything).Return(user, nil)
+				m.On("CreateUser", mock.Anything, models.RoleAdmin, mock.Anything).Return(user, nil)
 			},
 			expectedStatusCode: http.StatusCreated,
 			expectedSuccess:    true,
@@ -659,13 +509,13 @@ func TestCreateUser(t *testing.T) {
 			requestBody: models.CreateUserRequest{
 				Name:     "Duplicate",
 				Email:    "existing@test.com",
-				Password: "password123",
+				Password: "correct-horse-42",
 				Age:      30,
 			},
 			mockSetup: func(m *MockUserService) {
-				m.On("CreateUser", mock.Anything, mock.Anything).Return(nil, errors.New("email already exists"))
+				m.On("CreateUser", mock.Anything, models.RoleAdmin, mock.Anything).Return(nil, repository.ErrDuplicateEmail)
 			},
-			expectedStatusCode: http.StatusBadRequest,
+			expectedStatusCode: http.StatusConflict,
 			expectedSuccess:    false,
 		},
 		{
@@ -683,7 +533,7 @@ func TestCreateUser(t *testing.T) {
 			requestBody: models.CreateUserRequest{
 				Name:     "Test User",
 				Email:    "invalid-email",
-				Password: "password123",
+				Password: "correct-horse-42",
 				Age:      25,
 			},
 			mockSetup:          func(m *MockUserService) {},
@@ -706,7 +556,7 @@ func TestCreateUser(t *testing.T) {
 			handler := setupHandlerWithMock(mockService)
 
 			router := setupTestRouter()
-			router.POST("/users", handler.CreateUser)
+			router.POST("/users", asUser(models.RoleAdmin, handler.CreateUser))
 
 			var bodyBytes []byte
 			if strBody, ok := tt.requestBody.(string); ok {
@@ -741,6 +591,47 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+// TestCreateUser_MalformedBody checks that malformed bodies are described
+// without the decoder's Go type and struct field names
+func TestCreateUser_MalformedBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantMsg   string
+	}{
+		{"Empty body", "", "request", "request body is empty"},
+		{"Truncated JSON", `{"name": "Test", "email": "te`, "request", "request body is not valid JSON"},
+		{"Invalid JSON", "invalid json", "request", "request body is not valid JSON"},
+		{"Age as string", `{"name": "Test", "email": "test@test.com", "password": "correct-horse-42", "age": "thirty"}`, "age", "age must be a number"},
+		{"Name as number", `{"name": 42, "email": "test@test.com", "password": "correct-horse-42"}`, "name", "name must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := setupHandlerWithMock(mockService)
+
+			router := setupTestRouter()
+			router.POST("/users", asUser(models.RoleAdmin, handler.CreateUser))
+
+			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var response models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, []models.ValidationError{{Field: tt.wantField, Message: tt.wantMsg}}, response.Errors)
+			for _, leak := range []string{"Go struct", "CreateUserRequest", "json:", "unexpected"} {
+				assert.NotContains(t, w.Body.String(), leak)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 // Test UpdateUser
 func TestUpdateUser(t *testing.T) {
 	tests := []struct {
@@ -803,9 +694,9 @@ func TestUpdateUser(t *testing.T) {
 				Email: stringPtr("taken@test.com"),
 			},
 			mockSetup: func(m *MockUserService) {
-				m.On("UpdateUser", mock.Anything, uint(1), mock.Anything).Return(nil, errors.New("email already exists"))
+				m.On("UpdateUser", mock.Anything, uint(1), mock.Anything).Return(nil, repository.ErrDuplicateEmail)
 			},
-			expectedStatusCode: http.StatusBadRequest,
+			expectedStatusCode: http.StatusConflict,
 			expectedSuccess:    false,
 		},
 	}
@@ -931,39 +822,57 @@ func TestBatchCreateUsers(t *testing.T) {
 		{
 			name: "Successfully create multiple users",
 			requestBody: []*models.CreateUserRequest{
-				{Name: "User 1", Email: "user1@test.com", Password: "password1", Age: 25},
-				{Name: "User 2", Email: "user2@test.com", Password: "password2", Age: 30},
+				{Name: "User 1", Email: "user1@test.com", Password: "first-pass-1", Age: 25},
+				{Name: "User 2", Email: "user2@test.com", Password: "second-pass-2", Age: 30},
 			},
 			mockSetup: func(m *MockUserService) {
-				users := []*models.User{
-					{ID: 1, Name: "User 1", Email: "user1@test.com", Age: 25},
-					{ID: 2, Name: "User 2", Email: "user2@test.com", Age: 30},
+				result := &models.BatchCreateUsersResult{
+					Created: []*models.User{
+						{ID: 1, Name: "User 1", Email: "user1@test.com", Age: 25},
+						{ID: 2, Name: "User 2", Email: "user2@test.com", Age: 30},
+					},
+					Failed: []models.BatchCreateFailure{},
 				}
-				m.On("BatchCreateUsers", mock.Anything, mock.Anything).Return(users, nil)
+				m.On("BatchCreateUsers", mock.Anything, models.RoleAdmin, mock.Anything).Return(result, nil)
 			},
 			expectedStatusCode: http.StatusCreated,
 			expectedSuccess:    true,
 			validateResponse: func(t *testing.T, resp map[string]interface{}) {
-				if dataInterface, ok := resp["data"]; ok && dataInterface != nil {
-					if data, ok := dataInterface.([]interface{}); ok {
-						assert.Len(t, data, 2)
-					}
-				}
+				data := resp["data"].(map[string]interface{})
+				assert.Len(t, data["created"], 2)
+				assert.Empty(t, data["failed"])
 			},
 		},
 		{
 			name: "Partial success - some users fail",
 			requestBody: []*models.CreateUserRequest{
-				{Name: "User 1", Email: "new@test.com", Password: "password1", Age: 25},
-				{Name: "User 2", Email: "existing@test.com", Password: "password2", Age: 30},
+				{Name: "User 1", Email: "new@test.com", Password: "first-pass-1", Age: 25},
+				{Name: "User 2", Email: "existing@test.com", Password: "second-pass-2", Age: 30},
 			},
 			mockSetup: func(m *MockUserService) {
-				users := []*models.User{
-					{ID: 1, Name: "User 1", Email: "new@test.com", Age: 25},
+				result := &models.BatchCreateUsersResult{
+					Created: []*models.User{{ID: 1, Name: "User 1", Email: "new@test.com", Age: 25}},
+					Failed:  []models.BatchCreateFailure{{Index: 1, Email: "existing@test.com", Error: "email already exists"}},
 				}
-				m.On("BatchCreateUsers", mock.Anything, mock.Anything).Return(users, errors.New("batch create had 1 errors: user 1: email already exists"))
+				m.On("BatchCreateUsers", mock.Anything, models.RoleAdmin, mock.Anything).Return(result, nil)
 			},
-			expectedStatusCode: http.StatusBadRequest,
+			expectedStatusCode: http.StatusMultiStatus,
+			expectedSuccess:    true,
+			validateResponse: func(t *testing.T, resp map[string]interface{}) {
+				data := resp["data"].(map[string]interface{})
+				assert.Len(t, data["created"], 1)
+				assert.Len(t, data["failed"], 1)
+			},
+		},
+		{
+			name: "Role above the requester",
+			requestBody: []*models.CreateUserRequest{
+				{Name: "User 1", Email: "boss@test.com", Password: "first-pass-1", Age: 25, Role: models.RoleSuperAdmin},
+			},
+			mockSetup: func(m *MockUserService) {
+				m.On("BatchCreateUsers", mock.Anything, models.RoleAdmin, mock.Anything).Return(nil, services.ErrRoleNotAllowed)
+			},
+			expectedStatusCode: http.StatusForbidden,
 			expectedSuccess:    false,
 		},
 		{
@@ -989,7 +898,7 @@ func TestBatchCreateUsers(t *testing.T) {
 			handler := setupHandlerWithMock(mockService)
 
 			router := setupTestRouter()
-			router.POST("/users/batch", handler.BatchCreateUsers)
+			router.POST("/users/batch", asUser(models.RoleAdmin, handler.BatchCreateUsers))
 
 			var bodyBytes []byte
 			if strBody, ok := tt.requestBody.(string); ok {
@@ -1039,9 +948,11 @@ func TestGetUserStats(t *testing.T) {
 			name: "Successfully get user stats",
 			mockSetup: func(m *MockUserService) {
 				stats := map[string]interface{}{
-					"total_users":    100,
-					"active_users":   75,
-					"inactive_users": 25,
+					"total_users":           100,
+					"active_users":          75,
+					"inactive_users":        25,
+					"users_by_role":         map[models.Role]int64{models.RoleUser: 90, models.RoleAdmin: 9, models.RoleSuperAdmin: 1},
+					"new_users_last_7_days": 12,
 				}
 				m.On("GetUserStats", mock.Anything).Return(stats, nil)
 			},
@@ -1052,6 +963,8 @@ func TestGetUserStats(t *testing.T) {
 				assert.Equal(t, float64(100), data["total_users"])
 				assert.Equal(t, float64(75), data["active_users"])
 				assert.Equal(t, float64(25), data["inactive_users"])
+				assert.Equal(t, map[string]interface{}{"user": float64(90), "admin": float64(9), "superadmin": float64(1)}, data["users_by_role"])
+				assert.Equal(t, float64(12), data["new_users_last_7_days"])
 			},
 		},
 		{
@@ -1113,7 +1026,7 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "superadmin@test.com",
-				Role:  string(models.RoleSuperAdmin),
+				Role:  models.RoleSuperAdmin,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "admin",
@@ -1121,8 +1034,8 @@ func TestUpdateUserRole(t *testing.T) {
 			mockSetup: func(m *MockUserService) {
 				targetUser := &models.User{ID: 2, Email: "user@test.com", Role: "user"}
 				updatedUser := &models.User{ID: 2, Email: "user@test.com", Role: "admin"}
-				m.On("GetUserByID", mock.Anything, uint(2)).Return(targetUser, nil)
-				m.On("UpdateUserRole", mock.Anything, uint(2), "admin").Return(updatedUser, nil)
+				m.On("GetUserForUpdate", mock.Anything, uint(2)).Return(targetUser, nil)
+				m.On("UpdateUserRole", mock.Anything, uint(2), models.RoleAdmin).Return(updatedUser, nil)
 			},
 			expectedStatusCode: http.StatusOK,
 			expectedSuccess:    true,
@@ -1138,7 +1051,7 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "admin@test.com",
-				Role:  string(models.RoleAdmin),
+				Role:  models.RoleAdmin,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "admin",
@@ -1153,7 +1066,7 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "user@test.com",
-				Role:  string(models.RoleUser),
+				Role:  models.RoleUser,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "admin",
@@ -1168,14 +1081,14 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "superadmin@test.com",
-				Role:  string(models.RoleSuperAdmin),
+				Role:  models.RoleSuperAdmin,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "admin",
 			},
 			mockSetup: func(m *MockUserService) {
 				user := &models.User{ID: 1, Email: "superadmin@test.com", Role: "superadmin"}
-				m.On("GetUserByID", mock.Anything, uint(1)).Return(user, nil)
+				m.On("GetUserForUpdate", mock.Anything, uint(1)).Return(user, nil)
 			},
 			expectedStatusCode: http.StatusBadRequest,
 			expectedSuccess:    false,
@@ -1186,13 +1099,13 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "superadmin@test.com",
-				Role:  string(models.RoleSuperAdmin),
+				Role:  models.RoleSuperAdmin,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "admin",
 			},
 			mockSetup: func(m *MockUserService) {
-				m.On("GetUserByID", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
+				m.On("GetUserForUpdate", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
 			},
 			expectedStatusCode: http.StatusNotFound,
 			expectedSuccess:    false,
@@ -1203,7 +1116,7 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "superadmin@test.com",
-				Role:  string(models.RoleSuperAdmin),
+				Role:  models.RoleSuperAdmin,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "invalid_role",
@@ -1220,7 +1133,7 @@ func TestUpdateUserRole(t *testing.T) {
 			requestingUser: &models.User{
 				ID:    1,
 				Email: "superadmin@test.com",
-				Role:  string(models.RoleSuperAdmin),
+				Role:  models.RoleSuperAdmin,
 			},
 			requestBody: models.UpdateRoleRequest{
 				Role: "admin",
@@ -1302,16 +1215,16 @@ func TestRBACAuthorization(t *testing.T) {
 	t.Run("Admin can create users", func(t *testing.T) {
 		mockService := new(MockUserService)
 		user := &models.User{ID: 1, Name: "Test", Email: "test@test.com"}
-		mockService.On("CreateUser", mock.Anything, mock.Anything).Return(user, nil)
+		mockService.On("CreateUser", mock.Anything, models.RoleAdmin, mock.Anything).Return(user, nil)
 
 		handler := setupHandlerWithMock(mockService)
 		router := setupTestRouter()
-		router.POST("/users", handler.CreateUser)
+		router.POST("/users", asUser(models.RoleAdmin, handler.CreateUser))
 
 		reqBody := models.CreateUserRequest{
 			Name:     "Test",
 			Email:    "test@test.com",
-			Password: "password123",
+			Password: "correct-horse-42",
 			Age:      25,
 		}
 		bodyBytes, _ := json.Marshal(reqBody)
@@ -1325,12 +1238,12 @@ func TestRBACAuthorization(t *testing.T) {
 
 	t.Run("Only superadmin can change roles", func(t *testing.T) {
 		tests := []struct {
-			role               string
+			role               models.Role
 			expectedStatusCode int
 		}{
-			{string(models.RoleSuperAdmin), http.StatusOK},
-			{string(models.RoleAdmin), http.StatusForbidden},
-			{string(models.RoleUser), http.StatusForbidden},
+			{models.RoleSuperAdmin, http.StatusOK},
+			{models.RoleAdmin, http.StatusForbidden},
+			{models.RoleUser, http.StatusForbidden},
 		}
 
 		for _, tt := range tests {
@@ -1339,8 +1252,8 @@ func TestRBACAuthorization(t *testing.T) {
 				if tt.expectedStatusCode == http.StatusOK {
 					targetUser := &models.User{ID: 2, Role: "user"}
 					updatedUser := &models.User{ID: 2, Role: "admin"}
-					mockService.On("GetUserByID", mock.Anything, uint(2)).Return(targetUser, nil)
-					mockService.On("UpdateUserRole", mock.Anything, uint(2), "admin").Return(updatedUser, nil)
+					mockService.On("GetUserForUpdate", mock.Anything, uint(2)).Return(targetUser, nil)
+					mockService.On("UpdateUserRole", mock.Anything, uint(2), models.RoleAdmin).Return(updatedUser, nil)
 				}
 
 				handler := setupHandlerWithMock(mockService)
@@ -1373,7 +1286,7 @@ func BenchmarkGetAllUsers(b *testing.B) {
 	mockService := new(MockUserService)
 	users := make([]*models.User, 100)
 	for i := 0; i < 100; i++ {
-		users[i] = &models.User{ID: uint(i + 1), Name: "User", Email: "user@test.com"}
+		users[i] = &models.User{ID: models.ID(i + 1), Name: "User", Email: "user@test.com"}
 	}
 	meta := models.PaginationMeta{Page: 1, Limit: 10, Total: 100, TotalPages: 10}
 	mockService.On("GetAllUsersPaginated", mock.Anything, mock.Anything).Return(users, meta, nil)
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// UserImportConfig limits the files accepted by POST /users/import
+type UserImportConfig struct {
+	MaxBytes int64 // Largest file; <= 0 means 1 MiB
+	MaxRows  int   // Most users per file; <= 0 means 1000
+}
+
+// errTooManyRows is returned by readUserImport for files above MaxRows
+var errTooManyRows = errors.New("too many rows")
+
+// userImportRow is a parsed CSV row waiting to be created
+type userImportRow struct {
+	line int
+	req  *models.CreateUserRequest
+}
+
+// ImportBodyLimit is the largest request body of POST /users/import: the
+// largest file plus room for the multipart envelope around it. Use it for
+// the route's middleware.BodySizeLimit.
+func (h *UserHandler) ImportBodyLimit() int64 {
+	return h.imports.MaxBytes + 64<<10
+}
+
+// ImportUsers godoc
+// @Summary      Import users from CSV
+// @Description  Create users from a CSV file with the header name,email,password,age,role (age and role may be omitted). Rows fail on their own; the response lists the created IDs and the failed rows by line number.
+// @Tags         users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file                     true  "CSV file"
+// @Success      200   {object}  models.UserImportResponse  "Per-row results"
+// @Failure      400   {object}  models.ErrorResponse       "Missing file, malformed CSV or unknown columns"
+// @Failure      413   {object}  models.ErrorResponse       "File or row count above the configured limit"
+// @Router       /users/import [post]
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.ImportBodyLimit())
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than %d bytes", h.imports.MaxBytes))
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "a CSV file is required in the \"file\" field")
+		return
+	}
+	if header.Size > h.imports.MaxBytes {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than %d bytes", h.imports.MaxBytes))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	rows, result, err := readUserImport(file, h.imports.MaxRows)
+	switch {
+	case errors.Is(err, errTooManyRows):
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file has more than %d rows", h.imports.MaxRows))
+		return
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	creatorRole, ok := requesterRole(c)
+	if !ok {
+		return
+	}
+
+	requests := make([]*models.CreateUserRequest, len(rows))
+	for i, row := range rows {
+		requests[i] = row.req
+	}
+	for i, created := range h.service.BatchCreateUserResults(ctx, creatorRole, requests) {
+		if created.Err != nil {
+			result.Failed = append(result.Failed, models.UserImportFailure{
+				Row: rows[i].line, Email: rows[i].req.Email, Error: created.Err.Error(),
+			})
+			continue
+		}
+		result.Created = append(result.Created, models.UserImportCreated{Row: rows[i].line, ID: created.User.ID})
+		publishEvent(c, h.publisher, events.Event{
+			Type:     events.UserCreated,
+			TargetID: uint(created.User.ID),
+			Payload:  map[string]interface{}{"user": created.User},
+		})
+	}
+	slices.SortFunc(result.Failed, func(a, b models.UserImportFailure) int { return a.Row - b.Row })
+
+	utils.MessageResponse(c, fmt.Sprintf("imported %d of %d users", len(result.Created), result.Rows), result)
+}
+
+// readUserImport parses and validates a CSV file. Rows that fail validation
+// or repeat an earlier email are reported in the result; the others are
+// returned for creation. Malformed CSV fails the whole file.
+func readUserImport(r io.Reader, maxRows int) ([]userImportRow, *models.UserImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed CSV: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) // Spreadsheets may start with a byte order mark
+		if !slices.Contains(models.UserImportColumns, name) {
+			return nil, nil, fmt.Errorf("unknown column %q, expected %s", name, strings.Join(models.UserImportColumns, ","))
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"name", "email", "password"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	result := &models.UserImportResult{Created: []models.UserImportCreated{}, Failed: []models.UserImportFailure{}}
+	var rows []userImportRow
+	emails := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed CSV: %w", err)
+		}
+		if result.Rows++; result.Rows > maxRows {
+			return nil, nil, errTooManyRows
+		}
+		line, _ := reader.FieldPos(0)
+
+		req := &models.CreateUserRequest{
+			Name:     field(record, "name"),
+			Email:    field(record, "email"),
+			Password: field(record, "password"),
+			Role:     models.Role(strings.ToLower(field(record, "role"))),
+		}
+		failure := models.UserImportFailure{Row: line, Email: req.Email}
+		if age := field(record, "age"); age != "" {
+			if req.Age, err = strconv.Atoi(age); err != nil {
+				failure.Error = "Validation failed"
+				failure.Fields = []models.ValidationError{{Field: "age", Message: "age must be a whole number"}}
+				result.Failed = append(result.Failed, failure)
+				continue
+			}
+		}
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			failure.Error = "Validation failed"
+			failure.Fields = utils.ValidationErrors(err)
+			result.Failed = append(result.Failed, failure)
+			continue
+		}
+		email := strings.ToLower(req.Email)
+		if first, ok := emails[email]; ok {
+			failure.Error = fmt.Sprintf("duplicate email, already in row %d", first)
+			result.Failed = append(result.Failed, failure)
+			continue
+		}
+		emails[email] = line
+		rows = append(rows, userImportRow{line: line, req: req})
+	}
+	return rows, result, nil
+}
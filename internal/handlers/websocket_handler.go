@@ -3,10 +3,14 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
 	ws "Go-Lang-project-01/internal/websocket"
 	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,70 +20,101 @@ import (
 type WebSocketHandler struct {
 	hub        *ws.Hub
 	jwtManager *auth.JWTManager
+	upgrader   *ws.Upgrader
 }
 
-// NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(hub *ws.Hub, jwtManager *auth.JWTManager) *WebSocketHandler {
+// NewWebSocketHandler creates a new WebSocket handler. Browser handshakes
+// are only accepted from allowedOrigins (see ws.CheckOrigin)
+func NewWebSocketHandler(hub *ws.Hub, jwtManager *auth.JWTManager, allowedOrigins []string) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:        hub,
 		jwtManager: jwtManager,
+		upgrader:   ws.NewUpgrader(allowedOrigins),
 	}
 }
 
+// webSocketToken returns the access token of a handshake. Browsers can't
+// set headers on WebSocket requests, so they offer "bearer, <token>" in
+// Sec-WebSocket-Protocol; other clients send an Authorization header. The
+// token query parameter still works but ends up in access logs
+func webSocketToken(r *http.Request) string {
+	if parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+		return parts[1]
+	}
+
+	protocols := ws.Subprotocols(r)
+	for i, protocol := range protocols {
+		if protocol == ws.BearerSubprotocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+
+	return r.URL.Query().Get("token")
+}
+
 // HandleWebSocket upgrades HTTP connection to WebSocket
 // @Summary WebSocket connection endpoint
-// @Description Establish WebSocket connection for real-time updates (JWT token required via query param)
+// @Description Establish WebSocket connection for real-time updates. The access token is read from the
+// @Description Authorization header, from Sec-WebSocket-Protocol as "bearer, <token>" (the server echoes "bearer"),
+// @Description or from the token query parameter. Browser origins must be listed in websocket.allowedorigins.
 // @Tags websocket
-// @Param token query string true "JWT access token"
+// @Param Authorization header string false "Bearer access token"
+// @Param Sec-WebSocket-Protocol header string false "bearer, <access token>"
+// @Param token query string false "JWT access token (deprecated: shows up in access logs)"
 // @Success 101 {string} string "Switching Protocols"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {string} string "Origin not allowed"
 // @Router /ws [get]
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
-	// Get JWT token from query parameter (can't use headers in browser WebSocket)
-	tokenString := c.Query("token")
+	tokenString := webSocketToken(c.Request)
 	if tokenString == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
 		return
 	}
 
 	// Validate JWT token
-	claims, err := h.jwtManager.ValidateToken(tokenString)
+	claims, err := h.jwtManager.ValidateAccessToken(tokenString)
 	if err != nil {
 		logger.Warn("WebSocket auth failed", "error", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
 	}
 
-	// Upgrade HTTP connection to WebSocket
-	conn, err := ws.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	// Upgrade HTTP connection to WebSocket; rejects disallowed origins with 403
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		logger.Error("WebSocket upgrade failed", "error", err)
+		logger.Warn("WebSocket upgrade failed", "error", err, "origin", c.GetHeader("Origin"))
 		return
 	}
 
 	// Create client
 	client := &ws.Client{
-		ID:     uuid.New().String(),
-		UserID: claims.UserID,
-		Role:   claims.Role,
-		Hub:    h.hub,
-		Conn:   &ws.Conn{Conn: conn},
-		Send:   make(chan ws.Message, 256),
+		ID:          uuid.New().String(),
+		UserID:      claims.UserID,
+		Role:        claims.Role,
+		RemoteAddr:  c.ClientIP(),
+		ConnectedAt: time.Now(),
+		Hub:         h.hub,
+		Conn:        &ws.Conn{Conn: conn},
+		Send:        make(chan ws.Message, 256),
 	}
 
-	// Register client
-	h.hub.Register <- client
+	// Register client; over a connection limit WritePump sends the close frame with the reason
+	if err := h.hub.Add(client); err != nil {
+		go client.WritePump()
+		return
+	}
 
-	// Send welcome message
+	// Send welcome message with the protocol capabilities
+	welcome := ws.Capabilities()
+	welcome["client_id"] = client.ID
+	welcome["user_id"] = client.UserID
+	welcome["role"] = client.Role
+	welcome["message"] = "Welcome to WebSocket real-time updates"
 	client.Send <- ws.Message{
-		Type: ws.EventType("connection.established"),
-		Data: map[string]interface{}{
-			"client_id": client.ID,
-			"user_id":   client.UserID,
-			"role":      client.Role,
-			"message":   "Welcome to WebSocket real-time updates",
-		},
+		Type: ws.EventConnectionEstablished,
+		Data: welcome,
 	}
 
 	// Start client goroutines
@@ -89,11 +124,17 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 
 // GetStats returns WebSocket hub statistics
 // @Summary Get WebSocket statistics
-// @Description Get current WebSocket connection statistics (admin only)
+// @Description Get current WebSocket connection statistics (admin only).
+// @Description With detail=true, per-connection rows are included; remote addresses are only shown to superadmins.
 // @Tags websocket
 // @Security Bearer
 // @Produce json
+// @Param detail query bool false "Include per-connection rows"
+// @Param user_id query int false "Only connections of this user"
+// @Param page query int false "Page of connection rows (default: 1)"
+// @Param limit query int false "Connection rows per page (default: 20, max: 100)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 403 {object} map[string]string "Forbidden"
 // @Router /ws/stats [get]
@@ -108,8 +149,8 @@ func (h *WebSocketHandler) GetStats(c *gin.Context) {
 		return
 	}
 
-	userRole, exists := c.Get("userRole")
-	if !exists || (userRole != "admin" && userRole != "superadmin") {
+	userRole, _ := c.Value("userRole").(models.Role)
+	if !userRole.AtLeast(models.RoleAdmin) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
 			"message": "admin access required",
@@ -117,16 +158,44 @@ func (h *WebSocketHandler) GetStats(c *gin.Context) {
 		return
 	}
 
+	var query models.WebSocketStatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
 	stats := h.hub.GetStats()
-	
+	stats["user_id"] = userID
+
+	if query.Detail {
+		if query.Page == 0 {
+			query.Page = 1
+		}
+		if query.Limit == 0 {
+			query.Limit = 20
+		}
+
+		clients, total := h.hub.ListClients(ws.ClientFilter{
+			UserID: query.UserID,
+			Offset: (query.Page - 1) * query.Limit,
+			Limit:  query.Limit,
+		})
+
+		// Remote addresses identify the user's network: superadmin only
+		if userRole != models.RoleSuperAdmin {
+			for i := range clients {
+				clients[i].RemoteAddr = ""
+			}
+		}
+
+		stats["clients"] = clients
+		stats["pagination"] = models.NewPaginationMeta(query.Page, query.Limit, int64(total))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "WebSocket statistics retrieved successfully",
-		"data": gin.H{
-			"total_connections": stats["total_connections"],
-			"active_connections": stats["active_connections"],
-			"user_id": userID,
-		},
+		"data":    stats,
 	})
 }
 
@@ -144,14 +213,14 @@ func (h *WebSocketHandler) GetStats(c *gin.Context) {
 // @Router /ws/broadcast [post]
 func (h *WebSocketHandler) BroadcastMessage(c *gin.Context) {
 	// Check admin access
-	userRole, exists := c.Get("userRole")
-	if !exists || (userRole != "admin" && userRole != "superadmin") {
+	userRole, _ := c.Value("userRole").(models.Role)
+	if !userRole.AtLeast(models.RoleAdmin) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
 		return
 	}
 
 	var payload map[string]interface{}
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	if err := utils.BindJSON(c, &payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
@@ -166,6 +235,50 @@ func (h *WebSocketHandler) BroadcastMessage(c *gin.Context) {
 	})
 }
 
+// defaultDisconnectReason is sent when an admin disconnects a user without a reason
+const defaultDisconnectReason = "disconnected by an administrator"
+
+// DisconnectUser closes every WebSocket connection of a user (admin only)
+// @Summary Disconnect a user's WebSocket sessions
+// @Description Close every WebSocket connection of a user with a policy violation close frame (admin only).
+// @Description The reason is sent in the close frame. Connecting again needs a valid token.
+// @Tags websocket
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param request body models.WebSocketDisconnectRequest false "Reason sent to the client"
+// @Success 200 {object} models.WebSocketDisconnectResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid user ID or reason"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Forbidden"
+// @Router /ws/disconnect/{userId} [post]
+func (h *WebSocketHandler) DisconnectUser(c *gin.Context) {
+	userID, ok := utils.ParamUint(c, "userId")
+	if !ok {
+		return
+	}
+
+	var req models.WebSocketDisconnectRequest
+	if c.Request.ContentLength != 0 {
+		if err := utils.BindJSON(c, &req); err != nil {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = defaultDisconnectReason
+	}
+
+	closed := h.hub.DisconnectUser(userID, req.Reason)
+	logger.Info("WebSocket sessions closed by admin", "user_id", userID, "actor_id", c.GetUint("user_id"), "connections", closed)
+
+	utils.MessageResponse(c, "user disconnected", models.WebSocketDisconnectResult{
+		UserID:       userID,
+		Disconnected: closed,
+	})
+}
+
 // NotifyUserUpdate sends user update notification to specific user
 func (h *WebSocketHandler) NotifyUserUpdate(userID uint, data map[string]interface{}) {
 	h.hub.BroadcastToUser(userID, ws.EventUserUpdated, data)
@@ -173,14 +286,12 @@ func (h *WebSocketHandler) NotifyUserUpdate(userID uint, data map[string]interfa
 
 // NotifyUserCreated broadcasts user created event to admins
 func (h *WebSocketHandler) NotifyUserCreated(data map[string]interface{}) {
-	h.hub.BroadcastToRole("admin", ws.EventUserCreated, data)
-	h.hub.BroadcastToRole("superadmin", ws.EventUserCreated, data)
+	h.hub.BroadcastToMinimumRole(models.RoleAdmin, ws.EventUserCreated, data)
 }
 
 // NotifyUserDeleted broadcasts user deleted event to admins
 func (h *WebSocketHandler) NotifyUserDeleted(data map[string]interface{}) {
-	h.hub.BroadcastToRole("admin", ws.EventUserDeleted, data)
-	h.hub.BroadcastToRole("superadmin", ws.EventUserDeleted, data)
+	h.hub.BroadcastToMinimumRole(models.RoleAdmin, ws.EventUserDeleted, data)
 }
 
 // NotifyRoleChanged broadcasts role change event
@@ -189,8 +300,7 @@ func (h *WebSocketHandler) NotifyRoleChanged(userID uint, data map[string]interf
 	h.hub.BroadcastToUser(userID, ws.EventUserRoleChanged, data)
 
 	// Notify all admins
-	h.hub.BroadcastToRole("admin", ws.EventUserRoleChanged, data)
-	h.hub.BroadcastToRole("superadmin", ws.EventUserRoleChanged, data)
+	h.hub.BroadcastToMinimumRole(models.RoleAdmin, ws.EventUserRoleChanged, data)
 }
 
 // NotifyProfileUpdated broadcasts profile update event
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocketToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		headers  map[string]string
+		expected string
+	}{
+		{"authorization header", "/ws", map[string]string{"Authorization": "Bearer header-token"}, "header-token"},
+		{"bearer subprotocol", "/ws", map[string]string{"Sec-WebSocket-Protocol": "bearer, protocol-token"}, "protocol-token"},
+		{"bearer subprotocol after others", "/ws", map[string]string{"Sec-WebSocket-Protocol": "chat, bearer, protocol-token"}, "protocol-token"},
+		{"query parameter", "/ws?token=query-token", nil, "query-token"},
+		{"header before subprotocol and query", "/ws?token=query-token", map[string]string{
+			"Authorization":          "Bearer header-token",
+			"Sec-WebSocket-Protocol": "bearer, protocol-token",
+		}, "header-token"},
+		{"subprotocol before query", "/ws?token=query-token", map[string]string{"Sec-WebSocket-Protocol": "bearer, protocol-token"}, "protocol-token"},
+		{"bearer without token", "/ws", map[string]string{"Sec-WebSocket-Protocol": "bearer"}, ""},
+		{"other authorization scheme", "/ws?token=query-token", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, "query-token"},
+		{"none", "/ws", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			assert.Equal(t, tt.expected, webSocketToken(req))
+		})
+	}
+}
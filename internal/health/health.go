@@ -2,10 +2,17 @@ package health
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"runtime"
+	"sync"
 	"syscall"
 	"time"
 
+	"Go-Lang-project-01/internal/auth"
+
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -20,9 +27,10 @@ const (
 
 // ComponentHealth represents the health of a single component
 type ComponentHealth struct {
-	Status  Status                 `json:"status"`
-	Message string                 `json:"message,omitempty"`
-	Details map[string]interface{} `json:"details,omitempty"`
+	Status    Status                 `json:"status"`
+	Message   string                 `json:"message,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	LatencyMs float64                `json:"latency_ms"` // Time the check took; set by HealthService
 }
 
 // HealthResponse represents the overall health response
@@ -33,6 +41,19 @@ type HealthResponse struct {
 	System     SystemInfo                 `json:"system"`
 }
 
+// StatusResponse is the health response shown to callers not allowed to see component details
+type StatusResponse struct {
+	Status Status `json:"status"`
+}
+
+// ReadinessResponse is the response of the readiness probe. Components are
+// only shown to callers allowed to see details, and only when not ready.
+type ReadinessResponse struct {
+	Status     string                     `json:"status" example:"ready"` // "ready" or "not ready"
+	Service    string                     `json:"service" example:"Go-Lang-project-01"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
 // SystemInfo represents system-level information
 type SystemInfo struct {
 	Goroutines    int     `json:"goroutines"`
@@ -68,6 +89,48 @@ func (d *DatabaseChecker) Check(ctx context.Context) ComponentHealth {
 		}
 	}
 
+	return checkPool(ctx, sqlDB)
+}
+
+// PostgresReplicaLagQuery returns the replay lag of a PostgreSQL standby in seconds
+const PostgresReplicaLagQuery = "SELECT COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)"
+
+// ReplicaChecker checks a read replica pool
+type ReplicaChecker struct {
+	DB      *sql.DB
+	Timeout time.Duration
+	// LagQuery returns the replication lag in seconds; empty if the driver cannot report it
+	LagQuery string
+	MaxLag   time.Duration // lag above which the replica is degraded; 0 = never
+}
+
+// Check implements Checker for ReplicaChecker
+func (r *ReplicaChecker) Check(ctx context.Context) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	result := checkPool(ctx, r.DB)
+	if result.Status == StatusUnhealthy || r.LagQuery == "" {
+		return result
+	}
+
+	var lagSeconds float64
+	if err := r.DB.QueryRowContext(ctx, r.LagQuery).Scan(&lagSeconds); err != nil {
+		result.Details["lag_error"] = err.Error()
+		return result
+	}
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	result.Details["lag_seconds"] = lagSeconds
+
+	if r.MaxLag > 0 && lag > r.MaxLag && result.Status == StatusHealthy {
+		result.Status = StatusDegraded
+		result.Message = "replica lag exceeds threshold"
+	}
+	return result
+}
+
+// checkPool pings a connection pool and reports its stats
+func checkPool(ctx context.Context, sqlDB *sql.DB) ComponentHealth {
 	// Ping database
 	if err := sqlDB.PingContext(ctx); err != nil {
 		return ComponentHealth{
@@ -215,40 +278,129 @@ func (m *MemoryChecker) Check(ctx context.Context) ComponentHealth {
 	}
 }
 
-// HealthService manages health checks
+// CryptoChecker checks that tokens can be issued and passwords hashed.
+// It catches misconfigured secrets before the first user tries to log in.
+type CryptoChecker struct {
+	JWT        *auth.JWTManager
+	HashBudget time.Duration // slowest acceptable hash+verify of a probe password
+}
+
+// Check implements Checker for CryptoChecker
+func (c *CryptoChecker) Check(ctx context.Context) ComponentHealth {
+	if err := c.JWT.Probe(); err != nil {
+		message := "JWT probe token was rejected: check jwt.secretkey and jwt.accesstokenduration"
+		if errors.Is(err, auth.ErrEmptySecret) {
+			message = "JWT secret is empty: set jwt.secretkey (or JWT_SECRETKEY)"
+		}
+		return ComponentHealth{
+			Status:  StatusUnhealthy,
+			Message: message,
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	start := time.Now()
+	hash, err := auth.HashPassword("health-probe")
+	if err == nil {
+		err = auth.CheckPassword("health-probe", hash)
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		return ComponentHealth{
+			Status:  StatusUnhealthy,
+			Message: "password hashing failed",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		}
+	}
+
+	cost, _ := bcrypt.Cost([]byte(hash))
+	details := map[string]interface{}{
+		"hash_ms":     elapsed.Milliseconds(),
+		"bcrypt_cost": cost,
+	}
+	if c.HashBudget > 0 && elapsed > c.HashBudget {
+		return ComponentHealth{
+			Status:  StatusUnhealthy,
+			Message: fmt.Sprintf("password hashing took %s, over the %s budget: lower the bcrypt cost or add CPU", elapsed.Round(time.Millisecond), c.HashBudget),
+			Details: details,
+		}
+	}
+
+	return ComponentHealth{
+		Status:  StatusHealthy,
+		Message: "tokens and password hashes are working",
+		Details: details,
+	}
+}
+
+// HealthService manages health checks. Every checker is part of the full
+// health report; readiness checkers cover the dependencies traffic needs
+// (database, crypto) and liveness checkers the process itself (memory).
 type HealthService struct {
-	checkers map[string]Checker
+	checkers  map[string]Checker
+	readiness map[string]Checker
+	liveness  map[string]Checker
+	timeout   time.Duration // Longest a single checker may take
 }
 
+// DefaultCheckTimeout is how long a checker may take before it is reported
+// as timed out, unless SetCheckTimeout changes it
+const DefaultCheckTimeout = 5 * time.Second
+
 // NewHealthService creates a new health service
 func NewHealthService() *HealthService {
 	return &HealthService{
-		checkers: make(map[string]Checker),
+		checkers:  make(map[string]Checker),
+		readiness: make(map[string]Checker),
+		liveness:  make(map[string]Checker),
+		timeout:   DefaultCheckTimeout,
 	}
 }
 
+// SetCheckTimeout sets how long a single checker may take; <= 0 restores
+// DefaultCheckTimeout. The caller's context can only shorten it.
+func (s *HealthService) SetCheckTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	s.timeout = timeout
+}
+
 // RegisterChecker registers a new health checker
 func (s *HealthService) RegisterChecker(name string, checker Checker) {
 	s.checkers[name] = checker
 }
 
-// CheckHealth performs all health checks and returns the result
-func (s *HealthService) CheckHealth(ctx context.Context) HealthResponse {
-	components := make(map[string]ComponentHealth)
-	overallStatus := StatusHealthy
+// RegisterReadinessChecker registers a health checker that also gates readiness
+func (s *HealthService) RegisterReadinessChecker(name string, checker Checker) {
+	s.checkers[name] = checker
+	s.readiness[name] = checker
+}
 
-	// Run all checkers
-	for name, checker := range s.checkers {
-		health := checker.Check(ctx)
-		components[name] = health
+// RegisterLivenessChecker registers a health checker that also gates
+// liveness. It must be cheap and only fail when restarting the process helps.
+func (s *HealthService) RegisterLivenessChecker(name string, checker Checker) {
+	s.checkers[name] = checker
+	s.liveness[name] = checker
+}
 
-		// Determine overall status (worst case wins)
-		if health.Status == StatusUnhealthy {
-			overallStatus = StatusUnhealthy
-		} else if health.Status == StatusDegraded && overallStatus != StatusUnhealthy {
-			overallStatus = StatusDegraded
-		}
-	}
+// CheckReadiness runs the readiness checkers and returns their combined status
+func (s *HealthService) CheckReadiness(ctx context.Context) (Status, map[string]ComponentHealth) {
+	return s.runCheckers(ctx, s.readiness)
+}
+
+// CheckLiveness runs the liveness checkers and returns their combined status
+func (s *HealthService) CheckLiveness(ctx context.Context) (Status, map[string]ComponentHealth) {
+	return s.runCheckers(ctx, s.liveness)
+}
+
+// CheckHealth performs all health checks and returns the result
+func (s *HealthService) CheckHealth(ctx context.Context) HealthResponse {
+	overallStatus, components := s.runCheckers(ctx, s.checkers)
 
 	// Get system info
 	var memStats runtime.MemStats
@@ -267,6 +419,64 @@ func (s *HealthService) CheckHealth(ctx context.Context) HealthResponse {
 	}
 }
 
+// runCheckers runs checkers concurrently, each bounded by the check timeout,
+// and returns the worst status among them
+func (s *HealthService) runCheckers(ctx context.Context, checkers map[string]Checker) (Status, map[string]ComponentHealth) {
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		components = make(map[string]ComponentHealth, len(checkers))
+	)
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			health := s.runChecker(ctx, checker)
+			mu.Lock()
+			components[name] = health
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Determine overall status (worst case wins)
+	overallStatus := StatusHealthy
+	for _, health := range components {
+		if health.Status == StatusUnhealthy {
+			overallStatus = StatusUnhealthy
+		} else if health.Status == StatusDegraded && overallStatus != StatusUnhealthy {
+			overallStatus = StatusDegraded
+		}
+	}
+	return overallStatus, components
+}
+
+// runChecker runs one checker and times it. A checker still running at the
+// deadline is reported unhealthy and left to finish in the background.
+func (s *HealthService) runChecker(ctx context.Context, checker Checker) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan ComponentHealth, 1) // Buffered, so a late checker does not block forever
+	go func() { result <- checker.Check(ctx) }()
+
+	var health ComponentHealth
+	select {
+	case health = <-result:
+	case <-ctx.Done():
+		health = ComponentHealth{
+			Status:  StatusUnhealthy,
+			Message: "check timed out",
+			Details: map[string]interface{}{
+				"error": ctx.Err().Error(),
+			},
+		}
+	}
+	health.LatencyMs = round(float64(time.Since(start).Microseconds())/1000, 2)
+	return health
+}
+
 // Helper function to round float to n decimal places
 func round(val float64, precision int) float64 {
 	ratio := 1.0
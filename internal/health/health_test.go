@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCryptoChecker(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      string
+		access      time.Duration
+		budget      time.Duration
+		want        Status
+		wantMessage string
+	}{
+		{name: "working", secret: "test-secret", access: time.Minute, budget: 10 * time.Second, want: StatusHealthy},
+		{name: "empty secret", secret: "", access: time.Minute, want: StatusUnhealthy, wantMessage: "JWT secret is empty"},
+		{name: "whitespace secret", secret: " \t\n", access: time.Minute, want: StatusUnhealthy, wantMessage: "JWT secret is empty"},
+		{name: "tokens expire immediately", secret: "test-secret", access: -time.Second, want: StatusUnhealthy, wantMessage: "jwt.accesstokenduration"},
+		{name: "hashing over budget", secret: "test-secret", access: time.Minute, budget: time.Nanosecond, want: StatusUnhealthy, wantMessage: "budget"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &CryptoChecker{
+				JWT:        auth.NewJWTManager(tt.secret, tt.access, time.Hour),
+				HashBudget: tt.budget,
+			}
+
+			result := checker.Check(context.Background())
+			assert.Equal(t, tt.want, result.Status, result.Message)
+			assert.Contains(t, result.Message, tt.wantMessage)
+		})
+	}
+}
+
+// staticChecker always reports the same status
+type staticChecker Status
+
+func (s staticChecker) Check(context.Context) ComponentHealth {
+	return ComponentHealth{Status: Status(s)}
+}
+
+func TestCheckReadiness_OnlyRunsReadinessCheckers(t *testing.T) {
+	service := NewHealthService()
+	service.RegisterChecker("disk", staticChecker(StatusUnhealthy))
+	service.RegisterReadinessChecker("crypto", staticChecker(StatusHealthy))
+
+	status, components := service.CheckReadiness(context.Background())
+	assert.Equal(t, StatusHealthy, status)
+	assert.Contains(t, components, "crypto")
+	assert.NotContains(t, components, "disk")
+
+	// Readiness checkers are part of the full health report too
+	resp := service.CheckHealth(context.Background())
+	assert.Equal(t, StatusUnhealthy, resp.Status)
+	assert.Contains(t, resp.Components, "crypto")
+}
+
+func TestCheckLiveness_OnlyRunsLivenessCheckers(t *testing.T) {
+	service := NewHealthService()
+	service.RegisterReadinessChecker("database", staticChecker(StatusUnhealthy))
+	service.RegisterLivenessChecker("memory", staticChecker(StatusDegraded))
+
+	status, components := service.CheckLiveness(context.Background())
+	assert.Equal(t, StatusDegraded, status, "a failing dependency does not fail liveness")
+	assert.NotContains(t, components, "database")
+}
+
+// slowChecker reports healthy after sleeping
+type slowChecker time.Duration
+
+func (s slowChecker) Check(context.Context) ComponentHealth {
+	time.Sleep(time.Duration(s))
+	return ComponentHealth{Status: StatusHealthy}
+}
+
+func TestCheckHealth_ReportsLatency(t *testing.T) {
+	service := NewHealthService()
+	service.RegisterChecker("slow", slowChecker(20*time.Millisecond))
+
+	resp := service.CheckHealth(context.Background())
+	assert.GreaterOrEqual(t, resp.Components["slow"].LatencyMs, 20.0)
+}
+
+func TestCheckHealth_TimesOutSlowCheckers(t *testing.T) {
+	service := NewHealthService()
+	service.SetCheckTimeout(50 * time.Millisecond)
+	service.RegisterChecker("hung", slowChecker(2*time.Second)) // Ignores its context, like a stuck driver call
+	service.RegisterChecker("memory", staticChecker(StatusHealthy))
+	service.RegisterChecker("disk", staticChecker(StatusDegraded))
+
+	start := time.Now()
+	resp := service.CheckHealth(context.Background())
+	assert.Less(t, time.Since(start), time.Second, "the response does not wait for the hung checker")
+
+	assert.Equal(t, StatusUnhealthy, resp.Status, "worst status wins")
+	assert.Equal(t, StatusUnhealthy, resp.Components["hung"].Status)
+	assert.Equal(t, "check timed out", resp.Components["hung"].Message)
+	assert.Equal(t, StatusHealthy, resp.Components["memory"].Status)
+	assert.Equal(t, StatusDegraded, resp.Components["disk"].Status)
+}
+
+func TestCheckHealth_CallerContextShortensTimeout(t *testing.T) {
+	service := NewHealthService()
+	service.RegisterChecker("hung", slowChecker(2*time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resp := service.CheckHealth(ctx)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StatusUnhealthy, resp.Components["hung"].Status)
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// httpCheckMaxBody is the most of a response body searched for BodyContains
+const httpCheckMaxBody = 64 << 10
+
+// HTTPChecker checks a downstream HTTP service, e.g. the notification service
+type HTTPChecker struct {
+	URL            string
+	Method         string        // Defaults to GET
+	ExpectedStatus []int         // Accepted status codes; empty accepts any 2xx
+	Timeout        time.Duration // Defaults to 5s
+	WarnThreshold  time.Duration // Successful responses slower than this are degraded; 0 disables
+	BodyContains   string        // Substring the response body must contain; empty skips the check
+	Client         *http.Client  // Defaults to http.DefaultClient
+}
+
+// Check implements Checker for HTTPChecker
+func (h *HTTPChecker) Check(ctx context.Context) ComponentHealth {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	details := map[string]interface{}{
+		"url": redactURL(h.URL),
+	}
+	unhealthy := func(message string, err error) ComponentHealth {
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		return ComponentHealth{Status: StatusUnhealthy, Message: message, Details: details}
+	}
+
+	method := h.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, nil)
+	if err != nil {
+		return unhealthy("invalid health check request", err)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		details["latency_ms"] = time.Since(start).Milliseconds()
+		return unhealthy("service unreachable", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpCheckMaxBody))
+	elapsed := time.Since(start)
+	details["latency_ms"] = elapsed.Milliseconds()
+	details["status_code"] = resp.StatusCode
+	if err != nil {
+		return unhealthy("failed to read response", err)
+	}
+
+	if !h.expectedStatus(resp.StatusCode) {
+		return unhealthy(fmt.Sprintf("unexpected status %d", resp.StatusCode), nil)
+	}
+	if h.BodyContains != "" && !bytes.Contains(body, []byte(h.BodyContains)) {
+		return unhealthy(fmt.Sprintf("response does not contain %q", h.BodyContains), nil)
+	}
+	if h.WarnThreshold > 0 && elapsed > h.WarnThreshold {
+		return ComponentHealth{
+			Status:  StatusDegraded,
+			Message: fmt.Sprintf("response took %s, over the %s threshold", elapsed.Round(time.Millisecond), h.WarnThreshold),
+			Details: details,
+		}
+	}
+
+	return ComponentHealth{
+		Status:  StatusHealthy,
+		Message: "service is reachable",
+		Details: details,
+	}
+}
+
+// expectedStatus reports whether code is one of ExpectedStatus, or 2xx if it is empty
+func (h *HTTPChecker) expectedStatus(code int) bool {
+	if len(h.ExpectedStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(h.ExpectedStatus, code)
+}
+
+// redactURL hides the password and query of a URL shown in health details
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "" // Might hold credentials
+	}
+	u.RawQuery = ""
+	return u.Redacted()
+}
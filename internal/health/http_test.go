@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPChecker(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"status":"ok"}`)) })
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/teapot", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// A closed server refuses connections
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		checker HTTPChecker
+		want    Status
+	}{
+		{"200", HTTPChecker{URL: server.URL + "/ok"}, StatusHealthy},
+		{"500", HTTPChecker{URL: server.URL + "/fail"}, StatusUnhealthy},
+		{"500 expected", HTTPChecker{URL: server.URL + "/fail", ExpectedStatus: []int{http.StatusInternalServerError}}, StatusHealthy},
+		{"2xx not in expected", HTTPChecker{URL: server.URL + "/ok", ExpectedStatus: []int{http.StatusTeapot}}, StatusUnhealthy},
+		{"non-2xx", HTTPChecker{URL: server.URL + "/teapot"}, StatusUnhealthy},
+		{"body matches", HTTPChecker{URL: server.URL + "/ok", BodyContains: `"ok"`}, StatusHealthy},
+		{"body does not match", HTTPChecker{URL: server.URL + "/ok", BodyContains: "ready"}, StatusUnhealthy},
+		{"slow but successful", HTTPChecker{URL: server.URL + "/slow", WarnThreshold: 10 * time.Millisecond}, StatusDegraded},
+		{"slower than the timeout", HTTPChecker{URL: server.URL + "/slow", Timeout: 10 * time.Millisecond}, StatusUnhealthy},
+		{"connection refused", HTTPChecker{URL: closedURL}, StatusUnhealthy},
+		{"invalid method", HTTPChecker{URL: server.URL, Method: "BAD METHOD"}, StatusUnhealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.checker.Check(context.Background())
+			assert.Equal(t, tt.want, result.Status, result.Message)
+			assert.Contains(t, result.Details, "url")
+		})
+	}
+}
+
+func TestHTTPChecker_ReportsStatusAndLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	checker := &HTTPChecker{URL: server.URL + "/health?token=secret", Method: http.MethodHead}
+	result := checker.Check(context.Background())
+	assert.Equal(t, StatusHealthy, result.Status)
+	assert.Equal(t, http.StatusAccepted, result.Details["status_code"])
+	assert.Contains(t, result.Details, "latency_ms")
+	assert.Equal(t, server.URL+"/health", result.Details["url"], "the query may hold credentials")
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/websocket"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// WatcherConfig configures the health status watcher
+type WatcherConfig struct {
+	Interval time.Duration // Time between checks; <= 0 disables the watcher
+}
+
+// Broadcaster is the part of the WebSocket hub used by Watcher
+type Broadcaster interface {
+	BroadcastToMinimumRole(role models.Role, eventType websocket.EventType, data map[string]interface{})
+}
+
+// healthReporter runs every health check, e.g. HealthService
+type healthReporter interface {
+	CheckHealth(ctx context.Context) HealthResponse
+}
+
+// Watcher checks the overall health status every Interval and broadcasts
+// health.status.changed to admins when it changes. A new status is only
+// reported once two consecutive checks agree on it, so a component flapping
+// between checks does not flood clients.
+type Watcher struct {
+	reporter  healthReporter
+	hub       Broadcaster
+	config    WatcherConfig
+	newTicker func(time.Duration) (<-chan time.Time, func()) // Replaced in tests
+
+	// Only used by the goroutine running Run, or by the caller of Check
+	reported Status // Last status reported, or seen by the first check
+	pending  Status // Status seen by the previous check only, waiting for confirmation
+}
+
+// NewWatcher creates a watcher reporting the status of reporter through hub
+func NewWatcher(reporter healthReporter, hub Broadcaster, config WatcherConfig) *Watcher {
+	return &Watcher{
+		reporter: reporter,
+		hub:      hub,
+		config:   config,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(d)
+			return ticker.C, ticker.Stop
+		},
+	}
+}
+
+// Enabled reports whether the watcher checks anything
+func (w *Watcher) Enabled() bool {
+	return w.config.Interval > 0
+}
+
+// Run checks the status now and then every Interval until ctx is done. The
+// first check only records the status. It returns at once when disabled.
+func (w *Watcher) Run(ctx context.Context) {
+	if !w.Enabled() {
+		return
+	}
+	ticks, stop := w.newTicker(w.config.Interval)
+	defer stop()
+
+	w.Check(ctx)
+	for {
+		select {
+		case <-ticks:
+			w.Check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check runs the health checks once and reports whether a status change was
+// broadcast
+func (w *Watcher) Check(ctx context.Context) bool {
+	report := w.reporter.CheckHealth(ctx)
+	status := report.Status
+
+	switch {
+	case w.reported == "":
+		w.reported = status
+		return false
+	case status == w.reported:
+		w.pending = ""
+		return false
+	case status != w.pending:
+		w.pending = status
+		return false
+	}
+
+	previous := w.reported
+	w.reported, w.pending = status, ""
+
+	failing := make(map[string]ComponentHealth)
+	for name, component := range report.Components {
+		if component.Status != StatusHealthy {
+			failing[name] = component
+		}
+	}
+	if status == StatusHealthy {
+		logger.Info("Health status changed", "previous", previous, "status", status)
+	} else {
+		logger.Warn("Health status changed", "previous", previous, "status", status, "failing", len(failing))
+	}
+	w.hub.BroadcastToMinimumRole(models.RoleAdmin, websocket.EventHealthStatusChanged, map[string]interface{}{
+		"previous_status": previous,
+		"status":          status,
+		"components":      failing,
+		"checked_at":      report.Timestamp,
+	})
+	return true
+}
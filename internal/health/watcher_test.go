@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchChecker reports whatever status it was last set to
+type switchChecker struct {
+	mu     sync.Mutex
+	status Status
+}
+
+func (s *switchChecker) Set(status Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *switchChecker) Check(context.Context) ComponentHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ComponentHealth{Status: s.status, Message: "database is " + string(s.status)}
+}
+
+// broadcast is a message captured by recordingHub
+type broadcast struct {
+	role      models.Role
+	eventType websocket.EventType
+	data      map[string]interface{}
+}
+
+// recordingHub captures broadcasts instead of sending them
+type recordingHub struct {
+	mu   sync.Mutex
+	sent []broadcast
+}
+
+func (h *recordingHub) BroadcastToMinimumRole(role models.Role, eventType websocket.EventType, data map[string]interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sent = append(h.sent, broadcast{role: role, eventType: eventType, data: data})
+}
+
+func (h *recordingHub) Sent() []broadcast {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]broadcast(nil), h.sent...)
+}
+
+// newTestWatcher watches a service with a healthy "disk" and a switchable
+// "database" component
+func newTestWatcher() (*Watcher, *switchChecker, *recordingHub) {
+	database := &switchChecker{status: StatusHealthy}
+	service := NewHealthService()
+	service.RegisterChecker("disk", staticChecker(StatusHealthy))
+	service.RegisterChecker("database", database)
+
+	hub := &recordingHub{}
+	return NewWatcher(service, hub, WatcherConfig{Interval: time.Hour}), database, hub
+}
+
+func TestWatcher_BroadcastsConfirmedChanges(t *testing.T) {
+	watcher, database, hub := newTestWatcher()
+	ctx := context.Background()
+
+	assert.False(t, watcher.Check(ctx), "first check only records the status")
+	assert.False(t, watcher.Check(ctx))
+
+	database.Set(StatusDegraded)
+	assert.False(t, watcher.Check(ctx), "one check is not enough")
+	assert.True(t, watcher.Check(ctx))
+	assert.False(t, watcher.Check(ctx), "reported once")
+
+	database.Set(StatusUnhealthy)
+	watcher.Check(ctx)
+	watcher.Check(ctx)
+
+	database.Set(StatusHealthy)
+	watcher.Check(ctx)
+	watcher.Check(ctx)
+
+	sent := hub.Sent()
+	require.Len(t, sent, 3)
+	for _, msg := range sent {
+		assert.Equal(t, models.RoleAdmin, msg.role, "admins and superadmins")
+		assert.Equal(t, websocket.EventHealthStatusChanged, msg.eventType)
+	}
+
+	assert.Equal(t, StatusHealthy, sent[0].data["previous_status"])
+	assert.Equal(t, StatusDegraded, sent[0].data["status"])
+	failing := sent[0].data["components"].(map[string]ComponentHealth)
+	require.Len(t, failing, 1, "only failing components")
+	assert.Equal(t, StatusDegraded, failing["database"].Status)
+	assert.Equal(t, "database is degraded", failing["database"].Message)
+	assert.NotEmpty(t, sent[0].data["checked_at"])
+
+	assert.Equal(t, StatusDegraded, sent[1].data["previous_status"])
+	assert.Equal(t, StatusUnhealthy, sent[1].data["status"])
+
+	assert.Equal(t, StatusUnhealthy, sent[2].data["previous_status"])
+	assert.Equal(t, StatusHealthy, sent[2].data["status"])
+	assert.Empty(t, sent[2].data["components"])
+}
+
+func TestWatcher_IgnoresFlapping(t *testing.T) {
+	watcher, database, hub := newTestWatcher()
+	ctx := context.Background()
+	watcher.Check(ctx)
+
+	for _, status := range []Status{StatusDegraded, StatusHealthy, StatusUnhealthy, StatusDegraded, StatusHealthy} {
+		database.Set(status)
+		assert.False(t, watcher.Check(ctx), status)
+	}
+	assert.Empty(t, hub.Sent())
+}
+
+func TestWatcher_RunChecksOnStartAndEveryTick(t *testing.T) {
+	watcher, database, hub := newTestWatcher()
+	ticks := make(chan time.Time)
+	watcher.newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+
+	database.Set(StatusUnhealthy) // Status at startup is not a change
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	ticks <- time.Now()
+	database.Set(StatusHealthy)
+	ticks <- time.Now()
+	ticks <- time.Now()
+	ticks <- time.Now() // Received once the previous check is done
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after the context was canceled")
+	}
+	sent := hub.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, StatusUnhealthy, sent[0].data["previous_status"])
+	assert.Equal(t, StatusHealthy, sent[0].data["status"])
+}
+
+func TestWatcher_DisabledRunReturns(t *testing.T) {
+	hub := &recordingHub{}
+	watcher := NewWatcher(NewHealthService(), hub, WatcherConfig{})
+	assert.False(t, watcher.Enabled())
+
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("disabled watcher must return at once")
+	}
+	assert.Empty(t, hub.Sent())
+}
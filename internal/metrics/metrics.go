@@ -15,56 +15,253 @@ import (
 
 // Metrics holds all Prometheus metrics
 type Metrics struct {
-	HTTPRequestsTotal   *prometheus.CounterVec
-	HTTPRequestDuration *prometheus.HistogramVec
-	HTTPRequestSize     *prometheus.SummaryVec
-	HTTPResponseSize    *prometheus.SummaryVec
-	ActiveConnections   prometheus.Gauge
+	HTTPRequestsTotal    *prometheus.CounterVec
+	HTTPRequestDuration  *prometheus.HistogramVec
+	HTTPRequestSize      *prometheus.SummaryVec
+	HTTPResponseSize     *prometheus.SummaryVec
+	HTTPResponseTooLarge *prometheus.CounterVec
+	ActiveConnections    prometheus.Gauge
+
+	WebSocketConnectedClients *prometheus.GaugeVec
+	WebSocketMessagesSent     *prometheus.CounterVec
+	WebSocketMessagesDropped  *prometheus.CounterVec
+	WebSocketDisconnects      *prometheus.CounterVec
+
+	ThrottledRequestsInFlight *prometheus.GaugeVec
+	ThrottledRequestsRejected *prometheus.CounterVec
+	UserRequestsInFlight      *prometheus.GaugeVec
+	UserRequestsRejected      prometheus.Counter
+	RBACShadowDenials         *prometheus.CounterVec
+
+	UserBatchSize         prometheus.Histogram
+	UserBatchItemDuration prometheus.Histogram
+	UserBatchFailures     *prometheus.CounterVec
+
+	DBQueryDuration *prometheus.HistogramVec
+
+	sizeSummaries bool
 }
 
-// NewMetrics creates and registers all Prometheus metrics
-func NewMetrics() *Metrics {
+// Config selects optional metrics
+type Config struct {
+	SizeSummaries bool // Record http_request_size_bytes and http_response_size_bytes
+}
+
+// NotFoundEndpoint is the endpoint label of requests that matched no route
+// (404) or no method of their route (405), so that scans of random paths
+// add no series
+const NotFoundEndpoint = "not_found"
+
+// NewMetrics creates all Prometheus metrics and registers them with the
+// default registerer
+func NewMetrics(cfg Config) *Metrics {
+	return NewMetricsWith(prometheus.DefaultRegisterer, cfg)
+}
+
+// NewMetricsWith creates all Prometheus metrics and registers them with reg,
+// e.g. a test registry
+func NewMetricsWith(reg prometheus.Registerer, cfg Config) *Metrics {
+	factory := promauto.With(reg)
 	m := &Metrics{
-		HTTPRequestsTotal: promauto.NewCounterVec(
+		HTTPRequestsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "http_requests_total",
-				Help: "Total number of HTTP requests by method, endpoint, and status code",
+				Help: "Total number of HTTP requests by method, endpoint, status code and status class (2xx, 4xx, ...)",
 			},
-			[]string{"method", "endpoint", "status"},
+			[]string{"method", "endpoint", "status", "status_class"},
 		),
-		HTTPRequestDuration: promauto.NewHistogramVec(
+		HTTPRequestDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "http_request_duration_seconds",
-				Help:    "Duration of HTTP requests in seconds",
+				Help:    "Duration of HTTP requests in seconds by method, endpoint and status class",
 				Buckets: prometheus.DefBuckets, // [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
 			},
-			[]string{"method", "endpoint", "status"},
+			[]string{"method", "endpoint", "status_class"},
 		),
-		HTTPRequestSize: promauto.NewSummaryVec(
+		HTTPRequestSize: factory.NewSummaryVec(
 			prometheus.SummaryOpts{
 				Name: "http_request_size_bytes",
 				Help: "Size of HTTP request in bytes",
 			},
 			[]string{"method", "endpoint"},
 		),
-		HTTPResponseSize: promauto.NewSummaryVec(
+		HTTPResponseSize: factory.NewSummaryVec(
 			prometheus.SummaryOpts{
 				Name: "http_response_size_bytes",
 				Help: "Size of HTTP response in bytes",
 			},
 			[]string{"method", "endpoint"},
 		),
-		ActiveConnections: promauto.NewGauge(
+		HTTPResponseTooLarge: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_responses_too_large_total",
+				Help: "Total number of responses replaced because their body exceeded the route's size ceiling",
+			},
+			[]string{"method", "endpoint"},
+		),
+		ActiveConnections: factory.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "http_active_connections",
 				Help: "Number of active HTTP connections",
 			},
 		),
+		WebSocketConnectedClients: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "websocket_connected_clients",
+				Help: "Number of connected WebSocket clients, by role",
+			},
+			[]string{"role"},
+		),
+		WebSocketMessagesSent: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "websocket_messages_sent_total",
+				Help: "Total number of messages written to WebSocket clients, by event type",
+			},
+			[]string{"type"},
+		),
+		WebSocketMessagesDropped: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "websocket_messages_dropped_total",
+				Help: "Total number of WebSocket messages dropped due to full buffers, by reason",
+			},
+			[]string{"reason"},
+		),
+		WebSocketDisconnects: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "websocket_disconnects_total",
+				Help: "Total number of WebSocket clients disconnected, by reason (client_closed, slow_client, stale_client, user_disconnected, shutdown)",
+			},
+			[]string{"reason"},
+		),
+		ThrottledRequestsInFlight: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "throttled_requests_in_flight",
+				Help: "Number of in-flight requests per concurrency-limited endpoint group",
+			},
+			[]string{"group"},
+		),
+		ThrottledRequestsRejected: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "throttled_requests_rejected_total",
+				Help: "Total number of requests rejected because their endpoint group was saturated",
+			},
+			[]string{"group"},
+		),
+		UserRequestsInFlight: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "user_requests_in_flight",
+				Help: "Number of in-flight requests per authenticated user; users without requests have no series",
+			},
+			[]string{"user_id"},
+		),
+		UserRequestsRejected: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "user_requests_rejected_total",
+				Help: "Total number of requests rejected because their user was at the per-user concurrency limit",
+			},
+		),
+		RBACShadowDenials: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rbac_shadow_denials_total",
+				Help: "Total number of requests a shadow-mode RBAC rule would have denied, by route and role",
+			},
+			[]string{"route", "role"},
+		),
+		UserBatchSize: factory.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "user_batch_create_size",
+				Help:    "Number of users per batch create request",
+				Buckets: []float64{1, 5, 10, 25, 50, 100},
+			},
+		),
+		UserBatchItemDuration: factory.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "user_batch_create_item_duration_seconds",
+				Help:    "Time to create one user of a batch, including the wait for a free slot",
+				Buckets: prometheus.DefBuckets,
+			},
+		),
+		UserBatchFailures: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "user_batch_create_failures_total",
+				Help: "Total number of users a batch failed to create, by reason (timeout, canceled, error)",
+			},
+			[]string{"reason"},
+		),
+		DBQueryDuration: newDBQueryDuration(factory),
+
+		sizeSummaries: cfg.SizeSummaries,
 	}
 
 	return m
 }
 
+// WebSocketRecorder reports WebSocket hub activity to the metrics; it
+// implements websocket.HubMetrics
+type WebSocketRecorder struct {
+	metrics *Metrics
+}
+
+// WebSocketRecorder returns a recorder for the WebSocket hub
+func (m *Metrics) WebSocketRecorder() WebSocketRecorder {
+	return WebSocketRecorder{metrics: m}
+}
+
+// ClientConnected counts a connected client
+func (r WebSocketRecorder) ClientConnected(role string) {
+	r.metrics.WebSocketConnectedClients.WithLabelValues(role).Inc()
+}
+
+// ClientDisconnected uncounts a client and records why it left
+func (r WebSocketRecorder) ClientDisconnected(role, reason string) {
+	r.metrics.WebSocketConnectedClients.WithLabelValues(role).Dec()
+	r.metrics.WebSocketDisconnects.WithLabelValues(reason).Inc()
+}
+
+// MessageSent counts a message written to a client
+func (r WebSocketRecorder) MessageSent(eventType string) {
+	r.metrics.WebSocketMessagesSent.WithLabelValues(eventType).Inc()
+}
+
+// MessageDropped counts a message dropped because a buffer was full
+func (r WebSocketRecorder) MessageDropped(reason string) {
+	r.metrics.WebSocketMessagesDropped.WithLabelValues(reason).Inc()
+}
+
+// newDBQueryDuration creates the db_query_duration_seconds histogram with factory
+func newDBQueryDuration(factory promauto.Factory) *prometheus.HistogramVec {
+	return factory.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of database statements in seconds, by operation (create, query, update, delete) and table",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"operation", "table"},
+	)
+}
+
+// DBRecorder records database statement durations; it implements
+// database.QueryObserver
+type DBRecorder struct {
+	duration *prometheus.HistogramVec
+}
+
+// DBRecorder returns a recorder for the histogram registered by NewMetrics
+func (m *Metrics) DBRecorder() DBRecorder {
+	return DBRecorder{duration: m.DBQueryDuration}
+}
+
+// NewDBRecorder returns a recorder whose histogram is registered with reg,
+// e.g. a test registry
+func NewDBRecorder(reg prometheus.Registerer) DBRecorder {
+	return DBRecorder{duration: newDBQueryDuration(promauto.With(reg))}
+}
+
+// QueryExecuted observes the duration of one statement
+func (r DBRecorder) QueryExecuted(operation, table string, duration time.Duration) {
+	r.duration.WithLabelValues(operation, table).Observe(duration.Seconds())
+}
+
 // Middleware creates a Gin middleware that records metrics for each request
 func (m *Metrics) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -85,19 +282,46 @@ func (m *Metrics) Middleware() gin.HandlerFunc {
 		duration := time.Since(start).Seconds()
 
 		// Get status code and endpoint
-		status := strconv.Itoa(c.Writer.Status())
+		status := c.Writer.Status()
 		endpoint := c.FullPath()
-		if endpoint == "" {
-			endpoint = "unknown"
+		matched := endpoint != ""
+		if !matched {
+			endpoint = NotFoundEndpoint
 		}
-		method := c.Request.Method
+		method := methodLabel(c.Request.Method)
+		class := statusClass(status)
 
 		// Record metrics
-		m.HTTPRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
-		m.HTTPRequestDuration.WithLabelValues(method, endpoint, status).Observe(duration)
-		m.HTTPRequestSize.WithLabelValues(method, endpoint).Observe(float64(requestSize))
-		m.HTTPResponseSize.WithLabelValues(method, endpoint).Observe(float64(c.Writer.Size()))
+		m.HTTPRequestsTotal.WithLabelValues(method, endpoint, strconv.Itoa(status), class).Inc()
+		m.HTTPRequestDuration.WithLabelValues(method, endpoint, class).Observe(duration)
+		if matched && m.sizeSummaries {
+			m.HTTPRequestSize.WithLabelValues(method, endpoint).Observe(float64(requestSize))
+			m.HTTPResponseSize.WithLabelValues(method, endpoint).Observe(float64(c.Writer.Size()))
+		}
+	}
+}
+
+// standardMethods are the methods reported by name; others count as "OTHER"
+var standardMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodConnect: true,
+	http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// methodLabel keeps made-up methods of unmatched requests out of the labels
+func methodLabel(method string) string {
+	if standardMethods[method] {
+		return method
+	}
+	return "OTHER"
+}
+
+// statusClass returns "2xx" for 204 and so on
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
 	}
+	return strconv.Itoa(status/100) + "xx"
 }
 
 // computeApproximateRequestSize computes the approximate size of the request
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve sends requests through a router with the metrics middleware and one
+// route, GET /users/:id, answering with the status in the status query parameter
+func serve(t *testing.T, cfg Config, requests ...*http.Request) *prometheus.Registry {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	m := NewMetricsWith(registry, cfg)
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/users/:id", func(c *gin.Context) {
+		status := http.StatusOK
+		if c.Query("status") == "500" {
+			status = http.StatusInternalServerError
+		}
+		c.String(status, "user")
+	})
+
+	for _, req := range requests {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	return registry
+}
+
+// labelSets returns the label sets of every series of the metric family name,
+// each written as "key=value,..." and sorted
+func labelSets(t *testing.T, registry *prometheus.Registry, name string) []string {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	var sets []string
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var pairs []string
+			for _, label := range metric.GetLabel() {
+				pairs = append(pairs, label.GetName()+"="+label.GetValue())
+			}
+			sets = append(sets, strings.Join(pairs, ","))
+		}
+	}
+	sort.Strings(sets)
+	return sets
+}
+
+func TestMiddleware_Labels(t *testing.T) {
+	registry := serve(t, Config{SizeSummaries: true},
+		httptest.NewRequest("GET", "/users/1", nil),
+		httptest.NewRequest("GET", "/users/2", nil),
+		httptest.NewRequest("GET", "/users/3?status=500", nil),
+	)
+
+	assert.Equal(t, []string{
+		"endpoint=/users/:id,method=GET,status=200,status_class=2xx",
+		"endpoint=/users/:id,method=GET,status=500,status_class=5xx",
+	}, labelSets(t, registry, "http_requests_total"))
+	assert.Equal(t, []string{
+		"endpoint=/users/:id,method=GET,status_class=2xx",
+		"endpoint=/users/:id,method=GET,status_class=5xx",
+	}, labelSets(t, registry, "http_request_duration_seconds"))
+	assert.Equal(t, []string{"endpoint=/users/:id,method=GET"}, labelSets(t, registry, "http_request_size_bytes"))
+	assert.Equal(t, []string{"endpoint=/users/:id,method=GET"}, labelSets(t, registry, "http_response_size_bytes"))
+}
+
+func TestMiddleware_UnmatchedRoutes(t *testing.T) {
+	var scan []*http.Request
+	for _, path := range []string{"/wp-login.php", "/.env", "/admin/config.php", "/users/1/../../etc/passwd"} {
+		scan = append(scan, httptest.NewRequest("GET", path, nil))
+	}
+	scan = append(scan, httptest.NewRequest("PROPFIND", "/webdav", nil), httptest.NewRequest("BREW", "/coffee", nil))
+	registry := serve(t, Config{SizeSummaries: true}, scan...)
+
+	assert.Equal(t, []string{
+		"endpoint=not_found,method=GET,status=404,status_class=4xx",
+		"endpoint=not_found,method=OTHER,status=404,status_class=4xx",
+	}, labelSets(t, registry, "http_requests_total"))
+	assert.Equal(t, []string{
+		"endpoint=not_found,method=GET,status_class=4xx",
+		"endpoint=not_found,method=OTHER,status_class=4xx",
+	}, labelSets(t, registry, "http_request_duration_seconds"))
+	assert.Empty(t, labelSets(t, registry, "http_request_size_bytes"), "no sizes for unmatched routes")
+	assert.Empty(t, labelSets(t, registry, "http_response_size_bytes"))
+}
+
+func TestMiddleware_NoRouteAndNoMethodHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := prometheus.NewRegistry()
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.Use(NewMetricsWith(registry, Config{}).Middleware())
+	router.NoRoute(func(c *gin.Context) { c.JSON(http.StatusNotFound, gin.H{"message": "route not found"}) })
+	router.NoMethod(func(c *gin.Context) { c.JSON(http.StatusMethodNotAllowed, gin.H{"message": "method not allowed"}) })
+	router.POST("/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/bogus", nil),
+		httptest.NewRequest("DELETE", "/auth/login", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []string{
+		"endpoint=not_found,method=DELETE,status=405,status_class=4xx",
+		"endpoint=not_found,method=GET,status=404,status_class=4xx",
+	}, labelSets(t, registry, "http_requests_total"))
+}
+
+func TestMiddleware_SizeSummariesDisabled(t *testing.T) {
+	registry := serve(t, Config{SizeSummaries: false}, httptest.NewRequest("GET", "/users/1", nil))
+
+	assert.Len(t, labelSets(t, registry, "http_requests_total"), 1)
+	assert.Empty(t, labelSets(t, registry, "http_request_size_bytes"))
+	assert.Empty(t, labelSets(t, registry, "http_response_size_bytes"))
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, want := range map[int]string{101: "1xx", 204: "2xx", 302: "3xx", 429: "4xx", 503: "5xx", 0: "unknown", 600: "unknown"} {
+		assert.Equal(t, want, statusClass(status), status)
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditResourceIDKey is the context key handlers set to the ID of the
+// resource they created, which has no ID in the route for AuditTrail to read
+const AuditResourceIDKey = "audit_resource_id"
+
+// auditTrailMaxBody is the largest request body copied into audit details
+const auditTrailMaxBody = 64 << 10
+
+// AuditLogger writes audit log entries, e.g. services.AuditService.
+// LogAction is called on the request path and must not block.
+type AuditLogger interface {
+	LogAction(c *gin.Context, userID *models.ID, action models.AuditAction, resource models.AuditResource, resourceID *models.ID, details interface{}, success bool, errorMsg string)
+}
+
+// auditTrailRoute is the audit action of one route of the /users group
+type auditTrailRoute struct {
+	action   models.AuditAction
+	resource models.AuditResource
+}
+
+// auditTrailRoutes maps "METHOD route" below the API prefix to its action.
+// Routes whose handlers write their own, richer entries (activation,
+// password changes and resets, offboarding, bulk deletes and role changes)
+// are left out.
+var auditTrailRoutes = map[string]auditTrailRoute{
+	"POST /users":             {models.AuditActionUserCreate, models.AuditResourceUser},
+	"POST /users/batch":       {models.AuditActionUserBatchCreate, models.AuditResourceUser},
+	"POST /users/import":      {models.AuditActionUserBatchCreate, models.AuditResourceUser},
+	"PUT /users/:id":          {models.AuditActionUserUpdate, models.AuditResourceUser},
+	"DELETE /users/:id":       {models.AuditActionUserDelete, models.AuditResourceUser},
+	"POST /users/:id/restore": {models.AuditActionUserRestore, models.AuditResourceUser},
+	"DELETE /users/:id/purge": {models.AuditActionUserPurge, models.AuditResourceUser},
+	"PUT /users/:id/role":     {models.AuditActionRoleChange, models.AuditResourceUser},
+	"PUT /users/me":           {models.AuditActionProfileUpdate, models.AuditResourceProfile},
+}
+
+// AuditTrail middleware records the mutating requests of the /users group in
+// the audit log: the acting user, the target from the :id parameter (the
+// actor for /me routes, AuditResourceIDKey for creations), the response
+// status as success (2xx) and the JSON body with passwords redacted. It must
+// run after JWTAuth; requests rejected by RBAC are recorded as failures.
+func AuditTrail(auditLogger AuditLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, ok := auditTrailRoutes[c.Request.Method+" "+auditTrailPath(c.FullPath())]
+		if !ok {
+			c.Next()
+			return
+		}
+		body := auditTrailBody(c)
+
+		c.Next()
+
+		var actorID *models.ID
+		if id, ok := c.Get("user_id"); ok {
+			if id, ok := id.(uint); ok {
+				actorID = models.IDPtr(&id)
+			}
+		}
+		status := c.Writer.Status()
+		success := status >= 200 && status < 300
+		errorMsg := ""
+		if !success {
+			errorMsg = http.StatusText(status)
+		}
+		details := &models.AuditTrailRequest{
+			Method: c.Request.Method,
+			Route:  c.FullPath(),
+			Status: status,
+			Body:   body,
+		}
+		auditLogger.LogAction(c, actorID, route.action, route.resource, auditTrailResourceID(c, actorID), details, success, errorMsg)
+	}
+}
+
+// auditTrailPath returns the route from /users on, so that /api/v1 and
+// /api/v2 share the route table
+func auditTrailPath(fullPath string) string {
+	if i := strings.Index(fullPath, "/users"); i >= 0 {
+		return fullPath[i:]
+	}
+	return fullPath
+}
+
+// auditTrailResourceID returns the ID of the affected resource, or nil
+func auditTrailResourceID(c *gin.Context, actorID *models.ID) *models.ID {
+	if id, ok := c.Get(AuditResourceIDKey); ok {
+		switch id := id.(type) {
+		case models.ID:
+			return &id
+		case uint:
+			return models.IDPtr(&id)
+		}
+	}
+	if param := c.Param("id"); param != "" {
+		if id, err := strconv.ParseUint(param, 10, 0); err == nil {
+			resourceID := models.ID(id)
+			return &resourceID
+		}
+		return nil
+	}
+	if strings.HasSuffix(c.FullPath(), "/me") {
+		return actorID
+	}
+	return nil
+}
+
+// auditTrailBody reads the JSON request body, puts it back for the handler
+// and returns it with passwords redacted. Bodies that are not JSON or larger
+// than auditTrailMaxBody are not recorded.
+func auditTrailBody(c *gin.Context) interface{} {
+	if c.Request.Body == nil || c.ContentType() != gin.MIMEJSON {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, auditTrailMaxBody+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), c.Request.Body))
+	if err != nil || len(data) == 0 || len(data) > auditTrailMaxBody {
+		return nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil
+	}
+	return redactPasswords(body)
+}
+
+// redactPasswords replaces the value of every key containing "password",
+// at any depth, with "[REDACTED]"
+func redactPasswords(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if strings.Contains(strings.ToLower(key), "password") {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactPasswords(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactPasswords(item)
+		}
+	}
+	return value
+}
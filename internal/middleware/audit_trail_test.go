@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditTrailEntry is an entry recorded by auditTrailRecorder
+type auditTrailEntry struct {
+	userID, resourceID *models.ID
+	action             models.AuditAction
+	details            *models.AuditTrailRequest
+	success            bool
+}
+
+// auditTrailRecorder is an AuditLogger that keeps its entries in memory
+type auditTrailRecorder struct {
+	entries []auditTrailEntry
+}
+
+func (r *auditTrailRecorder) LogAction(c *gin.Context, userID *models.ID, action models.AuditAction, resource models.AuditResource, resourceID *models.ID, details interface{}, success bool, errorMsg string) {
+	r.entries = append(r.entries, auditTrailEntry{userID, resourceID, action, details.(*models.AuditTrailRequest), success})
+}
+
+func TestAuditTrail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := &auditTrailRecorder{}
+	var handlerBody string
+
+	router := gin.New()
+	users := router.Group("/api/v1/users")
+	users.Use(func(c *gin.Context) { c.Set("user_id", uint(7)) }, AuditTrail(recorder))
+	users.GET("/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	users.PUT("/:id", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		handlerBody = string(body)
+		c.Status(http.StatusOK)
+	})
+	users.DELETE("/:id", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+
+	send := func(method, path, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send(http.MethodGet, "/api/v1/users/3", "")
+	send(http.MethodPut, "/api/v1/users/3", `{"name":"Ada","password":"secret","nested":{"NewPassword":"x"}}`)
+	send(http.MethodDelete, "/api/v1/users/3", "")
+
+	require.Len(t, recorder.entries, 2, "reads are not audited")
+	update := recorder.entries[0]
+	assert.Equal(t, models.AuditActionUserUpdate, update.action)
+	assert.Equal(t, models.ID(7), *update.userID)
+	assert.Equal(t, models.ID(3), *update.resourceID)
+	assert.True(t, update.success)
+	assert.Equal(t, "/api/v1/users/:id", update.details.Route)
+	assert.Equal(t, map[string]interface{}{
+		"name":     "Ada",
+		"password": "[REDACTED]",
+		"nested":   map[string]interface{}{"NewPassword": "[REDACTED]"},
+	}, update.details.Body)
+	assert.Contains(t, handlerBody, `"password":"secret"`, "the handler still reads the original body")
+
+	deleted := recorder.entries[1]
+	assert.Equal(t, models.AuditActionUserDelete, deleted.action)
+	assert.False(t, deleted.success)
+	assert.Equal(t, http.StatusForbidden, deleted.details.Status)
+}
@@ -9,7 +9,9 @@ import (
 
 	"Go-Lang-project-01/internal/auth"
 	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/pkg/database"
 	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,10 +23,7 @@ func JWTAuth(jwtManager *auth.JWTManager, userRepo *repository.UserRepository) g
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			logger.Warn("Missing authorization header", "path", c.Request.URL.Path)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "authorization header required",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "authorization header required")
 			c.Abort()
 			return
 		}
@@ -33,35 +32,27 @@ func JWTAuth(jwtManager *auth.JWTManager, userRepo *repository.UserRepository) g
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			logger.Warn("Invalid authorization format", "header", authHeader)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "invalid authorization format (use: Bearer <token>)",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid authorization format (use: Bearer <token>)")
 			c.Abort()
 			return
 		}
 
 		// Validate token
 		token := parts[1]
-		claims, err := jwtManager.ValidateToken(token)
+		claims, err := jwtManager.ValidateAccessToken(token)
 		if err != nil {
 			logger.Warn("Invalid token", "error", err.Error())
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "invalid or expired token",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired token")
 			c.Abort()
 			return
 		}
 
-		// Fetch user from database to get role
-		user, err := userRepo.GetByID(c.Request.Context(), claims.UserID)
+		// Fetch user from the primary database so role changes and
+		// deactivations take effect without waiting for replicas
+		user, err := userRepo.GetByID(database.WithPrimary(c.Request.Context()), claims.UserID)
 		if err != nil {
 			logger.Warn("User not found", "user_id", claims.UserID)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "user not found",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "user not found")
 			c.Abort()
 			return
 		}
@@ -69,10 +60,23 @@ func JWTAuth(jwtManager *auth.JWTManager, userRepo *repository.UserRepository) g
 		// Check if user is active
 		if !user.IsActive {
 			logger.Warn("Inactive user attempted access", "user_id", user.ID)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "account is inactive",
-			})
+			utils.ErrorResponse(c, http.StatusForbidden, "account is inactive")
+			c.Abort()
+			return
+		}
+
+		// Reject tokens issued before an administrative revocation (e.g. offboarding)
+		if claims.IssuedAt != nil && user.TokenRevoked(claims.IssuedAt.Time) {
+			logger.Warn("Revoked token used", "user_id", user.ID)
+			utils.ErrorResponse(c, http.StatusUnauthorized, "token has been revoked")
+			c.Abort()
+			return
+		}
+
+		// After an admin password reset, changing the password is all the user may do
+		if user.MustChangePassword && !isPasswordChange(c) {
+			logger.Warn("Password change required", "user_id", user.ID, "path", c.Request.URL.Path)
+			utils.ErrorDataResponse(c, http.StatusForbidden, "password change required", gin.H{"must_change_password": true})
 			c.Abort()
 			return
 		}
@@ -90,17 +94,24 @@ func JWTAuth(jwtManager *auth.JWTManager, userRepo *repository.UserRepository) g
 	}
 }
 
+// isPasswordChange reports whether the request changes the user's own
+// password, the only route open to users who must change it
+func isPasswordChange(c *gin.Context) bool {
+	return c.Request.Method == http.MethodPut && strings.HasSuffix(c.FullPath(), "/users/me/password")
+}
+
 // AuthMiddleware validates JWT token from Authorization header (backward compatibility)
+//
+// Deprecated: AuthMiddleware trusts the token's claims alone, so deleted and
+// deactivated users keep access until the token expires. Use JWTAuth, which
+// loads the user on every request.
 func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			logger.Warn("Missing authorization header", "path", c.Request.URL.Path)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "authorization header required",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "authorization header required")
 			c.Abort()
 			return
 		}
@@ -109,23 +120,17 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			logger.Warn("Invalid authorization format", "header", authHeader)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "invalid authorization format (use: Bearer <token>)",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid authorization format (use: Bearer <token>)")
 			c.Abort()
 			return
 		}
 
 		// Validate token
 		token := parts[1]
-		claims, err := jwtManager.ValidateToken(token)
+		claims, err := jwtManager.ValidateAccessToken(token)
 		if err != nil {
 			logger.Warn("Invalid token", "error", err.Error())
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "invalid or expired token",
-			})
+			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired token")
 			c.Abort()
 			return
 		}
@@ -141,24 +146,34 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	}
 }
 
-// OptionalAuthMiddleware validates JWT token but doesn't abort if missing
-func OptionalAuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
+// OptionalAuthMiddleware authenticates the request like JWTAuth when a bearer
+// token is present, but doesn't abort: an invalid token, or one of a missing,
+// inactive or revoked user, leaves the request anonymous. The role comes from
+// the stored user, so a demoted user loses the token's role at once.
+func OptionalAuthMiddleware(jwtManager *auth.JWTManager, userRepo *repository.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.Next()
+			return
+		}
+		claims, err := jwtManager.ValidateAccessToken(parts[1])
+		if err != nil {
 			c.Next()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) == 2 && parts[0] == "Bearer" {
-			token := parts[1]
-			if claims, err := jwtManager.ValidateToken(token); err == nil {
-				c.Set("user_id", claims.UserID)
-				c.Set("user_email", claims.Email)
-			}
+		user, err := userRepo.GetByID(database.WithPrimary(c.Request.Context()), claims.UserID)
+		if err != nil || !user.IsActive || (claims.IssuedAt != nil && user.TokenRevoked(claims.IssuedAt.Time)) {
+			c.Next()
+			return
 		}
 
+		c.Set("user_id", claims.UserID)
+		c.Set("user_email", claims.Email)
+		c.Set("user_role", user.Role)
+		c.Set("user", user)
+
 		c.Next()
 	}
 }
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimitKey holds the request's *limitedBody in the gin context
+const bodyLimitKey = "body_limit"
+
+// BodySizeLimit middleware caps request bodies at maxBytes. Requests whose
+// Content-Length is larger are answered with 413 request_too_large before the
+// body is read; reading past the limit of a chunked body fails with
+// *http.MaxBytesError, which utils.ValidationErrorResponse also turns into 413.
+//
+// Registered on a group or route after the global one, it replaces the global
+// limit, e.g. to accept larger uploads on a single route. <= 0 lifts the limit.
+func BodySizeLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get(bodyLimitKey); ok {
+			// Group middleware such as AuditTrail may have started reading
+			// through the outer limit, so change it in place instead of wrapping
+			value.(*limitedBody).limit = maxBytes
+		} else if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body := &limitedBody{body: c.Request.Body, limit: maxBytes}
+			c.Request.Body = body
+			c.Set(bodyLimitKey, body)
+		}
+
+		if maxBytes > 0 && c.Request.ContentLength > maxBytes {
+			utils.RequestTooLargeResponse(c, maxBytes)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// limitedBody works like http.MaxBytesReader, but its limit can change while
+// the body is being read
+type limitedBody struct {
+	body  io.ReadCloser
+	limit int64 // <= 0 means unlimited
+	read  int64
+	err   error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.limit <= 0 {
+		n, err := b.body.Read(p)
+		b.read += int64(n)
+		return n, err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	remaining := b.limit - b.read
+	if remaining < 0 {
+		// The limit was lowered below what has been read already
+		b.err = &http.MaxBytesError{Limit: b.limit}
+		return 0, b.err
+	}
+	// Read one byte more than allowed to tell a body of exactly the limit
+	// from a larger one
+	if int64(len(p)) > remaining+1 {
+		p = p[:remaining+1]
+	}
+	n, err := b.body.Read(p)
+	if int64(n) <= remaining {
+		b.read += int64(n)
+		return n, err
+	}
+
+	b.read = b.limit
+	b.err = &http.MaxBytesError{Limit: b.limit}
+	return int(remaining), b.err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bodyLimitRouter caps bodies at 16 bytes, except on /large, which reads
+// part of the body in group middleware first, like AuditTrail
+func bodyLimitRouter(handlerRan *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodySizeLimit(16))
+
+	echo := func(c *gin.Context) {
+		*handlerRan = true
+		var body map[string]string
+		if err := c.ShouldBindJSON(&body); err != nil {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, body)
+	}
+	peek := func(c *gin.Context) {
+		head := make([]byte, 4)
+		n, _ := io.ReadFull(c.Request.Body, head)
+		c.Request.Body = io.NopCloser(io.MultiReader(strings.NewReader(string(head[:n])), c.Request.Body))
+	}
+
+	router.POST("/small", echo)
+	router.POST("/large", peek, BodySizeLimit(64), echo)
+	return router
+}
+
+// chunked hides the length of body, as a chunked request would
+func chunked(body string) io.Reader {
+	return io.MultiReader(strings.NewReader(body))
+}
+
+func serveBody(router *gin.Engine, path string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func assertTooLarge(t *testing.T, w *httptest.ResponseRecorder, limit int64) {
+	t.Helper()
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	var body struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    struct {
+			Error string `json:"error"`
+			Limit int64  `json:"limit"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.False(t, body.Success)
+	assert.Equal(t, "Request body too large", body.Message)
+	assert.Equal(t, "request_too_large", body.Data.Error)
+	assert.Equal(t, limit, body.Data.Limit)
+}
+
+func TestBodySizeLimit_WithinLimit(t *testing.T) {
+	var ran bool
+	router := bodyLimitRouter(&ran)
+
+	w := serveBody(router, "/small", chunked(`{"a":"01234567"}`)) // Exactly 16 bytes
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"a":"01234567"}`, w.Body.String())
+}
+
+func TestBodySizeLimit_ContentLengthRejectedUpFront(t *testing.T) {
+	var ran bool
+	router := bodyLimitRouter(&ran)
+
+	w := serveBody(router, "/small", strings.NewReader(`{"a":"012345678"}`))
+	assertTooLarge(t, w, 16)
+	assert.False(t, ran, "handler must not run")
+}
+
+func TestBodySizeLimit_ChunkedBodyTranslatedByBinder(t *testing.T) {
+	var ran bool
+	router := bodyLimitRouter(&ran)
+
+	w := serveBody(router, "/small", chunked(`{"a":"`+strings.Repeat("x", 1<<20)+`"}`))
+	assert.True(t, ran)
+	assertTooLarge(t, w, 16)
+}
+
+func TestBodySizeLimit_RouteOverridesGlobal(t *testing.T) {
+	var ran bool
+	router := bodyLimitRouter(&ran)
+
+	body := `{"a":"` + strings.Repeat("x", 40) + `"}`
+	w := serveBody(router, "/large", chunked(body))
+	assert.Equal(t, http.StatusOK, w.Code, "route limit must replace the smaller global one")
+	assert.JSONEq(t, body, w.Body.String())
+
+	w = serveBody(router, "/large", chunked(`{"a":"`+strings.Repeat("x", 100)+`"}`))
+	assertTooLarge(t, w, 64)
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrInvalidChaosFault is returned for faults missing the setting their type needs
+	ErrInvalidChaosFault = errors.New("invalid chaos fault")
+	// ErrPoolBusy is returned while a previous pool exhaustion is still holding connections
+	ErrPoolBusy = errors.New("database pool is already exhausted")
+)
+
+// ChaosFaultHeader names the fault applied to a response
+const ChaosFaultHeader = "X-Chaos-Fault"
+
+// chaosPathPrefix is the default scope of a fault
+const chaosPathPrefix = "/api/"
+
+// Chaos injects faults into requests for resilience testing. Faults are
+// added at runtime through the /_chaos endpoints and applied by Inject, which
+// runs inside the normal middleware chain. It must never be installed in
+// production.
+type Chaos struct {
+	pool *sql.DB // Primary database pool; nil disables ExhaustPool
+	now  func() time.Time
+	roll func() int // Uniform in 0-99
+
+	mu         sync.Mutex
+	faults     []models.ChaosFault
+	exhausting bool
+}
+
+// NewChaos creates a fault injector. pool may be nil.
+func NewChaos(pool *sql.DB) *Chaos {
+	return &Chaos{
+		pool: pool,
+		now:  time.Now,
+		roll: func() int { return rand.IntN(100) },
+	}
+}
+
+// Add activates a fault and returns it with its ID and expiry
+func (ch *Chaos) Add(req *models.CreateChaosFaultRequest) (models.ChaosFault, error) {
+	switch {
+	case req.Type == models.ChaosFaultLatency && req.LatencyMS <= 0:
+		return models.ChaosFault{}, fmt.Errorf("%w: latency faults need latency_ms", ErrInvalidChaosFault)
+	case req.Type == models.ChaosFaultStatus && req.Status == 0:
+		return models.ChaosFault{}, fmt.Errorf("%w: status faults need status", ErrInvalidChaosFault)
+	}
+
+	fault := models.ChaosFault{
+		ID:         utils.GenerateID(),
+		Type:       req.Type,
+		PathPrefix: req.PathPrefix,
+		LatencyMS:  req.LatencyMS,
+		Status:     req.Status,
+		Percentage: 100,
+		ExpiresAt:  ch.now().Add(5 * time.Minute),
+	}
+	if fault.PathPrefix == "" {
+		fault.PathPrefix = chaosPathPrefix
+	}
+	if req.Percentage != nil {
+		fault.Percentage = *req.Percentage
+	}
+	if req.DurationSeconds > 0 {
+		fault.ExpiresAt = ch.now().Add(time.Duration(req.DurationSeconds) * time.Second)
+	}
+
+	ch.mu.Lock()
+	ch.faults = append(ch.faults, fault)
+	ch.mu.Unlock()
+	return fault, nil
+}
+
+// Faults returns the active faults, dropping expired ones
+func (ch *Chaos) Faults() []models.ChaosFault {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	now := ch.now()
+	ch.faults = slices.DeleteFunc(ch.faults, func(f models.ChaosFault) bool { return !now.Before(f.ExpiresAt) })
+	return slices.Clone(ch.faults)
+}
+
+// Remove deactivates a fault and reports whether it was active
+func (ch *Chaos) Remove(id string) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	n := len(ch.faults)
+	ch.faults = slices.DeleteFunc(ch.faults, func(f models.ChaosFault) bool { return f.ID == id })
+	return len(ch.faults) < n
+}
+
+// Clear deactivates every fault and returns how many were active
+func (ch *Chaos) Clear() int {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	n := len(ch.faults)
+	ch.faults = nil
+	return n
+}
+
+// Inject middleware applies the active faults whose path prefix matches the
+// request. Latency is added before the rest of the chain runs, so timeouts,
+// logging and metrics see it; status and drop faults stop the chain. The
+// /_chaos endpoints are never affected, so faults can always be removed.
+func (ch *Chaos) Inject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if strings.Contains(path, "/_chaos") {
+			c.Next()
+			return
+		}
+
+		for _, fault := range ch.Faults() {
+			if !strings.HasPrefix(path, fault.PathPrefix) || ch.roll() >= fault.Percentage {
+				continue
+			}
+			c.Writer.Header().Add(ChaosFaultHeader, fault.ID)
+			logger.Warn("Injecting chaos fault", "fault_id", fault.ID, "type", fault.Type, "path", path)
+
+			switch fault.Type {
+			case models.ChaosFaultLatency:
+				select {
+				case <-time.After(time.Duration(fault.LatencyMS) * time.Millisecond):
+				case <-c.Request.Context().Done():
+					c.Abort()
+					return
+				}
+			case models.ChaosFaultStatus:
+				utils.ErrorResponse(c, fault.Status, "injected fault")
+				c.Abort()
+				return
+			case models.ChaosFaultDrop:
+				dropConnection(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// dropConnection closes the client connection without a response. Connections
+// that cannot be taken over (e.g. HTTP/2) get 503 instead.
+func dropConnection(c *gin.Context) {
+	c.Abort()
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "injected fault")
+		return
+	}
+	conn.Close()
+}
+
+// ExhaustPool takes connections from the database pool and holds them for
+// hold, so other queries block until they are released. A pool without a
+// limit is limited to connections for the duration.
+func (ch *Chaos) ExhaustPool(ctx context.Context, hold time.Duration, connections int) (models.ChaosPoolReport, error) {
+	if ch.pool == nil {
+		return models.ChaosPoolReport{}, errors.New("no database pool to exhaust")
+	}
+	ch.mu.Lock()
+	if ch.exhausting {
+		ch.mu.Unlock()
+		return models.ChaosPoolReport{}, ErrPoolBusy
+	}
+	ch.exhausting = true
+	ch.mu.Unlock()
+
+	n := ch.pool.Stats().MaxOpenConnections
+	unlimited := n == 0
+	if unlimited {
+		if connections <= 0 {
+			connections = 10
+		}
+		n = connections
+		ch.pool.SetMaxOpenConns(n)
+	}
+
+	release := func(conns []*sql.Conn) {
+		if unlimited {
+			ch.pool.SetMaxOpenConns(0)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+		ch.mu.Lock()
+		ch.exhausting = false
+		ch.mu.Unlock()
+	}
+
+	conns := make([]*sql.Conn, 0, n)
+	for len(conns) < n {
+		conn, err := ch.pool.Conn(ctx)
+		if err != nil {
+			release(conns)
+			return models.ChaosPoolReport{}, fmt.Errorf("failed to take connection %d of %d: %w", len(conns)+1, n, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	logger.Warn("Database pool exhausted by chaos endpoint", "connections", n, "hold", hold.String())
+	time.AfterFunc(hold, func() {
+		release(conns)
+		logger.Info("Database pool released", "connections", n)
+	})
+	return models.ChaosPoolReport{Held: n, ReleasedAt: ch.now().Add(hold)}, nil
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newChaosRouter returns a router whose /api/ping handler records that it ran
+func newChaosRouter(chaos *Chaos, handled *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(chaos.Inject())
+	ok := func(c *gin.Context) {
+		*handled = true
+		c.Status(http.StatusOK)
+	}
+	router.GET("/api/ping", ok)
+	router.GET("/api/v1/_chaos/faults", ok)
+	router.GET("/health", ok)
+	return router
+}
+
+func percent(p int) *int { return &p }
+
+func TestChaos_StatusFault(t *testing.T) {
+	chaos := NewChaos(nil)
+	var handled bool
+	router := newChaosRouter(chaos, &handled)
+
+	fault, err := chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultStatus, Status: http.StatusServiceUnavailable})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/", fault.PathPrefix)
+	assert.Equal(t, 100, fault.Percentage)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, fault.ID, w.Header().Get(ChaosFaultHeader))
+	assert.False(t, handled, "the handler is not reached")
+
+	// Paths outside the prefix and the chaos endpoints are not affected
+	for _, path := range []string{"/health", "/api/v1/_chaos/faults"} {
+		handled = false
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.True(t, handled, path)
+	}
+
+	assert.True(t, chaos.Remove(fault.ID))
+	assert.False(t, chaos.Remove(fault.ID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestChaos_LatencyFaultRunsChain(t *testing.T) {
+	chaos := NewChaos(nil)
+	var handled bool
+	router := newChaosRouter(chaos, &handled)
+	_, err := chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultLatency, LatencyMS: 50})
+	require.NoError(t, err)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, handled)
+}
+
+func TestChaos_Percentage(t *testing.T) {
+	chaos := NewChaos(nil)
+	var handled bool
+	router := newChaosRouter(chaos, &handled)
+	_, err := chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultStatus, Status: http.StatusBadGateway, Percentage: percent(30)})
+	require.NoError(t, err)
+
+	for roll, want := range map[int]int{0: http.StatusBadGateway, 29: http.StatusBadGateway, 30: http.StatusOK, 99: http.StatusOK} {
+		chaos.roll = func() int { return roll }
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+		assert.Equal(t, want, w.Code, "roll %d", roll)
+	}
+}
+
+func TestChaos_DropFaultClosesConnection(t *testing.T) {
+	chaos := NewChaos(nil)
+	var handled bool
+	server := httptest.NewServer(newChaosRouter(chaos, &handled))
+	defer server.Close()
+	_, err := chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultDrop})
+	require.NoError(t, err)
+
+	_, err = http.Get(server.URL + "/api/ping")
+	assert.Error(t, err, "no response is sent")
+	assert.False(t, handled)
+}
+
+func TestChaos_FaultsExpire(t *testing.T) {
+	chaos := NewChaos(nil)
+	now := time.Now()
+	chaos.now = func() time.Time { return now }
+
+	_, err := chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultDrop, DurationSeconds: 10})
+	require.NoError(t, err)
+	assert.Len(t, chaos.Faults(), 1)
+
+	now = now.Add(10 * time.Second)
+	assert.Empty(t, chaos.Faults())
+}
+
+func TestChaos_RejectsIncompleteFaults(t *testing.T) {
+	chaos := NewChaos(nil)
+	_, err := chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultLatency})
+	assert.ErrorIs(t, err, ErrInvalidChaosFault)
+	_, err = chaos.Add(&models.CreateChaosFaultRequest{Type: models.ChaosFaultStatus})
+	assert.ErrorIs(t, err, ErrInvalidChaosFault)
+	assert.Zero(t, chaos.Clear())
+}
+
+func TestChaos_ExhaustPool(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "chaos.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	pool, err := db.DB()
+	require.NoError(t, err)
+	defer pool.Close()
+
+	chaos := NewChaos(pool)
+	report, err := chaos.ExhaustPool(context.Background(), 200*time.Millisecond, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Held)
+
+	_, err = chaos.ExhaustPool(context.Background(), time.Second, 2)
+	assert.ErrorIs(t, err, ErrPoolBusy)
+
+	// Queries wait for a connection while the pool is held
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.PingContext(ctx), context.DeadlineExceeded)
+
+	// Released afterwards, with the original (unlimited) pool size
+	assert.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return pool.PingContext(ctx) == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Zero(t, pool.Stats().MaxOpenConnections)
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimiterConfig configures a ConcurrencyLimiter
+type ConcurrencyLimiterConfig struct {
+	Limit      int           // Max requests in flight for the group; <= 0 disables the limit
+	RetryAfter time.Duration // Advertised in the Retry-After header of rejected requests
+
+	// OnChange and OnReject are optional hooks, e.g. for Prometheus metrics
+	OnChange func(group string, inFlight int)
+	OnReject func(group string)
+}
+
+// ConcurrencyLimiter caps the number of in-flight requests of an endpoint group.
+// Unlike RateLimiter it is shared by all clients and protects slow endpoints
+// (exports, statistics) from being run many times in parallel.
+type ConcurrencyLimiter struct {
+	group      string
+	limit      int
+	sem        chan struct{} // nil when the limit is disabled
+	retryAfter time.Duration
+	onChange   func(group string, inFlight int)
+	onReject   func(group string)
+
+	inFlight atomic.Int64
+	rejected atomic.Uint64
+}
+
+// NewConcurrencyLimiter creates a limiter for the named endpoint group
+func NewConcurrencyLimiter(group string, cfg ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Second
+	}
+
+	l := &ConcurrencyLimiter{
+		group:      group,
+		limit:      cfg.Limit,
+		retryAfter: cfg.RetryAfter,
+		onChange:   cfg.OnChange,
+		onReject:   cfg.OnReject,
+	}
+	if cfg.Limit > 0 {
+		l.sem = make(chan struct{}, cfg.Limit)
+	}
+	return l
+}
+
+// Limit returns a middleware that rejects requests with 429 while the group is saturated
+func (l *ConcurrencyLimiter) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.sem != nil {
+			select {
+			case l.sem <- struct{}{}:
+				defer func() { <-l.sem }()
+			default:
+				l.rejected.Add(1)
+				if l.onReject != nil {
+					l.onReject(l.group)
+				}
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(l.retryAfter.Seconds()))))
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"success": false,
+					"message": "Too many concurrent requests for this endpoint. Please try again later.",
+					"error":   "concurrency_limit_exceeded",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		l.track(1)
+		defer l.track(-1)
+
+		c.Next()
+	}
+}
+
+// track updates the in-flight count and notifies the OnChange hook
+func (l *ConcurrencyLimiter) track(delta int64) {
+	n := l.inFlight.Add(delta)
+	if l.onChange != nil {
+		l.onChange(l.group, int(n))
+	}
+}
+
+// Group returns the endpoint group name
+func (l *ConcurrencyLimiter) Group() string {
+	return l.group
+}
+
+// InFlight returns the number of requests currently being served
+func (l *ConcurrencyLimiter) InFlight() int {
+	return int(l.inFlight.Load())
+}
+
+// Stats returns the limiter state for admin endpoints
+func (l *ConcurrencyLimiter) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"group":          l.group,
+		"limit":          l.limit,
+		"in_flight":      l.InFlight(),
+		"rejected_total": l.rejected.Load(),
+	}
+}
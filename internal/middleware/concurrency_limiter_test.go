@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowRouter serves GET /slow through the limiter; the handler blocks until release is closed
+func slowRouter(limiter *ConcurrencyLimiter, started chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/slow", limiter.Limit(), func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// saturate starts n requests and waits until all of them are inside the handler
+func saturate(t *testing.T, router *gin.Engine, n int, started <-chan struct{}) (*sync.WaitGroup, []*httptest.ResponseRecorder) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, n)
+	for i := 0; i < n; i++ {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+		}(recorders[i])
+	}
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %d of %d requests reached the handler", i, n)
+		}
+	}
+	return &wg, recorders
+}
+
+func TestConcurrencyLimiterRejectsWhenSaturated(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		rejected []string
+		peak     int
+	)
+	limiter := NewConcurrencyLimiter("exports", ConcurrencyLimiterConfig{
+		Limit:      2,
+		RetryAfter: 1500 * time.Millisecond,
+		OnChange: func(group string, inFlight int) {
+			mu.Lock()
+			defer mu.Unlock()
+			if inFlight > peak {
+				peak = inFlight
+			}
+		},
+		OnReject: func(group string) {
+			mu.Lock()
+			defer mu.Unlock()
+			rejected = append(rejected, group)
+		},
+	})
+
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	router := slowRouter(limiter, started, release)
+
+	wg, recorders := saturate(t, router, 2, started)
+	assert.Equal(t, 2, limiter.InFlight())
+
+	// A third request must be turned away immediately
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"), "Retry-After should be rounded up to whole seconds")
+	assert.Contains(t, w.Body.String(), "concurrency_limit_exceeded")
+
+	close(release)
+	wg.Wait()
+	for _, rec := range recorders {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, 0, limiter.InFlight())
+
+	// Capacity is available again once the slow requests finished
+	started2 := make(chan struct{}, 1)
+	release2 := make(chan struct{})
+	close(release2)
+	w = httptest.NewRecorder()
+	slowRouter(limiter, started2, release2).ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"exports"}, rejected)
+	assert.Equal(t, 2, peak)
+
+	stats := limiter.Stats()
+	assert.Equal(t, "exports", stats["group"])
+	assert.Equal(t, 2, stats["limit"])
+	assert.Equal(t, uint64(1), stats["rejected_total"])
+}
+
+func TestConcurrencyLimiterDisabled(t *testing.T) {
+	limiter := NewConcurrencyLimiter("stats", ConcurrencyLimiterConfig{Limit: 0})
+
+	const n = 10
+	started := make(chan struct{}, n)
+	release := make(chan struct{})
+	router := slowRouter(limiter, started, release)
+
+	wg, recorders := saturate(t, router, n, started)
+	require.Equal(t, n, limiter.InFlight(), "in-flight requests are still counted without a limit")
+
+	close(release)
+	wg.Wait()
+	for _, rec := range recorders {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, uint64(0), limiter.Stats()["rejected_total"])
+}
@@ -2,18 +2,78 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CORS middleware for cross-origin requests
+// CORSConfig configures CORSWithConfig
+type CORSConfig struct {
+	// AllowedOrigins lists exact origins ("https://app.example.com") and
+	// subdomain patterns ("https://*.example.com", which does not match the
+	// apex domain). "*" allows every origin.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // How long browsers may cache a preflight; 0 omits the header
+}
+
+// DefaultCORSConfig returns the policy used by CORS: every origin, the
+// methods of the API and the headers its clients send
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"POST", "OPTIONS", "GET", "HEAD", "PUT", "DELETE", "PATCH"},
+		AllowedHeaders:   []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", "X-Request-ID", "X-ID-Format"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+	}
+}
+
+// CORS middleware for cross-origin requests with DefaultCORSConfig
 func CORS() gin.HandlerFunc {
+	return CORSWithConfig(DefaultCORSConfig())
+}
+
+// CORSWithConfig middleware adds CORS headers for allowed origins. Requests
+// from other origins get no CORS headers, so browsers block the response.
+func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
+	allowOrigin := newOriginMatcher(cfg.AllowedOrigins)
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		origin := c.GetHeader("Origin")
+		if origin != "" {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+
+		if allowed, ok := allowOrigin(origin); ok {
+			header := c.Writer.Header()
+			header.Set("Access-Control-Allow-Origin", allowed)
+			if cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposed != "" {
+				header.Set("Access-Control-Expose-Headers", exposed)
+			}
+			if c.Request.Method == "OPTIONS" {
+				header.Set("Access-Control-Allow-Methods", methods)
+				header.Set("Access-Control-Allow-Headers", headers)
+				if cfg.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", maxAge)
+				}
+			}
+		}
 
+		// Answer preflight here: global middleware also runs for unmatched
+		// methods, so OPTIONS never reaches route-level authentication
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
 			return
@@ -22,3 +82,49 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// newOriginMatcher returns a function that reports whether an origin is
+// allowed and the value for Access-Control-Allow-Origin
+func newOriginMatcher(allowedOrigins []string) func(origin string) (string, bool) {
+	anyOrigin := false
+	exact := make(map[string]bool)
+	type pattern struct{ prefix, suffix string }
+	var patterns []pattern
+
+	for _, allowed := range allowedOrigins {
+		allowed = strings.ToLower(strings.TrimSuffix(allowed, "/"))
+		switch {
+		case allowed == "*":
+			anyOrigin = true
+		case strings.Contains(allowed, "://*."):
+			prefix, suffix, _ := strings.Cut(allowed, "*")
+			patterns = append(patterns, pattern{prefix: prefix, suffix: suffix})
+		default:
+			exact[allowed] = true
+		}
+	}
+
+	return func(origin string) (string, bool) {
+		if origin == "" {
+			return "", false
+		}
+		if anyOrigin {
+			return "*", true
+		}
+		lower := strings.ToLower(origin)
+		if exact[lower] {
+			return origin, true
+		}
+		for _, p := range patterns {
+			if len(lower) <= len(p.prefix)+len(p.suffix) || !strings.HasPrefix(lower, p.prefix) || !strings.HasSuffix(lower, p.suffix) {
+				continue
+			}
+			// The wildcard covers one or more labels, nothing else
+			sub := lower[len(p.prefix) : len(lower)-len(p.suffix)]
+			if !strings.ContainsAny(sub, "/:@?#") && !strings.HasPrefix(sub, ".") {
+				return origin, true
+			}
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// corsRouter serves GET /ping behind CORSWithConfig
+func corsRouter(cfg CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSWithConfig(cfg))
+	router.GET("/ping", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// corsRequest sends a request to /ping from origin; empty origin sends none
+func corsRequest(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/ping", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == "OPTIONS" {
+		req.Header.Set("Access-Control-Request-Method", "POST")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_AllowedOrigins(t *testing.T) {
+	router := corsRouter(CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:3000", "https://*.example.com"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"http://localhost:3000", true},
+		{"https://app.example.com", true},
+		{"https://eu.app.example.com", true},
+		{"https://example.com", false}, // the pattern needs a subdomain
+		{"http://app.example.com", false},
+		{"https://app.example.com.evil.test", false},
+		{"https://evilexample.com", false},
+		{"http://localhost:8080", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := corsRequest(router, "GET", tt.origin)
+			assert.Equal(t, http.StatusOK, w.Code, "CORS does not block the request itself")
+			assert.Equal(t, "Origin", w.Header().Get("Vary"))
+			if !tt.allowed {
+				for name := range w.Header() {
+					assert.NotContains(t, name, "Access-Control-", "disallowed origins get no CORS headers")
+				}
+				return
+			}
+			assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		})
+	}
+
+	w := corsRequest(router, "GET", "")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), "requests without Origin are not cross-origin")
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	router := corsRouter(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	w := corsRequest(router, "OPTIONS", "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"), "credentials are off")
+
+	// Preflight is answered without reaching route handlers, even when refused
+	w = corsRequest(router, "OPTIONS", "https://evil.test")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	// Simple requests only get the origin headers
+	w = corsRequest(router, "GET", "https://app.example.com")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORS_DefaultAllowsEveryOrigin(t *testing.T) {
+	router := corsRouter(DefaultCORSConfig())
+
+	w := corsRequest(router, "GET", "https://anywhere.test")
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(router, "OPTIONS", "https://anywhere.test")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+}
@@ -1,14 +1,17 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/reporting"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorHandler middleware for centralized error handling
+// ErrorHandler middleware for centralized error handling.
+// 5xx responses are sent to the error reporter.
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
@@ -22,12 +25,21 @@ func ErrorHandler() gin.HandlerFunc {
 				Message: err.Error(),
 			})
 		}
+
+		if status := c.Writer.Status(); status >= http.StatusInternalServerError {
+			var err error = fmt.Errorf("%s %s answered %d", c.Request.Method, c.FullPath(), status)
+			if last := c.Errors.Last(); last != nil {
+				err = last.Err
+			}
+			reporting.Error(err, reporting.GinTags(c))
+		}
 	}
 }
 
-// Recovery middleware for panic recovery
+// Recovery middleware for panic recovery. Panics are sent to the error reporter.
 func Recovery() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		reporting.Panic(recovered, reporting.GinTags(c))
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Success: false,
 			Message: "Internal server error",
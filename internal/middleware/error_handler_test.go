@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/pkg/reporting"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportRecorder collects delivered error reports
+type reportRecorder struct {
+	mu     sync.Mutex
+	events []reporting.Event
+}
+
+func (r *reportRecorder) Report(event reporting.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// serveReported runs one request and returns the reports it produced
+func serveReported(t *testing.T, router *gin.Engine, req *http.Request) (*httptest.ResponseRecorder, []reporting.Event) {
+	t.Helper()
+
+	rec := &reportRecorder{}
+	reporting.Init(rec, 10)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	reporting.Close(time.Second)
+	return w, rec.events
+}
+
+func newReportingRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), Recovery(), ErrorHandler())
+	r.GET("/panic/:id", func(c *gin.Context) {
+		c.Set("user_id", uint(42))
+		panic("handler bug")
+	})
+	r.GET("/unavailable", func(c *gin.Context) {
+		c.Status(http.StatusServiceUnavailable)
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+	return r
+}
+
+func TestRecoveryReportsPanics(t *testing.T) {
+	req := httptest.NewRequest("GET", "/panic/1", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+
+	w, events := serveReported(t, newReportingRouter(), req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Len(t, events, 1, "a panic is reported once, not again as a 5xx")
+	assert.True(t, events[0].Panicked)
+	assert.Equal(t, reporting.Tags{
+		"method":     "GET",
+		"route":      "/panic/:id",
+		"request_id": "req-123",
+		"user_id":    "42",
+	}, events[0].Tags)
+}
+
+func TestErrorHandlerReportsServerErrors(t *testing.T) {
+	router := newReportingRouter()
+
+	_, events := serveReported(t, router, httptest.NewRequest("GET", "/unavailable", nil))
+	require.Len(t, events, 1)
+	assert.EqualError(t, events[0].Err, "GET /unavailable answered 503")
+
+	_, events = serveReported(t, router, httptest.NewRequest("GET", "/missing", nil))
+	assert.Empty(t, events, "4xx responses are not reported")
+}
+
+func TestRequestID(t *testing.T) {
+	router := newReportingRouter()
+
+	t.Run("reuses a valid incoming ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/missing", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("replaces invalid IDs", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/missing", nil)
+		req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Len(t, w.Header().Get(RequestIDHeader), 36)
+	})
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"Go-Lang-project-01/internal/flags"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireFlag middleware answers 404 while the named feature flag is off for
+// the user, so unreleased routes look absent. Run it after JWTAuth for role and
+// percentage rollouts to apply.
+func RequireFlag(checker flags.Checker, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !checker.IsEnabled(c, name) {
+			utils.ErrorResponse(c, http.StatusNotFound, "not found")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// StrictBinding middleware makes utils.BindJSON reject unknown fields while the
+// flags.StrictBinding flag is on for the user. The flag is checked when the
+// body is bound, after JWTAuth, so role and percentage rollouts apply.
+func StrictBinding(checker flags.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(utils.StrictBindingKey, func() bool {
+			return checker.IsEnabled(c, flags.StrictBinding)
+		})
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/flags"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := flags.Static{"on": true}
+
+	for name, want := range map[string]int{"on": http.StatusOK, "off": http.StatusNotFound} {
+		router := gin.New()
+		router.GET("/", RequireFlag(checker, name), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, want, w.Code, name)
+	}
+}
+
+// strictBindingRouter binds {"name": ...} with utils.BindJSON behind StrictBinding
+func strictBindingRouter(checker flags.Checker, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(StrictBinding(checker))
+	handlers = append(handlers, func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := utils.BindJSON(c, &req); err != nil {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+	router.POST("/", handlers...)
+	return router
+}
+
+func postJSON(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestStrictBinding(t *testing.T) {
+	t.Run("off ignores unknown fields", func(t *testing.T) {
+		router := strictBindingRouter(flags.Static{})
+		w := postJSON(router, `{"name":"a","nmae":"b"}`)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("on rejects unknown fields", func(t *testing.T) {
+		router := strictBindingRouter(flags.Static{flags.StrictBinding: true})
+		w := postJSON(router, `{"name":"a","nmae":"b"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var resp struct {
+			Errors []models.ValidationError `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []models.ValidationError{{Field: "nmae", Message: "unknown field"}}, resp.Errors)
+
+		assert.Equal(t, http.StatusOK, postJSON(router, `{"name":"a"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, postJSON(router, `{}`).Code, "binding tags still apply")
+	})
+
+	t.Run("checked for the authenticated user", func(t *testing.T) {
+		var role models.Role
+		checker := checkerFunc(func(ctx context.Context, name string) bool {
+			subject, _ := flags.SubjectFromContext(ctx)
+			role = subject.Role
+			return true
+		})
+		authenticate := func(c *gin.Context) {
+			c.Set("user", &models.User{ID: 1, Role: models.RoleAdmin})
+		}
+		router := strictBindingRouter(checker, authenticate)
+		assert.Equal(t, http.StatusBadRequest, postJSON(router, `{"name":"a","extra":1}`).Code)
+		assert.Equal(t, models.RoleAdmin, role, "the flag is checked after authentication")
+	})
+}
+
+// checkerFunc adapts a function to flags.Checker
+type checkerFunc func(ctx context.Context, name string) bool
+
+func (f checkerFunc) IsEnabled(ctx context.Context, name string) bool {
+	return f(ctx, name)
+}
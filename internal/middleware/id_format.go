@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IDFormatHeader selects how identifiers are encoded in JSON responses
+const IDFormatHeader = "X-ID-Format"
+
+// IDFormatString is the IDFormatHeader value asking for IDs as JSON strings
+const IDFormatString = "string"
+
+// IDFormat middleware encodes every "id" and "*_id" integer of JSON responses
+// as a string when the request sends "X-ID-Format: string". JavaScript
+// clients need it for IDs above 2^53. Request bodies need no header:
+// models.ID accepts both forms, so string IDs can be sent back as received.
+func IDFormat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", IDFormatHeader)
+		if !strings.EqualFold(c.GetHeader(IDFormatHeader), IDFormatString) {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &idStringWriter{ResponseWriter: original}
+		c.Writer = w
+		// Restore even on panic, so Recovery writes to the client instead of the buffer
+		defer func() { c.Writer = original }()
+
+		c.Next()
+		w.flush()
+	}
+}
+
+// idStringWriter buffers a response body so IDs can be rewritten before it is sent
+type idStringWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idStringWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *idStringWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// Written reports buffered bodies too, so later handlers do not write a second response
+func (w *idStringWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *idStringWriter) Size() int {
+	if w.body.Len() > 0 {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// flush sends the buffered body, with IDs quoted if it is JSON
+func (w *idStringWriter) flush() {
+	body := w.body.Bytes()
+	if len(body) == 0 {
+		return
+	}
+	if strings.Contains(w.Header().Get("Content-Type"), "json") {
+		if quoted, err := quoteIDs(body); err == nil {
+			body = quoted
+		}
+	}
+	w.ResponseWriter.Write(body)
+}
+
+// isIDKey reports whether an object key names an identifier
+func isIDKey(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "_id")
+}
+
+// quoteIDs re-encodes a JSON document token by token, turning integer values
+// of ID keys into strings. Key order and all other values are kept.
+func quoteIDs(body []byte) ([]byte, error) {
+	type container struct {
+		object bool
+		tokens int // Keys and values in objects, elements in arrays
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var (
+		out   bytes.Buffer
+		stack []container
+		key   string
+	)
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteRune(rune(delim))
+			continue
+		}
+
+		// Separator before this key, value or element
+		isKey, isValue := false, false
+		if n := len(stack); n > 0 {
+			top := &stack[n-1]
+			switch {
+			case top.object && top.tokens%2 == 0:
+				isKey = true
+				if top.tokens > 0 {
+					out.WriteByte(',')
+				}
+			case top.object:
+				isValue = true
+				out.WriteByte(':')
+			case top.tokens > 0:
+				out.WriteByte(',')
+			}
+			top.tokens++
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			stack = append(stack, container{object: value == '{'})
+			out.WriteRune(rune(value))
+		case json.Number:
+			if isValue && isIDKey(key) && !strings.ContainsAny(value.String(), ".eE-") {
+				out.WriteString(`"` + value.String() + `"`)
+			} else {
+				out.WriteString(value.String())
+			}
+		default:
+			if isKey {
+				key = value.(string)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(encoded)
+		}
+	}
+	return out.Bytes(), nil
+}
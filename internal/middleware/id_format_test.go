@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func idFormatRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard), IDFormat())
+	router.GET("/user", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{
+			"id":         9007199254740993,
+			"name":       "Ada",
+			"age":        36,
+			"user_id":    nil,
+			"actor_id":   7,
+			"request_id": "abc",
+			"scores":     []interface{}{1, 2.5},
+			"items":      []gin.H{{"id": 1}, {"id": 2, "ratio": 0.5}},
+		}})
+	})
+	router.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusOK, `{"id": 1}`)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": 1})
+		panic("boom")
+	})
+	return router
+}
+
+func serveIDFormat(router *gin.Engine, path, format string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if format != "" {
+		req.Header.Set(IDFormatHeader, format)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIDFormat(t *testing.T) {
+	router := idFormatRouter()
+
+	t.Run("numbers by default", func(t *testing.T) {
+		w := serveIDFormat(router, "/user", "")
+		assert.Contains(t, w.Body.String(), `"id":9007199254740993`)
+		assert.Equal(t, IDFormatHeader, w.Header().Get("Vary"))
+	})
+
+	t.Run("strings on request, other values untouched", func(t *testing.T) {
+		w := serveIDFormat(router, "/user", "String")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data": {
+			"id": "9007199254740993",
+			"name": "Ada",
+			"age": 36,
+			"user_id": null,
+			"actor_id": "7",
+			"request_id": "abc",
+			"scores": [1, 2.5],
+			"items": [{"id": "1"}, {"id": "2", "ratio": 0.5}]
+		}}`, w.Body.String())
+		assert.Equal(t, IDFormatHeader, w.Header().Get("Vary"))
+	})
+
+	t.Run("non-JSON bodies pass through", func(t *testing.T) {
+		w := serveIDFormat(router, "/text", IDFormatString)
+		assert.Equal(t, `{"id": 1}`, w.Body.String())
+	})
+
+	t.Run("panics discard the buffered body", func(t *testing.T) {
+		w := serveIDFormat(router, "/panic", IDFormatString)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestQuoteIDsKeepsKeyOrder(t *testing.T) {
+	quoted, err := quoteIDs([]byte(`{"z":1,"id":2,"a":{"b_id":3,"list":[]},"e":{}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"z":1,"id":"2","a":{"b_id":"3","list":[]},"e":{}}`, string(quoted))
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +16,7 @@ func Logger() gin.HandlerFunc {
 		start := time.Now()
 		path := c.Request.URL.Path
 		method := c.Request.Method
-		clientIP := c.ClientIP()
+		clientIP := utils.ClientIP(c)
 
 		// Process request
 		c.Next()
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAuth middleware protects the public /metrics endpoint in production:
+// scrapers must send "Authorization: Bearer <token>". An empty token rejects
+// every request, so a missing metrics.authtoken never exposes the endpoint.
+// Outside production the endpoint stays open.
+func MetricsAuth(production bool, token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !production {
+			c.Next()
+			return
+		}
+
+		sent, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or missing metrics token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveMetrics(production bool, token, authorization string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics", MetricsAuth(production, token), func(c *gin.Context) {
+		c.String(http.StatusOK, "http_requests_total 1\n")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMetricsAuth_Production(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expected      int
+	}{
+		{"no token sent", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"not a bearer token", "s3cret", "s3cret", http.StatusUnauthorized},
+		{"no token configured", "", "Bearer ", http.StatusUnauthorized},
+		{"valid token", "s3cret", "Bearer s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveMetrics(true, tt.token, tt.authorization)
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusUnauthorized {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+				assert.Contains(t, w.Body.String(), `"success":false`)
+				assert.NotContains(t, w.Body.String(), "http_requests_total")
+			}
+		})
+	}
+}
+
+func TestMetricsAuth_Development(t *testing.T) {
+	w := serveMetrics(false, "s3cret", "")
+	assert.Equal(t, http.StatusOK, w.Code, "metrics are open outside production")
+	assert.Contains(t, w.Body.String(), "http_requests_total")
+
+	w = serveMetrics(false, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoRoute answers requests for unknown paths with the JSON error envelope.
+// Register it with engine.NoRoute.
+func NoRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		utils.ErrorDataResponse(c, http.StatusNotFound, "route not found", gin.H{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		})
+	}
+}
+
+// NoMethod answers requests for known paths with an unsupported method with
+// the JSON error envelope. Register it with engine.NoMethod and set
+// engine.HandleMethodNotAllowed, which also sets the Allow header.
+func NoMethod() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := []string{}
+		for _, method := range strings.Split(c.Writer.Header().Get("Allow"), ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				allowed = append(allowed, method)
+			}
+		}
+		utils.ErrorDataResponse(c, http.StatusMethodNotAllowed, "method not allowed", gin.H{
+			"method":  c.Request.Method,
+			"path":    c.Request.URL.Path,
+			"allowed": allowed,
+		})
+	}
+}
@@ -1,67 +1,182 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
+
+	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
-// RateLimiter holds rate limiters for each IP
+// DefaultRateLimiterIdleTTL is how long NewRateLimiter keeps the bucket of a
+// client that sends no requests
+const DefaultRateLimiterIdleTTL = 10 * time.Minute
+
+// RateLimiterConfig configures a RateLimiter
+type RateLimiterConfig struct {
+	Rate  rate.Limit // Requests per second per client
+	Burst int        // Requests a client may make at once
+
+	// IdleTTL is how long a client's bucket is kept after its last request.
+	// A dropped bucket is full again, so only clients that have been idle
+	// for longer than it takes to refill should be evicted. <= 0 keeps
+	// buckets forever.
+	IdleTTL time.Duration
+}
+
+// rateLimiterEntry is the bucket of one client
+type rateLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter keeps one token bucket per client IP or authenticated user, so
+// a noisy client only uses up its own budget. A janitor goroutine evicts
+// buckets idle for longer than IdleTTL; Close stops it.
 type RateLimiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
+	limiters map[string]*rateLimiterEntry
+	mu       sync.Mutex
 	r        rate.Limit // requests per second
 	b        int        // burst size
+	idleTTL  time.Duration
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 // NewRateLimiter creates a new rate limiter
 // r: requests per second (e.g., 10 = 10 requests/sec)
 // b: burst size (e.g., 20 = allow burst of 20 requests)
+// Idle buckets are evicted after DefaultRateLimiterIdleTTL.
 func NewRateLimiter(r rate.Limit, b int) *RateLimiter {
-	return &RateLimiter{
-		limiters: make(map[string]*rate.Limiter),
-		r:        r,
-		b:        b,
+	return NewRateLimiterWithConfig(RateLimiterConfig{Rate: r, Burst: b, IdleTTL: DefaultRateLimiterIdleTTL})
+}
+
+// NewRateLimiterWithConfig creates a rate limiter and starts its janitor
+func NewRateLimiterWithConfig(cfg RateLimiterConfig) *RateLimiter {
+	rl := &RateLimiter{
+		limiters: make(map[string]*rateLimiterEntry),
+		r:        cfg.Rate,
+		b:        cfg.Burst,
+		idleTTL:  cfg.IdleTTL,
+		done:     make(chan struct{}),
+	}
+	if rl.idleTTL > 0 {
+		go rl.janitor(max(rl.idleTTL/2, time.Second))
+	}
+	return rl
+}
+
+// Close stops the janitor
+func (rl *RateLimiter) Close() {
+	rl.stopOnce.Do(func() { close(rl.done) })
+}
+
+// janitor evicts idle buckets every interval until Close
+func (rl *RateLimiter) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rl.done:
+			return
+		case now := <-ticker.C:
+			rl.evictIdle(now)
+		}
+	}
+}
+
+// evictIdle drops the buckets not used since now - IdleTTL
+func (rl *RateLimiter) evictIdle(now time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for key, entry := range rl.limiters {
+		if now.Sub(entry.lastSeen) > rl.idleTTL {
+			delete(rl.limiters, key)
+		}
 	}
 }
 
-// getLimiter returns limiter for the given IP
-func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
+// Len returns the number of clients with a bucket
+func (rl *RateLimiter) Len() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return len(rl.limiters)
+}
+
+// getLimiter returns the limiter for the given key and marks it as used at now
+func (rl *RateLimiter) getLimiter(key string, now time.Time) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	limiter, exists := rl.limiters[ip]
+	entry, exists := rl.limiters[key]
 	if !exists {
-		limiter = rate.NewLimiter(rl.r, rl.b)
-		rl.limiters[ip] = limiter
+		entry = &rateLimiterEntry{limiter: rate.NewLimiter(rl.r, rl.b)}
+		rl.limiters[key] = entry
+	}
+	entry.lastSeen = now
+
+	return entry.limiter
+}
+
+// allow takes a token from key's bucket. It returns whether the request may
+// proceed, the whole tokens left and, when rejected, the wait for the next one.
+func (rl *RateLimiter) allow(key string) (bool, int, time.Duration) {
+	now := time.Now()
+	limiter := rl.getLimiter(key, now)
+
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, 0, delay
+	}
+	return true, max(int(limiter.TokensAt(now)), 0), 0
+}
+
+// limit applies key's bucket to the request
+func (rl *RateLimiter) limit(c *gin.Context, key string) {
+	ok, remaining, retryAfter := rl.allow(key)
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.b))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"message": "Rate limit exceeded. Please try again later.",
+			"error":   "too_many_requests",
+		})
+		c.Abort()
+		return
 	}
 
-	return limiter
+	c.Next()
 }
 
 // RateLimit returns a middleware that limits requests per IP
 func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get client IP
-		ip := c.ClientIP()
-
-		// Get limiter for this IP
-		limiter := rl.getLimiter(ip)
+		rl.limit(c, "ip:"+utils.ClientIP(c))
+	}
+}
 
-		// Check if request is allowed
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"success": false,
-				"message": "Rate limit exceeded. Please try again later.",
-				"error":   "too_many_requests",
-			})
-			c.Abort()
+// RateLimitByUser returns a middleware that limits requests per authenticated
+// user, so a user cannot escape the limit by spreading requests over several
+// IPs. It must run after JWTAuth; requests without a user pass, since
+// RateLimit already limits them per IP.
+func (rl *RateLimiter) RateLimitByUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		id, isUint := userID.(uint)
+		if !ok || !isUint {
+			c.Next()
 			return
 		}
-
-		c.Next()
+		rl.limit(c, "user:"+strconv.FormatUint(uint64(id), 10))
 	}
 }
 
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedRouter serves GET /ping through the per-IP limiter and then the
+// per-user limiter, as the user named in the X-User header
+func rateLimitedRouter(rl *RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(rl.RateLimit())
+	router.Use(func(c *gin.Context) {
+		if id, err := strconv.ParseUint(c.GetHeader("X-User"), 10, 64); err == nil {
+			c.Set("user_id", uint(id))
+		}
+	})
+	router.GET("/ping", rl.RateLimitByUser(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// ping sends GET /ping from ip, as user unless it is empty
+func ping(router *gin.Engine, ip, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.RemoteAddr = ip + ":40000"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter_IndependentBudgetsPerIP(t *testing.T) {
+	const burst = 20
+	rl := NewRateLimiterWithConfig(RateLimiterConfig{Rate: rate.Every(time.Hour), Burst: burst})
+	defer rl.Close()
+	router := rateLimitedRouter(rl)
+
+	// Two clients hammer concurrently; the noisy one cannot eat into the other's budget
+	var wg sync.WaitGroup
+	allowed := map[string]*atomic.Int64{"192.0.2.1": {}, "192.0.2.2": {}}
+	for ip, count := range allowed {
+		for range 5 * burst {
+			wg.Go(func() {
+				if ping(router, ip, "").Code == http.StatusOK {
+					count.Add(1)
+				}
+			})
+		}
+	}
+	wg.Wait()
+
+	for ip, count := range allowed {
+		assert.Equal(t, int64(burst), count.Load(), ip)
+	}
+}
+
+func TestRateLimiter_RejectionHeaders(t *testing.T) {
+	rl := NewRateLimiterWithConfig(RateLimiterConfig{Rate: rate.Every(2 * time.Second), Burst: 2})
+	defer rl.Close()
+	router := rateLimitedRouter(rl)
+
+	w := ping(router, "192.0.2.10", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	require.Equal(t, http.StatusOK, ping(router, "192.0.2.10", "").Code)
+
+	w = ping(router, "192.0.2.10", "")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "too_many_requests")
+}
+
+func TestRateLimiter_ByUserAcrossIPs(t *testing.T) {
+	rl := NewRateLimiterWithConfig(RateLimiterConfig{Rate: rate.Every(time.Hour), Burst: 2})
+	defer rl.Close()
+	router := rateLimitedRouter(rl)
+
+	// The user's budget is shared by every IP it uses
+	assert.Equal(t, http.StatusOK, ping(router, "192.0.2.20", "7").Code)
+	assert.Equal(t, http.StatusOK, ping(router, "192.0.2.21", "7").Code)
+	assert.Equal(t, http.StatusTooManyRequests, ping(router, "192.0.2.22", "7").Code)
+
+	// Other users are unaffected
+	assert.Equal(t, http.StatusOK, ping(router, "192.0.2.23", "8").Code)
+}
+
+func TestRateLimiter_EvictsIdleClients(t *testing.T) {
+	rl := NewRateLimiterWithConfig(RateLimiterConfig{Rate: rate.Every(time.Hour), Burst: 1, IdleTTL: time.Minute})
+	defer rl.Close()
+	router := rateLimitedRouter(rl)
+
+	ping(router, "192.0.2.30", "")
+	ping(router, "192.0.2.31", "")
+	require.Equal(t, 2, rl.Len())
+
+	rl.evictIdle(time.Now())
+	assert.Equal(t, 2, rl.Len(), "recently seen clients are kept")
+
+	rl.evictIdle(time.Now().Add(2 * time.Minute))
+	assert.Equal(t, 0, rl.Len())
+	assert.Equal(t, http.StatusOK, ping(router, "192.0.2.30", "").Code, "an evicted client starts with a full bucket")
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,45 +12,12 @@ import (
 // RequireRole middleware ensures user has one of the specified roles
 func RequireRole(allowedRoles ...models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Try to get user role from context (set by JWT middleware)
-		roleInterface, exists := c.Get("user_role")
-		if !exists {
-			// Fallback: try to get from user object
-			userInterface, userExists := c.Get("user")
-			if !userExists {
-				c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-					Success: false,
-					Message: "unauthorized: user not found in context",
-				})
-				c.Abort()
-				return
-			}
-
-			user, ok := userInterface.(*models.User)
-			if !ok {
-				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-					Success: false,
-					Message: "internal error: invalid user type",
-				})
-				c.Abort()
-				return
-			}
-			roleInterface = user.Role
-		}
-
-		// Get role string
-		roleStr, ok := roleInterface.(string)
+		userRole, ok := roleFromContext(c)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Success: false,
-				Message: "internal error: invalid role type",
-			})
-			c.Abort()
 			return
 		}
 
 		// Check if user's role is in allowed roles
-		userRole := models.Role(roleStr)
 		for _, role := range allowedRoles {
 			if userRole == role {
 				c.Next()
@@ -57,12 +25,41 @@ func RequireRole(allowedRoles ...models.Role) gin.HandlerFunc {
 			}
 		}
 
-		c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Success: false,
-			Message: "forbidden: insufficient permissions",
-		})
+		utils.ErrorResponse(c, http.StatusForbidden, "forbidden: insufficient permissions")
+		c.Abort()
+	}
+}
+
+// roleFromContext returns the role set by the auth middleware. When it is
+// missing or malformed the error response is written and ok is false.
+func roleFromContext(c *gin.Context) (models.Role, bool) {
+	// Try to get user role from context (set by JWT middleware)
+	roleInterface, exists := c.Get("user_role")
+	if !exists {
+		// Fallback: try to get from user object
+		userInterface, userExists := c.Get("user")
+		if !userExists {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized: user not found in context")
+			c.Abort()
+			return "", false
+		}
+
+		user, ok := userInterface.(*models.User)
+		if !ok {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "internal error: invalid user type")
+			c.Abort()
+			return "", false
+		}
+		roleInterface = user.Role
+	}
+
+	userRole, ok := roleInterface.(models.Role)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "internal error: invalid role type")
 		c.Abort()
+		return "", false
 	}
+	return userRole, true
 }
 
 // RequireSuperAdmin middleware ensures user is superadmin
@@ -88,5 +85,5 @@ func CheckOwnershipOrAdmin(userID uint, requestingUser *models.User) bool {
 	}
 
 	// User can only access their own resources
-	return requestingUser.ID == userID
+	return uint(requestingUser.ID) == userID
 }
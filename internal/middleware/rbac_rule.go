@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuleMode controls whether an RBACRule affects requests
+type RuleMode string
+
+const (
+	RuleOff     RuleMode = "off"     // Not evaluated
+	RuleShadow  RuleMode = "shadow"  // Evaluated and reported; requests are never denied
+	RuleEnforce RuleMode = "enforce" // Requests below MinRole get 403
+)
+
+// ParseRuleMode parses a configured rule mode. An empty string means RuleOff.
+func ParseRuleMode(s string) (RuleMode, error) {
+	switch mode := RuleMode(s); mode {
+	case "":
+		return RuleOff, nil
+	case RuleOff, RuleShadow, RuleEnforce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown RBAC rule mode %q (want off, shadow or enforce)", s)
+	}
+}
+
+// RBACRule is a permission rule that can be rolled out gradually. In shadow
+// mode the legacy decision (the route's other middleware) still applies;
+// denials are only logged and reported through OnShadowDeny, so a rule can
+// be switched to enforce once nobody would be denied.
+type RBACRule struct {
+	Name    string      // Stable identifier, used as the config key
+	MinRole models.Role // Lowest role the rule allows
+	Mode    RuleMode
+
+	// OnShadowDeny is an optional hook, e.g. for Prometheus metrics
+	OnShadowDeny func(rule, route string, role models.Role)
+}
+
+// Handler returns a middleware applying the rule. It must run after the
+// auth middleware.
+func (r RBACRule) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if r.Mode != RuleShadow && r.Mode != RuleEnforce {
+			c.Next()
+			return
+		}
+
+		role, ok := roleFromContext(c)
+		if !ok {
+			return
+		}
+		if role.AtLeast(r.MinRole) {
+			c.Next()
+			return
+		}
+
+		if r.Mode == RuleEnforce {
+			utils.ErrorResponse(c, http.StatusForbidden, "forbidden: insufficient permissions")
+			c.Abort()
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		logger.Warn("RBAC shadow denial",
+			"rule", r.Name,
+			"route", route,
+			"role", role,
+			"user_id", c.GetUint("user_id"),
+		)
+		if r.OnShadowDeny != nil {
+			r.OnShadowDeny(r.Name, route, role)
+		}
+		c.Next()
+	}
+}
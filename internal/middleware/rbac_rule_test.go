@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shadowDenial is one OnShadowDeny call
+type shadowDenial struct {
+	rule, route string
+	role        models.Role
+}
+
+// ruleRouter serves GET /users as a user with the given role, through rule
+func ruleRouter(rule RBACRule, role models.Role) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users", func(c *gin.Context) {
+		c.Set("user_role", role)
+	}, rule.Handler(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRBACRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       RuleMode
+		role       models.Role
+		wantStatus int
+		wantShadow bool
+	}{
+		{name: "off ignores the rule", mode: RuleOff, role: models.RoleUser, wantStatus: http.StatusOK},
+		{name: "shadow reports but allows", mode: RuleShadow, role: models.RoleUser, wantStatus: http.StatusOK, wantShadow: true},
+		{name: "shadow is silent when allowed", mode: RuleShadow, role: models.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "enforce denies", mode: RuleEnforce, role: models.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "enforce allows higher roles", mode: RuleEnforce, role: models.RoleSuperAdmin, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var denials []shadowDenial
+			rule := RBACRule{
+				Name:    "users_list_admin_only",
+				MinRole: models.RoleAdmin,
+				Mode:    tt.mode,
+				OnShadowDeny: func(rule, route string, role models.Role) {
+					denials = append(denials, shadowDenial{rule, route, role})
+				},
+			}
+
+			w := httptest.NewRecorder()
+			ruleRouter(rule, tt.role).ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantShadow {
+				require.Len(t, denials, 1)
+				assert.Equal(t, shadowDenial{"users_list_admin_only", "GET /users", models.RoleUser}, denials[0])
+			} else {
+				assert.Empty(t, denials)
+			}
+		})
+	}
+}
+
+func TestParseRuleMode(t *testing.T) {
+	for input, want := range map[string]RuleMode{"": RuleOff, "off": RuleOff, "shadow": RuleShadow, "enforce": RuleEnforce} {
+		mode, err := ParseRuleMode(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, mode)
+	}
+
+	_, err := ParseRuleMode("strict")
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits client-supplied IDs to short, log-safe values
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID middleware assigns every request an ID, reusing a valid incoming
+// X-Request-ID. The ID is stored as "request_id" and echoed in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
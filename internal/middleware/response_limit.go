@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseLimitConfig configures ResponseSizeLimit
+type ResponseLimitConfig struct {
+	MaxBytes int64            // Largest response body of a route without its own entry; <= 0 disables the ceiling
+	Routes   map[string]int64 // Ceilings by route template (c.FullPath()); <= 0 disables the ceiling for the route
+
+	// OnViolation is an optional hook, e.g. for Prometheus metrics
+	OnViolation func(method, route string)
+}
+
+// ResponseSizeLimit middleware replaces response bodies larger than the
+// route's ceiling by 500 with the error code response_too_large, so a runaway
+// query cannot stall clients with a multi-megabyte body. Bodies are buffered
+// up to the ceiling until the handler returns; routes that stream or export
+// large bodies must opt out with AllowLargeResponse.
+func ResponseSizeLimit(cfg ResponseLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := cfg.Routes[c.FullPath()]
+		if !ok {
+			limit = cfg.MaxBytes
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &limitedResponseWriter{ResponseWriter: original, limit: limit}
+		c.Writer = w
+		// Restore even on panic, so Recovery writes to the client instead of the buffer
+		defer func() { c.Writer = original }()
+
+		c.Next()
+		c.Writer = original
+		if w.exempt || w.streaming || w.size <= limit {
+			w.commit()
+			return
+		}
+
+		logger.Warn("Response too large",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"query", c.Request.URL.RawQuery,
+			"size", w.size,
+			"limit", limit,
+			"request_id", c.GetString("request_id"),
+		)
+		if cfg.OnViolation != nil {
+			cfg.OnViolation(c.Request.Method, c.FullPath())
+		}
+		for _, header := range []string{"Content-Type", "Content-Length", "Content-Disposition", "ETag"} {
+			original.Header().Del(header)
+		}
+		utils.ErrorDataResponse(c, http.StatusInternalServerError, "Response too large",
+			gin.H{"error": "response_too_large", "limit": limit})
+	}
+}
+
+// AllowLargeResponse exempts a route from ResponseSizeLimit. Put it first in
+// the route's handlers, before anything writes to the response.
+func AllowLargeResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if w, ok := c.Writer.(*limitedResponseWriter); ok {
+			w.exempt = true
+		}
+		c.Next()
+	}
+}
+
+// limitedResponseWriter buffers a response body until its size is known.
+// Bytes beyond the limit are counted but dropped.
+type limitedResponseWriter struct {
+	gin.ResponseWriter
+	limit     int64
+	body      bytes.Buffer
+	size      int64 // Bytes written by the handler, including dropped ones
+	exempt    bool  // Set by AllowLargeResponse; writes pass through
+	streaming bool  // Set on the first Flush; writes pass through
+}
+
+func (w *limitedResponseWriter) Write(data []byte) (int, error) {
+	if w.exempt || w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	w.size += int64(len(data))
+	if w.size > w.limit {
+		w.body = bytes.Buffer{} // Release the buffer; the body is replaced anyway
+		return len(data), nil
+	}
+	return w.body.Write(data)
+}
+
+func (w *limitedResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports buffered bodies too, so later handlers do not write a second response
+func (w *limitedResponseWriter) Written() bool {
+	return w.size > 0 || w.ResponseWriter.Written()
+}
+
+func (w *limitedResponseWriter) Size() int {
+	if w.size > 0 {
+		return int(w.size)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends the buffered body and passes later writes through: a handler
+// that flushes is streaming, and the ceiling can no longer be enforced
+func (w *limitedResponseWriter) Flush() {
+	if !w.exempt && !w.streaming {
+		w.streaming = true
+		w.commit()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// commit sends the buffered body
+func (w *limitedResponseWriter) commit() {
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
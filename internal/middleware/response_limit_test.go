@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// responseLimitRouter serves bodies of ?n bytes on several routes and records violations
+func responseLimitRouter(violations *[]string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ResponseSizeLimit(ResponseLimitConfig{
+		MaxBytes: 100,
+		Routes:   map[string]int64{"/small": 10, "/unlimited": 0},
+		OnViolation: func(method, route string) {
+			*violations = append(*violations, method+" "+route)
+		},
+	}))
+
+	body := func(c *gin.Context) {
+		c.Header("ETag", `W/"body"`)
+		c.String(http.StatusOK, strings.Repeat("x", len(c.Query("n"))))
+	}
+	router.GET("/default", body)
+	router.GET("/small", body)
+	router.GET("/unlimited", body)
+	router.GET("/export", AllowLargeResponse(), body)
+	router.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("x", 80))
+		c.Writer.Flush()
+		c.String(http.StatusOK, strings.Repeat("x", 80))
+	})
+	return router
+}
+
+func serveResponseLimit(router *gin.Engine, path string, size int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path+"?n="+strings.Repeat("1", size), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	var violations []string
+	router := responseLimitRouter(&violations)
+
+	tests := []struct {
+		name     string
+		path     string
+		size     int
+		tooLarge bool
+	}{
+		{"default ceiling, at the limit", "/default", 100, false},
+		{"default ceiling, above", "/default", 101, true},
+		{"route ceiling overrides the default", "/small", 11, true},
+		{"route without a ceiling", "/unlimited", 500, false},
+		{"exempt route", "/export", 500, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveResponseLimit(router, tt.path, tt.size)
+			if !tt.tooLarge {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, tt.size, w.Body.Len())
+				assert.Equal(t, `W/"body"`, w.Header().Get("ETag"))
+				return
+			}
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Contains(t, w.Body.String(), `"error":"response_too_large"`)
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+			assert.Empty(t, w.Header().Get("ETag"), "headers describing the dropped body are removed")
+		})
+	}
+	assert.Equal(t, []string{"GET /default", "GET /small"}, violations)
+}
+
+func TestResponseSizeLimit_StreamingPassesThrough(t *testing.T) {
+	var violations []string
+	router := responseLimitRouter(&violations)
+
+	w := serveResponseLimit(router, "/stream", 0)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 160, w.Body.Len(), "the ceiling is not enforced after a flush")
+	assert.Empty(t, violations)
+}
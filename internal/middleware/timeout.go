@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout middleware gives the request context a deadline of d. Handlers
+// pass c.Request.Context() to the services they call, so database queries
+// and other calls are canceled at the deadline.
+//
+// If the deadline passes before the handler has started the response, the
+// handler's writes are dropped and the client gets 504 with the error code
+// request_timeout once the handler returns. A response that started in time
+// is sent as is. A route's own Timeout replaces the one of its group, so a
+// group default can be raised for single routes.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		// An outer Timeout of the group already guards the response
+		if w, ok := c.Writer.(*timeoutResponseWriter); ok {
+			ctx, cancel := context.WithTimeout(w.parent, d)
+			defer cancel()
+			w.ctx, w.timeout = ctx, d
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		parent := c.Request.Context()
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		original := c.Writer
+		w := &timeoutResponseWriter{ResponseWriter: original, parent: parent, ctx: ctx, timeout: d}
+		c.Writer = w
+		// Restore even on panic, so Recovery writes to the client
+		defer func() { c.Writer = original }()
+
+		c.Next()
+		c.Writer = original
+		if !w.expired() {
+			return
+		}
+
+		logger.Warn("Request timed out",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"timeout", w.timeout,
+			"request_id", c.GetString("request_id"),
+		)
+		for _, header := range []string{"Content-Type", "Content-Length", "Content-Disposition", "ETag", "Link"} {
+			original.Header().Del(header)
+		}
+		utils.ErrorDataResponse(c, http.StatusGatewayTimeout, "Request timed out",
+			gin.H{"error": "request_timeout", "timeout": w.timeout.String()})
+	}
+}
+
+// timeoutResponseWriter drops the writes of a handler that starts its
+// response after the deadline, so that Timeout writes the only response
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+	parent  context.Context // Request context without the deadline
+	ctx     context.Context
+	timeout time.Duration
+	dropped bool
+	scratch http.Header // Receives header changes once the response is dropped
+}
+
+// expired reports whether the response is dropped: the deadline passed
+// before anything was written
+func (w *timeoutResponseWriter) expired() bool {
+	if !w.dropped && !w.ResponseWriter.Written() && w.ctx.Err() == context.DeadlineExceeded {
+		w.dropped = true
+	}
+	return w.dropped
+}
+
+func (w *timeoutResponseWriter) Header() http.Header {
+	if w.expired() {
+		if w.scratch == nil {
+			w.scratch = http.Header{}
+		}
+		return w.scratch
+	}
+	return w.ResponseWriter.Header()
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutResponseWriter) WriteHeaderNow() {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutResponseWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutResponseWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Written reports dropped responses as written, so later handlers do not try again
+func (w *timeoutResponseWriter) Written() bool {
+	return w.dropped || w.ResponseWriter.Written()
+}
+
+func (w *timeoutResponseWriter) Flush() {
+	if !w.expired() {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutRouter serves handlers that sleep for ?sleep before responding,
+// under a 20ms group timeout
+func timeoutRouter(ctxErrs chan<- error) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+
+	sleep := func(c *gin.Context) {
+		d, _ := time.ParseDuration(c.DefaultQuery("sleep", "0s"))
+		select {
+		case <-time.After(d):
+		case <-c.Request.Context().Done():
+			time.Sleep(5 * time.Millisecond) // Respond late, like a handler ignoring the context would
+		}
+		if ctxErrs != nil {
+			ctxErrs <- c.Request.Context().Err()
+		}
+	}
+
+	api := router.Group("/api", Timeout(20*time.Millisecond))
+	api.GET("/slow", sleep, func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, gin.H{"data": "late"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "second write"})
+	})
+	api.GET("/batch", Timeout(200*time.Millisecond), sleep, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "batch"})
+	})
+	api.GET("/early", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "early"})
+	}, sleep)
+	return router
+}
+
+func serveTimeout(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestTimeout_SlowHandler(t *testing.T) {
+	ctxErrs := make(chan error, 1)
+	router := timeoutRouter(ctxErrs)
+
+	var w *httptest.ResponseRecorder
+	require.NotPanics(t, func() { w = serveTimeout(router, "/api/slow?sleep=1s") })
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"), "headers of the dropped response must not leak")
+
+	// Exactly one envelope: decoding fails on trailing bytes from a second write
+	var body struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    struct {
+			Error   string `json:"error"`
+			Timeout string `json:"timeout"`
+		} `json:"data"`
+	}
+	dec := json.NewDecoder(w.Body)
+	require.NoError(t, dec.Decode(&body))
+	assert.False(t, dec.More(), "response written more than once: %s", w.Body.String())
+	assert.False(t, body.Success)
+	assert.Equal(t, "Request timed out", body.Message)
+	assert.Equal(t, "request_timeout", body.Data.Error)
+	assert.Equal(t, "20ms", body.Data.Timeout)
+
+	assert.ErrorIs(t, <-ctxErrs, context.DeadlineExceeded, "handler must see the canceled context")
+}
+
+func TestTimeout_FastHandler(t *testing.T) {
+	router := timeoutRouter(nil)
+
+	w := serveTimeout(router, "/api/slow")
+	assert.Equal(t, http.StatusOK, w.Code, "first write sets the status as usual")
+	assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"data":"late"`)
+}
+
+func TestTimeout_RouteOverridesGroup(t *testing.T) {
+	router := timeoutRouter(nil)
+
+	w := serveTimeout(router, "/api/batch?sleep=50ms")
+	assert.Equal(t, http.StatusOK, w.Code, "route timeout must replace the shorter group timeout")
+	assert.JSONEq(t, `{"data":"batch"}`, w.Body.String())
+
+	w = serveTimeout(router, "/api/batch?sleep=1s")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), `"timeout":"200ms"`)
+}
+
+func TestTimeout_ResponseStartedBeforeDeadline(t *testing.T) {
+	router := timeoutRouter(nil)
+
+	w := serveTimeout(router, "/api/early?sleep=1s")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":"early"}`, w.Body.String())
+}
+
+func TestTimeout_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", Timeout(0), func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+
+	w := serveTimeout(router, "/")
+	assert.JSONEq(t, `{"deadline":false}`, w.Body.String())
+}
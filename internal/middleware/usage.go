@@ -0,0 +1,24 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// UsageRecorder counts finished requests per user, e.g. services.UsageService.
+// Record is called on the request path and must not block.
+type UsageRecorder interface {
+	Record(userID uint, status int)
+}
+
+// TrackUsage middleware records every request of the authenticated user with
+// its response status. It must run after JWTAuth and before limiters whose
+// 429 responses should count as rate-limit rejections.
+func TrackUsage(recorder UsageRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if userID, ok := c.Get("user_id"); ok {
+			if id, ok := userID.(uint); ok {
+				recorder.Record(id, c.Writer.Status())
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserConcurrencyLimiterConfig configures a UserConcurrencyLimiter
+type UserConcurrencyLimiterConfig struct {
+	Limit      int           // Max requests in flight per user; <= 0 disables the limit
+	RetryAfter time.Duration // Advertised in the Retry-After header of rejected requests
+
+	// OnChange and OnReject are optional hooks, e.g. for Prometheus metrics.
+	// OnChange reports 0 when a user's last request finishes.
+	OnChange func(userID uint, inFlight int)
+	OnReject func(userID uint)
+}
+
+// UserConcurrencyLimiter caps the number of in-flight requests of each
+// authenticated user, so one client cannot hold every database connection
+// with slow queries. It must run after JWTAuth; requests without a user pass.
+// Users are forgotten as soon as their last request finishes, so the map
+// only holds users with requests in flight.
+type UserConcurrencyLimiter struct {
+	limit      int
+	retryAfter time.Duration
+	onChange   func(userID uint, inFlight int)
+	onReject   func(userID uint)
+
+	mu       sync.Mutex
+	inFlight map[uint]int
+	rejected atomic.Uint64
+}
+
+// NewUserConcurrencyLimiter creates a per-user limiter
+func NewUserConcurrencyLimiter(cfg UserConcurrencyLimiterConfig) *UserConcurrencyLimiter {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Second
+	}
+	return &UserConcurrencyLimiter{
+		limit:      cfg.Limit,
+		retryAfter: cfg.RetryAfter,
+		onChange:   cfg.OnChange,
+		onReject:   cfg.OnReject,
+		inFlight:   make(map[uint]int),
+	}
+}
+
+// Limit returns a middleware that rejects requests with 429 while the user is at the limit
+func (l *UserConcurrencyLimiter) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		id, isUint := userID.(uint)
+		if l.limit <= 0 || !ok || !isUint {
+			c.Next()
+			return
+		}
+
+		if !l.acquire(id) {
+			l.rejected.Add(1)
+			if l.onReject != nil {
+				l.onReject(id)
+			}
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(l.retryAfter.Seconds()))))
+			utils.ErrorDataResponse(c, http.StatusTooManyRequests,
+				"Too many concurrent requests for this user. Please try again later.",
+				gin.H{"error": "concurrency_limited", "limit": l.limit})
+			c.Abort()
+			return
+		}
+		defer l.release(id)
+
+		c.Next()
+	}
+}
+
+// acquire counts a request for userID unless the user is at the limit.
+// Hooks run under the lock so OnChange sees each user's counts in order.
+func (l *UserConcurrencyLimiter) acquire(userID uint) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.inFlight[userID]
+	if n >= l.limit {
+		return false
+	}
+	l.inFlight[userID] = n + 1
+	l.notify(userID, n+1)
+	return true
+}
+
+// release ends a request for userID, dropping the entry when it was the last
+func (l *UserConcurrencyLimiter) release(userID uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.inFlight[userID] - 1
+	if n <= 0 {
+		n = 0
+		delete(l.inFlight, userID)
+	} else {
+		l.inFlight[userID] = n
+	}
+	l.notify(userID, n)
+}
+
+// notify calls the OnChange hook; callers hold l.mu
+func (l *UserConcurrencyLimiter) notify(userID uint, inFlight int) {
+	if l.onChange != nil {
+		l.onChange(userID, inFlight)
+	}
+}
+
+// InFlight returns the number of requests currently being served for userID
+func (l *UserConcurrencyLimiter) InFlight(userID uint) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight[userID]
+}
+
+// Stats returns the limiter state for admin endpoints
+func (l *UserConcurrencyLimiter) Stats() map[string]interface{} {
+	l.mu.Lock()
+	byUser := make(map[string]int, len(l.inFlight))
+	for id, n := range l.inFlight {
+		byUser[strconv.FormatUint(uint64(id), 10)] = n
+	}
+	l.mu.Unlock()
+
+	return map[string]interface{}{
+		"limit":             l.limit,
+		"in_flight_by_user": byUser,
+		"rejected_total":    l.rejected.Load(),
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userSlowRouter serves GET /slow through the limiter as the user named in
+// the X-User header (no header means anonymous). The handler blocks until
+// release is closed.
+func userSlowRouter(limiter *UserConcurrencyLimiter, started chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if id, err := strconv.ParseUint(c.GetHeader("X-User"), 10, 64); err == nil {
+			c.Set("user_id", uint(id))
+		}
+	})
+	router.GET("/slow", limiter.Limit(), func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// userRequest builds a request to /slow as user, or anonymously for ""
+func userRequest(user string) *http.Request {
+	req := httptest.NewRequest("GET", "/slow", nil)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	return req
+}
+
+func TestUserConcurrencyLimiterRejectsPerUser(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		changes  = map[uint][]int{}
+		rejected []uint
+	)
+	limiter := NewUserConcurrencyLimiter(UserConcurrencyLimiterConfig{
+		Limit:      2,
+		RetryAfter: 1500 * time.Millisecond,
+		OnChange: func(userID uint, inFlight int) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes[userID] = append(changes[userID], inFlight)
+		},
+		OnReject: func(userID uint) {
+			mu.Lock()
+			defer mu.Unlock()
+			rejected = append(rejected, userID)
+		},
+	})
+
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	router := userSlowRouter(limiter, started, release)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, userRequest("7"))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %d of 2 requests reached the handler", i)
+		}
+	}
+	assert.Equal(t, 2, limiter.InFlight(7))
+
+	// User 7 is at the limit
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest("7"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "concurrency_limited")
+
+	// Other users are not affected
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, userRequest("8"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}()
+	<-started
+
+	stats := limiter.Stats()
+	assert.Equal(t, map[string]int{"7": 2, "8": 1}, stats["in_flight_by_user"])
+	assert.Equal(t, uint64(1), stats["rejected_total"])
+
+	close(release)
+	wg.Wait()
+
+	// Finished users are dropped from the map
+	assert.Empty(t, limiter.Stats()["in_flight_by_user"])
+	assert.Equal(t, 0, limiter.InFlight(7))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []uint{7}, rejected)
+	assert.Equal(t, []int{1, 2, 1, 0}, changes[7])
+	assert.Equal(t, []int{1, 0}, changes[8])
+}
+
+func TestUserConcurrencyLimiterSkipsAnonymousAndDisabled(t *testing.T) {
+	for name, tc := range map[string]struct {
+		limit int
+		user  string
+	}{
+		"anonymous": {limit: 1, user: ""},
+		"disabled":  {limit: 0, user: "7"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			limiter := NewUserConcurrencyLimiter(UserConcurrencyLimiterConfig{Limit: tc.limit})
+			started := make(chan struct{}, 3)
+			release := make(chan struct{})
+			router := userSlowRouter(limiter, started, release)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, userRequest(tc.user))
+					assert.Equal(t, http.StatusOK, w.Code)
+				}()
+			}
+			for i := 0; i < 3; i++ {
+				<-started
+			}
+			require.Empty(t, limiter.Stats()["in_flight_by_user"], "untracked requests are not counted")
+
+			close(release)
+			wg.Wait()
+		})
+	}
+}
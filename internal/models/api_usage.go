@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// UsageCounts are the request counters of one user over some period
+type UsageCounts struct {
+	Requests    int64 `gorm:"not null;default:0" json:"requests"`
+	Errors      int64 `gorm:"not null;default:0" json:"errors"`       // 4xx and 5xx responses other than 429
+	RateLimited int64 `gorm:"not null;default:0" json:"rate_limited"` // 429 responses
+}
+
+// Add adds other to c
+func (c *UsageCounts) Add(other UsageCounts) {
+	c.Requests += other.Requests
+	c.Errors += other.Errors
+	c.RateLimited += other.RateLimited
+}
+
+// APIUsage holds a user's request counters for one aggregation bucket
+type APIUsage struct {
+	ID          uint      `gorm:"primaryKey"`
+	UserID      uint      `gorm:"uniqueIndex:idx_api_usage_user_bucket;not null"`
+	BucketStart time.Time `gorm:"uniqueIndex:idx_api_usage_user_bucket;index;not null"`
+	UsageCounts
+}
+
+// TableName specifies the table name for APIUsage
+func (APIUsage) TableName() string {
+	return "api_usage"
+}
+
+// UsageBucket is one period of a usage report
+type UsageBucket struct {
+	Start time.Time `json:"start"`
+	UsageCounts
+}
+
+// UsageReport is returned by the usage endpoints
+type UsageReport struct {
+	UserID  uint          `json:"user_id" example:"42"`
+	Days    int           `json:"days" example:"7"`
+	Bucket  string        `json:"bucket" example:"24h0m0s"` // Aggregation granularity
+	Buckets []UsageBucket `json:"buckets"`                  // Oldest first, including empty periods
+	Totals  UsageCounts   `json:"totals"`
+}
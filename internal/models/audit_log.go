@@ -15,19 +15,48 @@ const (
 	AuditActionRefreshToken AuditAction = "refresh_token"
 	AuditActionRegister     AuditAction = "register"
 
+	// AuditActionRefreshTokenReuse records an already rotated refresh token
+	// presented again, which revokes its token family
+	AuditActionRefreshTokenReuse AuditAction = "refresh_token_reuse"
+
+	// AuditActionRegisterThrottled records registrations rejected by the
+	// per-IP or per-email-domain limits
+	AuditActionRegisterThrottled AuditAction = "register.throttled"
+
 	// User CRUD actions
 	AuditActionUserCreate      AuditAction = "user_create"
 	AuditActionUserRead        AuditAction = "user_read"
 	AuditActionUserUpdate      AuditAction = "user_update"
 	AuditActionUserDelete      AuditAction = "user_delete"
 	AuditActionUserBatchCreate AuditAction = "user_batch_create"
+	AuditActionUserOffboard    AuditAction = "user_offboard"
+	AuditActionUserDeactivate  AuditAction = "user_deactivate"
+	AuditActionUserActivate    AuditAction = "user_activate"
+	AuditActionUserBulkDelete  AuditAction = "user_bulk_delete" // One entry per deleted user
+	AuditActionUserRestore     AuditAction = "user_restore"     // Soft delete undone
+	AuditActionUserPurge       AuditAction = "user_purge"       // Soft-deleted user removed for good
 
 	// Profile actions
 	AuditActionProfileUpdate  AuditAction = "profile_update"
 	AuditActionPasswordChange AuditAction = "password_change"
+	AuditActionPasswordReset  AuditAction = "password_reset" // By an admin, on another user's account
 
 	// Role management
-	AuditActionRoleChange AuditAction = "role_change"
+	AuditActionRoleChange     AuditAction = "role_change"
+	AuditActionBulkRoleChange AuditAction = "bulk_role_change" // One entry per changed user
+
+	// Feature flag management
+	AuditActionFlagCreate AuditAction = "feature_flag_create"
+	AuditActionFlagUpdate AuditAction = "feature_flag_update"
+	AuditActionFlagDelete AuditAction = "feature_flag_delete"
+
+	// Fault injection (non-production only)
+	AuditActionChaosInject      AuditAction = "chaos_inject"
+	AuditActionChaosRemove      AuditAction = "chaos_remove"
+	AuditActionChaosExhaustPool AuditAction = "chaos_exhaust_pool"
+
+	// Audit log access
+	AuditActionAuditExport AuditAction = "audit_log_export"
 
 	// System actions
 	AuditActionSystemAccess AuditAction = "system_access"
@@ -41,24 +70,80 @@ const (
 	AuditResourceUser    AuditResource = "user"
 	AuditResourceProfile AuditResource = "profile"
 	AuditResourceSystem  AuditResource = "system"
+	AuditResourceFlag    AuditResource = "feature_flag"
+	AuditResourceAudit   AuditResource = "audit_log"
 )
 
+// AuditMetadata holds structured request context (captured headers, parsed
+// user agent, geolocation, ...) keyed by enricher
+type AuditMetadata map[string]interface{}
+
 // AuditLog represents an audit trail entry
 type AuditLog struct {
-	ID         uint          `gorm:"primaryKey" json:"id"`
-	UserID     *uint         `gorm:"index" json:"user_id,omitempty"` // Nullable for failed logins
-	Action     AuditAction   `gorm:"type:varchar(50);index" json:"action"`
-	Resource   AuditResource `gorm:"type:varchar(50);index" json:"resource"`
-	ResourceID *uint         `gorm:"index" json:"resource_id,omitempty"` // ID of affected resource
-	Details    string        `gorm:"type:text" json:"details,omitempty"` // JSON details
-	IPAddress  string        `gorm:"type:varchar(45)" json:"ip_address"` // IPv4 or IPv6
-	UserAgent  string        `gorm:"type:text" json:"user_agent,omitempty"`
-	Success    bool          `gorm:"default:true;index" json:"success"`
-	ErrorMsg   string        `gorm:"type:text" json:"error_message,omitempty"`
-	CreatedAt  time.Time     `gorm:"index" json:"created_at"`
+	ID            ID            `gorm:"primaryKey" json:"id"`
+	UserID        *ID           `gorm:"index" json:"user_id,omitempty"` // Nullable for failed logins
+	Action        AuditAction   `gorm:"type:varchar(50);index" json:"action"`
+	Resource      AuditResource `gorm:"type:varchar(50);index" json:"resource"`
+	ResourceID    *ID           `gorm:"index" json:"resource_id,omitempty"`               // ID of affected resource
+	Details       string        `gorm:"type:text" json:"details,omitempty"`               // JSON details
+	DetailsSchema string        `gorm:"type:varchar(50)" json:"details_schema,omitempty"` // Shape of Details, e.g. "user_offboard.v1"; empty on legacy rows
+	IPAddress     string        `gorm:"type:varchar(45)" json:"ip_address"`               // IPv4 or IPv6
+	UserAgent     string        `gorm:"type:text" json:"user_agent,omitempty"`
+	Metadata      AuditMetadata `gorm:"type:text;serializer:json" json:"metadata,omitempty"` // Request context added by enrichers
+	Success       bool          `gorm:"default:true;index" json:"success"`
+	ErrorMsg      string        `gorm:"type:text" json:"error_message,omitempty"`
+	CreatedAt     time.Time     `gorm:"index" json:"created_at"`
 }
 
 // TableName specifies the table name for AuditLog
 func (AuditLog) TableName() string {
 	return "audit_logs"
 }
+
+// AuditTrailRequest is the audit log payload of requests recorded by the
+// AuditTrail middleware. Body is the JSON request body with every password
+// field redacted, or nil when the body was empty, not JSON or too large.
+type AuditTrailRequest struct {
+	Method string      `json:"method"`
+	Route  string      `json:"route"` // Route pattern, e.g. /api/v1/users/:id
+	Status int         `json:"status"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// AuditLogExport is the audit log payload of a CSV export: the query
+// parameters it was filtered by and the number of rows written
+type AuditLogExport struct {
+	Filters map[string]string `json:"filters,omitempty"`
+	Rows    int               `json:"rows"`
+}
+
+// UserAuthSummary aggregates a user's authentication activity from the audit log
+type UserAuthSummary struct {
+	UserID       uint                  `json:"user_id"`
+	TotalLogins  int64                 `json:"total_logins"`  // Successful logins
+	FailedLogins int64                 `json:"failed_logins"` // Rejected logins for this account
+	ByAction     map[AuditAction]int64 `json:"by_action"`     // Every auth action, successful or not
+	LastLoginAt  *time.Time            `json:"last_login_at,omitempty"`
+	RecentIPs    []string              `json:"recent_ips"` // Up to five distinct IPs, most recent first
+}
+
+// AuditActor is the user who performed an audited action
+type AuditActor struct {
+	ID    ID     `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// UserActivityEntry is an audit log in a user's activity feed. Actor is nil
+// when the log has no user or the user no longer exists.
+type UserActivityEntry struct {
+	AuditLog
+	Actor *AuditActor `json:"actor,omitempty"`
+}
+
+// UserActivity is a page of a user's activity feed, newest first
+type UserActivity struct {
+	UserID     uint                `json:"user_id"`
+	Entries    []UserActivityEntry `json:"entries"`
+	NextBefore *time.Time          `json:"next_before,omitempty"` // before cursor of the next page; nil on the last page
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ChaosFaultType is a kind of fault injected by the chaos middleware
+type ChaosFaultType string
+
+const (
+	ChaosFaultLatency ChaosFaultType = "latency" // Delay the request, then handle it normally
+	ChaosFaultStatus  ChaosFaultType = "status"  // Answer with Status without calling the handler
+	ChaosFaultDrop    ChaosFaultType = "drop"    // Close the connection without answering
+)
+
+// ChaosFault is an active fault injected into matching requests
+type ChaosFault struct {
+	ID         string         `json:"id"`
+	Type       ChaosFaultType `json:"type"`
+	PathPrefix string         `json:"path_prefix"`
+	LatencyMS  int            `json:"latency_ms,omitempty"`
+	Status     int            `json:"status,omitempty"`
+	Percentage int            `json:"percentage"` // Share of matching requests affected
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
+
+// CreateChaosFaultRequest represents the request body for injecting a fault
+type CreateChaosFaultRequest struct {
+	Type            ChaosFaultType `json:"type" binding:"required,oneof=latency status drop" example:"latency"`
+	PathPrefix      string         `json:"path_prefix" binding:"omitempty,startswith=/" example:"/api/v1/users"` // Defaults to /api/
+	LatencyMS       int            `json:"latency_ms" binding:"omitempty,min=1,max=60000" example:"2000"`        // Required for latency
+	Status          int            `json:"status" binding:"omitempty,min=400,max=599" example:"503"`             // Required for status
+	Percentage      *int           `json:"percentage" binding:"omitempty,min=1,max=100" example:"50"`            // Defaults to 100
+	DurationSeconds int            `json:"duration_seconds" binding:"omitempty,min=1,max=3600" example:"300"`    // Defaults to 300
+}
+
+// ExhaustPoolRequest represents the request body for holding database connections
+type ExhaustPoolRequest struct {
+	DurationSeconds int `json:"duration_seconds" binding:"required,min=1,max=60" example:"5"`
+	Connections     int `json:"connections" binding:"omitempty,min=1,max=1000" example:"10"` // Used when the pool is unlimited; defaults to 10
+}
+
+// ChaosPoolReport describes a database pool exhaustion in progress
+type ChaosPoolReport struct {
+	Held       int       `json:"held"` // Connections taken from the pool
+	ReleasedAt time.Time `json:"released_at"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the JSON form of a Date
+const DateLayout = "2006-01-02"
+
+// MaxAge is the highest plausible age, in years
+const MaxAge = 150
+
+// Date is a calendar date at midnight UTC. It marshals as "2006-01-02" and
+// unmarshals from that form or from an RFC 3339 timestamp, of which only the
+// date is kept.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the date of t in t's location
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// MarshalJSON encodes the date as "2006-01-02"
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.Format(DateLayout) + `"`), nil
+}
+
+// UnmarshalJSON accepts "2006-01-02" and RFC 3339 timestamps
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("invalid date %s", data)
+	}
+	value := string(data[1 : len(data)-1])
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+		}
+	}
+	*d = NewDate(t)
+	return nil
+}
+
+// TimePtr returns the date as a time for storage, or nil for a nil date
+func (d *Date) TimePtr() *time.Time {
+	if d == nil {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// AgeOn returns the age in whole years on now of someone born on dob
+func AgeOn(dob, now time.Time) int {
+	dob, now = dob.UTC(), now.UTC()
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// ValidDateOfBirth reports whether dob lies before today and no more than MaxAge years back
+func ValidDateOfBirth(dob, now time.Time) bool {
+	today := NewDate(now.UTC()).Time
+	return dob.Before(today) && !dob.Before(today.AddDate(-MaxAge, 0, 0))
+}
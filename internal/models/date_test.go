@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateJSON(t *testing.T) {
+	for _, input := range []string{`"1990-05-17"`, `"1990-05-17T23:30:00+07:00"`} {
+		var date Date
+		require.NoError(t, json.Unmarshal([]byte(input), &date), input)
+		assert.Equal(t, time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), date.Time, input)
+
+		encoded, err := json.Marshal(date)
+		require.NoError(t, err)
+		assert.JSONEq(t, `"1990-05-17"`, string(encoded))
+	}
+
+	for _, input := range []string{`"17/05/1990"`, `19900517`, `"1990-02-30"`} {
+		var date Date
+		assert.Error(t, json.Unmarshal([]byte(input), &date), input)
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	dob := time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 23, AgeOn(dob, time.Date(2024, 2, 28, 12, 0, 0, 0, time.UTC)), "day before the birthday")
+	assert.Equal(t, 24, AgeOn(dob, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)), "on the birthday")
+	assert.Equal(t, 25, AgeOn(dob, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)), "leap day birthdays pass on March 1st")
+}
+
+func TestValidDateOfBirth(t *testing.T) {
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	assert.True(t, ValidDateOfBirth(time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), now))
+	assert.True(t, ValidDateOfBirth(time.Date(1876, 10, 15, 0, 0, 0, 0, time.UTC), now), "exactly MaxAge")
+	assert.False(t, ValidDateOfBirth(time.Date(1876, 10, 14, 0, 0, 0, 0, time.UTC), now), "older than MaxAge")
+	assert.False(t, ValidDateOfBirth(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), now), "today")
+	assert.False(t, ValidDateOfBirth(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), now), "future")
+}
+
+func TestUserJSONComputesAge(t *testing.T) {
+	dob := time.Now().UTC().AddDate(-30, 0, -1)
+	encoded, err := json.Marshal(User{Age: 99, DateOfBirth: &dob})
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.Equal(t, float64(30), fields["age"], "the date of birth wins over the stored age")
+	assert.NotEmpty(t, fields["date_of_birth"])
+
+	encoded, err = json.Marshal(&User{Age: 41})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.Equal(t, float64(41), fields["age"], "stored age without a date of birth")
+
+	encoded, err = json.Marshal(User{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), `"age"`, "unknown ages are omitted")
+}
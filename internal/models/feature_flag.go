@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// FeatureFlag toggles a behavior at runtime. A flag is on for a user when it is
+// enabled, the user's role is listed in Roles (or Roles is empty) and the user
+// falls within the first Percentage percent of the rollout.
+type FeatureFlag struct {
+	ID          ID        `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"name" example:"strict_binding"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	Enabled     bool      `gorm:"not null" json:"enabled"`                  // Master switch
+	Roles       []Role    `gorm:"type:text;serializer:json" json:"roles"`   // Empty means every role
+	Percentage  int       `gorm:"not null" json:"percentage" example:"100"` // Share of users, 0-100
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for FeatureFlag
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
+
+// CreateFeatureFlagRequest represents the request body for creating a flag
+type CreateFeatureFlagRequest struct {
+	Name        string `json:"name" binding:"required,max=100" example:"strict_binding"`
+	Description string `json:"description" binding:"max=500"`
+	Enabled     bool   `json:"enabled"`
+	Roles       []Role `json:"roles" binding:"omitempty,dive,oneof=user admin superadmin" example:"admin"`
+	Percentage  *int   `json:"percentage" binding:"omitempty,min=0,max=100" example:"25"` // Defaults to 100
+}
+
+// UpdateFeatureFlagRequest represents the request body for updating a flag; omitted fields are kept
+type UpdateFeatureFlagRequest struct {
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+	Enabled     *bool   `json:"enabled,omitempty"`
+	Roles       *[]Role `json:"roles,omitempty" binding:"omitempty,dive,oneof=user admin superadmin"`
+	Percentage  *int    `json:"percentage,omitempty" binding:"omitempty,min=0,max=100"`
+}
+
+// FeatureFlagChange is the audit log payload of flag changes. Before is nil
+// for created flags, After for deleted ones.
+type FeatureFlagChange struct {
+	Name   string       `json:"name"`
+	Before *FeatureFlag `json:"before,omitempty"`
+	After  *FeatureFlag `json:"after,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// ID is a database identifier. It marshals as a JSON number and unmarshals
+// from a number or a decimal string, so clients that asked for string IDs
+// (X-ID-Format: string) can send them back unchanged.
+type ID uint
+
+// ParseID parses a decimal ID
+func ParseID(s string) (ID, error) {
+	value, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return ID(value), nil
+}
+
+// String returns the decimal form of the ID
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// MarshalJSON encodes the ID as a JSON number
+func (id ID) MarshalJSON() ([]byte, error) {
+	return strconv.AppendUint(nil, uint64(id), 10), nil
+}
+
+// UnmarshalJSON accepts 42 and "42"
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+	parsed, err := ParseID(string(data))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// IDPtr converts an optional uint, e.g. a user ID from the request context, to an optional ID
+func IDPtr(id *uint) *ID {
+	if id == nil {
+		return nil
+	}
+	value := ID(*id)
+	return &value
+}
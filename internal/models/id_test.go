@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDJSONRoundTrip(t *testing.T) {
+	for name, input := range map[string]string{
+		"number": `{"id":42,"user_id":7,"resource_id":null}`,
+		"string": `{"id":"42","user_id":"7","resource_id":null}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var log AuditLog
+			require.NoError(t, json.Unmarshal([]byte(input), &log))
+			assert.Equal(t, ID(42), log.ID)
+			require.NotNil(t, log.UserID)
+			assert.Equal(t, ID(7), *log.UserID)
+			assert.Nil(t, log.ResourceID)
+
+			encoded, err := json.Marshal(log)
+			require.NoError(t, err)
+			var fields map[string]interface{}
+			require.NoError(t, json.Unmarshal(encoded, &fields))
+			assert.Equal(t, float64(42), fields["id"], "IDs marshal as numbers")
+			assert.Equal(t, float64(7), fields["user_id"])
+		})
+	}
+}
+
+func TestIDRejectsInvalidValues(t *testing.T) {
+	for _, input := range []string{`"abc"`, `-1`, `1.5`, `"42`, `""`} {
+		var id ID
+		assert.Error(t, json.Unmarshal([]byte(input), &id), input)
+	}
+}
+
+func TestIDPtr(t *testing.T) {
+	assert.Nil(t, IDPtr(nil))
+	value := uint(3)
+	assert.Equal(t, ID(3), *IDPtr(&value))
+}
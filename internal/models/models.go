@@ -1,11 +1,18 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrInvalidRole is returned when saving a user whose role is not a known Role
+var ErrInvalidRole = errors.New("invalid role")
+
 // Role represents user role in the system
 type Role string
 
@@ -15,6 +22,9 @@ const (
 	RoleUser       Role = "user"
 )
 
+// Roles lists every valid role, lowest first
+var Roles = []Role{RoleUser, RoleAdmin, RoleSuperAdmin}
+
 // IsValid checks if role is valid
 func (r Role) IsValid() bool {
 	return r == RoleSuperAdmin || r == RoleAdmin || r == RoleUser
@@ -25,26 +35,96 @@ func (r Role) String() string {
 	return string(r)
 }
 
+// Level returns the role's rank in the hierarchy (user < admin < superadmin).
+// Unknown roles rank below user.
+func (r Role) Level() int {
+	switch r {
+	case RoleSuperAdmin:
+		return 3
+	case RoleAdmin:
+		return 2
+	case RoleUser:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast checks if the role ranks at or above min
+func (r Role) AtLeast(min Role) bool {
+	return r.IsValid() && r.Level() >= min.Level()
+}
+
 // User represents a user in the system
 type User struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Name        string         `gorm:"not null" json:"name"`
-	Email       string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password    string         `gorm:"default:''" json:"-"` // Password is optional for migration, never exposed in JSON
-	Age         int            `gorm:"not null" json:"age"`
-	Role        string         `gorm:"type:varchar(20);default:'user';not null" json:"role"` // Role: superadmin, admin, user
-	IsActive    bool           `gorm:"default:true" json:"is_active"`
-	AvatarURL   string         `gorm:"type:varchar(255)" json:"avatar_url,omitempty"`  // Profile avatar URL
-	Bio         string         `gorm:"type:text" json:"bio,omitempty"`                 // User biography
-	PhoneNumber string         `gorm:"type:varchar(20)" json:"phone_number,omitempty"` // Contact phone number
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 ID             `gorm:"primaryKey" json:"id"`
+	Name               string         `gorm:"not null" json:"name"`
+	Email              string         `gorm:"uniqueIndex;not null" json:"email"`
+	Password           string         `gorm:"default:''" json:"-"`                                  // Password is optional for migration, never exposed in JSON
+	Age                int            `gorm:"not null" json:"age,omitempty"`                        // Stored age; 0 if unknown. Rendered by AgeAt
+	DateOfBirth        *time.Time     `gorm:"type:date" json:"date_of_birth,omitempty"`             // When set, the age is computed from it
+	Role               Role           `gorm:"type:varchar(20);default:'user';not null" json:"role"` // Role: superadmin, admin, user
+	IsActive           bool           `gorm:"default:true" json:"is_active"`
+	AvatarURL          string         `gorm:"type:varchar(255)" json:"avatar_url,omitempty"`                // Profile avatar URL
+	Bio                string         `gorm:"type:text" json:"bio,omitempty"`                               // User biography
+	PhoneNumber        string         `gorm:"type:varchar(20)" json:"phone_number,omitempty"`               // Contact phone number
+	TokensRevokedAt    *time.Time     `gorm:"index" json:"-"`                                               // Tokens issued before this instant are rejected
+	MustChangePassword bool           `gorm:"not null;default:false" json:"must_change_password,omitempty"` // Set by an admin password reset, cleared by the next password change
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// AgeAt returns the user's age on now: computed from DateOfBirth when it is
+// set, the stored Age otherwise
+func (u *User) AgeAt(now time.Time) int {
+	if u.DateOfBirth == nil {
+		return u.Age
+	}
+	return AgeOn(*u.DateOfBirth, now)
+}
+
+// MarshalJSON renders the age as of today, so it stays current for users
+// with a date of birth
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User // Without methods, so Marshal does not recurse
+	out := user(u)
+	out.Age = u.AgeAt(time.Now())
+	return json.Marshal(out)
+}
+
+// TokenRevoked reports whether a token issued at issuedAt was revoked administratively
+func (u *User) TokenRevoked(issuedAt time.Time) bool {
+	return u.TokensRevokedAt != nil && issuedAt.Before(*u.TokensRevokedAt)
+}
+
+// NormalizeEmail returns the form emails are stored and compared in:
+// trimmed and lowercased, so John@Example.com and john@example.com are one user
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// BeforeSave rejects unknown roles so junk values never reach the database.
+// An empty role on a new user falls back to the column default. The email
+// is normalized with NormalizeEmail.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Email = NormalizeEmail(u.Email)
+	if u.Role == "" && u.ID == 0 {
+		u.Role = RoleUser
+	}
+	if !u.Role.IsValid() {
+		return fmt.Errorf("%w: %q", ErrInvalidRole, u.Role)
+	}
+	// Keep the stored age close to the computed one, so sorting by age works
+	if u.DateOfBirth != nil {
+		u.Age = u.AgeAt(time.Now())
+	}
+	return nil
 }
 
 // HasRole checks if user has specific role
 func (u *User) HasRole(role Role) bool {
-	return Role(u.Role) == role
+	return u.Role == role
 }
 
 // IsSuperAdmin checks if user is superadmin
@@ -54,7 +134,7 @@ func (u *User) IsSuperAdmin() bool {
 
 // IsAdmin checks if user is admin or superadmin
 func (u *User) IsAdmin() bool {
-	return u.HasRole(RoleAdmin) || u.HasRole(RoleSuperAdmin)
+	return u.Role.AtLeast(RoleAdmin)
 }
 
 // CanManageUsers checks if user can manage other users
@@ -76,22 +156,62 @@ func (u *User) CanPromoteUsers() bool {
 type CreateUserRequest struct {
 	Name     string `json:"name" binding:"required,min=2,max=100" example:"John Doe"`
 	Email    string `json:"email" binding:"required,email" example:"john@example.com"`
-	Password string `json:"password" binding:"required,min=6,max=100" example:"password123"`
-	Age      int    `json:"age" binding:"required,min=1,max=150" example:"25"`
-	Role     string `json:"role" binding:"omitempty,oneof=user admin superadmin" example:"user"` // Optional, defaults to 'user'
+	Password string `json:"password" binding:"required,strong_password,max=100" example:"Tr4vel-Blue"`
+	Age      int    `json:"age,omitempty" binding:"omitempty,min=1,max=150,excluded_with=DateOfBirth" example:"25"` // Optional; give age or date_of_birth, not both
+	Role     Role   `json:"role" binding:"omitempty,oneof=user admin superadmin" example:"user"`                    // Optional, defaults to 'user'; may not rank above the creator's role
+
+	DateOfBirth *Date `json:"date_of_birth,omitempty" binding:"omitempty,dob" swaggertype:"string" format:"date" example:"1990-05-17"` // Optional; the age is computed from it
 }
 
 // UpdateUserRequest represents the request body for updating a user
 type UpdateUserRequest struct {
 	Name  *string `json:"name,omitempty" binding:"omitempty,min=2,max=100" example:"Jane Doe"`
 	Email *string `json:"email,omitempty" binding:"omitempty,email" example:"jane@example.com"`
-	Age   *int    `json:"age,omitempty" binding:"omitempty,min=1,max=150" example:"26"`
-	Role  *string `json:"role,omitempty" binding:"omitempty,oneof=user admin superadmin" example:"admin"` // Only superadmin can change roles
+	Age   *int    `json:"age,omitempty" binding:"omitempty,min=1,max=150,excluded_with=DateOfBirth" example:"26"` // Clears the date of birth
+	Role  *Role   `json:"role,omitempty" binding:"omitempty,oneof=user admin superadmin" example:"admin"`         // Only superadmin can change roles
+
+	DateOfBirth *Date `json:"date_of_birth,omitempty" binding:"omitempty,dob" swaggertype:"string" format:"date" example:"1990-05-17"`
 }
 
 // UpdateRoleRequest represents the request body for updating user role
 type UpdateRoleRequest struct {
-	Role string `json:"role" binding:"required,oneof=user admin superadmin" example:"admin"`
+	Role Role `json:"role" binding:"required,oneof=user admin superadmin" example:"admin"`
+}
+
+// BulkDeleteUsersRequest lists the users to delete at once
+type BulkDeleteUsersRequest struct {
+	IDs []ID `json:"ids" binding:"required,min=1,max=100" example:"1,2,3"`
+}
+
+// RoleAssignment is the new role of one user of a bulk role change
+type RoleAssignment struct {
+	ID   ID   `json:"id" binding:"required" example:"1"`
+	Role Role `json:"role" binding:"required,oneof=user admin superadmin" example:"admin"`
+}
+
+// BulkUpdateRolesRequest wraps the array of role assignments sent to
+// PUT /users/roles, so that each one is validated
+type BulkUpdateRolesRequest struct {
+	Users []RoleAssignment `binding:"required,min=1,max=100,dive"`
+}
+
+// BulkUserFailure is a user of a bulk change that was not changed
+type BulkUserFailure struct {
+	ID    ID     `json:"id" example:"3"`
+	Error string `json:"error" example:"user not found"`
+}
+
+// BulkUserResult reports every user of a bulk change, in request order
+type BulkUserResult struct {
+	Succeeded []ID              `json:"succeeded"`
+	Failed    []BulkUserFailure `json:"failed"`
+}
+
+// RoleChange is the role of one user before and after a bulk role change
+type RoleChange struct {
+	UserID ID   `json:"user_id" example:"1"`
+	From   Role `json:"from" example:"user"`
+	To     Role `json:"to" example:"admin"`
 }
 
 // BatchCreateUsersRequest represents batch user creation request
@@ -99,6 +219,19 @@ type BatchCreateUsersRequest struct {
 	Users []*CreateUserRequest `json:"users" binding:"required,min=1,max=100,dive"`
 }
 
+// BatchCreateFailure is a user of a batch that was not created
+type BatchCreateFailure struct {
+	Index int    `json:"index" example:"1"` // Position of the user in the request
+	Email string `json:"email,omitempty" example:"john@example.com"`
+	Error string `json:"error" example:"email already exists"`
+}
+
+// BatchCreateUsersResult reports every user of a batch, in request order
+type BatchCreateUsersResult struct {
+	Created []*User              `json:"created"`
+	Failed  []BatchCreateFailure `json:"failed"`
+}
+
 // PaginationQuery represents pagination query parameters
 type PaginationQuery struct {
 	Page   int    `form:"page" binding:"omitempty,min=1" example:"1"`
@@ -106,6 +239,44 @@ type PaginationQuery struct {
 	Sort   string `form:"sort" binding:"omitempty,oneof=name email age created_at" example:"created_at"`
 	Order  string `form:"order" binding:"omitempty,oneof=asc desc" example:"desc"`
 	Search string `form:"search" binding:"omitempty,max=100" example:"john"`
+	Active *bool  `form:"active" example:"true"`                                                // Only active (true) or inactive (false) users; all if omitted
+	Role   Role   `form:"role" binding:"omitempty,oneof=user admin superadmin" example:"admin"` // Only users with this role
+}
+
+// DeletedUserQuery represents the query parameters of the deleted user listing
+type DeletedUserQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1" example:"1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100" example:"10"`
+}
+
+// DeletedUser is a soft-deleted user with the time it was deleted
+type DeletedUser struct {
+	User      *User     `json:"user"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// UserByEmailQuery represents the query parameters of the user lookup by email
+type UserByEmailQuery struct {
+	Email string `form:"email" binding:"required,email" example:"john@example.com"`
+}
+
+// WebSocketStatsQuery represents the query parameters of the WebSocket stats endpoint
+type WebSocketStatsQuery struct {
+	Detail bool `form:"detail" example:"true"`                                // Include per-connection rows
+	UserID uint `form:"user_id" binding:"omitempty,min=1" example:"42"`       // Only connections of this user
+	Page   int  `form:"page" binding:"omitempty,min=1" example:"1"`           // Page of connection rows
+	Limit  int  `form:"limit" binding:"omitempty,min=1,max=100" example:"20"` // Connection rows per page
+}
+
+// WebSocketDisconnectRequest is the optional body of the WebSocket disconnect endpoint
+type WebSocketDisconnectRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=123" example:"session revoked by an administrator"` // Sent to the client in the close frame
+}
+
+// WebSocketDisconnectResult reports how many connections of a user were closed
+type WebSocketDisconnectResult struct {
+	UserID       uint `json:"user_id" example:"42"`
+	Disconnected int  `json:"disconnected" example:"2"`
 }
 
 // PaginationMeta represents pagination metadata
@@ -114,6 +285,30 @@ type PaginationMeta struct {
 	Limit      int   `json:"limit"`
 	Total      int64 `json:"total"`
 	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+	HasPrev    bool  `json:"has_prev"`
+	NextPage   *int  `json:"next_page,omitempty"`
+	PrevPage   *int  `json:"prev_page,omitempty"`
+}
+
+// NewPaginationMeta computes the metadata of page (from 1) of limit items
+// out of total. The previous page of a page past the end is the last page.
+func NewPaginationMeta(page, limit int, total int64) PaginationMeta {
+	meta := PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+	if page < meta.TotalPages {
+		next := page + 1
+		meta.HasNext, meta.NextPage = true, &next
+	}
+	if page > 1 {
+		prev := min(page-1, max(meta.TotalPages, 1))
+		meta.HasPrev, meta.PrevPage = true, &prev
+	}
+	return meta
 }
 
 // PaginatedResponse represents paginated API response
@@ -144,13 +339,35 @@ type ErrorResponse struct {
 	Errors  []ValidationError `json:"errors,omitempty"`
 }
 
+// Problem represents an RFC 7807 problem details error response (API v2)
+type Problem struct {
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Status int               `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Errors []ValidationError `json:"errors,omitempty"`
+	Data   interface{}       `json:"data,omitempty"`
+}
+
+// Page represents a page of a collection without the response envelope (API v2)
+type Page struct {
+	Items      interface{}    `json:"items"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
 // RegisterRequest represents the request body for user registration
 type RegisterRequest struct {
 	Name     string `json:"name" binding:"required,min=2,max=100" example:"John Doe"`
 	Email    string `json:"email" binding:"required,email" example:"john@example.com"`
-	Password string `json:"password" binding:"required,min=6,max=100" example:"password123"`
-	Age      int    `json:"age" binding:"required,min=1,max=150" example:"25"`
+	Password string `json:"password" binding:"required,strong_password,max=100" example:"Tr4vel-Blue"`
+	Age      int    `json:"age,omitempty" binding:"omitempty,min=1,max=150,excluded_with=DateOfBirth" example:"25"` // Optional; give age or date_of_birth, not both
 	// Role is not included in registration - all new users start as 'user'
+
+	DateOfBirth *Date `json:"date_of_birth,omitempty" binding:"omitempty,dob" swaggertype:"string" format:"date" example:"1990-05-17"` // Optional; the age is computed from it
+
+	// ChallengeToken answers the proof-of-work or captcha challenge when
+	// registration.challenge is enabled
+	ChallengeToken string `json:"challenge_token,omitempty" example:""`
 }
 
 // LoginRequest represents the request body for login
@@ -161,11 +378,16 @@ type LoginRequest struct {
 
 // LoginResponse represents the response body for login
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    int64  `json:"expires_in"` // seconds
-	User         User   `json:"user"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"` // seconds
+	ExpiresAt    time.Time `json:"expires_at"` // Expiry of the access token
+	User         User      `json:"user"`
+
+	// MustChangePassword is set after an admin password reset. Until the
+	// password is changed, the token is only accepted by PUT /users/me/password.
+	MustChangePassword bool `json:"must_change_password"`
 }
 
 // RefreshTokenRequest represents the request body for token refresh
@@ -175,15 +397,18 @@ type RefreshTokenRequest struct {
 
 // RefreshTokenResponse represents the response body for token refresh
 type RefreshTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int64  `json:"expires_in"` // seconds
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"` // Replaces the refresh token sent in the request
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"` // seconds
+	ExpiresAt    time.Time `json:"expires_at"` // Expiry of the access token
 }
 
 // UpdateProfileRequest represents the request body for updating own profile
 type UpdateProfileRequest struct {
 	Name        *string `json:"name,omitempty" binding:"omitempty,min=2,max=100" example:"John Doe"`
-	Age         *int    `json:"age,omitempty" binding:"omitempty,min=1,max=150" example:"26"`
+	Age         *int    `json:"age,omitempty" binding:"omitempty,min=1,max=150,excluded_with=DateOfBirth" example:"26"` // Clears the date of birth
+	DateOfBirth *Date   `json:"date_of_birth,omitempty" binding:"omitempty,dob" swaggertype:"string" format:"date" example:"1990-05-17"`
 	AvatarURL   *string `json:"avatar_url,omitempty" binding:"omitempty,url" example:"https://example.com/avatar.jpg"`
 	Bio         *string `json:"bio,omitempty" binding:"omitempty,max=500" example:"Software developer"`
 	PhoneNumber *string `json:"phone_number,omitempty" binding:"omitempty,min=10,max=20" example:"+628123456789"`
@@ -192,5 +417,18 @@ type UpdateProfileRequest struct {
 // ChangePasswordRequest represents the request body for changing password
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required,min=6" example:"oldpassword123"`
-	NewPassword     string `json:"new_password" binding:"required,min=6,max=100" example:"newpassword123"`
+	NewPassword     string `json:"new_password" binding:"required,strong_password,max=100" example:"Qu1et-Harbor"`
+}
+
+// AdminResetPasswordRequest is the optional body of POST /users/:id/reset-password.
+// Without a new password, a random temporary one is generated.
+type AdminResetPasswordRequest struct {
+	NewPassword string `json:"new_password,omitempty" binding:"omitempty,min=6,max=100" example:"temporary123"`
+}
+
+// AdminResetPasswordResponse reports an admin password reset
+type AdminResetPasswordResponse struct {
+	UserID             ID     `json:"user_id" example:"42"`
+	MustChangePassword bool   `json:"must_change_password" example:"true"`
+	TemporaryPassword  string `json:"temporary_password,omitempty" example:"q7Xv2LpN9sKd4RtW"` // Only when generated; not shown again
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPaginationMeta(t *testing.T) {
+	page := func(n int) *int { return &n }
+
+	tests := []struct {
+		name        string
+		page, limit int
+		total       int64
+		want        PaginationMeta
+	}{
+		{"first page", 1, 10, 25, PaginationMeta{Page: 1, Limit: 10, Total: 25, TotalPages: 3, HasNext: true, NextPage: page(2)}},
+		{"middle page", 2, 10, 25, PaginationMeta{Page: 2, Limit: 10, Total: 25, TotalPages: 3, HasNext: true, HasPrev: true, NextPage: page(3), PrevPage: page(1)}},
+		{"last page", 3, 10, 25, PaginationMeta{Page: 3, Limit: 10, Total: 25, TotalPages: 3, HasPrev: true, PrevPage: page(2)}},
+		{"past the end", 7, 10, 25, PaginationMeta{Page: 7, Limit: 10, Total: 25, TotalPages: 3, HasPrev: true, PrevPage: page(3)}},
+		{"exact fit", 1, 5, 5, PaginationMeta{Page: 1, Limit: 5, Total: 5, TotalPages: 1}},
+		{"empty", 1, 10, 0, PaginationMeta{Page: 1, Limit: 10}},
+		{"empty past the end", 2, 10, 0, PaginationMeta{Page: 2, Limit: 10, HasPrev: true, PrevPage: page(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewPaginationMeta(tt.page, tt.limit, tt.total))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// OffboardStepStatus is the outcome of a single offboarding step
+type OffboardStepStatus string
+
+const (
+	OffboardStepOK      OffboardStepStatus = "ok"
+	OffboardStepFailed  OffboardStepStatus = "failed"
+	OffboardStepSkipped OffboardStepStatus = "skipped"
+)
+
+// Offboarding step names, in execution order
+const (
+	OffboardStepDeactivate       = "deactivate_account"
+	OffboardStepRevokeTokens     = "revoke_tokens"
+	OffboardStepScramblePassword = "scramble_password"
+	OffboardStepRevokeAPIKeys    = "revoke_api_keys"
+	OffboardStepDisconnectWS     = "disconnect_websockets"
+)
+
+// OffboardStep reports what happened in one offboarding step
+type OffboardStep struct {
+	Name   string             `json:"name" example:"revoke_tokens"`
+	Status OffboardStepStatus `json:"status" example:"ok"`
+	Detail string             `json:"detail,omitempty" example:"tokens issued before 2024-01-01T00:00:00Z are rejected"`
+}
+
+// OffboardReport is returned by the offboard endpoint and stored in the audit log
+type OffboardReport struct {
+	UserID      uint           `json:"user_id" example:"42"`
+	Email       string         `json:"email" example:"leaver@example.com"`
+	Steps       []OffboardStep `json:"steps"`
+	Success     bool           `json:"success" example:"true"` // False if any step failed
+	CompletedAt time.Time      `json:"completed_at"`
+}
+
+// AddStep appends a step result and updates the overall success flag
+func (r *OffboardReport) AddStep(name string, status OffboardStepStatus, detail string) {
+	r.Steps = append(r.Steps, OffboardStep{Name: name, Status: status, Detail: detail})
+	if status == OffboardStepFailed {
+		r.Success = false
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// RefreshToken records an issued refresh token by its ID (jti). The tokens
+// issued from one login form a family: refreshing rotates the presented token
+// and issues the next one of the family. Presenting a rotated token again
+// means it was copied, so the whole family is revoked. Rows can be pruned once
+// ExpiresAt has passed.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	JTI       string     `gorm:"column:jti;type:varchar(64);uniqueIndex;not null"`
+	FamilyID  string     `gorm:"type:varchar(64);index;not null"` // JTI of the family's first token
+	UserID    uint       `gorm:"index;not null"`
+	ExpiresAt time.Time  `gorm:"index;not null"`
+	RotatedAt *time.Time // Set when the token was exchanged for its successor
+	RevokedAt *time.Time // Set when the family was revoked
+	CreatedAt time.Time
+}
+
+// TableName specifies the table name for RefreshToken
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RefreshTokenReuse is stored in the audit log for refresh_token_reuse entries
+type RefreshTokenReuse struct {
+	FamilyID      string `json:"family_id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	RevokedTokens int64  `json:"revoked_tokens" example:"1"`
+}
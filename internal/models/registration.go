@@ -0,0 +1,17 @@
+package models
+
+// RegisterThrottleScope names the limit that rejected a registration
+type RegisterThrottleScope string
+
+const (
+	RegisterThrottleIP     RegisterThrottleScope = "ip"
+	RegisterThrottleDomain RegisterThrottleScope = "email_domain"
+)
+
+// RegisterThrottle is stored in the audit log for register.throttled entries.
+// The email itself is not kept, only its domain.
+type RegisterThrottle struct {
+	Scope             RegisterThrottleScope `json:"scope" example:"email_domain"`
+	Domain            string                `json:"domain,omitempty" example:"example.com"`
+	RetryAfterSeconds int                   `json:"retry_after_seconds" example:"360"`
+}
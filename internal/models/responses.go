@@ -0,0 +1,176 @@
+package models
+
+// Typed response bodies. Handlers render them, directly or through the
+// envelope of utils.SuccessResponse, and the Swagger annotations reference
+// them, so the documented shapes are the ones sent.
+
+// MessageResponse is a success without data, e.g. a deletion
+type MessageResponse struct {
+	Success bool   `json:"success" example:"true"`
+	Message string `json:"message" example:"user deleted successfully"`
+}
+
+// MessageData is the data of a success that only reports a message
+type MessageData struct {
+	Message string `json:"message" example:"password changed successfully"`
+}
+
+// MessageDataResponse wraps MessageData
+type MessageDataResponse struct {
+	Success bool        `json:"success" example:"true"`
+	Data    MessageData `json:"data"`
+}
+
+// UserResponse is the response with a single user
+type UserResponse struct {
+	Success bool   `json:"success" example:"true"`
+	Message string `json:"message,omitempty" example:"user updated successfully"`
+	Data    *User  `json:"data"`
+}
+
+// UserListResponse is a page of users
+type UserListResponse struct {
+	Success    bool           `json:"success" example:"true"`
+	Data       []*User        `json:"data"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// DeletedUserListResponse is a page of soft-deleted users
+type DeletedUserListResponse struct {
+	Success    bool           `json:"success" example:"true"`
+	Data       []DeletedUser  `json:"data"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// UserChange is the data of a response that reports a change to a user
+type UserChange struct {
+	Message string `json:"message" example:"user role updated successfully"`
+	User    *User  `json:"user"`
+}
+
+// UserChangeResponse wraps UserChange
+type UserChangeResponse struct {
+	Success bool       `json:"success" example:"true"`
+	Data    UserChange `json:"data"`
+}
+
+// StatsResponse carries counters keyed by name, e.g. the user statistics
+type StatsResponse struct {
+	Success bool                   `json:"success" example:"true"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// BatchCreateUsersResponse wraps the result of a batch creation
+type BatchCreateUsersResponse struct {
+	Success bool                    `json:"success" example:"true"`
+	Message string                  `json:"message" example:"users created successfully"`
+	Data    *BatchCreateUsersResult `json:"data"`
+}
+
+// BulkUserResultResponse wraps the result of a bulk delete or role change
+type BulkUserResultResponse struct {
+	Success bool            `json:"success" example:"true"`
+	Message string          `json:"message" example:"deleted 3 users"`
+	Data    *BulkUserResult `json:"data"`
+}
+
+// UserImportResponse wraps the result of a CSV import
+type UserImportResponse struct {
+	Success bool              `json:"success" example:"true"`
+	Message string            `json:"message" example:"imported 2 of 3 users"`
+	Data    *UserImportResult `json:"data"`
+}
+
+// AdminResetPasswordResponseEnvelope wraps AdminResetPasswordResponse
+type AdminResetPasswordResponseEnvelope struct {
+	Success bool                       `json:"success" example:"true"`
+	Message string                     `json:"message" example:"password reset successfully"`
+	Data    AdminResetPasswordResponse `json:"data"`
+}
+
+// LoginResponseEnvelope wraps LoginResponse, the response to a login or registration
+type LoginResponseEnvelope struct {
+	Success bool          `json:"success" example:"true"`
+	Message string        `json:"message" example:"login successful"`
+	Data    LoginResponse `json:"data"`
+}
+
+// RefreshTokenResponseEnvelope wraps RefreshTokenResponse
+type RefreshTokenResponseEnvelope struct {
+	Success bool                 `json:"success" example:"true"`
+	Message string               `json:"message" example:"token refreshed successfully"`
+	Data    RefreshTokenResponse `json:"data"`
+}
+
+// UserAuthSummaryResponse wraps UserAuthSummary
+type UserAuthSummaryResponse struct {
+	Success bool             `json:"success" example:"true"`
+	Data    *UserAuthSummary `json:"data"`
+}
+
+// UserActivityResponse wraps UserActivity
+type UserActivityResponse struct {
+	Success bool          `json:"success" example:"true"`
+	Data    *UserActivity `json:"data"`
+}
+
+// AuditLogResponse is the response with a single audit log
+type AuditLogResponse struct {
+	Success bool      `json:"success" example:"true"`
+	Data    *AuditLog `json:"data"`
+}
+
+// AuditLogPagination is PaginationMeta with the names of the audit log listing
+type AuditLogPagination struct {
+	Page       int   `json:"page" example:"1"`
+	PageSize   int   `json:"page_size" example:"20"`
+	TotalItems int64 `json:"total_items" example:"42"`
+	TotalPages int   `json:"total_pages" example:"3"`
+	HasNext    bool  `json:"has_next" example:"true"`
+	HasPrev    bool  `json:"has_prev" example:"false"`
+	NextPage   *int  `json:"next_page,omitempty" example:"2"`
+	PrevPage   *int  `json:"prev_page,omitempty"`
+}
+
+// NewAuditLogPagination renames the fields of meta
+func NewAuditLogPagination(meta PaginationMeta) AuditLogPagination {
+	return AuditLogPagination{
+		Page:       meta.Page,
+		PageSize:   meta.Limit,
+		TotalItems: meta.Total,
+		TotalPages: meta.TotalPages,
+		HasNext:    meta.HasNext,
+		HasPrev:    meta.HasPrev,
+		NextPage:   meta.NextPage,
+		PrevPage:   meta.PrevPage,
+	}
+}
+
+// AuditLogListResponse is a page of audit logs
+type AuditLogListResponse struct {
+	Success    bool               `json:"success" example:"true"`
+	Data       []AuditLog         `json:"data"`
+	Pagination AuditLogPagination `json:"pagination"`
+}
+
+// MyAuditLogsResponse is the recent audit trail of the requesting user
+type MyAuditLogsResponse struct {
+	Success     bool             `json:"success" example:"true"`
+	Data        []AuditLog       `json:"data"`
+	Count       int              `json:"count" example:"2"`
+	AuthSummary *UserAuthSummary `json:"auth_summary"`
+}
+
+// AuditCleanupResponse reports how many audit logs a cleanup deleted
+type AuditCleanupResponse struct {
+	Success bool   `json:"success" example:"true"`
+	Message string `json:"message" example:"Old audit logs cleaned up successfully"`
+	Deleted int64  `json:"deleted" example:"120"`
+}
+
+// WebSocketDisconnectResponse wraps WebSocketDisconnectResult
+type WebSocketDisconnectResponse struct {
+	Success bool                      `json:"success" example:"true"`
+	Message string                    `json:"message" example:"user disconnected"`
+	Data    WebSocketDisconnectResult `json:"data"`
+}
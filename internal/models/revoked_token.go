@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RevokedToken records a refresh token invalidated by logout. Only the
+// token's SHA-256 hash is stored. Rows can be pruned once ExpiresAt has
+// passed, since the token is rejected as expired from then on.
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	UserID    uint      `gorm:"index;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// TableName specifies the table name for RevokedToken
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// LogoutRequest represents the request body for logout
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
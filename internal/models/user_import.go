@@ -0,0 +1,26 @@
+package models
+
+// UserImportColumns are the CSV columns read by POST /users/import. name,
+// email and password are required; age and role may be left out.
+var UserImportColumns = []string{"name", "email", "password", "age", "role"}
+
+// UserImportCreated is a CSV row that became a user
+type UserImportCreated struct {
+	Row int `json:"row" example:"2"` // Line of the row in the file; the header is line 1
+	ID  ID  `json:"id" example:"42"`
+}
+
+// UserImportFailure is a CSV row that was skipped
+type UserImportFailure struct {
+	Row    int               `json:"row" example:"3"`
+	Email  string            `json:"email,omitempty" example:"john@example.com"`
+	Error  string            `json:"error" example:"email already exists"`
+	Fields []ValidationError `json:"fields,omitempty"` // Set for validation errors
+}
+
+// UserImportResult reports every row of an imported file
+type UserImportResult struct {
+	Rows    int                 `json:"rows" example:"3"`
+	Created []UserImportCreated `json:"created"`
+	Failed  []UserImportFailure `json:"failed"`
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// APIUsageRepository stores per-user request counters
+type APIUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIUsageRepository creates a new API usage repository
+func NewAPIUsageRepository(db *gorm.DB) *APIUsageRepository {
+	return &APIUsageRepository{db: db}
+}
+
+// Increment adds the counters of rows to the stored buckets, creating missing ones
+func (r *APIUsageRepository) Increment(ctx context.Context, rows []models.APIUsage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "bucket_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":     gorm.Expr("api_usage.requests + excluded.requests"),
+			"errors":       gorm.Expr("api_usage.errors + excluded.errors"),
+			"rate_limited": gorm.Expr("api_usage.rate_limited + excluded.rate_limited"),
+		}),
+	}).Create(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to record API usage: %w", err)
+	}
+	return nil
+}
+
+// ListForUser returns the user's buckets starting at or after since, oldest first
+func (r *APIUsageRepository) ListForUser(ctx context.Context, userID uint, since time.Time) ([]models.APIUsage, error) {
+	var rows []models.APIUsage
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND bucket_start >= ?", userID, since).
+		Order("bucket_start ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API usage: %w", err)
+	}
+	return rows, nil
+}
+
+// PruneBefore deletes buckets that started before cutoff and returns the number of rows removed
+func (r *APIUsageRepository) PruneBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("bucket_start < ?", cutoff).Delete(&models.APIUsage{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune API usage: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"Go-Lang-project-01/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -30,15 +33,122 @@ type AuditLogFilter struct {
 	Success   *bool
 	StartDate *time.Time
 	EndDate   *time.Time
+	Sort      []AuditLogSort // Defaults to created_at descending
 	Page      int
 	PageSize  int
 }
 
+// AuditLogSort is one ORDER BY term of an audit log listing
+type AuditLogSort struct {
+	Field      string // One of AuditLogSortFields
+	Descending bool
+}
+
+// AuditLogSortFields lists the columns audit logs can be sorted by
+var AuditLogSortFields = []string{"created_at", "action", "user_id", "success"}
+
+// nullableAuditLogColumns are sorted with NULLs last regardless of direction
+var nullableAuditLogColumns = map[string]bool{"user_id": true}
+
+// ParseAuditLogSort parses comma-separated sort fields and orders, e.g.
+// sort="action,created_at" order="asc,desc". A single order applies to every
+// field; missing orders default to descending.
+func ParseAuditLogSort(sort, order string) ([]AuditLogSort, error) {
+	if sort == "" {
+		if order != "" {
+			return nil, fmt.Errorf("order requires sort")
+		}
+		return nil, nil
+	}
+
+	fields := strings.Split(sort, ",")
+	var orders []string
+	if order != "" {
+		orders = strings.Split(order, ",")
+	}
+	if len(orders) > 1 && len(orders) != len(fields) {
+		return nil, fmt.Errorf("order must have one value or one per sort field")
+	}
+
+	seen := make(map[string]bool, len(fields))
+	sorts := make([]AuditLogSort, 0, len(fields))
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if !isAuditLogSortField(field) {
+			return nil, fmt.Errorf("invalid sort field %q (allowed: %s)", field, strings.Join(AuditLogSortFields, ", "))
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field)
+		}
+		seen[field] = true
+
+		dir := "desc"
+		if len(orders) == 1 {
+			dir = orders[0]
+		} else if len(orders) > 1 {
+			dir = orders[i]
+		}
+		switch strings.ToLower(strings.TrimSpace(dir)) {
+		case "asc":
+			sorts = append(sorts, AuditLogSort{Field: field})
+		case "desc":
+			sorts = append(sorts, AuditLogSort{Field: field, Descending: true})
+		default:
+			return nil, fmt.Errorf("invalid order %q (allowed: asc, desc)", dir)
+		}
+	}
+	return sorts, nil
+}
+
+func isAuditLogSortField(field string) bool {
+	for _, f := range AuditLogSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// auditLogOrderClause builds the ORDER BY clause for the given SQL dialect.
+// Nullable columns keep NULLs last in both directions: Postgres supports
+// NULLS LAST natively, other dialects sort on "column IS NULL" first.
+// The ID is always appended so that pages are stable.
+func auditLogOrderClause(dialect string, sorts []AuditLogSort) string {
+	if len(sorts) == 0 {
+		sorts = []AuditLogSort{{Field: "created_at", Descending: true}}
+	}
+
+	terms := make([]string, 0, len(sorts)+1)
+	for _, s := range sorts {
+		dir := "ASC"
+		if s.Descending {
+			dir = "DESC"
+		}
+		switch {
+		case !nullableAuditLogColumns[s.Field]:
+			terms = append(terms, s.Field+" "+dir)
+		case dialect == "postgres":
+			terms = append(terms, s.Field+" "+dir+" NULLS LAST")
+		default:
+			terms = append(terms, s.Field+" IS NULL", s.Field+" "+dir)
+		}
+	}
+	terms = append(terms, "id DESC")
+	return strings.Join(terms, ", ")
+}
+
 // List retrieves audit logs with optional filters
 func (r *AuditLogRepository) List(filter *AuditLogFilter) ([]models.AuditLog, int64, error) {
 	var logs []models.AuditLog
 	var total int64
 
+	// Sort fields end up in raw SQL, so never trust them unchecked
+	for _, s := range filter.Sort {
+		if !isAuditLogSortField(s.Field) {
+			return nil, 0, fmt.Errorf("invalid sort field %q", s.Field)
+		}
+	}
+
 	query := r.db.Model(&models.AuditLog{})
 
 	// Apply filters
@@ -77,7 +187,8 @@ func (r *AuditLogRepository) List(filter *AuditLogFilter) ([]models.AuditLog, in
 	}
 
 	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
+	orderBy := auditLogOrderClause(r.db.Dialector.Name(), filter.Sort)
+	if err := query.Order(orderBy).Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -93,6 +204,22 @@ func (r *AuditLogRepository) GetByID(id uint) (*models.AuditLog, error) {
 	return &log, nil
 }
 
+// ListAfterID retrieves up to limit audit logs with an ID greater than afterID,
+// in ID order. It is used to walk the whole table in batches.
+func (r *AuditLogRepository) ListAfterID(afterID models.ID, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	if err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// UpdateDetails replaces the details and details schema of an audit log
+func (r *AuditLogRepository) UpdateDetails(id models.ID, details, schema string) error {
+	return r.db.Model(&models.AuditLog{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"details": details, "details_schema": schema}).Error
+}
+
 // GetRecentByUser retrieves recent audit logs for a specific user
 func (r *AuditLogRepository) GetRecentByUser(userID uint, limit int) ([]models.AuditLog, error) {
 	var logs []models.AuditLog
@@ -102,6 +229,92 @@ func (r *AuditLogRepository) GetRecentByUser(userID uint, limit int) ([]models.A
 	return logs, nil
 }
 
+// ListForTarget retrieves up to limit audit logs about a user, newest first:
+// actions on the user as a resource and the user's own auth actions. With
+// before set, only logs created before it are returned.
+func (r *AuditLogRepository) ListForTarget(userID uint, before *time.Time, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	query := r.db.Where("(resource = ? AND resource_id = ?) OR (user_id = ? AND action IN ?)",
+		models.AuditResourceUser, userID, userID, authActions)
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// authActions are the actions summarized by GetUserAuthSummary
+var authActions = []models.AuditAction{
+	models.AuditActionLogin,
+	models.AuditActionLoginFailed,
+	models.AuditActionLogout,
+	models.AuditActionRefreshToken,
+	models.AuditActionRefreshTokenReuse,
+	models.AuditActionRegister,
+}
+
+// recentIPLimit is how many distinct IPs GetUserAuthSummary returns
+const recentIPLimit = 5
+
+// GetUserAuthSummary aggregates the authentication actions of a user
+func (r *AuditLogRepository) GetUserAuthSummary(userID uint) (*models.UserAuthSummary, error) {
+	summary := &models.UserAuthSummary{
+		UserID:    userID,
+		ByAction:  make(map[models.AuditAction]int64),
+		RecentIPs: []string{},
+	}
+	userAuth := func() *gorm.DB {
+		return r.db.Model(&models.AuditLog{}).Where("user_id = ? AND action IN ?", userID, authActions)
+	}
+
+	var counts []struct {
+		Action  models.AuditAction
+		Success bool
+		Count   int64
+	}
+	if err := userAuth().
+		Select("action, success, COUNT(*) AS count").
+		Group("action, success").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range counts {
+		summary.ByAction[row.Action] += row.Count
+		switch {
+		case row.Action == models.AuditActionLogin && row.Success:
+			summary.TotalLogins += row.Count
+		case row.Action == models.AuditActionLoginFailed, row.Action == models.AuditActionLogin:
+			summary.FailedLogins += row.Count
+		}
+	}
+
+	if summary.TotalLogins > 0 {
+		var last models.AuditLog
+		if err := userAuth().
+			Where("action = ? AND success = ?", models.AuditActionLogin, true).
+			Order("created_at DESC").
+			First(&last).Error; err != nil {
+			return nil, err
+		}
+		summary.LastLoginAt = &last.CreatedAt
+	}
+
+	// MAX(id) orders IPs by their latest use without comparing timestamps
+	if err := userAuth().
+		Select("ip_address").
+		Where("ip_address <> ''").
+		Group("ip_address").
+		Order("MAX(id) DESC").
+		Limit(recentIPLimit).
+		Pluck("ip_address", &summary.RecentIPs).Error; err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
 // GetFailedLoginAttempts retrieves failed login attempts within a time window
 func (r *AuditLogRepository) GetFailedLoginAttempts(ipAddress string, since time.Time) (int64, error) {
 	var count int64
@@ -118,6 +331,46 @@ func (r *AuditLogRepository) DeleteOlderThan(date time.Time) (int64, error) {
 	return result.RowsAffected, result.Error
 }
 
+// Count returns the number of audit log rows
+func (r *AuditLogRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AuditLog{}).Count(&count).Error
+	return count, err
+}
+
+// TableSize returns the approximate on-disk size of the audit log table,
+// including its indexes, in bytes. It returns -1 when the database cannot
+// tell (e.g. SQLite builds without the dbstat table).
+func (r *AuditLogRepository) TableSize() (int64, error) {
+	var query string
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		query = "SELECT pg_total_relation_size('audit_logs')"
+	case "sqlite":
+		query = "SELECT SUM(pgsize) FROM dbstat WHERE name = 'audit_logs' OR tbl_name = 'audit_logs'"
+	default:
+		return -1, nil
+	}
+
+	var size sql.NullInt64
+	if err := r.db.Raw(query).Scan(&size).Error; err != nil || !size.Valid {
+		return -1, nil
+	}
+	return size.Int64, nil
+}
+
+// DeleteOldest deletes up to limit of the oldest audit logs created before
+// the given date and returns how many were deleted
+func (r *AuditLogRepository) DeleteOldest(limit int, before time.Time) (int64, error) {
+	oldest := r.db.Model(&models.AuditLog{}).
+		Select("id").
+		Where("created_at < ?", before).
+		Order("created_at ASC, id ASC").
+		Limit(limit)
+	result := r.db.Where("id IN (?)", oldest).Delete(&models.AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
 // GetStats retrieves audit log statistics
 func (r *AuditLogRepository) GetStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupAuditTestDB creates an in-memory SQLite database with seeded audit logs:
+//
+//	id  user_id  action        success  created_at
+//	1   2        login         true     base+0s
+//	2   NULL     login_failed  false    base+1s
+//	3   1        user_create   true     base+2s
+//	4   2        login         false    base+2s
+func setupAuditTestDB(t *testing.T) *AuditLogRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+
+	repo := NewAuditLogRepository(db)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	uid := func(id uint) *uint { return &id }
+
+	for _, log := range []*models.AuditLog{
+		{UserID: models.IDPtr(uid(2)), Action: models.AuditActionLogin, Success: true, CreatedAt: base},
+		{UserID: nil, Action: models.AuditActionLoginFailed, Success: false, CreatedAt: base.Add(time.Second)},
+		{UserID: models.IDPtr(uid(1)), Action: models.AuditActionUserCreate, Success: true, CreatedAt: base.Add(2 * time.Second)},
+		{UserID: models.IDPtr(uid(2)), Action: models.AuditActionLogin, Success: false, CreatedAt: base.Add(2 * time.Second)},
+	} {
+		success := log.Success
+		require.NoError(t, repo.Create(log))
+		// success defaults to true in the database, so false is skipped on insert
+		if !success {
+			require.NoError(t, db.Model(log).Update("success", false).Error)
+		}
+	}
+	return repo
+}
+
+func TestParseAuditLogSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		order   string
+		want    []AuditLogSort
+		wantErr bool
+	}{
+		{name: "empty uses default", want: nil},
+		{name: "single field defaults to desc", sort: "action", want: []AuditLogSort{{Field: "action", Descending: true}}},
+		{name: "single order applies to all", sort: "action,user_id", order: "asc",
+			want: []AuditLogSort{{Field: "action"}, {Field: "user_id"}}},
+		{name: "order per field", sort: "success, created_at", order: "asc,DESC",
+			want: []AuditLogSort{{Field: "success"}, {Field: "created_at", Descending: true}}},
+		{name: "unknown field", sort: "ip_address", wantErr: true},
+		{name: "injection attempt", sort: "created_at; DROP TABLE audit_logs", wantErr: true},
+		{name: "duplicate field", sort: "action,action", wantErr: true},
+		{name: "invalid order", sort: "action", order: "sideways", wantErr: true},
+		{name: "order count mismatch", sort: "action,user_id,success", order: "asc,desc", wantErr: true},
+		{name: "order without sort", order: "asc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuditLogSort(tt.sort, tt.order)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuditLogOrderClause(t *testing.T) {
+	tests := []struct {
+		name     string
+		sorts    []AuditLogSort
+		postgres string
+		sqlite   string
+	}{
+		{
+			name:     "default",
+			postgres: "created_at DESC, id DESC",
+			sqlite:   "created_at DESC, id DESC",
+		},
+		{
+			name:     "created_at",
+			sorts:    []AuditLogSort{{Field: "created_at"}},
+			postgres: "created_at ASC, id DESC",
+			sqlite:   "created_at ASC, id DESC",
+		},
+		{
+			name:     "action",
+			sorts:    []AuditLogSort{{Field: "action", Descending: true}},
+			postgres: "action DESC, id DESC",
+			sqlite:   "action DESC, id DESC",
+		},
+		{
+			name:     "success",
+			sorts:    []AuditLogSort{{Field: "success"}},
+			postgres: "success ASC, id DESC",
+			sqlite:   "success ASC, id DESC",
+		},
+		{
+			name:     "user_id ascending keeps nulls last",
+			sorts:    []AuditLogSort{{Field: "user_id"}},
+			postgres: "user_id ASC NULLS LAST, id DESC",
+			sqlite:   "user_id IS NULL, user_id ASC, id DESC",
+		},
+		{
+			name:     "user_id descending keeps nulls last",
+			sorts:    []AuditLogSort{{Field: "user_id", Descending: true}, {Field: "created_at"}},
+			postgres: "user_id DESC NULLS LAST, created_at ASC, id DESC",
+			sqlite:   "user_id IS NULL, user_id DESC, created_at ASC, id DESC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.postgres, auditLogOrderClause("postgres", tt.sorts))
+			assert.Equal(t, tt.sqlite, auditLogOrderClause("sqlite", tt.sorts))
+		})
+	}
+}
+
+func TestAuditLogRepository_ListSort(t *testing.T) {
+	repo := setupAuditTestDB(t)
+
+	tests := []struct {
+		name  string
+		sorts []AuditLogSort
+		want  []uint // expected IDs in order
+	}{
+		{name: "default is newest first with id tie-break", want: []uint{4, 3, 2, 1}},
+		{name: "created_at asc", sorts: []AuditLogSort{{Field: "created_at"}}, want: []uint{1, 2, 4, 3}},
+		{name: "action asc", sorts: []AuditLogSort{{Field: "action"}}, want: []uint{4, 1, 2, 3}},
+		{name: "action desc", sorts: []AuditLogSort{{Field: "action", Descending: true}}, want: []uint{3, 2, 4, 1}},
+		{name: "user_id asc nulls last", sorts: []AuditLogSort{{Field: "user_id"}}, want: []uint{3, 4, 1, 2}},
+		{name: "user_id desc nulls last", sorts: []AuditLogSort{{Field: "user_id", Descending: true}}, want: []uint{4, 1, 3, 2}},
+		{name: "success asc", sorts: []AuditLogSort{{Field: "success"}}, want: []uint{4, 2, 3, 1}},
+		{name: "success desc", sorts: []AuditLogSort{{Field: "success", Descending: true}}, want: []uint{3, 1, 4, 2}},
+		{name: "multiple fields", sorts: []AuditLogSort{{Field: "user_id"}, {Field: "created_at"}}, want: []uint{3, 1, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, total, err := repo.List(&AuditLogFilter{Sort: tt.sorts})
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), total)
+
+			ids := make([]uint, 0, len(logs))
+			for _, log := range logs {
+				ids = append(ids, uint(log.ID))
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestAuditLogRepository_ListRejectsUnknownSortField(t *testing.T) {
+	repo := setupAuditTestDB(t)
+
+	_, _, err := repo.List(&AuditLogFilter{Sort: []AuditLogSort{{Field: "1; DROP TABLE audit_logs"}}})
+
+	assert.Error(t, err)
+}
+
+func TestAuditLogRepository_GetUserAuthSummary(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+	repo := NewAuditLogRepository(db)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	uid := func(id uint) *uint { return &id }
+	rows := []struct {
+		userID  *uint
+		action  models.AuditAction
+		success bool
+		ip      string
+	}{
+		{uid(7), models.AuditActionRegister, true, "10.0.0.1"},
+		{uid(7), models.AuditActionLogin, true, "10.0.0.1"},
+		{uid(7), models.AuditActionLoginFailed, false, "10.0.0.2"},
+		{uid(7), models.AuditActionLoginFailed, false, "10.0.0.3"},
+		{uid(7), models.AuditActionLogin, true, "10.0.0.4"},
+		{uid(7), models.AuditActionLogin, false, "10.0.0.5"},
+		{uid(7), models.AuditActionRefreshToken, true, "10.0.0.6"},
+		{uid(7), models.AuditActionLogin, true, "10.0.0.2"},
+		{uid(7), models.AuditActionUserUpdate, true, "192.168.0.1"}, // not an auth action
+		{uid(8), models.AuditActionLogin, true, "172.16.0.1"},       // another user
+		{nil, models.AuditActionLoginFailed, false, "172.16.0.2"},   // unknown account
+	}
+	for i, row := range rows {
+		log := &models.AuditLog{UserID: models.IDPtr(row.userID), Action: row.action, Success: row.success, IPAddress: row.ip, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, repo.Create(log))
+		if !row.success {
+			require.NoError(t, db.Model(log).Update("success", false).Error)
+		}
+	}
+
+	summary, err := repo.GetUserAuthSummary(7)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(7), summary.UserID)
+	assert.Equal(t, int64(3), summary.TotalLogins)
+	assert.Equal(t, int64(3), summary.FailedLogins, "login_failed rows and unsuccessful logins")
+	assert.Equal(t, map[models.AuditAction]int64{
+		models.AuditActionRegister:     1,
+		models.AuditActionLogin:        4,
+		models.AuditActionLoginFailed:  2,
+		models.AuditActionRefreshToken: 1,
+	}, summary.ByAction)
+	require.NotNil(t, summary.LastLoginAt)
+	assert.True(t, base.Add(7*time.Minute).Equal(*summary.LastLoginAt))
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.6", "10.0.0.5", "10.0.0.4", "10.0.0.3"}, summary.RecentIPs)
+
+	t.Run("user without activity", func(t *testing.T) {
+		summary, err := repo.GetUserAuthSummary(99)
+		require.NoError(t, err)
+		assert.Zero(t, summary.TotalLogins)
+		assert.Nil(t, summary.LastLoginAt)
+		assert.Empty(t, summary.RecentIPs)
+		assert.Empty(t, summary.ByAction)
+	})
+}
+
+func TestAuditLogRepository_DeleteOldest(t *testing.T) {
+	repo := setupAuditTestDB(t)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	deleted, err := repo.DeleteOldest(1, base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.GetByID(1)
+	assert.Error(t, err, "the oldest row goes first")
+
+	// Rows created at or after the cutoff are kept regardless of the limit
+	deleted, err = repo.DeleteOldest(10, base.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	count, err := repo.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestAuditLogRepository_TableSize(t *testing.T) {
+	repo := setupAuditTestDB(t)
+
+	size, err := repo.TableSize()
+	require.NoError(t, err)
+	assert.True(t, size == -1 || size > 0, "size is either unknown or positive, got %d", size)
+}
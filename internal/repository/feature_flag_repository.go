@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"Go-Lang-project-01/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FeatureFlagRepository stores feature flags
+type FeatureFlagRepository struct {
+	db *gorm.DB
+}
+
+// NewFeatureFlagRepository creates a new feature flag repository
+func NewFeatureFlagRepository(db *gorm.DB) *FeatureFlagRepository {
+	return &FeatureFlagRepository{db: db}
+}
+
+// List returns every flag ordered by name
+func (r *FeatureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&flags).Error; err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	return flags, nil
+}
+
+// GetByName returns the named flag, or nil if it does not exist
+func (r *FeatureFlagRepository) GetByName(ctx context.Context, name string) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&flag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Not an error, just not found
+		}
+		return nil, fmt.Errorf("failed to get feature flag: %w", err)
+	}
+	return &flag, nil
+}
+
+// Create inserts a new flag
+func (r *FeatureFlagRepository) Create(ctx context.Context, flag *models.FeatureFlag) error {
+	if err := r.db.WithContext(ctx).Create(flag).Error; err != nil {
+		return fmt.Errorf("failed to create feature flag: %w", err)
+	}
+	return nil
+}
+
+// Update saves every field of an existing flag
+func (r *FeatureFlagRepository) Update(ctx context.Context, flag *models.FeatureFlag) error {
+	if err := r.db.WithContext(ctx).Save(flag).Error; err != nil {
+		return fmt.Errorf("failed to update feature flag: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the flag with the given ID
+func (r *FeatureFlagRepository) Delete(ctx context.Context, id models.ID) error {
+	if err := r.db.WithContext(ctx).Delete(&models.FeatureFlag{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	return nil
+}
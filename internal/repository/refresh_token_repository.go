@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository stores the issued refresh tokens used for rotation
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create records an issued refresh token
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to record refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByJTI returns the token with the given ID, or nil if it was never
+// recorded. It reads the primary so a rotation is seen immediately.
+func (r *RefreshTokenRepository) GetByJTI(ctx context.Context, jti string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := database.Primary(r.db.WithContext(ctx)).Where("jti = ?", jti).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Not an error, just not found
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// Rotate marks the token jti as rotated at now and records next, its
+// successor. It returns false without recording next when the token was
+// already rotated or revoked, which includes losing a race with a concurrent
+// rotation of the same token.
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, jti string, next *models.RefreshToken, now time.Time) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("jti = ? AND rotated_at IS NULL AND revoked_at IS NULL", jti).
+			Update("rotated_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		rotated = true
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return rotated, nil
+}
+
+// RevokeFamily revokes every unrevoked token of the family and returns how many were revoked
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke refresh token family: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// PruneExpired deletes tokens that expired before now and returns the number
+// of rows removed
+func (r *RefreshTokenRepository) PruneExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune refresh tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
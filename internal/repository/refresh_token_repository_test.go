@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupRefreshTokenRepo(t *testing.T) *RefreshTokenRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RefreshToken{}))
+	return NewRefreshTokenRepository(db)
+}
+
+func TestRefreshTokenRepository_RotateOnce(t *testing.T) {
+	repo := setupRefreshTokenRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	require.NoError(t, repo.Create(ctx, &models.RefreshToken{JTI: "first", FamilyID: "first", UserID: 1, ExpiresAt: expires}))
+
+	rotated, err := repo.Rotate(ctx, "first", &models.RefreshToken{JTI: "second", FamilyID: "first", UserID: 1, ExpiresAt: expires}, now)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	first, err := repo.GetByJTI(ctx, "first")
+	require.NoError(t, err)
+	require.NotNil(t, first.RotatedAt)
+
+	// A rotated token cannot be rotated again, and its would-be successor is not recorded
+	rotated, err = repo.Rotate(ctx, "first", &models.RefreshToken{JTI: "third", FamilyID: "first", UserID: 1, ExpiresAt: expires}, now)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	third, err := repo.GetByJTI(ctx, "third")
+	require.NoError(t, err)
+	assert.Nil(t, third)
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	repo := setupRefreshTokenRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	require.NoError(t, repo.Create(ctx, &models.RefreshToken{JTI: "a1", FamilyID: "a1", UserID: 1, ExpiresAt: expires}))
+	require.NoError(t, repo.Create(ctx, &models.RefreshToken{JTI: "a2", FamilyID: "a1", UserID: 1, ExpiresAt: expires}))
+	require.NoError(t, repo.Create(ctx, &models.RefreshToken{JTI: "b1", FamilyID: "b1", UserID: 1, ExpiresAt: expires}))
+
+	revoked, err := repo.RevokeFamily(ctx, "a1", now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	rotated, err := repo.Rotate(ctx, "a2", &models.RefreshToken{JTI: "a3", FamilyID: "a1", UserID: 1, ExpiresAt: expires}, now)
+	require.NoError(t, err)
+	assert.False(t, rotated, "revoked tokens cannot be rotated")
+
+	other, err := repo.GetByJTI(ctx, "b1")
+	require.NoError(t, err)
+	assert.Nil(t, other.RevokedAt, "other families are untouched")
+}
+
+func TestRefreshTokenRepository_PruneExpired(t *testing.T) {
+	repo := setupRefreshTokenRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.Create(ctx, &models.RefreshToken{JTI: "expired", FamilyID: "expired", UserID: 1, ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, repo.Create(ctx, &models.RefreshToken{JTI: "live", FamilyID: "live", UserID: 1, ExpiresAt: now.Add(time.Hour)}))
+
+	pruned, err := repo.PruneExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+
+	live, err := repo.GetByJTI(ctx, "live")
+	require.NoError(t, err)
+	assert.NotNil(t, live)
+}
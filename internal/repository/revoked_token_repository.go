@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenRepository stores refresh tokens revoked by logout
+type RevokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository creates a new revoked token repository
+func NewRevokedTokenRepository(db *gorm.DB) *RevokedTokenRepository {
+	return &RevokedTokenRepository{db: db}
+}
+
+// hashToken returns the hex SHA-256 of token, so raw tokens are never stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Revoke records token as revoked until expiresAt. Revoking a token twice is a no-op.
+func (r *RevokedTokenRepository) Revoke(ctx context.Context, token string, userID uint, expiresAt time.Time) error {
+	revoked := &models.RevokedToken{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(revoked).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether token was revoked. It reads the primary so a
+// logout takes effect immediately despite replica lag.
+func (r *RevokedTokenRepository) IsRevoked(ctx context.Context, token string) (bool, error) {
+	var count int64
+	err := database.Primary(r.db.WithContext(ctx)).
+		Model(&models.RevokedToken{}).
+		Where("token_hash = ?", hashToken(token)).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return count > 0, nil
+}
+
+// PruneExpired deletes revocations of tokens that expired before now and
+// returns the number of rows removed
+func (r *RevokedTokenRepository) PruneExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune revoked tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
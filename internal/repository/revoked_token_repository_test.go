@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupRevokedTokenRepo(t *testing.T) (*RevokedTokenRepository, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RevokedToken{}))
+	return NewRevokedTokenRepository(db), db
+}
+
+func TestRevokedTokenRepository_RevokeIsIdempotent(t *testing.T) {
+	repo, db := setupRevokedTokenRepo(t)
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	revoked, err := repo.IsRevoked(ctx, "refresh-token")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, repo.Revoke(ctx, "refresh-token", 1, expires))
+	require.NoError(t, repo.Revoke(ctx, "refresh-token", 1, expires), "revoking twice must not fail")
+
+	revoked, err = repo.IsRevoked(ctx, "refresh-token")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = repo.IsRevoked(ctx, "other-token")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	var rows []models.RevokedToken
+	require.NoError(t, db.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	assert.NotContains(t, rows[0].TokenHash, "refresh-token", "raw tokens are never stored")
+}
+
+func TestRevokedTokenRepository_PruneExpired(t *testing.T) {
+	repo, _ := setupRevokedTokenRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.Revoke(ctx, "expired", 1, now.Add(-time.Minute)))
+	require.NoError(t, repo.Revoke(ctx, "live", 1, now.Add(time.Hour)))
+
+	pruned, err := repo.PruneExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+
+	revoked, err := repo.IsRevoked(ctx, "live")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
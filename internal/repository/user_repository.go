@@ -4,17 +4,70 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/database"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrDuplicateEmail is returned when a user is created or updated with an
+// email another user already has, including soft-deleted users
+var ErrDuplicateEmail = errors.New("email already exists")
+
+// ErrDeletedUserEmail is the ErrDuplicateEmail of an email only a
+// soft-deleted user has. The deleted user must be restored or purged first.
+var ErrDeletedUserEmail = fmt.Errorf("%w: it belongs to a deleted user, restore or purge that user first", ErrDuplicateEmail)
+
+// ErrDeletedUserNotFound is returned by Restore and Purge when no
+// soft-deleted user has the ID
+var ErrDeletedUserNotFound = errors.New("deleted user not found")
+
+// ErrInvalidSort is returned by GetAllPaginated for a sort column or order
+// outside the allow-list
+var ErrInvalidSort = errors.New("invalid sort")
+
+// userSortColumns maps the sort values accepted by GetAllPaginated to their
+// columns. Sorting ends up in raw SQL, so nothing else may reach ORDER BY.
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"age":        "age",
+	"created_at": "created_at",
+}
+
+// userOrder returns the ORDER BY of a pagination query, created_at desc by
+// default, or ErrInvalidSort for a value outside the allow-list
+func userOrder(query models.PaginationQuery) (clause.OrderByColumn, error) {
+	column := "created_at"
+	if query.Sort != "" {
+		var ok bool
+		if column, ok = userSortColumns[query.Sort]; !ok {
+			return clause.OrderByColumn{}, fmt.Errorf("%w: unknown column %q", ErrInvalidSort, query.Sort)
+		}
+	}
+	desc := true
+	switch strings.ToLower(query.Order) {
+	case "", "desc":
+	case "asc":
+		desc = false
+	default:
+		return clause.OrderByColumn{}, fmt.Errorf("%w: order must be asc or desc, got %q", ErrInvalidSort, query.Order)
+	}
+	return clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc}, nil
+}
+
 // UserRepository handles data persistence with GORM
 type UserRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	onChange func(ids ...uint) // Set by OnChange
 }
 
 // NewUserRepository creates a new GORM user repository
@@ -24,30 +77,126 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
+// OnChange registers fn to be called after every successful write with the
+// IDs of the users it changed, e.g. to invalidate a cache of users. No IDs
+// means any user may have changed. Call it before the repository is used.
+func (r *UserRepository) OnChange(fn func(ids ...uint)) {
+	r.onChange = fn
+}
+
+// changed reports a successful write to the OnChange function
+func (r *UserRepository) changed(ids ...uint) {
+	if r.onChange != nil {
+		r.onChange(ids...)
+	}
+}
+
+// conn binds the query to ctx. Reads go to a replica when configured,
+// unless ctx was marked with database.WithPrimary.
+func (r *UserRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(r.db, ctx)
+}
+
+// translateWriteError turns a unique violation of the email index into
+// ErrDuplicateEmail. The dialector maps its driver's error shape (SQLite
+// extended code 2067, PostgreSQL SQLSTATE 23505) to gorm.ErrDuplicatedKey.
+func (r *UserRepository) translateWriteError(err error) error {
+	translator, ok := r.db.Dialector.(gorm.ErrorTranslator)
+	if !ok {
+		return err
+	}
+	if errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) && strings.Contains(err.Error(), "email") {
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+// emailWriteError translates err like translateWriteError, and reports
+// ErrDeletedUserEmail when the taken email belongs to a soft-deleted user
+func (r *UserRepository) emailWriteError(ctx context.Context, email string, err error) error {
+	err = r.translateWriteError(err)
+	if !errors.Is(err, ErrDuplicateEmail) {
+		return err
+	}
+	var deleted int64
+	if lookupErr := database.Primary(r.conn(ctx)).Unscoped().Model(&models.User{}).
+		Where("LOWER(email) = LOWER(?) AND deleted_at IS NOT NULL", models.NormalizeEmail(email)).
+		Count(&deleted).Error; lookupErr == nil && deleted > 0 {
+		return ErrDeletedUserEmail
+	}
+	return err
+}
+
 // GetAll returns all users (with goroutine support via context)
 func (r *UserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
 	var users []*models.User
 
 	// Using context for cancellation support
-	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+	if err := r.conn(ctx).Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
 	return users, nil
 }
 
+// userSearchCondition matches a search term anywhere in the name or email
+const userSearchCondition = "(LOWER(name) LIKE ? OR LOWER(email) LIKE ?)"
+
+// applySearch filters db by a search term. A positive integer also matches
+// the user ID. A term with an @ is looked up as an exact email first, which
+// uses the unique index; only when that finds nothing does the LIKE over
+// name and email run.
+func applySearch(db *gorm.DB, search string) (*gorm.DB, error) {
+	pattern := "%" + strings.ToLower(search) + "%"
+
+	if strings.Contains(search, "@") {
+		var exact int64
+		email := models.NormalizeEmail(search)
+		if err := db.Session(&gorm.Session{}).Where("email = ?", email).Count(&exact).Error; err != nil {
+			return nil, fmt.Errorf("failed to look up email: %w", err)
+		}
+		if exact > 0 {
+			return db.Where("email = ?", email), nil
+		}
+		return db.Where(userSearchCondition, pattern, pattern), nil
+	}
+
+	if id, err := strconv.ParseUint(search, 10, 64); err == nil && id > 0 {
+		return db.Where("(id = ? OR "+userSearchCondition+")", id, pattern, pattern), nil
+	}
+
+	return db.Where(userSearchCondition, pattern, pattern), nil
+}
+
 // GetAllPaginated returns paginated users with search and filtering
 func (r *UserRepository) GetAllPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, int64, error) {
 	var users []*models.User
 	var total int64
 
+	// Validate sorting before touching the database
+	order, err := userOrder(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Base query
-	db := r.db.WithContext(ctx).Model(&models.User{})
+	db := r.conn(ctx).Model(&models.User{})
+
+	// Apply status filter
+	if query.Active != nil {
+		db = db.Where("is_active = ?", *query.Active)
+	}
+
+	// Apply role filter
+	if query.Role != "" {
+		db = db.Where("role = ?", query.Role)
+	}
 
-	// Apply search filter
-	if query.Search != "" {
-		searchPattern := "%" + strings.ToLower(query.Search) + "%"
-		db = db.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", searchPattern, searchPattern)
+	// Apply search filter last, so an exact email lookup honors the filters above
+	if search := strings.TrimSpace(query.Search); search != "" {
+		if db, err = applySearch(db, search); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	// Count total records
@@ -56,15 +205,7 @@ func (r *UserRepository) GetAllPaginated(ctx context.Context, query models.Pagin
 	}
 
 	// Apply sorting
-	sortField := "created_at"
-	sortOrder := "desc"
-	if query.Sort != "" {
-		sortField = query.Sort
-	}
-	if query.Order != "" {
-		sortOrder = query.Order
-	}
-	db = db.Order(fmt.Sprintf("%s %s", sortField, sortOrder))
+	db = db.Order(order)
 
 	// Apply pagination
 	offset := (query.Page - 1) * query.Limit
@@ -79,7 +220,7 @@ func (r *UserRepository) GetAllPaginated(ctx context.Context, query models.Pagin
 func (r *UserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
 
-	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+	if err := r.conn(ctx).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("user not found")
 		}
@@ -89,11 +230,12 @@ func (r *UserRepository) GetByID(ctx context.Context, id uint) (*models.User, er
 	return &user, nil
 }
 
-// GetByEmail returns a user by email
+// GetByEmail returns a user by email, ignoring case
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	// Emails are stored lowercased; LOWER also finds rows written before that
+	if err := r.conn(ctx).Where("LOWER(email) = LOWER(?)", models.NormalizeEmail(email)).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil // Not an error, just not found
 		}
@@ -105,46 +247,357 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 // Create creates a new user
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+	if err := r.conn(ctx).Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", r.emailWriteError(ctx, user.Email, err))
 	}
+	r.changed(uint(user.ID))
 	return nil
 }
 
 // Update updates an existing user
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
-	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+	if err := r.conn(ctx).Save(user).Error; err != nil {
+		return fmt.Errorf("failed to update user: %w", r.emailWriteError(ctx, user.Email, err))
 	}
+	r.changed(uint(user.ID))
 	return nil
 }
 
 // Delete soft deletes a user
 func (r *UserRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&models.User{}, id).Error; err != nil {
+	if err := r.conn(ctx).Delete(&models.User{}, id).Error; err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	r.changed(id)
+	return nil
+}
+
+// ListDeleted returns a page (from 1) of soft-deleted users, most recently
+// deleted first, and the number of soft-deleted users
+func (r *UserRepository) ListDeleted(ctx context.Context, page, limit int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+
+	db := r.conn(ctx).Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL")
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted users: %w", err)
+	}
+	if err := db.Order("deleted_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get deleted users: %w", err)
+	}
+	return users, total, nil
+}
+
+// Restore undeletes a soft-deleted user. Tokens issued before restoredAt
+// stay rejected, so the user has to log in again.
+func (r *UserRepository) Restore(ctx context.Context, id uint, restoredAt time.Time) error {
+	result := r.conn(ctx).Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "tokens_revoked_at": restoredAt})
+	if result.Error != nil {
+		return fmt.Errorf("failed to restore user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeletedUserNotFound
+	}
+	r.changed(id)
+	return nil
+}
+
+// Purge permanently deletes a soft-deleted user with its refresh tokens and
+// usage counters, in a single transaction. Audit logs are kept.
+func (r *UserRepository) Purge(ctx context.Context, id uint) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&models.User{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to purge user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrDeletedUserNotFound
+		}
+		// SQLite may reuse the ID, so nothing may be left for a new user to inherit
+		if err := tx.Where("user_id = ?", id).Delete(&models.RefreshToken{}).Error; err != nil {
+			return fmt.Errorf("failed to purge refresh tokens: %w", err)
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.APIUsage{}).Error; err != nil {
+			return fmt.Errorf("failed to purge usage: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.changed(id)
+	return nil
+}
+
+// GetByIDs returns the users with the given IDs; missing IDs are skipped
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []uint) ([]*models.User, error) {
+	var users []*models.User
+	if err := r.conn(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return users, nil
+}
+
+// DeleteByIDs soft deletes the users with the given IDs in a single
+// transaction and returns the IDs of the users that existed
+func (r *UserRepository) DeleteByIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	var deleted []uint
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id IN ?", ids).Pluck("id", &deleted).Error; err != nil {
+			return fmt.Errorf("failed to get users: %w", err)
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		if err := tx.Where("id IN ?", deleted).Delete(&models.User{}).Error; err != nil {
+			return fmt.Errorf("failed to delete users: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(deleted) > 0 {
+		r.changed(deleted...)
+	}
+	return deleted, nil
+}
+
+// UpdateRoles sets the role of every user in roles in a single transaction
+// and returns the IDs of the users that existed
+func (r *UserRepository) UpdateRoles(ctx context.Context, roles map[uint]models.Role) ([]uint, error) {
+	ids := make([]uint, 0, len(roles))
+	for id, role := range roles {
+		if !role.IsValid() {
+			return nil, fmt.Errorf("%w: %q", models.ErrInvalidRole, role)
+		}
+		ids = append(ids, id)
+	}
+
+	var updated []uint
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id IN ?", ids).Pluck("id", &updated).Error; err != nil {
+			return fmt.Errorf("failed to get users: %w", err)
+		}
+		byRole := make(map[models.Role][]uint)
+		for _, id := range updated {
+			byRole[roles[id]] = append(byRole[roles[id]], id)
+		}
+		// Roles are checked above, so the hooks that reject junk roles are skipped
+		now := time.Now()
+		for role, group := range byRole {
+			if err := tx.Model(&models.User{}).Where("id IN ?", group).
+				UpdateColumns(map[string]interface{}{"role": role, "updated_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to update user roles: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(updated) > 0 {
+		r.changed(updated...)
+	}
+	return updated, nil
+}
+
+// RevokeAccess deactivates a user, invalidates previously issued tokens and
+// replaces the password hash, all in a single transaction
+func (r *UserRepository) RevokeAccess(ctx context.Context, id uint, passwordHash string, revokedAt time.Time) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"is_active":         false,
+			"tokens_revoked_at": revokedAt,
+			"password":          passwordHash,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to revoke user access: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.changed(id)
 	return nil
 }
 
+// ChangePassword replaces the password hash, clears a pending admin reset and
+// revokes every outstanding refresh token of the user, all in a single
+// transaction. It returns the number of refresh tokens revoked.
+func (r *UserRepository) ChangePassword(ctx context.Context, id uint, passwordHash string, revokedAt time.Time) (int64, error) {
+	var revoked int64
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"password":             passwordHash,
+			"must_change_password": false,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to change password: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+		result = tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", revokedAt)
+		if result.Error != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", result.Error)
+		}
+		revoked = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.changed(id)
+	return revoked, nil
+}
+
 // BatchCreate creates multiple users in a transaction (Goroutine example)
 func (r *UserRepository) BatchCreate(ctx context.Context, users []*models.User) error {
 	// Using transaction for batch insert
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.CreateInBatches(users, 100).Error; err != nil {
-			return fmt.Errorf("failed to batch create users: %w", err)
+			return fmt.Errorf("failed to batch create users: %w", r.translateWriteError(err))
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = uint(user.ID)
+	}
+	if len(ids) > 0 {
+		r.changed(ids...)
+	}
+	return nil
+}
+
+// ExistingEmails returns which of emails belong to a user, including
+// soft-deleted users, since the unique index covers them too
+func (r *UserRepository) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	var existing []string
+	normalized := make([]string, len(emails))
+	for i, email := range emails {
+		normalized[i] = models.NormalizeEmail(email)
+	}
+	if err := r.conn(ctx).Unscoped().Model(&models.User{}).Where("LOWER(email) IN ?", normalized).Pluck("email", &existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up emails: %w", err)
+	}
+	return existing, nil
 }
 
 // GetActiveUsers returns only active users
 func (r *UserRepository) GetActiveUsers(ctx context.Context) ([]*models.User, error) {
 	var users []*models.User
 
-	if err := r.db.WithContext(ctx).Where("is_active = ?", true).Find(&users).Error; err != nil {
+	if err := r.conn(ctx).Where("is_active = ?", true).Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active users: %w", err)
 	}
 
 	return users, nil
 }
+
+// Count returns the number of users
+func (r *UserRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&models.User{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// CountActive returns the number of active users
+func (r *UserRepository) CountActive(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&models.User{}).Where("is_active = ?", true).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count active users: %w", err)
+	}
+	return count, nil
+}
+
+// CountByRole returns the number of users per role. Roles without users are
+// missing from the result.
+func (r *UserRepository) CountByRole(ctx context.Context) (map[models.Role]int64, error) {
+	var rows []struct {
+		Role  models.Role
+		Count int64
+	}
+	if err := r.conn(ctx).Model(&models.User{}).
+		Select("role, COUNT(*) AS count").
+		Group("role").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+
+	counts := make(map[models.Role]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Role] = row.Count
+	}
+	return counts, nil
+}
+
+// CountCreatedSince returns the number of users created at or after since
+func (r *UserRepository) CountCreatedSince(ctx context.Context, since time.Time) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&models.User{}).Where("created_at >= ?", since).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count new users: %w", err)
+	}
+	return count, nil
+}
+
+// NormalizeRoles resets every user whose role is not a valid models.Role to
+// models.RoleUser and returns how many rows changed. Unknown roles never
+// granted any privileges, so this does not change anyone's access.
+func (r *UserRepository) NormalizeRoles(ctx context.Context) (int64, error) {
+	result := database.Primary(r.conn(ctx)).Unscoped().Model(&models.User{}).
+		Where("role IS NULL OR role NOT IN ?", models.Roles).
+		UpdateColumn("role", models.RoleUser)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to normalize user roles: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		r.changed()
+	}
+	return result.RowsAffected, nil
+}
+
+// NormalizeEmails lowercases the stored emails that are not yet lowercase
+// and returns how many rows changed. A row whose lowercased email another
+// user already has is left unchanged and its email is returned in
+// collisions, for an admin to resolve.
+func (r *UserRepository) NormalizeEmails(ctx context.Context) (normalized int64, collisions []string, err error) {
+	db := database.Primary(r.conn(ctx)).Unscoped().Session(&gorm.Session{})
+
+	var users []models.User
+	if err := db.Select("id", "email").Where("email <> LOWER(email)").Order("id").Find(&users).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to find emails to normalize: %w", err)
+	}
+
+	for _, user := range users {
+		email := models.NormalizeEmail(user.Email)
+		var taken int64
+		if err := db.Model(&models.User{}).Where("LOWER(email) = ? AND id <> ?", email, user.ID).Count(&taken).Error; err != nil {
+			return normalized, collisions, fmt.Errorf("failed to check email %q: %w", user.Email, err)
+		}
+		if taken > 0 {
+			collisions = append(collisions, user.Email)
+			continue
+		}
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("email", email).Error; err != nil {
+			return normalized, collisions, fmt.Errorf("failed to normalize email %q: %w", user.Email, r.translateWriteError(err))
+		}
+		normalized++
+		r.changed(uint(user.ID))
+	}
+	return normalized, collisions, nil
+}
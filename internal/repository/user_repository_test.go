@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"Go-Lang-project-01/internal/models"
 
@@ -45,6 +48,7 @@ func TestUserRepository_Create(t *testing.T) {
 		user    *models.User
 		wantErr bool
 		errMsg  string
+		errIs   error
 	}{
 		{
 			name: "successful_creation",
@@ -68,6 +72,7 @@ func TestUserRepository_Create(t *testing.T) {
 			},
 			wantErr: true,
 			errMsg:  "failed to create user",
+			errIs:   ErrDuplicateEmail,
 		},
 	}
 
@@ -94,6 +99,9 @@ func TestUserRepository_Create(t *testing.T) {
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.errMsg)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.NotZero(t, tt.user.ID)
@@ -125,7 +133,7 @@ func TestUserRepository_GetByID(t *testing.T) {
 	}{
 		{
 			name:    "existing_user",
-			id:      testUser.ID,
+			id:      uint(testUser.ID),
 			want:    testUser,
 			wantErr: false,
 		},
@@ -257,7 +265,11 @@ func TestUserRepository_GetAllPaginated(t *testing.T) {
 		}
 		_ = seedTestUser(t, db, user)
 	}
+	// The column default turns false into true on insert
+	require.NoError(t, db.Model(&models.User{}).Where("age % 2 = 1").Update("is_active", false).Error)
+	require.NoError(t, db.Model(&models.User{}).Where("age > ?", 32).Update("role", models.RoleAdmin).Error)
 
+	active, inactive := true, false
 	tests := []struct {
 		name      string
 		query     models.PaginationQuery
@@ -300,6 +312,60 @@ func TestUserRepository_GetAllPaginated(t *testing.T) {
 			wantTotal: 1,
 			wantErr:   false,
 		},
+		{
+			name: "active_only",
+			query: models.PaginationQuery{
+				Page:   1,
+				Limit:  10,
+				Active: &active,
+			},
+			wantCount: 7,
+			wantTotal: 7,
+		},
+		{
+			name: "inactive_only",
+			query: models.PaginationQuery{
+				Page:   1,
+				Limit:  10,
+				Active: &inactive,
+			},
+			wantCount: 8,
+			wantTotal: 8,
+		},
+		{
+			// "User 1" and "User 10"-"User 15"; 1, 11, 13 and 15 are inactive
+			name: "inactive_with_search_second_page",
+			query: models.PaginationQuery{
+				Page:   2,
+				Limit:  3,
+				Search: "user 1",
+				Active: &inactive,
+			},
+			wantCount: 1,
+			wantTotal: 4,
+		},
+		{
+			// Users 13-15 are admins; 14 is the only active one
+			name: "admin_active",
+			query: models.PaginationQuery{
+				Page:   1,
+				Limit:  10,
+				Role:   models.RoleAdmin,
+				Active: &active,
+			},
+			wantCount: 1,
+			wantTotal: 1,
+		},
+		{
+			name: "role_without_match",
+			query: models.PaginationQuery{
+				Page:  1,
+				Limit: 10,
+				Role:  models.RoleSuperAdmin,
+			},
+			wantCount: 0,
+			wantTotal: 0,
+		},
 		{
 			name: "sort_by_age_desc",
 			query: models.PaginationQuery{
@@ -329,6 +395,87 @@ func TestUserRepository_GetAllPaginated(t *testing.T) {
 	}
 }
 
+func TestUserRepository_GetAllPaginatedRejectsHostileSort(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, &models.User{Name: "Kept", Email: "kept@example.com", Password: "password", Age: 30}))
+
+	tests := []struct {
+		name  string
+		sort  string
+		order string
+	}{
+		{"statement in sort", "name; DROP TABLE users", ""},
+		{"subquery in sort", "(SELECT password FROM users LIMIT 1)", ""},
+		{"comment in sort", "name --", ""},
+		{"unknown column", "password", ""},
+		{"statement in order", "name", "asc; DROP TABLE users"},
+		{"expression in order", "name", "desc, (SELECT 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.GetAllPaginated(ctx, models.PaginationQuery{Page: 1, Limit: 10, Sort: tt.sort, Order: tt.order})
+			assert.ErrorIs(t, err, ErrInvalidSort)
+			assert.Nil(t, users)
+			assert.Zero(t, total)
+		})
+	}
+
+	assert.True(t, db.Migrator().HasTable(&models.User{}), "the users table survives")
+	users, total, err := repo.GetAllPaginated(ctx, models.PaginationQuery{Page: 1, Limit: 10, Sort: "name", Order: "ASC"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Kept", users[0].Name)
+}
+
+func TestUserRepository_GetAllPaginatedSearchShortcuts(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	bob := seedTestUser(t, db, &models.User{Name: "Bob", Email: "bob@x.com", Password: "password", Age: 30})
+	jimbob := seedTestUser(t, db, &models.User{Name: "Jim Bob", Email: "jimbob@x.com", Password: "password", Age: 31})
+	agent := seedTestUser(t, db, &models.User{Name: "Agent " + strconv.FormatUint(uint64(bob.ID), 10), Email: "agent@y.com", Password: "password", Age: 32})
+
+	emails := func(users []*models.User) []string {
+		var out []string
+		for _, u := range users {
+			out = append(out, u.Email)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		search string
+		want   []string
+	}{
+		{"numeric matches the id and names", strconv.FormatUint(uint64(bob.ID), 10), []string{bob.Email, agent.Email}},
+		{"numeric without name match", strconv.FormatUint(uint64(jimbob.ID), 10), []string{jimbob.Email}},
+		{"exact email skips substring matches", "bob@x.com", []string{bob.Email}},
+		{"exact email with surrounding spaces", "  jimbob@x.com ", []string{jimbob.Email}},
+		{"partial email falls back to LIKE", "bob@x", []string{bob.Email, jimbob.Email}},
+		{"exact email ignores case", "BOB@X.COM", []string{bob.Email}},
+		{"unknown email", "nobody@x.com", nil},
+		{"zero is not an id", "0", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.GetAllPaginated(ctx, models.PaginationQuery{Page: 1, Limit: 10, Sort: "id", Order: "asc", Search: tt.search})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, emails(users))
+			assert.Equal(t, int64(len(tt.want)), total)
+		})
+	}
+
+	inactive := false
+	users, total, err := repo.GetAllPaginated(ctx, models.PaginationQuery{Page: 1, Limit: 10, Search: "bob@x.com", Active: &inactive})
+	require.NoError(t, err)
+	assert.Empty(t, users, "the exact email lookup honors the other filters")
+	assert.Zero(t, total)
+}
+
 func TestUserRepository_Update(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
@@ -353,13 +500,122 @@ func TestUserRepository_Update(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Verify update
-	updated, err := repo.GetByID(ctx, testUser.ID)
+	updated, err := repo.GetByID(ctx, uint(testUser.ID))
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Name", updated.Name)
 	assert.Equal(t, 30, updated.Age)
 	assert.False(t, updated.IsActive)
 }
 
+func TestUserRepository_UpdateDuplicateEmail(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+
+	seedTestUser(t, db, &models.User{Name: "Taken", Email: "taken@example.com", Password: "password", Age: 25})
+	user := seedTestUser(t, db, &models.User{Name: "Other", Email: "other@example.com", Password: "password", Age: 25})
+
+	user.Email = "taken@example.com"
+	err := repo.Update(context.Background(), user)
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+}
+
+func TestUserRepository_RejectsInvalidRole(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	err := repo.Create(ctx, &models.User{Name: "Bad Role", Email: "badrole@example.com", Age: 25, Role: "root"})
+	assert.ErrorIs(t, err, models.ErrInvalidRole)
+
+	// An empty role on create falls back to the default
+	user := &models.User{Name: "No Role", Email: "norole@example.com", Age: 25}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.Equal(t, models.RoleUser, user.Role)
+
+	user.Role = "Admin"
+	assert.ErrorIs(t, repo.Update(ctx, user), models.ErrInvalidRole)
+}
+
+func TestUserRepository_NormalizeRoles(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	admin := seedTestUser(t, db, &models.User{Name: "Admin", Email: "admin@example.com", Age: 30, Role: models.RoleAdmin})
+	junk := seedTestUser(t, db, &models.User{Name: "Junk", Email: "junk@example.com", Age: 30})
+	// Bypass the model hook to simulate data written before validation existed
+	require.NoError(t, db.Model(junk).UpdateColumn("role", "ADMIN ").Error)
+
+	normalized, err := repo.NormalizeRoles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), normalized)
+
+	got, err := repo.GetByID(ctx, uint(junk.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, got.Role)
+
+	got, err = repo.GetByID(ctx, uint(admin.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, got.Role)
+}
+
+func TestUserRepository_EmailsIgnoreCase(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{Name: "John", Email: " John@Example.com", Password: "password", Age: 30}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.Equal(t, "john@example.com", user.Email, "emails are stored lowercased")
+
+	got, err := repo.GetByEmail(ctx, "JOHN@example.COM")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, user.ID, got.ID)
+
+	err = repo.Create(ctx, &models.User{Name: "Johnny", Email: "john@EXAMPLE.com", Password: "password", Age: 30})
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+
+	existing, err := repo.ExistingEmails(ctx, []string{"JOHN@example.com", "other@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"john@example.com"}, existing)
+}
+
+func TestUserRepository_NormalizeEmails(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// Bypass the model hook to simulate rows written before normalization
+	seed := func(email string) *models.User {
+		user := seedTestUser(t, db, &models.User{Name: "User", Email: strings.ToLower("seed-" + email), Password: "password", Age: 30})
+		require.NoError(t, db.Model(user).UpdateColumn("email", email).Error)
+		return user
+	}
+	mixed := seed("Mixed@Example.com")
+	seed("taken@example.com")
+	clash := seed("TAKEN@example.com")
+	deleted := seed("Deleted@Example.com")
+	require.NoError(t, db.Delete(deleted).Error)
+
+	normalized, collisions, err := repo.NormalizeEmails(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), normalized, "the mixed case and the soft-deleted user")
+	assert.Equal(t, []string{"TAKEN@example.com"}, collisions)
+
+	got, err := repo.GetByID(ctx, uint(mixed.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "mixed@example.com", got.Email)
+	got, err = repo.GetByID(ctx, uint(clash.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "TAKEN@example.com", got.Email, "collisions are left unchanged")
+
+	normalized, collisions, err = repo.NormalizeEmails(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, normalized)
+	assert.Equal(t, []string{"TAKEN@example.com"}, collisions)
+}
+
 func TestUserRepository_Delete(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
@@ -375,15 +631,84 @@ func TestUserRepository_Delete(t *testing.T) {
 	})
 
 	// Delete user
-	err := repo.Delete(ctx, testUser.ID)
+	err := repo.Delete(ctx, uint(testUser.ID))
 	assert.NoError(t, err)
 
 	// Verify deletion (soft delete)
-	_, err = repo.GetByID(ctx, testUser.ID)
+	_, err = repo.GetByID(ctx, uint(testUser.ID))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "user not found")
 }
 
+func TestUserRepository_DeletedUsers(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.RefreshToken{}, &models.APIUsage{}))
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	kept := seedTestUser(t, db, &models.User{Name: "Kept", Email: "kept@example.com", Password: "password", Age: 25})
+	first := seedTestUser(t, db, &models.User{Name: "First", Email: "first@example.com", Password: "password", Age: 25})
+	second := seedTestUser(t, db, &models.User{Name: "Second", Email: "second@example.com", Password: "password", Age: 25})
+	require.NoError(t, repo.Delete(ctx, uint(first.ID)))
+	time.Sleep(time.Millisecond) // Distinct deleted_at
+	require.NoError(t, repo.Delete(ctx, uint(second.ID)))
+
+	t.Run("list", func(t *testing.T) {
+		users, total, err := repo.ListDeleted(ctx, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.Len(t, users, 1)
+		assert.Equal(t, second.ID, users[0].ID, "most recently deleted first")
+		assert.True(t, users[0].DeletedAt.Valid)
+
+		users, _, err = repo.ListDeleted(ctx, 2, 1)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, first.ID, users[0].ID)
+	})
+
+	t.Run("email of a deleted user", func(t *testing.T) {
+		err := repo.Create(ctx, &models.User{Name: "New", Email: "First@example.com", Password: "password", Age: 25})
+		assert.ErrorIs(t, err, ErrDeletedUserEmail)
+		assert.ErrorIs(t, err, ErrDuplicateEmail)
+
+		kept.Email = "second@example.com"
+		assert.ErrorIs(t, repo.Update(ctx, kept), ErrDeletedUserEmail)
+
+		err = repo.Create(ctx, &models.User{Name: "New", Email: "kept@example.com", Password: "password", Age: 25})
+		assert.ErrorIs(t, err, ErrDuplicateEmail)
+		assert.NotErrorIs(t, err, ErrDeletedUserEmail, "taken by a live user")
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		restoredAt := time.Now()
+		require.NoError(t, repo.Restore(ctx, uint(first.ID), restoredAt))
+
+		user, err := repo.GetByID(ctx, uint(first.ID))
+		require.NoError(t, err)
+		require.NotNil(t, user.TokensRevokedAt)
+		assert.True(t, user.TokenRevoked(restoredAt.Add(-time.Second)), "tokens from before the restore stay rejected")
+
+		assert.ErrorIs(t, repo.Restore(ctx, uint(first.ID), restoredAt), ErrDeletedUserNotFound, "not deleted")
+		assert.ErrorIs(t, repo.Restore(ctx, 999, restoredAt), ErrDeletedUserNotFound)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		require.NoError(t, db.Create(&models.RefreshToken{JTI: "jti", FamilyID: "jti", UserID: uint(second.ID), ExpiresAt: time.Now().Add(time.Hour)}).Error)
+
+		assert.ErrorIs(t, repo.Purge(ctx, uint(kept.ID)), ErrDeletedUserNotFound, "live users cannot be purged")
+		require.NoError(t, repo.Purge(ctx, uint(second.ID)))
+
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&models.User{}).Where("id = ?", second.ID).Count(&count).Error)
+		assert.Zero(t, count)
+		require.NoError(t, db.Model(&models.RefreshToken{}).Where("user_id = ?", second.ID).Count(&count).Error)
+		assert.Zero(t, count)
+
+		require.NoError(t, repo.Create(ctx, &models.User{Name: "Again", Email: "second@example.com", Password: "password", Age: 25}), "email is free again")
+	})
+}
+
 func TestUserRepository_BatchCreate(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
@@ -443,6 +768,40 @@ func TestUserRepository_GetActiveUsers(t *testing.T) {
 	}
 }
 
+func TestUserRepository_Counts(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	users := []*models.User{
+		{Name: "Old User", Email: "old@example.com", Password: "pass", Age: 20, Role: models.RoleUser, CreatedAt: time.Now().AddDate(0, 0, -30)},
+		{Name: "New User", Email: "new@example.com", Password: "pass", Age: 21, Role: models.RoleUser},
+		{Name: "New Admin", Email: "admin@example.com", Password: "pass", Age: 22, Role: models.RoleAdmin},
+		{Name: "Deleted", Email: "deleted@example.com", Password: "pass", Age: 23, Role: models.RoleAdmin},
+	}
+	for _, u := range users {
+		_ = seedTestUser(t, db, u)
+	}
+	require.NoError(t, db.Model(users[1]).Update("is_active", false).Error)
+	require.NoError(t, repo.Delete(ctx, uint(users[3].ID)))
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total, "soft-deleted users are not counted")
+
+	active, err := repo.CountActive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), active)
+
+	byRole, err := repo.CountByRole(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.Role]int64{models.RoleUser: 2, models.RoleAdmin: 1}, byRole)
+
+	recent, err := repo.CountCreatedSince(ctx, time.Now().AddDate(0, 0, -7))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), recent)
+}
+
 func TestUserRepository_ContextCancellation(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
@@ -554,6 +913,7 @@ func TestUserRepository_EdgeCases(t *testing.T) {
 			Email:    "nonexist@example.com",
 			Password: "password",
 			Age:      25,
+			Role:     models.RoleUser,
 			IsActive: true,
 		}
 
@@ -728,7 +1088,7 @@ func BenchmarkUserRepository_GetByID(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = repo.GetByID(ctx, user.ID)
+		_, _ = repo.GetByID(ctx, uint(user.ID))
 	}
 }
 
@@ -759,3 +1119,29 @@ func BenchmarkUserRepository_GetAllPaginated(b *testing.B) {
 		_, _, _ = repo.GetAllPaginated(ctx, query)
 	}
 }
+
+func TestUserRepository_DeleteByIDsAndUpdateRoles(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	first := seedTestUser(t, db, &models.User{Name: "First", Email: "first@example.com", Password: "pass", Age: 20})
+	second := seedTestUser(t, db, &models.User{Name: "Second", Email: "second@example.com", Password: "pass", Age: 21})
+
+	updated, err := repo.UpdateRoles(ctx, map[uint]models.Role{uint(first.ID): models.RoleAdmin, 999: models.RoleAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{uint(first.ID)}, updated, "missing users are skipped")
+	stored, err := repo.GetByID(ctx, uint(first.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, stored.Role)
+
+	_, err = repo.UpdateRoles(ctx, map[uint]models.Role{uint(second.ID): "root"})
+	assert.ErrorIs(t, err, models.ErrInvalidRole)
+
+	deleted, err := repo.DeleteByIDs(ctx, []uint{uint(first.ID), uint(second.ID), 999})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{uint(first.ID), uint(second.ID)}, deleted)
+	users, err := repo.GetByIDs(ctx, []uint{uint(first.ID), uint(second.ID)})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/health"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// AuditCapWarnRatio is the share of MaxRows from which the audit cap reports
+// degraded health and alerts admins
+const AuditCapWarnRatio = 0.9
+
+// AuditCapConfig configures the size cap of the audit log table
+type AuditCapConfig struct {
+	MaxRows      int64         // Rows kept at most; <= 0 disables trimming and warnings
+	MinRetention time.Duration // Rows younger than this are never trimmed, even above MaxRows
+	BatchSize    int           // Rows deleted per statement; <= 0 means 1000
+}
+
+// AuditStorageUsage is the audit table usage reported by the stats endpoint
+// and the health check
+type AuditStorageUsage struct {
+	Rows         int64     `json:"rows"`
+	MaxRows      int64     `json:"max_rows"`
+	UsagePercent float64   `json:"usage_percent"`
+	ApproxBytes  int64     `json:"approx_bytes"` // -1 when the database cannot tell
+	Trimmed      int64     `json:"trimmed"`      // Rows deleted by the last check
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// nearCap reports whether the usage is within AuditCapWarnRatio of the cap
+func (u AuditStorageUsage) nearCap() bool {
+	return u.MaxRows > 0 && float64(u.Rows) >= AuditCapWarnRatio*float64(u.MaxRows)
+}
+
+// auditCapStore is the part of the audit log repository used by AuditCap
+type auditCapStore interface {
+	Count() (int64, error)
+	TableSize() (int64, error)
+	DeleteOldest(limit int, before time.Time) (int64, error)
+}
+
+// AuditCap keeps the audit log table below MaxRows by trimming the oldest
+// rows, and warns admins through a system.alert event and the health check
+// once the table gets close to the cap
+type AuditCap struct {
+	store     auditCapStore
+	config    AuditCapConfig
+	publisher events.Publisher
+	now       func() time.Time
+
+	mu      sync.Mutex
+	usage   *AuditStorageUsage
+	err     error
+	alerted bool
+}
+
+// NewAuditCap creates an audit cap; a nil publisher discards alerts
+func NewAuditCap(store auditCapStore, config AuditCapConfig, publisher events.Publisher) *AuditCap {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if publisher == nil {
+		publisher = events.Nop{}
+	}
+	return &AuditCap{store: store, config: config, publisher: publisher, now: time.Now}
+}
+
+// Enforce counts the rows, trims the oldest ones above MaxRows in batches of
+// BatchSize and records the resulting usage. Rows younger than MinRetention
+// are kept, so the table may stay above the cap.
+func (a *AuditCap) Enforce(ctx context.Context) (AuditStorageUsage, error) {
+	now := a.now()
+	usage, err := a.measure(ctx, now)
+	a.mu.Lock()
+	a.err = err
+	if err == nil {
+		a.usage = &usage
+	}
+	alert := err == nil && usage.nearCap() && !a.alerted
+	if err == nil {
+		a.alerted = usage.nearCap()
+	}
+	a.mu.Unlock()
+
+	if alert {
+		logger.Warn("Audit log table is close to its row cap",
+			"rows", usage.Rows, "max_rows", usage.MaxRows, "usage_percent", usage.UsagePercent)
+		if perr := a.publisher.Publish(ctx, events.Event{
+			Type: events.SystemAlert,
+			Payload: map[string]interface{}{
+				"alert":         "audit_log_near_cap",
+				"rows":          usage.Rows,
+				"max_rows":      usage.MaxRows,
+				"usage_percent": usage.UsagePercent,
+			},
+			OccurredAt: now,
+		}); perr != nil {
+			logger.Error("Failed to publish audit cap alert", "error", perr)
+		}
+	}
+	return usage, err
+}
+
+// measure trims the table if needed and returns its usage
+func (a *AuditCap) measure(ctx context.Context, now time.Time) (AuditStorageUsage, error) {
+	usage := AuditStorageUsage{MaxRows: a.config.MaxRows, CheckedAt: now}
+
+	rows, err := a.store.Count()
+	if err != nil {
+		return usage, fmt.Errorf("count audit logs: %w", err)
+	}
+
+	cutoff := now.Add(-a.config.MinRetention)
+	for excess := rows - a.config.MaxRows; a.config.MaxRows > 0 && excess > 0; {
+		if err := ctx.Err(); err != nil {
+			return usage, err
+		}
+		deleted, err := a.store.DeleteOldest(int(min(excess, int64(a.config.BatchSize))), cutoff)
+		if err != nil {
+			return usage, fmt.Errorf("trim audit logs: %w", err)
+		}
+		if deleted == 0 {
+			break // The remaining rows are within the minimum retention
+		}
+		usage.Trimmed += deleted
+		excess -= deleted
+	}
+	if usage.Trimmed > 0 {
+		logger.Info("Trimmed audit logs above the row cap", "count", usage.Trimmed, "max_rows", a.config.MaxRows)
+	}
+
+	usage.Rows = rows - usage.Trimmed
+	if a.config.MaxRows > 0 {
+		usage.UsagePercent = float64(usage.Rows) * 100 / float64(a.config.MaxRows)
+	}
+	if usage.ApproxBytes, err = a.store.TableSize(); err != nil {
+		return usage, fmt.Errorf("measure audit log table: %w", err)
+	}
+	return usage, nil
+}
+
+// Usage returns the usage recorded by the last successful Enforce, or false
+// before the first one
+func (a *AuditCap) Usage() (AuditStorageUsage, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.usage == nil {
+		return AuditStorageUsage{}, false
+	}
+	return *a.usage, true
+}
+
+// Check implements health.Checker. The audit log is degraded from
+// AuditCapWarnRatio of the cap on and while the last check failed.
+func (a *AuditCap) Check(context.Context) health.ComponentHealth {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err != nil {
+		return health.ComponentHealth{
+			Status:  health.StatusDegraded,
+			Message: "audit log size check failed",
+			Details: map[string]interface{}{"error": a.err.Error()},
+		}
+	}
+	if a.usage == nil {
+		return health.ComponentHealth{Status: health.StatusHealthy, Message: "audit log size not checked yet"}
+	}
+
+	details := map[string]interface{}{
+		"rows":          a.usage.Rows,
+		"max_rows":      a.usage.MaxRows,
+		"usage_percent": a.usage.UsagePercent,
+	}
+	if a.usage.nearCap() {
+		return health.ComponentHealth{
+			Status:  health.StatusDegraded,
+			Message: "audit log is close to its row cap",
+			Details: details,
+		}
+	}
+	return health.ComponentHealth{Status: health.StatusHealthy, Message: "audit log is below its row cap", Details: details}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/health"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newAuditCapDB returns a fresh database holding one audit log per entry of
+// ages, created that long before now
+func newAuditCapDB(t *testing.T, now time.Time, ages ...time.Duration) (*gorm.DB, *repository.AuditLogRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	repo := repository.NewAuditLogRepository(db)
+	for _, age := range ages {
+		require.NoError(t, repo.Create(&models.AuditLog{Action: models.AuditActionLogin, CreatedAt: now.Add(-age)}))
+	}
+	return db, repo
+}
+
+func TestAuditCap_TrimsOldestInBatches(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	db, repo := newAuditCapDB(t, now, 10*day, 9*day, 8*day, 7*day, 6*day, 1*day)
+
+	auditCap := NewAuditCap(repo, AuditCapConfig{MaxRows: 3, MinRetention: 2 * day, BatchSize: 2}, nil)
+	auditCap.now = func() time.Time { return now }
+
+	usage, err := auditCap.Enforce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), usage.Trimmed)
+	assert.Equal(t, int64(3), usage.Rows)
+
+	var oldest models.AuditLog
+	require.NoError(t, db.Order("created_at").First(&oldest).Error)
+	assert.True(t, oldest.CreatedAt.Equal(now.Add(-7*day)), "the oldest rows are trimmed first")
+}
+
+func TestAuditCap_KeepsRowsWithinMinRetention(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	hour := time.Hour
+	_, repo := newAuditCapDB(t, now, 48*hour, 3*hour, 2*hour, 1*hour)
+
+	auditCap := NewAuditCap(repo, AuditCapConfig{MaxRows: 2, MinRetention: 24 * hour}, nil)
+	auditCap.now = func() time.Time { return now }
+
+	usage, err := auditCap.Enforce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usage.Trimmed, "only the row older than the minimum retention is trimmed")
+	assert.Equal(t, int64(3), usage.Rows)
+	assert.Equal(t, float64(150), usage.UsagePercent)
+}
+
+func TestAuditCap_AlertsOnceNearCap(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	_, repo := newAuditCapDB(t, now, time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour, 5*time.Hour,
+		6*time.Hour, 7*time.Hour, 8*time.Hour, 9*time.Hour)
+	recorder := &events.Recorder{}
+
+	auditCap := NewAuditCap(repo, AuditCapConfig{MaxRows: 10, MinRetention: time.Hour}, recorder)
+	ctx := context.Background()
+	assert.Equal(t, health.StatusHealthy, auditCap.Check(ctx).Status, "healthy before the first check")
+
+	_, err := auditCap.Enforce(ctx)
+	require.NoError(t, err)
+	_, err = auditCap.Enforce(ctx)
+	require.NoError(t, err)
+
+	published := recorder.Events()
+	require.Len(t, published, 1, "the alert is published when the table gets near the cap, not on every check")
+	assert.Equal(t, events.SystemAlert, published[0].Type)
+	assert.Equal(t, "audit_log_near_cap", published[0].Payload["alert"])
+	assert.Equal(t, health.StatusDegraded, auditCap.Check(ctx).Status)
+
+	usage, ok := auditCap.Usage()
+	require.True(t, ok)
+	assert.Equal(t, int64(9), usage.Rows)
+	assert.Equal(t, int64(10), usage.MaxRows)
+}
+
+func TestAuditCap_HealthyBelowWarning(t *testing.T) {
+	now := time.Now()
+	_, repo := newAuditCapDB(t, now, time.Hour)
+	recorder := &events.Recorder{}
+
+	auditCap := NewAuditCap(repo, AuditCapConfig{MaxRows: 10}, recorder)
+	_, err := auditCap.Enforce(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, recorder.Events())
+	assert.Equal(t, health.StatusHealthy, auditCap.Check(context.Background()).Status)
+}
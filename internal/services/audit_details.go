@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"Go-Lang-project-01/internal/models"
+)
+
+// RawDetailsSchema marks details that match no registered schema.
+// The original value is stored under the "raw" key.
+const RawDetailsSchema = "raw.v1"
+
+// AuditDetailSchema describes the Details payload of one audit action
+type AuditDetailSchema struct {
+	Version string             // Stored in AuditLog.DetailsSchema
+	New     func() interface{} // Returns a pointer to an empty payload
+}
+
+// AuditDetailSchemas maps each action that carries details to its schema.
+// Bump Version when a payload changes incompatibly.
+var AuditDetailSchemas = map[models.AuditAction]AuditDetailSchema{
+	models.AuditActionUserOffboard: {
+		Version: "user_offboard.v1",
+		New:     func() interface{} { return &models.OffboardReport{} },
+	},
+	models.AuditActionRegisterThrottled: {
+		Version: "register_throttled.v1",
+		New:     func() interface{} { return &models.RegisterThrottle{} },
+	},
+	models.AuditActionRefreshTokenReuse: {
+		Version: "refresh_token_reuse.v1",
+		New:     func() interface{} { return &models.RefreshTokenReuse{} },
+	},
+	models.AuditActionFlagCreate: flagChangeSchema,
+	models.AuditActionFlagUpdate: flagChangeSchema,
+	models.AuditActionFlagDelete: flagChangeSchema,
+
+	models.AuditActionUserCreate:      auditTrailSchema,
+	models.AuditActionUserBatchCreate: auditTrailSchema,
+	models.AuditActionUserUpdate:      auditTrailSchema,
+	models.AuditActionUserDelete:      auditTrailSchema,
+	models.AuditActionRoleChange:      auditTrailSchema,
+	models.AuditActionProfileUpdate:   auditTrailSchema,
+
+	models.AuditActionBulkRoleChange: {
+		Version: "bulk_role_change.v1",
+		New:     func() interface{} { return &models.RoleChange{} },
+	},
+
+	models.AuditActionAuditExport: {
+		Version: "audit_log_export.v1",
+		New:     func() interface{} { return &models.AuditLogExport{} },
+	},
+}
+
+// flagChangeSchema is shared by all feature flag actions
+var flagChangeSchema = AuditDetailSchema{
+	Version: "feature_flag_change.v1",
+	New:     func() interface{} { return &models.FeatureFlagChange{} },
+}
+
+// auditTrailSchema is shared by the actions recorded by middleware.AuditTrail
+var auditTrailSchema = AuditDetailSchema{
+	Version: "audit_trail.v1",
+	New:     func() interface{} { return &models.AuditTrailRequest{} },
+}
+
+// rawDetails is the payload stored under RawDetailsSchema
+type rawDetails struct {
+	Raw interface{} `json:"raw"`
+}
+
+// EncodeAuditDetails serializes details for an action and returns the JSON with
+// its schema version. Details whose type does not match the action's schema, or
+// whose action has none, are wrapped under "raw"; values that cannot be marshaled
+// are kept as their string representation, so the result is always valid JSON.
+func EncodeAuditDetails(action models.AuditAction, details interface{}) (string, string, error) {
+	if details == nil {
+		return "", "", nil
+	}
+
+	schema, ok := AuditDetailSchemas[action]
+	if ok && indirectType(details) == indirectType(schema.New()) {
+		data, err := json.Marshal(details)
+		if err == nil {
+			return string(data), schema.Version, nil
+		}
+		return encodeRaw(fmt.Sprintf("%+v", details)), RawDetailsSchema,
+			fmt.Errorf("failed to marshal %s details: %w", action, err)
+	}
+
+	var shapeErr error
+	if ok {
+		shapeErr = fmt.Errorf("%s details must be %s, got %T", action, indirectType(schema.New()), details)
+	}
+	if _, err := json.Marshal(details); err != nil {
+		return encodeRaw(fmt.Sprintf("%+v", details)), RawDetailsSchema,
+			errors.Join(shapeErr, fmt.Errorf("failed to marshal %s details: %w", action, err))
+	}
+	return encodeRaw(details), RawDetailsSchema, shapeErr
+}
+
+// ValidateAuditDetails checks stored details against the schema of their action.
+// It returns the schema version the details conform to.
+func ValidateAuditDetails(action models.AuditAction, details string) (string, error) {
+	if details == "" {
+		return "", nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(details), &fields); err != nil {
+		return "", errors.New("details are not a JSON object")
+	}
+	_, hasRaw := fields["raw"]
+	isRaw := hasRaw && len(fields) == 1
+
+	schema, ok := AuditDetailSchemas[action]
+	if !ok {
+		if !isRaw {
+			return "", fmt.Errorf("no schema registered for %s and details are not wrapped under \"raw\"", action)
+		}
+		return RawDetailsSchema, nil
+	}
+
+	if err := decodeStrict(details, schema.New()); err != nil {
+		if isRaw {
+			return RawDetailsSchema, nil
+		}
+		return "", fmt.Errorf("details do not match %s: %w", schema.Version, err)
+	}
+	return schema.Version, nil
+}
+
+// WrapRawDetails wraps stored details that failed validation under "raw".
+// Invalid JSON is kept as a string.
+func WrapRawDetails(details string) string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(details), &value); err != nil {
+		return encodeRaw(details)
+	}
+	return encodeRaw(value)
+}
+
+// encodeRaw marshals value under the "raw" key; value must be marshalable
+func encodeRaw(value interface{}) string {
+	data, _ := json.Marshal(rawDetails{Raw: value})
+	return string(data)
+}
+
+// decodeStrict decodes details into target, rejecting unknown fields
+func decodeStrict(details string, target interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader([]byte(details)))
+	dec.DisallowUnknownFields()
+	return dec.Decode(target)
+}
+
+// indirectType returns the type of v with pointers removed
+func indirectType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
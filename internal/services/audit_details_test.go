@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeAuditDetails(t *testing.T) {
+	report := &models.OffboardReport{UserID: 7, Email: "leaver@example.com", Success: true}
+
+	t.Run("no details", func(t *testing.T) {
+		details, schema, err := EncodeAuditDetails(models.AuditActionLogin, nil)
+		require.NoError(t, err)
+		assert.Empty(t, details)
+		assert.Empty(t, schema)
+	})
+
+	t.Run("registered schema", func(t *testing.T) {
+		details, schema, err := EncodeAuditDetails(models.AuditActionUserOffboard, report)
+		require.NoError(t, err)
+		assert.Equal(t, "user_offboard.v1", schema)
+
+		var decoded models.OffboardReport
+		require.NoError(t, json.Unmarshal([]byte(details), &decoded))
+		assert.Equal(t, uint(7), decoded.UserID)
+	})
+
+	t.Run("value and pointer are the same schema", func(t *testing.T) {
+		_, schema, err := EncodeAuditDetails(models.AuditActionUserOffboard, *report)
+		require.NoError(t, err)
+		assert.Equal(t, "user_offboard.v1", schema)
+	})
+
+	t.Run("action without schema is wrapped", func(t *testing.T) {
+		details, schema, err := EncodeAuditDetails(models.AuditActionUserRead, map[string]string{"name": "new"})
+		require.NoError(t, err)
+		assert.Equal(t, RawDetailsSchema, schema)
+		assert.JSONEq(t, `{"raw":{"name":"new"}}`, details)
+	})
+
+	t.Run("wrong shape is wrapped and reported", func(t *testing.T) {
+		details, schema, err := EncodeAuditDetails(models.AuditActionUserOffboard, map[string]string{"user": "7"})
+		assert.Error(t, err)
+		assert.Equal(t, RawDetailsSchema, schema)
+		assert.JSONEq(t, `{"raw":{"user":"7"}}`, details)
+	})
+
+	t.Run("unmarshalable value is stored as text", func(t *testing.T) {
+		details, schema, err := EncodeAuditDetails(models.AuditActionUserUpdate, make(chan int))
+		assert.Error(t, err)
+		assert.Equal(t, RawDetailsSchema, schema)
+		assert.True(t, json.Valid([]byte(details)), "details must always be valid JSON: %s", details)
+	})
+}
+
+func TestValidateAuditDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  models.AuditAction
+		details string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", action: models.AuditActionLogin, want: ""},
+		{name: "matches schema", action: models.AuditActionUserOffboard,
+			details: `{"user_id":7,"email":"a@b.c","steps":[],"success":true,"completed_at":"2024-01-01T00:00:00Z"}`,
+			want:    "user_offboard.v1"},
+		{name: "raw under registered action", action: models.AuditActionUserOffboard, details: `{"raw":"legacy"}`, want: RawDetailsSchema},
+		{name: "raw under unregistered action", action: models.AuditActionUserUpdate, details: `{"raw":{"a":1}}`, want: RawDetailsSchema},
+		{name: "unknown field", action: models.AuditActionUserOffboard, details: `{"user_id":7,"extra":true}`, wantErr: true},
+		{name: "wrong type", action: models.AuditActionUserOffboard, details: `{"user_id":"seven"}`, wantErr: true},
+		{name: "malformed JSON", action: models.AuditActionUserOffboard, details: `{"user_id":`, wantErr: true},
+		{name: "not an object", action: models.AuditActionUserUpdate, details: `[1,2]`, wantErr: true},
+		{name: "unwrapped without schema", action: models.AuditActionUserUpdate, details: `{"name":"new"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateAuditDetails(tt.action, tt.details)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWrapRawDetails(t *testing.T) {
+	assert.JSONEq(t, `{"raw":{"name":"new"}}`, WrapRawDetails(`{"name":"new"}`))
+	assert.JSONEq(t, `{"raw":"{\"user_id\":"}`, WrapRawDetails(`{"user_id":`))
+
+	// Wrapped rows pass validation afterwards
+	schema, err := ValidateAuditDetails(models.AuditActionUserOffboard, WrapRawDetails(`{"user_id":`))
+	require.NoError(t, err)
+	assert.Equal(t, RawDetailsSchema, schema)
+}
+
+func TestAuditService_StoresDetailsSchema(t *testing.T) {
+	sink := make(channelSink, 1)
+	service := NewAuditService(sink)
+
+	report := &models.OffboardReport{UserID: 7, Success: true}
+	log := logTestActionWithDetails(t, service, sink, models.AuditActionUserOffboard, report)
+
+	assert.Equal(t, "user_offboard.v1", log.DetailsSchema)
+	_, err := ValidateAuditDetails(log.Action, log.Details)
+	assert.NoError(t, err)
+
+	log = logTestActionWithDetails(t, service, sink, models.AuditActionUserUpdate, map[string]string{"name": "new"})
+	assert.Equal(t, RawDetailsSchema, log.DetailsSchema)
+}
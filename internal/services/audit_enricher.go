@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// AuditRequest is the request data available to enrichers. It is captured
+// before the handler returns, since the gin.Context is recycled afterwards.
+type AuditRequest struct {
+	IPAddress string
+	UserAgent string
+	Header    http.Header
+}
+
+// MetadataEnricher adds request context to an audit log's metadata.
+// Errors are logged by the audit service and never prevent the write.
+type MetadataEnricher interface {
+	Name() string
+	Enrich(req *AuditRequest, metadata models.AuditMetadata) error
+}
+
+// HeaderEnricher copies selected request headers into metadata["headers"]
+type HeaderEnricher struct {
+	headers []string
+}
+
+// NewHeaderEnricher creates an enricher capturing the given headers
+func NewHeaderEnricher(headers ...string) *HeaderEnricher {
+	canonical := make([]string, 0, len(headers))
+	for _, h := range headers {
+		canonical = append(canonical, http.CanonicalHeaderKey(h))
+	}
+	return &HeaderEnricher{headers: canonical}
+}
+
+// Name returns the enricher name
+func (e *HeaderEnricher) Name() string { return "headers" }
+
+// Enrich records the configured headers that are present on the request
+func (e *HeaderEnricher) Enrich(req *AuditRequest, metadata models.AuditMetadata) error {
+	captured := make(map[string]string)
+	for _, h := range e.headers {
+		if value := req.Header.Get(h); value != "" {
+			captured[h] = value
+		}
+	}
+	if len(captured) > 0 {
+		metadata["headers"] = captured
+	}
+	return nil
+}
+
+// UserAgentEnricher stores a browser/OS/device summary in metadata["client"]
+type UserAgentEnricher struct{}
+
+// Name returns the enricher name
+func (UserAgentEnricher) Name() string { return "user_agent" }
+
+// Enrich parses the User-Agent header
+func (UserAgentEnricher) Enrich(req *AuditRequest, metadata models.AuditMetadata) error {
+	if req.UserAgent == "" {
+		return nil
+	}
+	metadata["client"] = ParseUserAgent(req.UserAgent)
+	return nil
+}
+
+// UserAgentSummary is a coarse description of a client
+type UserAgentSummary struct {
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	Device         string `json:"device"` // "desktop", "mobile", "tablet" or "bot"
+}
+
+// ParseUserAgent extracts browser, OS and device type from a User-Agent string.
+// It only recognises common clients; anything else is reported as unknown.
+func ParseUserAgent(ua string) UserAgentSummary {
+	lower := strings.ToLower(ua)
+	summary := UserAgentSummary{Device: "desktop"}
+
+	// Order matters: Edge and Opera also claim to be Chrome, Chrome claims to be Safari
+	browsers := []struct{ name, token string }{
+		{"Edge", "Edg/"},
+		{"Opera", "OPR/"},
+		{"Firefox", "Firefox/"},
+		{"Chrome", "Chrome/"},
+		{"Safari", "Version/"},
+		{"curl", "curl/"},
+	}
+	for _, b := range browsers {
+		if i := strings.Index(ua, b.token); i >= 0 {
+			summary.Browser = b.name
+			version := ua[i+len(b.token):]
+			if end := strings.IndexAny(version, " ;)"); end >= 0 {
+				version = version[:end]
+			}
+			summary.BrowserVersion = version
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "Windows"):
+		summary.OS = "Windows"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		summary.OS = "iOS" // Checked before macOS: iOS agents contain "like Mac OS X"
+	case strings.Contains(ua, "Android"):
+		summary.OS = "Android"
+	case strings.Contains(ua, "Mac OS X"):
+		summary.OS = "macOS"
+	case strings.Contains(ua, "Linux"):
+		summary.OS = "Linux"
+	}
+
+	switch {
+	case strings.Contains(lower, "bot"), strings.Contains(lower, "spider"), strings.Contains(lower, "crawl"):
+		summary.Device = "bot"
+	case strings.Contains(ua, "iPad"), strings.Contains(lower, "tablet"):
+		summary.Device = "tablet"
+	case strings.Contains(ua, "Mobile"), strings.Contains(ua, "iPhone"), strings.Contains(ua, "Android"):
+		summary.Device = "mobile"
+	}
+
+	return summary
+}
+
+// GeoIPEnricher stores the client's country in metadata["geo"] using a MaxMind MMDB database
+type GeoIPEnricher struct {
+	db *geoip2.Reader
+}
+
+// NewGeoIPEnricher opens the MMDB database at path
+func NewGeoIPEnricher(path string) (*GeoIPEnricher, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database %q: %w", path, err)
+	}
+	return &GeoIPEnricher{db: db}, nil
+}
+
+// Name returns the enricher name
+func (e *GeoIPEnricher) Name() string { return "geoip" }
+
+// Enrich looks up the client IP. Private and loopback addresses are skipped.
+func (e *GeoIPEnricher) Enrich(req *AuditRequest, metadata models.AuditMetadata) error {
+	ip := net.ParseIP(req.IPAddress)
+	if ip == nil {
+		return fmt.Errorf("invalid client IP %q", req.IPAddress)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() {
+		return nil
+	}
+
+	record, err := e.db.Country(ip)
+	if err != nil {
+		return fmt.Errorf("GeoIP lookup failed: %w", err)
+	}
+	if record.Country.IsoCode != "" {
+		metadata["geo"] = map[string]string{"country": record.Country.IsoCode}
+	}
+	return nil
+}
+
+// Close releases the database
+func (e *GeoIPEnricher) Close() error {
+	return e.db.Close()
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// channelSink hands every entry to the test goroutine
+type channelSink chan *models.AuditLog
+
+func (s channelSink) Create(log *models.AuditLog) error {
+	s <- log
+	return nil
+}
+
+// funcEnricher adapts a function to MetadataEnricher
+type funcEnricher func(req *AuditRequest, metadata models.AuditMetadata) error
+
+func (funcEnricher) Name() string { return "func" }
+
+func (f funcEnricher) Enrich(req *AuditRequest, metadata models.AuditMetadata) error {
+	return f(req, metadata)
+}
+
+// logTestAction calls LogAction for a request with the given headers and waits for the entry
+func logTestAction(t *testing.T, service *AuditService, sink channelSink, header http.Header) *models.AuditLog {
+	t.Helper()
+	return logAction(t, service, sink, header, models.AuditActionLogin, nil)
+}
+
+// logTestActionWithDetails calls LogAction with details and waits for the entry
+func logTestActionWithDetails(t *testing.T, service *AuditService, sink channelSink, action models.AuditAction, details interface{}) *models.AuditLog {
+	t.Helper()
+	return logAction(t, service, sink, http.Header{}, action, details)
+}
+
+func logAction(t *testing.T, service *AuditService, sink channelSink, header http.Header, action models.AuditAction, details interface{}) *models.AuditLog {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/api/v1/auth/login", nil)
+	c.Request.Header = header
+
+	service.LogAction(c, nil, action, models.AuditResourceAuth, nil, details, true, "")
+
+	select {
+	case log := <-sink:
+		return log
+	case <-time.After(2 * time.Second):
+		t.Fatal("audit log was not written")
+		return nil
+	}
+}
+
+func TestHeaderEnricher(t *testing.T) {
+	enricher := NewHeaderEnricher("x-client-version", "X-Missing")
+	metadata := models.AuditMetadata{}
+
+	header := http.Header{}
+	header.Set("X-Client-Version", "ios/3.2.1")
+	header.Set("Authorization", "Bearer secret")
+
+	require.NoError(t, enricher.Enrich(&AuditRequest{Header: header}, metadata))
+
+	assert.Equal(t, map[string]string{"X-Client-Version": "ios/3.2.1"}, metadata["headers"])
+}
+
+func TestHeaderEnricher_NothingCaptured(t *testing.T) {
+	metadata := models.AuditMetadata{}
+
+	require.NoError(t, NewHeaderEnricher("X-Client-Version").Enrich(&AuditRequest{Header: http.Header{}}, metadata))
+
+	assert.NotContains(t, metadata, "headers")
+}
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want UserAgentSummary
+	}{
+		{
+			name: "Chrome on Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: UserAgentSummary{Browser: "Chrome", BrowserVersion: "120.0.0.0", OS: "Windows", Device: "desktop"},
+		},
+		{
+			name: "Edge on Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: UserAgentSummary{Browser: "Edge", BrowserVersion: "120.0.2210.91", OS: "Windows", Device: "desktop"},
+		},
+		{
+			name: "Safari on iPhone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: UserAgentSummary{Browser: "Safari", BrowserVersion: "17.2", OS: "iOS", Device: "mobile"},
+		},
+		{
+			name: "Firefox on Linux",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: UserAgentSummary{Browser: "Firefox", BrowserVersion: "121.0", OS: "Linux", Device: "desktop"},
+		},
+		{
+			name: "Chrome on Android",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: UserAgentSummary{Browser: "Chrome", BrowserVersion: "120.0.6099.144", OS: "Android", Device: "mobile"},
+		},
+		{
+			name: "Safari on iPad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: UserAgentSummary{Browser: "Safari", BrowserVersion: "17.2", OS: "iOS", Device: "tablet"},
+		},
+		{
+			name: "Search engine bot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: UserAgentSummary{Device: "bot"},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.4.0",
+			want: UserAgentSummary{Browser: "curl", BrowserVersion: "8.4.0", Device: "desktop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseUserAgent(tt.ua))
+		})
+	}
+}
+
+func TestNewGeoIPEnricher_MissingDatabase(t *testing.T) {
+	_, err := NewGeoIPEnricher("testdata/does-not-exist.mmdb")
+	assert.Error(t, err)
+}
+
+func TestAuditService_EnrichesMetadata(t *testing.T) {
+	sink := make(channelSink, 1)
+	service := NewAuditService(sink, NewHeaderEnricher("X-Client-Version"), UserAgentEnricher{})
+
+	header := http.Header{}
+	header.Set("X-Client-Version", "web/1.4.0")
+	header.Set("User-Agent", "curl/8.4.0")
+
+	log := logTestAction(t, service, sink, header)
+
+	require.NotNil(t, log.Metadata)
+	assert.Equal(t, map[string]string{"X-Client-Version": "web/1.4.0"}, log.Metadata["headers"])
+	assert.Equal(t, UserAgentSummary{Browser: "curl", BrowserVersion: "8.4.0", Device: "desktop"}, log.Metadata["client"])
+}
+
+func TestAuditService_EnricherFailuresDoNotBlockWrite(t *testing.T) {
+	sink := make(channelSink, 1)
+	failing := funcEnricher(func(*AuditRequest, models.AuditMetadata) error {
+		return errors.New("lookup failed")
+	})
+	panicking := funcEnricher(func(*AuditRequest, models.AuditMetadata) error {
+		panic("boom")
+	})
+	service := NewAuditService(sink, failing, panicking, NewHeaderEnricher("X-Client-Version"))
+
+	header := http.Header{}
+	header.Set("X-Client-Version", "web/1.4.0")
+
+	log := logTestAction(t, service, sink, header)
+
+	assert.Equal(t, models.AuditActionLogin, log.Action)
+	assert.Equal(t, map[string]string{"X-Client-Version": "web/1.4.0"}, log.Metadata["headers"],
+		"enrichers after a failing one still run")
+}
+
+func TestAuditService_NoEnrichersLeavesMetadataEmpty(t *testing.T) {
+	sink := make(channelSink, 1)
+
+	log := logTestAction(t, NewAuditService(sink), sink, http.Header{})
+
+	assert.Nil(t, log.Metadata)
+}
+
+func TestAuditLogMetadataRoundTrip(t *testing.T) {
+	repo := newAuditRepo(t)
+
+	log := &models.AuditLog{
+		Action:    models.AuditActionLogin,
+		Resource:  models.AuditResourceAuth,
+		IPAddress: "127.0.0.1",
+		Success:   true,
+		Metadata:  models.AuditMetadata{"geo": map[string]string{"country": "ID"}},
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, repo.Create(log))
+
+	stored, err := repo.GetByID(uint(log.ID))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"country": "ID"}, stored.Metadata["geo"])
+}
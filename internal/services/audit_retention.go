@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// defaultAuditCleanupInterval is used when AuditRetentionConfig.Interval is not positive
+const defaultAuditCleanupInterval = 24 * time.Hour
+
+// AuditRetentionConfig configures the scheduled deletion of old audit logs
+type AuditRetentionConfig struct {
+	RetentionDays int           // Logs older than this are deleted; <= 0 disables the job
+	Interval      time.Duration // Time between runs; <= 0 means 24h
+}
+
+// auditCleaner deletes audit logs older than a number of days, e.g. AuditService
+type auditCleaner interface {
+	CleanupOldLogs(retentionDays int) (int64, error)
+}
+
+// AuditRetention deletes audit logs older than RetentionDays on a schedule,
+// like DELETE /audit-logs/cleanup does on demand
+type AuditRetention struct {
+	cleaner   auditCleaner
+	config    AuditRetentionConfig
+	newTicker func(time.Duration) (<-chan time.Time, func()) // Replaced in tests
+
+	running atomic.Bool
+}
+
+// NewAuditRetention creates a retention job for cleaner
+func NewAuditRetention(cleaner auditCleaner, config AuditRetentionConfig) *AuditRetention {
+	if config.Interval <= 0 {
+		config.Interval = defaultAuditCleanupInterval
+	}
+	return &AuditRetention{
+		cleaner: cleaner,
+		config:  config,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(d)
+			return ticker.C, ticker.Stop
+		},
+	}
+}
+
+// Enabled reports whether the job deletes anything
+func (r *AuditRetention) Enabled() bool {
+	return r.config.RetentionDays > 0
+}
+
+// Run deletes old logs now and then every Interval until ctx is done.
+// It returns at once when the job is disabled.
+func (r *AuditRetention) Run(ctx context.Context) {
+	if !r.Enabled() {
+		return
+	}
+	ticks, stop := r.newTicker(r.config.Interval)
+	defer stop()
+
+	r.Cleanup()
+	for {
+		select {
+		case <-ticks:
+			r.Cleanup()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Cleanup runs one deletion and returns the number of deleted logs. It
+// skips the run and reports false while another one is still going.
+func (r *AuditRetention) Cleanup() (int64, bool) {
+	if !r.running.CompareAndSwap(false, true) {
+		logger.Warn("Skipping audit log retention run, the previous one is still running")
+		return 0, false
+	}
+	defer r.running.Store(false)
+
+	deleted, err := r.cleaner.CleanupOldLogs(r.config.RetentionDays)
+	if err != nil {
+		logger.Error("Audit log retention run failed", "error", err)
+		return 0, true
+	}
+	logger.Info("Audit log retention run finished", "deleted", deleted, "retention_days", r.config.RetentionDays)
+	return deleted, true
+}
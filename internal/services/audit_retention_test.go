@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCleaner counts CleanupOldLogs calls; each call blocks on release
+// when it is set
+type countingCleaner struct {
+	calls   atomic.Int32
+	days    atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingCleaner) CleanupOldLogs(retentionDays int) (int64, error) {
+	c.calls.Add(1)
+	c.days.Store(int32(retentionDays))
+	if c.release != nil {
+		<-c.release
+	}
+	return 3, nil
+}
+
+// newManualRetention returns a retention job whose ticks are sent on the returned channel
+func newManualRetention(cleaner auditCleaner, days int) (*AuditRetention, chan time.Time) {
+	ticks := make(chan time.Time)
+	retention := NewAuditRetention(cleaner, AuditRetentionConfig{RetentionDays: days, Interval: time.Hour})
+	retention.newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	return retention, ticks
+}
+
+func TestAuditRetention_RunsOnStartAndEveryTick(t *testing.T) {
+	cleaner := &countingCleaner{}
+	retention, ticks := newManualRetention(cleaner, 30)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		retention.Run(ctx)
+		close(done)
+	}()
+
+	ticks <- time.Now()
+	ticks <- time.Now()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after the context was canceled")
+	}
+	assert.Equal(t, int32(3), cleaner.calls.Load(), "one run on start and one per tick")
+	assert.Equal(t, int32(30), cleaner.days.Load())
+}
+
+func TestAuditRetention_ZeroRetentionDisables(t *testing.T) {
+	cleaner := &countingCleaner{}
+	retention, _ := newManualRetention(cleaner, 0)
+
+	done := make(chan struct{})
+	go func() {
+		retention.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return for a disabled job")
+	}
+	assert.False(t, retention.Enabled())
+	assert.Zero(t, cleaner.calls.Load())
+}
+
+func TestAuditRetention_SkipsOverlappingRuns(t *testing.T) {
+	cleaner := &countingCleaner{release: make(chan struct{})}
+	retention, _ := newManualRetention(cleaner, 7)
+
+	first := make(chan int64)
+	go func() {
+		deleted, _ := retention.Cleanup()
+		first <- deleted
+	}()
+	require.Eventually(t, func() bool { return cleaner.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	_, ran := retention.Cleanup()
+	assert.False(t, ran, "a run is skipped while the previous one is still going")
+
+	close(cleaner.release)
+	assert.Equal(t, int64(3), <-first)
+	assert.Equal(t, int32(1), cleaner.calls.Load())
+}
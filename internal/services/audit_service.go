@@ -7,96 +7,204 @@ import (
 	"Go-Lang-project-01/internal/models"
 	"Go-Lang-project-01/internal/repository"
 	"Go-Lang-project-01/pkg/logger"
-	"encoding/json"
+	"Go-Lang-project-01/pkg/reporting"
+	"Go-Lang-project-01/pkg/utils"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// authSummaryTTL is how long a user's auth summary is reused; computing it scans the audit table
+const authSummaryTTL = 30 * time.Second
+
+// cachedAuthSummary is an auth summary with its expiry
+type cachedAuthSummary struct {
+	summary *models.UserAuthSummary
+	expires time.Time
+}
+
 // AuditService handles audit logging business logic
 type AuditService struct {
-	repo *repository.AuditLogRepository
+	sink          AuditSink
+	reader        AuditReader // nil when no sink supports queries
+	enrichers     []MetadataEnricher
+	authSummaries sync.Map // user ID -> cachedAuthSummary
 }
 
-// NewAuditService creates a new audit service
-func NewAuditService(repo *repository.AuditLogRepository) *AuditService {
-	return &AuditService{repo: repo}
+// NewAuditService creates a new audit service writing to sink.
+// Query methods use the first sink that supports reads. Enrichers add
+// request context to each entry's Metadata, in order.
+func NewAuditService(sink AuditSink, enrichers ...MetadataEnricher) *AuditService {
+	return &AuditService{
+		sink:      sink,
+		reader:    readerOf(sink),
+		enrichers: enrichers,
+	}
 }
 
 // LogAction creates an audit log entry asynchronously
-func (s *AuditService) LogAction(c *gin.Context, userID *uint, action models.AuditAction, resource models.AuditResource, resourceID *uint, details interface{}, success bool, errorMsg string) {
+func (s *AuditService) LogAction(c *gin.Context, userID *models.ID, action models.AuditAction, resource models.AuditResource, resourceID *models.ID, details interface{}, success bool, errorMsg string) {
+	// Read request data now: the gin.Context is recycled once the handler returns
+	ipAddress := utils.ClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	var req *AuditRequest
+	if len(s.enrichers) > 0 {
+		req = &AuditRequest{
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+			Header:    c.Request.Header.Clone(),
+		}
+	}
+
+	tags := reporting.GinTags(c)
+	tags["component"] = "audit"
+	tags["action"] = string(action)
+
 	// Create audit log in goroutine to not block the request
 	go func() {
-		detailsJSON := ""
-		if details != nil {
-			if jsonBytes, err := json.Marshal(details); err == nil {
-				detailsJSON = string(jsonBytes)
-			}
+		defer reporting.Recover(tags)
+
+		// Details that don't match the action's schema are still written, wrapped under "raw"
+		detailsJSON, detailsSchema, err := EncodeAuditDetails(action, details)
+		if err != nil {
+			logger.Warn("Audit details do not match schema", "action", action, "error", err)
 		}
 
 		log := &models.AuditLog{
-			UserID:     userID,
-			Action:     action,
-			Resource:   resource,
-			ResourceID: resourceID,
-			Details:    detailsJSON,
-			IPAddress:  s.getClientIP(c),
-			UserAgent:  c.GetHeader("User-Agent"),
-			Success:    success,
-			ErrorMsg:   errorMsg,
-			CreatedAt:  time.Now(),
+			UserID:        userID,
+			Action:        action,
+			Resource:      resource,
+			ResourceID:    resourceID,
+			Details:       detailsJSON,
+			DetailsSchema: detailsSchema,
+			IPAddress:     ipAddress,
+			UserAgent:     userAgent,
+			Success:       success,
+			ErrorMsg:      errorMsg,
+			CreatedAt:     time.Now(),
+		}
+		if req != nil {
+			log.Metadata = s.enrich(req, action)
 		}
 
-		if err := s.repo.Create(log); err != nil {
+		if err := s.sink.Create(log); err != nil {
 			logger.Error("Failed to create audit log", "error", err, "action", action)
+			reporting.Error(err, tags)
 		}
 	}()
 }
 
+// enrich runs every enricher. A failing or panicking enricher is logged and
+// skipped so that enrichment never prevents the audit write.
+func (s *AuditService) enrich(req *AuditRequest, action models.AuditAction) models.AuditMetadata {
+	metadata := models.AuditMetadata{}
+	for _, enricher := range s.enrichers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Warn("Audit enricher panicked", "enricher", enricher.Name(), "action", action, "panic", r)
+				}
+			}()
+			if err := enricher.Enrich(req, metadata); err != nil {
+				logger.Warn("Audit enricher failed", "enricher", enricher.Name(), "action", action, "error", err)
+			}
+		}()
+	}
+
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
 // LogAuthAction logs authentication-related actions
-func (s *AuditService) LogAuthAction(c *gin.Context, userID *uint, action models.AuditAction, success bool, errorMsg string) {
+func (s *AuditService) LogAuthAction(c *gin.Context, userID *models.ID, action models.AuditAction, success bool, errorMsg string) {
 	s.LogAction(c, userID, action, models.AuditResourceAuth, nil, nil, success, errorMsg)
 }
 
 // LogUserAction logs user management actions
 func (s *AuditService) LogUserAction(c *gin.Context, userID uint, action models.AuditAction, targetUserID uint, details interface{}, success bool, errorMsg string) {
-	s.LogAction(c, &userID, action, models.AuditResourceUser, &targetUserID, details, success, errorMsg)
+	s.LogAction(c, models.IDPtr(&userID), action, models.AuditResourceUser, models.IDPtr(&targetUserID), details, success, errorMsg)
 }
 
 // LogProfileAction logs profile-related actions
 func (s *AuditService) LogProfileAction(c *gin.Context, userID uint, action models.AuditAction, details interface{}, success bool, errorMsg string) {
-	s.LogAction(c, &userID, action, models.AuditResourceProfile, &userID, details, success, errorMsg)
+	s.LogAction(c, models.IDPtr(&userID), action, models.AuditResourceProfile, models.IDPtr(&userID), details, success, errorMsg)
 }
 
 // GetLogs retrieves audit logs with filters
 func (s *AuditService) GetLogs(filter *repository.AuditLogFilter) ([]models.AuditLog, int64, error) {
-	return s.repo.List(filter)
+	if s.reader == nil {
+		return nil, 0, ErrAuditReadsUnsupported
+	}
+	return s.reader.List(filter)
 }
 
 // GetLogByID retrieves a single audit log
 func (s *AuditService) GetLogByID(id uint) (*models.AuditLog, error) {
-	return s.repo.GetByID(id)
+	if s.reader == nil {
+		return nil, ErrAuditReadsUnsupported
+	}
+	return s.reader.GetByID(id)
 }
 
 // GetRecentByUser retrieves recent logs for a user
 func (s *AuditService) GetRecentByUser(userID uint, limit int) ([]models.AuditLog, error) {
-	return s.repo.GetRecentByUser(userID, limit)
+	if s.reader == nil {
+		return nil, ErrAuditReadsUnsupported
+	}
+	return s.reader.GetRecentByUser(userID, limit)
+}
+
+// ListForTarget retrieves the logs about a user, newest first
+func (s *AuditService) ListForTarget(userID uint, before *time.Time, limit int) ([]models.AuditLog, error) {
+	if s.reader == nil {
+		return nil, ErrAuditReadsUnsupported
+	}
+	return s.reader.ListForTarget(userID, before, limit)
+}
+
+// GetUserAuthSummary returns a user's login statistics, cached for authSummaryTTL
+func (s *AuditService) GetUserAuthSummary(userID uint) (*models.UserAuthSummary, error) {
+	if s.reader == nil {
+		return nil, ErrAuditReadsUnsupported
+	}
+	if cached, ok := s.authSummaries.Load(userID); ok && time.Now().Before(cached.(cachedAuthSummary).expires) {
+		return cached.(cachedAuthSummary).summary, nil
+	}
+
+	summary, err := s.reader.GetUserAuthSummary(userID)
+	if err != nil {
+		return nil, err
+	}
+	s.authSummaries.Store(userID, cachedAuthSummary{summary: summary, expires: time.Now().Add(authSummaryTTL)})
+	return summary, nil
 }
 
 // GetFailedLoginAttempts gets failed login count from an IP
 func (s *AuditService) GetFailedLoginAttempts(ipAddress string, since time.Time) (int64, error) {
-	return s.repo.GetFailedLoginAttempts(ipAddress, since)
+	if s.reader == nil {
+		return 0, ErrAuditReadsUnsupported
+	}
+	return s.reader.GetFailedLoginAttempts(ipAddress, since)
 }
 
 // GetStats retrieves audit log statistics
 func (s *AuditService) GetStats() (map[string]interface{}, error) {
-	return s.repo.GetStats()
+	if s.reader == nil {
+		return nil, ErrAuditReadsUnsupported
+	}
+	return s.reader.GetStats()
 }
 
 // CleanupOldLogs deletes logs older than the retention period
 func (s *AuditService) CleanupOldLogs(retentionDays int) (int64, error) {
+	if s.reader == nil {
+		return 0, ErrAuditReadsUnsupported
+	}
 	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
-	deleted, err := s.repo.DeleteOlderThan(cutoffDate)
+	deleted, err := s.reader.DeleteOlderThan(cutoffDate)
 	if err != nil {
 		logger.Error("Failed to cleanup old audit logs", "error", err)
 		return 0, err
@@ -104,22 +212,3 @@ func (s *AuditService) CleanupOldLogs(retentionDays int) (int64, error) {
 	logger.Info("Cleaned up old audit logs", "deleted", deleted, "cutoff_date", cutoffDate)
 	return deleted, nil
 }
-
-// getClientIP extracts the real client IP from the request
-func (s *AuditService) getClientIP(c *gin.Context) string {
-	// Try X-Forwarded-For header first (for proxies/load balancers)
-	xff := c.GetHeader("X-Forwarded-For")
-	if xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		return xff
-	}
-
-	// Try X-Real-IP header
-	xri := c.GetHeader("X-Real-IP")
-	if xri != "" {
-		return xri
-	}
-
-	// Fall back to RemoteAddr
-	return c.ClientIP()
-}
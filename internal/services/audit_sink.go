@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// ErrAuditReadsUnsupported is returned by query methods when no configured sink supports reads
+var ErrAuditReadsUnsupported = errors.New("audit log queries are not supported by the configured sinks")
+
+// AuditSink receives audit log entries.
+// *repository.AuditLogRepository is the database implementation.
+type AuditSink interface {
+	Create(log *models.AuditLog) error
+}
+
+// AuditReader is implemented by sinks that can also answer audit queries
+type AuditReader interface {
+	List(filter *repository.AuditLogFilter) ([]models.AuditLog, int64, error)
+	GetByID(id uint) (*models.AuditLog, error)
+	GetRecentByUser(userID uint, limit int) ([]models.AuditLog, error)
+	ListForTarget(userID uint, before *time.Time, limit int) ([]models.AuditLog, error)
+	GetUserAuthSummary(userID uint) (*models.UserAuthSummary, error)
+	GetFailedLoginAttempts(ipAddress string, since time.Time) (int64, error)
+	DeleteOlderThan(date time.Time) (int64, error)
+	GetStats() (map[string]interface{}, error)
+}
+
+// readerOf returns the reader behind a sink, or nil if it cannot be queried
+func readerOf(sink AuditSink) AuditReader {
+	switch s := sink.(type) {
+	case AuditReader:
+		return s
+	case *MultiSink:
+		return s.Reader()
+	default:
+		return nil
+	}
+}
+
+// MultiSink fans entries out to a primary sink and any number of secondary sinks.
+// Only the primary write determines success; secondary failures are logged.
+type MultiSink struct {
+	primary     AuditSink
+	secondaries []AuditSink
+}
+
+// NewMultiSink creates a fan-out sink
+func NewMultiSink(primary AuditSink, secondaries ...AuditSink) *MultiSink {
+	return &MultiSink{
+		primary:     primary,
+		secondaries: secondaries,
+	}
+}
+
+// Create writes the entry to the primary sink, then to every secondary sink
+func (m *MultiSink) Create(log *models.AuditLog) error {
+	if err := m.primary.Create(log); err != nil {
+		return err
+	}
+
+	for _, sink := range m.secondaries {
+		if err := sink.Create(log); err != nil {
+			logger.Warn("Secondary audit sink failed", "sink", fmt.Sprintf("%T", sink), "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Reader returns the first sink (primary first) that supports queries, or nil
+func (m *MultiSink) Reader() AuditReader {
+	if reader := readerOf(m.primary); reader != nil {
+		return reader
+	}
+	for _, sink := range m.secondaries {
+		if reader := readerOf(sink); reader != nil {
+			return reader
+		}
+	}
+	return nil
+}
+
+// JSONLSink writes one JSON object per line, e.g. to stdout or a file tailed by a log pipeline
+type JSONLSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLSink creates a sink writing JSON lines to w
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{w: w}
+}
+
+// Create encodes the entry as a single JSON line
+func (s *JSONLSink) Create(log *models.AuditLog) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// HTTPSink POSTs each entry as JSON to a collector endpoint
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink creates a sink posting entries to url
+func NewHTTPSink(url string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Create sends the entry and treats any non-2xx response as a failure
+func (s *HTTPSink) Create(log *models.AuditLog) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build audit request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit log: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingSink collects entries and optionally fails every write
+type recordingSink struct {
+	logs []*models.AuditLog
+	err  error
+}
+
+func (s *recordingSink) Create(log *models.AuditLog) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.logs = append(s.logs, log)
+	return nil
+}
+
+func newAuditRepo(t *testing.T) *repository.AuditLogRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+	return repository.NewAuditLogRepository(db)
+}
+
+func TestMultiSink_SecondaryFailureDoesNotFailPrimary(t *testing.T) {
+	primary := &recordingSink{}
+	broken := &recordingSink{err: errors.New("pipeline down")}
+	healthy := &recordingSink{}
+
+	sink := NewMultiSink(primary, broken, healthy)
+	err := sink.Create(&models.AuditLog{Action: models.AuditActionLogin})
+
+	assert.NoError(t, err)
+	assert.Len(t, primary.logs, 1)
+	assert.Len(t, healthy.logs, 1, "Remaining secondaries still receive the entry")
+}
+
+func TestMultiSink_PrimaryFailureIsReturned(t *testing.T) {
+	secondary := &recordingSink{}
+	sink := NewMultiSink(&recordingSink{err: errors.New("db down")}, secondary)
+
+	err := sink.Create(&models.AuditLog{Action: models.AuditActionLogin})
+
+	assert.Error(t, err)
+	assert.Empty(t, secondary.logs, "Secondaries are skipped when the primary write fails")
+}
+
+func TestMultiSink_ReaderFromSecondary(t *testing.T) {
+	repo := newAuditRepo(t)
+	sink := NewMultiSink(NewJSONLSink(io.Discard), repo)
+
+	assert.Same(t, repo, sink.Reader())
+
+	// Reads go through the database sink even though it is not primary
+	service := NewAuditService(sink)
+	require.NoError(t, sink.Create(&models.AuditLog{Action: models.AuditActionLogin, CreatedAt: time.Now()}))
+	logs, total, err := service.GetLogs(&repository.AuditLogFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, logs, 1)
+}
+
+func TestAuditService_ReadsUnsupported(t *testing.T) {
+	service := NewAuditService(NewJSONLSink(io.Discard))
+
+	_, _, err := service.GetLogs(&repository.AuditLogFilter{})
+	assert.ErrorIs(t, err, ErrAuditReadsUnsupported)
+	_, err = service.GetStats()
+	assert.ErrorIs(t, err, ErrAuditReadsUnsupported)
+	_, err = service.CleanupOldLogs(30)
+	assert.ErrorIs(t, err, ErrAuditReadsUnsupported)
+	_, err = service.GetUserAuthSummary(1)
+	assert.ErrorIs(t, err, ErrAuditReadsUnsupported)
+}
+
+func TestAuditService_AuthSummaryIsCached(t *testing.T) {
+	repo := newAuditRepo(t)
+	service := NewAuditService(repo)
+	userID := uint(3)
+
+	require.NoError(t, repo.Create(&models.AuditLog{UserID: models.IDPtr(&userID), Action: models.AuditActionLogin, Success: true}))
+	first, err := service.GetUserAuthSummary(userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first.TotalLogins)
+
+	// A new login within the TTL is not visible yet
+	require.NoError(t, repo.Create(&models.AuditLog{UserID: models.IDPtr(&userID), Action: models.AuditActionLogin, Success: true}))
+	second, err := service.GetUserAuthSummary(userID)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestJSONLSink_WritesOneLinePerEntry(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLSink(&buf)
+
+	require.NoError(t, sink.Create(&models.AuditLog{Action: models.AuditActionLogin}))
+	require.NoError(t, sink.Create(&models.AuditLog{Action: models.AuditActionLogout}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var entry models.AuditLog
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, models.AuditActionLogout, entry.Action)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var received models.AuditLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	err := NewHTTPSink(server.URL, time.Second).Create(&models.AuditLog{Action: models.AuditActionRegister})
+	require.NoError(t, err)
+	assert.Equal(t, models.AuditActionRegister, received.Action)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	err = NewHTTPSink(failing.URL, time.Second).Create(&models.AuditLog{})
+	assert.Error(t, err, "Non-2xx responses are failures")
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/pkg/database"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// ErrCannotOffboardSelf is returned when a user tries to offboard their own account
+var ErrCannotOffboardSelf = errors.New("cannot offboard yourself")
+
+// SessionRevoker drops the live sessions (e.g. WebSocket connections) of a
+// user, telling the client why, and returns how many were dropped
+type SessionRevoker interface {
+	DisconnectUser(userID uint, reason string) int
+}
+
+// OffboardService revokes every kind of access a user has in one operation
+type OffboardService struct {
+	repo     *repository.UserRepository
+	sessions SessionRevoker // nil when no live session registry is available (e.g. CLI)
+}
+
+// NewOffboardService creates a new offboard service
+func NewOffboardService(repo *repository.UserRepository, sessions SessionRevoker) *OffboardService {
+	return &OffboardService{
+		repo:     repo,
+		sessions: sessions,
+	}
+}
+
+// OffboardUser deactivates the account, revokes its tokens, scrambles its password
+// and disconnects its live sessions. An error is returned only when offboarding
+// could not start; failures of individual steps are recorded in the report.
+func (s *OffboardService) OffboardUser(ctx context.Context, actorID, userID uint) (*models.OffboardReport, error) {
+	ctx = database.WithPrimary(ctx)
+	if actorID == userID {
+		return nil, ErrCannotOffboardSelf
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.OffboardReport{
+		UserID:  uint(user.ID),
+		Email:   user.Email,
+		Success: true,
+	}
+
+	// Database steps share one transaction, so they succeed or fail together
+	revokedAt := time.Now()
+	passwordHash, err := randomPasswordHash()
+	if err != nil {
+		report.AddStep(models.OffboardStepDeactivate, models.OffboardStepFailed, "not attempted: "+err.Error())
+		report.AddStep(models.OffboardStepRevokeTokens, models.OffboardStepFailed, "not attempted: "+err.Error())
+		report.AddStep(models.OffboardStepScramblePassword, models.OffboardStepFailed, err.Error())
+	} else if err := s.repo.RevokeAccess(ctx, uint(user.ID), passwordHash, revokedAt); err != nil {
+		detail := "rolled back: " + err.Error()
+		report.AddStep(models.OffboardStepDeactivate, models.OffboardStepFailed, detail)
+		report.AddStep(models.OffboardStepRevokeTokens, models.OffboardStepFailed, detail)
+		report.AddStep(models.OffboardStepScramblePassword, models.OffboardStepFailed, detail)
+	} else {
+		report.AddStep(models.OffboardStepDeactivate, models.OffboardStepOK, "")
+		report.AddStep(models.OffboardStepRevokeTokens, models.OffboardStepOK,
+			fmt.Sprintf("tokens issued before %s are rejected", revokedAt.UTC().Format(time.RFC3339)))
+		report.AddStep(models.OffboardStepScramblePassword, models.OffboardStepOK, "")
+	}
+
+	report.AddStep(models.OffboardStepRevokeAPIKeys, models.OffboardStepSkipped, "API keys are not supported")
+
+	if s.sessions == nil {
+		report.AddStep(models.OffboardStepDisconnectWS, models.OffboardStepSkipped, "no live session registry in this process")
+	} else {
+		closed := s.sessions.DisconnectUser(uint(user.ID), "account offboarded")
+		report.AddStep(models.OffboardStepDisconnectWS, models.OffboardStepOK, fmt.Sprintf("%d connection(s) closed", closed))
+	}
+
+	report.CompletedAt = time.Now()
+
+	logger.Info("User offboarded", "user_id", user.ID, "actor_id", actorID, "success", report.Success)
+
+	return report, nil
+}
+
+// randomPasswordHash returns the hash of a random password nobody knows
+func randomPasswordHash() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	hash, err := auth.HashPassword(hex.EncodeToString(buf))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hash, nil
+}
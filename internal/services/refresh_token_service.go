@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+)
+
+var (
+	// ErrRefreshTokenUnknown is returned by Rotate for tokens that were never
+	// recorded, including tokens issued before rotation was introduced
+	ErrRefreshTokenUnknown = errors.New("refresh token is not recorded")
+	// ErrRefreshTokenRevoked is returned by Rotate for tokens of a revoked family
+	ErrRefreshTokenRevoked = errors.New("refresh token family is revoked")
+)
+
+// RefreshTokenReuseError is returned by Rotate when an already rotated token
+// is presented again. Its family has been revoked by then.
+type RefreshTokenReuseError struct {
+	Reuse models.RefreshTokenReuse
+}
+
+func (e *RefreshTokenReuseError) Error() string {
+	return "refresh token reused; family " + e.Reuse.FamilyID + " revoked"
+}
+
+// RefreshTokenService issues refresh tokens and rotates them on every refresh
+type RefreshTokenService struct {
+	jwtManager *auth.JWTManager
+	repo       *repository.RefreshTokenRepository
+}
+
+// NewRefreshTokenService creates a new refresh token service
+func NewRefreshTokenService(jwtManager *auth.JWTManager, repo *repository.RefreshTokenRepository) *RefreshTokenService {
+	return &RefreshTokenService{jwtManager: jwtManager, repo: repo}
+}
+
+// Issue generates a refresh token for user that starts a new family
+func (s *RefreshTokenService) Issue(ctx context.Context, user *models.User) (string, error) {
+	token, record, err := s.newToken(user, "")
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Rotate exchanges the validated refresh token with claims for the next token
+// of its family; the presented token cannot be used again. Presenting a token
+// that was already rotated revokes the family and returns a
+// *RefreshTokenReuseError.
+func (s *RefreshTokenService) Rotate(ctx context.Context, claims *auth.JWTClaims, user *models.User) (string, error) {
+	if claims.ID == "" {
+		return "", ErrRefreshTokenUnknown
+	}
+	current, err := s.repo.GetByJTI(ctx, claims.ID)
+	if err != nil {
+		return "", err
+	}
+	if current == nil || current.UserID != uint(user.ID) {
+		return "", ErrRefreshTokenUnknown
+	}
+	if current.RevokedAt != nil {
+		return "", ErrRefreshTokenRevoked
+	}
+
+	token, next, err := s.newToken(user, current.FamilyID)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if current.RotatedAt == nil {
+		rotated, err := s.repo.Rotate(ctx, current.JTI, next, now)
+		if err != nil || rotated {
+			return token, err
+		}
+		// Another request rotated or revoked the token first
+	}
+
+	revoked, err := s.repo.RevokeFamily(ctx, current.FamilyID, now)
+	if err != nil {
+		return "", err
+	}
+	return "", &RefreshTokenReuseError{Reuse: models.RefreshTokenReuse{FamilyID: current.FamilyID, RevokedTokens: revoked}}
+}
+
+// newToken generates a refresh token for user and its record. An empty
+// familyID starts a new family named after the token.
+func (s *RefreshTokenService) newToken(user *models.User, familyID string) (string, *models.RefreshToken, error) {
+	token, claims, err := s.jwtManager.NewRefreshToken(uint(user.ID), user.Email, user.Role)
+	if err != nil {
+		return "", nil, err
+	}
+	if familyID == "" {
+		familyID = claims.ID
+	}
+	return token, &models.RefreshToken{
+		JTI:       claims.ID,
+		FamilyID:  familyID,
+		UserID:    uint(user.ID),
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
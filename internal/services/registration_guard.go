@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrChallengeFailed is returned when a registration's challenge token is missing or invalid
+var ErrChallengeFailed = errors.New("registration challenge failed")
+
+// RegistrationChallenge verifies the proof-of-work or captcha token sent with
+// a registration before the user is created. Implementations return an error
+// wrapping ErrChallengeFailed for tokens that do not pass.
+type RegistrationChallenge interface {
+	Verify(ctx context.Context, req *models.RegisterRequest, clientIP string) error
+}
+
+// NoChallenge accepts every registration
+type NoChallenge struct{}
+
+// Verify implements RegistrationChallenge
+func (NoChallenge) Verify(context.Context, *models.RegisterRequest, string) error {
+	return nil
+}
+
+// ProofOfWork requires challenge_token to be a nonce for which
+// sha256(lowercased email + ":" + nonce) starts with Difficulty zero bits.
+// Binding the work to the email makes a solved token useless for other
+// signups; each additional bit doubles the client's average work.
+type ProofOfWork struct {
+	Difficulty int
+}
+
+// Verify implements RegistrationChallenge
+func (p ProofOfWork) Verify(_ context.Context, req *models.RegisterRequest, _ string) error {
+	if req.ChallengeToken == "" {
+		return ErrChallengeFailed
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(req.Email) + ":" + req.ChallengeToken))
+	if leadingZeroBits(sum[:]) < p.Difficulty {
+		return ErrChallengeFailed
+	}
+	return nil
+}
+
+// leadingZeroBits counts the zero bits at the start of b
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
+
+// RegistrationGuardConfig configures the limits of POST /auth/register
+type RegistrationGuardConfig struct {
+	IPPerHour     int // Registrations per client IP and hour; <= 0 disables the limit
+	IPBurst       int // Registrations an IP may make at once
+	DomainPerHour int // Registrations per email domain and hour; <= 0 disables the limit
+	DomainBurst   int // Registrations a domain may get at once
+
+	// Challenge is verified after the limits pass; nil means NoChallenge
+	Challenge RegistrationChallenge
+}
+
+// RegistrationGuard applies the registration limits, which are stricter than
+// and independent of the global per-IP rate limiter, and the challenge hook
+type RegistrationGuard struct {
+	ip        *keyedLimiter
+	domain    *keyedLimiter
+	audits    *keyedLimiter
+	challenge RegistrationChallenge
+}
+
+// NewRegistrationGuard creates a registration guard
+func NewRegistrationGuard(config RegistrationGuardConfig) *RegistrationGuard {
+	challenge := config.Challenge
+	if challenge == nil {
+		challenge = NoChallenge{}
+	}
+	return &RegistrationGuard{
+		ip:        newHourlyLimiter(config.IPPerHour, config.IPBurst),
+		domain:    newHourlyLimiter(config.DomainPerHour, config.DomainBurst),
+		audits:    newKeyedLimiter(rate.Every(time.Minute), 1),
+		challenge: challenge,
+	}
+}
+
+// AllowIP takes one registration from the client IP's allowance. When none is
+// left it returns false and how long until the next one.
+func (g *RegistrationGuard) AllowIP(ip string) (bool, time.Duration) {
+	return g.ip.allow(ip)
+}
+
+// AllowDomain takes one registration from the allowance of the email's domain
+func (g *RegistrationGuard) AllowDomain(email string) (bool, time.Duration) {
+	return g.domain.allow(EmailDomain(email))
+}
+
+// Verify runs the challenge hook
+func (g *RegistrationGuard) Verify(ctx context.Context, req *models.RegisterRequest, clientIP string) error {
+	return g.challenge.Verify(ctx, req, clientIP)
+}
+
+// ShouldAudit reports whether a rejection for key should be written to the
+// audit log. Bots retry rejected requests, so at most one entry per key and
+// minute is written to keep them from flooding the log.
+func (g *RegistrationGuard) ShouldAudit(scope models.RegisterThrottleScope, key string) bool {
+	ok, _ := g.audits.allow(string(scope) + ":" + key)
+	return ok
+}
+
+// EmailDomain returns the lowercased domain of an email address
+func EmailDomain(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	return strings.ToLower(email[at+1:])
+}
+
+// keyedLimiterPruneAt is the number of keys above which idle limiters are dropped
+const keyedLimiterPruneAt = 10000
+
+// keyedLimiter keeps one token bucket per key. A nil keyedLimiter allows everything.
+type keyedLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	r        rate.Limit
+	b        int
+}
+
+// newHourlyLimiter returns a limiter of perHour events per key, or nil when perHour <= 0
+func newHourlyLimiter(perHour, burst int) *keyedLimiter {
+	if perHour <= 0 {
+		return nil
+	}
+	return newKeyedLimiter(rate.Every(time.Hour/time.Duration(perHour)), max(burst, 1))
+}
+
+func newKeyedLimiter(r rate.Limit, b int) *keyedLimiter {
+	return &keyedLimiter{limiters: make(map[string]*rate.Limiter), r: r, b: b}
+}
+
+// allow takes a token for key, or returns the delay until one is available
+func (l *keyedLimiter) allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		if len(l.limiters) >= keyedLimiterPruneAt {
+			l.prune(now)
+		}
+		limiter = rate.NewLimiter(l.r, l.b)
+		l.limiters[key] = limiter
+	}
+	l.mu.Unlock()
+
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// prune drops limiters whose bucket is full again; they behave like new ones
+func (l *keyedLimiter) prune(now time.Time) {
+	for key, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(l.b) {
+			delete(l.limiters, key)
+		}
+	}
+}
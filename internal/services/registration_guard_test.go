@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestRegistrationGuard_Limits(t *testing.T) {
+	guard := NewRegistrationGuard(RegistrationGuardConfig{IPPerHour: 6, IPBurst: 1, DomainPerHour: 1, DomainBurst: 2})
+
+	ok, _ := guard.AllowIP("198.51.100.1")
+	require.True(t, ok)
+	ok, retryAfter := guard.AllowIP("198.51.100.1")
+	assert.False(t, ok)
+	assert.InDelta(t, 10*time.Minute, retryAfter, float64(time.Second), "one registration every 10 minutes")
+
+	ok, _ = guard.AllowDomain("a@Example.com")
+	assert.True(t, ok)
+	ok, _ = guard.AllowDomain("b@example.COM")
+	assert.True(t, ok)
+	ok, _ = guard.AllowDomain("c@example.com")
+	assert.False(t, ok, "domains are compared case-insensitively")
+}
+
+func TestRegistrationGuard_ZeroConfigAllowsEverything(t *testing.T) {
+	guard := NewRegistrationGuard(RegistrationGuardConfig{})
+	for range 100 {
+		ok, _ := guard.AllowIP("198.51.100.1")
+		require.True(t, ok)
+		ok, _ = guard.AllowDomain("a@example.com")
+		require.True(t, ok)
+	}
+	assert.NoError(t, guard.Verify(context.Background(), &models.RegisterRequest{}, ""))
+}
+
+func TestRegistrationGuard_ShouldAuditOncePerMinute(t *testing.T) {
+	guard := NewRegistrationGuard(RegistrationGuardConfig{})
+	assert.True(t, guard.ShouldAudit(models.RegisterThrottleIP, "198.51.100.1"))
+	assert.False(t, guard.ShouldAudit(models.RegisterThrottleIP, "198.51.100.1"))
+	assert.True(t, guard.ShouldAudit(models.RegisterThrottleDomain, "198.51.100.1"), "scopes are separate")
+}
+
+func TestKeyedLimiter_PrunesIdleKeys(t *testing.T) {
+	limiter := newKeyedLimiter(rate.Inf, 1)
+	for i := range keyedLimiterPruneAt + 1 {
+		limiter.allow(fmt.Sprint(i))
+	}
+	assert.Len(t, limiter.limiters, 1, "full buckets are dropped once the map is large")
+}
+
+func TestProofOfWork(t *testing.T) {
+	pow := ProofOfWork{Difficulty: 12}
+	req := &models.RegisterRequest{Email: "Work@Example.com"}
+	assert.ErrorIs(t, pow.Verify(context.Background(), req, ""), ErrChallengeFailed, "missing token")
+
+	nonce := 0
+	for {
+		req.ChallengeToken = fmt.Sprint(nonce)
+		if pow.Verify(context.Background(), req, "") == nil {
+			break
+		}
+		nonce++
+	}
+	req.Email = "work@example.com"
+	assert.NoError(t, pow.Verify(context.Background(), req, ""), "the email is lowercased")
+	req.Email = "other@example.com"
+	assert.ErrorIs(t, pow.Verify(context.Background(), req, ""), ErrChallengeFailed, "tokens are bound to the email")
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	assert.Equal(t, 0, leadingZeroBits([]byte{0x80}))
+	assert.Equal(t, 11, leadingZeroBits([]byte{0x00, 0x10, 0xff}))
+	assert.Equal(t, 16, leadingZeroBits([]byte{0x00, 0x00}))
+}
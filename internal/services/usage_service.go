@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// ErrInvalidUsageWindow is returned for report windows outside 1..RetentionDays
+var ErrInvalidUsageWindow = errors.New("invalid usage window")
+
+// UsageConfig configures per-user API usage accounting
+type UsageConfig struct {
+	Bucket        time.Duration // Aggregation granularity; must divide 24h, e.g. 1h or 24h
+	RetentionDays int           // Older buckets are pruned; also the longest report window
+	FlushInterval time.Duration // How often counters are written to the database
+}
+
+// DefaultUsageConfig returns the settings used for zero values
+func DefaultUsageConfig() UsageConfig {
+	return UsageConfig{
+		Bucket:        24 * time.Hour,
+		RetentionDays: 90,
+		FlushInterval: 10 * time.Second,
+	}
+}
+
+// Validate rejects buckets that do not split days evenly, so every report
+// day starts on a bucket boundary
+func (c UsageConfig) Validate() error {
+	if c.Bucket < 0 || c.Bucket > 24*time.Hour || (c.Bucket > 0 && (24*time.Hour)%c.Bucket != 0) {
+		return fmt.Errorf("usage bucket %s must divide 24h", c.Bucket)
+	}
+	return nil
+}
+
+// usageKey identifies one user's counters in one bucket
+type usageKey struct {
+	userID uint
+	bucket int64 // Bucket start, Unix seconds
+}
+
+// UsageService counts requests per user in memory and writes the counters to
+// the database in the background, so counting never adds a query to a request
+type UsageService struct {
+	repo   *repository.APIUsageRepository
+	config UsageConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[usageKey]models.UsageCounts
+
+	running   atomic.Bool
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewUsageService creates a usage service; zero config values fall back to DefaultUsageConfig
+func NewUsageService(repo *repository.APIUsageRepository, config UsageConfig) *UsageService {
+	defaults := DefaultUsageConfig()
+	if config.Bucket <= 0 {
+		config.Bucket = defaults.Bucket
+	}
+	if config.RetentionDays <= 0 {
+		config.RetentionDays = defaults.RetentionDays
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	return &UsageService{
+		repo:    repo,
+		config:  config,
+		now:     time.Now,
+		pending: make(map[usageKey]models.UsageCounts),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Config returns the effective configuration
+func (s *UsageService) Config() UsageConfig {
+	return s.config
+}
+
+// bucketStart returns the start of the bucket containing t, in UTC
+func (s *UsageService) bucketStart(t time.Time) time.Time {
+	return t.UTC().Truncate(s.config.Bucket)
+}
+
+// Record counts one finished request of userID with the response status
+func (s *UsageService) Record(userID uint, status int) {
+	key := usageKey{userID: userID, bucket: s.bucketStart(s.now()).Unix()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := s.pending[key]
+	counts.Requests++
+	switch {
+	case status == http.StatusTooManyRequests:
+		counts.RateLimited++
+	case status >= http.StatusBadRequest:
+		counts.Errors++
+	}
+	s.pending[key] = counts
+}
+
+// Flush writes the counters recorded since the last flush. On failure they
+// are kept and retried by the next flush.
+func (s *UsageService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]models.UsageCounts)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	rows := make([]models.APIUsage, 0, len(pending))
+	for key, counts := range pending {
+		rows = append(rows, models.APIUsage{
+			UserID:      key.userID,
+			BucketStart: time.Unix(key.bucket, 0).UTC(),
+			UsageCounts: counts,
+		})
+	}
+	if err := s.repo.Increment(ctx, rows); err != nil {
+		s.mu.Lock()
+		for key, counts := range pending {
+			merged := s.pending[key]
+			merged.Add(counts)
+			s.pending[key] = merged
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// prune deletes buckets older than the retention period
+func (s *UsageService) prune(ctx context.Context) {
+	cutoff := s.bucketStart(s.now()).AddDate(0, 0, -s.config.RetentionDays)
+	pruned, err := s.repo.PruneBefore(ctx, cutoff)
+	if err != nil {
+		logger.Error("Failed to prune API usage", "error", err)
+		return
+	}
+	if pruned > 0 {
+		logger.Info("Pruned API usage buckets", "count", pruned)
+	}
+}
+
+// Run flushes counters every FlushInterval and prunes expired buckets daily
+// until Close is called
+func (s *UsageService) Run() {
+	s.running.Store(true)
+	defer close(s.stopped)
+
+	flush := time.NewTicker(s.config.FlushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(24 * time.Hour)
+	defer prune.Stop()
+
+	s.prune(context.Background())
+	for {
+		select {
+		case <-flush.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := s.Flush(ctx); err != nil {
+				logger.Error("Failed to flush API usage", "error", err)
+			}
+			cancel()
+		case <-prune.C:
+			s.prune(context.Background())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops Run, if it was started, and writes the remaining counters
+func (s *UsageService) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.done) })
+	if s.running.Load() {
+		select {
+		case <-s.stopped:
+		case <-ctx.Done():
+		}
+	}
+	return s.Flush(ctx)
+}
+
+// Report returns the user's usage over the last days days, today included,
+// with one entry per bucket. Counters not yet flushed are included.
+func (s *UsageService) Report(ctx context.Context, userID uint, days int) (*models.UsageReport, error) {
+	if days < 1 || days > s.config.RetentionDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidUsageWindow, s.config.RetentionDays)
+	}
+
+	now := s.now().UTC()
+	since := now.Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	rows, err := s.repo.ListForUser(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]models.UsageCounts, len(rows))
+	for _, row := range rows {
+		c := counts[row.BucketStart.Unix()]
+		c.Add(row.UsageCounts)
+		counts[row.BucketStart.Unix()] = c
+	}
+	s.mu.Lock()
+	for key, pending := range s.pending {
+		if key.userID == userID {
+			c := counts[key.bucket]
+			c.Add(pending)
+			counts[key.bucket] = c
+		}
+	}
+	s.mu.Unlock()
+
+	report := &models.UsageReport{
+		UserID:  userID,
+		Days:    days,
+		Bucket:  s.config.Bucket.String(),
+		Buckets: []models.UsageBucket{},
+	}
+	last := s.bucketStart(now)
+	for start := since; !start.After(last); start = start.Add(s.config.Bucket) {
+		bucket := models.UsageBucket{Start: start, UsageCounts: counts[start.Unix()]}
+		report.Buckets = append(report.Buckets, bucket)
+		report.Totals.Add(bucket.UsageCounts)
+	}
+	return report, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newUsageService returns a UsageService over a fresh database whose clock
+// reads *now, so tests can move time forward
+func newUsageService(tb testing.TB, cfg UsageConfig, now *time.Time) *UsageService {
+	tb.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tb.TempDir(), "usage.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(tb, err)
+	require.NoError(tb, db.AutoMigrate(&models.APIUsage{}))
+	sqlDB, err := db.DB()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	service := NewUsageService(repository.NewAPIUsageRepository(db), cfg)
+	service.now = func() time.Time { return *now }
+	return service
+}
+
+func TestUsageService_ReportsDailyCounts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	service := newUsageService(t, UsageConfig{}, &now)
+
+	// Yesterday, flushed
+	now = now.Add(-24 * time.Hour)
+	service.Record(1, http.StatusOK)
+	service.Record(1, http.StatusNotFound)
+	require.NoError(t, service.Flush(ctx))
+
+	// Today: one flushed, the rest still in memory
+	now = now.Add(24 * time.Hour)
+	service.Record(1, http.StatusOK)
+	require.NoError(t, service.Flush(ctx))
+	service.Record(1, http.StatusTooManyRequests)
+	service.Record(1, http.StatusInternalServerError)
+	service.Record(2, http.StatusOK) // Other users are not reported
+
+	report, err := service.Report(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "24h0m0s", report.Bucket)
+	assert.Equal(t, []models.UsageBucket{
+		{Start: time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), UsageCounts: models.UsageCounts{Requests: 2, Errors: 1}},
+		{Start: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), UsageCounts: models.UsageCounts{Requests: 3, Errors: 1, RateLimited: 1}},
+	}, report.Buckets)
+	assert.Equal(t, models.UsageCounts{Requests: 5, Errors: 2, RateLimited: 1}, report.Totals)
+
+	// Flushing again adds to the stored bucket
+	require.NoError(t, service.Flush(ctx))
+	flushed, err := service.Report(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, report, flushed)
+}
+
+func TestUsageService_HourlyBuckets(t *testing.T) {
+	now := time.Date(2026, 10, 15, 2, 15, 0, 0, time.UTC)
+	service := newUsageService(t, UsageConfig{Bucket: time.Hour}, &now)
+	service.Record(1, http.StatusOK)
+
+	report, err := service.Report(context.Background(), 1, 1)
+	require.NoError(t, err)
+	require.Len(t, report.Buckets, 3, "00:00, 01:00 and the current 02:00 bucket")
+	assert.Equal(t, int64(1), report.Buckets[2].Requests)
+}
+
+func TestUsageService_RejectsWindowsOutsideRetention(t *testing.T) {
+	now := time.Now()
+	service := newUsageService(t, UsageConfig{RetentionDays: 30}, &now)
+
+	for _, days := range []int{0, -1, 31} {
+		_, err := service.Report(context.Background(), 1, days)
+		assert.ErrorIs(t, err, ErrInvalidUsageWindow, "days=%d", days)
+	}
+}
+
+func TestUsageService_PrunesExpiredBuckets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	service := newUsageService(t, UsageConfig{RetentionDays: 2}, &now)
+
+	now = now.AddDate(0, 0, -3)
+	service.Record(1, http.StatusOK)
+	require.NoError(t, service.Flush(ctx))
+	now = now.AddDate(0, 0, 3)
+	service.Record(1, http.StatusOK)
+	require.NoError(t, service.Flush(ctx))
+
+	service.prune(ctx)
+	rows, err := service.repo.ListForUser(ctx, 1, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), rows[0].BucketStart.UTC())
+}
+
+func TestUsageService_CloseFlushes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	service := newUsageService(t, UsageConfig{FlushInterval: time.Hour}, &now)
+	go service.Run()
+
+	service.Record(1, http.StatusOK)
+	require.NoError(t, service.Close(ctx))
+
+	rows, err := service.repo.ListForUser(ctx, 1, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(1), rows[0].Requests)
+}
+
+func TestUsageConfig_Validate(t *testing.T) {
+	for bucket, valid := range map[time.Duration]bool{
+		0:                true, // Default
+		time.Hour:        true,
+		6 * time.Hour:    true,
+		24 * time.Hour:   true,
+		7 * time.Hour:    false,
+		48 * time.Hour:   false,
+		-time.Hour:       false,
+		90 * time.Minute: true,
+	} {
+		err := UsageConfig{Bucket: bucket}.Validate()
+		assert.Equal(t, valid, err == nil, "bucket %s", bucket)
+	}
+}
+
+// BenchmarkUsageRecord measures the cost added to every authenticated request
+func BenchmarkUsageRecord(b *testing.B) {
+	now := time.Now()
+	service := newUsageService(b, UsageConfig{}, &now)
+	b.RunParallel(func(pb *testing.PB) {
+		var userID uint
+		for pb.Next() {
+			userID = (userID + 1) % 100
+			service.Record(userID, http.StatusOK)
+		}
+	})
+}
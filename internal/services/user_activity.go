@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+)
+
+// MaxActivityLimit is the largest page of a user's activity feed
+const MaxActivityLimit = 100
+
+// ErrInvalidActivityLimit is returned for page sizes outside 1..MaxActivityLimit
+var ErrInvalidActivityLimit = errors.New("invalid activity limit")
+
+// UserActivityService builds the activity feed of a user from the audit log
+type UserActivityService struct {
+	audit *AuditService
+	users *repository.UserRepository
+}
+
+// NewUserActivityService creates a new user activity service
+func NewUserActivityService(audit *AuditService, users *repository.UserRepository) *UserActivityService {
+	return &UserActivityService{audit: audit, users: users}
+}
+
+// GetUserActivity returns up to limit audit logs about userID created before
+// before (any time when nil), newest first. The acting users of all entries
+// are resolved with a single query.
+func (s *UserActivityService) GetUserActivity(ctx context.Context, userID uint, before *time.Time, limit int) (*models.UserActivity, error) {
+	if limit < 1 || limit > MaxActivityLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidActivityLimit, MaxActivityLimit)
+	}
+
+	logs, err := s.audit.ListForTarget(userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	actors, err := s.actors(ctx, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &models.UserActivity{
+		UserID:  userID,
+		Entries: make([]models.UserActivityEntry, len(logs)),
+	}
+	for i, log := range logs {
+		activity.Entries[i] = models.UserActivityEntry{AuditLog: log}
+		if log.UserID != nil {
+			activity.Entries[i].Actor = actors[*log.UserID]
+		}
+	}
+	if len(logs) == limit {
+		next := logs[len(logs)-1].CreatedAt
+		activity.NextBefore = &next
+	}
+	return activity, nil
+}
+
+// actors looks up the distinct users who wrote logs
+func (s *UserActivityService) actors(ctx context.Context, logs []models.AuditLog) (map[models.ID]*models.AuditActor, error) {
+	actors := make(map[models.ID]*models.AuditActor)
+	var ids []uint
+	for _, log := range logs {
+		if log.UserID == nil {
+			continue
+		}
+		if _, seen := actors[*log.UserID]; !seen {
+			actors[*log.UserID] = nil
+			ids = append(ids, uint(*log.UserID))
+		}
+	}
+	if len(ids) == 0 {
+		return actors, nil
+	}
+
+	users, err := s.users.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		actors[user.ID] = &models.AuditActor{ID: user.ID, Name: user.Name, Email: user.Email}
+	}
+	return actors, nil
+}
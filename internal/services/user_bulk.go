@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/database"
+)
+
+var (
+	// ErrDeleteSelf is returned when the acting user is in a bulk delete
+	ErrDeleteSelf = errors.New("cannot delete yourself")
+
+	// ErrRoleChangeNotAllowed is returned when a user below superadmin changes roles
+	ErrRoleChangeNotAllowed = errors.New("only superadmin can change user roles")
+
+	// Reported for single users of a bulk change
+	errBulkUserNotFound = errors.New("user not found")
+	errBulkDuplicateID  = errors.New("user is listed more than once")
+	errBulkRanksAbove   = errors.New("cannot delete a user ranking above you")
+	errBulkDemoteSelf   = errors.New("cannot demote yourself")
+)
+
+// bulkChange tracks the outcome of every ID of a bulk change by its
+// position in the request. A repeated ID fails; its first occurrence stands
+// for the user.
+type bulkChange struct {
+	ids   []uint
+	errs  []error
+	first map[uint]int
+}
+
+func newBulkChange(ids []uint) *bulkChange {
+	b := &bulkChange{ids: ids, errs: make([]error, len(ids)), first: make(map[uint]int, len(ids))}
+	for i, id := range ids {
+		if _, ok := b.first[id]; ok {
+			b.errs[i] = errBulkDuplicateID
+			continue
+		}
+		b.first[id] = i
+	}
+	return b
+}
+
+// fail records that the user id is not changed
+func (b *bulkChange) fail(id uint, err error) {
+	b.errs[b.first[id]] = err
+}
+
+// pending returns the users that have not failed, once each
+func (b *bulkChange) pending() []uint {
+	var pending []uint
+	for i, id := range b.ids {
+		if b.errs[i] == nil {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// result reports the pending users in done as succeeded and the others as
+// not found, in request order
+func (b *bulkChange) result(done []uint) *models.BulkUserResult {
+	changed := make(map[uint]bool, len(done))
+	for _, id := range done {
+		changed[id] = true
+	}
+	result := &models.BulkUserResult{Succeeded: []models.ID{}, Failed: []models.BulkUserFailure{}}
+	for i, id := range b.ids {
+		err := b.errs[i]
+		if err == nil && !changed[id] {
+			err = errBulkUserNotFound
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, models.BulkUserFailure{ID: models.ID(id), Error: err.Error()})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, models.ID(id))
+	}
+	return result
+}
+
+// usersByID loads the users of ids from the primary database
+func (s *UserService) usersByID(ctx context.Context, ids []uint) (map[uint]*models.User, error) {
+	users, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.User, len(users))
+	for _, user := range users {
+		byID[uint(user.ID)] = user
+	}
+	return byID, nil
+}
+
+// BulkDelete soft deletes the users with the given IDs in one transaction.
+// The acting user may not be among them, which fails the whole request with
+// ErrDeleteSelf. Users that do not exist, are listed twice or rank above
+// actorRole fail on their own and are reported in the result.
+func (s *UserService) BulkDelete(ctx context.Context, actorID uint, actorRole models.Role, ids []uint) (*models.BulkUserResult, error) {
+	ctx = database.WithPrimary(ctx)
+	if slices.Contains(ids, actorID) {
+		return nil, ErrDeleteSelf
+	}
+
+	change := newBulkChange(ids)
+	users, err := s.usersByID(ctx, change.pending())
+	if err != nil {
+		return nil, err
+	}
+	var allowed []uint
+	for _, id := range change.pending() {
+		if user, ok := users[id]; ok && !actorRole.AtLeast(user.Role) {
+			change.fail(id, errBulkRanksAbove)
+			continue
+		}
+		allowed = append(allowed, id) // Missing users are reported by result
+	}
+
+	var deleted []uint
+	if len(allowed) > 0 {
+		if deleted, err = s.repo.DeleteByIDs(ctx, allowed); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range deleted {
+		s.InvalidateUsers(id)
+	}
+	return change.result(deleted), nil
+}
+
+// BulkUpdateRoles sets the role of several users in one transaction. Only a
+// superadmin may call it, and they may not demote themselves. Users that do
+// not exist, are listed twice or are the demoted actor fail on their own.
+// The changes made are returned with the result, in request order.
+func (s *UserService) BulkUpdateRoles(ctx context.Context, actorID uint, actorRole models.Role, assignments []models.RoleAssignment) (*models.BulkUserResult, []models.RoleChange, error) {
+	ctx = database.WithPrimary(ctx)
+	if actorRole != models.RoleSuperAdmin {
+		return nil, nil, ErrRoleChangeNotAllowed
+	}
+
+	ids := make([]uint, len(assignments))
+	roles := make(map[uint]models.Role, len(assignments))
+	for i, assignment := range assignments {
+		if !assignment.Role.IsValid() {
+			return nil, nil, models.ErrInvalidRole
+		}
+		ids[i] = uint(assignment.ID)
+		if _, ok := roles[ids[i]]; !ok {
+			roles[ids[i]] = assignment.Role // Later assignments of the user fail
+		}
+	}
+
+	change := newBulkChange(ids)
+	users, err := s.usersByID(ctx, change.pending())
+	if err != nil {
+		return nil, nil, err
+	}
+	allowed := make(map[uint]models.Role, len(users))
+	for _, id := range change.pending() {
+		if id == actorID && roles[id] != models.RoleSuperAdmin {
+			change.fail(id, errBulkDemoteSelf)
+			continue
+		}
+		if _, ok := users[id]; ok {
+			allowed[id] = roles[id]
+		}
+	}
+
+	var updated []uint
+	if len(allowed) > 0 {
+		if updated, err = s.repo.UpdateRoles(ctx, allowed); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, id := range updated {
+		s.InvalidateUsers(id)
+	}
+
+	result := change.result(updated)
+	changes := make([]models.RoleChange, 0, len(result.Succeeded))
+	for _, id := range result.Succeeded {
+		changes = append(changes, models.RoleChange{UserID: id, From: users[uint(id)].Role, To: roles[uint(id)]})
+	}
+	return result, changes, nil
+}
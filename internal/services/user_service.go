@@ -2,28 +2,138 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"math"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"Go-Lang-project-01/internal/auth"
 	"Go-Lang-project-01/internal/models"
 	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/pkg/cache"
+	"Go-Lang-project-01/pkg/database"
 )
 
-// UserService handles business logic with GORM
+// ErrRoleNotAllowed is returned when creating a user whose role ranks above the creator's
+var ErrRoleNotAllowed = errors.New("cannot create a user with a higher role than your own")
+
+// ErrUserNotFound is returned by GetUserByEmail when no user has the email
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrDuplicateInBatch is returned for a user of a batch whose email an
+// earlier user of the same batch already has
+var ErrDuplicateInBatch = fmt.Errorf("%w earlier in the batch", repository.ErrDuplicateEmail)
+
+// BatchConfig bounds and observes BatchCreateUsers
+type BatchConfig struct {
+	Concurrency   int                              // Passwords hashed at once
+	InsertTimeout time.Duration                    // Budget for the transaction inserting a whole batch; a closer caller deadline wins
+	OnBatch       func(size int)                   // Optional, e.g. a Prometheus histogram
+	OnItem        func(d time.Duration, err error) // Optional; called once per user, err is nil on success
+}
+
+// DefaultBatchConfig returns the settings used by NewUserService
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		Concurrency:   5,
+		InsertTimeout: 30 * time.Second,
+	}
+}
+
+// UserRepositoryInterface is the user storage UserService works on.
+// *repository.UserRepository is the database implementation.
+type UserRepositoryInterface interface {
+	Create(ctx context.Context, user *models.User) error
+	GetAll(ctx context.Context) ([]*models.User, error)
+	GetAllPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, int64, error)
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	ExistingEmails(ctx context.Context, emails []string) ([]string, error)
+	Update(ctx context.Context, user *models.User) error
+	UpdateRoles(ctx context.Context, roles map[uint]models.Role) ([]uint, error)
+	ChangePassword(ctx context.Context, id uint, passwordHash string, revokedAt time.Time) (int64, error)
+	Delete(ctx context.Context, id uint) error
+	ListDeleted(ctx context.Context, page, limit int) ([]*models.User, int64, error)
+	Restore(ctx context.Context, id uint, restoredAt time.Time) error
+	Purge(ctx context.Context, id uint) error
+	DeleteByIDs(ctx context.Context, ids []uint) ([]uint, error)
+	BatchCreate(ctx context.Context, users []*models.User) error
+	Count(ctx context.Context) (int64, error)
+	CountActive(ctx context.Context) (int64, error)
+	CountByRole(ctx context.Context) (map[models.Role]int64, error)
+	CountCreatedSince(ctx context.Context, since time.Time) (int64, error)
+}
+
+// UserService handles business logic with GORM.
+// Methods that read a row before writing it read from the primary database.
 type UserService struct {
-	repo *repository.UserRepository
+	repo  UserRepositoryInterface
+	batch BatchConfig
+
+	// Set by EnableCache; nil when reads always go to the database
+	users *cache.Cache[uint, models.User]
+	stats *cache.Cache[string, userStats]
+}
+
+// userStats are the counts cached for GetUserStats
+type userStats struct {
+	total, active, newLastWeek int64
+	byRole                     map[models.Role]int64
 }
 
 // NewUserService creates a new GORM user service
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo UserRepositoryInterface) *UserService {
+	return NewUserServiceWithConfig(repo, DefaultBatchConfig())
+}
+
+// NewUserServiceWithConfig creates a user service with custom batch settings.
+// Zero values fall back to DefaultBatchConfig.
+func NewUserServiceWithConfig(repo UserRepositoryInterface, batch BatchConfig) *UserService {
+	defaults := DefaultBatchConfig()
+	if batch.Concurrency <= 0 {
+		batch.Concurrency = defaults.Concurrency
+	}
+	if batch.InsertTimeout <= 0 {
+		batch.InsertTimeout = defaults.InsertTimeout
+	}
 	return &UserService{
-		repo: repo,
+		repo:  repo,
+		batch: batch,
 	}
 }
 
+// EnableCache caches GetUserByID and GetUserStats for ttl. Writes through
+// the service invalidate the entries they affect at once; register
+// InvalidateUsers with UserRepository.OnChange so writes that bypass the
+// service (GraphQL, offboarding, registration) do too. Writes of other
+// instances show up after ttl at the latest, so decisions about a user, such
+// as permission checks, must read it with GetUserForUpdate.
+// Call it before the service is used.
+func (s *UserService) EnableCache(ttl time.Duration) {
+	s.users = cache.New[uint, models.User](ttl)
+	s.stats = cache.New[string, userStats](ttl)
+}
+
+// InvalidateUsers drops the cached users ids, or every cached user when no
+// IDs are given, and the cached stats
+func (s *UserService) InvalidateUsers(ids ...uint) {
+	if s.users == nil {
+		return
+	}
+	if len(ids) == 0 {
+		s.users.Clear()
+	}
+	for _, id := range ids {
+		s.users.Delete(id)
+	}
+	s.stats.Clear()
+}
+
 // GetAllUsers returns all users
 func (s *UserService) GetAllUsers(ctx context.Context) ([]*models.User, error) {
 	return s.repo.GetAll(ctx)
@@ -54,52 +164,109 @@ func (s *UserService) GetAllUsersPaginated(ctx context.Context, query models.Pag
 		return nil, models.PaginationMeta{}, err
 	}
 
-	// Calculate pagination metadata
-	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
+	return users, models.NewPaginationMeta(query.Page, query.Limit, total), nil
+}
 
-	meta := models.PaginationMeta{
-		Page:       query.Page,
-		Limit:      query.Limit,
-		Total:      total,
-		TotalPages: totalPages,
+// GetUserByID returns a user by ID
+func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	if s.users == nil {
+		return s.repo.GetByID(ctx, id)
 	}
+	user, err := s.users.GetOrLoad(id, func() (models.User, error) {
+		user, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return models.User{}, err
+		}
+		return *user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil // A copy, so callers cannot change the cached user
+}
 
-	return users, meta, nil
+// GetUserForUpdate returns a user by ID from the primary database, never
+// from the cache. Use it when the user decides a write, e.g. for rank checks
+// or to verify a password.
+func (s *UserService) GetUserForUpdate(ctx context.Context, id uint) (*models.User, error) {
+	return s.repo.GetByID(database.WithPrimary(ctx), id)
 }
 
-// GetUserByID returns a user by ID
-func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
-	return s.repo.GetByID(ctx, id)
+// GetUserByEmail returns the user with exactly this email, or ErrUserNotFound
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
-// CreateUser creates a new user with validation
-func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+// CreateUser creates a new user with validation. The user gets req.Role
+// (default: user), which may not rank above creatorRole.
+func (s *UserService) CreateUser(ctx context.Context, creatorRole models.Role, req *models.CreateUserRequest) (*models.User, error) {
+	ctx = database.WithPrimary(ctx)
 	// Validation is now handled by Gin's validator
 	// Additional business logic validation can be added here
 
-	// Check if email already exists
+	role, err := assignableRole(creatorRole, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if email already exists; the unique index still catches
+	// concurrent requests that pass this check at the same time
 	existingUser, err := s.repo.GetByEmail(ctx, req.Email)
 	if err == nil && existingUser != nil {
-		return nil, errors.New("email already exists")
+		return nil, repository.ErrDuplicateEmail
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	// Create user
 	user := &models.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Age:      req.Age,
-		IsActive: true,
+		Name:        req.Name,
+		Email:       req.Email,
+		Password:    hashedPassword,
+		Age:         req.Age,
+		DateOfBirth: req.DateOfBirth.TimePtr(),
+		Role:        role,
+		IsActive:    true,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
-		return nil, err
+		return nil, duplicateEmailError(err)
 	}
+	s.InvalidateUsers(uint(user.ID))
 
 	return user, nil
 }
 
+// assignableRole returns the role of a new user, defaulting to user, or
+// ErrRoleNotAllowed if it ranks above creatorRole. Only superadmins can
+// therefore create superadmins.
+func assignableRole(creatorRole, requested models.Role) (models.Role, error) {
+	if requested == "" {
+		return models.RoleUser, nil
+	}
+	if !requested.IsValid() {
+		return "", models.ErrInvalidRole
+	}
+	if !creatorRole.AtLeast(requested) {
+		return "", fmt.Errorf("%w: %s cannot create %s", ErrRoleNotAllowed, creatorRole, requested)
+	}
+	return requested, nil
+}
+
 // UpdateUser updates an existing user
 func (s *UserService) UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error) {
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(id)
 	// Get existing user
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -113,131 +280,361 @@ func (s *UserService) UpdateUser(ctx context.Context, id uint, req *models.Updat
 	if req.Email != nil && *req.Email != "" {
 		// Check if new email already exists
 		existingUser, err := s.repo.GetByEmail(ctx, *req.Email)
-		if err == nil && existingUser != nil && existingUser.ID != id {
-			return nil, errors.New("email already exists")
+		if err == nil && existingUser != nil && uint(existingUser.ID) != id {
+			return nil, repository.ErrDuplicateEmail
 		}
 		user.Email = *req.Email
 	}
 	if req.Age != nil && *req.Age > 0 {
 		user.Age = *req.Age
+		user.DateOfBirth = nil
+	}
+	if req.DateOfBirth != nil {
+		user.DateOfBirth = req.DateOfBirth.TimePtr()
 	}
 
 	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, duplicateEmailError(err)
+	}
+
+	return user, nil
+}
+
+// duplicateEmailError strips the repository's wrapping from a taken email
+// error, so the message can be shown to clients. Other errors are returned as is.
+func duplicateEmailError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrDeletedUserEmail):
+		return repository.ErrDeletedUserEmail
+	case errors.Is(err, repository.ErrDuplicateEmail):
+		return repository.ErrDuplicateEmail
+	default:
+		return err
+	}
+}
+
+// SetUserActive activates or deactivates a user. Inactive users cannot log
+// in, and JWTAuth rejects their tokens; unlike DeleteUser, the account stays
+// visible and can be reactivated.
+func (s *UserService) SetUserActive(ctx context.Context, id uint, active bool) (*models.User, error) {
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(id)
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	if user.IsActive == active {
+		return user, nil
+	}
 
+	user.IsActive = active
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
 // DeleteUser deletes a user
 func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	defer s.InvalidateUsers(id)
 	return s.repo.Delete(ctx, id)
 }
 
-// BatchCreateUsers creates multiple users concurrently using goroutines
-func (s *UserService) BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) ([]*models.User, error) {
-	var (
-		wg      sync.WaitGroup
-		mu      sync.Mutex
-		users   []*models.User
-		errList []error
-	)
+// ListDeletedUsers returns a page of soft-deleted users, most recently deleted first
+func (s *UserService) ListDeletedUsers(ctx context.Context, query models.DeletedUserQuery) ([]models.DeletedUser, models.PaginationMeta, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 10
+	}
+
+	users, total, err := s.repo.ListDeleted(ctx, query.Page, query.Limit)
+	if err != nil {
+		return nil, models.PaginationMeta{}, err
+	}
+	deleted := make([]models.DeletedUser, len(users))
+	for i, user := range users {
+		deleted[i] = models.DeletedUser{User: user, DeletedAt: user.DeletedAt.Time}
+	}
+	return deleted, models.NewPaginationMeta(query.Page, query.Limit, total), nil
+}
+
+// RestoreUser undoes the soft delete of a user and returns the user. Tokens
+// issued before the restore stay rejected. Returns
+// repository.ErrDeletedUserNotFound unless the user is soft-deleted.
+func (s *UserService) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(id)
+	// Token iat claims have second precision; a login right after the restore must pass
+	if err := s.repo.Restore(ctx, id, time.Now().Truncate(time.Second)); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
 
-	// Create a channel to limit concurrent goroutines
-	semaphore := make(chan struct{}, 5) // Max 5 concurrent operations
+// PurgeUser permanently deletes a soft-deleted user. Returns
+// repository.ErrDeletedUserNotFound unless the user is soft-deleted.
+func (s *UserService) PurgeUser(ctx context.Context, id uint) error {
+	defer s.InvalidateUsers(id)
+	return s.repo.Purge(ctx, id)
+}
 
+// BatchCreateUsers creates multiple users concurrently using goroutines.
+// Nothing is created if any requested role ranks above creatorRole; otherwise
+// every user succeeds or fails on its own and is reported in request order.
+func (s *UserService) BatchCreateUsers(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest) (*models.BatchCreateUsersResult, error) {
 	for i, req := range requests {
-		wg.Add(1)
-		go func(index int, request *models.CreateUserRequest) {
-			defer wg.Done()
+		if _, err := assignableRole(creatorRole, req.Role); err != nil {
+			return nil, fmt.Errorf("user %d: %w", i, err)
+		}
+	}
+
+	result := &models.BatchCreateUsersResult{Created: []*models.User{}, Failed: []models.BatchCreateFailure{}}
+	for i, created := range s.BatchCreateUserResults(ctx, creatorRole, requests) {
+		if created.Err != nil {
+			result.Failed = append(result.Failed, models.BatchCreateFailure{
+				Index: i, Email: requests[i].Email, Error: created.Err.Error(),
+			})
+			continue
+		}
+		result.Created = append(result.Created, created.User)
+	}
+	return result, nil
+}
+
+// BatchCreateResult is the outcome of one user of a batch
+type BatchCreateResult struct {
+	User *models.User // Nil when Err is set
+	Err  error
+}
 
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+// BatchCreateUserResults creates multiple users like BatchCreateUsers, but
+// every user succeeds or fails on its own, including users whose role ranks
+// above creatorRole. Passwords are hashed concurrently, then every user that
+// passed is inserted in one transaction. Results are in request order.
+func (s *UserService) BatchCreateUserResults(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest) []BatchCreateResult {
+	ctx = database.WithPrimary(ctx)
+	if s.batch.OnBatch != nil {
+		s.batch.OnBatch(len(requests))
+	}
+	start := time.Now()
 
-			// Create context with timeout for each goroutine
-			goCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
+	results := make([]BatchCreateResult, len(requests))
+	fail := func(i int, err error) {
+		results[i] = BatchCreateResult{Err: err}
+		if s.batch.OnItem != nil {
+			s.batch.OnItem(time.Since(start), err)
+		}
+	}
 
-			user, err := s.CreateUser(goCtx, request)
+	pending := s.prepareBatch(ctx, creatorRole, requests, results, fail)
+	for _, i := range s.insertBatch(ctx, pending, results, fail) {
+		s.InvalidateUsers(uint(results[i].User.ID))
+		if s.batch.OnItem != nil {
+			s.batch.OnItem(time.Since(start), nil)
+		}
+	}
+	return results
+}
 
+// prepareBatch checks the role and email of every user and hashes the
+// passwords of those that pass. It sets their results[i].User and returns
+// their indexes in request order.
+func (s *UserService) prepareBatch(ctx context.Context, creatorRole models.Role, requests []*models.CreateUserRequest, results []BatchCreateResult, fail func(int, error)) []int {
+	// Checked in request order, so the first user with an email wins
+	var valid []int
+	seen := make(map[string]bool, len(requests))
+	for i, req := range requests {
+		if _, err := assignableRole(creatorRole, req.Role); err != nil {
+			fail(i, err)
+			continue
+		}
+		email := models.NormalizeEmail(req.Email)
+		if seen[email] {
+			fail(i, ErrDuplicateInBatch)
+			continue
+		}
+		seen[email] = true
+		valid = append(valid, i)
+	}
+
+	// Hashing is CPU bound and the slowest step, so it alone runs concurrently
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		hashed    []int
+		semaphore = make(chan struct{}, s.batch.Concurrency)
+	)
+	for _, i := range valid {
+		wg.Go(func() {
+			user, err := s.newBatchUser(ctx, creatorRole, semaphore, requests[i])
 			mu.Lock()
 			defer mu.Unlock()
-
 			if err != nil {
-				errList = append(errList, fmt.Errorf("user %d: %w", index, err))
-			} else {
-				users = append(users, user)
+				fail(i, err)
+				return
 			}
-		}(i, req)
+			results[i].User = user
+			hashed = append(hashed, i)
+		})
 	}
-
 	wg.Wait()
 
-	if len(errList) > 0 {
-		return users, fmt.Errorf("batch create had %d errors: %v", len(errList), errList[0])
+	slices.Sort(hashed)
+	return hashed
+}
+
+// newBatchUser hashes the password of one user of a batch once a semaphore
+// slot is free. Users still waiting when ctx ends fail without being hashed.
+func (s *UserService) newBatchUser(ctx context.Context, creatorRole models.Role, semaphore chan struct{}, req *models.CreateUserRequest) (*models.User, error) {
+	select {
+	case semaphore <- struct{}{}:
+		defer func() { <-semaphore }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	// Both cases may be ready at once; never start work for an ended batch
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	role, _ := assignableRole(creatorRole, req.Role) // Checked by prepareBatch
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	return &models.User{
+		Name:        req.Name,
+		Email:       req.Email,
+		Password:    hashedPassword,
+		Age:         req.Age,
+		DateOfBirth: req.DateOfBirth.TimePtr(),
+		Role:        role,
+		IsActive:    true,
+	}, nil
 }
 
-// GetUserStats returns user statistics using goroutines
-func (s *UserService) GetUserStats(ctx context.Context) (map[string]interface{}, error) {
-	var (
-		wg          sync.WaitGroup
-		mu          sync.Mutex
-		totalUsers  int
-		activeUsers int
-		errors      []error
-	)
-
-	// Get total users count
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		users, err := s.repo.GetAll(ctx)
-		mu.Lock()
-		defer mu.Unlock()
-		if err != nil {
-			errors = append(errors, err)
-		} else {
-			totalUsers = len(users)
+// insertBatch inserts the users of pending in one transaction and returns
+// the indexes of those created. A unique violation fails only the users
+// whose email is taken, e.g. by an existing user or a concurrent request,
+// and the others are inserted again without them. Any other error fails
+// them all.
+func (s *UserService) insertBatch(ctx context.Context, pending []int, results []BatchCreateResult, fail func(int, error)) []int {
+	ctx, cancel := context.WithTimeout(ctx, s.batch.InsertTimeout)
+	defer cancel()
+
+	for len(pending) > 0 {
+		users := make([]*models.User, len(pending))
+		emails := make([]string, len(pending))
+		for j, i := range pending {
+			results[i].User.ID = 0 // Assigned by a rolled back attempt
+			users[j] = results[i].User
+			emails[j] = users[j].Email
 		}
-	}()
-
-	// Get active users count
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		users, err := s.repo.GetActiveUsers(ctx)
-		mu.Lock()
-		defer mu.Unlock()
-		if err != nil {
-			errors = append(errors, err)
-		} else {
-			activeUsers = len(users)
+		err := s.repo.BatchCreate(ctx, users)
+		if err == nil {
+			return pending
 		}
-	}()
 
-	wg.Wait()
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			existing, lookupErr := s.repo.ExistingEmails(ctx, emails)
+			if lookupErr == nil && len(existing) > 0 {
+				pending = slices.DeleteFunc(pending, func(i int) bool {
+					if slices.ContainsFunc(existing, func(email string) bool { return strings.EqualFold(email, results[i].User.Email) }) {
+						fail(i, repository.ErrDuplicateEmail)
+						return true
+					}
+					return false
+				})
+				continue
+			}
+		}
+		for _, i := range pending {
+			fail(i, err)
+		}
+		return nil
+	}
+	return nil
+}
 
-	if len(errors) > 0 {
-		return nil, errors[0]
+// GetUserStats returns user statistics, from the cache when it is enabled
+func (s *UserService) GetUserStats(ctx context.Context) (map[string]interface{}, error) {
+	var stats userStats
+	var err error
+	if s.stats == nil {
+		stats, err = s.loadUserStats(ctx)
+	} else {
+		stats, err = s.stats.GetOrLoad("", func() (userStats, error) { return s.loadUserStats(ctx) })
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	// Every role is listed, so clients need not handle missing ones
+	byRole := make(map[models.Role]int64, len(models.Roles))
+	for _, role := range models.Roles {
+		byRole[role] = stats.byRole[role]
+	}
 	return map[string]interface{}{
-		"total_users":    totalUsers,
-		"active_users":   activeUsers,
-		"inactive_users": totalUsers - activeUsers,
+		"total_users":           int(stats.total),
+		"active_users":          int(stats.active),
+		"inactive_users":        int(stats.total - stats.active),
+		"users_by_role":         byRole,
+		"new_users_last_7_days": int(stats.newLastWeek),
 	}, nil
 }
 
+// loadUserStats runs the count queries of GetUserStats concurrently
+func (s *UserService) loadUserStats(ctx context.Context) (userStats, error) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		stats  userStats
+		errors []error
+	)
+	count := func(query func() error) {
+		wg.Go(func() {
+			if err := query(); err != nil {
+				mu.Lock()
+				errors = append(errors, err)
+				mu.Unlock()
+			}
+		})
+	}
+
+	// Each query writes its own field
+	count(func() (err error) {
+		stats.total, err = s.repo.Count(ctx)
+		return err
+	})
+	count(func() (err error) {
+		stats.active, err = s.repo.CountActive(ctx)
+		return err
+	})
+	count(func() (err error) {
+		stats.byRole, err = s.repo.CountByRole(ctx)
+		return err
+	})
+	count(func() (err error) {
+		stats.newLastWeek, err = s.repo.CountCreatedSince(ctx, time.Now().AddDate(0, 0, -7))
+		return err
+	})
+	wg.Wait()
+
+	if len(errors) > 0 {
+		return userStats{}, errors[0]
+	}
+	return stats, nil
+}
+
 // UpdateUserRole updates user role (superadmin only operation)
-func (s *UserService) UpdateUserRole(ctx context.Context, userID uint, newRole string) (*models.User, error) {
+func (s *UserService) UpdateUserRole(ctx context.Context, userID uint, newRole models.Role) (*models.User, error) {
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(userID)
 	// Validate role
-	role := models.Role(newRole)
-	if !role.IsValid() {
-		return nil, errors.New("invalid role")
+	if !newRole.IsValid() {
+		return nil, models.ErrInvalidRole
 	}
 
 	// Get user
@@ -257,6 +654,8 @@ func (s *UserService) UpdateUserRole(ctx context.Context, userID uint, newRole s
 
 // UpdateProfile updates user's own profile (excluding role and password)
 func (s *UserService) UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.User, error) {
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(userID)
 	// Get user
 	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
@@ -269,6 +668,10 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID uint, req *model
 	}
 	if req.Age != nil {
 		user.Age = *req.Age
+		user.DateOfBirth = nil
+	}
+	if req.DateOfBirth != nil {
+		user.DateOfBirth = req.DateOfBirth.TimePtr()
 	}
 	if req.AvatarURL != nil {
 		user.AvatarURL = *req.AvatarURL
@@ -288,26 +691,54 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID uint, req *model
 	return user, nil
 }
 
-// ChangePassword changes user's password
+// ChangePassword changes user's password to the already-hashed newPassword,
+// clears a pending admin reset and revokes the user's outstanding refresh
+// tokens, so every other session ends when its access token expires.
+// The current password must have been verified by the caller.
 func (s *UserService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
-	// Get user
-	user, err := s.repo.GetByID(ctx, userID)
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(userID)
+	_, err := s.repo.ChangePassword(ctx, userID, newPassword, time.Now())
+	return err
+}
+
+// AdminResetPassword sets a user's password on behalf of an admin and flags
+// the account so that the user must change it before doing anything else.
+// An empty newPassword is replaced by a random temporary password, which is
+// returned; it is stored nowhere but in hashed form.
+func (s *UserService) AdminResetPassword(ctx context.Context, id uint, newPassword string) (string, error) {
+	ctx = database.WithPrimary(ctx)
+	defer s.InvalidateUsers(id)
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Note: Password verification should be done at handler level with auth.CheckPassword
-	// This service assumes password has already been verified
-
-	// Hash new password
-	// Note: Hashing should be done at handler level with auth.HashPassword
-	// This service receives already-hashed password
-	user.Password = newPassword
+	temporary := ""
+	if newPassword == "" {
+		if temporary, err = temporaryPassword(); err != nil {
+			return "", err
+		}
+		newPassword = temporary
+	}
+	hashedPassword, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
 
-	// Save updates
+	user.Password = hashedPassword
+	user.MustChangePassword = true
 	if err := s.repo.Update(ctx, user); err != nil {
-		return err
+		return "", err
 	}
+	return temporary, nil
+}
 
-	return nil
+// temporaryPassword returns a random 16-character password
+func temporaryPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
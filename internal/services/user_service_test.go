@@ -4,31 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"Go-Lang-project-01/internal/auth"
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-// UserRepositoryInterface defines the repository interface for testing
-type UserRepositoryInterface interface {
-	Create(ctx context.Context, user *models.User) error
-	GetAll(ctx context.Context) ([]*models.User, error)
-	GetAllPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, int, error)
-	GetByID(ctx context.Context, id uint) (*models.User, error)
-	GetByEmail(ctx context.Context, email string) (*models.User, error)
-	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id uint) error
-	GetActiveUsers(ctx context.Context) ([]*models.User, error)
-	BatchCreate(ctx context.Context, users []*models.User) error
-}
-
 // MockUserRepository is a mock implementation of UserRepositoryInterface
 type MockUserRepository struct {
 	mock.Mock
@@ -47,12 +43,12 @@ func (m *MockUserRepository) GetAll(ctx context.Context) ([]*models.User, error)
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetAllPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, int, error) {
+func (m *MockUserRepository) GetAllPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, int64, error) {
 	args := m.Called(ctx, query)
 	if args.Get(0) == nil {
-		return nil, args.Int(1), args.Error(2)
+		return nil, args.Get(1).(int64), args.Error(2)
 	}
-	return args.Get(0).([]*models.User), args.Int(1), args.Error(2)
+	return args.Get(0).([]*models.User), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *MockUserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
@@ -63,6 +59,14 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id uint) (*models.User
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []uint) ([]*models.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -71,236 +75,89 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(0)
+func (m *MockUserRepository) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	args := m.Called(ctx, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id uint) error {
-	args := m.Called(ctx, id)
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetActiveUsers(ctx context.Context) ([]*models.User, error) {
-	args := m.Called(ctx)
+func (m *MockUserRepository) UpdateRoles(ctx context.Context, roles map[uint]models.Role) ([]uint, error) {
+	args := m.Called(ctx, roles)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*models.User), args.Error(1)
-}
-
-func (m *MockUserRepository) BatchCreate(ctx context.Context, users []*models.User) error {
-	args := m.Called(ctx, users)
-	return args.Error(0)
+	return args.Get(0).([]uint), args.Error(1)
 }
 
-// UserServiceTestable is a testable version of UserService that accepts interface
-type UserServiceTestable struct {
-	repo UserRepositoryInterface
+func (m *MockUserRepository) ChangePassword(ctx context.Context, id uint, passwordHash string, revokedAt time.Time) (int64, error) {
+	args := m.Called(ctx, id, passwordHash, revokedAt)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-func (s *UserServiceTestable) GetAllUsers(ctx context.Context) ([]*models.User, error) {
-	return s.repo.GetAll(ctx)
+func (m *MockUserRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
 
-func (s *UserServiceTestable) GetAllUsersPaginated(ctx context.Context, query models.PaginationQuery) ([]*models.User, models.PaginationMeta, error) {
-	// Set default values
-	if query.Page < 1 {
-		query.Page = 1
-	}
-	if query.Limit < 1 {
-		query.Limit = 10
-	}
-	if query.Limit > 100 {
-		query.Limit = 100
-	}
-	if query.Sort == "" {
-		query.Sort = "created_at"
-	}
-	if query.Order == "" {
-		query.Order = "desc"
-	}
-
-	users, total, err := s.repo.GetAllPaginated(ctx, query)
-	if err != nil {
-		return nil, models.PaginationMeta{}, err
-	}
-
-	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
-
-	meta := models.PaginationMeta{
-		Page:       query.Page,
-		Limit:      query.Limit,
-		Total:      int64(total),
-		TotalPages: totalPages,
+func (m *MockUserRepository) ListDeleted(ctx context.Context, page, limit int) ([]*models.User, int64, error) {
+	args := m.Called(ctx, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
 	}
-
-	return users, meta, nil
+	return args.Get(0).([]*models.User), args.Get(1).(int64), args.Error(2)
 }
 
-func (s *UserServiceTestable) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
-	return s.repo.GetByID(ctx, id)
+func (m *MockUserRepository) Restore(ctx context.Context, id uint, restoredAt time.Time) error {
+	args := m.Called(ctx, id, restoredAt)
+	return args.Error(0)
 }
 
-func (s *UserServiceTestable) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
-	existingUser, err := s.repo.GetByEmail(ctx, req.Email)
-	if err == nil && existingUser != nil {
-		return nil, errors.New("email already exists")
-	}
-
-	user := &models.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Age:      req.Age,
-		IsActive: true,
-	}
-
-	if err := s.repo.Create(ctx, user); err != nil {
-		return nil, err
-	}
-
-	return user, nil
+func (m *MockUserRepository) Purge(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
 
-func (s *UserServiceTestable) UpdateUser(ctx context.Context, id uint, req *models.UpdateUserRequest) (*models.User, error) {
-	user, err := s.repo.GetByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-
-	if req.Name != nil && *req.Name != "" {
-		user.Name = *req.Name
-	}
-	if req.Email != nil && *req.Email != "" {
-		existingUser, err := s.repo.GetByEmail(ctx, *req.Email)
-		if err == nil && existingUser != nil && existingUser.ID != id {
-			return nil, errors.New("email already exists")
-		}
-		user.Email = *req.Email
-	}
-	if req.Age != nil && *req.Age > 0 {
-		user.Age = *req.Age
-	}
-
-	if err := s.repo.Update(ctx, user); err != nil {
-		return nil, err
+func (m *MockUserRepository) DeleteByIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-
-	return user, nil
+	return args.Get(0).([]uint), args.Error(1)
 }
 
-func (s *UserServiceTestable) DeleteUser(ctx context.Context, id uint) error {
-	return s.repo.Delete(ctx, id)
+func (m *MockUserRepository) BatchCreate(ctx context.Context, users []*models.User) error {
+	args := m.Called(ctx, users)
+	return args.Error(0)
 }
 
-func (s *UserServiceTestable) GetUserStats(ctx context.Context) (map[string]interface{}, error) {
-	var (
-		wg          sync.WaitGroup
-		mu          sync.Mutex
-		totalUsers  int
-		activeUsers int
-		errors      []error
-	)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		users, err := s.repo.GetAll(ctx)
-		mu.Lock()
-		defer mu.Unlock()
-		if err != nil {
-			errors = append(errors, err)
-		} else {
-			totalUsers = len(users)
-		}
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		users, err := s.repo.GetActiveUsers(ctx)
-		mu.Lock()
-		defer mu.Unlock()
-		if err != nil {
-			errors = append(errors, err)
-		} else {
-			activeUsers = len(users)
-		}
-	}()
-
-	wg.Wait()
-
-	if len(errors) > 0 {
-		return nil, errors[0]
-	}
-
-	return map[string]interface{}{
-		"total_users":    totalUsers,
-		"active_users":   activeUsers,
-		"inactive_users": totalUsers - activeUsers,
-	}, nil
+func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-func (s *UserServiceTestable) UpdateUserRole(ctx context.Context, userID uint, newRole string) (*models.User, error) {
-	role := models.Role(newRole)
-	if !role.IsValid() {
-		return nil, errors.New("invalid role")
-	}
-
-	user, err := s.repo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
-
-	user.Role = newRole
-	if err := s.repo.Update(ctx, user); err != nil {
-		return nil, err
-	}
-
-	return user, nil
+func (m *MockUserRepository) CountActive(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-func (s *UserServiceTestable) BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) ([]*models.User, error) {
-	var (
-		wg      sync.WaitGroup
-		mu      sync.Mutex
-		users   []*models.User
-		errList []error
-	)
-
-	semaphore := make(chan struct{}, 5)
-
-	for i, req := range requests {
-		wg.Add(1)
-		go func(index int, request *models.CreateUserRequest) {
-			defer wg.Done()
-
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			goCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
-
-			user, err := s.CreateUser(goCtx, request)
-
-			mu.Lock()
-			defer mu.Unlock()
-
-			if err != nil {
-				errList = append(errList, fmt.Errorf("user %d: %w", index, err))
-			} else {
-				users = append(users, user)
-			}
-		}(i, req)
-	}
-
-	wg.Wait()
-
-	if len(errList) > 0 {
-		return users, fmt.Errorf("batch create had %d errors: %v", len(errList), errList[0])
+func (m *MockUserRepository) CountByRole(ctx context.Context) (map[models.Role]int64, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(map[models.Role]int64), args.Error(1)
+}
 
-	return users, nil
+func (m *MockUserRepository) CountCreatedSince(ctx context.Context, since time.Time) (int64, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 // Helper function to create mock repository
@@ -309,10 +166,8 @@ func setupMockRepository() *MockUserRepository {
 }
 
 // Helper function to create service with mock repository
-func setupService(mockRepo *MockUserRepository) *UserServiceTestable {
-	return &UserServiceTestable{
-		repo: mockRepo,
-	}
+func setupService(mockRepo *MockUserRepository) *UserService {
+	return NewUserService(mockRepo)
 }
 
 // Test GetAllUsers
@@ -403,7 +258,7 @@ func TestGetAllUsersPaginated(t *testing.T) {
 					Sort:  "created_at",
 					Order: "desc",
 				}
-				m.On("GetAllPaginated", mock.Anything, expectedQuery).Return(users, 2, nil)
+				m.On("GetAllPaginated", mock.Anything, expectedQuery).Return(users, int64(2), nil)
 			},
 			expectedUsers:     2,
 			expectedTotal:     2,
@@ -423,7 +278,7 @@ func TestGetAllUsersPaginated(t *testing.T) {
 				users := []*models.User{
 					{ID: 6, Name: "Test User", Email: "test6@test.com"},
 				}
-				m.On("GetAllPaginated", mock.Anything, mock.Anything).Return(users, 15, nil)
+				m.On("GetAllPaginated", mock.Anything, mock.Anything).Return(users, int64(15), nil)
 			},
 			expectedUsers:     1,
 			expectedTotal:     15,
@@ -443,7 +298,7 @@ func TestGetAllUsersPaginated(t *testing.T) {
 					Sort:  "created_at",
 					Order: "desc",
 				}
-				m.On("GetAllPaginated", mock.Anything, expectedQuery).Return([]*models.User{}, 0, nil)
+				m.On("GetAllPaginated", mock.Anything, expectedQuery).Return([]*models.User{}, int64(0), nil)
 			},
 			expectedUsers:     0,
 			expectedTotal:     0,
@@ -457,7 +312,7 @@ func TestGetAllUsersPaginated(t *testing.T) {
 				Limit: 10,
 			},
 			mockSetup: func(m *MockUserRepository) {
-				m.On("GetAllPaginated", mock.Anything, mock.Anything).Return(nil, 0, errors.New("database error"))
+				m.On("GetAllPaginated", mock.Anything, mock.Anything).Return(nil, int64(0), errors.New("database error"))
 			},
 			expectedError: true,
 		},
@@ -542,7 +397,7 @@ func TestGetUserByID(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, user)
-				assert.Equal(t, tt.userID, user.ID)
+				assert.Equal(t, tt.userID, uint(user.ID))
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -572,7 +427,8 @@ func TestCreateUser(t *testing.T) {
 				m.On("GetByEmail", mock.Anything, "new@test.com").Return(nil, gorm.ErrRecordNotFound)
 				// Create user
 				m.On("Create", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
-					return u.Email == "new@test.com" && u.Name == "New User" && u.IsActive
+					return u.Email == "new@test.com" && u.Name == "New User" && u.IsActive &&
+						u.Role == models.RoleUser && auth.CheckPassword("password123", u.Password) == nil
 				})).Return(nil)
 			},
 			expectedError: false,
@@ -614,7 +470,7 @@ func TestCreateUser(t *testing.T) {
 			tt.mockSetup(mockRepo)
 			service := setupService(mockRepo)
 
-			user, err := service.CreateUser(context.Background(), tt.request)
+			user, err := service.CreateUser(context.Background(), models.RoleAdmin, tt.request)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -807,11 +663,11 @@ func TestDeleteUser(t *testing.T) {
 // Test BatchCreateUsers
 func TestBatchCreateUsers(t *testing.T) {
 	tests := []struct {
-		name              string
-		requests          []*models.CreateUserRequest
-		mockSetup         func(*MockUserRepository)
-		expectedSuccesses int
-		expectedError     bool
+		name            string
+		requests        []*models.CreateUserRequest
+		mockSetup       func(*MockUserRepository)
+		expectedCreated int
+		expectedFailed  []string
 	}{
 		{
 			name: "Successfully create multiple users",
@@ -821,13 +677,12 @@ func TestBatchCreateUsers(t *testing.T) {
 				{Name: "User 3", Email: "user3@test.com", Password: "pass3", Age: 35},
 			},
 			mockSetup: func(m *MockUserRepository) {
-				// All emails don't exist
-				m.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
-				// All creates succeed
-				m.On("Create", mock.Anything, mock.Anything).Return(nil)
+				// All users are inserted in one call
+				m.On("BatchCreate", mock.Anything, mock.MatchedBy(func(users []*models.User) bool {
+					return len(users) == 3
+				})).Return(nil).Once()
 			},
-			expectedSuccesses: 3,
-			expectedError:     false,
+			expectedCreated: 3,
 		},
 		{
 			name: "Some users fail due to duplicate email",
@@ -836,25 +691,34 @@ func TestBatchCreateUsers(t *testing.T) {
 				{Name: "User 2", Email: "existing@test.com", Password: "pass2", Age: 30},
 			},
 			mockSetup: func(m *MockUserRepository) {
-				// First email doesn't exist
-				m.On("GetByEmail", mock.Anything, "new@test.com").Return(nil, gorm.ErrRecordNotFound)
-				m.On("Create", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
-					return u.Email == "new@test.com"
-				})).Return(nil)
-
-				// Second email exists
-				existingUser := &models.User{ID: 1, Email: "existing@test.com"}
-				m.On("GetByEmail", mock.Anything, "existing@test.com").Return(existingUser, nil)
+				// The first insert hits the taken email and is retried without it
+				m.On("BatchCreate", mock.Anything, mock.MatchedBy(func(users []*models.User) bool {
+					return len(users) == 2
+				})).Return(repository.ErrDuplicateEmail).Once()
+				m.On("ExistingEmails", mock.Anything, []string{"new@test.com", "existing@test.com"}).
+					Return([]string{"existing@test.com"}, nil).Once()
+				m.On("BatchCreate", mock.Anything, mock.MatchedBy(func(users []*models.User) bool {
+					return len(users) == 1 && users[0].Email == "new@test.com"
+				})).Return(nil).Once()
 			},
-			expectedSuccesses: 1,
-			expectedError:     true,
+			expectedCreated: 1,
+			expectedFailed:  []string{"existing@test.com"},
 		},
 		{
-			name:              "Empty batch",
-			requests:          []*models.CreateUserRequest{},
-			mockSetup:         func(m *MockUserRepository) {},
-			expectedSuccesses: 0,
-			expectedError:     false,
+			name: "Database error fails every user",
+			requests: []*models.CreateUserRequest{
+				{Name: "User 1", Email: "user1@test.com", Password: "pass1", Age: 25},
+				{Name: "User 2", Email: "user2@test.com", Password: "pass2", Age: 30},
+			},
+			mockSetup: func(m *MockUserRepository) {
+				m.On("BatchCreate", mock.Anything, mock.Anything).Return(errors.New("database error")).Once()
+			},
+			expectedFailed: []string{"user1@test.com", "user2@test.com"},
+		},
+		{
+			name:      "Empty batch",
+			requests:  []*models.CreateUserRequest{},
+			mockSetup: func(m *MockUserRepository) {},
 		},
 	}
 
@@ -864,15 +728,15 @@ func TestBatchCreateUsers(t *testing.T) {
 			tt.mockSetup(mockRepo)
 			service := setupService(mockRepo)
 
-			users, err := service.BatchCreateUsers(context.Background(), tt.requests)
+			result, err := service.BatchCreateUsers(context.Background(), models.RoleAdmin, tt.requests)
+			require.NoError(t, err)
 
-			if tt.expectedError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
+			assert.Len(t, result.Created, tt.expectedCreated)
+			var failed []string
+			for _, failure := range result.Failed {
+				failed = append(failed, failure.Email)
 			}
-
-			assert.Len(t, users, tt.expectedSuccesses)
+			assert.Equal(t, tt.expectedFailed, failed)
 
 			mockRepo.AssertExpectations(t)
 		})
@@ -890,53 +754,58 @@ func TestGetUserStats(t *testing.T) {
 		{
 			name: "Successfully get user stats",
 			mockSetup: func(m *MockUserRepository) {
-				allUsers := []*models.User{
-					{ID: 1, IsActive: true},
-					{ID: 2, IsActive: true},
-					{ID: 3, IsActive: false},
-					{ID: 4, IsActive: true},
-				}
-				activeUsers := []*models.User{
-					{ID: 1, IsActive: true},
-					{ID: 2, IsActive: true},
-					{ID: 4, IsActive: true},
-				}
-				m.On("GetAll", mock.Anything).Return(allUsers, nil)
-				m.On("GetActiveUsers", mock.Anything).Return(activeUsers, nil)
+				m.On("Count", mock.Anything).Return(int64(4), nil)
+				m.On("CountActive", mock.Anything).Return(int64(3), nil)
+				m.On("CountByRole", mock.Anything).Return(map[models.Role]int64{models.RoleUser: 3, models.RoleAdmin: 1}, nil)
+				m.On("CountCreatedSince", mock.Anything, mock.Anything).Return(int64(2), nil)
 			},
 			expectedStats: map[string]interface{}{
 				"total_users":    4,
 				"active_users":   3,
 				"inactive_users": 1,
+				"users_by_role": map[models.Role]int64{
+					models.RoleUser: 3, models.RoleAdmin: 1, models.RoleSuperAdmin: 0,
+				},
+				"new_users_last_7_days": 2,
 			},
 			expectedError: false,
 		},
 		{
 			name: "Empty database",
 			mockSetup: func(m *MockUserRepository) {
-				m.On("GetAll", mock.Anything).Return([]*models.User{}, nil)
-				m.On("GetActiveUsers", mock.Anything).Return([]*models.User{}, nil)
+				m.On("Count", mock.Anything).Return(int64(0), nil)
+				m.On("CountActive", mock.Anything).Return(int64(0), nil)
+				m.On("CountByRole", mock.Anything).Return(map[models.Role]int64{}, nil)
+				m.On("CountCreatedSince", mock.Anything, mock.Anything).Return(int64(0), nil)
 			},
 			expectedStats: map[string]interface{}{
 				"total_users":    0,
 				"active_users":   0,
 				"inactive_users": 0,
+				"users_by_role": map[models.Role]int64{
+					models.RoleUser: 0, models.RoleAdmin: 0, models.RoleSuperAdmin: 0,
+				},
+				"new_users_last_7_days": 0,
 			},
 			expectedError: false,
 		},
 		{
-			name: "Error getting all users",
+			name: "Error counting users",
 			mockSetup: func(m *MockUserRepository) {
-				m.On("GetAll", mock.Anything).Return(nil, errors.New("database error"))
-				m.On("GetActiveUsers", mock.Anything).Return([]*models.User{}, nil)
+				m.On("Count", mock.Anything).Return(int64(0), errors.New("database error"))
+				m.On("CountActive", mock.Anything).Return(int64(0), nil)
+				m.On("CountByRole", mock.Anything).Return(map[models.Role]int64{}, nil)
+				m.On("CountCreatedSince", mock.Anything, mock.Anything).Return(int64(0), nil)
 			},
 			expectedError: true,
 		},
 		{
-			name: "Error getting active users",
+			name: "Error counting users by role",
 			mockSetup: func(m *MockUserRepository) {
-				m.On("GetAll", mock.Anything).Return([]*models.User{}, nil)
-				m.On("GetActiveUsers", mock.Anything).Return(nil, errors.New("database error"))
+				m.On("Count", mock.Anything).Return(int64(0), nil)
+				m.On("CountActive", mock.Anything).Return(int64(0), nil)
+				m.On("CountByRole", mock.Anything).Return(nil, errors.New("database error"))
+				m.On("CountCreatedSince", mock.Anything, mock.Anything).Return(int64(0), nil)
 			},
 			expectedError: true,
 		},
@@ -955,10 +824,7 @@ func TestGetUserStats(t *testing.T) {
 				assert.Nil(t, stats)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, stats)
-				assert.Equal(t, tt.expectedStats["total_users"], stats["total_users"])
-				assert.Equal(t, tt.expectedStats["active_users"], stats["active_users"])
-				assert.Equal(t, tt.expectedStats["inactive_users"], stats["inactive_users"])
+				assert.Equal(t, tt.expectedStats, stats)
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -966,12 +832,39 @@ func TestGetUserStats(t *testing.T) {
 	}
 }
 
+// GetUserStats against a real database, so the count queries are exercised
+func TestGetUserStats_CountQueries(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	users := []*models.User{
+		{Name: "Old User", Email: "old@test.com", Password: "x", Age: 30, Role: models.RoleUser, CreatedAt: time.Now().AddDate(0, 0, -30)},
+		{Name: "New User", Email: "new@test.com", Password: "x", Age: 30, Role: models.RoleUser},
+		{Name: "New Admin", Email: "admin@test.com", Password: "x", Age: 30, Role: models.RoleAdmin},
+	}
+	require.NoError(t, service.repo.BatchCreate(ctx, users))
+	_, err := service.SetUserActive(ctx, uint(users[1].ID), false)
+	require.NoError(t, err)
+
+	stats, err := service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"total_users":    3,
+		"active_users":   2,
+		"inactive_users": 1,
+		"users_by_role": map[models.Role]int64{
+			models.RoleUser: 2, models.RoleAdmin: 1, models.RoleSuperAdmin: 0,
+		},
+		"new_users_last_7_days": 2,
+	}, stats)
+}
+
 // Test UpdateUserRole (RBAC)
 func TestUpdateUserRole(t *testing.T) {
 	tests := []struct {
 		name          string
 		userID        uint
-		newRole       string
+		newRole       models.Role
 		mockSetup     func(*MockUserRepository)
 		expectedError bool
 		errorMessage  string
@@ -1064,7 +957,7 @@ func BenchmarkGetAllUsers(b *testing.B) {
 	users := make([]*models.User, 100)
 	for i := 0; i < 100; i++ {
 		users[i] = &models.User{
-			ID:    uint(i + 1),
+			ID:    models.ID(i + 1),
 			Name:  "User",
 			Email: "user@test.com",
 		}
@@ -1080,16 +973,10 @@ func BenchmarkGetAllUsers(b *testing.B) {
 
 func BenchmarkGetUserStats(b *testing.B) {
 	mockRepo := setupMockRepository()
-	allUsers := make([]*models.User, 50)
-	activeUsers := make([]*models.User, 30)
-	for i := 0; i < 50; i++ {
-		allUsers[i] = &models.User{ID: uint(i + 1), IsActive: i < 30}
-	}
-	for i := 0; i < 30; i++ {
-		activeUsers[i] = &models.User{ID: uint(i + 1), IsActive: true}
-	}
-	mockRepo.On("GetAll", mock.Anything).Return(allUsers, nil)
-	mockRepo.On("GetActiveUsers", mock.Anything).Return(activeUsers, nil)
+	mockRepo.On("Count", mock.Anything).Return(int64(50), nil)
+	mockRepo.On("CountActive", mock.Anything).Return(int64(30), nil)
+	mockRepo.On("CountByRole", mock.Anything).Return(map[models.Role]int64{models.RoleUser: 50}, nil)
+	mockRepo.On("CountCreatedSince", mock.Anything, mock.Anything).Return(int64(5), nil)
 	service := setupService(mockRepo)
 
 	b.ResetTimer()
@@ -1120,11 +1007,598 @@ func TestBatchCreateUsersConcurrency(t *testing.T) {
 			{Name: "User 2", Email: "user2@test.com", Password: "pass", Age: 30},
 		}
 
-		mockRepo.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
-		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("BatchCreate", mock.Anything, mock.Anything).Return(nil)
 
 		// Run with race detector: go test -race
-		_, err := service.BatchCreateUsers(context.Background(), requests)
+		result, err := service.BatchCreateUsers(context.Background(), models.RoleAdmin, requests)
 		assert.NoError(t, err)
+		assert.Len(t, result.Created, 2)
 	})
 }
+
+// newBatchService returns a UserService backed by a fresh SQLite file, so
+// concurrent batch items share one database
+func newBatchService(tb testing.TB, cfg BatchConfig) *UserService {
+	tb.Helper()
+	service, _ := newBatchServiceDB(tb, cfg)
+	return service
+}
+
+// newBatchServiceDB is newBatchService that also returns the database
+func newBatchServiceDB(tb testing.TB, cfg BatchConfig) (*UserService, *gorm.DB) {
+	tb.Helper()
+	return newBatchServiceOn(tb, openBatchSQLite(tb), cfg)
+}
+
+// newBatchServiceOn returns a UserService backed by db, migrated for users
+func newBatchServiceOn(tb testing.TB, db *gorm.DB, cfg BatchConfig) (*UserService, *gorm.DB) {
+	tb.Helper()
+	require.NoError(tb, db.AutoMigrate(&models.User{}, &models.RefreshToken{}))
+	return NewUserServiceWithConfig(repository.NewUserRepository(db), cfg), db
+}
+
+// openBatchSQLite opens a fresh SQLite file, closed when tb ends
+func openBatchSQLite(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tb.TempDir(), "batch.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(tb, err)
+	sqlDB, err := db.DB()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// openBatchPostgres opens a fresh schema on the server at TEST_POSTGRES_HOST,
+// dropped when tb ends. It skips tb when the variable is not set.
+func openBatchPostgres(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	host := os.Getenv("TEST_POSTGRES_HOST")
+	if host == "" {
+		tb.Skip("TEST_POSTGRES_HOST is not set")
+	}
+	dsn := fmt.Sprintf("host=%s port=5432 user=postgres password=%s dbname=postgres sslmode=disable", host, os.Getenv("TEST_POSTGRES_PASSWORD"))
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	require.NoError(tb, err)
+	adminDB, err := admin.DB()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { adminDB.Close() })
+
+	schema := fmt.Sprintf("batch_bench_%d", time.Now().UnixNano())
+	require.NoError(tb, admin.Exec("CREATE SCHEMA "+schema).Error)
+	tb.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{Logger: logger.Discard})
+	require.NoError(tb, err)
+	sqlDB, err := db.DB()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// countQueries counts the statements db runs from now on
+func countQueries(tb testing.TB, db *gorm.DB) *atomic.Int64 {
+	tb.Helper()
+	var count atomic.Int64
+	increment := func(*gorm.DB) { count.Add(1) }
+	require.NoError(tb, db.Callback().Query().After("gorm:query").Register("test:count_query", increment))
+	require.NoError(tb, db.Callback().Create().After("gorm:create").Register("test:count_create", increment))
+	return &count
+}
+
+func batchRequests(prefix string, n int) []*models.CreateUserRequest {
+	requests := make([]*models.CreateUserRequest, n)
+	for i := range requests {
+		requests[i] = &models.CreateUserRequest{
+			Name:     fmt.Sprintf("Batch User %d", i),
+			Email:    fmt.Sprintf("%s-%d@test.com", prefix, i),
+			Password: "password123",
+			Age:      30,
+		}
+	}
+	return requests
+}
+
+func TestCreateUser_HashesPassword(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	_, err := service.CreateUser(ctx, models.RoleAdmin, &models.CreateUserRequest{
+		Name:     "Hashed User",
+		Email:    "hashed@test.com",
+		Password: "password123",
+		Age:      30,
+	})
+	require.NoError(t, err)
+
+	stored, err := service.repo.GetByEmail(ctx, "hashed@test.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, "password123", stored.Password)
+	assert.NoError(t, auth.CheckPassword("password123", stored.Password))
+	assert.Error(t, auth.CheckPassword("wrong-password", stored.Password))
+}
+
+func TestCreateUser_ConcurrentDuplicateEmail(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	// All calls pass the GetByEmail check before the first insert lands,
+	// so the unique index decides
+	const calls = 10
+	errs := make([]error, calls)
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Go(func() {
+			_, errs[i] = service.CreateUser(ctx, models.RoleAdmin, &models.CreateUserRequest{
+				Name:     fmt.Sprintf("Racer %d", i),
+				Email:    "race@test.com",
+				Password: "password123",
+				Age:      30,
+			})
+		})
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.ErrorIs(t, err, repository.ErrDuplicateEmail)
+		assert.Equal(t, "email already exists", err.Error())
+	}
+	assert.Equal(t, 1, created)
+}
+
+func TestBatchCreateUsers_HashesPasswords(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	requests := batchRequests("hashed", 3)
+	_, err := service.BatchCreateUsers(ctx, models.RoleAdmin, requests)
+	require.NoError(t, err)
+
+	for _, req := range requests {
+		stored, err := service.repo.GetByEmail(ctx, req.Email)
+		require.NoError(t, err)
+		assert.NoError(t, auth.CheckPassword(req.Password, stored.Password), req.Email)
+	}
+}
+
+func TestCreateUser_AssignsRequestedRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		creator models.Role
+		role    models.Role
+		want    models.Role
+		wantErr error
+	}{
+		{"admin creates user by default", models.RoleAdmin, "", models.RoleUser, nil},
+		{"admin creates user", models.RoleAdmin, models.RoleUser, models.RoleUser, nil},
+		{"admin creates admin", models.RoleAdmin, models.RoleAdmin, models.RoleAdmin, nil},
+		{"admin cannot create superadmin", models.RoleAdmin, models.RoleSuperAdmin, "", ErrRoleNotAllowed},
+		{"superadmin creates admin", models.RoleSuperAdmin, models.RoleAdmin, models.RoleAdmin, nil},
+		{"superadmin creates superadmin", models.RoleSuperAdmin, models.RoleSuperAdmin, models.RoleSuperAdmin, nil},
+		{"unknown role", models.RoleSuperAdmin, "root", "", models.ErrInvalidRole},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newBatchService(t, BatchConfig{})
+			user, err := service.CreateUser(context.Background(), tt.creator, &models.CreateUserRequest{
+				Name:     "Role User",
+				Email:    fmt.Sprintf("role-%d@test.com", i),
+				Password: "password123",
+				Age:      30,
+				Role:     tt.role,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, user)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, user.Role)
+		})
+	}
+}
+
+func TestBatchCreateUsers_RejectsRoleAboveCreator(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	requests := batchRequests("escalate", 3)
+	requests[2].Role = models.RoleSuperAdmin
+	result, err := service.BatchCreateUsers(ctx, models.RoleAdmin, requests)
+	assert.ErrorIs(t, err, ErrRoleNotAllowed)
+	assert.Nil(t, result)
+
+	stored, err := service.repo.GetByEmail(ctx, requests[0].Email)
+	require.NoError(t, err)
+	assert.Nil(t, stored, "nothing is created")
+}
+
+func TestBatchCreateUsers_ReportsFailuresInOrder(t *testing.T) {
+	service := newBatchService(t, BatchConfig{Concurrency: 3})
+	ctx := context.Background()
+
+	existing := batchRequests("existing", 2)
+	for _, req := range existing {
+		_, err := service.CreateUser(ctx, models.RoleAdmin, req)
+		require.NoError(t, err)
+	}
+
+	requests := batchRequests("partial", 4)
+	requests[1].Email = existing[0].Email
+	requests[3].Email = existing[1].Email
+	result, err := service.BatchCreateUsers(ctx, models.RoleAdmin, requests)
+	require.NoError(t, err)
+
+	require.Len(t, result.Created, 2)
+	assert.Equal(t, requests[0].Email, result.Created[0].Email)
+	assert.Equal(t, requests[2].Email, result.Created[1].Email)
+	require.Len(t, result.Failed, 2)
+	for i, index := range []int{1, 3} {
+		assert.Equal(t, index, result.Failed[i].Index)
+		assert.Equal(t, existing[i].Email, result.Failed[i].Email)
+		assert.Equal(t, "email already exists", result.Failed[i].Error)
+	}
+}
+
+func TestBatchCreateUsers_RejectsDuplicatesWithinBatch(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	requests := batchRequests("intra", 4)
+	requests[2].Email = requests[0].Email
+	requests[3].Email = strings.ToUpper(requests[0].Email) // Emails ignore case
+	result, err := service.BatchCreateUsers(ctx, models.RoleAdmin, requests)
+	require.NoError(t, err)
+
+	require.Len(t, result.Created, 2, "the first user with an email is created")
+	assert.Equal(t, requests[0].Email, result.Created[0].Email)
+	assert.Equal(t, requests[1].Email, result.Created[1].Email)
+	require.Len(t, result.Failed, 2)
+	for i, index := range []int{2, 3} {
+		assert.Equal(t, index, result.Failed[i].Index)
+		assert.Equal(t, "email already exists earlier in the batch", result.Failed[i].Error)
+	}
+
+	stored, err := service.repo.GetByEmail(ctx, requests[0].Email)
+	require.NoError(t, err)
+	assert.Equal(t, requests[0].Name, stored.Name)
+}
+
+func TestBatchCreateUsers_SoftDeletedEmailFailsOnlyItsUser(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+
+	deleted, err := service.CreateUser(ctx, models.RoleAdmin, batchRequests("deleted", 1)[0])
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteUser(ctx, uint(deleted.ID)))
+
+	// The unique index rejects the whole insert; the retry leaves the user out
+	requests := batchRequests("retry", 3)
+	requests[1].Email = deleted.Email
+	result, err := service.BatchCreateUsers(ctx, models.RoleAdmin, requests)
+	require.NoError(t, err)
+
+	require.Len(t, result.Created, 2)
+	for _, user := range result.Created {
+		assert.NotZero(t, user.ID)
+	}
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, 1, result.Failed[0].Index)
+	assert.Equal(t, "email already exists", result.Failed[0].Error)
+}
+
+func TestNewUserServiceWithConfig_Defaults(t *testing.T) {
+	service := NewUserServiceWithConfig(nil, BatchConfig{})
+	assert.Equal(t, DefaultBatchConfig().Concurrency, service.batch.Concurrency)
+	assert.Equal(t, DefaultBatchConfig().InsertTimeout, service.batch.InsertTimeout)
+}
+
+func TestBatchCreateUsers_ReportsBatchAndItems(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		size     int
+		items    int
+		failures int
+	)
+	service := newBatchService(t, BatchConfig{
+		Concurrency: 2,
+		OnBatch:     func(n int) { size = n },
+		OnItem: func(_ time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			items++
+			if err != nil {
+				failures++
+			}
+		},
+	})
+
+	result, err := service.BatchCreateUsers(context.Background(), models.RoleAdmin, batchRequests("report", 6))
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 6)
+	assert.Empty(t, result.Failed)
+	assert.Equal(t, 6, size)
+	assert.Equal(t, 6, items)
+	assert.Zero(t, failures)
+}
+
+func TestBatchCreateUsers_StopsWhenContextEnds(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	service := newBatchService(t, BatchConfig{
+		Concurrency: 1,
+		OnItem: func(_ time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := service.BatchCreateUsers(ctx, models.RoleAdmin, batchRequests("canceled", 4))
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	require.Len(t, result.Failed, 4)
+	require.Len(t, errs, 4)
+	for _, itemErr := range errs {
+		assert.ErrorIs(t, itemErr, context.Canceled)
+	}
+}
+
+// batchBenchConcurrency lists the hashing concurrency limits benchmarked
+var batchBenchConcurrency = []int{1, 5, 20}
+
+// batchBenchDatabases lists the databases benchmarked; postgres needs a
+// server, e.g. TEST_POSTGRES_HOST=localhost TEST_POSTGRES_PASSWORD=postgres
+var batchBenchDatabases = []struct {
+	name string
+	open func(testing.TB) *gorm.DB
+}{
+	{"sqlite", openBatchSQLite},
+	{"postgres", openBatchPostgres},
+}
+
+// BenchmarkBatchCreateUsers compares one 100-user batch per iteration
+// created with one CreateUser per user, as batches used to be, and with
+// BatchCreateUsers, which inserts them in one transaction, at several
+// concurrency limits on each database. Hashing dominates the time on SQLite,
+// which also serializes writers, so higher limits mostly add lock contention
+// to per_user; queries/op shows the difference a remote database pays.
+func BenchmarkBatchCreateUsers(b *testing.B) {
+	for _, database := range batchBenchDatabases {
+		b.Run(database.name, func(b *testing.B) {
+			b.Run("per_user", func(b *testing.B) {
+				for _, concurrency := range batchBenchConcurrency {
+					b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+						service, db := newBatchServiceOn(b, database.open(b), BatchConfig{Concurrency: concurrency})
+						queries := countQueries(b, db)
+						i := 0
+						for b.Loop() {
+							var wg sync.WaitGroup
+							semaphore := make(chan struct{}, service.batch.Concurrency)
+							for _, req := range batchRequests(fmt.Sprintf("per-user-%d", i), 100) {
+								wg.Go(func() {
+									semaphore <- struct{}{}
+									defer func() { <-semaphore }()
+									if _, err := service.CreateUser(context.Background(), models.RoleAdmin, req); err != nil {
+										b.Error(err)
+									}
+								})
+							}
+							wg.Wait()
+							i++
+						}
+						b.ReportMetric(float64(queries.Load())/float64(i), "queries/op")
+					})
+				}
+			})
+			b.Run("transaction", func(b *testing.B) {
+				for _, concurrency := range batchBenchConcurrency {
+					b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+						service, db := newBatchServiceOn(b, database.open(b), BatchConfig{Concurrency: concurrency})
+						queries := countQueries(b, db)
+						i := 0
+						for b.Loop() {
+							result, err := service.BatchCreateUsers(context.Background(), models.RoleAdmin, batchRequests(fmt.Sprintf("transaction-%d", i), 100))
+							if err != nil || len(result.Failed) > 0 {
+								b.Fatal(err, result.Failed)
+							}
+							i++
+						}
+						b.ReportMetric(float64(queries.Load())/float64(i), "queries/op")
+					})
+				}
+			})
+		})
+	}
+}
+
+func TestSetUserActive(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+	user, err := service.CreateUser(ctx, models.RoleAdmin, &models.CreateUserRequest{
+		Name: "Active User", Email: "active@test.com", Password: "password123", Age: 30,
+	})
+	require.NoError(t, err)
+
+	updated, err := service.SetUserActive(ctx, uint(user.ID), false)
+	require.NoError(t, err)
+	assert.False(t, updated.IsActive)
+	stored, err := service.GetUserByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.False(t, stored.IsActive, "false is written despite the column default")
+
+	updated, err = service.SetUserActive(ctx, uint(user.ID), true)
+	require.NoError(t, err)
+	assert.True(t, updated.IsActive)
+
+	_, err = service.SetUserActive(ctx, 999999, false)
+	assert.Error(t, err)
+}
+
+func TestAdminResetPassword(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	ctx := context.Background()
+	user, err := service.CreateUser(ctx, models.RoleAdmin, batchRequests("reset", 1)[0])
+	require.NoError(t, err)
+
+	temporary, err := service.AdminResetPassword(ctx, uint(user.ID), "")
+	require.NoError(t, err)
+	assert.Len(t, temporary, 16)
+	stored, err := service.repo.GetByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.True(t, stored.MustChangePassword)
+	assert.NoError(t, auth.CheckPassword(temporary, stored.Password))
+
+	temporary, err = service.AdminResetPassword(ctx, uint(user.ID), "given-password")
+	require.NoError(t, err)
+	assert.Empty(t, temporary, "a given password is not returned")
+	stored, err = service.repo.GetByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.NoError(t, auth.CheckPassword("given-password", stored.Password))
+
+	hashed, err := auth.HashPassword("chosen-password")
+	require.NoError(t, err)
+	require.NoError(t, service.ChangePassword(ctx, uint(user.ID), stored.Password, hashed))
+	stored, err = service.repo.GetByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.False(t, stored.MustChangePassword, "changing the password clears the flag")
+
+	_, err = service.AdminResetPassword(ctx, 999999, "")
+	assert.Error(t, err)
+}
+
+func TestUserCache_WritesInvalidateImmediately(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	service.EnableCache(time.Hour)
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, models.RoleAdmin, &models.CreateUserRequest{
+		Name: "Cached User", Email: "cached@test.com", Password: "password123", Age: 30,
+	})
+	require.NoError(t, err)
+	id := uint(user.ID)
+
+	cached, err := service.GetUserByID(ctx, id)
+	require.NoError(t, err)
+	cached.Name = "Changed By Caller"
+	again, err := service.GetUserByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Cached User", again.Name, "callers get copies of cached users")
+
+	stats, err := service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats["total_users"])
+
+	_, err = service.UpdateUser(ctx, id, &models.UpdateUserRequest{Name: stringPtr("Renamed User")})
+	require.NoError(t, err)
+	updated, err := service.GetUserByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed User", updated.Name)
+
+	_, err = service.SetUserActive(ctx, id, false)
+	require.NoError(t, err)
+	stats, err = service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats["active_users"])
+
+	require.NoError(t, service.DeleteUser(ctx, id))
+	_, err = service.GetUserByID(ctx, id)
+	assert.Error(t, err, "deleted users are not served from the cache")
+	stats, err = service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats["total_users"])
+}
+
+func TestUserCache_ServesRepeatedReads(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	service.EnableCache(time.Hour)
+	ctx := context.Background()
+
+	_, err := service.BatchCreateUsers(ctx, models.RoleAdmin, batchRequests("cached", 2))
+	require.NoError(t, err)
+	stats, err := service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats["total_users"])
+
+	// A write that bypasses the service stays unseen until the TTL
+	require.NoError(t, service.repo.Create(ctx, &models.User{Name: "Direct", Email: "direct@test.com", Password: "x", Age: 30}))
+	stats, err = service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats["total_users"])
+}
+
+func TestUserCache_RepositoryWritesInvalidate(t *testing.T) {
+	service := newBatchService(t, BatchConfig{})
+	service.EnableCache(time.Hour)
+	repo := service.repo.(*repository.UserRepository)
+	ctx := context.Background()
+
+	user := &models.User{Name: "Direct", Email: "direct@test.com", Password: "x", Age: 30, Role: models.RoleAdmin, IsActive: true}
+	require.NoError(t, repo.Create(ctx, user))
+	cached, err := service.GetUserByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, cached.Role)
+
+	// Without the hook a write that bypasses the service stays cached, but
+	// GetUserForUpdate always reads the database
+	user.Role = models.RoleUser
+	require.NoError(t, repo.Update(ctx, user))
+	cached, err = service.GetUserByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, cached.Role)
+	fresh, err := service.GetUserForUpdate(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, fresh.Role)
+
+	repo.OnChange(service.InvalidateUsers)
+	_, err = service.GetUserStats(ctx) // Fills the stats cache
+	require.NoError(t, err)
+
+	user.Role = models.RoleSuperAdmin
+	require.NoError(t, repo.Update(ctx, user))
+	cached, err = service.GetUserByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleSuperAdmin, cached.Role, "GraphQL and offboarding write through the repository")
+
+	require.NoError(t, repo.RevokeAccess(ctx, uint(user.ID), "revoked", time.Now()))
+	cached, err = service.GetUserByID(ctx, uint(user.ID))
+	require.NoError(t, err)
+	assert.False(t, cached.IsActive)
+
+	require.NoError(t, repo.Create(ctx, &models.User{Name: "Registered", Email: "registered@test.com", Password: "x", Age: 30}))
+	stats, err := service.GetUserStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats["total_users"])
+}
+
+// BenchmarkGetUserStats_Cached shows that cached stats cost the same
+// regardless of how many users there are
+func BenchmarkGetUserStats_Cached(b *testing.B) {
+	for _, users := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("users=%d", users), func(b *testing.B) {
+			service := newBatchService(b, BatchConfig{})
+			service.EnableCache(time.Hour)
+			ctx := context.Background()
+			batch := make([]*models.User, users)
+			for i := range batch {
+				batch[i] = &models.User{Name: "Bench", Email: fmt.Sprintf("bench-%d@test.com", i), Password: "x", Age: 30, IsActive: i%2 == 0}
+			}
+			require.NoError(b, service.repo.BatchCreate(ctx, batch))
+			_, err := service.GetUserStats(ctx) // Fills the cache
+			require.NoError(b, err)
+
+			for b.Loop() {
+				_, _ = service.GetUserStats(ctx)
+			}
+		})
+	}
+}
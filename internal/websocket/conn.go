@@ -5,6 +5,8 @@ package websocket
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gorilla/websocket"
 )
@@ -46,13 +48,48 @@ func IsUnexpectedCloseError(err error, expectedCodes ...int) bool {
 	return websocket.IsUnexpectedCloseError(err, expectedCodes...)
 }
 
+// FormatCloseMessage formats a close frame payload with a code and reason
+func FormatCloseMessage(closeCode int, text string) []byte {
+	return websocket.FormatCloseMessage(closeCode, text)
+}
+
+// BearerSubprotocol is offered next to the access token in
+// Sec-WebSocket-Protocol ("bearer, <token>") and echoed back on upgrade
+const BearerSubprotocol = "bearer"
+
 // Upgrader wraps gorilla/websocket.Upgrader
-var Upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow all origins in development
-		// In production, restrict this to your domain
-		return true
-	},
+type Upgrader = websocket.Upgrader
+
+// NewUpgrader returns an upgrader that only accepts the given origins
+// (see CheckOrigin) and selects BearerSubprotocol when the client offers it
+func NewUpgrader(allowedOrigins []string) *Upgrader {
+	return &Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{BearerSubprotocol},
+		CheckOrigin:     CheckOrigin(allowedOrigins),
+	}
+}
+
+// CheckOrigin accepts requests without an Origin header (non-browser
+// clients), same-origin requests and the listed origins; "*" accepts all
+func CheckOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed["*"] || allowed[strings.ToLower(origin)] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// Subprotocols returns the protocols requested in Sec-WebSocket-Protocol
+func Subprotocols(r *http.Request) []string {
+	return websocket.Subprotocols(r)
 }
@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	check := CheckOrigin([]string{"https://app.example.com/"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://api.example.com", true}, // same host as the request
+		{"https://evil.example.com", false},
+		{"http://app.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://api.example.com/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			assert.Equal(t, tt.want, check(req))
+		})
+	}
+
+	req := httptest.NewRequest("GET", "http://api.example.com/ws", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	assert.True(t, CheckOrigin([]string{"*"})(req), "* allows every origin")
+}
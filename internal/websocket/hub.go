@@ -2,10 +2,17 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"Go-Lang-project-01/internal/models"
 	"Go-Lang-project-01/pkg/logger"
+	"Go-Lang-project-01/pkg/reporting"
 )
 
 // EventType represents the type of WebSocket event
@@ -22,6 +29,101 @@ const (
 	EventHealthStatusChanged EventType = "health.status.changed"
 )
 
+// DropPolicy decides what BroadcastToAll does when the broadcast buffer is full
+type DropPolicy string
+
+const (
+	DropNewest       DropPolicy = "drop_newest"        // Discard the message being sent
+	DropOldest       DropPolicy = "drop_oldest"        // Evict the oldest queued message to make room
+	BlockWithTimeout DropPolicy = "block_with_timeout" // Wait up to BlockTimeout, then discard
+)
+
+// ParseDropPolicy validates a drop policy name; empty means DropNewest
+func ParseDropPolicy(name string) (DropPolicy, error) {
+	switch policy := DropPolicy(name); policy {
+	case "":
+		return DropNewest, nil
+	case DropNewest, DropOldest, BlockWithTimeout:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown websocket drop policy %q", name)
+	}
+}
+
+// Drop reasons reported to HubConfig.OnDrop
+const (
+	DropReasonBroadcastFull = "broadcast_buffer_full" // Hub-wide broadcast queue was full
+	DropReasonClientFull    = "client_buffer_full"    // A client's send queue was full
+)
+
+// Disconnect reasons reported to HubMetrics.ClientDisconnected
+const (
+	DisconnectReasonClosed   = "client_closed"     // The connection ended, e.g. the client went away
+	DisconnectReasonSlow     = "slow_client"       // The client's send queue was full during a broadcast
+	DisconnectReasonUser     = "user_disconnected" // DisconnectUser closed the user's connections
+	DisconnectReasonShutdown = "shutdown"          // Stop closed every connection
+	DisconnectReasonStale    = "stale_client"      // No pong within HubConfig.StaleTimeout
+)
+
+// Errors returned by Add when a client is refused. The client's send channel
+// is closed and WritePump answers with the matching close frame.
+var (
+	ErrHubStopped             = errors.New("server shutting down")
+	ErrTooManyConnections     = errors.New("server connection limit reached")
+	ErrTooManyUserConnections = errors.New("too many connections for this user")
+)
+
+// refusalCloseCodes maps the errors of Add to the code of the close frame
+var refusalCloseCodes = map[error]int{
+	ErrHubStopped:             CloseGoingAway,
+	ErrTooManyConnections:     CloseTryAgainLater,
+	ErrTooManyUserConnections: ClosePolicyViolation,
+}
+
+// HubMetrics records hub activity, e.g. as Prometheus metrics. Roles and
+// event types are passed as strings so recorders need not import this package.
+type HubMetrics interface {
+	ClientConnected(role string)
+	ClientDisconnected(role, reason string)
+	MessageSent(eventType string)
+	MessageDropped(reason string)
+}
+
+// nopHubMetrics discards hub activity
+type nopHubMetrics struct{}
+
+func (nopHubMetrics) ClientConnected(string)            {}
+func (nopHubMetrics) ClientDisconnected(string, string) {}
+func (nopHubMetrics) MessageSent(string)                {}
+func (nopHubMetrics) MessageDropped(string)             {}
+
+// HubConfig configures broadcast buffering, backpressure and connection limits.
+// Negative limits and a negative StaleTimeout disable the check.
+type HubConfig struct {
+	BroadcastBufferSize   int
+	DropPolicy            DropPolicy
+	BlockTimeout          time.Duration // Only used by BlockWithTimeout
+	Metrics               HubMetrics    // Optional, e.g. Prometheus metrics
+	MaxConnections        int           // Clients across all users
+	MaxConnectionsPerUser int
+	StaleTimeout          time.Duration // Clients without a pong for this long are evicted
+	SweepInterval         time.Duration // How often the janitor looks for stale clients
+}
+
+// DefaultHubConfig returns the settings used by NewHub. StaleTimeout leaves
+// room for one missed ping, which WritePump sends every 54 seconds.
+func DefaultHubConfig() HubConfig {
+	return HubConfig{
+		BroadcastBufferSize:   256,
+		DropPolicy:            DropNewest,
+		BlockTimeout:          100 * time.Millisecond,
+		MaxConnections:        10000,
+		MaxConnectionsPerUser: 5,
+		StaleTimeout:          2 * time.Minute,
+		SweepInterval:         15 * time.Second,
+	}
+}
+
 // Message represents a WebSocket message
 type Message struct {
 	Type      EventType              `json:"type"`
@@ -31,12 +133,76 @@ type Message struct {
 
 // Client represents a WebSocket client connection
 type Client struct {
-	ID     string
-	UserID uint
-	Role   string
-	Hub    *Hub
-	Conn   *Conn
-	Send   chan Message
+	ID          string
+	UserID      uint
+	Role        models.Role
+	RemoteAddr  string
+	ConnectedAt time.Time
+	Hub         *Hub
+	Conn        *Conn
+	Send        chan Message
+
+	// Unix nanoseconds of the last pong, or of the registration
+	lastPong atomic.Int64
+
+	// Close frame WritePump sends when the hub closes Send; set before closing
+	closeFrame []byte
+
+	// Event types the client subscribed to; nil receives every event
+	subMu         sync.RWMutex
+	subscriptions map[EventType]bool
+}
+
+// LastPong returns when the client last answered a ping. Until the first
+// pong it is the time the client was registered.
+func (c *Client) LastPong() time.Time {
+	return time.Unix(0, c.lastPong.Load())
+}
+
+// markPong records that the client answered a ping at t
+func (c *Client) markPong(t time.Time) {
+	c.lastPong.Store(t.UnixNano())
+}
+
+// Wants reports whether broadcasts of eventType should reach the client
+func (c *Client) Wants(eventType EventType) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.subscriptions == nil || c.subscriptions[eventType]
+}
+
+// Subscriptions returns the subscribed event types, sorted, or nil when the
+// client receives every event
+func (c *Client) Subscriptions() []EventType {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	if c.subscriptions == nil {
+		return nil
+	}
+	eventTypes := make([]EventType, 0, len(c.subscriptions))
+	for eventType := range c.subscriptions {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Slice(eventTypes, func(i, j int) bool { return eventTypes[i] < eventTypes[j] })
+	return eventTypes
+}
+
+// ClientInfo describes a connected client for admin tooling
+type ClientInfo struct {
+	ID                string      `json:"client_id"`
+	UserID            uint        `json:"user_id"`
+	Role              models.Role `json:"role"`
+	ConnectedAt       time.Time   `json:"connected_at"`
+	RemoteAddr        string      `json:"remote_addr,omitempty"`
+	SendQueueLength   int         `json:"send_queue_length"`
+	SendQueueCapacity int         `json:"send_queue_capacity"`
+}
+
+// ClientFilter selects and pages clients in ListClients
+type ClientFilter struct {
+	UserID uint // 0 matches every user
+	Offset int
+	Limit  int // <= 0 returns every matching client
 }
 
 // Hub maintains the set of active clients and broadcasts messages
@@ -55,37 +221,181 @@ type Hub struct {
 
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
+
+	config HubConfig
+
+	// Set by Stop; guarded by mu
+	stopped bool
+
+	// Cumulative counters, exposed in GetStats
+	broadcastDrops atomic.Uint64
+	clientDrops    atomic.Uint64
+	staleEvictions atomic.Uint64
+	rejections     atomic.Uint64
 }
 
-// NewHub creates a new Hub instance
+// NewHub creates a new Hub instance with DefaultHubConfig
 func NewHub() *Hub {
+	return NewHubWithConfig(DefaultHubConfig())
+}
+
+// NewHubWithConfig creates a new Hub instance; zero values fall back to defaults
+func NewHubWithConfig(config HubConfig) *Hub {
+	defaults := DefaultHubConfig()
+	if config.BroadcastBufferSize <= 0 {
+		config.BroadcastBufferSize = defaults.BroadcastBufferSize
+	}
+	if config.DropPolicy == "" {
+		config.DropPolicy = defaults.DropPolicy
+	}
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = defaults.BlockTimeout
+	}
+	if config.Metrics == nil {
+		config.Metrics = nopHubMetrics{}
+	}
+	if config.MaxConnections == 0 {
+		config.MaxConnections = defaults.MaxConnections
+	}
+	if config.MaxConnectionsPerUser == 0 {
+		config.MaxConnectionsPerUser = defaults.MaxConnectionsPerUser
+	}
+	if config.StaleTimeout == 0 {
+		config.StaleTimeout = defaults.StaleTimeout
+	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = defaults.SweepInterval
+	}
+
 	return &Hub{
 		clients:    make(map[*Client]bool),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
-		Broadcast:  make(chan Message, 256), // Buffered channel
+		Broadcast:  make(chan Message, config.BroadcastBufferSize),
+		config:     config,
+	}
+}
+
+// recordDrop counts a dropped message and notifies the configured recorder
+func (h *Hub) recordDrop(reason string) {
+	if reason == DropReasonBroadcastFull {
+		h.broadcastDrops.Add(1)
+	} else {
+		h.clientDrops.Add(1)
+	}
+	h.config.Metrics.MessageDropped(reason)
+}
+
+// removeClient unregisters a client and closes its send channel, which makes
+// WritePump close the connection. The caller holds the write lock.
+func (h *Hub) removeClient(client *Client, reason string) {
+	delete(h.clients, client)
+	close(client.Send)
+	h.config.Metrics.ClientDisconnected(client.Role.String(), reason)
+}
+
+// Add registers a client right away. If the hub is stopped or a connection
+// limit is reached, the client is refused: its send channel is closed, so
+// WritePump sends a close frame with the reason, and the error is returned.
+// Sending the client on Register does the same without reporting the error.
+func (h *Hub) Add(client *Client) error {
+	h.mu.Lock()
+	err := h.admit(client)
+	if err != nil {
+		client.closeFrame = FormatCloseMessage(refusalCloseCodes[err], err.Error())
+		close(client.Send)
+		h.mu.Unlock()
+		if err != ErrHubStopped {
+			h.rejections.Add(1)
+			logger.Warn("WebSocket client rejected", "client_id", client.ID, "user_id", client.UserID, "reason", err)
+		}
+		return err
+	}
+
+	now := time.Now()
+	if client.ConnectedAt.IsZero() {
+		client.ConnectedAt = now
 	}
+	client.markPong(now)
+	h.clients[client] = true
+	total := len(h.clients)
+	h.mu.Unlock()
+
+	h.config.Metrics.ClientConnected(client.Role.String())
+	logger.Info("WebSocket client connected",
+		"client_id", client.ID,
+		"user_id", client.UserID,
+		"total_clients", total,
+	)
+	return nil
+}
+
+// admit checks whether a client may be registered. The caller holds the write lock.
+func (h *Hub) admit(client *Client) error {
+	if h.stopped {
+		return ErrHubStopped
+	}
+	if h.config.MaxConnections > 0 && len(h.clients) >= h.config.MaxConnections {
+		return ErrTooManyConnections
+	}
+	if h.config.MaxConnectionsPerUser > 0 {
+		count := 0
+		for other := range h.clients {
+			if other.UserID == client.UserID {
+				count++
+			}
+		}
+		if count >= h.config.MaxConnectionsPerUser {
+			return ErrTooManyUserConnections
+		}
+	}
+	return nil
+}
+
+// EvictStale disconnects clients whose last pong is older than
+// HubConfig.StaleTimeout at now and returns how many were evicted.
+// Run calls it every SweepInterval.
+func (h *Hub) EvictStale(now time.Time) int {
+	if h.config.StaleTimeout < 0 {
+		return 0
+	}
+	deadline := now.Add(-h.config.StaleTimeout)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count := 0
+	for client := range h.clients {
+		if client.LastPong().Before(deadline) {
+			h.removeClient(client, DisconnectReasonStale)
+			count++
+		}
+	}
+
+	if count > 0 {
+		h.staleEvictions.Add(uint64(count))
+		logger.Info("WebSocket stale clients evicted", "count", count, "total_clients", len(h.clients))
+	}
+	return count
 }
 
 // Run starts the hub's main event loop
 func (h *Hub) Run() {
+	janitor := time.NewTicker(h.config.SweepInterval)
+	defer janitor.Stop()
+
 	for {
 		select {
 		case client := <-h.Register:
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
-			logger.Info("WebSocket client connected",
-				"client_id", client.ID,
-				"user_id", client.UserID,
-				"total_clients", len(h.clients),
-			)
+			h.Add(client)
+
+		case now := <-janitor.C:
+			h.EvictStale(now)
 
 		case client := <-h.Unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.Send)
+				h.removeClient(client, DisconnectReasonClosed)
 				logger.Info("WebSocket client disconnected",
 					"client_id", client.ID,
 					"user_id", client.UserID,
@@ -95,25 +405,50 @@ func (h *Hub) Run() {
 			h.mu.Unlock()
 
 		case message := <-h.Broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					// Client's send channel is full, close and unregister
-					h.mu.RUnlock()
-					h.mu.Lock()
-					close(client.Send)
-					delete(h.clients, client)
-					h.mu.Unlock()
-					h.mu.RLock()
-					logger.Warn("Client send channel full, disconnecting",
-						"client_id", client.ID,
-					)
-				}
-			}
-			h.mu.RUnlock()
+			h.disconnect(h.deliver(message))
+		}
+	}
+}
+
+// deliver queues a broadcast for every interested client under the read
+// lock, so targeted broadcasts are not held up, and returns the clients
+// whose send channel was full
+func (h *Hub) deliver(message Message) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var slow []*Client
+	for client := range h.clients {
+		if !client.Wants(message.Type) {
+			continue
+		}
+		select {
+		case client.Send <- message:
+		default:
+			slow = append(slow, client)
+		}
+	}
+	return slow
+}
+
+// disconnect unregisters slow clients and closes their send channel.
+// Clients already removed since deliver released the lock are skipped.
+func (h *Hub) disconnect(slow []*Client) {
+	if len(slow) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, client := range slow {
+		if !h.clients[client] {
+			continue
 		}
+		h.removeClient(client, DisconnectReasonSlow)
+		h.recordDrop(DropReasonClientFull)
+		logger.Warn("Client send channel full, disconnecting",
+			"client_id", client.ID,
+		)
 	}
 }
 
@@ -125,11 +460,53 @@ func (h *Hub) BroadcastToAll(eventType EventType, data map[string]interface{}) {
 		Timestamp: time.Now(),
 	}
 
+	if h.enqueue(message) {
+		logger.Debug("Broadcasting message", "type", eventType)
+		return
+	}
+
+	h.recordDrop(DropReasonBroadcastFull)
+	logger.Warn("Broadcast channel full, message dropped", "type", eventType, "policy", h.config.DropPolicy)
+}
+
+// enqueue puts a message on the broadcast channel according to the drop policy.
+// It returns false if the message itself was dropped.
+func (h *Hub) enqueue(message Message) bool {
 	select {
 	case h.Broadcast <- message:
-		logger.Debug("Broadcasting message", "type", eventType, "clients", len(h.clients))
+		return true
 	default:
-		logger.Warn("Broadcast channel full, message dropped", "type", eventType)
+	}
+
+	switch h.config.DropPolicy {
+	case DropOldest:
+		// Bounded retries: concurrent senders may refill the slot we free
+		for attempt := 0; attempt < 3; attempt++ {
+			select {
+			case <-h.Broadcast:
+				h.recordDrop(DropReasonBroadcastFull)
+			default:
+			}
+			select {
+			case h.Broadcast <- message:
+				return true
+			default:
+			}
+		}
+		return false
+
+	case BlockWithTimeout:
+		timer := time.NewTimer(h.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case h.Broadcast <- message:
+			return true
+		case <-timer.C:
+			return false
+		}
+
+	default: // DropNewest
+		return false
 	}
 }
 
@@ -146,11 +523,12 @@ func (h *Hub) BroadcastToUser(userID uint, eventType EventType, data map[string]
 
 	count := 0
 	for client := range h.clients {
-		if client.UserID == userID {
+		if client.UserID == userID && client.Wants(eventType) {
 			select {
 			case client.Send <- message:
 				count++
 			default:
+				h.recordDrop(DropReasonClientFull)
 				logger.Warn("Client send channel full", "client_id", client.ID)
 			}
 		}
@@ -161,8 +539,26 @@ func (h *Hub) BroadcastToUser(userID uint, eventType EventType, data map[string]
 	}
 }
 
-// BroadcastToRole sends a message to all users with a specific role
-func (h *Hub) BroadcastToRole(role string, eventType EventType, data map[string]interface{}) {
+// sendTo queues a message for one client if it is still registered.
+// The read lock keeps the hub from closing client.Send meanwhile.
+func (h *Hub) sendTo(client *Client, message Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.clients[client] {
+		return
+	}
+	select {
+	case client.Send <- message:
+	default:
+		h.recordDrop(DropReasonClientFull)
+		logger.Warn("Client send channel full", "client_id", client.ID)
+	}
+}
+
+// BroadcastToRole sends a message to all users with exactly the given role.
+// Use BroadcastToMinimumRole unless higher roles must be excluded.
+func (h *Hub) BroadcastToRole(role models.Role, eventType EventType, data map[string]interface{}) {
 	message := Message{
 		Type:      eventType,
 		Data:      data,
@@ -174,11 +570,12 @@ func (h *Hub) BroadcastToRole(role string, eventType EventType, data map[string]
 
 	count := 0
 	for client := range h.clients {
-		if client.Role == role {
+		if client.Role == role && client.Wants(eventType) {
 			select {
 			case client.Send <- message:
 				count++
 			default:
+				h.recordDrop(DropReasonClientFull)
 				logger.Warn("Client send channel full", "client_id", client.ID)
 			}
 		}
@@ -189,18 +586,120 @@ func (h *Hub) BroadcastToRole(role string, eventType EventType, data map[string]
 	}
 }
 
+// BroadcastToMinimumRole sends a message to all users whose role is at or above
+// the given role, e.g. "admin" reaches both admins and superadmins
+func (h *Hub) BroadcastToMinimumRole(role models.Role, eventType EventType, data map[string]interface{}) {
+	message := Message{
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := 0
+	for client := range h.clients {
+		if client.Role.AtLeast(role) && client.Wants(eventType) {
+			select {
+			case client.Send <- message:
+				count++
+			default:
+				h.recordDrop(DropReasonClientFull)
+				logger.Warn("Client send channel full", "client_id", client.ID)
+			}
+		}
+	}
+
+	if count > 0 {
+		logger.Debug("Message sent to minimum role", "role", role, "clients", count, "type", eventType)
+	}
+}
+
+// DisconnectUser closes all connections of a user with a policy violation
+// close frame carrying reason, and returns how many were closed. It takes
+// the write lock, so it is safe to call while Run delivers a broadcast.
+func (h *Hub) DisconnectUser(userID uint, reason string) int {
+	closeFrame := FormatCloseMessage(ClosePolicyViolation, truncateCloseReason(reason))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count := 0
+	for client := range h.clients {
+		if client.UserID == userID {
+			client.closeFrame = closeFrame
+			h.removeClient(client, DisconnectReasonUser)
+			count++
+		}
+	}
+
+	if count > 0 {
+		logger.Info("WebSocket user disconnected", "user_id", userID, "connections", count, "reason", reason)
+	}
+
+	return count
+}
+
+// maxCloseReason is the longest reason a close frame holds: control frames
+// carry at most 125 bytes, two of which are the close code
+const maxCloseReason = 123
+
+// truncateCloseReason cuts reason to maxCloseReason bytes without splitting a rune
+func truncateCloseReason(reason string) string {
+	if len(reason) <= maxCloseReason {
+		return reason
+	}
+	end := maxCloseReason
+	for end > 0 && !utf8.RuneStart(reason[end]) {
+		end--
+	}
+	return reason[:end]
+}
+
+// Stop closes every client connection with a "going away" close frame and
+// refuses clients that register afterwards. It is called on shutdown, since
+// http.Server.Shutdown does not wait for hijacked WebSocket connections.
+// Run keeps draining Unregister so the pumps of closed clients can exit.
+func (h *Hub) Stop() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stopped = true
+	count := len(h.clients)
+	for client := range h.clients {
+		h.removeClient(client, DisconnectReasonShutdown)
+	}
+
+	logger.Info("WebSocket hub stopped", "connections_closed", count)
+	return count
+}
+
+// Stopped reports whether Stop has been called
+func (h *Hub) Stopped() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.stopped
+}
+
 // GetStats returns current hub statistics
 func (h *Hub) GetStats() map[string]interface{} {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
 	stats := map[string]interface{}{
-		"total_clients": len(h.clients),
-		"timestamp":     time.Now(),
+		"total_clients":           len(h.clients),
+		"timestamp":               time.Now(),
+		"broadcast_buffer_size":   cap(h.Broadcast),
+		"broadcast_queue_length":  len(h.Broadcast),
+		"drop_policy":             h.config.DropPolicy,
+		"broadcast_dropped_total": h.broadcastDrops.Load(),
+		"client_dropped_total":    h.clientDrops.Load(),
+		"stale_evicted_total":     h.staleEvictions.Load(),
+		"rejected_total":          h.rejections.Load(),
 	}
 
 	// Count by role
-	roleCount := make(map[string]int)
+	roleCount := make(map[models.Role]int)
 	for client := range h.clients {
 		roleCount[client.Role]++
 	}
@@ -209,8 +708,50 @@ func (h *Hub) GetStats() map[string]interface{} {
 	return stats
 }
 
+// ListClients returns the clients matching filter, oldest connection first,
+// along with the number of matching clients before paging
+func (h *Hub) ListClients(filter ClientFilter) ([]ClientInfo, int) {
+	h.mu.RLock()
+	matched := make([]ClientInfo, 0, len(h.clients))
+	for client := range h.clients {
+		if filter.UserID != 0 && client.UserID != filter.UserID {
+			continue
+		}
+		matched = append(matched, ClientInfo{
+			ID:                client.ID,
+			UserID:            client.UserID,
+			Role:              client.Role,
+			ConnectedAt:       client.ConnectedAt,
+			RemoteAddr:        client.RemoteAddr,
+			SendQueueLength:   len(client.Send),
+			SendQueueCapacity: cap(client.Send),
+		})
+	}
+	h.mu.RUnlock()
+
+	// Map iteration order is random; sort so that pages are stable
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].ConnectedAt.Equal(matched[j].ConnectedAt) {
+			return matched[i].ConnectedAt.Before(matched[j].ConnectedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	total := len(matched)
+	if filter.Offset >= total {
+		return []ClientInfo{}, total
+	}
+	matched = matched[max(filter.Offset, 0):]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+	return matched, total
+}
+
 // WritePump pumps messages from the hub to the websocket connection
 func (c *Client) WritePump() {
+	defer reporting.Recover(c.reportTags("write_pump"))
+
 	ticker := time.NewTicker(54 * time.Second) // Ping every 54 seconds
 	defer func() {
 		ticker.Stop()
@@ -223,7 +764,11 @@ func (c *Client) WritePump() {
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
 				// Hub closed the channel
-				c.Conn.WriteMessage(CloseMessage, []byte{})
+				payload := c.closeFrame
+				if payload == nil && c.Hub != nil && c.Hub.Stopped() {
+					payload = FormatCloseMessage(CloseGoingAway, ErrHubStopped.Error())
+				}
+				c.Conn.WriteMessage(CloseMessage, payload)
 				return
 			}
 
@@ -231,6 +776,7 @@ func (c *Client) WritePump() {
 			data, err := json.Marshal(message)
 			if err != nil {
 				logger.Error("Failed to marshal message", "error", err)
+				reporting.Error(err, c.reportTags("write_pump"))
 				continue
 			}
 
@@ -238,6 +784,9 @@ func (c *Client) WritePump() {
 				logger.Error("Write error", "error", err, "client_id", c.ID)
 				return
 			}
+			if c.Hub != nil {
+				c.Hub.config.Metrics.MessageSent(string(message.Type))
+			}
 
 		case <-ticker.C:
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
@@ -250,6 +799,8 @@ func (c *Client) WritePump() {
 
 // ReadPump pumps messages from the websocket connection to the hub
 func (c *Client) ReadPump() {
+	defer reporting.Recover(c.reportTags("read_pump"))
+
 	defer func() {
 		c.Hub.Unregister <- c
 		c.Conn.Close()
@@ -257,19 +808,31 @@ func (c *Client) ReadPump() {
 
 	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.Conn.SetPongHandler(func(string) error {
+		c.markPong(time.Now())
 		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
 
 	for {
-		_, _, err := c.Conn.ReadMessage()
+		messageType, data, err := c.Conn.ReadMessage()
 		if err != nil {
 			if IsUnexpectedCloseError(err, CloseGoingAway, CloseAbnormalClosure) {
 				logger.Error("WebSocket read error", "error", err, "client_id", c.ID)
 			}
 			break
 		}
-		// Currently we don't process incoming messages from clients
-		// This is a broadcast-only system
+		if messageType == TextMessage {
+			c.handleClientMessage(data)
+		}
+	}
+}
+
+// reportTags identifies the client in error reports
+func (c *Client) reportTags(pump string) reporting.Tags {
+	return reporting.Tags{
+		"component": "websocket",
+		"pump":      pump,
+		"client_id": c.ID,
+		"user_id":   fmt.Sprint(c.UserID),
 	}
 }
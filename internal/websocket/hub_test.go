@@ -0,0 +1,808 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics counts hub activity per label
+type recordingMetrics struct {
+	mu           sync.Mutex
+	connected    map[string]int
+	disconnected map[string]int
+	sent         map[string]int
+	dropped      map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		connected:    map[string]int{},
+		disconnected: map[string]int{},
+		sent:         map[string]int{},
+		dropped:      map[string]int{},
+	}
+}
+
+func (m *recordingMetrics) ClientConnected(role string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected[role]++
+}
+
+func (m *recordingMetrics) ClientDisconnected(role, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected[role]--
+	m.disconnected[reason]++
+}
+
+func (m *recordingMetrics) MessageSent(eventType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent[eventType]++
+}
+
+func (m *recordingMetrics) MessageDropped(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped[reason]++
+}
+
+// get reads one counter under the lock
+func (m *recordingMetrics) get(counters map[string]int, label string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return counters[label]
+}
+
+// floodBroadcasts sends n broadcasts from several goroutines without a running hub,
+// so nothing drains the buffer and every overflow exercises the drop policy
+func floodBroadcasts(hub *Hub, n int) {
+	const senders = 8
+	var wg sync.WaitGroup
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := s; i < n; i += senders {
+				hub.BroadcastToAll(EventSystemAlert, map[string]interface{}{"seq": i})
+			}
+		}(s)
+	}
+	wg.Wait()
+}
+
+func TestBroadcastDropPolicies_Load(t *testing.T) {
+	const (
+		bufferSize = 16
+		messages   = 2000
+	)
+
+	t.Run("drop_newest keeps the first messages", func(t *testing.T) {
+		recorded := newRecordingMetrics()
+		hub := NewHubWithConfig(HubConfig{
+			BroadcastBufferSize: bufferSize,
+			DropPolicy:          DropNewest,
+			Metrics:             recorded,
+		})
+
+		floodBroadcasts(hub, messages)
+
+		stats := hub.GetStats()
+		assert.Equal(t, bufferSize, len(hub.Broadcast))
+		assert.Equal(t, uint64(messages-bufferSize), stats["broadcast_dropped_total"])
+		assert.Equal(t, messages-bufferSize, recorded.get(recorded.dropped, DropReasonBroadcastFull), "Every drop reaches the recorder")
+	})
+
+	t.Run("drop_oldest keeps the latest messages", func(t *testing.T) {
+		hub := NewHubWithConfig(HubConfig{
+			BroadcastBufferSize: bufferSize,
+			DropPolicy:          DropOldest,
+		})
+
+		// Single sender so "latest" is well defined
+		for i := 0; i < messages; i++ {
+			hub.BroadcastToAll(EventSystemAlert, map[string]interface{}{"seq": i})
+		}
+
+		assert.Equal(t, uint64(messages-bufferSize), hub.GetStats()["broadcast_dropped_total"])
+		first := <-hub.Broadcast
+		assert.Equal(t, messages-bufferSize, first.Data["seq"], "Oldest queued message is the first one after the evictions")
+	})
+
+	t.Run("drop_oldest under concurrent load accounts for every message", func(t *testing.T) {
+		hub := NewHubWithConfig(HubConfig{
+			BroadcastBufferSize: bufferSize,
+			DropPolicy:          DropOldest,
+		})
+
+		floodBroadcasts(hub, messages)
+
+		dropped := hub.GetStats()["broadcast_dropped_total"].(uint64)
+		assert.Equal(t, uint64(messages), dropped+uint64(len(hub.Broadcast)), "Messages are either queued or counted as dropped")
+	})
+
+	t.Run("block_with_timeout waits for a slow consumer", func(t *testing.T) {
+		hub := NewHubWithConfig(HubConfig{
+			BroadcastBufferSize: bufferSize,
+			DropPolicy:          BlockWithTimeout,
+			BlockTimeout:        time.Second,
+		})
+
+		// Slow consumer drains one message per millisecond
+		done := make(chan struct{})
+		var consumed atomic.Uint64
+		go func() {
+			for {
+				select {
+				case <-hub.Broadcast:
+					consumed.Add(1)
+					time.Sleep(time.Millisecond)
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		floodBroadcasts(hub, 200)
+		close(done)
+
+		assert.Zero(t, hub.GetStats()["broadcast_dropped_total"], "Consumer is fast enough for the timeout")
+		assert.Equal(t, uint64(200), consumed.Load()+uint64(len(hub.Broadcast)))
+	})
+
+	t.Run("block_with_timeout drops after the timeout", func(t *testing.T) {
+		timeout := 20 * time.Millisecond
+		hub := NewHubWithConfig(HubConfig{
+			BroadcastBufferSize: 1,
+			DropPolicy:          BlockWithTimeout,
+			BlockTimeout:        timeout,
+		})
+
+		hub.BroadcastToAll(EventSystemAlert, nil)
+		start := time.Now()
+		hub.BroadcastToAll(EventSystemAlert, nil)
+
+		assert.GreaterOrEqual(t, time.Since(start), timeout)
+		assert.Equal(t, uint64(1), hub.GetStats()["broadcast_dropped_total"])
+	})
+}
+
+func TestBroadcast_SlowClientsUnderLoad(t *testing.T) {
+	const (
+		clients  = 100
+		slow     = 5
+		messages = 500
+		senders  = 8
+	)
+	hub := NewHubWithConfig(HubConfig{
+		BroadcastBufferSize: 64,
+		DropPolicy:          BlockWithTimeout,
+		BlockTimeout:        5 * time.Second,
+	})
+	go hub.Run()
+
+	// Fast clients can buffer every message, slow ones stop reading after a few
+	counts := make([]atomic.Int64, clients)
+	all := make([]*Client, clients)
+	for i := range all {
+		capacity := messages + clients
+		if i < slow {
+			capacity = 4
+		}
+		all[i] = &Client{ID: fmt.Sprint(i), UserID: uint(i + 1), Role: models.RoleAdmin, Hub: hub, Send: make(chan Message, capacity)}
+		hub.Register <- all[i]
+		if i >= slow {
+			go func() {
+				for range all[i].Send {
+					counts[i].Add(1)
+				}
+			}()
+		}
+	}
+
+	require.Eventually(t, func() bool { return hub.GetStats()["total_clients"] == clients }, time.Second, 5*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for s := range senders {
+		wg.Go(func() {
+			for n := s; n < messages; n += senders {
+				hub.BroadcastToAll(EventSystemAlert, map[string]interface{}{"seq": n})
+			}
+		})
+	}
+	// Targeted broadcasts and stats run concurrently with the run loop
+	wg.Go(func() {
+		for n := range clients {
+			hub.BroadcastToUser(uint(n+1), EventUserUpdated, nil)
+			hub.GetStats()
+		}
+	})
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		for i := slow; i < clients; i++ {
+			if counts[i].Load() != messages+1 {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 10*time.Millisecond, "every fast client receives every message")
+
+	stats := hub.GetStats()
+	assert.Equal(t, clients-slow, stats["total_clients"], "slow clients are disconnected")
+	assert.Equal(t, uint64(0), stats["broadcast_dropped_total"])
+	assert.GreaterOrEqual(t, stats["client_dropped_total"], uint64(slow))
+	for _, client := range all[:slow] {
+		for range client.Send {
+			// Drain the queued messages until the hub closes the channel
+		}
+	}
+}
+
+func TestHubMetrics(t *testing.T) {
+	recorded := newRecordingMetrics()
+	hub := NewHubWithConfig(HubConfig{Metrics: recorded})
+	go hub.Run()
+
+	register := func(id string, userID uint, role models.Role, capacity int) *Client {
+		client := &Client{ID: id, UserID: userID, Role: role, Hub: hub, Send: make(chan Message, capacity)}
+		hub.Register <- client
+		return client
+	}
+	leaving := register("leaving", 1, models.RoleUser, 4)
+	slow := register("slow", 2, models.RoleUser, 1)
+	kicked := register("kicked", 3, models.RoleAdmin, 4)
+	register("remaining", 4, models.RoleAdmin, 4)
+	require.Eventually(t, func() bool { return hub.GetStats()["total_clients"] == 4 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, recorded.get(recorded.connected, "user"))
+	assert.Equal(t, 2, recorded.get(recorded.connected, "admin"))
+
+	hub.Unregister <- leaving
+	hub.BroadcastToAll(EventSystemAlert, nil)
+	hub.BroadcastToAll(EventSystemAlert, nil)
+	require.Eventually(t, func() bool { return recorded.get(recorded.disconnected, DisconnectReasonSlow) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, recorded.get(recorded.disconnected, DisconnectReasonClosed))
+	assert.Equal(t, 1, recorded.get(recorded.dropped, DropReasonClientFull))
+	assert.Equal(t, 0, recorded.get(recorded.connected, "user"), "both users left")
+	_, open := <-slow.Send
+	assert.True(t, open, "the queued message is still delivered")
+
+	assert.Equal(t, 1, hub.DisconnectUser(kicked.UserID, "kicked"))
+	assert.Equal(t, 1, recorded.get(recorded.disconnected, DisconnectReasonUser))
+
+	assert.Equal(t, 1, hub.Stop())
+	assert.Equal(t, 1, recorded.get(recorded.disconnected, DisconnectReasonShutdown))
+	assert.Equal(t, 0, recorded.get(recorded.connected, "admin"))
+}
+
+func TestHubMetrics_MessageSent(t *testing.T) {
+	recorded := newRecordingMetrics()
+	hub := NewHubWithConfig(HubConfig{Metrics: recorded})
+	go hub.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := NewUpgrader(nil).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{ID: "c1", UserID: 1, Role: models.RoleUser, Hub: hub, Conn: &Conn{conn}, Send: make(chan Message, 4)}
+		hub.Register <- client
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return hub.GetStats()["total_clients"] == 1 }, time.Second, 5*time.Millisecond)
+
+	hub.BroadcastToUser(1, EventProfileUpdated, nil)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return recorded.get(recorded.sent, string(EventProfileUpdated)) == 1 }, time.Second, 5*time.Millisecond)
+
+	conn.Close()
+	require.Eventually(t, func() bool { return recorded.get(recorded.disconnected, DisconnectReasonClosed) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, recorded.get(recorded.connected, "user"))
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	policy, err := ParseDropPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, DropNewest, policy)
+
+	policy, err = ParseDropPolicy("block_with_timeout")
+	require.NoError(t, err)
+	assert.Equal(t, BlockWithTimeout, policy)
+
+	_, err = ParseDropPolicy("drop_everything")
+	assert.Error(t, err)
+}
+
+func TestListClients(t *testing.T) {
+	hub := NewHub()
+	base := time.Now()
+
+	// Insert directly: Run is not needed to inspect the registry
+	for i, spec := range []struct {
+		id     string
+		userID uint
+	}{
+		{"c", 1}, {"a", 2}, {"b", 1}, {"d", 3},
+	} {
+		client := &Client{
+			ID:          spec.id,
+			UserID:      spec.userID,
+			Role:        "user",
+			RemoteAddr:  "203.0.113.1",
+			ConnectedAt: base.Add(time.Duration(i) * time.Second),
+			Send:        make(chan Message, 4),
+		}
+		hub.clients[client] = true
+	}
+	for c := range hub.clients {
+		if c.ID == "c" {
+			c.Send <- Message{Type: EventSystemAlert}
+		}
+	}
+
+	t.Run("all clients oldest first", func(t *testing.T) {
+		clients, total := hub.ListClients(ClientFilter{})
+		require.Len(t, clients, 4)
+		assert.Equal(t, 4, total)
+		assert.Equal(t, []string{"c", "a", "b", "d"}, clientIDs(clients))
+		assert.Equal(t, 1, clients[0].SendQueueLength)
+		assert.Equal(t, 4, clients[0].SendQueueCapacity)
+		assert.Equal(t, "203.0.113.1", clients[0].RemoteAddr)
+	})
+
+	t.Run("filter by user", func(t *testing.T) {
+		clients, total := hub.ListClients(ClientFilter{UserID: 1})
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []string{"c", "b"}, clientIDs(clients))
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		clients, total := hub.ListClients(ClientFilter{Offset: 1, Limit: 2})
+		assert.Equal(t, 4, total)
+		assert.Equal(t, []string{"a", "b"}, clientIDs(clients))
+
+		clients, total = hub.ListClients(ClientFilter{Offset: 10, Limit: 2})
+		assert.Equal(t, 4, total)
+		assert.Empty(t, clients)
+	})
+}
+
+func clientIDs(clients []ClientInfo) []string {
+	ids := make([]string, 0, len(clients))
+	for _, c := range clients {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestBroadcastToMinimumRole(t *testing.T) {
+	hub := NewHub()
+
+	clients := make(map[models.Role]*Client)
+	for _, role := range []models.Role{models.RoleUser, models.RoleAdmin, models.RoleSuperAdmin} {
+		client := &Client{ID: role.String(), Role: role, Send: make(chan Message, 4)}
+		hub.clients[client] = true
+		clients[role] = client
+	}
+
+	received := func(role models.Role) bool {
+		select {
+		case <-clients[role].Send:
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("admin events reach superadmins", func(t *testing.T) {
+		hub.BroadcastToMinimumRole("admin", EventUserCreated, map[string]interface{}{"id": 1})
+
+		assert.True(t, received("admin"))
+		assert.True(t, received("superadmin"))
+		assert.False(t, received("user"))
+	})
+
+	t.Run("user events reach every role", func(t *testing.T) {
+		hub.BroadcastToMinimumRole("user", EventSystemAlert, nil)
+
+		assert.True(t, received("user"))
+		assert.True(t, received("admin"))
+		assert.True(t, received("superadmin"))
+	})
+
+	t.Run("exact role broadcast stays exclusive", func(t *testing.T) {
+		hub.BroadcastToRole("admin", EventUserCreated, nil)
+
+		assert.True(t, received("admin"))
+		assert.False(t, received("superadmin"))
+	})
+}
+
+func TestCapabilities(t *testing.T) {
+	caps := Capabilities()
+
+	assert.Equal(t, ProtocolVersion, caps["protocol_version"])
+	assert.Equal(t, []string{"ping", "subscribe", "unsubscribe"}, caps["actions"])
+	assert.Contains(t, caps["event_types"], EventUserCreated)
+	assert.Contains(t, caps["event_types"], EventConnectionEstablished)
+	assert.Equal(t, 0, caps["replay_horizon_seconds"])
+}
+
+func TestHandleClientMessage(t *testing.T) {
+	hub := NewHub()
+	client := &Client{ID: "c1", Hub: hub, Send: make(chan Message, 4)}
+	hub.clients[client] = true
+
+	t.Run("ping is answered with pong", func(t *testing.T) {
+		client.handleClientMessage([]byte(`{"action":"ping","data":{"seq":7}}`))
+
+		require.Len(t, client.Send, 1)
+		msg := <-client.Send
+		assert.Equal(t, EventPong, msg.Type)
+		assert.Equal(t, float64(7), msg.Data["seq"])
+	})
+
+	t.Run("unknown actions and malformed frames are ignored", func(t *testing.T) {
+		client.handleClientMessage([]byte(`{"action":"teleport"}`))
+		client.handleClientMessage([]byte(`not json`))
+
+		assert.Empty(t, client.Send)
+	})
+
+	t.Run("no reply after the client is unregistered", func(t *testing.T) {
+		delete(hub.clients, client)
+		client.handleClientMessage([]byte(`{"action":"ping"}`))
+
+		assert.Empty(t, client.Send)
+	})
+}
+
+func TestSubscriptions_FilterBroadcasts(t *testing.T) {
+	hub := NewHub()
+	newClient := func(id string, userID uint, role models.Role) *Client {
+		client := &Client{ID: id, UserID: userID, Role: role, Hub: hub, Send: make(chan Message, 8)}
+		hub.clients[client] = true
+		return client
+	}
+	everything := newClient("everything", 1, models.RoleAdmin)
+	roles := newClient("roles", 2, models.RoleAdmin)
+	alerts := newClient("alerts", 3, models.RoleAdmin)
+
+	ack := func(client *Client, frame string) Message {
+		t.Helper()
+		client.handleClientMessage([]byte(frame))
+		require.Len(t, client.Send, 1)
+		msg := <-client.Send
+		require.Equal(t, EventSubscriptionAck, msg.Type)
+		return msg
+	}
+	received := func(client *Client) []EventType {
+		var types []EventType
+		for len(client.Send) > 0 {
+			types = append(types, (<-client.Send).Type)
+		}
+		return types
+	}
+	broadcastAll := func() {
+		hub.BroadcastToMinimumRole(models.RoleAdmin, EventUserCreated, nil)
+		hub.BroadcastToRole(models.RoleAdmin, EventUserRoleChanged, nil)
+		for _, userID := range []uint{1, 2, 3} {
+			hub.BroadcastToUser(userID, EventSystemAlert, nil)
+		}
+	}
+
+	msg := ack(roles, `{"action":"subscribe","events":["user.role.changed","user.exploded"]}`)
+	assert.Equal(t, "subscribe", msg.Data["action"])
+	assert.Equal(t, []EventType{EventUserRoleChanged}, msg.Data["events"])
+	assert.Equal(t, []EventType{"user.exploded"}, msg.Data["unknown_events"])
+	ack(alerts, `{"action":"subscribe","events":["user.created","system.alert"]}`)
+
+	t.Run("subscribers only receive their event types", func(t *testing.T) {
+		broadcastAll()
+
+		assert.Equal(t, []EventType{EventUserCreated, EventUserRoleChanged, EventSystemAlert}, received(everything),
+			"clients without a subscription receive everything")
+		assert.Equal(t, []EventType{EventUserRoleChanged}, received(roles))
+		assert.Equal(t, []EventType{EventUserCreated, EventSystemAlert}, received(alerts))
+	})
+
+	t.Run("broadcasts through the run loop are filtered too", func(t *testing.T) {
+		go hub.Run()
+		hub.BroadcastToAll(EventUserRoleChanged, nil)
+		hub.BroadcastToAll(EventSystemAlert, nil)
+
+		require.Eventually(t, func() bool {
+			return len(everything.Send) == 2 && len(roles.Send) == 1 && len(alerts.Send) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []EventType{EventUserRoleChanged}, received(roles))
+		assert.Equal(t, []EventType{EventSystemAlert}, received(alerts))
+		received(everything)
+	})
+
+	t.Run("unsubscribe removes event types", func(t *testing.T) {
+		msg := ack(alerts, `{"action":"unsubscribe","events":["system.alert"]}`)
+		assert.Equal(t, []EventType{EventUserCreated}, msg.Data["events"])
+
+		msg = ack(everything, `{"action":"unsubscribe","events":["user.created"]}`)
+		assert.NotContains(t, msg.Data["events"], EventUserCreated)
+		assert.Contains(t, msg.Data["events"], EventUserRoleChanged)
+
+		broadcastAll()
+		assert.Equal(t, []EventType{EventUserRoleChanged}, received(roles))
+		assert.Equal(t, []EventType{EventUserCreated}, received(alerts))
+		assert.Equal(t, []EventType{EventUserRoleChanged, EventSystemAlert}, received(everything))
+	})
+
+	t.Run("unsubscribe without events receives everything again", func(t *testing.T) {
+		msg := ack(roles, `{"action":"unsubscribe"}`)
+		assert.Nil(t, msg.Data["events"])
+
+		broadcastAll()
+		assert.Len(t, received(roles), 3)
+	})
+}
+
+func TestStop_ClosesConnectionsAndRefusesNewClients(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := NewUpgrader(nil).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{ID: "c1", UserID: 1, Hub: hub, Conn: &Conn{conn}, Send: make(chan Message, 4)}
+		hub.Register <- client
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return hub.GetStats()["total_clients"] == 1 }, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, 1, hub.Stop())
+	assert.True(t, hub.Stopped())
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+
+	// Clients registering after Stop are closed straight away
+	late := &Client{ID: "late", Send: make(chan Message, 1)}
+	hub.Register <- late
+	_, open := <-late.Send
+	assert.False(t, open)
+	assert.Equal(t, 0, hub.GetStats()["total_clients"])
+}
+
+func TestEvictStale(t *testing.T) {
+	hub := NewHubWithConfig(HubConfig{StaleTimeout: time.Minute})
+	quiet := &Client{ID: "quiet", UserID: 1, Send: make(chan Message, 1)}
+	alive := &Client{ID: "alive", UserID: 2, Send: make(chan Message, 1)}
+	require.NoError(t, hub.Add(quiet))
+	require.NoError(t, hub.Add(alive))
+	registered := quiet.LastPong()
+	assert.WithinDuration(t, time.Now(), registered, time.Second, "registration counts as the first pong")
+
+	alive.markPong(registered.Add(30 * time.Second))
+	assert.Zero(t, hub.EvictStale(registered.Add(time.Minute)), "exactly at the timeout is not stale yet")
+	assert.Equal(t, 1, hub.EvictStale(registered.Add(time.Minute+time.Millisecond)))
+
+	_, open := <-quiet.Send
+	assert.False(t, open, "the stale client's send channel is closed")
+	clients, _ := hub.ListClients(ClientFilter{})
+	assert.Equal(t, []string{"alive"}, clientIDs(clients))
+	assert.Equal(t, uint64(1), hub.GetStats()["stale_evicted_total"])
+
+	disabled := NewHubWithConfig(HubConfig{StaleTimeout: -1})
+	require.NoError(t, disabled.Add(&Client{ID: "forever", Send: make(chan Message, 1)}))
+	assert.Zero(t, disabled.EvictStale(time.Now().Add(24*time.Hour)))
+}
+
+func TestJanitor_EvictsSilentClients(t *testing.T) {
+	recorded := newRecordingMetrics()
+	hub := NewHubWithConfig(HubConfig{
+		StaleTimeout:  100 * time.Millisecond,
+		SweepInterval: 10 * time.Millisecond,
+		Metrics:       recorded,
+	})
+	go hub.Run()
+
+	silent := &Client{ID: "silent", UserID: 1, Role: models.RoleUser, Send: make(chan Message, 1)}
+	ponging := &Client{ID: "ponging", UserID: 2, Role: models.RoleUser, Send: make(chan Message, 1)}
+	start := time.Now()
+	hub.Register <- silent
+	hub.Register <- ponging
+
+	// Answer pings until the test ends
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				ponging.markPong(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	require.Eventually(t, func() bool { return recorded.get(recorded.disconnected, DisconnectReasonStale) == 1 }, 2*time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "not evicted before the timeout")
+	_, open := <-silent.Send
+	assert.False(t, open)
+
+	time.Sleep(150 * time.Millisecond)
+	clients, _ := hub.ListClients(ClientFilter{})
+	assert.Equal(t, []string{"ponging"}, clientIDs(clients), "clients that answer pings stay")
+}
+
+func TestAdd_ConnectionLimits(t *testing.T) {
+	hub := NewHubWithConfig(HubConfig{MaxConnections: 3, MaxConnectionsPerUser: 2})
+	add := func(id string, userID uint) (*Client, error) {
+		client := &Client{ID: id, UserID: userID, Send: make(chan Message, 1)}
+		return client, hub.Add(client)
+	}
+
+	for _, id := range []string{"a1", "a2"} {
+		_, err := add(id, 1)
+		require.NoError(t, err)
+	}
+	third, err := add("a3", 1)
+	assert.ErrorIs(t, err, ErrTooManyUserConnections)
+	_, open := <-third.Send
+	assert.False(t, open, "a refused client's send channel is closed")
+	assert.Equal(t, FormatCloseMessage(ClosePolicyViolation, "too many connections for this user"), third.closeFrame)
+
+	_, err = add("b1", 2)
+	require.NoError(t, err, "other users are not affected by the per-user cap")
+	refused, err := add("c1", 3)
+	assert.ErrorIs(t, err, ErrTooManyConnections)
+	assert.Equal(t, FormatCloseMessage(CloseTryAgainLater, "server connection limit reached"), refused.closeFrame)
+
+	stats := hub.GetStats()
+	assert.Equal(t, 3, stats["total_clients"])
+	assert.Equal(t, uint64(2), stats["rejected_total"])
+
+	// A slot frees up once a client leaves
+	assert.Equal(t, 1, hub.DisconnectUser(2, "slot needed"))
+	_, err = add("c2", 3)
+	assert.NoError(t, err)
+
+	unlimited := NewHubWithConfig(HubConfig{MaxConnections: -1, MaxConnectionsPerUser: -1})
+	for i := range 10 {
+		require.NoError(t, unlimited.Add(&Client{ID: fmt.Sprint(i), UserID: 1, Send: make(chan Message, 1)}))
+	}
+}
+
+func TestAdd_RefusedClientReceivesCloseFrame(t *testing.T) {
+	hub := NewHubWithConfig(HubConfig{MaxConnectionsPerUser: 1})
+	go hub.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := NewUpgrader(nil).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{ID: r.URL.Query().Get("id"), UserID: 1, Hub: hub, Conn: &Conn{conn}, Send: make(chan Message, 4)}
+		if err := hub.Add(client); err != nil {
+			go client.WritePump()
+			return
+		}
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	first, _, err := websocket.DefaultDialer.Dial(url+"?id=first", nil)
+	require.NoError(t, err)
+	defer first.Close()
+	require.Eventually(t, func() bool { return hub.GetStats()["total_clients"] == 1 }, time.Second, 5*time.Millisecond)
+
+	second, _, err := websocket.DefaultDialer.Dial(url+"?id=second", nil)
+	require.NoError(t, err)
+	defer second.Close()
+	second.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = second.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+	assert.Equal(t, "too many connections for this user", closeErr.Text)
+	assert.Equal(t, 1, hub.GetStats()["total_clients"])
+}
+
+func TestDisconnectUser(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	add := func(id string, userID uint) *Client {
+		client := &Client{ID: id, UserID: userID, Send: make(chan Message, 64)}
+		require.NoError(t, hub.Add(client))
+		return client
+	}
+	first, second := add("a1", 1), add("a2", 1)
+	other := add("b1", 2)
+
+	// Broadcasts flow through Run while the user is disconnected; fewer
+	// than fit in a send queue, so nobody is dropped as a slow client
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 32 {
+			hub.BroadcastToAll(EventSystemAlert, nil)
+		}
+	}()
+	assert.Equal(t, 2, hub.DisconnectUser(1, "role changed from admin to user"))
+	<-done
+
+	want := FormatCloseMessage(ClosePolicyViolation, "role changed from admin to user")
+	for _, client := range []*Client{first, second} {
+		for range client.Send {
+		}
+		assert.Equal(t, want, client.closeFrame, client.ID)
+	}
+
+	_, total := hub.ListClients(ClientFilter{UserID: 2})
+	assert.Equal(t, 1, total, "other users keep their connections")
+	assert.Nil(t, other.closeFrame)
+	assert.Equal(t, 0, hub.DisconnectUser(1, "again"))
+}
+
+func TestDisconnectUser_SendsCloseFrame(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := NewUpgrader(nil).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{ID: "c1", UserID: 1, Hub: hub, Conn: &Conn{conn}, Send: make(chan Message, 4)}
+		hub.Register <- client
+		go client.WritePump()
+		go client.ReadPump()
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return hub.GetStats()["total_clients"] == 1 }, time.Second, 5*time.Millisecond)
+
+	reason := strings.Repeat("é", 100) // 200 bytes, over the close frame limit
+	assert.Equal(t, 1, hub.DisconnectUser(1, reason))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+	assert.Equal(t, strings.Repeat("é", 61), closeErr.Text, "the reason is cut at a rune boundary")
+}
@@ -0,0 +1,164 @@
+package websocket
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+)
+
+// ProtocolVersion is the version of the message protocol announced in the
+// connection.established message. It is bumped on incompatible changes;
+// clients built for another version should fall back to receive-only use.
+const ProtocolVersion = 1
+
+// Server messages that are not domain events
+const (
+	EventConnectionEstablished EventType = "connection.established"
+	EventPong                  EventType = "pong"
+	EventSubscriptionAck       EventType = "subscription.ack"
+)
+
+// EventTypes lists every message type the server may send. Add new event
+// types here so they are announced to clients.
+var EventTypes = []EventType{
+	EventConnectionEstablished,
+	EventPong,
+	EventSubscriptionAck,
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventUserRoleChanged,
+	EventProfileUpdated,
+	EventPasswordChanged,
+	EventSystemAlert,
+	EventHealthStatusChanged,
+}
+
+// ClientMessage is a request sent by a client, e.g. {"action": "ping"} or
+// {"action": "subscribe", "events": ["user.role.changed"]}
+type ClientMessage struct {
+	Action string                 `json:"action"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Events []EventType            `json:"events,omitempty"`
+}
+
+// ActionHandler answers one client action
+type ActionHandler func(c *Client, msg ClientMessage)
+
+// actions maps incoming actions to their handlers. Registering a handler
+// here also announces the action to clients.
+var actions = map[string]ActionHandler{
+	"ping":        handlePing,
+	"subscribe":   handleSubscribe,
+	"unsubscribe": handleUnsubscribe,
+}
+
+// Actions returns the supported incoming actions, sorted
+func Actions() []string {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Capabilities describes the protocol for the connection.established message.
+// replay_horizon_seconds is 0 because the server keeps no replay buffer:
+// events sent while a client is disconnected are lost.
+func Capabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"protocol_version":       ProtocolVersion,
+		"actions":                Actions(),
+		"event_types":            EventTypes,
+		"replay_horizon_seconds": 0,
+	}
+}
+
+// handleClientMessage decodes a client frame and runs its action.
+// Unknown actions and malformed frames are ignored so newer clients keep
+// working against older servers.
+func (c *Client) handleClientMessage(data []byte) {
+	var msg ClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Debug("Ignoring malformed WebSocket message", "client_id", c.ID, "error", err)
+		return
+	}
+	handler, ok := actions[msg.Action]
+	if !ok {
+		logger.Debug("Ignoring unknown WebSocket action", "client_id", c.ID, "action", msg.Action)
+		return
+	}
+	handler(c, msg)
+}
+
+// handlePing answers with a pong echoing the request data
+func handlePing(c *Client, msg ClientMessage) {
+	c.Hub.sendTo(c, Message{Type: EventPong, Data: msg.Data, Timestamp: time.Now()})
+}
+
+// handleSubscribe adds event types to the client's subscription. Once a
+// client has subscribed, broadcasts of other event types skip it.
+func handleSubscribe(c *Client, msg ClientMessage) {
+	known, unknown := splitEventTypes(msg.Events)
+	c.subMu.Lock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[EventType]bool, len(known))
+	}
+	for _, eventType := range known {
+		c.subscriptions[eventType] = true
+	}
+	c.subMu.Unlock()
+	c.sendSubscriptionAck(msg.Action, unknown)
+}
+
+// handleUnsubscribe removes event types from the client's subscription; a
+// client without one starts from every event type. Without events it drops
+// the subscription, so the client receives everything again.
+func handleUnsubscribe(c *Client, msg ClientMessage) {
+	known, unknown := splitEventTypes(msg.Events)
+	c.subMu.Lock()
+	if len(msg.Events) == 0 {
+		c.subscriptions = nil
+	} else {
+		if c.subscriptions == nil {
+			c.subscriptions = make(map[EventType]bool, len(EventTypes))
+			for _, eventType := range EventTypes {
+				c.subscriptions[eventType] = true
+			}
+		}
+		for _, eventType := range known {
+			delete(c.subscriptions, eventType)
+		}
+	}
+	c.subMu.Unlock()
+	c.sendSubscriptionAck(msg.Action, unknown)
+}
+
+// sendSubscriptionAck confirms a subscription change with the resulting
+// subscription; "events" is null while the client receives everything
+func (c *Client) sendSubscriptionAck(action string, unknown []EventType) {
+	data := map[string]interface{}{
+		"action": action,
+		"events": c.Subscriptions(),
+	}
+	if len(unknown) > 0 {
+		data["unknown_events"] = unknown
+	}
+	c.Hub.sendTo(c, Message{Type: EventSubscriptionAck, Data: data, Timestamp: time.Now()})
+}
+
+// splitEventTypes separates the event types in EventTypes from unknown ones
+func splitEventTypes(eventTypes []EventType) (known, unknown []EventType) {
+	for _, eventType := range eventTypes {
+		if slices.Contains(EventTypes, eventType) {
+			known = append(known, eventType)
+		} else {
+			unknown = append(unknown, eventType)
+		}
+	}
+	return known, unknown
+}
@@ -0,0 +1,140 @@
+// Package cache provides a small in-process cache whose entries expire after
+// a fixed TTL. It is meant for hot reads of single rows and aggregates, not
+// as a shared cache: every instance of the API keeps its own copy.
+package cache
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// shardCount is the number of independently locked parts of a cache
+const shardCount = 16
+
+// Cache maps keys to values for TTL after they were stored. Keys are spread
+// over shards with their own lock, so requests for different keys rarely
+// wait on each other. It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	ttl    time.Duration
+	seed   maphash.Seed
+	shards [shardCount]shard[K, V]
+	now    func() time.Time // Replaced in tests
+}
+
+// shard is one locked part of a cache. gen counts invalidations, so that
+// GetOrLoad does not store a value loaded before the key was invalidated.
+type shard[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]entry[V]
+	gen     uint64
+}
+
+// entry is a cached value and the time it expires
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// New creates a cache whose entries live for ttl
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	c := &Cache[K, V]{ttl: ttl, seed: maphash.MakeSeed(), now: time.Now}
+	for i := range c.shards {
+		c.shards[i].entries = make(map[K]entry[V])
+	}
+	return c
+}
+
+// shard returns the shard holding key
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	return &c.shards[maphash.Comparable(c.seed, key)%shardCount]
+}
+
+// Get returns the value stored for key, if it has not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.get(s, key)
+}
+
+// get looks key up in s, dropping it if it expired. s.mu must be held.
+func (c *Cache[K, V]) get(s *shard[K, V], key K) (V, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(s.entries, key)
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value for key for the TTL of the cache
+func (c *Cache[K, V]) Set(key K, value V) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry[V]{value: value, expires: c.now().Add(c.ttl)}
+}
+
+// GetOrLoad returns the value stored for key, or calls load and stores its
+// result. Errors are returned and not cached. A value is not stored if key
+// was deleted or the cache cleared while load ran, since it may be stale.
+func (c *Cache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	if value, ok := c.get(s, key); ok {
+		s.mu.Unlock()
+		return value, nil
+	}
+	gen := s.gen
+	s.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.gen == gen {
+		s.entries[key] = entry[V]{value: value, expires: c.now().Add(c.ttl)}
+	}
+	return value, nil
+}
+
+// Delete removes key
+func (c *Cache[K, V]) Delete(key K) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	s.gen++
+}
+
+// Clear removes every key
+func (c *Cache[K, V]) Clear() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		clear(s.entries)
+		s.gen++
+		s.mu.Unlock()
+	}
+}
+
+// Len returns the number of stored entries, including expired ones not yet dropped
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache returns a cache whose clock is advanced by the returned function
+func newTestCache(ttl time.Duration) (*Cache[string, int], func(time.Duration)) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	c := New[string, int](ttl)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCache_Expires(t *testing.T) {
+	c, advance := newTestCache(time.Minute)
+	c.Set("a", 1)
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	advance(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "entries expire after the TTL")
+	assert.Zero(t, c.Len(), "expired entries are dropped when read")
+}
+
+func TestCache_DeleteAndClear(t *testing.T) {
+	c, _ := newTestCache(time.Minute)
+	for i := range 20 {
+		c.Set(fmt.Sprint(i), i)
+	}
+	c.Delete("3")
+	_, ok := c.Get("3")
+	assert.False(t, ok)
+	assert.Equal(t, 19, c.Len())
+
+	c.Clear()
+	assert.Zero(t, c.Len())
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	c, _ := newTestCache(time.Minute)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return 42, nil
+	}
+
+	for range 3 {
+		value, err := c.GetOrLoad("a", load)
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+	}
+	assert.Equal(t, 1, loads, "later calls are served from the cache")
+
+	_, err := c.GetOrLoad("b", func() (int, error) { return 0, errors.New("boom") })
+	assert.Error(t, err)
+	_, ok := c.Get("b")
+	assert.False(t, ok, "errors are not cached")
+}
+
+func TestCache_GetOrLoadDropsValuesInvalidatedWhileLoading(t *testing.T) {
+	c, _ := newTestCache(time.Minute)
+
+	value, err := c.GetOrLoad("a", func() (int, error) {
+		c.Delete("a") // A write lands while the old value is being read
+		return 1, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, value, "the caller still gets what it loaded")
+	_, ok := c.Get("a")
+	assert.False(t, ok, "the possibly stale value is not stored")
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	c := New[int, int](time.Minute)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for j := range 1000 {
+				key := j % 50
+				c.Set(key, i)
+				c.Get(key)
+				if j%100 == 0 {
+					c.Delete(key)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, c.Len(), 50)
+}
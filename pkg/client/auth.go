@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrNoRefreshToken is returned by Refresh before Login, Register or SetTokens
+var ErrNoRefreshToken = errors.New("no refresh token")
+
+// Login authenticates and stores the returned tokens for later calls
+func (c *Client) Login(ctx context.Context, email, password string) (*AuthResponse, error) {
+	var resp AuthResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   map[string]string{"email": email, "password": password},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	c.SetTokens(resp.AccessToken, resp.RefreshToken)
+	return &resp, nil
+}
+
+// Register creates an account and stores the returned tokens for later calls
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*AuthResponse, error) {
+	var resp AuthResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/register",
+		body:   req,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	c.SetTokens(resp.AccessToken, resp.RefreshToken)
+	return &resp, nil
+}
+
+// Refresh exchanges the refresh token for a new access token. A rotated
+// refresh token in the response replaces the stored one. Authenticated
+// calls refresh automatically, so calling this is rarely needed.
+func (c *Client) Refresh(ctx context.Context) (*RefreshResponse, error) {
+	_, refreshToken := c.Tokens()
+	if refreshToken == "" {
+		return nil, ErrNoRefreshToken
+	}
+
+	var resp RefreshResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/refresh",
+		body:   map[string]string{"refresh_token": refreshToken},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = resp.AccessToken
+	if resp.RefreshToken != "" {
+		c.refreshToken = resp.RefreshToken
+	}
+	return &resp, nil
+}
+
+// refreshAfter refreshes the access token after staleToken was rejected.
+// Calls that failed with the same token wait for a single refresh.
+func (c *Client) refreshAfter(ctx context.Context, staleToken string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if current, _ := c.Tokens(); current != staleToken {
+		return nil // Another call already refreshed
+	}
+	_, err := c.Refresh(ctx)
+	return err
+}
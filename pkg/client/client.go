@@ -0,0 +1,258 @@
+// Package client is a typed Go client for the v2 REST API.
+// Types mirror the models in docs/swagger.yaml; request plumbing (auth,
+// token refresh, retries, errors) is written by hand.
+//
+// Example:
+//
+//	c, err := client.New(client.Config{BaseURL: "http://localhost:8080"})
+//	if err != nil { ... }
+//	if _, err := c.Login(ctx, "admin@example.com", "password123"); err != nil { ... }
+//	for user, err := range c.AllUsers(ctx, client.ListUsersOptions{Limit: 50}) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiPrefix is the API version the client speaks
+const apiPrefix = "/api/v2"
+
+// RetryPolicy controls how failed idempotent requests are retried.
+// Network errors and 429, 502, 503 and 504 responses are retried; a
+// Retry-After header takes precedence over the computed backoff.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first; 1 disables retries
+	MinBackoff  time.Duration // Wait before the first retry, doubled after each attempt
+	MaxBackoff  time.Duration // Upper bound for any single wait
+}
+
+// Config holds the client settings
+type Config struct {
+	BaseURL    string        // Server root, e.g. "http://localhost:8080"
+	Timeout    time.Duration // Per-attempt timeout; ignored when HTTPClient is set
+	Retry      RetryPolicy
+	HTTPClient *http.Client // Optional, e.g. for custom transports
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  200 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+	}
+}
+
+// Client calls the API. It is safe for concurrent use; tokens obtained by
+// Login or Register are shared by all calls.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retry   RetryPolicy
+
+	mu           sync.RWMutex
+	accessToken  string
+	refreshToken string
+	refreshMu    sync.Mutex // Serializes refreshes so concurrent 401s refresh once
+}
+
+// New creates a client. Zero values in cfg fall back to defaults.
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
+
+	retry := cfg.Retry
+	defaults := DefaultRetryPolicy()
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaults.MaxAttempts
+	}
+	if retry.MinBackoff <= 0 {
+		retry.MinBackoff = defaults.MinBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaults.MaxBackoff
+	}
+
+	return &Client{
+		baseURL: base.String(),
+		http:    httpClient,
+		retry:   retry,
+	}, nil
+}
+
+// SetTokens sets the tokens used for authenticated calls, e.g. ones
+// persisted from an earlier session. An empty refresh token disables
+// automatic refresh.
+func (c *Client) SetTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = accessToken
+	c.refreshToken = refreshToken
+}
+
+// Tokens returns the current access and refresh tokens
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.accessToken, c.refreshToken
+}
+
+// request describes one API call
+type request struct {
+	method string
+	path   string // Relative to the API prefix, e.g. "/users/1"
+	query  url.Values
+	body   interface{}
+	auth   bool // Send the access token and refresh it on 401
+}
+
+// do sends req and decodes a successful response into out (when non-nil).
+// An authenticated call answered with 401 refreshes the access token once
+// and is sent again.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	token, _ := c.Tokens()
+	err := c.send(ctx, req, body, token, out)
+	if !req.auth || !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	if refreshErr := c.refreshAfter(ctx, token); refreshErr != nil {
+		return err // The original 401 explains the failure better
+	}
+	token, _ = c.Tokens()
+	return c.send(ctx, req, body, token, out)
+}
+
+// send performs req with retries and decodes the response
+func (c *Client) send(ctx context.Context, req request, body []byte, token string, out interface{}) error {
+	endpoint := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		endpoint += "?" + req.query.Encode()
+	}
+
+	for attempt := 1; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		if req.auth && token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.http.Do(httpReq)
+		var wait time.Duration
+		if err == nil {
+			err = decodeResponse(resp, out)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || !retryableStatus(apiErr.StatusCode) {
+				return err
+			}
+			wait = apiErr.RetryAfter
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if attempt >= c.retry.MaxAttempts || !idempotent(req.method) {
+			return err
+		}
+		if wait <= 0 {
+			wait = c.retry.MinBackoff << (attempt - 1)
+		}
+		if wait > c.retry.MaxBackoff {
+			wait = c.retry.MaxBackoff
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// decodeResponse closes resp and decodes its body into out, or returns an *APIError
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotent reports whether a request can be sent again without side effects
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
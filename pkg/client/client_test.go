@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first failures requests with status, then answers 200
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"id": 7, "name": "Jane"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(Config{
+		BaseURL: srv.URL,
+		Retry:   RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	return c, &calls
+}
+
+func TestNew_RejectsInvalidBaseURL(t *testing.T) {
+	for _, base := range []string{"", "localhost:8080", "://bad"} {
+		_, err := New(Config{BaseURL: base})
+		assert.Error(t, err, base)
+	}
+}
+
+func TestRetry_IdempotentRequests(t *testing.T) {
+	c, calls := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	user, err := c.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", user.Name)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	c, calls := flakyServer(t, 5, http.StatusBadGateway, nil)
+
+	_, err := c.GetUser(context.Background(), 7)
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_NotForPost(t *testing.T) {
+	c, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+
+	_, err := c.CreateUser(context.Background(), CreateUserRequest{Name: "Jane"})
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetry_HonorsRetryAfterUpToMaxBackoff(t *testing.T) {
+	c, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}})
+
+	start := time.Now()
+	_, err := c.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), time.Second, "waits are capped by MaxBackoff")
+}
+
+func TestRetry_StopsWhenContextEnds(t *testing.T) {
+	c, _ := flakyServer(t, 5, http.StatusServiceUnavailable, nil)
+	c.retry.MinBackoff = time.Hour
+	c.retry.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.GetUser(ctx, 7)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAPIError_DecodesProblemDetails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"Validation failed","errors":[{"field":"email","message":"email is required"}]}`))
+	}))
+	defer srv.Close()
+	c, err := New(Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	_, err = c.CreateUser(context.Background(), CreateUserRequest{})
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "Validation failed", apiErr.Detail)
+	assert.Equal(t, []FieldError{{Field: "email", Message: "email is required"}}, apiErr.Fields)
+}
+
+func TestAPIError_PlainTextBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	c, err := New(Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	_, err = c.Me(context.Background())
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Contains(t, err.Error(), "upstream unavailable")
+}
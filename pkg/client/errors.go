@@ -0,0 +1,98 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *APIError through errors.Is
+var (
+	ErrValidation   = errors.New("validation failed") // 400
+	ErrUnauthorized = errors.New("unauthorized")      // 401
+	ErrForbidden    = errors.New("forbidden")         // 403
+	ErrNotFound     = errors.New("not found")         // 404
+	ErrConflict     = errors.New("conflict")          // 409
+	ErrRateLimited  = errors.New("rate limited")      // 429
+	ErrServer       = errors.New("server error")      // 5xx
+)
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is a non-2xx response, decoded from its problem details body
+type APIError struct {
+	StatusCode int
+	Title      string
+	Detail     string
+	Fields     []FieldError  // Set on validation errors
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+// Error returns the status and the server's explanation
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Detail)
+	}
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Title)
+}
+
+// Is matches the sentinel error for the status code, so callers can write
+// errors.Is(err, client.ErrNotFound)
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// problem is the application/problem+json body of v2 errors
+type problem struct {
+	Title  string       `json:"title"`
+	Detail string       `json:"detail"`
+	Errors []FieldError `json:"errors"`
+}
+
+// newAPIError builds an APIError from resp. Bodies that are not problem
+// details (e.g. from a proxy) become the Detail text.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var p problem
+	if err := json.Unmarshal(raw, &p); err == nil && (p.Title != "" || p.Detail != "") {
+		if p.Title != "" {
+			apiErr.Title = p.Title
+		}
+		apiErr.Detail = p.Detail
+		apiErr.Fields = p.Errors
+	} else {
+		apiErr.Detail = strings.TrimSpace(string(raw))
+	}
+	return apiErr
+}
@@ -0,0 +1,110 @@
+package client
+
+import "time"
+
+// Role is a user role
+type Role string
+
+const (
+	RoleSuperAdmin Role = "superadmin"
+	RoleAdmin      Role = "admin"
+	RoleUser       Role = "user"
+)
+
+// User is a user as returned by the API
+type User struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Age         int        `json:"age,omitempty"`           // 0 if unknown; computed from DateOfBirth when set
+	DateOfBirth *time.Time `json:"date_of_birth,omitempty"` // Midnight UTC
+	Role        Role       `json:"role"`
+	IsActive    bool       `json:"is_active"`
+	AvatarURL   string     `json:"avatar_url,omitempty"`
+	Bio         string     `json:"bio,omitempty"`
+	PhoneNumber string     `json:"phone_number,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AuthResponse is returned by Login and Register
+type AuthResponse struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"` // seconds
+	ExpiresAt    time.Time `json:"expires_at"`
+	User         User      `json:"user"`
+}
+
+// RefreshResponse is returned by Refresh. RefreshToken is only set when
+// the server rotates refresh tokens.
+type RefreshResponse struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"` // seconds
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// RegisterRequest is the body of Register
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+
+	// Optional; at most one of Age and DateOfBirth. Only the date of DateOfBirth is used.
+	Age         int        `json:"age,omitempty"`
+	DateOfBirth *time.Time `json:"date_of_birth,omitempty"`
+}
+
+// CreateUserRequest is the body of CreateUser
+type CreateUserRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Role     Role   `json:"role,omitempty"` // Defaults to user
+
+	// Optional; at most one of Age and DateOfBirth. Only the date of DateOfBirth is used.
+	Age         int        `json:"age,omitempty"`
+	DateOfBirth *time.Time `json:"date_of_birth,omitempty"`
+}
+
+// UpdateUserRequest is the body of UpdateUser. Nil fields are left unchanged.
+type UpdateUserRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Age   *int    `json:"age,omitempty"`  // Clears the date of birth
+	Role  *Role   `json:"role,omitempty"` // Superadmin only
+
+	DateOfBirth *time.Time `json:"date_of_birth,omitempty"`
+}
+
+// ListUsersOptions filters and orders ListUsers. Zero values use server defaults.
+type ListUsersOptions struct {
+	Page   int
+	Limit  int    // At most 100
+	Sort   string // name, email, age or created_at
+	Order  string // asc or desc
+	Search string
+	Active *bool // Only active (true) or inactive (false) users
+	Role   Role  // Only users with this role
+}
+
+// Pagination describes the page returned by a list call
+type Pagination struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+	HasPrev    bool  `json:"has_prev"`
+	NextPage   *int  `json:"next_page,omitempty"`
+	PrevPage   *int  `json:"prev_page,omitempty"`
+}
+
+// UserPage is one page of users
+type UserPage struct {
+	Items      []User     `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
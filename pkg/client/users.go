@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Me returns the authenticated user
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/users/me", auth: true}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns the user with the given ID
+func (c *Client) GetUser(ctx context.Context, id uint) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodGet, path: userPath(id), auth: true}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers returns one page of users
+func (c *Client) ListUsers(ctx context.Context, opts ListUsersOptions) (*UserPage, error) {
+	var page UserPage
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/users",
+		query:  opts.values(),
+		auth:   true,
+	}, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllUsers iterates over every user matching opts, fetching pages as needed
+// starting at opts.Page. Iteration stops after the first error.
+func (c *Client) AllUsers(ctx context.Context, opts ListUsersOptions) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		if opts.Page < 1 {
+			opts.Page = 1
+		}
+		for {
+			page, err := c.ListUsers(ctx, opts)
+			if err != nil {
+				yield(User{}, err)
+				return
+			}
+			for _, user := range page.Items {
+				if !yield(user, nil) {
+					return
+				}
+			}
+			if len(page.Items) == 0 || page.Pagination.Page >= page.Pagination.TotalPages {
+				return
+			}
+			opts.Page = page.Pagination.Page + 1
+		}
+	}
+}
+
+// CreateUser creates a user (admin only)
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/users", body: req, auth: true}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the given fields of a user (admin only)
+func (c *Client) UpdateUser(ctx context.Context, id uint, req UpdateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodPut, path: userPath(id), body: req, auth: true}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser deletes a user (admin only)
+func (c *Client) DeleteUser(ctx context.Context, id uint) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: userPath(id), auth: true}, nil)
+}
+
+// userPath returns the path of one user
+func userPath(id uint) string {
+	return "/users/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// values encodes the options as query parameters, omitting zero values
+func (o ListUsersOptions) values() url.Values {
+	q := url.Values{}
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	if o.Order != "" {
+		q.Set("order", o.Order)
+	}
+	if o.Search != "" {
+		q.Set("search", o.Search)
+	}
+	if o.Active != nil {
+		q.Set("active", strconv.FormatBool(*o.Active))
+	}
+	if o.Role != "" {
+		q.Set("role", string(o.Role))
+	}
+	return q
+}
@@ -1,30 +1,143 @@
 // Package database provides database connection management and initialization
-// using GORM ORM with SQLite driver for persistent data storage.
+// using GORM ORM with the SQLite or PostgreSQL driver, chosen by configuration.
+// Optional read replicas receive SELECT queries; writes go to the primary.
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 
+	"Go-Lang-project-01/configs"
+
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+// Supported values of DatabaseConfig.Driver
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
 )
 
 var DB *gorm.DB
 
-// Connect establishes database connection using SQLite
-func Connect() error {
-	var err error
-	DB, err = gorm.Open(sqlite.Open("goproject.db"), &gorm.Config{
+// replicas holds the read replica pools, in configuration order
+var replicas []*sql.DB
+
+// Connect opens the database selected by cfg.Driver: the SQLite file named
+// cfg.DBName, or the PostgreSQL server described by cfg. The pool limits of
+// cfg apply to the primary and every replica; reads are routed to a random
+// replica when cfg.Replicas is set.
+func Connect(cfg configs.DatabaseConfig) error {
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
-
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return fmt.Errorf("failed to connect to %s database at %s: %w", cfg.Driver, describe(cfg), err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get %s connection pool: %w", cfg.Driver, err)
+	}
+	configurePool(sqlDB, cfg)
+	DB = db
+
+	if len(cfg.Replicas) > 0 {
+		if err := UseReplicas(DB, cfg.Replicas); err != nil {
+			return err
+		}
+		for _, pool := range replicas {
+			configurePool(pool, cfg)
+		}
+		log.Printf("✅ Read replicas configured: %d", len(cfg.Replicas))
+	}
+
+	log.Printf("✅ Database connected successfully! (%s)", cfg.Driver)
+	return nil
+}
+
+// Dialector returns the GORM dialector for cfg.Driver
+func Dialector(cfg configs.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverSQLite:
+		if cfg.DBName == "" {
+			return nil, errors.New("database.dbname must name the SQLite file")
+		}
+		return sqlite.Open(cfg.DBName), nil
+	case DriverPostgres:
+		return postgres.Open(cfg.GetDSN()), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q, expected %q or %q", cfg.Driver, DriverSQLite, DriverPostgres)
+	}
+}
+
+// describe names the database of cfg for error messages, without the password
+func describe(cfg configs.DatabaseConfig) string {
+	if cfg.Driver == DriverSQLite {
+		return fmt.Sprintf("%q", cfg.DBName)
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=%s", cfg.Host, cfg.Port, cfg.User, cfg.DBName, cfg.SSLMode)
+}
+
+// configurePool applies the pool limits of cfg; zero values keep the
+// database/sql defaults
+func configurePool(pool *sql.DB, cfg configs.DatabaseConfig) {
+	if cfg.MaxIdleConns > 0 {
+		pool.SetMaxIdleConns(cfg.MaxIdleConns)
 	}
+	if cfg.MaxOpenConns > 0 {
+		pool.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		pool.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+}
 
-	log.Println("✅ Database connected successfully! (SQLite)")
+// UseReplicas routes reads on db to the replicas at dsns, which use the
+// driver of db. Writes, transactions and queries made through a context
+// marked with WithPrimary use the primary.
+func UseReplicas(db *gorm.DB, dsns []string) error {
+	dialectors := make([]gorm.Dialector, 0, len(dsns))
+	pools := make([]*sql.DB, 0, len(dsns))
+	for i, dsn := range dsns {
+		// Open the pool ourselves so health checks can ping each replica
+		var dialector gorm.Dialector
+		var pool *sql.DB
+		var err error
+		switch name := db.Dialector.Name(); name {
+		case DriverSQLite:
+			pool, err = sql.Open(sqlite.DriverName, dsn)
+			dialector = sqlite.New(sqlite.Config{DSN: dsn, Conn: pool})
+		case DriverPostgres:
+			pool, err = sql.Open("pgx", dsn)
+			dialector = postgres.New(postgres.Config{DSN: dsn, Conn: pool})
+		default:
+			err = fmt.Errorf("read replicas are not supported for %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		pools = append(pools, pool)
+		dialectors = append(dialectors, dialector)
+	}
+
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   dbresolver.RandomPolicy{},
+	})); err != nil {
+		return fmt.Errorf("failed to configure read replicas: %w", err)
+	}
+	replicas = pools
 	return nil
 }
 
@@ -32,3 +145,59 @@ func Connect() error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// Close closes the primary connection pool and every replica pool.
+// It is called once on shutdown, after the HTTP server has drained.
+func Close() error {
+	var errs []error
+	if DB != nil {
+		sqlDB, err := DB.DB()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get primary pool: %w", err))
+		} else if err := sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close primary: %w", err))
+		}
+	}
+	for i, pool := range replicas {
+		if err := pool.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close replica %d: %w", i, err))
+		}
+	}
+	replicas = nil
+	return errors.Join(errs...)
+}
+
+// Replicas returns the read replica pools, in configuration order
+func Replicas() []*sql.DB {
+	return replicas
+}
+
+// Primary returns db pinned to the primary. Migrations must use it, since
+// schema inspection would otherwise read from a replica.
+func Primary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write)
+}
+
+// primaryKey marks contexts whose queries must read from the primary
+type primaryKey struct{}
+
+// WithPrimary returns a context whose queries (through Conn) read from the
+// primary. Use it for read-after-write paths that cannot tolerate replica lag.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether ctx was marked with WithPrimary
+func UsesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// Conn returns db bound to ctx, pinned to the primary if ctx was marked with WithPrimary
+func Conn(db *gorm.DB, ctx context.Context) *gorm.DB {
+	db = db.WithContext(ctx)
+	if UsesPrimary(ctx) {
+		db = Primary(db)
+	}
+	return db
+}
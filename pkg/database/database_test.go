@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/configs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+// openPair returns a primary database routed to a separate replica file. Both
+// files hold the item table so tests can tell which one served a query.
+func openPair(t *testing.T) (primary *gorm.DB, replica *gorm.DB) {
+	t.Helper()
+	dir := t.TempDir()
+	primaryDSN := filepath.Join(dir, "primary.db")
+	replicaDSN := filepath.Join(dir, "replica.db")
+
+	open := func(dsn string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+		require.NoError(t, err)
+		require.NoError(t, db.AutoMigrate(&item{}))
+		return db
+	}
+	primary = open(primaryDSN)
+	replica = open(replicaDSN)
+
+	require.NoError(t, replica.Create(&item{Name: "from replica"}).Error)
+	require.NoError(t, UseReplicas(primary, []string{replicaDSN}))
+	t.Cleanup(func() {
+		for _, pool := range Replicas() {
+			pool.Close()
+		}
+	})
+	return primary, replica
+}
+
+func TestUseReplicas_RoutesReadsToReplica(t *testing.T) {
+	primary, _ := openPair(t)
+
+	var items []item
+	require.NoError(t, Conn(primary, context.Background()).Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, "from replica", items[0].Name)
+	assert.Len(t, Replicas(), 1)
+}
+
+func TestUseReplicas_WritesGoToPrimary(t *testing.T) {
+	primary, replica := openPair(t)
+
+	require.NoError(t, Conn(primary, context.Background()).Create(&item{Name: "written"}).Error)
+
+	var count int64
+	require.NoError(t, replica.Model(&item{}).Where("name = ?", "written").Count(&count).Error)
+	assert.Zero(t, count, "write must not reach the replica")
+
+	// Not yet replicated, so only a primary read sees it
+	var got item
+	err := Conn(primary, WithPrimary(context.Background())).Where("name = ?", "written").First(&got).Error
+	require.NoError(t, err)
+	assert.Equal(t, "written", got.Name)
+
+	err = Conn(primary, context.Background()).Where("name = ?", "written").First(&got).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestWithPrimary(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, UsesPrimary(ctx))
+	assert.True(t, UsesPrimary(WithPrimary(ctx)))
+}
+
+func TestPrimary_MigratesPrimarySchema(t *testing.T) {
+	primary, _ := openPair(t)
+
+	type widget struct{ ID uint }
+	// The replica has no widgets table; inspecting it would re-create the table on the primary
+	require.NoError(t, Primary(primary).AutoMigrate(&widget{}))
+	require.NoError(t, Primary(primary).AutoMigrate(&widget{}))
+}
+
+func TestClose_ClosesPrimaryAndReplicas(t *testing.T) {
+	primary, _ := openPair(t)
+	replicaPools := Replicas()
+	DB = primary
+	t.Cleanup(func() { DB = nil })
+
+	require.NoError(t, Close())
+
+	sqlDB, err := primary.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping(), "primary pool is closed")
+	assert.Error(t, replicaPools[0].Ping(), "replica pool is closed")
+	assert.Empty(t, Replicas())
+}
+
+// sqliteConfig returns a SQLite configuration for a file in a temporary directory
+func sqliteConfig(t *testing.T) configs.DatabaseConfig {
+	t.Helper()
+	return configs.DatabaseConfig{
+		Driver:          DriverSQLite,
+		DBName:          filepath.Join(t.TempDir(), "app.db"),
+		MaxIdleConns:    2,
+		MaxOpenConns:    3,
+		ConnMaxLifetime: time.Minute,
+	}
+}
+
+func TestConnect_SQLite(t *testing.T) {
+	cfg := sqliteConfig(t)
+	cfg.Replicas = []string{filepath.Join(t.TempDir(), "replica.db")}
+	require.NoError(t, Connect(cfg))
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+
+	assert.Equal(t, DriverSQLite, GetDB().Dialector.Name())
+	require.NoError(t, GetDB().AutoMigrate(&item{}))
+	assert.FileExists(t, cfg.DBName)
+
+	sqlDB, err := GetDB().DB()
+	require.NoError(t, err)
+	assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+	require.Len(t, Replicas(), 1)
+	assert.Equal(t, 3, Replicas()[0].Stats().MaxOpenConnections, "replicas get the same pool limits")
+}
+
+func TestConnect_Errors(t *testing.T) {
+	t.Run("unsupported driver", func(t *testing.T) {
+		err := Connect(configs.DatabaseConfig{Driver: "mysql"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported database driver "mysql"`)
+	})
+
+	t.Run("sqlite file that cannot be created", func(t *testing.T) {
+		cfg := sqliteConfig(t)
+		cfg.DBName = filepath.Join(t.TempDir(), "missing", "app.db")
+
+		err := Connect(cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), cfg.DBName, "the error names the file")
+	})
+
+	t.Run("unreachable postgres server", func(t *testing.T) {
+		cfg := configs.DatabaseConfig{
+			Driver: DriverPostgres, Host: "127.0.0.1", Port: 1, User: "app",
+			Password: "hunter2", DBName: "app", SSLMode: "disable",
+		}
+
+		err := Connect(cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "postgres database at host=127.0.0.1 port=1 user=app dbname=app")
+		assert.NotContains(t, err.Error(), "hunter2", "the password is not logged")
+		assert.Nil(t, GetDB(), "a failed connection leaves no database behind")
+	})
+}
+
+func TestDialector_Postgres(t *testing.T) {
+	dialector, err := Dialector(configs.DatabaseConfig{
+		Driver: DriverPostgres, Host: "db.internal", Port: 5433, User: "app",
+		Password: "secret", DBName: "users", SSLMode: "require",
+	})
+	require.NoError(t, err)
+
+	pg, ok := dialector.(*postgres.Dialector)
+	require.True(t, ok)
+	assert.Equal(t, "host=db.internal port=5433 user=app password=secret dbname=users sslmode=require", pg.Config.DSN)
+}
+
+// TestConnect_Postgres needs a server, e.g.
+// TEST_POSTGRES_HOST=localhost TEST_POSTGRES_PASSWORD=postgres go test ./pkg/database
+func TestConnect_Postgres(t *testing.T) {
+	host := os.Getenv("TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set")
+	}
+	require.NoError(t, Connect(configs.DatabaseConfig{
+		Driver: DriverPostgres, Host: host, Port: 5432, User: "postgres",
+		Password: os.Getenv("TEST_POSTGRES_PASSWORD"), DBName: "postgres", SSLMode: "disable",
+		MaxOpenConns: 2,
+	}))
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+
+	assert.Equal(t, DriverPostgres, GetDB().Dialector.Name())
+	var one int
+	require.NoError(t, GetDB().Raw("SELECT 1").Scan(&one).Error)
+	assert.Equal(t, 1, one)
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// QueryObserver records query durations, e.g. as Prometheus metrics.
+// metrics.DBRecorder implements it.
+type QueryObserver interface {
+	QueryExecuted(operation, table string, duration time.Duration)
+}
+
+// queryStartKey holds the start time of a statement in its instance values
+const queryStartKey = "query_metrics:start"
+
+// QueryMetrics is a GORM plugin that reports the duration of every create,
+// query, update and delete to Observer, labeled by operation and table, and
+// logs statements slower than SlowThreshold. The logged SQL keeps its
+// placeholders; parameter values are never logged.
+type QueryMetrics struct {
+	Observer      QueryObserver // Optional
+	SlowThreshold time.Duration // <= 0 disables slow-query logging
+}
+
+// UseQueryMetrics registers QueryMetrics on db with the slow-query threshold
+// of cfg. It does nothing unless cfg.QueryMetrics is set.
+func UseQueryMetrics(db *gorm.DB, cfg configs.DatabaseConfig, observer QueryObserver) error {
+	if !cfg.QueryMetrics {
+		return nil
+	}
+	if err := db.Use(&QueryMetrics{Observer: observer, SlowThreshold: cfg.SlowQueryThreshold}); err != nil {
+		return fmt.Errorf("failed to register query metrics: %w", err)
+	}
+	return nil
+}
+
+// Name implements gorm.Plugin
+func (p *QueryMetrics) Name() string {
+	return "query_metrics"
+}
+
+// Initialize implements gorm.Plugin by timing the create, query, update and
+// delete callbacks that run the SQL
+func (p *QueryMetrics) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("query_metrics:before_create", startQueryTimer),
+		callbacks.Create().After("gorm:create").Register("query_metrics:after_create", p.observe("create")),
+		callbacks.Query().Before("gorm:query").Register("query_metrics:before_query", startQueryTimer),
+		callbacks.Query().After("gorm:query").Register("query_metrics:after_query", p.observe("query")),
+		callbacks.Update().Before("gorm:update").Register("query_metrics:before_update", startQueryTimer),
+		callbacks.Update().After("gorm:update").Register("query_metrics:after_update", p.observe("update")),
+		callbacks.Delete().Before("gorm:delete").Register("query_metrics:before_delete", startQueryTimer),
+		callbacks.Delete().After("gorm:delete").Register("query_metrics:after_delete", p.observe("delete")),
+	)
+}
+
+// startQueryTimer records when a statement starts
+func startQueryTimer(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+// observe returns the callback that reports a finished statement of operation
+func (p *QueryMetrics) observe(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		start, _ := value.(time.Time)
+		if !ok || start.IsZero() {
+			return
+		}
+		elapsed := time.Since(start)
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		if p.Observer != nil {
+			p.Observer.QueryExecuted(operation, table, elapsed)
+		}
+
+		if p.SlowThreshold > 0 && elapsed >= p.SlowThreshold {
+			logger.Warn("Slow database query",
+				"operation", operation,
+				"table", table,
+				"duration_ms", elapsed.Milliseconds(),
+				"rows", db.RowsAffected,
+				"sql", db.Statement.SQL.String(),
+				"error", db.Error,
+			)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openWithQueryMetrics returns an in-memory database whose statements are
+// recorded in a histogram on a fresh registry
+func openWithQueryMetrics(t *testing.T) (*gorm.DB, *prometheus.Registry) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	registry := prometheus.NewRegistry()
+	cfg := configs.DatabaseConfig{QueryMetrics: true, SlowQueryThreshold: time.Hour}
+	require.NoError(t, UseQueryMetrics(db, cfg, metrics.NewDBRecorder(registry)))
+	return db, registry
+}
+
+// observations returns the number of samples of one series of db_query_duration_seconds
+func observations(t *testing.T, registry *prometheus.Registry, operation, table string) uint64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "db_query_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == operation && labels["table"] == table {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestQueryMetrics_ObservesStatements(t *testing.T) {
+	db, registry := openWithQueryMetrics(t)
+
+	require.NoError(t, db.Create(&item{Name: "first"}).Error)
+	var items []item
+	require.NoError(t, db.Find(&items).Error)
+	require.NoError(t, db.Find(&items).Error)
+	require.NoError(t, db.Model(&item{}).Where("name = ?", "first").Update("name", "renamed").Error)
+	require.NoError(t, db.Where("name = ?", "renamed").Delete(&item{}).Error)
+
+	assert.Equal(t, uint64(1), observations(t, registry, "create", "items"))
+	assert.Equal(t, uint64(2), observations(t, registry, "query", "items"))
+	assert.Equal(t, uint64(1), observations(t, registry, "update", "items"))
+	assert.Equal(t, uint64(1), observations(t, registry, "delete", "items"))
+	assert.Equal(t, 4, testutil.CollectAndCount(registry, "db_query_duration_seconds"), "one series per operation and table")
+}
+
+func TestUseQueryMetrics_Disabled(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, UseQueryMetrics(db, configs.DatabaseConfig{}, metrics.NewDBRecorder(registry)))
+	require.NoError(t, db.Create(&item{Name: "unobserved"}).Error)
+
+	assert.Zero(t, observations(t, registry, "create", "items"))
+	_, registered := db.Config.Plugins["query_metrics"]
+	assert.False(t, registered)
+}
@@ -0,0 +1,187 @@
+// Package reporting forwards panics and server errors to an external error
+// tracker such as Sentry. Reports are queued and delivered by a background
+// worker, so reporting never blocks or fails the caller; when the queue is
+// full, reports are dropped.
+package reporting
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Go-Lang-project-01/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tags are indexed key/value pairs attached to a report (request ID, user ID, route, ...)
+type Tags map[string]string
+
+// Event is one reported error or panic
+type Event struct {
+	Err      error
+	Panicked bool   // Err wraps a recovered panic value
+	Stack    []byte // Stack of the reporting goroutine
+	Tags     Tags
+	Time     time.Time
+}
+
+// Reporter delivers events to an error tracker. It is only called from the
+// background worker, so it may block on network I/O.
+type Reporter interface {
+	Report(event Event)
+}
+
+// Flusher is implemented by reporters that buffer events themselves
+type Flusher interface {
+	Flush(timeout time.Duration) bool
+}
+
+// Nop discards all events
+type Nop struct{}
+
+// Report does nothing
+func (Nop) Report(Event) {}
+
+// dispatcher owns the queue and worker of one Init call
+type dispatcher struct {
+	reporter Reporter
+	queue    chan Event
+	done     chan struct{}
+}
+
+var (
+	mu      sync.RWMutex
+	active  *dispatcher
+	dropped atomic.Int64
+)
+
+// Init starts delivering reports to r, replacing any previous reporter.
+// queueSize bounds how many reports wait for delivery.
+func Init(r Reporter, queueSize int) {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	d := &dispatcher{
+		reporter: r,
+		queue:    make(chan Event, queueSize),
+		done:     make(chan struct{}),
+	}
+	go d.run()
+
+	mu.Lock()
+	previous := active
+	active = d
+	mu.Unlock()
+
+	if previous != nil {
+		close(previous.queue)
+	}
+}
+
+// Close stops accepting reports and waits up to timeout for queued ones to be delivered
+func Close(timeout time.Duration) {
+	mu.Lock()
+	d := active
+	active = nil
+	mu.Unlock()
+
+	if d == nil {
+		return
+	}
+	close(d.queue)
+
+	deadline := time.Now().Add(timeout)
+	select {
+	case <-d.done:
+	case <-time.After(timeout):
+		logger.Warn("Error reports still queued at shutdown")
+		return
+	}
+	if f, ok := d.reporter.(Flusher); ok {
+		f.Flush(time.Until(deadline))
+	}
+}
+
+// Dropped returns how many reports were discarded because the queue was full
+func Dropped() int64 {
+	return dropped.Load()
+}
+
+// Error reports err with tags
+func Error(err error, tags Tags) {
+	if err == nil {
+		return
+	}
+	enqueue(Event{Err: err, Stack: debug.Stack(), Tags: tags, Time: time.Now()})
+}
+
+// Panic reports a value returned by recover()
+func Panic(recovered interface{}, tags Tags) {
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("%v", recovered)
+	}
+	enqueue(Event{Err: fmt.Errorf("panic: %w", err), Panicked: true, Stack: debug.Stack(), Tags: tags, Time: time.Now()})
+}
+
+// Recover reports and stops a panic. Use it directly in a defer statement of
+// background goroutines that must not crash the process:
+//
+//	defer reporting.Recover(reporting.Tags{"component": "websocket"})
+func Recover(tags Tags) {
+	if r := recover(); r != nil {
+		logger.Error("Recovered from panic", "panic", r, "tags", tags)
+		Panic(r, tags)
+	}
+}
+
+// GinTags returns the request ID, authenticated user ID, method and route of a request
+func GinTags(c *gin.Context) Tags {
+	tags := Tags{
+		"method": c.Request.Method,
+		"route":  c.FullPath(),
+	}
+	if requestID := c.GetString("request_id"); requestID != "" {
+		tags["request_id"] = requestID
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		tags["user_id"] = fmt.Sprint(userID)
+	}
+	return tags
+}
+
+// enqueue hands the event to the worker without blocking
+func enqueue(event Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if active == nil {
+		return
+	}
+	select {
+	case active.queue <- event:
+	default:
+		dropped.Add(1)
+	}
+}
+
+// run delivers events until the queue is closed
+func (d *dispatcher) run() {
+	defer close(d.done)
+	for event := range d.queue {
+		d.deliver(event)
+	}
+}
+
+// deliver calls the reporter, containing its failures
+func (d *dispatcher) deliver(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Error reporter panicked", "panic", r)
+		}
+	}()
+	d.reporter.Report(event)
+}
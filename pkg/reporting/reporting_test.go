@@ -0,0 +1,106 @@
+package reporting
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects delivered events
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+	block  chan struct{} // when set, Report waits on it
+}
+
+func (r *recorder) Report(event Event) {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) delivered() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func TestErrorIsDeliveredWithTags(t *testing.T) {
+	rec := &recorder{}
+	Init(rec, 10)
+
+	Error(errors.New("boom"), Tags{"route": "/api/v1/users"})
+	Close(time.Second)
+
+	events := rec.delivered()
+	require.Len(t, events, 1)
+	assert.EqualError(t, events[0].Err, "boom")
+	assert.Equal(t, "/api/v1/users", events[0].Tags["route"])
+	assert.False(t, events[0].Panicked)
+	assert.NotEmpty(t, events[0].Stack)
+}
+
+func TestReportingNeverBlocks(t *testing.T) {
+	rec := &recorder{block: make(chan struct{})}
+	Init(rec, 2)
+	before := Dropped()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 50; i++ {
+			Error(errors.New("flood"), nil)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reporting blocked the caller")
+	}
+	assert.Greater(t, Dropped(), before)
+
+	close(rec.block)
+	Close(time.Second)
+}
+
+func TestRecoverStopsPanic(t *testing.T) {
+	rec := &recorder{}
+	Init(rec, 10)
+
+	func() {
+		defer Recover(Tags{"component": "test"})
+		panic("worker crashed")
+	}()
+	Close(time.Second)
+
+	events := rec.delivered()
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Panicked)
+	assert.EqualError(t, events[0].Err, "panic: worker crashed")
+	assert.Equal(t, "test", events[0].Tags["component"])
+}
+
+func TestPanickingReporterIsContained(t *testing.T) {
+	Init(panicReporter{}, 10)
+
+	Error(errors.New("first"), nil)
+	Error(errors.New("second"), nil)
+	Close(time.Second) // Returns because the worker survived both events
+}
+
+func TestReportWithoutInitIsDiscarded(t *testing.T) {
+	Close(time.Second)
+	assert.NotPanics(t, func() { Error(errors.New("ignored"), nil) })
+}
+
+type panicReporter struct{}
+
+func (panicReporter) Report(Event) { panic("reporter bug") }
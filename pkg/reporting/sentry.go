@@ -0,0 +1,56 @@
+package reporting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SentryReporter sends events to Sentry or any server speaking its protocol
+type SentryReporter struct {
+	client *sentry.Client
+}
+
+// NewSentryReporter creates a reporter for dsn. environment and release are
+// attached to every event.
+func NewSentryReporter(dsn, environment, release string) (*SentryReporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+		Release:     release,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	return &SentryReporter{client: client}, nil
+}
+
+// Report sends the event. The stack is attached as context because it was
+// captured on the reporting goroutine, not on the worker calling Report.
+func (r *SentryReporter) Report(event Event) {
+	ev := sentry.NewEvent()
+	ev.Level = sentry.LevelError
+	ev.Timestamp = event.Time
+	for k, v := range event.Tags {
+		ev.Tags[k] = v
+	}
+	ev.Exception = []sentry.Exception{{
+		Type:  fmt.Sprintf("%T", event.Err),
+		Value: event.Err.Error(),
+	}}
+	if event.Panicked {
+		ev.Exception[0].Type = "panic"
+		ev.Level = sentry.LevelFatal
+	}
+	if len(event.Stack) > 0 {
+		ev.Contexts["goroutine"] = sentry.Context{"stack": string(event.Stack)}
+	}
+
+	r.client.CaptureEvent(ev, nil, nil)
+}
+
+// Flush waits until buffered events are sent or timeout elapses
+func (r *SentryReporter) Flush(timeout time.Duration) bool {
+	return r.client.Flush(timeout)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// StrictBindingKey holds a func() bool on the gin context reporting whether
+// BindJSON rejects unknown fields; see middleware.StrictBinding
+const StrictBindingKey = "strict_binding"
+
+// UnknownFieldError is returned by BindJSON for a field the request type does not have
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "unknown field " + e.Field
+}
+
+// BindJSON binds the JSON body into obj like c.ShouldBindJSON. When strict
+// binding is on for the request, a field obj does not have is an
+// *UnknownFieldError instead of being ignored.
+func BindJSON(c *gin.Context, obj interface{}) error {
+	strict, _ := c.Get(StrictBindingKey)
+	if enabled, ok := strict.(func() bool); !ok || !enabled() {
+		return c.ShouldBindJSON(obj)
+	}
+	if c.Request == nil || c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(obj); err != nil {
+		// The decoder has no error type for unknown fields
+		if field, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+			return &UnknownFieldError{Field: strings.TrimSuffix(field, `"`)}
+		}
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
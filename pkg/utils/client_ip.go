@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP returns the IP address of the client that sent the request.
+//
+// X-Forwarded-For lists the client first and every proxy after it, e.g.
+// "203.0.113.5, 10.0.0.2, 10.0.0.3". The left-most public address is taken,
+// or the left-most address if all are private. Entries that are not IP
+// addresses are skipped. Without a usable X-Forwarded-For, a valid X-Real-IP
+// is used, then the address of the connection (c.ClientIP).
+func ClientIP(c *gin.Context) string {
+	var private net.IP
+	for _, entry := range strings.Split(c.GetHeader("X-Forwarded-For"), ",") {
+		ip := parseForwardedIP(entry)
+		if ip == nil {
+			continue
+		}
+		if isPublicIP(ip) {
+			return ip.String()
+		}
+		if private == nil {
+			private = ip
+		}
+	}
+	if private != nil {
+		return private.String()
+	}
+
+	if ip := parseForwardedIP(c.GetHeader("X-Real-IP")); ip != nil {
+		return ip.String()
+	}
+	return c.ClientIP()
+}
+
+// parseForwardedIP parses one proxy header entry, which some proxies send
+// with a port ("203.0.113.5:4711", "[2001:db8::1]:443"); nil if it is no IP
+func parseForwardedIP(entry string) net.IP {
+	entry = strings.TrimSpace(entry)
+	if host, _, err := net.SplitHostPort(entry); err == nil {
+		entry = host
+	}
+	return net.ParseIP(strings.Trim(entry, "[]"))
+}
+
+// isPublicIP reports whether ip is routable on the internet
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsUnspecified() && !ip.IsMulticast()
+}
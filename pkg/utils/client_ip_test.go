@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"multi-hop header", "203.0.113.5, 10.0.0.2, 10.0.0.3", "", "203.0.113.5"},
+		{"private hops before the client are skipped", "10.1.2.3, 198.51.100.7, 10.0.0.2", "", "198.51.100.7"},
+		{"only private hops", "10.1.2.3, 192.168.0.4", "", "10.1.2.3"},
+		{"no spaces", "203.0.113.5,10.0.0.2", "", "203.0.113.5"},
+		{"with port", "203.0.113.5:4711, 10.0.0.2", "", "203.0.113.5"},
+		{"IPv6", "2001:db8:85a3::8a2e:370:7334, 10.0.0.2", "", "2001:db8:85a3::8a2e:370:7334"},
+		{"IPv6 with port", "[2001:db8::1]:443", "", "2001:db8::1"},
+		{"garbage entries are skipped", "unknown, <script>, 203.0.113.9", "", "203.0.113.9"},
+		{"garbage falls back to X-Real-IP", "unknown, not-an-ip", "198.51.100.2", "198.51.100.2"},
+		{"X-Real-IP without X-Forwarded-For", "", " 198.51.100.3 ", "198.51.100.3"},
+		{"garbage X-Real-IP falls back to the connection", "", "localhost", "192.0.2.1"},
+		{"no headers", "", "", "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/", nil) // RemoteAddr 192.0.2.1:1234
+			if tt.forwarded != "" {
+				c.Request.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				c.Request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, ClientIP(c))
+		})
+	}
+}
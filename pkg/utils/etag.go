@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WeakETag returns a weak ETag over the JSON encoding of parts, e.g. a
+// resource or a page of resources and its pagination
+func WeakETag(parts ...interface{}) (string, error) {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, part := range parts {
+		if err := enc.Encode(part); err != nil {
+			return "", err
+		}
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// NotModified sets the ETag header for parts and answers 304 Not Modified
+// with an empty body when the request's If-None-Match matches it. Handlers
+// return when it reports true and render the response otherwise:
+//
+//	if utils.NotModified(c, user) {
+//		return
+//	}
+//	utils.SuccessResponse(c, user)
+func NotModified(c *gin.Context, parts ...interface{}) bool {
+	etag, err := WeakETag(parts...)
+	if err != nil {
+		return false // Render without an ETag; the response helper reports the encoding error
+	}
+	c.Header("ETag", etag)
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison of RFC 9110 to an If-None-Match header
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParamUint parses a positive integer path parameter such as ":id".
+// On failure it writes the standard validation error response and returns false,
+// so handlers can simply return.
+func ParamUint(c *gin.Context, name string) (uint, bool) {
+	value, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || value == 0 {
+		writerFor(c).Error(c, http.StatusBadRequest, "Validation failed", []models.ValidationError{{
+			Field:   name,
+			Message: name + " must be a positive integer",
+		}})
+		return 0, false
+	}
+	return uint(value), true
+}
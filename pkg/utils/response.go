@@ -1,7 +1,14 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"Go-Lang-project-01/internal/models"
@@ -10,44 +17,94 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-// Response helpers untuk mengurangi boilerplate code
+// Response helpers untuk mengurangi boilerplate code.
+// They render through the route group's ResponseWriter (see UseResponseWriter).
 
 // SuccessResponse sends a success response
 func SuccessResponse(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Data:    data,
-	})
+	writerFor(c).Success(c, http.StatusOK, "", data)
+}
+
+// MessageResponse sends a success response with a message
+func MessageResponse(c *gin.Context, message string, data interface{}) {
+	writerFor(c).Success(c, http.StatusOK, message, data)
 }
 
 // CreatedResponse sends a created response
 func CreatedResponse(c *gin.Context, message string, data interface{}) {
-	c.JSON(http.StatusCreated, models.Response{
-		Success: true,
-		Message: message,
-		Data:    data,
-	})
+	writerFor(c).Success(c, http.StatusCreated, message, data)
+}
+
+// MultiStatusResponse sends a multi-status response for requests that
+// partly succeeded
+func MultiStatusResponse(c *gin.Context, message string, data interface{}) {
+	writerFor(c).Success(c, http.StatusMultiStatus, message, data)
 }
 
 // ErrorResponse sends an error response
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, models.Response{
-		Success: false,
-		Message: message,
-	})
+	writerFor(c).Error(c, statusCode, message, nil)
 }
 
-// ValidationErrorResponse sends a validation error response with detailed field errors
+// ErrorDataResponse sends an error response carrying partial results or details
+func ErrorDataResponse(c *gin.Context, statusCode int, message string, data interface{}) {
+	writerFor(c).Error(c, statusCode, message, data)
+}
+
+// ValidationErrorResponse sends a validation error response with detailed field errors.
+// Bodies cut off by a size limit are answered with RequestTooLargeResponse instead.
 func ValidationErrorResponse(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		RequestTooLargeResponse(c, tooLarge.Limit)
+		return
+	}
+	writerFor(c).Error(c, http.StatusBadRequest, "Validation failed", ValidationErrors(err))
+}
+
+// RequestTooLargeResponse sends a 413 for a request body larger than limit bytes
+func RequestTooLargeResponse(c *gin.Context, limit int64) {
+	writerFor(c).Error(c, http.StatusRequestEntityTooLarge, "Request body too large", gin.H{
+		"error": "request_too_large",
+		"limit": limit,
+	})
+}
+
+// ValidationErrors converts a binding or validation error into field errors.
+// Malformed JSON bodies are described without the decoder's wording, which
+// names Go types and struct fields.
+func ValidationErrors(err error) []models.ValidationError {
 	var validationErrors []models.ValidationError
 
+	var (
+		syntaxErr  *json.SyntaxError
+		typeErr    *json.UnmarshalTypeError
+		unknownErr *UnknownFieldError
+	)
 	if ve, ok := err.(validator.ValidationErrors); ok {
 		for _, fe := range ve {
 			validationErrors = append(validationErrors, models.ValidationError{
-				Field:   strings.ToLower(fe.Field()),
+				Field:   fieldPath(fe),
 				Message: getValidationErrorMessage(fe),
 			})
 		}
+	} else if errors.Is(err, io.EOF) {
+		validationErrors = append(validationErrors, models.ValidationError{
+			Field:   "request",
+			Message: "request body is empty",
+		})
+	} else if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		validationErrors = append(validationErrors, models.ValidationError{
+			Field:   "request",
+			Message: "request body is not valid JSON",
+		})
+	} else if errors.As(err, &typeErr) {
+		validationErrors = append(validationErrors, jsonTypeError(typeErr))
+	} else if errors.As(err, &unknownErr) {
+		validationErrors = append(validationErrors, models.ValidationError{
+			Field:   unknownErr.Field, // As sent by the client
+			Message: "unknown field",
+		})
 	} else {
 		// Generic error fallback
 		validationErrors = append(validationErrors, models.ValidationError{
@@ -56,11 +113,75 @@ func ValidationErrorResponse(c *gin.Context, err error) {
 		})
 	}
 
-	c.JSON(http.StatusBadRequest, models.ErrorResponse{
-		Success: false,
-		Message: "Validation failed",
-		Errors:  validationErrors,
-	})
+	return validationErrors
+}
+
+// jsonTypeError describes a JSON value of the wrong type. The decoder's
+// field path, e.g. "users.1.age", is written like fieldPath: "users[1].age".
+func jsonTypeError(err *json.UnmarshalTypeError) models.ValidationError {
+	if err.Field == "" {
+		return models.ValidationError{
+			Field:   "request",
+			Message: "request body must be " + jsonKind(err.Type),
+		}
+	}
+
+	var path strings.Builder
+	segments := strings.Split(err.Field, ".")
+	for i, segment := range segments {
+		if _, convErr := strconv.Atoi(segment); convErr == nil {
+			path.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			path.WriteByte('.')
+		}
+		path.WriteString(segment)
+	}
+	field := strings.ToLower(path.String())
+	name := strings.ToLower(segments[len(segments)-1])
+
+	message := name + " must be " + jsonKind(err.Type)
+	if strings.HasPrefix(err.Value, "number") && jsonKind(err.Type) == "a number" {
+		// A number that does not fit, e.g. 1.5 or -1 for an unsigned field
+		message = name + " must be a whole number in range"
+	}
+	return models.ValidationError{Field: field, Message: message}
+}
+
+// jsonKind names the JSON value a Go type is decoded from
+func jsonKind(t reflect.Type) string {
+	if t == nil {
+		return "a valid value"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Pointer:
+		return jsonKind(t.Elem())
+	default:
+		return "a valid value"
+	}
+}
+
+// fieldPath names the field of a validation error below the validated
+// struct, e.g. "email" or "users[1].email" for an element of a dive
+func fieldPath(fe validator.FieldError) string {
+	path := fe.Namespace()
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.ToLower(path)
 }
 
 // getValidationErrorMessage returns human-readable error message for validation
@@ -86,16 +207,43 @@ func getValidationErrorMessage(fe validator.FieldError) string {
 		return field + " must be one of: " + fe.Param()
 	case "dive":
 		return "invalid item in " + field
+	case "strong_password":
+		return passwordErrorMessage(field, fe.Value())
+	case "dob":
+		return field + " must be in the past and at most " + strconv.Itoa(models.MaxAge) + " years ago"
+	case "excluded_with":
+		return field + " cannot be combined with " + strings.ToLower(fe.Param())
 	default:
 		return field + " is invalid"
 	}
 }
 
-// PaginatedResponse sends paginated response
+// PaginatedResponse sends paginated response with SetPaginationLinks
 func PaginatedResponse(c *gin.Context, data interface{}, meta models.PaginationMeta) {
-	c.JSON(http.StatusOK, models.PaginatedResponse{
-		Success:    true,
-		Data:       data,
-		Pagination: meta,
-	})
+	SetPaginationLinks(c, meta)
+	writerFor(c).Paginated(c, data, meta)
+}
+
+// SetPaginationLinks sets an RFC 5988 Link header with the next and previous
+// pages of meta, e.g. </api/v1/users?page=3&search=john>; rel="next". The
+// links keep the request's query parameters and only replace page.
+func SetPaginationLinks(c *gin.Context, meta models.PaginationMeta) {
+	var links []string
+	if meta.NextPage != nil {
+		links = append(links, pageLink(c.Request.URL, *meta.NextPage, "next"))
+	}
+	if meta.PrevPage != nil {
+		links = append(links, pageLink(c.Request.URL, *meta.PrevPage, "prev"))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageLink returns the link to page of the resource at u
+func pageLink(u *url.URL, page int, rel string) string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	link := url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=%q", link.String(), rel)
 }
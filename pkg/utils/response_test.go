@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPaginationLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		target string
+		page   int
+		want   string
+	}{
+		{"first page", "/api/v1/users?limit=10", 1, `</api/v1/users?limit=10&page=2>; rel="next"`},
+		{"middle page", "/api/v1/users?page=2&limit=10", 2, `</api/v1/users?limit=10&page=3>; rel="next", </api/v1/users?limit=10&page=1>; rel="prev"`},
+		{"last page", "/api/v1/users?page=3&limit=10", 3, `</api/v1/users?limit=10&page=2>; rel="prev"`},
+		{"past the end links back to the last page", "/api/v1/users?page=9&limit=10", 9, `</api/v1/users?limit=10&page=3>; rel="prev"`},
+		{
+			"filters are kept",
+			"/api/v1/users?search=john+doe&sort=name&order=asc&active=true&page=2&limit=10",
+			2,
+			`</api/v1/users?active=true&limit=10&order=asc&page=3&search=john+doe&sort=name>; rel="next", ` +
+				`</api/v1/users?active=true&limit=10&order=asc&page=1&search=john+doe&sort=name>; rel="prev"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", tt.target, nil)
+
+			SetPaginationLinks(c, models.NewPaginationMeta(tt.page, 10, 25))
+			assert.Equal(t, tt.want, w.Header().Get("Link"))
+		})
+	}
+
+	t.Run("single page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/users", nil)
+
+		SetPaginationLinks(c, models.NewPaginationMeta(1, 10, 5))
+		assert.Empty(t, w.Header().Values("Link"))
+	})
+}
+
+func TestValidationErrors_MalformedJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type user struct {
+		Name string `json:"name"`
+		Age  uint   `json:"age"`
+	}
+	type batch struct {
+		Users  []user `json:"users"`
+		Active *bool  `json:"active"`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want models.ValidationError
+	}{
+		{"empty body", "", models.ValidationError{Field: "request", Message: "request body is empty"}},
+		{"truncated body", `{"users": [{"name": "Jo`, models.ValidationError{Field: "request", Message: "request body is not valid JSON"}},
+		{"syntax error", `{"users": [}`, models.ValidationError{Field: "request", Message: "request body is not valid JSON"}},
+		{"string for a number", `{"users": [{"age": 1}, {"age": "30"}]}`, models.ValidationError{Field: "users[1].age", Message: "age must be a number"}},
+		{"number for a string", `{"users": [{"name": 7}]}`, models.ValidationError{Field: "users[0].name", Message: "name must be a string"}},
+		{"negative unsigned number", `{"users": [{"age": -1}]}`, models.ValidationError{Field: "users[0].age", Message: "age must be a whole number in range"}},
+		{"string for a pointer to bool", `{"active": "yes"}`, models.ValidationError{Field: "active", Message: "active must be true or false"}},
+		{"object for an array", `{"users": {}}`, models.ValidationError{Field: "users", Message: "users must be an array"}},
+		{"array for the body", `[]`, models.ValidationError{Field: "request", Message: "request body must be an object"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/users", strings.NewReader(tt.body))
+
+			var req batch
+			err := c.ShouldBindJSON(&req)
+			require.Error(t, err)
+
+			errs := ValidationErrors(err)
+			assert.Equal(t, []models.ValidationError{tt.want}, errs)
+			assert.NotContains(t, errs[0].Message, "Go ")
+			assert.NotContains(t, errs[0].Message, "json:")
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"net/http"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseWriterKey is the gin context key holding the ResponseWriter for a route group
+const responseWriterKey = "response_writer"
+
+// ProblemContentType is the media type of RFC 7807 error bodies
+const ProblemContentType = "application/problem+json"
+
+// ResponseWriter renders handler results in the format of one API version.
+// Handlers never use it directly; the response helpers in this package
+// look it up from the context, so the same handler serves every version.
+type ResponseWriter interface {
+	// Success renders a successful result. A nil data means there is no resource to return.
+	Success(c *gin.Context, status int, message string, data interface{})
+	// Error renders a failure. data carries optional details such as validation errors.
+	Error(c *gin.Context, status int, message string, data interface{})
+	// Paginated renders one page of a collection
+	Paginated(c *gin.Context, data interface{}, meta models.PaginationMeta)
+}
+
+// UseResponseWriter selects the ResponseWriter for all routes of a group
+func UseResponseWriter(w ResponseWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(responseWriterKey, w)
+		c.Next()
+	}
+}
+
+// writerFor returns the group's ResponseWriter, defaulting to the v1 envelope
+func writerFor(c *gin.Context) ResponseWriter {
+	if value, ok := c.Get(responseWriterKey); ok {
+		if w, ok := value.(ResponseWriter); ok {
+			return w
+		}
+	}
+	return EnvelopeWriter{}
+}
+
+// EnvelopeWriter renders the v1 {success, message, data} envelope
+type EnvelopeWriter struct{}
+
+// Success wraps data in models.Response
+func (EnvelopeWriter) Success(c *gin.Context, status int, message string, data interface{}) {
+	c.JSON(status, models.Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// Error renders models.ErrorResponse for validation errors and models.Response otherwise
+func (EnvelopeWriter) Error(c *gin.Context, status int, message string, data interface{}) {
+	if errs, ok := data.([]models.ValidationError); ok {
+		c.JSON(status, models.ErrorResponse{
+			Success: false,
+			Message: message,
+			Errors:  errs,
+		})
+		return
+	}
+	c.JSON(status, models.Response{
+		Success: false,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// Paginated wraps the page in models.PaginatedResponse
+func (EnvelopeWriter) Paginated(c *gin.Context, data interface{}, meta models.PaginationMeta) {
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Success:    true,
+		Data:       data,
+		Pagination: meta,
+	})
+}
+
+// ProblemWriter renders bare resources on success and RFC 7807 problem
+// details on error. It is used by the v2 API.
+type ProblemWriter struct{}
+
+// Success renders data as the body. Messages are dropped; results without data answer 204.
+func (ProblemWriter) Success(c *gin.Context, status int, _ string, data interface{}) {
+	if data == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(status, data)
+}
+
+// Error renders an application/problem+json body
+func (ProblemWriter) Error(c *gin.Context, status int, message string, data interface{}) {
+	problem := models.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: message,
+	}
+	if errs, ok := data.([]models.ValidationError); ok {
+		problem.Errors = errs
+	} else {
+		problem.Data = data
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, problem)
+}
+
+// Paginated renders the items next to their pagination metadata
+func (ProblemWriter) Paginated(c *gin.Context, data interface{}, meta models.PaginationMeta) {
+	c.JSON(http.StatusOK, models.Page{
+		Items:      data,
+		Pagination: meta,
+	})
+}
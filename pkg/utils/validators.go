@@ -0,0 +1,124 @@
+package utils
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Custom binding tags, registered with gin's validator when the package is loaded
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = v.RegisterValidation("dob", validateDateOfBirth)
+		_ = v.RegisterValidation("strong_password", validateStrongPassword)
+	}
+}
+
+// validateDateOfBirth implements the "dob" tag: a models.Date before today
+// and at most models.MaxAge years back
+func validateDateOfBirth(fl validator.FieldLevel) bool {
+	date, ok := fl.Field().Interface().(models.Date)
+	return ok && models.ValidDateOfBirth(date.Time, time.Now())
+}
+
+// PasswordPolicy holds the rules of the "strong_password" tag
+type PasswordPolicy struct {
+	MinLength             int  // In characters
+	RequireLetterAndDigit bool // At least one letter and one digit
+	RejectCommon          bool // Reject the passwords in common_passwords.txt
+}
+
+// DefaultPasswordPolicy returns the rules used until SetPasswordPolicy is called
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: 8, RequireLetterAndDigit: true, RejectCommon: true}
+}
+
+var passwordPolicy atomic.Pointer[PasswordPolicy]
+
+func init() {
+	policy := DefaultPasswordPolicy()
+	passwordPolicy.Store(&policy)
+}
+
+// SetPasswordPolicy replaces the rules of the "strong_password" tag, e.g.
+// with relaxed ones in tests
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicy.Store(&policy)
+}
+
+// CurrentPasswordPolicy returns the rules of the "strong_password" tag
+func CurrentPasswordPolicy() PasswordPolicy {
+	return *passwordPolicy.Load()
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords holds the lines of common_passwords.txt that are not comments
+var commonPasswords = func() map[string]bool {
+	passwords := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			passwords[line] = true
+		}
+	}
+	return passwords
+}()
+
+// Returned by PasswordPolicy.Check
+var (
+	ErrPasswordTooShort    = errors.New("password is too short")
+	ErrPasswordLetterDigit = errors.New("password needs a letter and a digit")
+	ErrPasswordTooCommon   = errors.New("password is too common")
+)
+
+// Check returns the first rule password breaks, or nil
+func (p PasswordPolicy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: at least %d characters are required", ErrPasswordTooShort, p.MinLength)
+	}
+	if p.RequireLetterAndDigit {
+		hasLetter := strings.IndexFunc(password, unicode.IsLetter) >= 0
+		hasDigit := strings.IndexFunc(password, unicode.IsDigit) >= 0
+		if !hasLetter || !hasDigit {
+			return ErrPasswordLetterDigit
+		}
+	}
+	if p.RejectCommon && commonPasswords[strings.ToLower(password)] {
+		return ErrPasswordTooCommon
+	}
+	return nil
+}
+
+// validateStrongPassword implements the "strong_password" tag with the
+// current PasswordPolicy
+func validateStrongPassword(fl validator.FieldLevel) bool {
+	password, ok := fl.Field().Interface().(string)
+	return ok && CurrentPasswordPolicy().Check(password) == nil
+}
+
+// passwordErrorMessage describes the rule a password broke for field
+func passwordErrorMessage(field string, value interface{}) string {
+	password, _ := value.(string)
+	policy := CurrentPasswordPolicy()
+	switch err := policy.Check(password); {
+	case errors.Is(err, ErrPasswordTooShort):
+		return fmt.Sprintf("%s must be at least %d characters", field, policy.MinLength)
+	case errors.Is(err, ErrPasswordLetterDigit):
+		return field + " must contain at least one letter and one digit"
+	case errors.Is(err, ErrPasswordTooCommon):
+		return field + " is too common, choose a less predictable password"
+	default:
+		return field + " is too weak"
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		password string
+		want     error
+	}{
+		{"strong", "correct-horse-42", nil},
+		{"exactly the minimum length", "abcdef12", nil},
+		{"non-ASCII letters", "contraseña9", nil},
+		{"too short", "abc12", ErrPasswordTooShort},
+		{"multi-byte characters count once", "ñññññ12", ErrPasswordTooShort},
+		{"no digit", "correct-horse", ErrPasswordLetterDigit},
+		{"no letter", "12345678-90", ErrPasswordLetterDigit},
+		{"common", "password123", ErrPasswordTooCommon},
+		{"common in other case", "PassWord123", ErrPasswordTooCommon},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestPasswordPolicy_RulesCanBeDisabled(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 6}
+	assert.NoError(t, policy.Check("password123"), "common passwords are allowed")
+	assert.NoError(t, policy.Check("abcdefgh"), "digits are optional")
+	assert.ErrorIs(t, policy.Check("abc12"), ErrPasswordTooShort)
+}
+
+func TestCommonPasswords_SkipsComments(t *testing.T) {
+	assert.True(t, commonPasswords["123456"])
+	for password := range commonPasswords {
+		assert.NotContains(t, password, "#")
+	}
+}
+
+func TestStrongPasswordMessages(t *testing.T) {
+	type request struct {
+		Password string `json:"password" binding:"strong_password"`
+	}
+
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"abc12", "password must be at least 8 characters"},
+		{"correct-horse", "password must contain at least one letter and one digit"},
+		{"password123", "password is too common, choose a less predictable password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(&request{Password: tt.password})
+			var errs validator.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.want, getValidationErrorMessage(errs[0]))
+		})
+	}
+	assert.NoError(t, binding.Validator.ValidateStruct(&request{Password: "correct-horse-42"}))
+}
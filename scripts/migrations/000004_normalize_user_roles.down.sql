@@ -0,0 +1,6 @@
+-- Drop role check constraint from users table
+-- Migration: normalize_user_roles (down)
+-- Created: 2026-10-15
+
+-- Normalized roles cannot be restored; only the constraint is dropped
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
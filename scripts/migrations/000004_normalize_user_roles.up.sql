@@ -0,0 +1,9 @@
+-- Normalize user roles and reject unknown values
+-- Migration: normalize_user_roles
+-- Created: 2026-10-15
+
+-- Reset unknown roles to the least privileged role
+UPDATE users SET role = 'user' WHERE role IS NULL OR role NOT IN ('user', 'admin', 'superadmin');
+
+-- Reject unknown roles at the database level
+ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'admin', 'superadmin'));
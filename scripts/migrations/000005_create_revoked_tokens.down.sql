@@ -0,0 +1,5 @@
+-- Rollback revoked_tokens table
+-- Migration: create_revoked_tokens (down)
+-- Created: 2026-10-15
+
+DROP TABLE IF EXISTS revoked_tokens;
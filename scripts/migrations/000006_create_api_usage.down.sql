@@ -0,0 +1,5 @@
+-- Rollback api_usage table
+-- Migration: create_api_usage (down)
+-- Created: 2026-10-15
+
+DROP TABLE IF EXISTS api_usage;
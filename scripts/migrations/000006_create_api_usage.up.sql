@@ -0,0 +1,19 @@
+-- Create api_usage table for per-user usage reports
+-- Migration: create_api_usage
+-- Created: 2026-10-15
+
+-- Request counters per user and aggregation bucket (usage.bucket, daily by default)
+CREATE TABLE IF NOT EXISTS api_usage (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    rate_limited BIGINT NOT NULL DEFAULT 0
+);
+
+-- Flushes upsert on (user_id, bucket_start)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_usage_user_bucket ON api_usage(user_id, bucket_start);
+
+-- Buckets older than usage.retentiondays are pruned daily
+CREATE INDEX IF NOT EXISTS idx_api_usage_bucket_start ON api_usage(bucket_start);
@@ -0,0 +1,5 @@
+-- Rollback feature_flags table
+-- Migration: create_feature_flags (down)
+-- Created: 2026-10-15
+
+DROP TABLE IF EXISTS feature_flags;
@@ -0,0 +1,18 @@
+-- Create feature_flags table for runtime toggles
+-- Migration: create_feature_flags
+-- Created: 2026-10-15
+
+-- A flag is on for a user when enabled, the user's role is in roles (or roles
+-- is empty) and the user falls within the first percentage percent
+CREATE TABLE IF NOT EXISTS feature_flags (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    roles TEXT, -- JSON array of roles
+    percentage INTEGER NOT NULL DEFAULT 100 CHECK (percentage BETWEEN 0 AND 100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feature_flags_name ON feature_flags(name);
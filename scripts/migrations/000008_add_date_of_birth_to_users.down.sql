@@ -0,0 +1,9 @@
+-- Rollback date_of_birth column from users table
+-- Migration: add_date_of_birth_to_users (down)
+-- Created: 2026-10-15
+
+-- Keep the age of users that only had a date of birth
+UPDATE users SET age = EXTRACT(YEAR FROM AGE(CURRENT_DATE, date_of_birth))::INTEGER
+WHERE date_of_birth IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN IF EXISTS date_of_birth;
@@ -0,0 +1,9 @@
+-- Add date_of_birth column to users table
+-- Migration: add_date_of_birth_to_users
+-- Created: 2026-10-15
+
+-- Nullable: existing users keep their stored age, which is used while no
+-- date of birth is known. age stays NOT NULL, with 0 for unknown ages.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS date_of_birth DATE;
+
+COMMENT ON COLUMN users.date_of_birth IS 'When set, the age is computed from it';
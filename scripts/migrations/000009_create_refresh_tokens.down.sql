@@ -0,0 +1,5 @@
+-- Rollback refresh_tokens table
+-- Migration: create_refresh_tokens (down)
+-- Created: 2026-10-15
+
+DROP TABLE IF EXISTS refresh_tokens;
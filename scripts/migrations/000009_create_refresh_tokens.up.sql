@@ -0,0 +1,23 @@
+-- Create refresh_tokens table for refresh token rotation
+-- Migration: create_refresh_tokens
+-- Created: 2026-10-15
+
+-- Issued refresh tokens by ID (jti). Tokens issued from one login share a
+-- family_id; reusing a rotated token revokes the whole family.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    jti VARCHAR(64) NOT NULL,
+    family_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    rotated_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_jti ON refresh_tokens(jti);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+-- Expired rows are pruned periodically
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
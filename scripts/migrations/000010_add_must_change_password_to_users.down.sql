@@ -0,0 +1,5 @@
+-- Rollback must_change_password column from users table
+-- Migration: add_must_change_password_to_users (down)
+-- Created: 2026-10-15
+
+ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,9 @@
+-- Add must_change_password column to users table
+-- Migration: add_must_change_password_to_users
+-- Created: 2026-10-15
+
+-- Set by an admin password reset; the user can only change their password
+-- until it is cleared
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN users.must_change_password IS 'Set by an admin password reset, cleared by the next password change';
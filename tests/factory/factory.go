@@ -0,0 +1,220 @@
+// Package factory builds unique test fixtures (users, audit logs) so that
+// integration tests can share one database and run in parallel without
+// colliding on unique columns such as email.
+package factory
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPassword is the plain-text password of every factory user unless overridden
+const DefaultPassword = "correct-horse-42"
+
+var (
+	// runID keeps emails unique across test runs sharing a persistent database
+	runID    = time.Now().UnixNano()
+	sequence atomic.Uint64
+
+	defaultHashOnce sync.Once
+	defaultHash     string
+	defaultHashErr  error
+)
+
+// Next returns a process-wide unique sequence number
+func Next() uint64 {
+	return sequence.Add(1)
+}
+
+// UniqueEmail returns an email address that is unique for this test run
+func UniqueEmail(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d@factory.test", prefix, runID, Next())
+}
+
+// UniqueName returns a display name that is unique for this test run
+func UniqueName(prefix string) string {
+	return fmt.Sprintf("%s %d-%d", prefix, runID, Next())
+}
+
+// Factory creates fixtures in the given database
+type Factory struct {
+	db *gorm.DB
+}
+
+// New creates a new factory backed by db
+func New(db *gorm.DB) *Factory {
+	return &Factory{db: db}
+}
+
+// UserOption overrides a field of a generated user
+type UserOption func(*userSpec)
+
+type userSpec struct {
+	user     models.User
+	password string
+}
+
+// WithRole sets the user's role
+func WithRole(role models.Role) UserOption {
+	return func(s *userSpec) { s.user.Role = role }
+}
+
+// WithName sets the user's name
+func WithName(name string) UserOption {
+	return func(s *userSpec) { s.user.Name = name }
+}
+
+// WithEmail sets the user's email (callers are responsible for uniqueness)
+func WithEmail(email string) UserOption {
+	return func(s *userSpec) { s.user.Email = email }
+}
+
+// WithAge sets the user's age
+func WithAge(age int) UserOption {
+	return func(s *userSpec) { s.user.Age = age }
+}
+
+// WithPassword sets the user's plain-text password (it is hashed on create)
+func WithPassword(password string) UserOption {
+	return func(s *userSpec) { s.password = password }
+}
+
+// Inactive marks the user as deactivated
+func Inactive() UserOption {
+	return func(s *userSpec) { s.user.IsActive = false }
+}
+
+// User creates and persists a unique user. Defaults: role "user", age 30, active,
+// password DefaultPassword.
+func (f *Factory) User(opts ...UserOption) (*models.User, error) {
+	n := Next()
+	spec := &userSpec{
+		user: models.User{
+			Name:     fmt.Sprintf("Factory User %d", n),
+			Email:    fmt.Sprintf("user-%d-%d@factory.test", runID, n),
+			Age:      30,
+			Role:     models.RoleUser,
+			IsActive: true,
+		},
+		password: DefaultPassword,
+	}
+	for _, opt := range opts {
+		opt(spec)
+	}
+
+	hashed, err := hashPassword(spec.password)
+	if err != nil {
+		return nil, err
+	}
+	user := spec.user
+	user.Password = hashed
+
+	if err := f.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	// is_active has a database default of true, so a false value is skipped on insert
+	if !spec.user.IsActive {
+		if err := f.db.Model(&user).Update("is_active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// Users creates n unique users sharing the same options
+func (f *Factory) Users(n int, opts ...UserOption) ([]*models.User, error) {
+	users := make([]*models.User, 0, n)
+	for i := 0; i < n; i++ {
+		user, err := f.User(opts...)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// AuditLogOption overrides a field of a generated audit log
+type AuditLogOption func(*models.AuditLog)
+
+// ForUser attributes the audit log to a user
+func ForUser(userID uint) AuditLogOption {
+	return func(l *models.AuditLog) { l.UserID = models.IDPtr(&userID) }
+}
+
+// WithAction sets the audit action
+func WithAction(action models.AuditAction) AuditLogOption {
+	return func(l *models.AuditLog) { l.Action = action }
+}
+
+// WithResource sets the audited resource and its ID
+func WithResource(resource models.AuditResource, resourceID uint) AuditLogOption {
+	return func(l *models.AuditLog) {
+		l.Resource = resource
+		l.ResourceID = models.IDPtr(&resourceID)
+	}
+}
+
+// WithIPAddress sets the client IP address
+func WithIPAddress(ip string) AuditLogOption {
+	return func(l *models.AuditLog) { l.IPAddress = ip }
+}
+
+// Failed marks the audit log as a failed action with the given error
+func Failed(errorMsg string) AuditLogOption {
+	return func(l *models.AuditLog) {
+		l.Success = false
+		l.ErrorMsg = errorMsg
+	}
+}
+
+// AuditLog creates and persists an audit log. Defaults: successful system access
+// from 127.0.0.1 with a unique user agent.
+func (f *Factory) AuditLog(opts ...AuditLogOption) (*models.AuditLog, error) {
+	log := &models.AuditLog{
+		Action:    models.AuditActionSystemAccess,
+		Resource:  models.AuditResourceSystem,
+		IPAddress: "127.0.0.1",
+		UserAgent: fmt.Sprintf("factory-agent-%d", Next()),
+		Success:   true,
+		CreatedAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(log)
+	}
+
+	// Create reloads defaulted columns into log, so remember the requested value first
+	success := log.Success
+	if err := f.db.Create(log).Error; err != nil {
+		return nil, err
+	}
+
+	// success has a database default of true, so a false value is skipped on insert
+	if !success {
+		if err := f.db.Model(log).Update("success", false).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return log, nil
+}
+
+// hashPassword hashes password, reusing a cached hash for DefaultPassword since bcrypt is slow
+func hashPassword(password string) (string, error) {
+	if password != DefaultPassword {
+		return auth.HashPassword(password)
+	}
+	defaultHashOnce.Do(func() {
+		defaultHash, defaultHashErr = auth.HashPassword(DefaultPassword)
+	})
+	return defaultHash, defaultHashErr
+}
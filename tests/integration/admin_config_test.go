@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/configs"
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminConfig(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/admin/config", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, get(adminToken).Code, "only superadmins may read the configuration")
+
+	w := get(superadminToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data map[string]configs.Setting `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	assert.Equal(t, configs.Setting{Value: configs.Redacted, Source: configs.SourceFile}, resp.Data["jwt.secretkey"])
+	assert.NotContains(t, w.Body.String(), "change-this-secret-key-in-production")
+	assert.Equal(t, configs.Setting{Value: "8080", Source: configs.SourceFile}, resp.Data["server.port"])
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPIv2Responses tests v2 rendering not covered by the golden files
+func TestAPIv2Responses(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Delete without resource answers 204", func(t *testing.T) {
+		target, err := testFactory.User()
+		require.NoError(t, err)
+
+		w := send("DELETE", fmt.Sprintf("/api/v2/users/%d", target.ID), adminToken)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("RBAC rejection is a problem", func(t *testing.T) {
+		target, err := testFactory.User()
+		require.NoError(t, err)
+
+		w := send("DELETE", fmt.Sprintf("/api/v2/users/%d", target.ID), userToken)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+
+		var problem models.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, models.Problem{
+			Type:   "about:blank",
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: "forbidden: insufficient permissions",
+		}, problem)
+	})
+
+	t.Run("v1 keeps the envelope for the same handler", func(t *testing.T) {
+		target, err := testFactory.User()
+		require.NoError(t, err)
+
+		w := send("DELETE", fmt.Sprintf("/api/v1/users/%d", target.ID), adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Success)
+		assert.Equal(t, "user deleted successfully", resp.Message)
+	})
+}
@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportAuditLogs checks that the CSV export applies the filters, keeps
+// details intact and is audited itself
+func TestExportAuditLogs(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	subject, _ := newUserWithToken(t, models.RoleUser)
+	other, _ := newUserWithToken(t, models.RoleUser)
+
+	seed := func(userID models.ID, action models.AuditAction, success bool, details string) {
+		log := &models.AuditLog{UserID: &userID, Action: action, Resource: models.AuditResourceAuth, Details: details, Success: true}
+		require.NoError(t, testDB.Create(log).Error)
+		if !success {
+			// success has a database default of true, so false is skipped on insert
+			require.NoError(t, testDB.Model(log).Update("success", false).Error)
+		}
+	}
+	seed(subject.ID, models.AuditActionLogin, true, `{"note":"comma, and \"quotes\""}`)
+	seed(subject.ID, models.AuditActionLoginFailed, false, "")
+	seed(subject.ID, models.AuditActionLogout, true, "")
+	seed(other.ID, models.AuditActionLogin, true, "")
+
+	export := func(token, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/audit-logs/export?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	records := func(w *httptest.ResponseRecorder) [][]string {
+		t.Helper()
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		require.NoError(t, err)
+		require.NotEmpty(t, rows)
+		assert.Equal(t, []string{"id", "created_at", "user_id", "action", "resource", "resource_id", "ip_address", "success", "error_msg", "details"}, rows[0])
+		return rows[1:]
+	}
+
+	t.Run("filters by user", func(t *testing.T) {
+		w := export(adminToken, fmt.Sprintf("user_id=%d", subject.ID))
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+		assert.Regexp(t, `^attachment; filename="audit-logs-\d{8}T\d{6}Z\.csv"$`, w.Header().Get("Content-Disposition"))
+
+		rows := records(w)
+		require.Len(t, rows, 3)
+		assert.Equal(t, []string{"login", "login_failed", "logout"}, []string{rows[0][3], rows[1][3], rows[2][3]}, "oldest first")
+		for _, row := range rows {
+			assert.Equal(t, subject.ID.String(), row[2])
+		}
+		assert.Equal(t, `{"note":"comma, and \"quotes\""}`, rows[0][9])
+	})
+
+	t.Run("filters by user and success", func(t *testing.T) {
+		rows := records(export(adminToken, fmt.Sprintf("user_id=%d&success=false", subject.ID)))
+		require.Len(t, rows, 1)
+		assert.Equal(t, "login_failed", rows[0][3])
+		assert.Equal(t, "false", rows[0][7])
+	})
+
+	t.Run("filters by action", func(t *testing.T) {
+		rows := records(export(adminToken, fmt.Sprintf("user_id=%d&action=logout", subject.ID)))
+		assert.Len(t, rows, 1)
+	})
+
+	t.Run("reads several pages", func(t *testing.T) {
+		bulk, _ := newUserWithToken(t, models.RoleUser)
+		logs := make([]models.AuditLog, 250)
+		for i := range logs {
+			logs[i] = models.AuditLog{UserID: &bulk.ID, Action: models.AuditActionLogin, Resource: models.AuditResourceAuth, Success: true}
+		}
+		require.NoError(t, testDB.CreateInBatches(logs, 100).Error)
+
+		rows := records(export(adminToken, fmt.Sprintf("user_id=%d", bulk.ID)))
+		require.Len(t, rows, 250)
+		seen := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			seen[row[0]] = true
+		}
+		assert.Len(t, seen, 250, "no log is exported twice")
+	})
+
+	t.Run("exempt from the response ceiling", func(t *testing.T) {
+		large, _ := newUserWithToken(t, models.RoleUser)
+		details := `{"note":"` + strings.Repeat("x", 8<<10) + `"}`
+		logs := make([]models.AuditLog, 2*testMaxResponseBytes/len(details))
+		for i := range logs {
+			logs[i] = models.AuditLog{UserID: &large.ID, Action: models.AuditActionLogin, Resource: models.AuditResourceAuth, Details: details, Success: true}
+		}
+		require.NoError(t, testDB.CreateInBatches(logs, 100).Error)
+
+		w := export(adminToken, fmt.Sprintf("user_id=%d", large.ID))
+		assert.Greater(t, w.Body.Len(), testMaxResponseBytes)
+		rows := records(w)
+		require.Len(t, rows, len(logs), "every row, including the first page")
+		assert.Equal(t, details, rows[0][9])
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, export(userToken, "").Code)
+	})
+
+	t.Run("audited", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			var count int64
+			testDB.Model(&models.AuditLog{}).
+				Where("user_id = ? AND action = ? AND success = ?", admin.ID, models.AuditActionAuditExport, true).
+				Count(&count)
+			return count == 5
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+// TestAuditLogsPagination checks the pagination metadata and Link header of
+// the audit log listing
+func TestAuditLogsPagination(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	subject, _ := newUserWithToken(t, models.RoleUser)
+	for range 5 {
+		require.NoError(t, testDB.Create(&models.AuditLog{UserID: &subject.ID, Action: models.AuditActionLogin, Resource: models.AuditResourceAuth, Success: true}).Error)
+	}
+
+	list := func(page int) (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/audit-logs?user_id=%d&action=login&page_size=2&page=%d", subject.ID, page), nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Pagination map[string]interface{} `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w, resp.Pagination
+	}
+	link := func(page int) string {
+		return fmt.Sprintf("/api/v1/audit-logs?action=login&page=%d&page_size=2&user_id=%d", page, subject.ID)
+	}
+
+	w, pagination := list(1)
+	assert.Equal(t, map[string]interface{}{
+		"page": float64(1), "page_size": float64(2), "total_items": float64(5), "total_pages": float64(3),
+		"has_next": true, "has_prev": false, "next_page": float64(2),
+	}, pagination)
+	assert.Equal(t, fmt.Sprintf(`<%s>; rel="next"`, link(2)), w.Header().Get("Link"))
+
+	w, pagination = list(2)
+	assert.Equal(t, true, pagination["has_next"])
+	assert.Equal(t, true, pagination["has_prev"])
+	assert.Equal(t, fmt.Sprintf(`<%s>; rel="next", <%s>; rel="prev"`, link(3), link(1)), w.Header().Get("Link"))
+
+	w, pagination = list(3)
+	assert.Equal(t, false, pagination["has_next"])
+	assert.NotContains(t, pagination, "next_page")
+	assert.Equal(t, float64(2), pagination["prev_page"])
+	assert.Equal(t, fmt.Sprintf(`<%s>; rel="prev"`, link(2)), w.Header().Get("Link"))
+}
@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditTrail_CreateAndDelete checks that the AuditTrail middleware writes
+// one audit log per mutating user request, without the password
+func TestAuditTrail_CreateAndDelete(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	email := fmt.Sprintf("audit-trail-%d@example.com", time.Now().UnixNano())
+	w := send("POST", "/api/v1/users", fmt.Sprintf(`{"name":"Audit Trail","email":%q,"password":"correct-horse-42","age":30}`, email))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data models.User `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = send("DELETE", fmt.Sprintf("/api/v1/users/%d", created.Data.ID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var logs []models.AuditLog
+	assert.Eventually(t, func() bool {
+		logs = nil
+		testDB.Where("user_id = ? AND resource_id = ?", admin.ID, created.Data.ID).Order("id").Find(&logs)
+		return len(logs) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Len(t, logs, 2)
+
+	assert.Equal(t, models.AuditActionUserCreate, logs[0].Action)
+	assert.Equal(t, models.AuditActionUserDelete, logs[1].Action)
+	for _, log := range logs {
+		assert.Equal(t, models.AuditResourceUser, log.Resource)
+		assert.True(t, log.Success)
+	}
+	assert.Contains(t, logs[0].Details, email)
+	assert.NotContains(t, logs[0].Details, "correct-horse-42")
+}
@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserAuthSummary tests GET /api/v1/users/:id/auth-summary
+func TestUserAuthSummary(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	target, _ := newUserWithToken(t, models.RoleUser)
+
+	auditRepo := repository.NewAuditLogRepository(testDB)
+	base := time.Now().Add(-time.Hour)
+	for i, action := range []models.AuditAction{
+		models.AuditActionLogin,
+		models.AuditActionLoginFailed,
+		models.AuditActionLogin,
+	} {
+		log := &models.AuditLog{
+			UserID:    &target.ID,
+			Action:    action,
+			Resource:  models.AuditResourceAuth,
+			IPAddress: fmt.Sprintf("203.0.113.%d", i+1),
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}
+		require.NoError(t, auditRepo.Create(log))
+		if action == models.AuditActionLoginFailed {
+			require.NoError(t, testDB.Model(log).Update("success", false).Error)
+		}
+	}
+
+	get := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/users/%d/auth-summary", target.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Admin sees login statistics", func(t *testing.T) {
+		w := get(adminToken)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Success bool                   `json:"success"`
+			Data    models.UserAuthSummary `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Success)
+		assert.Equal(t, uint(target.ID), resp.Data.UserID)
+		assert.Equal(t, int64(2), resp.Data.TotalLogins)
+		assert.Equal(t, int64(1), resp.Data.FailedLogins)
+		assert.Equal(t, []string{"203.0.113.3", "203.0.113.2", "203.0.113.1"}, resp.Data.RecentIPs)
+		assert.NotNil(t, resp.Data.LastLoginAt)
+	})
+
+	t.Run("Regular user is forbidden", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get(userToken).Code)
+	})
+}
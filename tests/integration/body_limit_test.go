@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestBodyLimit tests that bodies above the global limit are rejected
+// with 413 and leave the server serving
+func TestRequestBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+
+	user := `{"name":"Too Large","email":"too-large@body-limit.test","password":"correct-horse-42"},`
+	body := "[" + strings.Repeat(user, testMaxBodyBytes/len(user)+1) + "]"
+
+	assertTooLarge := func(t *testing.T, w *httptest.ResponseRecorder) {
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		var resp struct {
+			Success bool   `json:"success"`
+			Message string `json:"message"`
+			Data    struct {
+				Error string `json:"error"`
+				Limit int64  `json:"limit"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.False(t, resp.Success)
+		assert.Equal(t, "request_too_large", resp.Data.Error)
+		assert.Equal(t, int64(testMaxBodyBytes), resp.Data.Limit)
+	}
+
+	t.Run("Content-Length above the limit", func(t *testing.T) {
+		assertTooLarge(t, sendJSON("POST", "/api/v1/users/batch", adminToken, body))
+	})
+
+	t.Run("Chunked body above the limit", func(t *testing.T) {
+		// A reader of unknown length, so the limit is only hit by the JSON binder
+		req := httptest.NewRequest("POST", "/api/v1/users/batch", io.MultiReader(strings.NewReader(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		assertTooLarge(t, w)
+	})
+
+	t.Run("Server stays healthy", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", "too-large@body-limit.test").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
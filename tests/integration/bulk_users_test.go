@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// missingUserID is an ID no test user reaches
+const missingUserID = 999999999
+
+// sendJSON sends a JSON request as the holder of token
+func sendJSON(method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+// bulkResult decodes the data of a bulk change response
+func bulkResult(t *testing.T, w *httptest.ResponseRecorder) models.BulkUserResult {
+	t.Helper()
+	var resp struct {
+		Data models.BulkUserResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp.Data
+}
+
+// userExists reports whether id is a user that is not soft deleted
+func userExists(t *testing.T, id models.ID) bool {
+	t.Helper()
+	var count int64
+	require.NoError(t, testDB.Model(&models.User{}).Where("id = ?", id).Count(&count).Error)
+	return count == 1
+}
+
+func TestBulkDeleteUsers_RejectsOwnID(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	target, _ := newUserWithToken(t, models.RoleUser)
+
+	w := sendJSON("DELETE", "/api/v1/users", adminToken, fmt.Sprintf(`{"ids":[%d,%d]}`, target.ID, admin.ID))
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "cannot delete yourself")
+	assert.True(t, userExists(t, admin.ID))
+	assert.True(t, userExists(t, target.ID), "nothing is deleted")
+}
+
+func TestBulkDeleteUsers_MixedIDs(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	first, _ := newUserWithToken(t, models.RoleUser)
+	second, _ := newUserWithToken(t, models.RoleUser)
+	superadmin, _ := newUserWithToken(t, models.RoleSuperAdmin)
+
+	body := fmt.Sprintf(`{"ids":[%d,%d,%d,%d,"%d"]}`, first.ID, missingUserID, superadmin.ID, first.ID, second.ID)
+	w := sendJSON("DELETE", "/api/v1/users", adminToken, body)
+	require.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+
+	result := bulkResult(t, w)
+	assert.Equal(t, []models.ID{first.ID, second.ID}, result.Succeeded)
+	assert.Equal(t, []models.BulkUserFailure{
+		{ID: missingUserID, Error: "user not found"},
+		{ID: superadmin.ID, Error: "cannot delete a user ranking above you"},
+		{ID: first.ID, Error: "user is listed more than once"},
+	}, result.Failed)
+
+	assert.False(t, userExists(t, first.ID))
+	assert.False(t, userExists(t, second.ID))
+	assert.True(t, userExists(t, superadmin.ID))
+
+	var logs []models.AuditLog
+	assert.Eventually(t, func() bool {
+		logs = nil
+		testDB.Where("user_id = ? AND action = ?", admin.ID, models.AuditActionUserBulkDelete).Order("resource_id").Find(&logs)
+		return len(logs) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Len(t, logs, 2, "one entry per deleted user")
+	assert.Equal(t, first.ID, *logs[0].ResourceID)
+	assert.Equal(t, second.ID, *logs[1].ResourceID)
+
+	w = sendJSON("DELETE", "/api/v1/users", adminToken, fmt.Sprintf(`{"ids":[%d]}`, missingUserID))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "no user could be deleted")
+}
+
+func TestBulkUpdateRoles(t *testing.T) {
+	t.Parallel()
+
+	superadmin, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	promoted, _ := newUserWithToken(t, models.RoleUser)
+	demoted, _ := newUserWithToken(t, models.RoleAdmin)
+
+	body := fmt.Sprintf(`[{"id":%d,"role":"admin"},{"id":%d,"role":"user"},{"id":%d,"role":"admin"},{"id":%d,"role":"user"}]`,
+		promoted.ID, demoted.ID, missingUserID, superadmin.ID)
+
+	w := sendJSON("PUT", "/api/v1/users/roles", adminToken, body)
+	assert.Equal(t, http.StatusForbidden, w.Code, "role changes require superadmin")
+
+	w = sendJSON("PUT", "/api/v1/users/roles", superadminToken, body)
+	require.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+	result := bulkResult(t, w)
+	assert.Equal(t, []models.ID{promoted.ID, demoted.ID}, result.Succeeded)
+	assert.Equal(t, []models.BulkUserFailure{
+		{ID: missingUserID, Error: "user not found"},
+		{ID: superadmin.ID, Error: "cannot demote yourself"},
+	}, result.Failed)
+
+	roleOf := func(id models.ID) models.Role {
+		var user models.User
+		require.NoError(t, testDB.First(&user, id).Error)
+		return user.Role
+	}
+	assert.Equal(t, models.RoleAdmin, roleOf(promoted.ID))
+	assert.Equal(t, models.RoleUser, roleOf(demoted.ID))
+	assert.Equal(t, models.RoleSuperAdmin, roleOf(superadmin.ID))
+
+	var log models.AuditLog
+	assert.Eventually(t, func() bool {
+		return testDB.Where("user_id = ? AND action = ? AND resource_id = ?", superadmin.ID, models.AuditActionBulkRoleChange, promoted.ID).
+			First(&log).Error == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, fmt.Sprintf(`{"user_id":%d,"from":"user","to":"admin"}`, promoted.ID), log.Details)
+
+	w = sendJSON("PUT", "/api/v1/users/roles", superadminToken, `[{"id":1,"role":"root"}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "unknown roles fail validation")
+}
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangePassword_RejectsCurrentPassword(t *testing.T) {
+	t.Parallel()
+
+	user, token := newUserWithToken(t, models.RoleUser)
+	refreshToken := newRefreshToken(t, user)
+
+	body := fmt.Sprintf(`{"current_password":%q,"new_password":%q}`, factory.DefaultPassword, factory.DefaultPassword)
+	w := sendJSON("PUT", "/api/v1/users/me/password", token, body)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "new password must differ from the current password")
+
+	w = postRefreshToken("/api/v1/auth/refresh", refreshToken)
+	assert.Equal(t, http.StatusOK, w.Code, "a rejected change keeps sessions alive")
+
+	assert.Eventually(t, func() bool {
+		var count int64
+		testDB.Model(&models.AuditLog{}).
+			Where("user_id = ? AND action = ? AND error_msg = ?", user.ID, models.AuditActionPasswordChange, "new password must differ from the current password").
+			Count(&count)
+		return count == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestChangePassword_RevokesRefreshTokens(t *testing.T) {
+	t.Parallel()
+
+	user, token := newUserWithToken(t, models.RoleUser)
+	other := newRefreshToken(t, user)
+	rotated := refreshTokenFrom(t, postRefreshToken("/api/v1/auth/refresh", newRefreshToken(t, user)))
+
+	body := fmt.Sprintf(`{"current_password":%q,"new_password":"Qu1et-Harbor"}`, factory.DefaultPassword)
+	w := sendJSON("PUT", "/api/v1/users/me/password", token, body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for name, refreshToken := range map[string]string{"other session": other, "rotated session": rotated} {
+		w = postRefreshToken("/api/v1/auth/refresh", refreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+
+	var revoked int64
+	require.NoError(t, testDB.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&revoked).Error)
+	assert.Zero(t, revoked, "no refresh token of the user is left")
+
+	// Signing in with the new password starts a fresh session
+	w = sendJSON("POST", "/api/v1/auth/login", "", fmt.Sprintf(`{"email":%q,"password":"Qu1et-Harbor"}`, user.Email))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postRefreshToken("/api/v1/auth/refresh", refreshTokenFrom(t, w))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
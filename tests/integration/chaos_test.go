@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/middleware"
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChaos_InjectAndRemoveFault checks that faults hit the real routes and
+// that only superadmins can manage them
+func TestChaos_InjectAndRemoveFault(t *testing.T) {
+	t.Parallel()
+
+	superadmin, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	target, err := testFactory.User()
+	require.NoError(t, err)
+	// Scoped to a path no other test requests
+	targetPath := fmt.Sprintf("/api/v1/users/%d/usage", target.ID)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/api/v1/_chaos/faults", superadminToken, fmt.Sprintf(`{"type":"status","status":503,"path_prefix":%q}`, targetPath))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Data models.ChaosFault `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	fault := resp.Data
+
+	w = send("GET", targetPath, adminToken, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, fault.ID, w.Header().Get(middleware.ChaosFaultHeader))
+
+	w = send("GET", "/api/v1/_chaos/faults", superadminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), fault.ID)
+
+	w = send("DELETE", "/api/v1/_chaos/faults/"+fault.ID, superadminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = send("GET", targetPath, adminToken, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Eventually(t, func() bool {
+		var actions []models.AuditAction
+		testDB.Model(&models.AuditLog{}).Where("user_id = ?", superadmin.ID).Pluck("action", &actions)
+		// Both logs are written asynchronously, so their order is not fixed
+		return len(actions) == 2 &&
+			slices.Contains(actions, models.AuditActionChaosInject) &&
+			slices.Contains(actions, models.AuditActionChaosRemove)
+	}, 10*time.Second, 20*time.Millisecond, "changes are audited")
+
+	t.Run("superadmin only", func(t *testing.T) {
+		w := send("POST", "/api/v1/_chaos/faults", adminToken, `{"type":"drop","path_prefix":"/api/v1/never"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = send("GET", "/api/v1/_chaos/faults", adminToken, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		for _, body := range []string{
+			`{"type":"latency","path_prefix":"/api/v1/never"}`,
+			`{"type":"status","status":200,"path_prefix":"/api/v1/never"}`,
+			`{"type":"explode"}`,
+			`{"type":"drop","path_prefix":"api"}`,
+		} {
+			w := send("POST", "/api/v1/_chaos/faults", superadminToken, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+}
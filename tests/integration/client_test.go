@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/pkg/client"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns an API client talking to the test router
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(testRouter)
+	t.Cleanup(srv.Close)
+
+	c, err := client.New(client.Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+	return c
+}
+
+// loginClient returns a client logged in as a new user with the given role
+func loginClient(t *testing.T, role models.Role) (*client.Client, *models.User) {
+	t.Helper()
+	user, _ := newUserWithToken(t, role, factory.WithPassword("correct-horse-42"))
+	c := newTestClient(t)
+	_, err := c.Login(context.Background(), user.Email, "correct-horse-42")
+	require.NoError(t, err)
+	return c, user
+}
+
+func TestClient_LoginAndMe(t *testing.T) {
+	t.Parallel()
+	c, user := loginClient(t, models.RoleUser)
+
+	me, err := c.Me(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint(user.ID), me.ID)
+	assert.Equal(t, client.RoleUser, me.Role)
+}
+
+func TestClient_LoginFailure(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t)
+
+	_, err := c.Login(context.Background(), "nobody@test.com", "wrong-password")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+}
+
+func TestClient_RefreshesExpiredAccessToken(t *testing.T) {
+	t.Parallel()
+	c, user := loginClient(t, models.RoleUser)
+	_, refreshToken := c.Tokens()
+	c.SetTokens("expired-or-garbage", refreshToken)
+
+	me, err := c.Me(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint(user.ID), me.ID)
+
+	accessToken, _ := c.Tokens()
+	assert.NotEqual(t, "expired-or-garbage", accessToken)
+}
+
+func TestClient_WithoutRefreshTokenReturnsUnauthorized(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t)
+	c.SetTokens("garbage", "")
+
+	_, err := c.Me(context.Background())
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+}
+
+func TestClient_UserCRUD(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	admin, _ := loginClient(t, models.RoleAdmin)
+
+	created, err := admin.CreateUser(ctx, client.CreateUserRequest{
+		Name:     "Client Created",
+		Email:    "client-crud@test.com",
+		Password: "correct-horse-42",
+		Age:      28,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, client.RoleUser, created.Role)
+
+	_, err = newTestClient(t).Login(ctx, "client-crud@test.com", "correct-horse-42")
+	require.NoError(t, err, "users created by an admin can log in")
+
+	_, err = admin.CreateUser(ctx, client.CreateUserRequest{
+		Name:     "Client Created",
+		Email:    "client-crud@test.com",
+		Password: "correct-horse-42",
+		Age:      28,
+	})
+	assert.ErrorIs(t, err, client.ErrConflict, "duplicate emails are rejected as conflicts")
+
+	got, err := admin.GetUser(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Email, got.Email)
+
+	name := "Client Renamed"
+	updated, err := admin.UpdateUser(ctx, created.ID, client.UpdateUserRequest{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+
+	require.NoError(t, admin.DeleteUser(ctx, created.ID))
+	_, err = admin.GetUser(ctx, created.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	c, _ := loginClient(t, models.RoleUser)
+
+	_, err := c.CreateUser(ctx, client.CreateUserRequest{Name: "Nope", Email: "nope@test.com", Password: "correct-horse-42", Age: 20})
+	assert.ErrorIs(t, err, client.ErrForbidden)
+
+	admin, _ := loginClient(t, models.RoleAdmin)
+	_, err = admin.CreateUser(ctx, client.CreateUserRequest{Name: "X"})
+	require.ErrorIs(t, err, client.ErrValidation)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.NotEmpty(t, apiErr.Fields)
+}
+
+func TestClient_AllUsersIteratesEveryPage(t *testing.T) {
+	t.Parallel()
+	c, _ := loginClient(t, models.RoleUser)
+	for i := 0; i < 5; i++ {
+		_, err := testFactory.User(factory.WithName("Client Paging"))
+		require.NoError(t, err)
+	}
+
+	opts := client.ListUsersOptions{Limit: 2, Search: "Client Paging"}
+	page, err := c.ListUsers(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, int64(5), page.Pagination.Total)
+
+	seen := map[uint]bool{}
+	for user, err := range c.AllUsers(context.Background(), opts) {
+		require.NoError(t, err)
+		seen[user.ID] = true
+	}
+	assert.Len(t, seen, 5)
+
+	active := true
+	page, err = c.ListUsers(context.Background(), client.ListUsersOptions{Search: "Client Paging", Active: &active})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), page.Pagination.Total)
+	active = false
+	page, err = c.ListUsers(context.Background(), client.ListUsersOptions{Search: "Client Paging", Active: &active})
+	require.NoError(t, err)
+	assert.Zero(t, page.Pagination.Total)
+}
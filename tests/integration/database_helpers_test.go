@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,7 +56,7 @@ func TestGetUserByEmail(t *testing.T) {
 		assert.NotNil(t, user)
 		assert.Equal(t, "user@test.com", user.Email)
 		assert.Equal(t, "Test user", user.Name)
-		assert.Equal(t, "user", user.Role)
+		assert.Equal(t, models.RoleUser, user.Role)
 	})
 
 	// Test non-existent user
@@ -82,7 +83,7 @@ func TestDeleteUser(t *testing.T) {
 	assert.Equal(t, int64(1), count)
 
 	// Delete user
-	err = deleteUser(user.ID)
+	err = deleteUser(uint(user.ID))
 	require.NoError(t, err)
 
 	// Verify user is deleted
@@ -98,6 +99,8 @@ func TestDeleteUser(t *testing.T) {
 
 // TestCleanDatabase tests the cleanDatabase helper function
 func TestCleanDatabase(t *testing.T) {
+	cleanDatabase()
+
 	// Create multiple users
 	_, err := seedTestUser("user")
 	require.NoError(t, err)
@@ -122,15 +125,33 @@ func TestCleanDatabase(t *testing.T) {
 	assert.Equal(t, int64(0), auditCount)
 }
 
+// TestCleanDatabaseAllTables tests that cleanDatabase empties every migrated table
+func TestCleanDatabaseAllTables(t *testing.T) {
+	cleanDatabase()
+
+	user, err := testFactory.User()
+	require.NoError(t, err)
+	_, err = testFactory.AuditLog(factory.ForUser(uint(user.ID)), factory.Failed("boom"))
+	require.NoError(t, err)
+
+	cleanDatabase()
+
+	for _, model := range testModels {
+		var count int64
+		require.NoError(t, testDB.Model(model).Count(&count).Error)
+		assert.Zero(t, count, "%T table should be empty", model)
+	}
+}
+
 // TestSeedTestUser tests the seedTestUser helper function
 func TestSeedTestUser(t *testing.T) {
 	cleanDatabase()
 
 	tests := []struct {
 		name     string
-		role     string
+		role     models.Role
 		wantName string
-		wantRole string
+		wantRole models.Role
 	}{
 		{
 			name:     "Create Regular User",
@@ -160,7 +181,7 @@ func TestSeedTestUser(t *testing.T) {
 
 			// Verify user properties
 			assert.Equal(t, tt.wantName, user.Name)
-			assert.Equal(t, tt.role+"@test.com", user.Email)
+			assert.Equal(t, tt.role.String()+"@test.com", user.Email)
 			assert.Equal(t, tt.wantRole, user.Role)
 			assert.Equal(t, 30, user.Age)
 			assert.True(t, user.IsActive)
@@ -220,7 +241,7 @@ func TestCountAuditLogs(t *testing.T) {
 	// Create audit log manually
 	userID1 := uint(1)
 	auditLog := &models.AuditLog{
-		UserID:    &userID1,
+		UserID:    models.IDPtr(&userID1),
 		Action:    "test_action",
 		Resource:  "test_resource",
 		IPAddress: "127.0.0.1",
@@ -237,7 +258,7 @@ func TestCountAuditLogs(t *testing.T) {
 	// Create another audit log
 	userID2 := uint(2)
 	auditLog2 := &models.AuditLog{
-		UserID:    &userID2,
+		UserID:    models.IDPtr(&userID2),
 		Action:    "test_action_2",
 		Resource:  "test_resource_2",
 		IPAddress: "127.0.0.2",
@@ -294,7 +315,7 @@ func TestHelperFunctionsIntegration(t *testing.T) {
 	assert.Equal(t, user2.ID, foundUser2.ID)
 
 	// Step 6: Delete one user
-	err = deleteUser(user1.ID)
+	err = deleteUser(uint(user1.ID))
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), countUsers())
 
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDateOfBirth checks that age and date_of_birth are optional and exclusive,
+// and that the age of users with a date of birth is computed
+func TestDateOfBirth(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	registerBody := func(extra string) string {
+		return fmt.Sprintf(`{"name":"Born","email":%q,"password":"correct-horse-42"%s}`, factory.UniqueEmail("born"), extra)
+	}
+	userOf := func(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+		t.Helper()
+		var resp struct {
+			Data struct {
+				User map[string]interface{} `json:"user"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data.User
+	}
+	dob := time.Now().UTC().AddDate(-30, 0, -1).Format(models.DateLayout)
+
+	t.Run("register with date of birth", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/register", "", registerBody(fmt.Sprintf(`,"date_of_birth":%q`, dob)))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		user := userOf(t, w)
+		assert.Equal(t, float64(30), user["age"])
+		assert.True(t, strings.HasPrefix(user["date_of_birth"].(string), dob), user["date_of_birth"])
+	})
+
+	t.Run("register without age", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/register", "", registerBody(""))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.NotContains(t, userOf(t, w), "age")
+	})
+
+	t.Run("reject age with date of birth", func(t *testing.T) {
+		w := send("POST", "/api/v1/auth/register", "", registerBody(fmt.Sprintf(`,"age":30,"date_of_birth":%q`, dob)))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "age cannot be combined with dateofbirth")
+	})
+
+	t.Run("reject implausible dates of birth", func(t *testing.T) {
+		for _, date := range []string{time.Now().UTC().AddDate(0, 0, 1).Format(models.DateLayout), "1800-01-01"} {
+			w := send("POST", "/api/v1/users", adminToken, registerBody(fmt.Sprintf(`,"date_of_birth":%q`, date)))
+			require.Equal(t, http.StatusBadRequest, w.Code, date)
+			assert.Contains(t, w.Body.String(), "dateofbirth must be in the past", date)
+		}
+	})
+
+	t.Run("setting the age clears the date of birth", func(t *testing.T) {
+		w := send("POST", "/api/v1/users", adminToken, registerBody(fmt.Sprintf(`,"date_of_birth":%q`, dob)))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created struct {
+			Data models.User `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.NotNil(t, created.Data.DateOfBirth)
+
+		w = send("PUT", fmt.Sprintf("/api/v1/users/%d", created.Data.ID), adminToken, `{"age":45}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		stored, err := getUserByEmail(created.Data.Email)
+		require.NoError(t, err)
+		assert.Nil(t, stored.DateOfBirth)
+		assert.Equal(t, 45, stored.Age)
+	})
+}
@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publicPaths are the routes of the test router that need no token
+var publicPaths = []string{"/auth/register", "/auth/login", "/auth/refresh", "/auth/logout", "/health", "/ready"}
+
+// TestDeletedUserTokenRejected checks that a deleted user's token is refused
+// on every protected route of the router, not only on the /users group
+func TestDeletedUserTokenRejected(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	target, targetToken := newUserWithToken(t, models.RoleSuperAdmin)
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, send("GET", "/api/v1/auth/profile", targetToken).Code)
+	w := send("DELETE", fmt.Sprintf("/api/v1/users/%d", target.ID), adminToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	pathParams := strings.NewReplacer(":id", fmt.Sprint(target.ID), ":name", "deleted-user-flag")
+	checked := 0
+	for _, route := range testRouter.Routes() {
+		// The WebSocket handshake validates the token itself, without the
+		// user lookup of JWTAuth; see websocket_auth_test.go
+		if isPublicPath(route.Path) || route.Path == "/ws" {
+			continue
+		}
+		path := pathParams.Replace(route.Path)
+		w := send(route.Method, path, targetToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s: %s", route.Method, path, w.Body.String())
+		checked++
+	}
+	assert.Greater(t, checked, 20, "the protected routes are covered")
+}
+
+func isPublicPath(path string) bool {
+	for _, public := range publicPaths {
+		if strings.HasSuffix(path, public) {
+			return true
+		}
+	}
+	return false
+}
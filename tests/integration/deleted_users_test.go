@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeletedUsers tests listing, restoring and purging soft-deleted users
+func TestDeletedUsers(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, superToken := newUserWithToken(t, models.RoleSuperAdmin)
+	target, targetToken := newUserWithToken(t, models.RoleUser)
+	purged, _ := newUserWithToken(t, models.RoleUser)
+
+	// Tokens issued in the second of the restore are accepted, so the old
+	// token has to be older than that
+	time.Sleep(time.Second)
+
+	for _, user := range []*models.User{target, purged} {
+		w := sendJSON("DELETE", fmt.Sprintf("/api/v1/users/%d", user.ID), adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	create := func(email string) *http.Response {
+		body := fmt.Sprintf(`{"name":"Again","email":%q,"password":%q,"age":30}`, email, factory.DefaultPassword)
+		return sendJSON("POST", "/api/v1/users", adminToken, body).Result()
+	}
+	login := func(user *models.User) int {
+		body := fmt.Sprintf(`{"email":%q,"password":%q}`, user.Email, factory.DefaultPassword)
+		return sendJSON("POST", "/api/v1/auth/login", "", body).Code
+	}
+
+	t.Run("Deleted users are listed", func(t *testing.T) {
+		w := sendJSON("GET", "/api/v1/users/deleted?limit=100", adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Data       []models.DeletedUser  `json:"data"`
+			Pagination models.PaginationMeta `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		listed := map[models.ID]models.DeletedUser{}
+		for _, deleted := range resp.Data {
+			listed[deleted.User.ID] = deleted
+		}
+		require.Contains(t, listed, target.ID)
+		assert.Equal(t, target.Email, listed[target.ID].User.Email)
+		assert.WithinDuration(t, time.Now(), listed[target.ID].DeletedAt, time.Minute)
+		assert.GreaterOrEqual(t, resp.Pagination.Total, int64(2))
+	})
+
+	t.Run("Email of a deleted user conflicts", func(t *testing.T) {
+		resp := create(target.Email)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var body models.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Contains(t, body.Message, "restore or purge")
+	})
+
+	t.Run("Restore then login", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, login(target), "deleted users cannot log in")
+
+		w := sendJSON("POST", fmt.Sprintf("/api/v1/users/%d/restore", target.ID), adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = sendJSON("GET", "/api/v1/users/me", targetToken, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "tokens from before the delete stay rejected")
+
+		w = sendJSON("POST", "/api/v1/auth/login", "", fmt.Sprintf(`{"email":%q,"password":%q}`, target.Email, factory.DefaultPassword))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data models.LoginResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		w = sendJSON("GET", "/api/v1/users/me", resp.Data.AccessToken, "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = sendJSON("POST", fmt.Sprintf("/api/v1/users/%d/restore", target.ID), adminToken, "")
+		assert.Equal(t, http.StatusNotFound, w.Code, "not deleted any more")
+	})
+
+	t.Run("Purge frees the email", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/users/%d/purge", purged.ID)
+		assert.Equal(t, http.StatusForbidden, sendJSON("DELETE", path, adminToken, "").Code, "superadmin only")
+
+		w := sendJSON("DELETE", path, superToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, http.StatusNotFound, sendJSON("DELETE", path, superToken, "").Code)
+
+		resp := create(purged.Email)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("Live users cannot be purged", func(t *testing.T) {
+		w := sendJSON("DELETE", fmt.Sprintf("/api/v1/users/%d/purge", target.ID), superToken, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
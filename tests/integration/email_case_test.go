@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmailsIgnoreCase checks that emails are stored lowercased, match in
+// any case at login, and cannot be registered twice in different cases
+func TestEmailsIgnoreCase(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	email := factory.UniqueEmail("mixed")
+	mixed := strings.Replace(email, "mixed", "MiXeD", 1)
+
+	body := func(email string) string {
+		return fmt.Sprintf(`{"name":"Mixed Case","email":%q,"password":%q}`, email, factory.DefaultPassword)
+	}
+
+	w := sendJSON("POST", "/api/v1/auth/register", "", body(mixed))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			User models.User `json:"user"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, email, resp.Data.User.Email, "the email is stored lowercased")
+
+	for _, login := range []string{email, mixed, strings.ToUpper(email)} {
+		w = sendJSON("POST", "/api/v1/auth/login", "", body(login))
+		assert.Equal(t, http.StatusOK, w.Code, login)
+	}
+
+	w = sendJSON("POST", "/api/v1/auth/register", "", body(strings.ToUpper(email)))
+	assert.Equal(t, http.StatusConflict, w.Code, "registering the email in another case")
+
+	w = sendJSON("POST", "/api/v1/users", adminToken, fmt.Sprintf(`{"name":"Duplicate","email":%q,"password":%q,"age":30}`, strings.ToUpper(email), factory.DefaultPassword))
+	assert.Equal(t, http.StatusConflict, w.Code, "creating the email in another case")
+
+	other, _ := newUserWithToken(t, models.RoleUser)
+	w = sendJSON("PUT", fmt.Sprintf("/api/v1/users/%d", other.ID), adminToken, fmt.Sprintf(`{"email":%q}`, mixed))
+	assert.Equal(t, http.StatusConflict, w.Code, "changing an email to another case of a taken one")
+}
@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserETags checks conditional GETs of a user and of a page of users
+func TestUserETags(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	target, _ := newUserWithToken(t, models.RoleUser, factory.WithName("ETag Target"), factory.WithEmail("target@etag.test"))
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/api/v1/users/%d", target.ID),
+		"/api/v1/users?search=etag.test",
+		fmt.Sprintf("/api/v2/users/%d", target.ID),
+	} {
+		t.Run(path, func(t *testing.T) {
+			// Missing header: full response with an ETag
+			w := get(path, "")
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			etag := w.Header().Get("ETag")
+			require.True(t, strings.HasPrefix(etag, `W/"`), "weak ETag, got %q", etag)
+			assert.Equal(t, etag, get(path, "").Header().Get("ETag"), "the ETag is stable")
+
+			// Match, also within a list and as a strong tag
+			w = get(path, etag)
+			assert.Equal(t, http.StatusNotModified, w.Code)
+			assert.Empty(t, w.Body.String())
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			assert.Equal(t, http.StatusNotModified, get(path, `"other", `+strings.TrimPrefix(etag, "W/")).Code)
+
+			// Mismatch
+			w = get(path, `W/"stale"`)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NotEmpty(t, w.Body.String())
+		})
+	}
+
+	// An update changes the ETag
+	path := fmt.Sprintf("/api/v1/users/%d", target.ID)
+	etag := get(path, "").Header().Get("ETag")
+	require.NoError(t, testDB.Model(&models.User{}).Where("id = ?", target.ID).Update("bio", "changed").Error)
+	w := get(path, etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
@@ -0,0 +1,159 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventsFor returns the recorded events about targetID.
+// Filtering by target keeps parallel tests from seeing each other's events.
+func eventsFor(targetID uint) []events.Event {
+	var matched []events.Event
+	for _, event := range testEvents.Events() {
+		if event.TargetID == targetID {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// TestHandlersPublishEvents tests that user and auth handlers emit the expected domain events
+func TestHandlersPublishEvents(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	superadmin, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Register and login", func(t *testing.T) {
+		email := factory.UniqueEmail("events-register")
+		w := send("POST", "/api/v1/auth/register", "", models.RegisterRequest{
+			Name:     "Event Register",
+			Email:    email,
+			Password: factory.DefaultPassword,
+			Age:      30,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		user, err := getUserByEmail(email)
+		require.NoError(t, err)
+
+		w = send("POST", "/api/v1/auth/login", "", models.LoginRequest{Email: email, Password: factory.DefaultPassword})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		published := eventsFor(uint(user.ID))
+		require.Len(t, published, 2)
+		assert.Equal(t, events.UserRegistered, published[0].Type)
+		assert.Equal(t, uint(user.ID), published[0].ActorID)
+		assert.Equal(t, events.UserLoggedIn, published[1].Type)
+	})
+
+	t.Run("Failed login publishes nothing", func(t *testing.T) {
+		user, err := testFactory.User()
+		require.NoError(t, err)
+
+		w := send("POST", "/api/v1/auth/login", "", models.LoginRequest{Email: user.Email, Password: "wrong-password"})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+
+		assert.Empty(t, eventsFor(uint(user.ID)))
+	})
+
+	t.Run("Admin user lifecycle", func(t *testing.T) {
+		w := send("POST", "/api/v1/users", adminToken, models.CreateUserRequest{
+			Name:     "Event Lifecycle",
+			Email:    factory.UniqueEmail("events-lifecycle"),
+			Password: factory.DefaultPassword,
+			Age:      30,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created struct {
+			Data models.User `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		id := created.Data.ID
+
+		name := "Event Lifecycle Renamed"
+		w = send("PUT", fmt.Sprintf("/api/v1/users/%d", id), adminToken, models.UpdateUserRequest{Name: &name})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = send("PUT", fmt.Sprintf("/api/v1/users/%d/role", id), superadminToken, models.UpdateRoleRequest{Role: "admin"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = send("DELETE", fmt.Sprintf("/api/v1/users/%d", id), adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		published := eventsFor(uint(id))
+		require.Len(t, published, 4)
+
+		assert.Equal(t, events.UserCreated, published[0].Type)
+		assert.Equal(t, uint(admin.ID), published[0].ActorID)
+
+		assert.Equal(t, events.UserUpdated, published[1].Type)
+		assert.Equal(t, uint(admin.ID), published[1].ActorID)
+
+		assert.Equal(t, events.UserRoleChanged, published[2].Type)
+		assert.Equal(t, uint(superadmin.ID), published[2].ActorID)
+		assert.Equal(t, models.RoleUser, published[2].Payload["old_role"])
+		assert.Equal(t, models.RoleAdmin, published[2].Payload["new_role"])
+
+		assert.Equal(t, events.UserDeleted, published[3].Type)
+		assert.Equal(t, uint(admin.ID), published[3].ActorID)
+	})
+
+	t.Run("Own profile and password", func(t *testing.T) {
+		user, token := newUserWithToken(t, models.RoleUser)
+
+		bio := "Publishes events"
+		w := send("PUT", "/api/v1/users/me", token, models.UpdateProfileRequest{Bio: &bio})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = send("PUT", "/api/v1/users/me/password", token, models.ChangePasswordRequest{
+			CurrentPassword: factory.DefaultPassword,
+			NewPassword:     "new-password-456",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		published := eventsFor(uint(user.ID))
+		require.Len(t, published, 2)
+		assert.Equal(t, events.ProfileUpdated, published[0].Type)
+		assert.Equal(t, events.PasswordChanged, published[1].Type)
+		for _, event := range published {
+			assert.Equal(t, uint(user.ID), event.ActorID)
+		}
+	})
+
+	t.Run("Rejected update publishes nothing", func(t *testing.T) {
+		user, err := testFactory.User()
+		require.NoError(t, err)
+
+		w := send("PUT", fmt.Sprintf("/api/v1/users/%d", user.ID), adminToken, map[string]interface{}{"email": "not-an-email"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		assert.Empty(t, eventsFor(uint(user.ID)))
+	})
+}
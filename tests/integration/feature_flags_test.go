@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeatureFlags_AdminCRUD checks flag management and its audit trail
+func TestFeatureFlags_AdminCRUD(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) models.FeatureFlag {
+		t.Helper()
+		var resp struct {
+			Data models.FeatureFlag `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data
+	}
+
+	w := send("POST", "/api/v1/admin/flags", adminToken, `{"name":"crud_flag","enabled":true,"roles":["admin"],"percentage":25}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decode(w)
+	assert.Equal(t, []models.Role{models.RoleAdmin}, created.Roles)
+	assert.Equal(t, 25, created.Percentage)
+
+	w = send("POST", "/api/v1/admin/flags", adminToken, `{"name":"crud_flag"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "names are unique")
+
+	w = send("PUT", "/api/v1/admin/flags/crud_flag", adminToken, `{"percentage":100}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := decode(w)
+	assert.Equal(t, 100, updated.Percentage)
+	assert.True(t, updated.Enabled, "omitted fields are kept")
+
+	w = send("GET", "/api/v1/admin/flags/crud_flag", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, updated.Percentage, decode(w).Percentage)
+
+	w = send("GET", "/api/v1/admin/flags", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"crud_flag"`)
+
+	w = send("DELETE", "/api/v1/admin/flags/crud_flag", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = send("GET", "/api/v1/admin/flags/crud_flag", adminToken, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Every change is audited with the flag before and after it
+	assert.Eventually(t, func() bool {
+		var logs []models.AuditLog
+		testDB.Where("user_id = ? AND resource = ?", admin.ID, models.AuditResourceFlag).Order("id").Find(&logs)
+		if len(logs) != 3 {
+			return false
+		}
+		var change models.FeatureFlagChange
+		if err := json.Unmarshal([]byte(logs[1].Details), &change); err != nil || change.Before == nil || change.After == nil {
+			return false
+		}
+		return logs[0].Action == models.AuditActionFlagCreate &&
+			logs[1].Action == models.AuditActionFlagUpdate && change.Before.Percentage == 25 && change.After.Percentage == 100 &&
+			logs[2].Action == models.AuditActionFlagDelete && logs[2].DetailsSchema == "feature_flag_change.v1"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	t.Run("validation", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"name":"f","percentage":101}`, `{"name":"f","roles":["root"]}`} {
+			w := send("POST", "/api/v1/admin/flags", adminToken, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+		w := send("PUT", "/api/v1/admin/flags/missing_flag", adminToken, `{"roles":["root"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = send("PUT", "/api/v1/admin/flags/missing_flag", adminToken, `{"enabled":true}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		w := send("GET", "/api/v1/admin/flags", userToken, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = send("POST", "/api/v1/admin/flags", userToken, `{"name":"user_flag"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,218 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites the golden files instead of comparing against them:
+//
+//	go test ./tests/integration -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+var (
+	// Values that change on every run are replaced before comparison
+	goldenTokenPattern     = regexp.MustCompile(`"(access_token|refresh_token)":\s*"[^"]*"`)
+	goldenTimestampPattern = regexp.MustCompile(`"(created_at|updated_at|timestamp|expires_at)":\s*"[^"]*"`)
+	goldenIDPattern        = regexp.MustCompile(`"(id|user_id)":\s*\d+`)
+)
+
+// goldenResponse is the layout of a golden file
+type goldenResponse struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// normalizeGolden masks volatile values and indents the response for a stable, reviewable diff
+func normalizeGolden(t *testing.T, w *httptest.ResponseRecorder) []byte {
+	t.Helper()
+
+	body := w.Body.Bytes()
+	require.True(t, json.Valid(body), "response is not valid JSON: %s", body)
+
+	body = goldenTokenPattern.ReplaceAll(body, []byte(`"$1":"<token>"`))
+	body = goldenTimestampPattern.ReplaceAll(body, []byte(`"$1":"<timestamp>"`))
+	body = goldenIDPattern.ReplaceAll(body, []byte(`"$1":"<id>"`))
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	require.NoError(t, enc.Encode(goldenResponse{Status: w.Code, ContentType: w.Header().Get("Content-Type"), Body: body}))
+	return out.Bytes()
+}
+
+// assertGolden compares the response status and body with testdata/golden/<name>.json
+func assertGolden(t *testing.T, name string, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	got := normalizeGolden(t, w)
+	path := filepath.Join("testdata", "golden", name+".json")
+
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run with -update to create it")
+	require.Equal(t, string(want), string(got), "response for %s differs from %s", name, path)
+}
+
+// TestGoldenResponses pins the wire format of the main public endpoints in both
+// API versions. Any intentional change must be reviewed as a diff of testdata/golden.
+func TestGoldenResponses(t *testing.T) {
+	cleanDatabase()
+	defer cleanDatabase()
+
+	admin, err := seedTestUser(models.RoleAdmin)
+	require.NoError(t, err)
+	_, err = seedTestUser(models.RoleUser)
+	require.NoError(t, err)
+
+	adminToken, err := getAuthToken(admin)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		token  string
+	}{
+		{
+			name:   "login_success",
+			method: "POST",
+			path:   "/api/v1/auth/login",
+			body:   models.LoginRequest{Email: "admin@test.com", Password: "correct-horse-42"},
+		},
+		{
+			name:   "login_invalid_credentials",
+			method: "POST",
+			path:   "/api/v1/auth/login",
+			body:   models.LoginRequest{Email: "admin@test.com", Password: "wrong-password"},
+		},
+		{
+			name:   "register_validation_error",
+			method: "POST",
+			path:   "/api/v1/auth/register",
+			body:   map[string]interface{}{"email": "not-an-email"},
+		},
+		{
+			name:   "users_list",
+			method: "GET",
+			path:   "/api/v1/users",
+			token:  adminToken,
+		},
+		{
+			name:   "user_detail",
+			method: "GET",
+			path:   fmt.Sprintf("/api/v1/users/%d", admin.ID),
+			token:  adminToken,
+		},
+		{
+			name:   "user_not_found",
+			method: "GET",
+			path:   "/api/v1/users/999999",
+			token:  adminToken,
+		},
+		{
+			name:   "invalid_path_param",
+			method: "GET",
+			path:   "/api/v1/users/abc",
+			token:  adminToken,
+		},
+		{
+			name:   "missing_token",
+			method: "GET",
+			path:   "/api/v1/users",
+		},
+		{
+			name:   "health_status_only",
+			method: "GET",
+			path:   "/health",
+		},
+		{
+			name:   "v2_login_success",
+			method: "POST",
+			path:   "/api/v2/auth/login",
+			body:   models.LoginRequest{Email: "admin@test.com", Password: "correct-horse-42"},
+		},
+		{
+			name:   "v2_login_invalid_credentials",
+			method: "POST",
+			path:   "/api/v2/auth/login",
+			body:   models.LoginRequest{Email: "admin@test.com", Password: "wrong-password"},
+		},
+		{
+			name:   "v2_register_validation_error",
+			method: "POST",
+			path:   "/api/v2/auth/register",
+			body:   map[string]interface{}{"email": "not-an-email"},
+		},
+		{
+			name:   "v2_users_list",
+			method: "GET",
+			path:   "/api/v2/users",
+			token:  adminToken,
+		},
+		{
+			name:   "v2_user_detail",
+			method: "GET",
+			path:   fmt.Sprintf("/api/v2/users/%d", admin.ID),
+			token:  adminToken,
+		},
+		{
+			name:   "v2_user_not_found",
+			method: "GET",
+			path:   "/api/v2/users/999999",
+			token:  adminToken,
+		},
+		{
+			name:   "v2_invalid_path_param",
+			method: "GET",
+			path:   "/api/v2/users/abc",
+			token:  adminToken,
+		},
+		{
+			name:   "v2_missing_token",
+			method: "GET",
+			path:   "/api/v2/users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			if tt.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tt.body))
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, &body)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			w := httptest.NewRecorder()
+			testRouter.ServeHTTP(w, req)
+
+			require.NotEqual(t, http.StatusInternalServerError, w.Code, "unexpected server error: %s", w.Body.String())
+			assertGolden(t, tt.name, w)
+		})
+	}
+}
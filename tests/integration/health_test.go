@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHealthDetailTier tests that component details are hidden from anonymous callers
+func TestHealthDetailTier(t *testing.T) {
+	t.Parallel()
+
+	getHealth := func(t *testing.T, setHeaders func(*http.Request)) map[string]interface{} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/health", nil)
+		if setHeaders != nil {
+			setHeaders(req)
+		}
+		testRouter.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("Anonymous caller gets status only", func(t *testing.T) {
+		resp := getHealth(t, nil)
+
+		assert.Equal(t, "healthy", resp["status"])
+		assert.Len(t, resp, 1, "Only the status field should be exposed")
+	})
+
+	t.Run("Wrong health token gets status only", func(t *testing.T) {
+		resp := getHealth(t, func(r *http.Request) {
+			r.Header.Set("X-Health-Token", "not-the-token")
+		})
+
+		assert.NotContains(t, resp, "components")
+	})
+
+	t.Run("Regular user gets status only", func(t *testing.T) {
+		_, token := newUserWithToken(t, models.RoleUser)
+
+		resp := getHealth(t, func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		})
+
+		assert.NotContains(t, resp, "components")
+	})
+
+	t.Run("Admin gets component details", func(t *testing.T) {
+		_, token := newUserWithToken(t, models.RoleAdmin)
+
+		resp := getHealth(t, func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		})
+
+		require.Contains(t, resp, "components")
+		assert.Contains(t, resp["components"], "database")
+		assert.Contains(t, resp, "system")
+	})
+
+	t.Run("Stale admin token gets status only", func(t *testing.T) {
+		for name, change := range map[string]map[string]interface{}{
+			"demoted":     {"role": models.RoleUser},
+			"deactivated": {"is_active": false},
+			"offboarded":  {"tokens_revoked_at": time.Now().Add(time.Minute)},
+		} {
+			admin, token := newUserWithToken(t, models.RoleAdmin)
+			require.NoError(t, testDB.Model(&models.User{}).Where("id = ?", admin.ID).Updates(change).Error)
+
+			resp := getHealth(t, func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+token)
+			})
+			assert.NotContains(t, resp, "components", name)
+		}
+	})
+
+	t.Run("Health token gets component details", func(t *testing.T) {
+		resp := getHealth(t, func(r *http.Request) {
+			r.Header.Set("X-Health-Token", testHealthToken)
+		})
+
+		assert.Contains(t, resp, "components")
+	})
+}
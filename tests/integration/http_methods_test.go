@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHTTPMethodHandling tests HEAD, OPTIONS and 405 handling at the router level
+func TestHTTPMethodHandling(t *testing.T) {
+	t.Parallel()
+
+	t.Run("HEAD /health succeeds", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("HEAD", "/health", nil)
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "HEAD should be served for health checks")
+	})
+
+	t.Run("OPTIONS /api/v1/users answers preflight without auth", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("OPTIONS", "/api/v1/users", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, "Preflight should not require a token")
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "POST")
+		assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Unsupported method returns 405 with Allow header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/health", nil)
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code, "Known path with wrong method should be 405")
+		assert.Contains(t, w.Header().Get("Allow"), "GET")
+		assert.Contains(t, w.Header().Get("Allow"), "HEAD")
+	})
+
+	t.Run("Wrong method answers the JSON envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("DELETE", "/api/v1/auth/login", nil)
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "POST", w.Header().Get("Allow"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.JSONEq(t, `{
+			"success": false,
+			"message": "method not allowed",
+			"data": {"method": "DELETE", "path": "/api/v1/auth/login", "allowed": ["POST"]}
+		}`, w.Body.String())
+	})
+
+	t.Run("Unknown path answers the JSON envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/no-such-thing?x=1", nil)
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Allow"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.JSONEq(t, `{
+			"success": false,
+			"message": "route not found",
+			"data": {"method": "GET", "path": "/api/v1/no-such-thing"}
+		}`, w.Body.String())
+	})
+}
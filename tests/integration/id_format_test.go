@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/middleware"
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIDFormatString checks that string IDs can be requested on every API
+// version and sent back where the API takes IDs
+func TestIDFormatString(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	target, err := testFactory.User()
+	require.NoError(t, err)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.IDFormatHeader, middleware.IDFormatString)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	wantID := fmt.Sprint(target.ID)
+
+	t.Run("v1 envelope", func(t *testing.T) {
+		w := send("GET", "/api/v1/users/"+wantID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, wantID, resp.Data["id"])
+		assert.IsType(t, float64(0), resp.Data["age"], "only IDs are quoted")
+	})
+
+	t.Run("v2 round trip", func(t *testing.T) {
+		w := send("GET", "/api/v2/users/"+wantID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		// Decoding the string ID into the model and using it again works
+		var user models.User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+		assert.Equal(t, target.ID, user.ID)
+
+		w = send("PUT", "/api/v2/users/"+user.ID.String(), `{"name":"String ID"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":"`+wantID+`"`)
+	})
+
+	t.Run("responses built outside models", func(t *testing.T) {
+		w := send("GET", fmt.Sprintf("/api/v1/users/%d/auth-summary", admin.ID), "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, fmt.Sprint(admin.ID), resp.Data["user_id"])
+	})
+}
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postRefreshToken posts {"refresh_token": token} to an auth endpoint
+func postRefreshToken(path, token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"refresh_token": token})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+// refreshTokenFrom returns the refresh token of a v1 login or refresh response
+func refreshTokenFrom(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var resp struct {
+		Data struct {
+			RefreshToken string `json:"refresh_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Data.RefreshToken)
+	return resp.Data.RefreshToken
+}
+
+func TestLogout(t *testing.T) {
+	t.Parallel()
+
+	user, _ := newUserWithToken(t, models.RoleUser)
+	refreshToken := newRefreshToken(t, user)
+
+	// The token works before logout; refreshing rotates it
+	w := postRefreshToken("/api/v1/auth/refresh", refreshToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	refreshToken = refreshTokenFrom(t, w)
+
+	w = postRefreshToken("/api/v1/auth/logout", refreshToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Logging out again is idempotent
+	w = postRefreshToken("/api/v1/auth/logout", refreshToken)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postRefreshToken("/api/v2/auth/logout", refreshToken)
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = postRefreshToken("/api/v1/auth/refresh", refreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "revoked refresh token must be rejected")
+
+	// Audit logs are written asynchronously
+	assert.Eventually(t, func() bool {
+		var logouts int64
+		testDB.Model(&models.AuditLog{}).
+			Where("user_id = ? AND action = ? AND success = ?", user.ID, models.AuditActionLogout, true).
+			Count(&logouts)
+		return logouts == 3
+	}, 2*time.Second, 10*time.Millisecond, "every logout is audited")
+}
+
+func TestLogout_OnlyRevokesGivenToken(t *testing.T) {
+	t.Parallel()
+
+	user, _ := newUserWithToken(t, models.RoleUser, factory.WithPassword("correct-horse-42"))
+	revoked := newRefreshToken(t, user)
+	require.Equal(t, http.StatusOK, postRefreshToken("/api/v1/auth/logout", revoked).Code)
+
+	// A different session of the same user keeps working
+	other := newRefreshToken(t, user)
+	w := postRefreshToken("/api/v1/auth/refresh", other)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestLogout_RejectsInvalidToken(t *testing.T) {
+	t.Parallel()
+
+	w := postRefreshToken("/api/v1/auth/logout", "not-a-jwt")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/auth/logout", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOffboardUser tests that offboarding revokes every kind of access
+func TestOffboardUser(t *testing.T) {
+	t.Parallel()
+
+	_, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
+
+	offboard := func(token string, id uint) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/users/%d/offboard", id), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	login := func(email string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"email":    email,
+			"password": factory.DefaultPassword,
+		})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Superadmin offboards a user", func(t *testing.T) {
+		leaver, leaverToken := newUserWithToken(t, models.RoleUser)
+
+		// Obtain a refresh token before offboarding
+		w := login(leaver.Email)
+		require.Equal(t, http.StatusOK, w.Code)
+		var loginResp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+		refreshToken := loginResp["data"].(map[string]interface{})["refresh_token"].(string)
+
+		w = offboard(superadminToken, uint(leaver.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Success bool                  `json:"success"`
+			Data    models.OffboardReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Success)
+		assert.True(t, resp.Data.Success)
+		assert.Equal(t, uint(leaver.ID), resp.Data.UserID)
+
+		statuses := map[string]models.OffboardStepStatus{}
+		for _, step := range resp.Data.Steps {
+			statuses[step.Name] = step.Status
+		}
+		assert.Equal(t, models.OffboardStepOK, statuses[models.OffboardStepDeactivate])
+		assert.Equal(t, models.OffboardStepOK, statuses[models.OffboardStepRevokeTokens])
+		assert.Equal(t, models.OffboardStepOK, statuses[models.OffboardStepScramblePassword])
+		assert.Equal(t, models.OffboardStepSkipped, statuses[models.OffboardStepRevokeAPIKeys])
+		assert.Equal(t, models.OffboardStepOK, statuses[models.OffboardStepDisconnectWS])
+
+		// Existing access token is rejected
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+leaverToken)
+		testRouter.ServeHTTP(w, req)
+		assert.NotEqual(t, http.StatusOK, w.Code, "Offboarded user's token must not work")
+
+		// Refresh token is rejected
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest("POST", "/api/v1/auth/refresh", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Offboarded user's refresh token must not work")
+
+		// Old password no longer logs in
+		w = login(leaver.Email)
+		assert.NotEqual(t, http.StatusOK, w.Code, "Offboarded user must not be able to log in")
+
+		// Account is deactivated in the database
+		stored, err := getUserByEmail(leaver.Email)
+		require.NoError(t, err)
+		assert.False(t, stored.IsActive)
+		assert.NotNil(t, stored.TokensRevokedAt)
+	})
+
+	t.Run("Admin cannot offboard", func(t *testing.T) {
+		_, adminToken := newUserWithToken(t, models.RoleAdmin)
+		target, _ := newUserWithToken(t, models.RoleUser)
+
+		w := offboard(adminToken, uint(target.ID))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Superadmin cannot offboard self", func(t *testing.T) {
+		self, selfToken := newUserWithToken(t, models.RoleSuperAdmin)
+
+		w := offboard(selfToken, uint(self.ID))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown user returns 404", func(t *testing.T) {
+		w := offboard(superadminToken, 999999)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminResetPassword checks that a reset user can log in with the new
+// password but can do nothing except change it
+func TestAdminResetPassword(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	superadmin, _ := newUserWithToken(t, models.RoleSuperAdmin)
+	target, _ := newUserWithToken(t, models.RoleUser)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	resetPath := func(id models.ID) string {
+		return fmt.Sprintf("/api/v1/users/%d/reset-password", id)
+	}
+	login := func(password string) (models.LoginResponse, int) {
+		w := send("POST", "/api/v1/auth/login", "", fmt.Sprintf(`{"email":%q,"password":%q}`, target.Email, password))
+		var resp struct {
+			Data models.LoginResponse `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data, w.Code
+	}
+
+	// Without a body, a temporary password is generated and returned once
+	w := send("POST", resetPath(target.ID), adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reset struct {
+		Data models.AdminResetPasswordResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reset))
+	assert.True(t, reset.Data.MustChangePassword)
+	temporary := reset.Data.TemporaryPassword
+	require.Len(t, temporary, 16)
+
+	_, code := login("correct-horse-42")
+	assert.Equal(t, http.StatusUnauthorized, code, "the old password no longer works")
+	session, code := login(temporary)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, session.MustChangePassword)
+
+	t.Run("blocked until the password is changed", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/users/me", "/api/v1/users", "/api/v2/users/me", "/api/v1/auth/profile"} {
+			assert.Equal(t, http.StatusForbidden, send("GET", path, session.AccessToken, "").Code, path)
+		}
+		assert.Equal(t, http.StatusForbidden, send("PUT", "/api/v1/users/me", session.AccessToken, `{"name":"Sneaky"}`).Code)
+	})
+
+	// Changing the password clears the flag
+	w = send("PUT", "/api/v1/users/me/password", session.AccessToken,
+		fmt.Sprintf(`{"current_password":%q,"new_password":"chosen-password-7"}`, temporary))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, send("GET", "/api/v1/users/me", session.AccessToken, "").Code)
+	session, code = login("chosen-password-7")
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, session.MustChangePassword)
+
+	t.Run("given password", func(t *testing.T) {
+		w := send("POST", resetPath(target.ID), adminToken, `{"new_password":"given-password"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "temporary_password", "a given password is not echoed")
+
+		session, code := login("given-password")
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, session.MustChangePassword)
+	})
+
+	t.Run("guards", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, send("POST", resetPath(superadmin.ID), adminToken, "").Code, "higher role")
+		assert.Equal(t, http.StatusForbidden, send("POST", resetPath(target.ID), userToken, "").Code, "admin only")
+		assert.Equal(t, http.StatusBadRequest, send("POST", resetPath(target.ID), adminToken, `{"new_password":"short"}`).Code)
+		assert.Equal(t, http.StatusNotFound, send("POST", resetPath(999999999), adminToken, "").Code)
+	})
+
+	t.Run("audited", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			var resets, changes int64
+			testDB.Model(&models.AuditLog{}).
+				Where("user_id = ? AND resource_id = ? AND action = ? AND success = ?", admin.ID, target.ID, models.AuditActionPasswordReset, true).
+				Count(&resets)
+			testDB.Model(&models.AuditLog{}).
+				Where("user_id = ? AND action = ? AND success = ?", target.ID, models.AuditActionPasswordChange, true).
+				Count(&changes)
+			return resets == 2 && changes == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInvalidPathParams tests that malformed IDs get a uniform validation error
+func TestInvalidPathParams(t *testing.T) {
+	t.Parallel()
+
+	_, token := newUserWithToken(t, models.RoleAdmin)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"Non-numeric ID", "GET", "/api/v1/users/abc"},
+		{"Zero ID", "GET", "/api/v1/users/0"},
+		{"Negative ID", "DELETE", "/api/v1/users/-1"},
+		{"Overflowing ID", "PUT", "/api/v1/users/99999999999"},
+		{"Non-numeric summary ID", "GET", "/api/v1/users/abc/auth-summary"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			testRouter.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.False(t, resp["success"].(bool))
+			assert.Equal(t, "Validation failed", resp["message"])
+
+			errs := resp["errors"].([]interface{})
+			require.Len(t, errs, 1)
+			field := errs[0].(map[string]interface{})
+			assert.Equal(t, "id", field["field"])
+			assert.Equal(t, "id must be a positive integer", field["message"])
+		})
+	}
+}
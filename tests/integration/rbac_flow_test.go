@@ -8,29 +8,20 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 )
 
 // TestRBACPermissions tests role-based access control
 func TestRBACPermissions(t *testing.T) {
-	cleanDatabase()
+	t.Parallel()
 
 	// Create users with different roles
-	regularUser, err := seedTestUser("user")
-	require.NoError(t, err)
-	userToken, err := getAuthToken(regularUser)
-	require.NoError(t, err)
-
-	adminUser, err := seedTestUser("admin")
-	require.NoError(t, err)
-	adminToken, err := getAuthToken(adminUser)
-	require.NoError(t, err)
-
-	superadminUser, err := seedTestUser("superadmin")
-	require.NoError(t, err)
-	superadminToken, err := getAuthToken(superadminUser)
-	require.NoError(t, err)
+	regularUser, userToken := newUserWithToken(t, models.RoleUser)
+	adminUser, adminToken := newUserWithToken(t, models.RoleAdmin)
+	superadminUser, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
 
 	t.Run("Regular User Permissions", func(t *testing.T) {
 		t.Run("Can view users list", func(t *testing.T) {
@@ -63,8 +54,8 @@ func TestRBACPermissions(t *testing.T) {
 		t.Run("Cannot create users", func(t *testing.T) {
 			createReq := map[string]interface{}{
 				"name":     "New User",
-				"email":    "newuser@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("newuser"),
+				"password": "correct-horse-42",
 				"age":      25,
 				"role":     "user",
 			}
@@ -123,8 +114,8 @@ func TestRBACPermissions(t *testing.T) {
 		t.Run("Can create users", func(t *testing.T) {
 			createReq := map[string]interface{}{
 				"name":     "Admin Created User",
-				"email":    "admincreated@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("admincreated"),
+				"password": "correct-horse-42",
 				"age":      30,
 				"role":     "user",
 			}
@@ -137,6 +128,46 @@ func TestRBACPermissions(t *testing.T) {
 			testRouter.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusCreated, w.Code, "Admin can create users")
+			assert.Contains(t, w.Body.String(), `"role":"user"`)
+		})
+
+		t.Run("Cannot create superadmins", func(t *testing.T) {
+			email := factory.UniqueEmail("escalation")
+			createReq := map[string]interface{}{
+				"name":     "Escalated User",
+				"email":    email,
+				"password": "correct-horse-42",
+				"age":      30,
+				"role":     "superadmin",
+			}
+			body, _ := json.Marshal(createReq)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			req.Header.Set("Content-Type", "application/json")
+			testRouter.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusForbidden, w.Code, "Admin cannot create superadmins")
+			var count int64
+			testDB.Model(&models.User{}).Where("email = ?", email).Count(&count)
+			assert.Zero(t, count, "No user is created")
+		})
+
+		t.Run("Cannot batch create superadmins", func(t *testing.T) {
+			batchReq := []map[string]interface{}{
+				{"name": "Batch User", "email": factory.UniqueEmail("batchok"), "password": "correct-horse-42", "age": 25},
+				{"name": "Batch Superadmin", "email": factory.UniqueEmail("batchescalation"), "password": "correct-horse-42", "age": 25, "role": "superadmin"},
+			}
+			body, _ := json.Marshal(batchReq)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/users/batch", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			req.Header.Set("Content-Type", "application/json")
+			testRouter.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusForbidden, w.Code, "Admin cannot batch create superadmins")
 		})
 
 		t.Run("Can update users", func(t *testing.T) {
@@ -158,8 +189,8 @@ func TestRBACPermissions(t *testing.T) {
 			// Create a user to delete
 			createReq := map[string]interface{}{
 				"name":     "To Be Deleted",
-				"email":    "tobedeleted@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("tobedeleted"),
+				"password": "correct-horse-42",
 				"age":      25,
 				"role":     "user",
 			}
@@ -189,14 +220,14 @@ func TestRBACPermissions(t *testing.T) {
 			batchReq := []map[string]interface{}{
 				{
 					"name":     "Admin Batch 1",
-					"email":    "adminbatch1@example.com",
-					"password": "password123",
+					"email":    factory.UniqueEmail("adminbatch1"),
+					"password": "correct-horse-42",
 					"age":      25,
 				},
 				{
 					"name":     "Admin Batch 2",
-					"email":    "adminbatch2@example.com",
-					"password": "password123",
+					"email":    factory.UniqueEmail("adminbatch2"),
+					"password": "correct-horse-42",
 					"age":      30,
 				},
 			}
@@ -231,8 +262,8 @@ func TestRBACPermissions(t *testing.T) {
 		t.Run("Can create users", func(t *testing.T) {
 			createReq := map[string]interface{}{
 				"name":     "Superadmin Created",
-				"email":    "superadmincreated@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("superadmincreated"),
+				"password": "correct-horse-42",
 				"age":      35,
 			}
 			body, _ := json.Marshal(createReq)
@@ -246,6 +277,26 @@ func TestRBACPermissions(t *testing.T) {
 			assert.Equal(t, http.StatusCreated, w.Code, "Superadmin can create users")
 		})
 
+		t.Run("Can create admins", func(t *testing.T) {
+			createReq := map[string]interface{}{
+				"name":     "Superadmin Created Admin",
+				"email":    factory.UniqueEmail("createdadmin"),
+				"password": "correct-horse-42",
+				"age":      35,
+				"role":     "admin",
+			}
+			body, _ := json.Marshal(createReq)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+superadminToken)
+			req.Header.Set("Content-Type", "application/json")
+			testRouter.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code, "Superadmin can create admins")
+			assert.Contains(t, w.Body.String(), `"role":"admin"`)
+		})
+
 		t.Run("Can update users", func(t *testing.T) {
 			updateReq := map[string]interface{}{
 				"name": "Updated By Superadmin",
@@ -265,8 +316,8 @@ func TestRBACPermissions(t *testing.T) {
 			// Create a user to delete
 			createReq := map[string]interface{}{
 				"name":     "To Be Deleted Super",
-				"email":    "tobedeletedsuper@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("tobedeletedsuper"),
+				"password": "correct-horse-42",
 				"age":      25,
 			}
 			body, _ := json.Marshal(createReq)
@@ -295,8 +346,8 @@ func TestRBACPermissions(t *testing.T) {
 			// Create a user to promote
 			createReq := map[string]interface{}{
 				"name":     "To Be Promoted",
-				"email":    "tobepromoted@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("tobepromoted"),
+				"password": "correct-horse-42",
 				"age":      28,
 			}
 			body, _ := json.Marshal(createReq)
@@ -352,29 +403,18 @@ func TestRBACPermissions(t *testing.T) {
 
 // TestRBACRoleHierarchy tests the permission hierarchy
 func TestRBACRoleHierarchy(t *testing.T) {
-	cleanDatabase()
-
-	regularUser, err := seedTestUser("user")
-	require.NoError(t, err)
-	userToken, err := getAuthToken(regularUser)
-	require.NoError(t, err)
+	t.Parallel()
 
-	adminUser, err := seedTestUser("admin")
-	require.NoError(t, err)
-	adminToken, err := getAuthToken(adminUser)
-	require.NoError(t, err)
-
-	superadminUser, err := seedTestUser("superadmin")
-	require.NoError(t, err)
-	superadminToken, err := getAuthToken(superadminUser)
-	require.NoError(t, err)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
 
 	t.Run("Role Change Permission Matrix", func(t *testing.T) {
 		// Create target user
 		createReq := map[string]interface{}{
 			"name":     "Target User",
-			"email":    "target@example.com",
-			"password": "password123",
+			"email":    factory.UniqueEmail("target"),
+			"password": "correct-horse-42",
 			"age":      25,
 		}
 		body, _ := json.Marshal(createReq)
@@ -467,17 +507,10 @@ func TestRBACRoleHierarchy(t *testing.T) {
 
 // TestCrossRoleInteractions tests interactions between different roles
 func TestCrossRoleInteractions(t *testing.T) {
-	cleanDatabase()
-
-	adminUser, err := seedTestUser("admin")
-	require.NoError(t, err)
-	adminToken, err := getAuthToken(adminUser)
-	require.NoError(t, err)
+	t.Parallel()
 
-	superadminUser, err := seedTestUser("superadmin")
-	require.NoError(t, err)
-	superadminToken, err := getAuthToken(superadminUser)
-	require.NoError(t, err)
+	adminUser, adminToken := newUserWithToken(t, models.RoleAdmin)
+	superadminUser, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
 
 	t.Run("Admin cannot update superadmin", func(t *testing.T) {
 		updateReq := map[string]interface{}{
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshToken_Rotation(t *testing.T) {
+	t.Parallel()
+
+	user, _ := newUserWithToken(t, models.RoleUser)
+	first := newRefreshToken(t, user)
+
+	w := postRefreshToken("/api/v1/auth/refresh", first)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	second := refreshTokenFrom(t, w)
+	assert.NotEqual(t, first, second, "refreshing returns a new refresh token")
+
+	w = postRefreshToken("/api/v1/auth/refresh", second)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	third := refreshTokenFrom(t, w)
+
+	// v2 returns the rotated token in the bare resource
+	w = postRefreshToken("/api/v2/auth/refresh", third)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var v2 models.RefreshTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v2))
+	assert.NotEmpty(t, v2.RefreshToken)
+	assert.NotEmpty(t, v2.AccessToken)
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	t.Parallel()
+
+	user, _ := newUserWithToken(t, models.RoleUser)
+	stolen := newRefreshToken(t, user)
+	otherSession := newRefreshToken(t, user)
+
+	w := postRefreshToken("/api/v1/auth/refresh", stolen)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	current := refreshTokenFrom(t, w)
+
+	// Replaying the rotated token fails and revokes the family...
+	w = postRefreshToken("/api/v1/auth/refresh", stolen)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = postRefreshToken("/api/v1/auth/refresh", current)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the legitimate successor is revoked too")
+
+	// ...but not the user's other sessions
+	w = postRefreshToken("/api/v1/auth/refresh", otherSession)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Eventually(t, func() bool {
+		var entry models.AuditLog
+		err := testDB.Where("user_id = ? AND action = ?", user.ID, models.AuditActionRefreshTokenReuse).First(&entry).Error
+		return err == nil && entry.DetailsSchema == "refresh_token_reuse.v1"
+	}, 2*time.Second, 10*time.Millisecond, "the reuse is audited")
+}
+
+func TestRefreshToken_UnrecordedTokenRejected(t *testing.T) {
+	t.Parallel()
+
+	// Signed by us but never recorded, like tokens issued before rotation
+	user, _ := newUserWithToken(t, models.RoleUser)
+	token, err := jwtManager.GenerateRefreshToken(uint(user.ID), user.Email, user.Role)
+	require.NoError(t, err)
+
+	w := postRefreshToken("/api/v1/auth/refresh", token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+func TestRefreshToken_NotAcceptedAsAccessToken(t *testing.T) {
+	t.Parallel()
+
+	user, _ := newUserWithToken(t, models.RoleUser)
+	w := sendJSON("GET", "/api/v1/users", newRefreshToken(t, user), "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+func TestAccessToken_NotAcceptedAsRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	_, token := newUserWithToken(t, models.RoleUser)
+	for _, path := range []string{"/api/v1/auth/refresh", "/api/v2/auth/refresh", "/api/v1/auth/logout"} {
+		w := postRefreshToken(path, token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+	}
+}
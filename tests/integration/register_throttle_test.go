@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/handlers"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+	"Go-Lang-project-01/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRegisterRouter serves POST /api/v1/auth/register with its own registration
+// guard, so tests can use low limits without affecting the shared router
+func newRegisterRouter(guard *services.RegistrationGuard) *gin.Engine {
+	router := gin.New()
+	authHandler := handlers.NewAuthHandler(
+		repository.NewUserRepository(testDB),
+		repository.NewRevokedTokenRepository(testDB),
+		testRefreshTokens,
+		jwtManager,
+		services.NewAuditService(repository.NewAuditLogRepository(testDB)),
+		testEvents,
+		guard,
+	)
+	router.POST("/api/v1/auth/register", authHandler.Register)
+	return router
+}
+
+// register posts a registration for email from ip
+func register(router *gin.Engine, ip, email, challengeToken string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"name":"Signup","email":%q,"password":"correct-horse-42","age":30,"challenge_token":%q}`, email, challengeToken)
+	req := httptest.NewRequest("POST", "/api/v1/auth/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":40000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// throttledAudits returns the register.throttled entries about domain or, for
+// IP throttles, from ip
+func throttledAudits(scope models.RegisterThrottleScope, ip, domain string) int64 {
+	query := testDB.Model(&models.AuditLog{}).
+		Where("action = ? AND details LIKE ?", models.AuditActionRegisterThrottled, fmt.Sprintf(`%%"scope":"%s"%%`, scope))
+	if domain != "" {
+		query = query.Where("details LIKE ?", fmt.Sprintf(`%%"domain":"%s"%%`, domain))
+	} else {
+		query = query.Where("ip_address = ?", ip)
+	}
+	var count int64
+	query.Count(&count)
+	return count
+}
+
+func TestRegister_ThrottledPerIP(t *testing.T) {
+	t.Parallel()
+
+	router := newRegisterRouter(services.NewRegistrationGuard(services.RegistrationGuardConfig{IPPerHour: 1, IPBurst: 2}))
+	const ip = "203.0.113.10"
+
+	require.Equal(t, http.StatusCreated, register(router, ip, "ip-one@throttle-ip.test", "").Code)
+	// Invalid bodies count against the IP limit too
+	require.Equal(t, http.StatusBadRequest, register(router, ip, "not-an-email", "").Code)
+
+	w := register(router, ip, "ip-two@throttle-ip.test", "")
+	require.Equal(t, http.StatusTooManyRequests, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"error":"registration_throttled"`)
+	assert.Contains(t, w.Body.String(), `"scope":"ip"`)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	_, err := getUserByEmail("ip-two@throttle-ip.test")
+	assert.Error(t, err, "throttled registrations create no user")
+
+	// Other IPs have their own allowance
+	assert.Equal(t, http.StatusCreated, register(router, "203.0.113.11", "ip-three@throttle-ip.test", "").Code)
+
+	// Retries within a minute add no audit entries
+	require.Equal(t, http.StatusTooManyRequests, register(router, ip, "ip-four@throttle-ip.test", "").Code)
+	assert.Eventually(t, func() bool {
+		return throttledAudits(models.RegisterThrottleIP, ip, "") == 1
+	}, 2*time.Second, 10*time.Millisecond, "the throttle is audited once")
+}
+
+func TestRegister_ThrottledPerEmailDomain(t *testing.T) {
+	t.Parallel()
+
+	router := newRegisterRouter(services.NewRegistrationGuard(services.RegistrationGuardConfig{DomainPerHour: 1, DomainBurst: 1}))
+	const domain = "throttle-domain.test"
+
+	require.Equal(t, http.StatusCreated, register(router, "203.0.113.20", "first@"+domain, "").Code)
+
+	// A different IP does not help, and domains are compared case-insensitively
+	w := register(router, "203.0.113.21", "second@THROTTLE-domain.test", "")
+	require.Equal(t, http.StatusTooManyRequests, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"scope":"email_domain"`)
+
+	assert.Equal(t, http.StatusCreated, register(router, "203.0.113.21", "second@other-"+domain, "").Code)
+	assert.Eventually(t, func() bool {
+		return throttledAudits(models.RegisterThrottleDomain, "", domain) == 1
+	}, 2*time.Second, 10*time.Millisecond, "the throttle is audited with the domain")
+}
+
+func TestRegister_ChallengeVerifiedBeforeCreation(t *testing.T) {
+	t.Parallel()
+
+	pow := services.ProofOfWork{Difficulty: 8}
+	router := newRegisterRouter(services.NewRegistrationGuard(services.RegistrationGuardConfig{Challenge: pow}))
+	const email = "pow@challenge.test"
+
+	w := register(router, "203.0.113.30", email, "")
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	_, err := getUserByEmail(email)
+	assert.Error(t, err, "no user is created without a solved challenge")
+
+	nonce := 0
+	for pow.Verify(t.Context(), &models.RegisterRequest{Email: email, ChallengeToken: fmt.Sprint(nonce)}, "") != nil {
+		nonce++
+	}
+	assert.Equal(t, http.StatusCreated, register(router, "203.0.113.30", email, fmt.Sprint(nonce)).Code)
+}
@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/health"
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertBodyIs checks that the body of w decodes into typed without unknown
+// fields and encodes back to the same JSON, so no key is missing either
+func assertBodyIs(t *testing.T, w *httptest.ResponseRecorder, status int, typed interface{}) {
+	t.Helper()
+	require.Equal(t, status, w.Code, w.Body.String())
+
+	decoder := json.NewDecoder(bytes.NewReader(w.Body.Bytes()))
+	decoder.DisallowUnknownFields()
+	require.NoError(t, decoder.Decode(typed), w.Body.String())
+	encoded, err := json.Marshal(typed)
+	require.NoError(t, err)
+	assert.JSONEq(t, w.Body.String(), string(encoded))
+}
+
+// TestResponseModels checks that responses have exactly the shape of the
+// models referenced by the Swagger annotations
+func TestResponseModels(t *testing.T) {
+	t.Parallel()
+
+	superadmin, superToken := newUserWithToken(t, models.RoleSuperAdmin)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	target, _ := newUserWithToken(t, models.RoleUser)
+	userPath := fmt.Sprintf("/api/v1/users/%d", target.ID)
+
+	t.Run("auth", func(t *testing.T) {
+		email := factory.UniqueEmail("typed")
+		credentials := fmt.Sprintf(`{"name":"Typed","email":%q,"password":%q}`, email, factory.DefaultPassword)
+		assertBodyIs(t, sendJSON("POST", "/api/v1/auth/register", "", credentials), http.StatusCreated, &models.LoginResponseEnvelope{})
+
+		var login models.LoginResponseEnvelope
+		assertBodyIs(t, sendJSON("POST", "/api/v1/auth/login", "", credentials), http.StatusOK, &login)
+		assertBodyIs(t, sendJSON("GET", "/api/v1/auth/profile", login.Data.AccessToken, ""), http.StatusOK, &models.UserResponse{})
+
+		var refreshed models.RefreshTokenResponseEnvelope
+		body := fmt.Sprintf(`{"refresh_token":%q}`, login.Data.RefreshToken)
+		assertBodyIs(t, sendJSON("POST", "/api/v1/auth/refresh", "", body), http.StatusOK, &refreshed)
+		body = fmt.Sprintf(`{"refresh_token":%q}`, refreshed.Data.RefreshToken)
+		assertBodyIs(t, sendJSON("POST", "/api/v1/auth/logout", "", body), http.StatusOK, &models.MessageResponse{})
+
+		assertBodyIs(t, sendJSON("POST", "/api/v1/auth/login", "", `{"email":"nobody@factory.test","password":"wrong"}`), http.StatusUnauthorized, &models.ErrorResponse{})
+		assertBodyIs(t, sendJSON("POST", "/api/v1/auth/login", "", `{}`), http.StatusBadRequest, &models.ErrorResponse{})
+	})
+
+	t.Run("users", func(t *testing.T) {
+		assertBodyIs(t, sendJSON("GET", "/api/v1/users?limit=2", adminToken, ""), http.StatusOK, &models.UserListResponse{})
+		assertBodyIs(t, sendJSON("GET", userPath, adminToken, ""), http.StatusOK, &models.UserResponse{})
+		assertBodyIs(t, sendJSON("GET", "/api/v1/users/by-email?email="+target.Email, adminToken, ""), http.StatusOK, &models.UserResponse{})
+		assertBodyIs(t, sendJSON("GET", "/api/v1/users/stats", adminToken, ""), http.StatusOK, &models.StatsResponse{})
+		assertBodyIs(t, sendJSON("PUT", userPath, adminToken, `{"name":"Typed Target"}`), http.StatusOK, &models.UserResponse{})
+		assertBodyIs(t, sendJSON("PUT", userPath+"/deactivate", adminToken, ""), http.StatusOK, &models.UserResponse{})
+		assertBodyIs(t, sendJSON("PUT", userPath+"/activate", adminToken, ""), http.StatusOK, &models.UserResponse{})
+		assertBodyIs(t, sendJSON("PUT", userPath+"/role", superToken, `{"role":"admin"}`), http.StatusOK, &models.UserChangeResponse{})
+		assertBodyIs(t, sendJSON("POST", userPath+"/reset-password", superToken, ""), http.StatusOK, &models.AdminResetPasswordResponseEnvelope{})
+		assertBodyIs(t, sendJSON("GET", "/api/v1/users/999999999", adminToken, ""), http.StatusNotFound, &models.ErrorResponse{})
+
+		_, ownToken := newUserWithToken(t, models.RoleUser)
+		assertBodyIs(t, sendJSON("PUT", "/api/v1/users/me", ownToken, `{"bio":"typed"}`), http.StatusOK, &models.UserChangeResponse{})
+		body := fmt.Sprintf(`{"current_password":%q,"new_password":"Typed-Harbor-7"}`, factory.DefaultPassword)
+		assertBodyIs(t, sendJSON("PUT", "/api/v1/users/me/password", ownToken, body), http.StatusOK, &models.MessageDataResponse{})
+
+		created := &models.UserResponse{}
+		body = fmt.Sprintf(`{"name":"Typed Created","email":%q,"password":%q,"age":30}`, factory.UniqueEmail("typed"), factory.DefaultPassword)
+		assertBodyIs(t, sendJSON("POST", "/api/v1/users", adminToken, body), http.StatusCreated, created)
+		body = fmt.Sprintf(`{"users":[{"name":"Typed Batch","email":%q,"password":%q,"age":30}]}`, factory.UniqueEmail("typed"), factory.DefaultPassword)
+		assertBodyIs(t, sendJSON("POST", "/api/v1/users/batch", adminToken, body), http.StatusCreated, &models.BatchCreateUsersResponse{})
+		body = fmt.Sprintf(`{"ids":[%d]}`, created.Data.ID)
+		assertBodyIs(t, sendJSON("DELETE", "/api/v1/users", adminToken, body), http.StatusOK, &models.BulkUserResultResponse{})
+	})
+
+	t.Run("audit logs", func(t *testing.T) {
+		var list models.AuditLogListResponse
+		assertBodyIs(t, sendJSON("GET", "/api/v1/audit-logs?page_size=2", adminToken, ""), http.StatusOK, &list)
+		require.NotEmpty(t, list.Data)
+		assertBodyIs(t, sendJSON("GET", fmt.Sprintf("/api/v1/audit-logs/%d", list.Data[0].ID), adminToken, ""), http.StatusOK, &models.AuditLogResponse{})
+		assertBodyIs(t, sendJSON("GET", "/api/v1/audit-logs/999999999", adminToken, ""), http.StatusNotFound, &models.ErrorResponse{})
+		assertBodyIs(t, sendJSON("GET", "/api/v1/audit-logs/me", adminToken, ""), http.StatusOK, &models.MyAuditLogsResponse{})
+		assertBodyIs(t, sendJSON("GET", "/api/v1/audit-logs/stats", adminToken, ""), http.StatusOK, &models.StatsResponse{})
+		assertBodyIs(t, sendJSON("DELETE", "/api/v1/audit-logs/cleanup?days=36500", adminToken, ""), http.StatusOK, &models.AuditCleanupResponse{})
+		assertBodyIs(t, sendJSON("DELETE", "/api/v1/audit-logs/cleanup", adminToken, ""), http.StatusBadRequest, &models.ErrorResponse{})
+		assertBodyIs(t, sendJSON("GET", fmt.Sprintf("/api/v1/users/%d/auth-summary", superadmin.ID), adminToken, ""), http.StatusOK, &models.UserAuthSummaryResponse{})
+	})
+
+	t.Run("readiness", func(t *testing.T) {
+		assertBodyIs(t, sendJSON("GET", "/ready", "", ""), http.StatusOK, &health.ReadinessResponse{})
+	})
+}
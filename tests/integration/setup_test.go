@@ -7,12 +7,19 @@ import (
 	"testing"
 	"time"
 
+	"Go-Lang-project-01/configs"
 	"Go-Lang-project-01/internal/auth"
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/flags"
 	"Go-Lang-project-01/internal/handlers"
+	"Go-Lang-project-01/internal/health"
 	"Go-Lang-project-01/internal/middleware"
 	"Go-Lang-project-01/internal/models"
 	"Go-Lang-project-01/internal/repository"
 	"Go-Lang-project-01/internal/services"
+	"Go-Lang-project-01/internal/websocket"
+	"Go-Lang-project-01/pkg/utils"
+	"Go-Lang-project-01/tests/factory"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
@@ -20,13 +27,38 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+const testHealthToken = "test-health-token"
+
+// testMaxBodyBytes is the global request body limit, below the import route's
+const testMaxBodyBytes = 32 << 10
+
+// testMaxResponseBytes is the global response ceiling; streaming and export
+// routes opt out of it
+const testMaxResponseBytes = 512 << 10
+
 var (
-	testDB     *gorm.DB
-	testRouter *gin.Engine
-	jwtManager *auth.JWTManager
-	cleanup    func()
+	testDB            *gorm.DB
+	testRouter        *gin.Engine
+	jwtManager        *auth.JWTManager
+	testRefreshTokens *services.RefreshTokenService
+	testFactory       *factory.Factory
+	testEvents        *events.Recorder
+	testHub           *websocket.Hub
+	testUsage         *services.UsageService
+	testChaos         *middleware.Chaos
+	cleanup           func()
 )
 
+// testModels lists every migrated model; cleanDatabase truncates all their tables
+var testModels = []interface{}{
+	&models.User{},
+	&models.AuditLog{},
+	&models.RevokedToken{},
+	&models.RefreshToken{},
+	&models.APIUsage{},
+	&models.FeatureFlag{},
+}
+
 // TestMain sets up the test environment
 func TestMain(m *testing.M) {
 	// Setup
@@ -63,19 +95,26 @@ func setupTestEnvironment() {
 	if err != nil {
 		log.Fatalf("Failed to get database instance: %v", err)
 	}
-	sqlDB.SetMaxOpenConns(1) // SQLite only supports 1 connection properly
+	// A single connection also serializes access from parallel tests, and keeps
+	// the in-memory database alive for the whole run
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
 
 	// Run migrations
-	err = testDB.AutoMigrate(&models.User{}, &models.AuditLog{})
+	err = testDB.AutoMigrate(testModels...)
 	if err != nil {
 		log.Fatalf("Failed to migrate test database: %v", err)
 	}
 
 	// Initialize JWT manager with test config
-	jwtManager = auth.NewJWTManager("test-secret-key-for-integration-tests-only", 1*time.Hour, 24*time.Hour)
+	jwtManager = auth.NewJWTManagerWithOptions("test-secret-key-for-integration-tests-only", 1*time.Hour, 24*time.Hour, auth.JWTOptions{
+		Issuer:   "integration-tests",
+		Audience: "integration-tests-api",
+	})
 
-	// Setup router
+	// Setup router and fixture factory
 	testRouter = setupRouter()
+	testFactory = factory.New(testDB)
 
 	// Cleanup function
 	cleanup = func() {
@@ -89,10 +128,20 @@ func setupTestEnvironment() {
 // setupRouter creates the router with all routes and middleware
 func setupRouter() *gin.Engine {
 	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(middleware.NoRoute())
+	router.NoMethod(middleware.NoMethod())
 
 	// Add middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORS())
+	router.Use(middleware.ResponseSizeLimit(middleware.ResponseLimitConfig{MaxBytes: testMaxResponseBytes}))
+	router.Use(middleware.BodySizeLimit(testMaxBodyBytes))
+	router.Use(middleware.IDFormat())
+
+	// Fault injection; tests scope their faults to paths no other test uses
+	testChaos = middleware.NewChaos(nil)
+	router.Use(testChaos.Inject())
 
 	// Add rate limiting (very high limits for tests)
 	rateLimiter := middleware.NewRateLimiter(10000, 1000) // 10000 requests per second, burst of 1000
@@ -101,56 +150,158 @@ func setupRouter() *gin.Engine {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(testDB)
 	auditRepo := repository.NewAuditLogRepository(testDB)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(testDB)
+	testRefreshTokens = services.NewRefreshTokenService(jwtManager, repository.NewRefreshTokenRepository(testDB))
 
 	// Initialize services
 	auditService := services.NewAuditService(auditRepo)
 	userService := services.NewUserService(userRepo)
+	testUsage = services.NewUsageService(repository.NewAPIUsageRepository(testDB), services.UsageConfig{})
 
-	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
-	authHandler := handlers.NewAuthHandler(userRepo, jwtManager, auditService)
+	// Record published events so tests can assert on them
+	testEvents = &events.Recorder{}
 
-	// Setup routes
-	api := router.Group("/api/v1")
-	{
+	// Live sessions, closed on offboarding, deactivation and demotion
+	testHub = websocket.NewHub()
+	go testHub.Run()
+
+	// Initialize handlers
+	userHandler := handlers.NewUserHandler(userService, testEvents, auditService, testHub, handlers.UserImportConfig{MaxBytes: 4 << 10, MaxRows: 5})
+	authHandler := handlers.NewAuthHandler(userRepo, revokedTokenRepo, testRefreshTokens, jwtManager, auditService, testEvents, nil)
+	auditHandler := handlers.NewAuditHandler(auditService, nil)
+	usageHandler := handlers.NewUsageHandler(testUsage)
+	activityHandler := handlers.NewActivityHandler(services.NewUserActivityService(auditService, userRepo))
+	flagHandler := handlers.NewFlagHandler(flags.NewService(repository.NewFeatureFlagRepository(testDB), flags.Config{}), auditService)
+
+	offboardHandler := handlers.NewOffboardHandler(services.NewOffboardService(userRepo, testHub), auditService)
+	wsHandler := handlers.NewWebSocketHandler(testHub, jwtManager, []string{"https://app.example.com"})
+
+	// Setup routes; v2 shares the handlers with the problem+json writer
+	v1 := router.Group("/api/v1")
+	v2 := router.Group("/api/v2", utils.UseResponseWriter(utils.ProblemWriter{}))
+	for _, api := range []*gin.RouterGroup{v1, v2} {
 		// Public routes
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/logout", authHandler.Logout)
+		}
+
+		// Protected auth routes
+		authProtected := api.Group("/auth")
+		authProtected.Use(middleware.JWTAuth(jwtManager, userRepo))
+		{
+			authProtected.GET("/profile", authHandler.GetProfile)
 		}
 
 		// Protected routes
 		users := api.Group("/users")
-		users.Use(middleware.AuthMiddleware(jwtManager))
+		users.Use(middleware.Timeout(5*time.Second), middleware.JWTAuth(jwtManager, userRepo), middleware.TrackUsage(testUsage), middleware.AuditTrail(auditService))
 		{
+			users.GET("/me", userHandler.GetMe)
+			users.PUT("/me", userHandler.UpdateMe)
+			users.PUT("/me/password", userHandler.ChangePassword)
+			users.GET("/me/usage", usageHandler.GetMyUsage)
+
 			// All authenticated users can view
-			users.GET("", userHandler.GetAllUsers)
+			users.GET("", middleware.Timeout(10*time.Second), userHandler.GetAllUsers)
 			users.GET("/stats", userHandler.GetUserStats)
 			users.GET("/:id", userHandler.GetUserByID)
 
 			// Admin and above can create/update/delete
+			users.GET("/by-email", middleware.RequireAdmin(), userHandler.GetUserByEmail)
 			users.POST("", middleware.RequireAdmin(), userHandler.CreateUser)
-			users.POST("/batch", middleware.RequireAdmin(), userHandler.BatchCreateUsers)
+			users.POST("/batch", middleware.Timeout(30*time.Second), middleware.RequireAdmin(), userHandler.BatchCreateUsers)
+			users.POST("/import", middleware.Timeout(30*time.Second), middleware.BodySizeLimit(userHandler.ImportBodyLimit()), middleware.RequireAdmin(), userHandler.ImportUsers)
 			users.PUT("/:id", middleware.RequireAdmin(), userHandler.UpdateUser)
 			users.DELETE("/:id", middleware.RequireAdmin(), userHandler.DeleteUser)
+			users.GET("/deleted", middleware.RequireAdmin(), userHandler.ListDeletedUsers)
+			users.POST("/:id/restore", middleware.RequireAdmin(), userHandler.RestoreUser)
+			users.DELETE("", middleware.RequireAdmin(), userHandler.BulkDeleteUsers)
+			users.PUT("/:id/deactivate", middleware.RequireAdmin(), userHandler.DeactivateUser)
+			users.PUT("/:id/activate", middleware.RequireAdmin(), userHandler.ActivateUser)
+			users.POST("/:id/reset-password", middleware.RequireAdmin(), userHandler.ResetPassword)
+			users.GET("/:id/auth-summary", middleware.RequireAdmin(), auditHandler.GetUserAuthSummary)
+			users.GET("/:id/usage", middleware.RequireAdmin(), usageHandler.GetUserUsage)
+			users.GET("/:id/activity", middleware.RequireAdmin(), activityHandler.GetUserActivity)
 
 			// Only superadmin can change roles
 			users.PUT("/:id/role", middleware.RequireSuperAdmin(), userHandler.UpdateUserRole)
+			users.PUT("/roles", middleware.RequireSuperAdmin(), userHandler.BulkUpdateRoles)
+			users.DELETE("/:id/purge", middleware.RequireSuperAdmin(), userHandler.PurgeUser)
+			users.POST("/:id/offboard", middleware.RequireSuperAdmin(), offboardHandler.OffboardUser)
 		}
 	}
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+	auditLogs := v1.Group("/audit-logs")
+	auditLogs.Use(middleware.JWTAuth(jwtManager, userRepo))
+	{
+		auditLogs.GET("/me", auditHandler.GetMyAuditLogs)
+		auditLogs.GET("", middleware.RequireAdmin(), auditHandler.GetAuditLogs)
+		auditLogs.GET("/stats", middleware.RequireAdmin(), auditHandler.GetAuditStats)
+		auditLogs.GET("/export", middleware.AllowLargeResponse(), middleware.RequireAdmin(), auditHandler.ExportAuditLogs)
+		auditLogs.GET("/:id", middleware.RequireAdmin(), auditHandler.GetAuditLog)
+		auditLogs.DELETE("/cleanup", middleware.RequireAdmin(), auditHandler.CleanupOldLogs)
+	}
+
+	// The effective configuration is read from configs/config.yaml, as in main
+	if _, err := configs.LoadConfig(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	configHandler := handlers.NewConfigHandler(configs.Effective())
+
+	// Admin routes are only available under v1
+	admin := v1.Group("/admin")
+	admin.Use(middleware.JWTAuth(jwtManager, userRepo), middleware.RequireAdmin())
+	{
+		admin.GET("/config", middleware.RequireSuperAdmin(), configHandler.GetConfig)
+		admin.GET("/flags", flagHandler.ListFlags)
+		admin.POST("/flags", flagHandler.CreateFlag)
+		admin.GET("/flags/:name", flagHandler.GetFlag)
+		admin.PUT("/flags/:name", flagHandler.UpdateFlag)
+		admin.DELETE("/flags/:name", flagHandler.DeleteFlag)
+	}
+
+	chaosHandler := handlers.NewChaosHandler(testChaos, auditService)
+	chaosRoutes := v1.Group("/_chaos")
+	chaosRoutes.Use(middleware.JWTAuth(jwtManager, userRepo), middleware.RequireSuperAdmin())
+	{
+		chaosRoutes.GET("/faults", chaosHandler.ListFaults)
+		chaosRoutes.POST("/faults", chaosHandler.InjectFault)
+		chaosRoutes.DELETE("/faults", chaosHandler.ClearFaults)
+		chaosRoutes.DELETE("/faults/:id", chaosHandler.RemoveFault)
+		chaosRoutes.POST("/db-exhaust", chaosHandler.ExhaustPool)
+	}
+
+	// WebSocket management endpoints
+	router.GET("/ws", wsHandler.HandleWebSocket)
+	wsRoutes := router.Group("/ws")
+	wsRoutes.Use(middleware.JWTAuth(jwtManager, userRepo))
+	{
+		wsRoutes.GET("/stats", wsHandler.GetStats)
+		wsRoutes.POST("/disconnect/:userId", middleware.RequireAdmin(), wsHandler.DisconnectUser)
+	}
+
+	// Health check (database only, so results don't depend on the host)
+	healthService := health.NewHealthService()
+	healthService.RegisterReadinessChecker("database", &health.DatabaseChecker{
+		DB:      testDB,
+		Timeout: 5 * time.Second,
 	})
+	healthHandler := handlers.NewHealthHandler(healthService, testHealthToken)
+	optionalAuth := middleware.OptionalAuthMiddleware(jwtManager, userRepo)
+	router.GET("/health", optionalAuth, healthHandler.HealthCheck)
+	router.HEAD("/health", optionalAuth, healthHandler.HealthCheck)
+	router.GET("/ready", healthHandler.ReadinessCheck)
 
 	return router
 }
 
 // seedTestUser creates a test user and returns the user object
-func seedTestUser(role string) (*models.User, error) {
-	hashedPassword, err := auth.HashPassword("password123")
+func seedTestUser(role models.Role) (*models.User, error) {
+	hashedPassword, err := auth.HashPassword("correct-horse-42")
 	if err != nil {
 		return nil, err
 	}
@@ -174,13 +325,48 @@ func seedTestUser(role string) (*models.User, error) {
 
 // getAuthToken generates a JWT token for a test user
 func getAuthToken(user *models.User) (string, error) {
-	return jwtManager.GenerateAccessToken(user.ID, user.Email, user.Role)
+	return jwtManager.GenerateAccessToken(uint(user.ID), user.Email, user.Role)
+}
+
+// newUserWithToken creates a unique user with the given role and returns it with an access token.
+// Prefer this over seedTestUser in tests that call t.Parallel().
+func newUserWithToken(t *testing.T, role models.Role, opts ...factory.UserOption) (*models.User, string) {
+	t.Helper()
+
+	user, err := testFactory.User(append([]factory.UserOption{factory.WithRole(role)}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create %s user: %v", role, err)
+	}
+	token, err := getAuthToken(user)
+	if err != nil {
+		t.Fatalf("failed to create token for %s user: %v", role, err)
+	}
+	return user, token
 }
 
-// cleanDatabase truncates all tables
+// newRefreshToken issues a recorded refresh token for user, as a login does
+func newRefreshToken(t *testing.T, user *models.User) string {
+	t.Helper()
+
+	token, err := testRefreshTokens.Issue(t.Context(), user)
+	if err != nil {
+		t.Fatalf("failed to issue refresh token for user %d: %v", user.ID, err)
+	}
+	return token
+}
+
+// cleanDatabase truncates all tables.
+// It must only be called from serial tests: parallel tests share the database.
 func cleanDatabase() {
-	testDB.Exec("DELETE FROM users")
-	testDB.Exec("DELETE FROM audit_logs")
+	tables, err := testDB.Migrator().GetTables()
+	if err != nil {
+		log.Fatalf("Failed to list test tables: %v", err)
+	}
+	for _, table := range tables {
+		if err := testDB.Exec(fmt.Sprintf("DELETE FROM %q", table)).Error; err != nil {
+			log.Fatalf("Failed to clean table %s: %v", table, err)
+		}
+	}
 }
 
 // countUsers returns the number of users in the database
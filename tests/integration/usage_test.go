@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUsageReport checks that authenticated requests are counted per user and
+// reported to the user and to admins
+func TestUsageReport(t *testing.T) {
+	t.Parallel()
+
+	user, userToken := newUserWithToken(t, models.RoleUser)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	report := func(w *httptest.ResponseRecorder) models.UsageReport {
+		t.Helper()
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data models.UsageReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data
+	}
+
+	require.Equal(t, http.StatusOK, get("/api/v1/users/me", userToken).Code)
+	require.Equal(t, http.StatusNotFound, get("/api/v1/users/999999999", userToken).Code)
+
+	t.Run("own usage", func(t *testing.T) {
+		got := report(get("/api/v1/users/me/usage?days=3", userToken))
+		assert.Equal(t, uint(user.ID), got.UserID)
+		assert.Len(t, got.Buckets, 3, "one bucket per day")
+		assert.Equal(t, models.UsageCounts{Requests: 2, Errors: 1}, got.Totals)
+	})
+
+	t.Run("admin reads any user", func(t *testing.T) {
+		// The previous report request is counted too
+		got := report(get(fmt.Sprintf("/api/v1/users/%d/usage", user.ID), adminToken))
+		assert.Equal(t, 7, got.Days)
+		assert.Equal(t, models.UsageCounts{Requests: 3, Errors: 1}, got.Totals)
+	})
+
+	t.Run("users cannot read others", func(t *testing.T) {
+		w := get(fmt.Sprintf("/api/v1/users/%d/usage", user.ID), userToken)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		for _, days := range []string{"0", "abc", "10000"} {
+			w := get("/api/v1/users/me/usage?days="+days, userToken)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "days=%s", days)
+			assert.Contains(t, w.Body.String(), `"field":"days"`)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/events"
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeactivateAndActivateUser checks that deactivated users lose access
+// until they are reactivated, and who may change the status
+func TestDeactivateAndActivateUser(t *testing.T) {
+	t.Parallel()
+
+	admin, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	superadmin, _ := newUserWithToken(t, models.RoleSuperAdmin)
+	target, targetToken := newUserWithToken(t, models.RoleUser)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w
+	}
+	statusPath := func(id models.ID, action string) string {
+		return fmt.Sprintf("/api/v1/users/%d/%s", id, action)
+	}
+	login := func() int {
+		body := fmt.Sprintf(`{"email":%q,"password":"correct-horse-42"}`, target.Email)
+		return send("POST", "/api/v1/auth/login", "", body).Code
+	}
+
+	w := send("PUT", statusPath(target.ID, "deactivate"), adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data models.User `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Data.IsActive)
+
+	assert.Equal(t, http.StatusForbidden, send("GET", "/api/v1/users/me", targetToken, "").Code, "tokens of inactive users are rejected")
+	assert.Equal(t, http.StatusUnauthorized, login(), "inactive users cannot log in")
+
+	w = send("PUT", statusPath(target.ID, "activate"), adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, send("GET", "/api/v1/users/me", targetToken, "").Code)
+	assert.Equal(t, http.StatusOK, login())
+
+	t.Run("guards", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, send("PUT", statusPath(admin.ID, "deactivate"), adminToken, "").Code, "own account")
+		assert.Equal(t, http.StatusForbidden, send("PUT", statusPath(superadmin.ID, "deactivate"), adminToken, "").Code, "higher role")
+		assert.Equal(t, http.StatusForbidden, send("PUT", statusPath(target.ID, "deactivate"), userToken, "").Code, "admin only")
+		assert.Equal(t, http.StatusNotFound, send("PUT", statusPath(999999999, "deactivate"), adminToken, "").Code)
+	})
+
+	t.Run("audited and published", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			var logs []models.AuditLog
+			testDB.Where("user_id = ? AND resource_id = ?", admin.ID, target.ID).Order("id").Find(&logs)
+			return len(logs) == 2 &&
+				logs[0].Action == models.AuditActionUserDeactivate && logs[0].Success &&
+				logs[1].Action == models.AuditActionUserActivate && logs[1].Success
+		}, 2*time.Second, 10*time.Millisecond)
+
+		var updates int
+		for _, event := range eventsFor(uint(target.ID)) {
+			if event.Type == events.UserUpdated && event.ActorID == uint(admin.ID) {
+				updates++
+			}
+		}
+		assert.Equal(t, 2, updates)
+	})
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserActivity tests GET /api/v1/users/:id/activity
+func TestUserActivity(t *testing.T) {
+	t.Parallel()
+
+	alice, adminToken := newUserWithToken(t, models.RoleAdmin)
+	bob, _ := newUserWithToken(t, models.RoleAdmin)
+	gone, _ := newUserWithToken(t, models.RoleAdmin)
+	target, userToken := newUserWithToken(t, models.RoleUser)
+	other, _ := newUserWithToken(t, models.RoleUser)
+	require.NoError(t, testDB.Delete(gone).Error)
+
+	// Seeded in the past, so the entries the requests themselves add are
+	// outside the pages read below
+	auditRepo := repository.NewAuditLogRepository(testDB)
+	base := time.Now().Add(-time.Hour)
+	seed := func(minute int, actor *models.User, action models.AuditAction, resource models.AuditResource, resourceID *models.ID) {
+		require.NoError(t, auditRepo.Create(&models.AuditLog{
+			UserID:     &actor.ID,
+			Action:     action,
+			Resource:   resource,
+			ResourceID: resourceID,
+			CreatedAt:  base.Add(time.Duration(minute) * time.Minute),
+		}))
+	}
+	seed(0, target, models.AuditActionLogin, models.AuditResourceAuth, nil)
+	seed(1, alice, models.AuditActionUserUpdate, models.AuditResourceUser, &target.ID)
+	seed(2, gone, models.AuditActionUserActivate, models.AuditResourceUser, &target.ID)
+	seed(3, bob, models.AuditActionUserDeactivate, models.AuditResourceUser, &target.ID)
+	seed(4, alice, models.AuditActionRoleChange, models.AuditResourceUser, &target.ID)
+	seed(5, alice, models.AuditActionUserUpdate, models.AuditResourceUser, &other.ID)  // Another user
+	seed(6, target, models.AuditActionProfileUpdate, models.AuditResourceProfile, nil) // Not an auth action
+
+	get := func(token, query string) (int, models.UserActivity) {
+		w := sendJSON("GET", fmt.Sprintf("/api/v1/users/%d/activity?%s", target.ID, query), token, "")
+		var resp struct {
+			Data models.UserActivity `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		}
+		return w.Code, resp.Data
+	}
+	page := func(limit int, before time.Time) models.UserActivity {
+		code, activity := get(adminToken, fmt.Sprintf("limit=%d&before=%s", limit, url.QueryEscape(before.Format(time.RFC3339Nano))))
+		require.Equal(t, http.StatusOK, code)
+		return activity
+	}
+	actions := func(activity models.UserActivity) []models.AuditAction {
+		var got []models.AuditAction
+		for _, entry := range activity.Entries {
+			got = append(got, entry.Action)
+		}
+		return got
+	}
+
+	t.Run("Entries carry their actor", func(t *testing.T) {
+		activity := page(10, base.Add(10*time.Minute))
+		assert.Equal(t, uint(target.ID), activity.UserID)
+		require.Equal(t, []models.AuditAction{
+			models.AuditActionRoleChange,
+			models.AuditActionUserDeactivate,
+			models.AuditActionUserActivate,
+			models.AuditActionUserUpdate,
+			models.AuditActionLogin,
+		}, actions(activity))
+		assert.Nil(t, activity.NextBefore, "last page")
+
+		assert.Equal(t, &models.AuditActor{ID: alice.ID, Name: alice.Name, Email: alice.Email}, activity.Entries[0].Actor)
+		assert.Equal(t, &models.AuditActor{ID: bob.ID, Name: bob.Name, Email: bob.Email}, activity.Entries[1].Actor)
+		assert.Nil(t, activity.Entries[2].Actor, "deleted actor")
+		assert.Equal(t, activity.Entries[0].Actor, activity.Entries[3].Actor)
+		assert.Equal(t, target.Email, activity.Entries[4].Actor.Email)
+	})
+
+	t.Run("Before cursor pages through the feed", func(t *testing.T) {
+		first := page(2, base.Add(10*time.Minute))
+		assert.Equal(t, []models.AuditAction{models.AuditActionRoleChange, models.AuditActionUserDeactivate}, actions(first))
+		require.NotNil(t, first.NextBefore)
+
+		second := page(2, *first.NextBefore)
+		assert.Equal(t, []models.AuditAction{models.AuditActionUserActivate, models.AuditActionUserUpdate}, actions(second))
+		require.NotNil(t, second.NextBefore)
+
+		third := page(2, *second.NextBefore)
+		assert.Equal(t, []models.AuditAction{models.AuditActionLogin}, actions(third))
+		assert.Nil(t, third.NextBefore)
+
+		assert.Empty(t, page(2, base).Entries)
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=101", "limit=ten", "before=yesterday"} {
+			code, _ := get(adminToken, query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
+
+	t.Run("Regular user is forbidden", func(t *testing.T) {
+		code, _ := get(userToken, "")
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/tests/factory"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,13 +17,15 @@ import (
 
 // TestAuthFlow tests the complete authentication flow
 func TestAuthFlow(t *testing.T) {
-	cleanDatabase()
+	t.Parallel()
 
 	t.Run("Complete Auth Flow - Register, Login, Access Protected Route", func(t *testing.T) {
+		email := factory.UniqueEmail("john")
+
 		// Step 1: Register a new user
 		registerReq := map[string]interface{}{
 			"name":     "John Doe",
-			"email":    "john@example.com",
+			"email":    email,
 			"password": "securePassword123",
 			"age":      25,
 		}
@@ -42,7 +45,7 @@ func TestAuthFlow(t *testing.T) {
 
 		// Step 2: Login with the registered user
 		loginReq := map[string]interface{}{
-			"email":    "john@example.com",
+			"email":    email,
 			"password": "securePassword123",
 		}
 		body, _ = json.Marshal(loginReq)
@@ -79,7 +82,7 @@ func TestAuthFlow(t *testing.T) {
 
 	t.Run("Login with invalid credentials", func(t *testing.T) {
 		loginReq := map[string]interface{}{
-			"email":    "wrong@example.com",
+			"email":    factory.UniqueEmail("wrong"),
 			"password": "wrongpassword",
 		}
 		body, _ := json.Marshal(loginReq)
@@ -104,8 +107,8 @@ func TestAuthFlow(t *testing.T) {
 		// First registration
 		registerReq := map[string]interface{}{
 			"name":     "Alice",
-			"email":    "alice@example.com",
-			"password": "password123",
+			"email":    factory.UniqueEmail("alice"),
+			"password": "correct-horse-42",
 			"age":      28,
 		}
 		body, _ := json.Marshal(registerReq)
@@ -129,20 +132,18 @@ func TestAuthFlow(t *testing.T) {
 
 // TestUserCRUDFlow tests the complete user CRUD operations
 func TestUserCRUDFlow(t *testing.T) {
-	cleanDatabase()
+	t.Parallel()
 
 	t.Run("Complete CRUD Flow as Admin", func(t *testing.T) {
 		// Create admin user
-		adminUser, err := seedTestUser("admin")
-		require.NoError(t, err)
-		adminToken, err := getAuthToken(adminUser)
-		require.NoError(t, err)
+		_, adminToken := newUserWithToken(t, models.RoleAdmin)
+		bobEmail := factory.UniqueEmail("bob")
 
 		// Step 1: Create a new user (POST /users)
 		createReq := map[string]interface{}{
 			"name":     "Bob Smith",
-			"email":    "bob@example.com",
-			"password": "password123",
+			"email":    bobEmail,
+			"password": "correct-horse-42",
 			"age":      35,
 		}
 		body, _ := json.Marshal(createReq)
@@ -156,7 +157,7 @@ func TestUserCRUDFlow(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, w.Code, "Admin should create user")
 
 		var createResp map[string]interface{}
-		err = json.Unmarshal(w.Body.Bytes(), &createResp)
+		err := json.Unmarshal(w.Body.Bytes(), &createResp)
 		require.NoError(t, err)
 
 		data := createResp["data"].(map[string]interface{})
@@ -176,7 +177,7 @@ func TestUserCRUDFlow(t *testing.T) {
 		require.NoError(t, err)
 		userData := getResp["data"].(map[string]interface{})
 		assert.Equal(t, "Bob Smith", userData["name"])
-		assert.Equal(t, "bob@example.com", userData["email"])
+		assert.Equal(t, bobEmail, userData["email"])
 
 		// Step 3: Update user (PUT /users/:id)
 		updateReq := map[string]interface{}{
@@ -227,15 +228,12 @@ func TestUserCRUDFlow(t *testing.T) {
 
 	t.Run("Regular user cannot create users", func(t *testing.T) {
 		// Create regular user
-		regularUser, err := seedTestUser("user")
-		require.NoError(t, err)
-		userToken, err := getAuthToken(regularUser)
-		require.NoError(t, err)
+		_, userToken := newUserWithToken(t, models.RoleUser)
 
 		createReq := map[string]interface{}{
 			"name":     "Test User",
-			"email":    "test@example.com",
-			"password": "password123",
+			"email":    factory.UniqueEmail("test"),
+			"password": "correct-horse-42",
 			"age":      25,
 		}
 		body, _ := json.Marshal(createReq)
@@ -252,20 +250,18 @@ func TestUserCRUDFlow(t *testing.T) {
 
 // TestPaginationAndFiltering tests pagination features
 func TestPaginationAndFiltering(t *testing.T) {
-	cleanDatabase()
+	t.Parallel()
 
 	// Create admin user for auth
-	adminUser, err := seedTestUser("admin")
-	require.NoError(t, err)
-	adminToken, err := getAuthToken(adminUser)
-	require.NoError(t, err)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
 
-	// Create multiple users for pagination testing
+	// Create multiple users for pagination testing; the unique prefix keeps search results ours
+	namePrefix := fmt.Sprintf("Paged%d", factory.Next())
 	for i := 1; i <= 15; i++ {
 		createReq := map[string]interface{}{
-			"name":     fmt.Sprintf("User %d", i),
-			"email":    fmt.Sprintf("user%d@example.com", i),
-			"password": "password123",
+			"name":     fmt.Sprintf("%s User %d", namePrefix, i),
+			"email":    factory.UniqueEmail("paged"),
+			"password": "correct-horse-42",
 			"age":      20 + i,
 		}
 		body, _ := json.Marshal(createReq)
@@ -318,7 +314,7 @@ func TestPaginationAndFiltering(t *testing.T) {
 
 	t.Run("Search users by name", func(t *testing.T) {
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest("GET", "/api/v1/users?search=User%201", nil)
+		req := httptest.NewRequest("GET", "/api/v1/users?search="+namePrefix+"%20User%201", nil)
 		req.Header.Set("Authorization", "Bearer "+adminToken)
 		testRouter.ServeHTTP(w, req)
 
@@ -330,36 +326,112 @@ func TestPaginationAndFiltering(t *testing.T) {
 
 		data := resp["data"].([]interface{})
 		assert.Greater(t, len(data), 0, "Should find users matching search")
+		for _, item := range data {
+			assert.Contains(t, item.(map[string]interface{})["name"], namePrefix)
+		}
+	})
+
+	t.Run("Filter inactive users with search", func(t *testing.T) {
+		johns := fmt.Sprintf("John%d", factory.Next())
+		_, err := testFactory.Users(3, factory.WithName(johns+" Inactive"), factory.Inactive())
+		require.NoError(t, err)
+		_, err = testFactory.Users(2, factory.WithName(johns+" Active"))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/users?active=false&search="+johns+"&limit=2&page=2", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		testRouter.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data       []models.User         `json:"data"`
+			Pagination models.PaginationMeta `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 1, "Last of 3 inactive users")
+		assert.False(t, resp.Data[0].IsActive)
+		prev := 1
+		assert.Equal(t, models.PaginationMeta{Page: 2, Limit: 2, Total: 3, TotalPages: 2, HasPrev: true, PrevPage: &prev}, resp.Pagination)
+		assert.Equal(t, `</api/v1/users?active=false&limit=2&page=1&search=`+johns+`>; rel="prev"`, w.Header().Get("Link"), "the link keeps the filters")
+	})
+
+	t.Run("Filter by role sorted by name", func(t *testing.T) {
+		team := fmt.Sprintf("Team%d", factory.Next())
+		for _, name := range []string{"Charlie", "Alice", "Bob"} {
+			_, err := testFactory.User(factory.WithName(team+" "+name), factory.WithRole(models.RoleAdmin))
+			require.NoError(t, err)
+		}
+		_, err := testFactory.User(factory.WithName(team + " Aaron"))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/users?role=admin&sort=name&order=asc&search="+team, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		testRouter.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data       []models.User         `json:"data"`
+			Pagination models.PaginationMeta `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		names := make([]string, 0, len(resp.Data))
+		for _, user := range resp.Data {
+			assert.Equal(t, models.RoleAdmin, user.Role)
+			names = append(names, user.Name)
+		}
+		assert.Equal(t, []string{team + " Alice", team + " Bob", team + " Charlie"}, names)
+		assert.Equal(t, int64(3), resp.Pagination.Total)
+	})
+
+	t.Run("Reject invalid role", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/users?role=root", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"role"`)
+	})
+
+	t.Run("Reject invalid active value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/users?active=maybe", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
 // TestBatchOperations tests batch creation
 func TestBatchOperations(t *testing.T) {
-	cleanDatabase()
+	t.Parallel()
 
-	adminUser, err := seedTestUser("admin")
-	require.NoError(t, err)
-	adminToken, err := getAuthToken(adminUser)
-	require.NoError(t, err)
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	existingEmail := factory.UniqueEmail("batch")
 
 	t.Run("Batch create multiple users", func(t *testing.T) {
 		batchReq := []map[string]interface{}{
 			{
 				"name":     "Batch User 1",
-				"email":    "batch1@example.com",
-				"password": "password123",
+				"email":    existingEmail,
+				"password": "correct-horse-42",
 				"age":      25,
 			},
 			{
 				"name":     "Batch User 2",
-				"email":    "batch2@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("batch"),
+				"password": "correct-horse-42",
 				"age":      30,
 			},
 			{
 				"name":     "Batch User 3",
-				"email":    "batch3@example.com",
-				"password": "password123",
+				"email":    factory.UniqueEmail("batch"),
+				"password": "correct-horse-42",
 				"age":      35,
 			},
 		}
@@ -377,22 +449,71 @@ func TestBatchOperations(t *testing.T) {
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		require.NoError(t, err)
 
-		data := resp["data"].([]interface{})
-		assert.Len(t, data, 3, "Should create 3 users")
+		data := resp["data"].(map[string]interface{})
+		assert.Len(t, data["created"], 3, "Should create 3 users")
+		assert.Empty(t, data["failed"])
+	})
+
+	t.Run("Batch create accepts the users wrapper", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"users": []map[string]interface{}{
+				{"name": "Wrapped User", "email": factory.UniqueEmail("wrapped"), "password": "correct-horse-42", "age": 40},
+			},
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/users/batch", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("Batch create names invalid users by index", func(t *testing.T) {
+		validEmail := factory.UniqueEmail("valid")
+		body, _ := json.Marshal([]map[string]interface{}{
+			{"name": "Valid User", "email": validEmail, "password": "correct-horse-42", "age": 25},
+			{"name": "x"},
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/users/batch", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		var resp struct {
+			Errors []models.ValidationError `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		fields := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			fields = append(fields, e.Field)
+		}
+		assert.Contains(t, fields, "users[1].email")
+		assert.Contains(t, fields, "users[1].password")
+		assert.NotContains(t, fields, "users[0].email", "the valid user has no errors")
+
+		var count int64
+		testDB.Model(&models.User{}).Where("email = ?", validEmail).Count(&count)
+		assert.Zero(t, count, "nothing is created when a user is invalid")
 	})
 
 	t.Run("Batch create with duplicate email", func(t *testing.T) {
+		uniqueEmail := factory.UniqueEmail("unique")
 		batchReq := []map[string]interface{}{
 			{
 				"name":     "Unique User",
-				"email":    "unique@example.com",
-				"password": "password123",
+				"email":    uniqueEmail,
+				"password": "correct-horse-42",
 				"age":      25,
 			},
 			{
 				"name":     "Duplicate Email",
-				"email":    "batch1@example.com", // Already exists
-				"password": "password123",
+				"email":    existingEmail, // Already exists
+				"password": "correct-horse-42",
 				"age":      30,
 			},
 		}
@@ -404,32 +525,51 @@ func TestBatchOperations(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		testRouter.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code, "Should fail with duplicate email")
+		require.Equal(t, http.StatusMultiStatus, w.Code, "Only the duplicate email should fail")
+		var resp struct {
+			Data models.BatchCreateUsersResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data.Created, 1)
+		assert.Equal(t, uniqueEmail, resp.Data.Created[0].Email)
+		require.Len(t, resp.Data.Failed, 1)
+		assert.Equal(t, models.BatchCreateFailure{Index: 1, Email: existingEmail, Error: "email already exists"}, resp.Data.Failed[0])
+	})
+
+	t.Run("Batch create fails when no user is created", func(t *testing.T) {
+		body, _ := json.Marshal([]map[string]interface{}{
+			{"name": "Duplicate Email", "email": existingEmail, "password": "correct-horse-42", "age": 30},
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/users/batch", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		var resp struct {
+			Data models.BatchCreateUsersResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Data.Created)
+		require.Len(t, resp.Data.Failed, 1)
+		assert.Equal(t, 0, resp.Data.Failed[0].Index)
 	})
 }
 
 // TestUserStats tests statistics endpoint
 func TestUserStats(t *testing.T) {
-	cleanDatabase()
-
-	// Create users with different active states
-	for i := 1; i <= 5; i++ {
-		user := &models.User{
-			Name:     fmt.Sprintf("User %d", i),
-			Email:    fmt.Sprintf("user%d@stats.com", i),
-			Password: "hashed",
-			Age:      25,
-			Role:     "user",
-			IsActive: i <= 3, // First 3 active, last 2 inactive
-		}
-		testDB.Create(user)
-	}
+	t.Parallel()
 
-	regularUser, err := seedTestUser("user")
+	// Create users with different active states: 3 active, 2 inactive
+	_, err := testFactory.Users(3)
 	require.NoError(t, err)
-	userToken, err := getAuthToken(regularUser)
+	_, err = testFactory.Users(2, factory.Inactive())
 	require.NoError(t, err)
 
+	_, userToken := newUserWithToken(t, models.RoleUser)
+
 	t.Run("Get user statistics", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest("GET", "/api/v1/users/stats", nil)
@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// importUsers uploads csv as the "file" field of POST /api/v1/users/import
+func importUsers(t *testing.T, token, csv string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "users.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csv))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest("POST", "/api/v1/users/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w
+}
+
+// importResult decodes the result of a successful import
+func importResult(t *testing.T, w *httptest.ResponseRecorder) models.UserImportResult {
+	t.Helper()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data models.UserImportResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+func TestImportUsers_ValidFile(t *testing.T) {
+	t.Parallel()
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+
+	// Byte order mark and column order as exported by spreadsheets
+	csv := "\ufeffemail,name,password,role,age\n" +
+		"ada@import-valid.test,Ada Lovelace,correct-horse-42,admin,36\n" +
+		"alan@import-valid.test,Alan Turing,correct-horse-42,,\n"
+	result := importResult(t, importUsers(t, adminToken, csv))
+
+	assert.Equal(t, 2, result.Rows)
+	assert.Empty(t, result.Failed)
+	require.Len(t, result.Created, 2)
+	assert.Equal(t, 2, result.Created[0].Row)
+	assert.Equal(t, 3, result.Created[1].Row)
+
+	ada, err := getUserByEmail("ada@import-valid.test")
+	require.NoError(t, err)
+	assert.Equal(t, result.Created[0].ID, ada.ID)
+	assert.Equal(t, models.RoleAdmin, ada.Role)
+	assert.Equal(t, 36, ada.Age)
+	alan, err := getUserByEmail("alan@import-valid.test")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, alan.Role, "the role defaults to user")
+}
+
+func TestImportUsers_MixedRows(t *testing.T) {
+	t.Parallel()
+	existing, adminToken := newUserWithToken(t, models.RoleAdmin)
+
+	csv := "name,email,password,age,role\n" +
+		"Good One,good@import-mixed.test,correct-horse-42,30,user\n" +
+		"Bad Email,not-an-email,correct-horse-42,30,user\n" +
+		"Existing," + existing.Email + ",correct-horse-42,30,user\n" +
+		"Good Two,good@import-mixed.test,correct-horse-42,30,user\n" +
+		"Bad Age,age@import-mixed.test,correct-horse-42,x,user\n"
+	result := importResult(t, importUsers(t, adminToken, csv))
+
+	assert.Equal(t, 5, result.Rows)
+	require.Len(t, result.Created, 1)
+	assert.Equal(t, 2, result.Created[0].Row)
+
+	failed := make(map[int]models.UserImportFailure)
+	for _, f := range result.Failed {
+		failed[f.Row] = f
+	}
+	require.Len(t, failed, 4)
+	assert.Equal(t, "email", failed[3].Fields[0].Field)
+	assert.Equal(t, "email already exists", failed[4].Error)
+	assert.Contains(t, failed[5].Error, "duplicate email, already in row 2")
+	assert.Equal(t, "age", failed[6].Fields[0].Field)
+
+	_, err := getUserByEmail("age@import-mixed.test")
+	assert.Error(t, err, "failed rows create no user")
+
+	// Admins cannot import superadmins; the row fails on its own
+	result = importResult(t, importUsers(t, adminToken, "name,email,password,role\n"+
+		"Super,super@import-mixed.test,correct-horse-42,superadmin\n"+
+		"Fine,fine@import-mixed.test,correct-horse-42,user\n"))
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, 2, result.Failed[0].Row)
+	assert.Contains(t, result.Failed[0].Error, "cannot create superadmin")
+	assert.Len(t, result.Created, 1)
+}
+
+func TestImportUsers_RejectedFiles(t *testing.T) {
+	t.Parallel()
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+
+	tests := []struct {
+		name    string
+		token   string
+		csv     string
+		status  int
+		message string
+	}{
+		{"malformed CSV", adminToken, "name,email,password\n\"Unclosed,a@import-bad.test,correct-horse-42\n", http.StatusBadRequest, "malformed CSV"},
+		{"row with extra fields", adminToken, "name,email,password\nA,a@import-bad.test,correct-horse-42,extra\n", http.StatusBadRequest, "malformed CSV"},
+		{"unknown column", adminToken, "name,email,password,shoe_size\n", http.StatusBadRequest, "unknown column"},
+		{"missing column", adminToken, "name,email\n", http.StatusBadRequest, `missing column \"password\"`},
+		{"empty file", adminToken, "", http.StatusBadRequest, "file is empty"},
+		{"too many rows", adminToken, "name,email,password\n" + strings.Repeat("A,a@import-bad.test,correct-horse-42\n", 6), http.StatusRequestEntityTooLarge, "more than 5 rows"},
+		{"too large", adminToken, "name,email,password\n" + strings.Repeat("x", 5<<10), http.StatusRequestEntityTooLarge, "larger than"},
+		{"not an admin", userToken, "name,email,password\n", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := importUsers(t, tt.token, tt.csv)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.message)
+		})
+	}
+	_, err := getUserByEmail("a@import-bad.test")
+	assert.Error(t, err, "rejected files create no users")
+}
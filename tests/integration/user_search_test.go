@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"Go-Lang-project-01/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserSearchShortcuts checks ID and exact email searches on the user
+// list and the lookup by email
+func TestUserSearchShortcuts(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	target, _ := newUserWithToken(t, models.RoleUser)
+
+	listEmails := func(search string) []string {
+		t.Helper()
+		w := sendJSON("GET", "/api/v1/users?search="+url.QueryEscape(search), adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data []models.User `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var emails []string
+		for _, u := range resp.Data {
+			emails = append(emails, u.Email)
+		}
+		return emails
+	}
+
+	assert.Equal(t, []string{target.Email}, listEmails(target.Email), "an exact email matches only that user")
+	assert.Contains(t, listEmails(fmt.Sprint(target.ID)), target.Email, "a numeric search matches the ID")
+
+	byEmail := func(email, token string) int {
+		return sendJSON("GET", "/api/v1/users/by-email?email="+url.QueryEscape(email), token, "").Code
+	}
+	assert.Equal(t, http.StatusOK, byEmail(target.Email, adminToken))
+	assert.Equal(t, http.StatusNotFound, byEmail("nobody-"+target.Email, adminToken))
+	assert.Equal(t, http.StatusBadRequest, byEmail("not-an-email", adminToken))
+	assert.Equal(t, http.StatusForbidden, byEmail(target.Email, userToken), "the lookup is admin only")
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	ws "Go-Lang-project-01/internal/websocket"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWebSocket opens /ws on a test server; the caller closes the connection
+func dialWebSocket(t *testing.T, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	srv := httptest.NewServer(testRouter)
+	t.Cleanup(srv.Close)
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws"+query, header)
+}
+
+// readWelcome reads the connection_established message
+func readWelcome(t *testing.T, conn *websocket.Conn) ws.Message {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var msg ws.Message
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestWebSocket_AuthTransports(t *testing.T) {
+	t.Parallel()
+
+	user, token := newUserWithToken(t, models.RoleUser)
+
+	tests := []struct {
+		name     string
+		query    string
+		header   http.Header
+		protocol string
+	}{
+		{"authorization header", "", http.Header{"Authorization": {"Bearer " + token}}, ""},
+		{"bearer subprotocol", "", http.Header{"Sec-WebSocket-Protocol": {"bearer, " + token}}, ws.BearerSubprotocol},
+		{"query parameter", "?token=" + token, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := dialWebSocket(t, tt.query, tt.header)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			assert.Equal(t, tt.protocol, resp.Header.Get("Sec-WebSocket-Protocol"), "the selected protocol is echoed")
+			assert.Equal(t, tt.protocol, conn.Subprotocol())
+
+			msg := readWelcome(t, conn)
+			assert.Equal(t, ws.EventConnectionEstablished, msg.Type)
+			assert.EqualValues(t, user.ID, msg.Data["user_id"])
+		})
+	}
+}
+
+func TestWebSocket_RejectsMissingAndRefreshTokens(t *testing.T) {
+	t.Parallel()
+
+	user, _ := newUserWithToken(t, models.RoleUser)
+
+	_, resp, err := dialWebSocket(t, "", nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp, err = dialWebSocket(t, "", http.Header{"Sec-WebSocket-Protocol": {"bearer, " + newRefreshToken(t, user)}})
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestWebSocket_Origins(t *testing.T) {
+	t.Parallel()
+
+	_, token := newUserWithToken(t, models.RoleUser)
+	auth := func(origin string) http.Header {
+		return http.Header{"Authorization": {"Bearer " + token}, "Origin": {origin}}
+	}
+
+	_, resp, err := dialWebSocket(t, "", auth("https://evil.example.com"))
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := dialWebSocket(t, "", auth("https://app.example.com"))
+	require.NoError(t, err, "listed origins are allowed")
+	defer conn.Close()
+	assert.Equal(t, ws.EventConnectionEstablished, readWelcome(t, conn).Type)
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectFakeClients registers count connections for user that nothing reads from
+func connectFakeClients(t *testing.T, user *models.User, count int) []*websocket.Client {
+	t.Helper()
+	clients := make([]*websocket.Client, count)
+	for i := range clients {
+		clients[i] = &websocket.Client{
+			ID:     fmt.Sprintf("disconnect-client-%d-%d", user.ID, i),
+			UserID: uint(user.ID),
+			Role:   user.Role,
+			Send:   make(chan websocket.Message, 8),
+		}
+		testHub.Register <- clients[i]
+	}
+	require.Eventually(t, func() bool { return connectionCount(user) == count }, time.Second, 10*time.Millisecond)
+	t.Cleanup(func() { testHub.DisconnectUser(uint(user.ID), "test finished") })
+	return clients
+}
+
+func connectionCount(user *models.User) int {
+	_, total := testHub.ListClients(websocket.ClientFilter{UserID: uint(user.ID)})
+	return total
+}
+
+// TestWebSocketDisconnect tests POST /ws/disconnect/:userId and the
+// disconnects that follow a deactivation or a demotion
+func TestWebSocketDisconnect(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, superToken := newUserWithToken(t, models.RoleSuperAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+
+	t.Run("Admin disconnects a user", func(t *testing.T) {
+		target, _ := newUserWithToken(t, models.RoleUser)
+		bystander, _ := newUserWithToken(t, models.RoleUser)
+		connectFakeClients(t, target, 2)
+		connectFakeClients(t, bystander, 1)
+
+		w := sendJSON("POST", fmt.Sprintf("/ws/disconnect/%d", target.ID), adminToken, `{"reason":"session revoked"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp models.WebSocketDisconnectResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, models.WebSocketDisconnectResult{UserID: uint(target.ID), Disconnected: 2}, resp.Data)
+
+		assert.Equal(t, 0, connectionCount(target))
+		assert.Equal(t, 1, connectionCount(bystander), "other users keep their connections")
+	})
+
+	t.Run("Body is optional", func(t *testing.T) {
+		target, _ := newUserWithToken(t, models.RoleUser)
+		connectFakeClients(t, target, 1)
+
+		w := sendJSON("POST", fmt.Sprintf("/ws/disconnect/%d", target.ID), adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, connectionCount(target))
+	})
+
+	t.Run("Non-admin is forbidden", func(t *testing.T) {
+		target, _ := newUserWithToken(t, models.RoleUser)
+		connectFakeClients(t, target, 1)
+
+		w := sendJSON("POST", fmt.Sprintf("/ws/disconnect/%d", target.ID), userToken, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, 1, connectionCount(target))
+	})
+
+	t.Run("Invalid user ID", func(t *testing.T) {
+		w := sendJSON("POST", "/ws/disconnect/abc", adminToken, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Deactivation disconnects", func(t *testing.T) {
+		target, _ := newUserWithToken(t, models.RoleUser)
+		connectFakeClients(t, target, 2)
+
+		w := sendJSON("PUT", fmt.Sprintf("/api/v1/users/%d/deactivate", target.ID), adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, connectionCount(target))
+	})
+
+	t.Run("Demotion disconnects, promotion does not", func(t *testing.T) {
+		target, _ := newUserWithToken(t, models.RoleUser)
+		connectFakeClients(t, target, 1)
+		path := fmt.Sprintf("/api/v1/users/%d/role", target.ID)
+
+		w := sendJSON("PUT", path, superToken, `{"role":"admin"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 1, connectionCount(target))
+
+		w = sendJSON("PUT", path, superToken, `{"role":"user"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, connectionCount(target))
+	})
+
+	t.Run("Bulk demotion disconnects", func(t *testing.T) {
+		target, _ := newUserWithToken(t, models.RoleAdmin)
+		connectFakeClients(t, target, 1)
+
+		w := sendJSON("PUT", "/api/v1/users/roles", superToken, fmt.Sprintf(`[{"id":%d,"role":"user"}]`, target.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, connectionCount(target))
+	})
+}
@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go-Lang-project-01/internal/models"
+	"Go-Lang-project-01/internal/websocket"
+	"Go-Lang-project-01/tests/factory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWebSocketStatsDetail tests per-connection rows on GET /ws/stats
+func TestWebSocketStatsDetail(t *testing.T) {
+	t.Parallel()
+
+	_, adminToken := newUserWithToken(t, models.RoleAdmin)
+	_, superadminToken := newUserWithToken(t, models.RoleSuperAdmin)
+	_, userToken := newUserWithToken(t, models.RoleUser)
+	connected, _ := newUserWithToken(t, models.RoleUser)
+
+	// Register two fake connections for the user; nothing reads from them
+	for i := 0; i < 2; i++ {
+		testHub.Register <- &websocket.Client{
+			ID:         fmt.Sprintf("stats-client-%d-%d", connected.ID, i),
+			UserID:     uint(connected.ID),
+			Role:       connected.Role,
+			RemoteAddr: "198.51.100.7",
+			Send:       make(chan websocket.Message, 8),
+		}
+	}
+	require.Eventually(t, func() bool {
+		_, total := testHub.ListClients(websocket.ClientFilter{UserID: uint(connected.ID)})
+		return total == 2
+	}, time.Second, 10*time.Millisecond)
+	t.Cleanup(func() { testHub.DisconnectUser(uint(connected.ID), "test finished") })
+
+	type statsResponse struct {
+		Success bool `json:"success"`
+		Data    struct {
+			TotalClients int                    `json:"total_clients"`
+			Clients      []websocket.ClientInfo `json:"clients"`
+			Pagination   *models.PaginationMeta `json:"pagination"`
+		} `json:"data"`
+	}
+
+	getStats := func(t *testing.T, token, query string) (*httptest.ResponseRecorder, statsResponse) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/ws/stats"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		testRouter.ServeHTTP(w, req)
+
+		var resp statsResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w, resp
+	}
+
+	t.Run("Totals only without detail", func(t *testing.T) {
+		w, resp := getStats(t, adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Nil(t, resp.Data.Clients)
+		assert.Nil(t, resp.Data.Pagination)
+	})
+
+	t.Run("Admin sees connection rows without remote address", func(t *testing.T) {
+		w, resp := getStats(t, adminToken, fmt.Sprintf("?detail=true&user_id=%d", connected.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, resp.Data.Clients, 2)
+		for _, client := range resp.Data.Clients {
+			assert.Equal(t, uint(connected.ID), client.UserID)
+			assert.Equal(t, models.RoleUser, client.Role)
+			assert.False(t, client.ConnectedAt.IsZero())
+			assert.Equal(t, 8, client.SendQueueCapacity)
+			assert.Empty(t, client.RemoteAddr, "remote address is superadmin only")
+		}
+		assert.Equal(t, int64(2), resp.Data.Pagination.Total)
+	})
+
+	t.Run("Superadmin sees remote address", func(t *testing.T) {
+		w, resp := getStats(t, superadminToken, fmt.Sprintf("?detail=true&user_id=%d", connected.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, resp.Data.Clients, 2)
+		for _, client := range resp.Data.Clients {
+			assert.Equal(t, "198.51.100.7", client.RemoteAddr)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		w, resp := getStats(t, adminToken, fmt.Sprintf("?detail=true&user_id=%d&limit=1&page=2", connected.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, resp.Data.Clients, 1)
+		prev := 1
+		assert.Equal(t, models.PaginationMeta{Page: 2, Limit: 1, Total: 2, TotalPages: 2, HasPrev: true, PrevPage: &prev}, *resp.Data.Pagination)
+	})
+
+	t.Run("Unknown user is not connected", func(t *testing.T) {
+		offline, err := testFactory.User(factory.WithName("Offline User"))
+		require.NoError(t, err)
+
+		w, resp := getStats(t, adminToken, fmt.Sprintf("?detail=true&user_id=%d", offline.ID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Empty(t, resp.Data.Clients)
+		assert.Equal(t, int64(0), resp.Data.Pagination.Total)
+	})
+
+	t.Run("Invalid filter is rejected", func(t *testing.T) {
+		w, _ := getStats(t, adminToken, "?detail=true&user_id=abc")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = getStats(t, adminToken, "?detail=true&limit=1000")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Regular user is forbidden", func(t *testing.T) {
+		w, _ := getStats(t, userToken, "?detail=true")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}